@@ -0,0 +1,224 @@
+package analysis
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/myleshyson/lsprotocol-go/protocol"
+)
+
+// CodeLensKind tells what a code lens shows or runs
+type CodeLensKind string
+
+const (
+	CognitiveComplexityLens  CodeLensKind = "cognitive_complexity"
+	CyclomaticComplexityLens CodeLensKind = "cyclomatic_complexity"
+	RunTestLens              CodeLensKind = "run_test"
+	RunAllTestsLens          CodeLensKind = "run_all_tests"
+	DebugTestLens            CodeLensKind = "debug_test"
+	OtherLens                CodeLensKind = "other"
+)
+
+// codeLensDataIDs are the ids BSL LS puts in the data of its lenses, which is all an
+// unresolved lens carries
+var codeLensDataIDs = map[string]CodeLensKind{
+	"cognitivecomplexity":  CognitiveComplexityLens,
+	"cyclomaticcomplexity": CyclomaticComplexityLens,
+	"runtest":              RunTestLens,
+	"runalltests":          RunAllTestsLens,
+	"debugtest":            DebugTestLens,
+}
+
+// ClassifyCodeLens tells the kind of a lens from its data id, or once resolved from
+// its command and title (BSL LS titles are localized: "Cognitive complexity is 12",
+// "Когнитивная сложность 12", "⚙ Run test", "⚙ Запустить тест" ...)
+func ClassifyCodeLens(lens protocol.CodeLens) CodeLensKind {
+	if kind, ok := codeLensDataIDs[strings.ToLower(codeLensDataString(lens, "id"))]; ok {
+		return kind
+	}
+	if lens.Command == nil {
+		return OtherLens
+	}
+
+	command := strings.ToLower(lens.Command.Command)
+	title := strings.ToLower(lens.Command.Title)
+	switch {
+	case strings.Contains(command, "cognitivecomplexity"), strings.Contains(title, "cognitive"), strings.Contains(title, "когнитивн"):
+		return CognitiveComplexityLens
+	case strings.Contains(command, "cyclomaticcomplexity"), strings.Contains(title, "cyclomatic"), strings.Contains(title, "цикломатическ"):
+		return CyclomaticComplexityLens
+	case strings.Contains(command, "runalltests"), strings.Contains(title, "all tests"), strings.Contains(title, "все тесты"):
+		return RunAllTestsLens
+	case strings.Contains(command, "debugtest"), strings.Contains(title, "debug test"), strings.Contains(title, "отладить тест"):
+		return DebugTestLens
+	case strings.Contains(command, "runtest"), strings.Contains(title, "run test"), strings.Contains(title, "запустить тест"):
+		return RunTestLens
+	}
+	return OtherLens
+}
+
+// IsComplexityLens reports whether the kind carries a complexity value
+func IsComplexityLens(kind CodeLensKind) bool {
+	return kind == CognitiveComplexityLens || kind == CyclomaticComplexityLens
+}
+
+// codeLensDataString returns a string field of the lens data
+func codeLensDataString(lens protocol.CodeLens, key string) string {
+	data, ok := lens.Data.(map[string]any)
+	if !ok {
+		return ""
+	}
+	value, _ := data[key].(string)
+	return value
+}
+
+// CodeLensMethodName returns the method or test a lens belongs to, when the lens
+// data names it
+func CodeLensMethodName(lens protocol.CodeLens) string {
+	if name := codeLensDataString(lens, "methodName"); name != "" {
+		return name
+	}
+	return codeLensDataString(lens, "testName")
+}
+
+// codeLensValue returns the last number in the title of a resolved lens
+func codeLensValue(lens protocol.CodeLens) (int, bool) {
+	if lens.Command == nil {
+		return 0, false
+	}
+	title := []rune(lens.Command.Title)
+	end := len(title)
+	for end > 0 && !unicode.IsDigit(title[end-1]) {
+		end--
+	}
+	start := end
+	for start > 0 && unicode.IsDigit(title[start-1]) {
+		start--
+	}
+	if start == end {
+		return 0, false
+	}
+	value, err := strconv.Atoi(string(title[start:end]))
+	return value, err == nil
+}
+
+// MethodComplexity is the complexity of one method as reported by code lenses.
+// A nil value means the server sent no lens of that kind.
+type MethodComplexity struct {
+	Name       string
+	Uri        string
+	Line       uint32
+	Cognitive  *int
+	Cyclomatic *int
+}
+
+// Score ranks methods: the cognitive complexity, or the cyclomatic one without it
+func (m MethodComplexity) Score() int {
+	if m.Cognitive != nil {
+		return *m.Cognitive
+	}
+	if m.Cyclomatic != nil {
+		return *m.Cyclomatic
+	}
+	return 0
+}
+
+// MethodComplexities collects the complexity lenses of a document per method. Both
+// lenses of a method sit on its name, so they are matched by line. Lenses that are
+// not resolved yet have no value and are skipped.
+func MethodComplexities(uri string, lenses []protocol.CodeLens) []MethodComplexity {
+	byLine := make(map[uint32]*MethodComplexity)
+	var lines []uint32
+	for _, lens := range lenses {
+		kind := ClassifyCodeLens(lens)
+		if !IsComplexityLens(kind) {
+			continue
+		}
+		value, ok := codeLensValue(lens)
+		if !ok {
+			continue
+		}
+
+		line := lens.Range.Start.Line
+		method, exists := byLine[line]
+		if !exists {
+			method = &MethodComplexity{Uri: uri, Line: line}
+			byLine[line] = method
+			lines = append(lines, line)
+		}
+		if method.Name == "" {
+			method.Name = CodeLensMethodName(lens)
+		}
+		if kind == CognitiveComplexityLens {
+			method.Cognitive = &value
+		} else {
+			method.Cyclomatic = &value
+		}
+	}
+
+	sort.Slice(lines, func(i, j int) bool { return lines[i] < lines[j] })
+	methods := make([]MethodComplexity, 0, len(lines))
+	for _, line := range lines {
+		methods = append(methods, *byLine[line])
+	}
+	return methods
+}
+
+// NameMethodsFromSymbols names the methods whose lenses did not, using the method
+// symbol declared on the same line
+func NameMethodsFromSymbols(methods []MethodComplexity, symbols []protocol.DocumentSymbol) {
+	names := make(map[uint32]string)
+	var collect func([]protocol.DocumentSymbol)
+	collect = func(symbols []protocol.DocumentSymbol) {
+		for _, symbol := range symbols {
+			if symbol.Kind == protocol.SymbolKindMethod || symbol.Kind == protocol.SymbolKindFunction {
+				names[symbol.SelectionRange.Start.Line] = symbol.Name
+			}
+			collect(symbol.Children)
+		}
+	}
+	collect(symbols)
+
+	for i := range methods {
+		if methods[i].Name == "" {
+			methods[i].Name = names[methods[i].Line]
+		}
+	}
+}
+
+// RankMethodComplexity sorts methods from the most to the least complex
+func RankMethodComplexity(methods []MethodComplexity) {
+	cyclomatic := func(m MethodComplexity) int {
+		if m.Cyclomatic != nil {
+			return *m.Cyclomatic
+		}
+		return 0
+	}
+	sort.SliceStable(methods, func(i, j int) bool {
+		a, b := methods[i], methods[j]
+		if a.Score() != b.Score() {
+			return a.Score() > b.Score()
+		}
+		if cyclomatic(a) != cyclomatic(b) {
+			return cyclomatic(a) > cyclomatic(b)
+		}
+		if a.Uri != b.Uri {
+			return a.Uri < b.Uri
+		}
+		return a.Line < b.Line
+	})
+}
+
+// AverageMethodComplexity is the mean score of the methods
+func AverageMethodComplexity(methods []MethodComplexity) float64 {
+	if len(methods) == 0 {
+		return 0
+	}
+	total := 0
+	for _, m := range methods {
+		total += m.Score()
+	}
+	return float64(total) / float64(len(methods))
+}
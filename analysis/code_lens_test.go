@@ -0,0 +1,92 @@
+package analysis
+
+import (
+	"testing"
+
+	"github.com/myleshyson/lsprotocol-go/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func lensAt(line uint32, title, command string, data any) protocol.CodeLens {
+	lens := protocol.CodeLens{
+		Range: protocol.Range{Start: protocol.Position{Line: line, Character: 10}, End: protocol.Position{Line: line, Character: 20}},
+		Data:  data,
+	}
+	if title != "" || command != "" {
+		lens.Command = &protocol.Command{Title: title, Command: command}
+	}
+	return lens
+}
+
+func TestClassifyCodeLens(t *testing.T) {
+	tests := []struct {
+		name string
+		lens protocol.CodeLens
+		want CodeLensKind
+	}{
+		{"data id", lensAt(1, "", "", map[string]any{"id": "cyclomaticComplexity"}), CyclomaticComplexityLens},
+		{"unresolved without id", lensAt(1, "", "", nil), OtherLens},
+		{"english title", lensAt(1, "Cognitive complexity is 12", "", nil), CognitiveComplexityLens},
+		{"russian title", lensAt(1, "Цикломатическая сложность 4", "", nil), CyclomaticComplexityLens},
+		{"run all tests", lensAt(0, "⚙ Запустить все тесты", "language-1c-bsl.languageServer.runAllTests", nil), RunAllTestsLens},
+		{"run test", lensAt(3, "⚙ Run test", "", nil), RunTestLens},
+		{"debug test command", lensAt(3, "⚙", "language-1c-bsl.languageServer.debugTest", nil), DebugTestLens},
+		{"other", lensAt(3, "3 references", "editor.showReferences", nil), OtherLens},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ClassifyCodeLens(tt.lens))
+		})
+	}
+}
+
+func TestMethodComplexities(t *testing.T) {
+	uri := "file:///projects/Module.bsl"
+	lenses := []protocol.CodeLens{
+		lensAt(20, "Когнитивная сложность 3", "", map[string]any{"id": "cognitiveComplexity", "methodName": "Заполнить"}),
+		lensAt(5, "Cognitive complexity is 17", "", nil),
+		lensAt(5, "Cyclomatic complexity is 9", "", nil),
+		lensAt(20, "Цикломатическая сложность 4", "", nil),
+		lensAt(30, "", "", map[string]any{"id": "cognitiveComplexity"}), // not resolved
+		lensAt(40, "⚙ Run test", "", map[string]any{"id": "runTest", "testName": "ТестЗаполнения"}),
+	}
+
+	methods := MethodComplexities(uri, lenses)
+	require.Len(t, methods, 2)
+	assert.Equal(t, uint32(5), methods[0].Line)
+	assert.Equal(t, 17, *methods[0].Cognitive)
+	assert.Equal(t, 9, *methods[0].Cyclomatic)
+	assert.Equal(t, "", methods[0].Name)
+	assert.Equal(t, "Заполнить", methods[1].Name)
+	assert.Equal(t, 3, *methods[1].Cognitive)
+
+	NameMethodsFromSymbols(methods, []protocol.DocumentSymbol{{
+		Name: "Область", Kind: protocol.SymbolKindNamespace,
+		Children: []protocol.DocumentSymbol{{
+			Name: "Провести", Kind: protocol.SymbolKindMethod,
+			SelectionRange: protocol.Range{Start: protocol.Position{Line: 5, Character: 10}},
+		}},
+	}})
+	assert.Equal(t, "Провести", methods[0].Name)
+	assert.Equal(t, "Заполнить", methods[1].Name, "names from lenses are kept")
+
+	assert.InDelta(t, 10.0, AverageMethodComplexity(methods), 0.001)
+}
+
+func TestRankMethodComplexity(t *testing.T) {
+	value := func(v int) *int { return &v }
+	methods := []MethodComplexity{
+		{Name: "A", Uri: "file:///b.bsl", Cognitive: value(5), Cyclomatic: value(3)},
+		{Name: "B", Uri: "file:///a.bsl", Cyclomatic: value(8)},
+		{Name: "C", Uri: "file:///a.bsl", Cognitive: value(5), Cyclomatic: value(6)},
+		{Name: "D", Uri: "file:///a.bsl", Cognitive: value(5), Cyclomatic: value(3)},
+	}
+	RankMethodComplexity(methods)
+
+	var names []string
+	for _, m := range methods {
+		names = append(names, m.Name)
+	}
+	assert.Equal(t, []string{"B", "C", "D", "A"}, names, "cyclomatic stands in without cognitive and breaks ties, then the file")
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"math"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -189,17 +190,37 @@ func (a *ProjectAnalyzer) analyzeWorkspace(request AnalysisRequest, metadata *An
 	// Analyze language distribution with more accurate metrics
 	langStats := make(map[types.Language]LanguageStats)
 	totalFiles := len(allFiles)
+	var ranking []MethodComplexity
+	skipped := 0
 
 	for lang, symbols := range symbolsByLanguage {
 		langFiles := filesByLanguage[lang]
 
-		langStats[lang] = LanguageStats{
-			FileCount:     len(langFiles),
-			SymbolCount:   len(symbols),
-			Percentage:    float64(len(langFiles)) / float64(totalFiles) * 100,
-			ComplexityAvg: a.calculateEnhancedLanguageComplexity(symbols, langFiles),
+		stats := LanguageStats{
+			FileCount:   len(langFiles),
+			SymbolCount: len(symbols),
+			Percentage:  float64(len(langFiles)) / float64(totalFiles) * 100,
 		}
+
+		// Servers that report method complexity through code lenses give the real
+		// numbers; the others get an estimate from the symbol mix
+		if methods, measured := a.languageMethodComplexity(a.clients[lang], langFiles); len(methods) > 0 {
+			stats.ComplexityAvg = AverageMethodComplexity(methods)
+			stats.MethodsMeasured = len(methods)
+			stats.FilesMeasured = measured
+			skipped += len(langFiles) - measured
+			ranking = append(ranking, methods...)
+		} else {
+			stats.ComplexityAvg = a.calculateEnhancedLanguageComplexity(symbols, langFiles)
+		}
+		langStats[lang] = stats
+	}
+
+	RankMethodComplexity(ranking)
+	if len(ranking) > complexityRankingSize {
+		ranking = ranking[:complexityRankingSize]
 	}
+	a.nameRankedMethods(ranking)
 
 	// Enhanced dependency pattern detection
 	dependencyPatterns := a.detectAdvancedDependencyPatterns(allSymbols)
@@ -216,11 +237,100 @@ func (a *ProjectAnalyzer) analyzeWorkspace(request AnalysisRequest, metadata *An
 			TotalFiles:           totalFiles,
 			DependencyPatterns:   dependencyPatterns,
 			ArchitecturalHealth:  architecturalHealth,
+			ComplexityRanking:    ranking,
+
+			ComplexityFileLimit:    a.config.ComplexityFileLimit,
+			ComplexityFilesSkipped: skipped,
 		},
 		Metadata: *metadata,
 	}, nil
 }
 
+// complexityRankingSize is how many methods a workspace analysis ranks
+const complexityRankingSize = 20
+
+// methodComplexity returns the per-method complexity a client reports through code
+// lenses, resolving the lenses that came without a command. Servers without
+// complexity lenses give nothing.
+func (a *ProjectAnalyzer) methodComplexity(client types.LanguageClientInterface, uri string) []MethodComplexity {
+	lenses, err := client.CodeLens(uri)
+	if err != nil || len(lenses) == 0 {
+		return nil
+	}
+
+	resolved := make([]protocol.CodeLens, 0, len(lenses))
+	for _, lens := range lenses {
+		if lens.Command == nil {
+			if kind := ClassifyCodeLens(lens); kind != OtherLens && !IsComplexityLens(kind) {
+				continue
+			}
+			if r, err := client.ResolveCodeLens(lens); err == nil && r != nil {
+				lens = *r
+			}
+		}
+		resolved = append(resolved, lens)
+	}
+
+	return MethodComplexities(uri, resolved)
+}
+
+// languageMethodComplexity measures the methods of the files of one language, most
+// complex first, and returns how many files it measured: the first
+// config.ComplexityFileLimit in path order (0: all)
+func (a *ProjectAnalyzer) languageMethodComplexity(client types.LanguageClientInterface, files []string) ([]MethodComplexity, int) {
+	files = append([]string(nil), files...)
+	sort.Strings(files)
+	if limit := a.config.ComplexityFileLimit; limit > 0 && len(files) > limit {
+		files = files[:limit]
+	}
+
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		methods []MethodComplexity
+	)
+	semaphore := make(chan struct{}, max(a.config.MaxGoroutines, 1))
+	for _, file := range files {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			fileMethods := a.methodComplexity(client, file)
+			mu.Lock()
+			methods = append(methods, fileMethods...)
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	RankMethodComplexity(methods)
+	return methods, len(files)
+}
+
+// nameRankedMethods names the ranked methods whose lenses did not, from the
+// document symbols of their files
+func (a *ProjectAnalyzer) nameRankedMethods(methods []MethodComplexity) {
+	symbolsByFile := make(map[string][]protocol.DocumentSymbol)
+	for i := range methods {
+		if methods[i].Name != "" {
+			continue
+		}
+		uri := methods[i].Uri
+		symbols, fetched := symbolsByFile[uri]
+		if !fetched {
+			if lang, err := a.languageDetector(uri); err == nil {
+				if client, ok := a.clients[*lang]; ok {
+					symbols, _ = client.DocumentSymbols(uri)
+				}
+			}
+			symbolsByFile[uri] = symbols
+		}
+		NameMethodsFromSymbols(methods[i:i+1], symbols)
+	}
+}
+
 // calculateEnhancedLanguageComplexity provides a more nuanced complexity analysis
 func (a *ProjectAnalyzer) calculateEnhancedLanguageComplexity(symbols []protocol.WorkspaceSymbol, files []string) float64 {
 	if len(symbols) == 0 {
@@ -1156,21 +1266,23 @@ func (a *ProjectAnalyzer) analyzeFile(request AnalysisRequest, metadata *Analysi
 		documentSymbols = []protocol.DocumentSymbol{}
 	}
 
+	// Method complexity as reported by the server, when it has complexity lenses
+	methods := a.methodComplexity(fileClient, request.Target)
+	NameMethodsFromSymbols(methods, documentSymbols)
+	RankMethodComplexity(methods)
+
+	// Recommendations build on the complexity, so it comes first
+	complexity := a.calculateEnhancedFileComplexity(documentSymbols, methods)
+
 	// Parallel analysis tasks
 	var wg sync.WaitGroup
 
-	var complexity ComplexityMetrics
 	var importExport ImportExportAnalysis
 	var crossFileRelations []CrossFileRelation
 	var codeQuality CodeQualityMetrics
 	var recommendations []Recommendation
 
-	wg.Add(5)
-	go func() {
-		defer wg.Done()
-		complexity = a.calculateEnhancedFileComplexity(documentSymbols)
-	}()
-
+	wg.Add(4)
 	go func() {
 		defer wg.Done()
 		importExport = a.analyzeDetailedImportExport(request.Target, fileLanguage, fileClient)
@@ -1188,7 +1300,7 @@ func (a *ProjectAnalyzer) analyzeFile(request AnalysisRequest, metadata *Analysi
 
 	go func() {
 		defer wg.Done()
-		recommendations = a.generateFileImprovementRecommendations(documentSymbols, complexity)
+		recommendations = a.generateFileImprovementRecommendations(documentSymbols, complexity, methods)
 	}()
 
 	wg.Wait()
@@ -1210,9 +1322,11 @@ func (a *ProjectAnalyzer) analyzeFile(request AnalysisRequest, metadata *Analysi
 	}, nil
 }
 
-// calculateEnhancedFileComplexity provides a more nuanced complexity analysis
-func (a *ProjectAnalyzer) calculateEnhancedFileComplexity(symbols []protocol.DocumentSymbol) ComplexityMetrics {
-	if len(symbols) == 0 {
+// calculateEnhancedFileComplexity provides a more nuanced complexity analysis. With
+// measured methods the score is their average complexity; otherwise it is
+// estimated from the symbol mix.
+func (a *ProjectAnalyzer) calculateEnhancedFileComplexity(symbols []protocol.DocumentSymbol, methods []MethodComplexity) ComplexityMetrics {
+	if len(symbols) == 0 && len(methods) == 0 {
 		return ComplexityMetrics{}
 	}
 
@@ -1250,6 +1364,14 @@ func (a *ProjectAnalyzer) calculateEnhancedFileComplexity(symbols []protocol.Doc
 		metrics.TotalLines += int(symbol.Range.End.Line - symbol.Range.Start.Line + 1)
 	}
 
+	if len(methods) > 0 {
+		metrics.ComplexityScore = AverageMethodComplexity(methods)
+		metrics.ComplexityLevel = measuredComplexityLevel(metrics.ComplexityScore)
+		metrics.ComplexitySource = "code_lens"
+		metrics.Methods = methods
+		return metrics
+	}
+
 	// Advanced complexity scoring
 	var complexityScore float64
 	for kind, count := range symbolComplexities {
@@ -1258,6 +1380,7 @@ func (a *ProjectAnalyzer) calculateEnhancedFileComplexity(symbols []protocol.Doc
 
 	// Normalize complexity
 	metrics.ComplexityScore = complexityScore / float64(len(symbols))
+	metrics.ComplexitySource = "symbols"
 
 	// Categorize complexity level with more granularity
 	switch {
@@ -1276,6 +1399,27 @@ func (a *ProjectAnalyzer) calculateEnhancedFileComplexity(symbols []protocol.Doc
 	return metrics
 }
 
+// methodComplexityThreshold is the default threshold of the BSL LS cognitive
+// complexity diagnostic
+const methodComplexityThreshold = 15
+
+// measuredComplexityLevel grades an average method complexity against
+// methodComplexityThreshold
+func measuredComplexityLevel(score float64) string {
+	switch {
+	case score < 3:
+		return "very_low"
+	case score < 6:
+		return "low"
+	case score < 10:
+		return "moderate"
+	case score < methodComplexityThreshold:
+		return "high"
+	default:
+		return "very_high"
+	}
+}
+
 // analyzeDetailedImportExport provides comprehensive import/export analysis
 func (a *ProjectAnalyzer) analyzeDetailedImportExport(uri string, lang types.Language, client types.LanguageClientInterface) ImportExportAnalysis {
 	// These methods are not available in all LSP clients, so return empty for now
@@ -1410,9 +1554,31 @@ func (a *ProjectAnalyzer) calculateMaintainabilityIndex(symbols []protocol.Docum
 func (a *ProjectAnalyzer) generateFileImprovementRecommendations(
 	symbols []protocol.DocumentSymbol,
 	complexity ComplexityMetrics,
+	methods []MethodComplexity,
 ) []Recommendation {
 	recommendations := []Recommendation{}
 
+	// Methods measured above the threshold, most complex first
+	for _, method := range methods {
+		if method.Score() < methodComplexityThreshold {
+			break
+		}
+		name := method.Name
+		if name == "" {
+			name = fmt.Sprintf("method at line %d", method.Line)
+		}
+		recommendations = append(recommendations, Recommendation{
+			Type:        "refactor",
+			Priority:    "high",
+			Description: fmt.Sprintf("%s has complexity %d (threshold %d); split it into smaller methods", name, method.Score(), methodComplexityThreshold),
+			Location: &protocol.Location{
+				Uri:   protocol.DocumentUri(method.Uri),
+				Range: protocol.Range{Start: protocol.Position{Line: method.Line}, End: protocol.Position{Line: method.Line}},
+			},
+			Effort: "medium",
+		})
+	}
+
 	// Complexity-based recommendations
 	if complexity.ComplexityLevel == "high" || complexity.ComplexityLevel == "very_high" {
 		recommendations = append(recommendations, Recommendation{
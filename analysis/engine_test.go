@@ -441,3 +441,100 @@ func TestCircularDependencyDetection(t *testing.T) {
 	assert.True(t, ok)
 	assert.Greater(t, len(data.DependencyPatterns), 0)
 }
+
+// TestAnalyzeComplexityFromCodeLens tests that servers reporting method complexity
+// through code lenses give measured numbers instead of estimates
+func TestAnalyzeComplexityFromCodeLens(t *testing.T) {
+	uri := "file:///projects/CommonModules/Common/Ext/Module.bsl"
+	client := &mocks.MockLanguageClient{}
+	cognitive := lensWithData(4, map[string]any{"id": "cognitiveComplexity"})
+	cyclomatic := lensWithData(4, map[string]any{"id": "cyclomaticComplexity"})
+	client.On("CodeLens", uri).Return([]protocol.CodeLens{
+		cognitive,
+		cyclomatic,
+		lensAt(12, "Cognitive complexity is 2", "", nil),
+		lensWithData(20, map[string]any{"id": "runTest"}),
+	}, nil)
+	client.On("ResolveCodeLens", cognitive).Return(&protocol.CodeLens{Range: cognitive.Range, Command: &protocol.Command{Title: "Когнитивная сложность 22"}}, nil)
+	client.On("ResolveCodeLens", cyclomatic).Return(&protocol.CodeLens{Range: cyclomatic.Range, Command: &protocol.Command{Title: "Цикломатическая сложность 11"}}, nil)
+	client.On("DocumentSymbols", uri).Return([]protocol.DocumentSymbol{
+		{Name: "Провести", Kind: protocol.SymbolKindFunction, SelectionRange: protocol.Range{Start: protocol.Position{Line: 4}}},
+		{Name: "Заполнить", Kind: protocol.SymbolKindFunction, SelectionRange: protocol.Range{Start: protocol.Position{Line: 12}}},
+	}, nil)
+	client.On("WorkspaceSymbols", mock.Anything).Return([]protocol.WorkspaceSymbol{{
+		Name:     "Провести",
+		Kind:     protocol.SymbolKindFunction,
+		Location: protocol.Or2[protocol.Location, protocol.LocationUriOnly]{Value: protocol.Location{Uri: protocol.DocumentUri(uri)}},
+	}}, nil)
+
+	bsl := types.Language("bsl")
+	analyzer := NewProjectAnalyzer(map[types.Language]types.LanguageClientInterface{bsl: client},
+		WithLanguageDetector(func(string) (*types.Language, error) { return &bsl, nil }))
+
+	t.Run("file", func(t *testing.T) {
+		result, err := analyzer.Analyze(AnalysisRequest{Type: FileAnalysis, Target: uri, Scope: "file"})
+		require.NoError(t, err)
+		complexity := result.Data.(FileAnalysisData).Complexity
+
+		assert.Equal(t, "code_lens", complexity.ComplexitySource)
+		assert.InDelta(t, 12.0, complexity.ComplexityScore, 0.001)
+		assert.Equal(t, "high", complexity.ComplexityLevel)
+		require.Len(t, complexity.Methods, 2)
+		assert.Equal(t, "Провести", complexity.Methods[0].Name)
+		assert.Equal(t, 11, *complexity.Methods[0].Cyclomatic)
+
+		recommendations := result.Data.(FileAnalysisData).Recommendations
+		require.NotEmpty(t, recommendations)
+		assert.Contains(t, recommendations[0].Description, "Провести has complexity 22")
+		client.AssertNotCalled(t, "ResolveCodeLens", lensWithData(20, map[string]any{"id": "runTest"}))
+	})
+
+	t.Run("workspace", func(t *testing.T) {
+		result, err := analyzer.Analyze(AnalysisRequest{Type: WorkspaceAnalysis, Target: "", Scope: "project"})
+		require.NoError(t, err)
+		data := result.Data.(WorkspaceAnalysisData)
+
+		assert.Equal(t, 2, data.LanguageDistribution[bsl].MethodsMeasured)
+		assert.Equal(t, 1, data.LanguageDistribution[bsl].FilesMeasured)
+		assert.InDelta(t, 12.0, data.LanguageDistribution[bsl].ComplexityAvg, 0.001)
+		require.Len(t, data.ComplexityRanking, 2)
+		assert.Equal(t, "Провести", data.ComplexityRanking[0].Name)
+		assert.Equal(t, "Заполнить", data.ComplexityRanking[1].Name)
+		assert.Zero(t, data.ComplexityFilesSkipped)
+	})
+
+	t.Run("workspace over the file limit", func(t *testing.T) {
+		other := "file:///projects/CommonModules/Other/Ext/Module.bsl"
+		limited := &mocks.MockLanguageClient{}
+		limited.On("CodeLens", uri).Return([]protocol.CodeLens{lensAt(12, "Cognitive complexity is 2", "", nil)}, nil)
+		limited.On("DocumentSymbols", uri).Return([]protocol.DocumentSymbol{
+			{Name: "Заполнить", Kind: protocol.SymbolKindFunction, SelectionRange: protocol.Range{Start: protocol.Position{Line: 12}}},
+		}, nil)
+		limited.On("WorkspaceSymbols", mock.Anything).Return([]protocol.WorkspaceSymbol{
+			{Name: "Заполнить", Kind: protocol.SymbolKindFunction,
+				Location: protocol.Or2[protocol.Location, protocol.LocationUriOnly]{Value: protocol.Location{Uri: protocol.DocumentUri(uri)}}},
+			{Name: "Другой", Kind: protocol.SymbolKindFunction,
+				Location: protocol.Or2[protocol.Location, protocol.LocationUriOnly]{Value: protocol.Location{Uri: protocol.DocumentUri(other)}}},
+		}, nil)
+
+		config := DefaultPerformanceConfig()
+		config.ComplexityFileLimit = 1
+		analyzer := NewProjectAnalyzer(map[types.Language]types.LanguageClientInterface{bsl: limited},
+			WithLanguageDetector(func(string) (*types.Language, error) { return &bsl, nil }),
+			WithPerformanceConfig(config))
+
+		result, err := analyzer.Analyze(AnalysisRequest{Type: WorkspaceAnalysis, Target: "", Scope: "project"})
+		require.NoError(t, err)
+		data := result.Data.(WorkspaceAnalysisData)
+
+		assert.Equal(t, 2, data.LanguageDistribution[bsl].FileCount)
+		assert.Equal(t, 1, data.LanguageDistribution[bsl].FilesMeasured)
+		assert.Equal(t, 1, data.ComplexityFileLimit)
+		assert.Equal(t, 1, data.ComplexityFilesSkipped)
+		limited.AssertNotCalled(t, "CodeLens", other)
+	})
+}
+
+func lensWithData(line uint32, data map[string]any) protocol.CodeLens {
+	return lensAt(line, "", "", data)
+}
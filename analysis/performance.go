@@ -12,6 +12,9 @@ type PerformanceConfig struct {
 	MemoryLimit     int64 // bytes
 	EnableProfiling bool
 	BatchSize       int
+	// ComplexityFileLimit caps the files asked for complexity lenses per language
+	// in a workspace analysis
+	ComplexityFileLimit int
 }
 
 // DefaultPerformanceConfig creates a default configuration optimized for most systems
@@ -22,5 +25,7 @@ func DefaultPerformanceConfig() *PerformanceConfig {
 		MemoryLimit:     1024 * 1024 * 1024, // 1GB
 		EnableProfiling: false,
 		BatchSize:       50,
+
+		ComplexityFileLimit: 500,
 	}
 }
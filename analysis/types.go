@@ -57,6 +57,13 @@ type LanguageStats struct {
 	SymbolCount   int
 	Percentage    float64
 	ComplexityAvg float64
+	// MethodsMeasured is how many methods ComplexityAvg averages over when the
+	// server reports complexity through code lenses; 0 means it is an estimate
+	// from the symbol mix
+	MethodsMeasured int
+	// FilesMeasured is how many files were asked for complexity lenses; fewer than
+	// FileCount when PerformanceConfig.ComplexityFileLimit cut the list
+	FilesMeasured int
 }
 
 // WorkspaceAnalysisData provides comprehensive analysis of the project's workspace
@@ -66,6 +73,12 @@ type WorkspaceAnalysisData struct {
 	TotalFiles           int
 	DependencyPatterns   []DependencyPattern
 	ArchitecturalHealth  ArchitecturalHealthMetrics
+	// ComplexityRanking lists the most complex methods across languages, from code lenses
+	ComplexityRanking []MethodComplexity
+	// ComplexityFileLimit is the number of files per language measured for the ranking
+	// (0: all) and ComplexityFilesSkipped the files left out because of it
+	ComplexityFileLimit    int
+	ComplexityFilesSkipped int
 }
 
 // DependencyPattern represents a dependency relationship in the project
@@ -175,6 +188,11 @@ type ComplexityMetrics struct {
 	VariableCount   int
 	ComplexityScore float64
 	ComplexityLevel string
+	// ComplexitySource is "code_lens" when the score is the average method
+	// complexity reported by the server, "symbols" when it is estimated
+	ComplexitySource string
+	// Methods are the measured methods, most complex first
+	Methods []MethodComplexity
 }
 
 // ImportExportAnalysis provides details about import and export dependencies
@@ -1,16 +1,19 @@
 package bridge
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
 	"path"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
+	"rockerboo/mcp-lsp-bridge/interfaces"
 	"rockerboo/mcp-lsp-bridge/logger"
 	"rockerboo/mcp-lsp-bridge/lsp"
 	"rockerboo/mcp-lsp-bridge/security"
@@ -23,25 +26,47 @@ import (
 
 // NewMCPLSPBridge creates a new bridge instance with provided configuration and client factory
 func NewMCPLSPBridge(config types.LSPServerConfigProvider, allowedDirectories []string) *MCPLSPBridge {
-	bridge := &MCPLSPBridge{
+	bridge := &MCPLSPBridge{bridgeState: &bridgeState{
 		clients:            make(map[types.LanguageServer]types.LanguageClientInterface),
 		config:             config,
 		allowedDirectories: allowedDirectories,
-	}
+		documents:          newDocumentStore(),
+	}}
 
 	// Попытаться создать path mapper из переменных окружения
 	pathMapper, err := utils.NewDockerPathMapperFromEnv()
 	if err != nil {
 		logger.Warn(fmt.Sprintf("Path mapper initialization failed, using local mode: %v", err))
 	} else if pathMapper.IsEnabled() {
-		logger.Info(fmt.Sprintf("Docker path mapping enabled: %s -> %s",
-			pathMapper.HostRoot(), pathMapper.ContainerRoot()))
+		for _, m := range pathMapper.Mounts() {
+			logger.Info(fmt.Sprintf("Docker path mapping enabled: %s -> %s", m.HostRoot, m.ContainerRoot))
+		}
 	}
 	bridge.pathMapper = pathMapper
 
 	return bridge
 }
 
+// WithContext returns a view of the bridge whose language server requests start from
+// ctx. Tool handlers use it so the Session Manager sees the correlation id of the call.
+func (b *MCPLSPBridge) WithContext(ctx context.Context) interfaces.BridgeInterface {
+	return &MCPLSPBridge{bridgeState: b.bridgeState, ctx: ctx}
+}
+
+// requestContext returns the context of the view, or a background context
+func (b *MCPLSPBridge) requestContext() context.Context {
+	if b.ctx != nil {
+		return b.ctx
+	}
+	return context.Background()
+}
+
+// bind returns client with its requests started from the context of the view.
+// Clients are stored and compared unbound.
+func (b *MCPLSPBridge) bind(client types.LanguageClientInterface) types.LanguageClientInterface {
+	return bindClient(b.ctx, client)
+}
+
 // ConnectionAttemptConfig defines retry parameters for language server connections
 type ConnectionAttemptConfig struct {
 	MaxRetries   int
@@ -82,8 +107,7 @@ func (b *MCPLSPBridge) NormalizeURIForLSP(uri string) string {
 	// If it's already a container path, do not remap.
 	p := utils.URIToFilePath(normalized)
 	slash := strings.ReplaceAll(p, "\\", "/")
-	cr := strings.TrimSuffix(b.pathMapper.ContainerRoot(), "/")
-	if slash == cr || strings.HasPrefix(slash, cr+"/") {
+	if b.pathMapper.IsContainerPath(slash) {
 		return utils.NormalizeURI(slash)
 	}
 
@@ -167,11 +191,10 @@ func (b *MCPLSPBridge) validateAndConnectClient(language string, serverConfig ty
 				lastErr = fmt.Errorf("failed to connect to Session Manager on attempt %d: %w", attempt+1, connectErr)
 				continue
 			}
-			// Session Manager is already initialized - skip the initialize phase below
+			// Session Manager is already initialized - skip the initialize phase below.
+			// The adapter took BSL LS's capabilities and token legend from it on connect.
 			adapter.SetProjectRoots([]string{dir})
-			b.mu.Lock()
-			b.clients[types.LanguageServer(language)] = adapter
-			b.mu.Unlock()
+			b.storeClient(types.LanguageServer(language), adapter)
 			logger.Info("Connected to LSP Session Manager for language: " + language)
 			return adapter, nil
 
@@ -222,11 +245,41 @@ func (b *MCPLSPBridge) validateAndConnectClient(language string, serverConfig ty
 			// RootUri:          &root_uri,
 			WorkspaceFolders: &workspaceFolders,
 			Capabilities: protocol.ClientCapabilities{
+				// Let the server count positions in UTF-32 or UTF-8 where it can;
+				// the edit engine and range tools convert whatever it picks.
+				General: &protocol.GeneralClientCapabilities{
+					PositionEncodings: utils.ClientPositionEncodings,
+				},
 				// Enable features that some servers gate behind client capabilities.
 				TextDocument: &protocol.TextDocumentClientCapabilities{
 					CallHierarchy: &protocol.CallHierarchyClientCapabilities{
 						DynamicRegistration: true,
 					},
+					// The completion tool resolves documentation and detail lazily
+					Completion: &protocol.CompletionClientCapabilities{
+						CompletionItem: &protocol.ClientCompletionItemOptions{
+							DocumentationFormat: []protocol.MarkupKind{protocol.MarkupKindMarkdown, protocol.MarkupKindPlainText},
+							LabelDetailsSupport: true,
+							DeprecatedSupport:   true,
+							ResolveSupport: &protocol.ClientCompletionItemResolveOptions{
+								Properties: []string{"documentation", "detail"},
+							},
+						},
+					},
+					// Both forms of navigation results are decoded
+					Declaration: &protocol.DeclarationClientCapabilities{
+						LinkSupport: true,
+					},
+					TypeDefinition: &protocol.TypeDefinitionClientCapabilities{
+						LinkSupport: true,
+					},
+					DocumentHighlight: &protocol.DocumentHighlightClientCapabilities{},
+					// The code_lens tool resolves commands (complexity, test runs) lazily
+					CodeLens: &protocol.CodeLensClientCapabilities{
+						ResolveSupport: &protocol.ClientCodeLensResolveOptions{
+							Properties: []string{"command"},
+						},
+					},
 				},
 				// Critical for server-initiated progress:
 				// allows `window/workDoneProgress/create` + `$/progress`.
@@ -352,41 +405,28 @@ func (b *MCPLSPBridge) GetClientForLanguage(language string) (types.LanguageClie
 		return nil, fmt.Errorf("no server found for language %s", language)
 	}
 
-	// Check if client already exists
-	if existingClient, exists := b.clients[server]; exists {
-		// Check if client context is still valid (not cancelled)
-		if existingClient.Context().Err() == nil {
-			metrics := existingClient.GetMetrics()
-			logger.Debug(fmt.Sprintf("GetClientForLanguage: Existing client for %s, metrics: %+v", language, metrics))
-
-			// If client is not connected (or is in error/disconnected state), recreate it.
-			// This prevents one-off request timeouts from permanently poisoning readiness checks.
-			statusStr := lsp.ClientStatus(metrics.GetStatus()).String()
-			lastErr := metrics.GetLastError()
-			connErr := strings.Contains(lastErr, "connection is closed") ||
-				strings.Contains(lastErr, "already disconnected") ||
-				strings.Contains(lastErr, "EOF")
-			if !metrics.IsConnected() || statusStr == "disconnected" || connErr {
-				logger.Warn(fmt.Sprintf("GetClientForLanguage: recreating unhealthy client for %s (connected=%v status=%s last_error=%s)",
-					language, metrics.IsConnected(), statusStr, metrics.GetLastError()))
-
-				if err := existingClient.Close(); err != nil {
-					return nil, err
-				}
-				delete(b.clients, server)
-			} else {
-				return existingClient, nil
-			}
+	if existingClient, exists := b.lookupClient(server); exists {
+		if usable, _ := clientUsable(existingClient); usable {
+			return existingClient, nil
 		}
-		// Client context is cancelled, remove it and create a new one
-		logger.Warn("Removing client with cancelled context for language " + language)
+	}
 
-		err := existingClient.Close()
-		if err != nil {
-			return nil, err
+	// Tool calls from concurrent transports must not start one server each
+	b.connectMu.Lock()
+	defer b.connectMu.Unlock()
+
+	// Check if client already exists; another caller may have replaced it meanwhile
+	if existingClient, exists := b.lookupClient(server); exists {
+		usable, reason := clientUsable(existingClient)
+		if usable {
+			return existingClient, nil
 		}
+		logger.Warn(fmt.Sprintf("GetClientForLanguage: recreating client for %s: %s", language, reason))
 
-		delete(b.clients, server)
+		b.removeClient(server, existingClient)
+		if err := existingClient.Close(); err != nil {
+			return nil, err
+		}
 	}
 
 	// Find the server configuration
@@ -402,11 +442,56 @@ func (b *MCPLSPBridge) GetClientForLanguage(language string) (types.LanguageClie
 	}
 
 	// Store the new client
-	b.clients[server] = client
+	b.storeClient(server, client)
 
 	return client, nil
 }
 
+// clientUsable tells whether an existing client can serve requests, and why not
+func clientUsable(client types.LanguageClientInterface) (bool, string) {
+	// Check if client context is still valid (not cancelled)
+	if client.Context().Err() != nil {
+		return false, "client context is cancelled"
+	}
+
+	// If client is not connected (or is in error/disconnected state), recreate it.
+	// This prevents one-off request timeouts from permanently poisoning readiness checks.
+	metrics := client.GetMetrics()
+	statusStr := lsp.ClientStatus(metrics.GetStatus()).String()
+	lastErr := metrics.GetLastError()
+	connErr := strings.Contains(lastErr, "connection is closed") ||
+		strings.Contains(lastErr, "already disconnected") ||
+		strings.Contains(lastErr, "EOF")
+	if !metrics.IsConnected() || statusStr == "disconnected" || connErr {
+		return false, fmt.Sprintf("unhealthy (connected=%v status=%s last_error=%s)", metrics.IsConnected(), statusStr, lastErr)
+	}
+	return true, ""
+}
+
+// lookupClient returns the client stored for a server
+func (b *MCPLSPBridge) lookupClient(server types.LanguageServer) (types.LanguageClientInterface, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	client, ok := b.clients[server]
+	return client, ok
+}
+
+// storeClient remembers the client of a server
+func (b *MCPLSPBridge) storeClient(server types.LanguageServer, client types.LanguageClientInterface) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.clients[server] = client
+}
+
+// removeClient forgets the client of a server unless it was replaced already
+func (b *MCPLSPBridge) removeClient(server types.LanguageServer, client types.LanguageClientInterface) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.clients[server] == client {
+		delete(b.clients, server)
+	}
+}
+
 // GetAllClientsForLanguage retrieves or creates all language server clients for a specific language
 func (b *MCPLSPBridge) GetAllClientsForLanguage(language string) ([]types.LanguageClientInterface, []types.LanguageServer, error) {
 	// Find all server configurations for this language
@@ -415,6 +500,9 @@ func (b *MCPLSPBridge) GetAllClientsForLanguage(language string) ([]types.Langua
 		return nil, nil, fmt.Errorf("no server configurations found for language %s: %w", language, err)
 	}
 
+	b.connectMu.Lock()
+	defer b.connectMu.Unlock()
+
 	var clients []types.LanguageClientInterface
 	var validServerNames []types.LanguageServer
 
@@ -422,7 +510,7 @@ func (b *MCPLSPBridge) GetAllClientsForLanguage(language string) ([]types.Langua
 		serverConfig := serverConfigs[i]
 
 		// Check if client already exists
-		if existingClient, exists := b.clients[serverName]; exists {
+		if existingClient, exists := b.lookupClient(serverName); exists {
 			// Check if client context is still valid (not cancelled)
 			if existingClient.Context().Err() == nil {
 				clients = append(clients, existingClient)
@@ -432,12 +520,11 @@ func (b *MCPLSPBridge) GetAllClientsForLanguage(language string) ([]types.Langua
 			// Client context is cancelled, remove it and create a new one
 			logger.Warn("Removing client with cancelled context for language " + language + " server " + string(serverName))
 
+			b.removeClient(serverName, existingClient)
 			err := existingClient.Close()
 			if err != nil {
 				logger.Error(fmt.Sprintf("Error closing cancelled client for %s: %v", serverName, err))
 			}
-
-			delete(b.clients, serverName)
 		}
 
 		// Attempt to connect with default configuration
@@ -448,7 +535,7 @@ func (b *MCPLSPBridge) GetAllClientsForLanguage(language string) ([]types.Langua
 		}
 
 		// Store the new client
-		b.clients[serverName] = client
+		b.storeClient(serverName, client)
 		clients = append(clients, client)
 		validServerNames = append(validServerNames, serverName)
 	}
@@ -462,14 +549,17 @@ func (b *MCPLSPBridge) GetAllClientsForLanguage(language string) ([]types.Langua
 
 // CloseAllClients closes all active language server clients
 func (b *MCPLSPBridge) CloseAllClients() {
-	for serverName, client := range b.clients {
+	b.mu.Lock()
+	clients := b.clients
+	b.clients = make(map[types.LanguageServer]types.LanguageClientInterface)
+	b.mu.Unlock()
+
+	for serverName, client := range clients {
 		err := client.Close()
 		if err != nil {
 			logger.Error(fmt.Errorf("failed to close client for %s: %w", serverName, err))
 		}
 	}
-
-	b.clients = make(map[types.LanguageServer]types.LanguageClientInterface)
 }
 
 // InferLanguage infers the programming language from a file path
@@ -484,6 +574,25 @@ func (b *MCPLSPBridge) InferLanguage(filePath string) (*types.Language, error) {
 	return language, nil
 }
 
+// PositionEncoding returns the position encoding negotiated with the language server
+// for uri. Without a connected server positions are UTF-16, the LSP default.
+func (b *MCPLSPBridge) PositionEncoding(uri string) protocol.PositionEncodingKind {
+	if b.config == nil {
+		return protocol.PositionEncodingKindUTF16
+	}
+	language, err := b.InferLanguage(uri)
+	if err != nil {
+		return protocol.PositionEncodingKindUTF16
+	}
+	server := b.config.GetServerNameFromLanguage(*language)
+
+	client, ok := b.lookupClient(server)
+	if !ok {
+		return protocol.PositionEncodingKindUTF16
+	}
+	return utils.ServerPositionEncoding(client.ServerCapabilities())
+}
+
 // GetConfig returns the bridge's configuration
 func (b *MCPLSPBridge) GetConfig() types.LSPServerConfigProvider {
 	return b.config
@@ -524,7 +633,7 @@ func (b *MCPLSPBridge) FindSymbolReferences(language, uri string, line, characte
 		return nil, fmt.Errorf("failed to get client for language %s: %w", language, err)
 	}
 
-	references, err := client.References(uri, line, character, includeDeclaration)
+	references, err := b.bind(client).References(uri, line, character, includeDeclaration)
 	if err != nil {
 		return nil, fmt.Errorf("failed to find references: %w", err)
 	}
@@ -540,10 +649,10 @@ func (b *MCPLSPBridge) FindSymbolDefinitions(language, uri string, line, charact
 	}
 
 	normalizedURI := b.NormalizeURIForLSP(uri)
-	definitions, err := client.Definition(normalizedURI, line, character)
+	definitions, err := b.bind(client).Definition(normalizedURI, line, character)
 	if err != nil {
 		// Log the error but return empty results instead of failing
-		logger.Warn(fmt.Sprintf("Failed to find definitions for %s at %s:%d:%d: %v", language, normalizedURI, line, character, err))
+		logger.WarnContext(b.requestContext(), "Failed to find definitions", "language", language, "uri", normalizedURI, "line", line, "character", character, "error", err)
 		return []protocol.Or2[protocol.LocationLink, protocol.Location]{}, nil
 	}
 
@@ -557,7 +666,7 @@ func (b *MCPLSPBridge) SearchTextInWorkspace(language, query string) ([]protocol
 		return nil, fmt.Errorf("failed to get client for language %s: %w", language, err)
 	}
 
-	symbols, err := client.WorkspaceSymbols(query)
+	symbols, err := b.bind(client).WorkspaceSymbols(query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to search workspace symbols: %w", err)
 	}
@@ -587,7 +696,7 @@ func (b *MCPLSPBridge) SearchTextInAllLanguages(query string) ([]protocol.Worksp
 
 	// Search across all connected clients
 	for server, client := range clientMap {
-		symbols, err := client.WorkspaceSymbols(query)
+		symbols, err := b.bind(client).WorkspaceSymbols(query)
 		if err != nil {
 			errs = append(errs, fmt.Errorf("search failed for %s: %w", server, err))
 			continue
@@ -631,7 +740,7 @@ func (b *MCPLSPBridge) GetMultiLanguageClients(languages []string) (map[types.La
 
 			client, err := b.GetClientForLanguage(lang)
 			if err != nil {
-				logger.Error(fmt.Sprintf("Failed to get client for language %s: %v", lang, err))
+				logger.ErrorContext(b.requestContext(), "Failed to get client for language", "language", lang, "error", err)
 				errMu.Lock()
 				lastErr = err
 				errMu.Unlock()
@@ -657,7 +766,7 @@ func (b *MCPLSPBridge) GetMultiLanguageClients(languages []string) (map[types.La
 // GetHoverInformation gets hover information for a symbol at a specific position
 func (b *MCPLSPBridge) GetHoverInformation(uri string, line, character uint32) (*protocol.Hover, error) {
 	// Extensive debug logging
-	logger.Debug(fmt.Sprintf("GetHoverInformation: Starting hover request for URI: %s, Line: %d, Character: %d", uri, line, character))
+	logger.DebugContext(b.requestContext(), "GetHoverInformation: Starting hover request", "uri", uri, "line", line, "character", character)
 
 	// Normalize URI to ensure proper file:// scheme
 	normalizedURI := b.NormalizeURIForLSP(uri)
@@ -665,14 +774,14 @@ func (b *MCPLSPBridge) GetHoverInformation(uri string, line, character uint32) (
 	// Infer language from URI (use original URI for file extension detection)
 	language, err := b.InferLanguage(uri)
 	if err != nil {
-		logger.Error("GetHoverInformation: Failed to infer language", fmt.Sprintf("URI: %s, Error: %v", uri, err))
+		logger.ErrorContext(b.requestContext(), "GetHoverInformation: Failed to infer language", "uri", uri, "error", err)
 		return nil, fmt.Errorf("failed to infer language: %w", err)
 	}
 
 	// Get language client
 	client, err := b.GetClientForLanguage(string(*language))
 	if err != nil {
-		logger.Error("GetHoverInformation: Failed to get language client", fmt.Sprintf("Language: %s, Error: %v", string(*language), err))
+		logger.ErrorContext(b.requestContext(), "GetHoverInformation: Failed to get language client", "language", string(*language), "error", err)
 		return nil, fmt.Errorf("failed to get client for language %s: %w", string(*language), err)
 	}
 
@@ -680,23 +789,56 @@ func (b *MCPLSPBridge) GetHoverInformation(uri string, line, character uint32) (
 	err = b.ensureDocumentOpen(client, normalizedURI, string(*language))
 	if err != nil {
 		// Continue anyway, as some servers might still work without explicit didOpen
-		logger.Error("GetHoverInformation: Failed to open document", fmt.Sprintf("URI: %s, Error: %v", normalizedURI, err))
+		logger.ErrorContext(b.requestContext(), "GetHoverInformation: Failed to open document", "uri", normalizedURI, "error", err)
 	}
 
-	result, err := client.Hover(normalizedURI, line, character)
+	result, err := b.bind(client).Hover(normalizedURI, line, character)
 
 	if err != nil {
-		logger.Error("GetHoverInformation: Request failed", fmt.Sprintf("Language: %s, Error: %v", string(*language), err))
+		logger.ErrorContext(b.requestContext(), "GetHoverInformation: Request failed", "language", string(*language), "error", err)
 		return nil, fmt.Errorf("hover request failed: %w", err)
 	}
 
 	return result, nil
 }
 
-// ensureDocumentOpen sends a textDocument/didOpen notification to the language server
-// This is often required before other document operations can be performed
+// ensureDocumentOpen makes sure the language server sees the current content of the document.
+// The first call sends textDocument/didOpen, later calls send didChange only when the file on
+// disk differs from what the server has. Documents with an agent-supplied overlay (UpdateBuffer)
+// are left untouched until the overlay is reverted or written to disk.
 func (b *MCPLSPBridge) ensureDocumentOpen(client types.LanguageClientInterface, uri, language string) error {
-	// Read the file content. Accept file URI or raw path.
+	absPath, err := b.resolveDocumentPath(client, uri)
+	if err != nil {
+		return err
+	}
+
+	// absPath is already in the server filesystem namespace (container or local).
+	serverURI := utils.NormalizeURI(absPath)
+
+	if b.documents.hasOverlay(client, serverURI) {
+		logger.DebugContext(b.requestContext(), "Document has an in-memory overlay, skipping disk sync", "uri", serverURI)
+		return nil
+	}
+
+	content, err := os.ReadFile(absPath) // #nosec G304 - validated against project roots above
+	if err != nil {
+		return fmt.Errorf("failed to read file %s: %w", absPath, err)
+	}
+
+	version, err := b.documents.sync(b.ctx, client, serverURI, language, string(content), false)
+	if err != nil {
+		return err
+	}
+
+	logger.DebugContext(b.requestContext(), "Document synced with LSP server", "uri", uri, "language", language, "version", version)
+
+	return nil
+}
+
+// resolveDocumentPath maps a URI or path to an absolute path in the server filesystem namespace
+// and validates it against the client's project roots.
+func (b *MCPLSPBridge) resolveDocumentPath(client types.LanguageClientInterface, uri string) (string, error) {
+	// Accept file URI or raw path.
 	// If running in container mode, map host paths to container paths before any fs operations.
 	filePath := utils.URIToFilePath(uri)
 	if b.HasPathMapper() && b.pathMapper != nil {
@@ -709,12 +851,10 @@ func (b *MCPLSPBridge) ensureDocumentOpen(client types.LanguageClientInterface,
 			filePath = path.Join(b.pathMapper.ContainerRoot(), normalizedPath)
 		}
 
-		slash := strings.ReplaceAll(filePath, "\\", "/")
-		cr := strings.TrimSuffix(b.pathMapper.ContainerRoot(), "/")
-		if !(slash == cr || strings.HasPrefix(slash, cr+"/")) {
+		if !b.pathMapper.IsContainerPath(filePath) {
 			mapped, mapErr := b.pathMapper.HostToContainer(filePath)
 			if mapErr != nil {
-				return fmt.Errorf("path mapping failed: %w", mapErr)
+				return "", fmt.Errorf("path mapping failed: %w", mapErr)
 			}
 			filePath = mapped
 		}
@@ -728,7 +868,7 @@ func (b *MCPLSPBridge) ensureDocumentOpen(client types.LanguageClientInterface,
 	// Convert to absolute path
 	absPath, err := filepath.Abs(cleanPath)
 	if err != nil {
-		return fmt.Errorf("invalid file path: %w", err)
+		return "", fmt.Errorf("invalid file path: %w", err)
 	}
 
 	// Validate against allowed project roots (ANY match is allowed).
@@ -741,79 +881,54 @@ func (b *MCPLSPBridge) ensureDocumentOpen(client types.LanguageClientInterface,
 			}
 		}
 		if !allowed {
-			return errors.New("access denied: path outside allowed directory")
+			return "", errors.New("access denied: path outside allowed directory")
 		}
 	}
 
-	content, err := os.ReadFile(absPath)
-	if err != nil {
-		return fmt.Errorf("failed to read file %s: %w", filePath, err)
-	}
-
-	// Send textDocument/didOpen notification
-	// absPath is already in the server filesystem namespace (container or local).
-	serverURI := utils.NormalizeURI(absPath)
-
-	didOpenParams := protocol.DidOpenTextDocumentParams{
-		TextDocument: protocol.TextDocumentItem{
-			Uri:        protocol.DocumentUri(serverURI),
-			LanguageId: protocol.LanguageKind(language),
-			Version:    1,
-			Text:       string(content),
-		},
-	}
-
-	err = client.SendNotification("textDocument/didOpen", didOpenParams)
-	if err != nil {
-		return fmt.Errorf("failed to send didOpen notification: %w", err)
-	}
-
-	logger.Debug(fmt.Sprintf("Document opened in LSP server: %s (language: %s)", uri, language))
-
-	return nil
+	return absPath, nil
 }
 
 // GetDocumentSymbols gets all symbols in a document
 func (b *MCPLSPBridge) GetDocumentSymbols(uri string) ([]protocol.DocumentSymbol, error) {
 	// Normalize URI to ensure proper file:// scheme
 	normalizedURI := b.NormalizeURIForLSP(uri)
-	logger.Debug(fmt.Sprintf("GetDocumentSymbols: Starting request for URI: %s -> %s", uri, normalizedURI))
+	logger.DebugContext(b.requestContext(), "GetDocumentSymbols: Starting request", "uri", uri, "normalized_uri", normalizedURI)
 
 	// Infer language from URI
 	language, err := b.InferLanguage(uri)
 	if err != nil {
-		logger.Error("GetDocumentSymbols: Failed to infer language", fmt.Sprintf("URI: %s, Error: %v", uri, err))
+		logger.ErrorContext(b.requestContext(), "GetDocumentSymbols: Failed to infer language", "uri", uri, "error", err)
 		return nil, fmt.Errorf("failed to infer language: %w", err)
 	}
 
-	logger.Debug(fmt.Sprintf("GetDocumentSymbols: Inferred language: %s", *language))
+	logger.DebugContext(b.requestContext(), "GetDocumentSymbols: Inferred language", "language", *language)
 
 	// Get language client
 	client, err := b.GetClientForLanguage(string(*language))
 	if err != nil {
-		logger.Error("GetDocumentSymbols: Failed to get language client", fmt.Sprintf("Language: %s, Error: %v", string(*language), err))
+		logger.ErrorContext(b.requestContext(), "GetDocumentSymbols: Failed to get language client", "language", string(*language), "error", err)
 		return nil, fmt.Errorf("failed to get client for language %s: %w", string(*language), err)
 	}
 
 	// Additional debugging: check client status
-	metrics := client.GetMetrics()
-	logger.Debug(fmt.Sprintf("GetDocumentSymbols: Client metrics: %+v", metrics))
+	metrics := b.bind(client).GetMetrics()
+	logger.DebugContext(b.requestContext(), "GetDocumentSymbols: Client metrics", "metrics", fmt.Sprintf("%+v", metrics))
 
 	// Ensure the document is opened in the language server
 	err = b.ensureDocumentOpen(client, normalizedURI, string(*language))
 	if err != nil {
 		// Continue anyway, as some servers might still work without explicit didOpen
-		logger.Error("GetDocumentSymbols: Failed to open document", fmt.Sprintf("URI: %s, Error: %v", normalizedURI, err))
+		logger.ErrorContext(b.requestContext(), "GetDocumentSymbols: Failed to open document", "uri", normalizedURI, "error", err)
 	}
 
 	// Get document symbols
-	symbols, err := client.DocumentSymbols(normalizedURI)
+	symbols, err := b.bind(client).DocumentSymbols(normalizedURI)
 	if err != nil {
-		logger.Error("GetDocumentSymbols: Request failed", fmt.Sprintf("Language: %s, Error: %v", string(*language), err))
+		logger.ErrorContext(b.requestContext(), "GetDocumentSymbols: Request failed", "language", string(*language), "error", err)
 		return nil, fmt.Errorf("document symbols request failed: %w", err)
 	}
 
-	logger.Debug(fmt.Sprintf("GetDocumentSymbols: Found %d symbols", len(symbols)))
+	logger.DebugContext(b.requestContext(), "GetDocumentSymbols: Found symbols", "count", len(symbols))
 
 	return symbols, nil
 }
@@ -835,14 +950,14 @@ func (b *MCPLSPBridge) GetSignatureHelp(uri string, line, character uint32) (*pr
 	// Infer language from URI
 	language, err := b.InferLanguage(normalizedURI)
 	if err != nil {
-		logger.Error("GetSignatureHelp: Language inference failed", fmt.Sprintf("URI: %s, Error: %v", normalizedURI, err))
+		logger.ErrorContext(b.requestContext(), "GetSignatureHelp: Language inference failed", "uri", normalizedURI, "error", err)
 		return nil, fmt.Errorf("failed to infer language: %w", err)
 	}
 
 	// Get language client
 	client, err := b.GetClientForLanguage(string(*language))
 	if err != nil {
-		logger.Error("GetSignatureHelp: Client creation failed", fmt.Sprintf("Language: %s, Error: %v", string(*language), err))
+		logger.ErrorContext(b.requestContext(), "GetSignatureHelp: Client creation failed", "language", string(*language), "error", err)
 		return nil, fmt.Errorf("failed to get client for language %s: %w", string(*language), err)
 	}
 
@@ -850,30 +965,88 @@ func (b *MCPLSPBridge) GetSignatureHelp(uri string, line, character uint32) (*pr
 	err = b.ensureDocumentOpen(client, normalizedURI, string(*language))
 	if err != nil {
 		// Continue anyway, as some servers might still work without explicit didOpen
-		logger.Error("GetSignatureHelp: Failed to open document", fmt.Sprintf("URI: %s, Error: %v", normalizedURI, err))
+		logger.ErrorContext(b.requestContext(), "GetSignatureHelp: Failed to open document", "uri", normalizedURI, "error", err)
 	}
 
 	// Execute signature help request using the LSP client method
 	var signatureHelp *protocol.SignatureHelp
 
-	signatureHelp, err = client.SignatureHelp(normalizedURI, line, character)
+	signatureHelp, err = b.bind(client).SignatureHelp(normalizedURI, line, character)
 	if err != nil {
-		logger.Error("GetSignatureHelp: Request failed", fmt.Sprintf("Language: %s, Error: %v", string(*language), err))
+		logger.ErrorContext(b.requestContext(), "GetSignatureHelp: Request failed", "language", string(*language), "error", err)
 		return nil, fmt.Errorf("signature help request failed: %w", err)
 	}
 
 	// Check if the signatureHelp response is valid and contains signatures
 	if signatureHelp == nil || len(signatureHelp.Signatures) == 0 {
-		logger.Warn(fmt.Sprintf("GetSignatureHelp: No signatures found for position %d:%d in %s. Response was: %+v", line, character, normalizedURI, signatureHelp))
+		logger.WarnContext(b.requestContext(), "GetSignatureHelp: No signatures found", "uri", normalizedURI, "line", line, "character", character, "response", fmt.Sprintf("%+v", signatureHelp))
 		// Return an empty result or a specific error indicating no signatures were found
 		return nil, nil // Return empty, or you could return a specific error
 	}
 
-	logger.Debug(fmt.Sprintf("GetSignatureHelp: Found signature help for position %d:%d", line, character))
+	logger.DebugContext(b.requestContext(), "GetSignatureHelp: Found signature help", "line", line, "character", character)
 
 	return signatureHelp, nil
 }
 
+// Completion returns completion items at a position. Items are computed against the
+// text the server has for the document, which is an UpdateBuffer overlay if one is set.
+func (b *MCPLSPBridge) Completion(uri string, line, character uint32) (*protocol.CompletionList, error) {
+	normalizedURI := b.NormalizeURIForLSP(uri)
+
+	language, err := b.InferLanguage(normalizedURI)
+	if err != nil {
+		return nil, fmt.Errorf("failed to infer language: %w", err)
+	}
+
+	client, err := b.GetClientForLanguage(string(*language))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get client for language %s: %w", string(*language), err)
+	}
+
+	if err := b.ensureDocumentOpen(client, normalizedURI, string(*language)); err != nil {
+		logger.ErrorContext(b.requestContext(), "Completion: Failed to open document", "uri", normalizedURI, "error", err)
+	}
+
+	list, err := b.bind(client).Completion(normalizedURI, line, character)
+	if err != nil {
+		return nil, fmt.Errorf("completion request failed: %w", err)
+	}
+
+	logger.DebugContext(b.requestContext(), "Completion: items", "count", len(list.Items), "uri", normalizedURI, "line", line, "character", character, "incomplete", list.IsIncomplete)
+	return list, nil
+}
+
+// ResolveCompletionItem resolves a completion item returned for uri. The item is
+// returned as-is when the server does not advertise completionItem/resolve (or its
+// capabilities are not known yet) or answers with nothing.
+func (b *MCPLSPBridge) ResolveCompletionItem(uri string, item protocol.CompletionItem) (*protocol.CompletionItem, error) {
+	normalizedURI := b.NormalizeURIForLSP(uri)
+
+	language, err := b.InferLanguage(normalizedURI)
+	if err != nil {
+		return nil, fmt.Errorf("failed to infer language: %w", err)
+	}
+
+	client, err := b.GetClientForLanguage(string(*language))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get client for language %s: %w", string(*language), err)
+	}
+
+	if provider := client.ServerCapabilities().CompletionProvider; provider == nil || !provider.ResolveProvider {
+		return &item, nil
+	}
+
+	resolved, err := b.bind(client).ResolveCompletionItem(item)
+	if err != nil {
+		return nil, err
+	}
+	if resolved == nil || resolved.Label == "" {
+		return &item, nil
+	}
+	return resolved, nil
+}
+
 // GetCodeActions gets code actions for a specific range
 func (b *MCPLSPBridge) GetCodeActions(uri string, line, character, endLine, endCharacter uint32) ([]protocol.CodeAction, error) {
 	// Infer language from URI
@@ -888,7 +1061,7 @@ func (b *MCPLSPBridge) GetCodeActions(uri string, line, character, endLine, endC
 		return nil, fmt.Errorf("failed to get client for language %s: %w", string(*language), err)
 	}
 
-	codeActions, err := client.CodeActions(uri, line, character, endLine, endCharacter)
+	codeActions, err := b.bind(client).CodeActions(uri, line, character, endLine, endCharacter)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get code actions: %w", err)
 	}
@@ -922,7 +1095,7 @@ func (b *MCPLSPBridge) FormatDocument(uri string, tabSize uint32, insertSpaces b
 	var result []protocol.TextEdit
 
 	// Formatting can be slow on large BSL modules (10k LOC).
-	err = client.SendRequest("textDocument/formatting", params, &result, 5*time.Minute)
+	err = b.bind(client).SendRequest("textDocument/formatting", params, &result, 5*time.Minute)
 	if err != nil {
 		return nil, fmt.Errorf("document formatting request failed: %w", err)
 	}
@@ -945,10 +1118,10 @@ func (b *MCPLSPBridge) RangeFormatting(uri string, startLine, startCharacter, en
 	}
 
 	if err := b.ensureDocumentOpen(client, normalizedURI, string(*language)); err != nil {
-		logger.Error("RangeFormatting: Failed to open document", fmt.Sprintf("URI: %s, Error: %v", normalizedURI, err))
+		logger.ErrorContext(b.requestContext(), "RangeFormatting: Failed to open document", "uri", normalizedURI, "error", err)
 	}
 
-	edits, err := client.RangeFormatting(normalizedURI, startLine, startCharacter, endLine, endCharacter, tabSize, insertSpaces)
+	edits, err := b.bind(client).RangeFormatting(normalizedURI, startLine, startCharacter, endLine, endCharacter, tabSize, insertSpaces)
 	if err != nil {
 		return nil, fmt.Errorf("range formatting request failed: %w", err)
 	}
@@ -971,10 +1144,10 @@ func (b *MCPLSPBridge) PrepareRename(uri string, line, character uint32) (*proto
 	}
 
 	if err := b.ensureDocumentOpen(client, normalizedURI, string(*language)); err != nil {
-		logger.Error("PrepareRename: Failed to open document", fmt.Sprintf("URI: %s, Error: %v", normalizedURI, err))
+		logger.ErrorContext(b.requestContext(), "PrepareRename: Failed to open document", "uri", normalizedURI, "error", err)
 	}
 
-	result, err := client.PrepareRename(normalizedURI, line, character)
+	result, err := b.bind(client).PrepareRename(normalizedURI, line, character)
 	if err != nil {
 		return nil, fmt.Errorf("prepare rename request failed: %w", err)
 	}
@@ -997,10 +1170,10 @@ func (b *MCPLSPBridge) FoldingRange(uri string) ([]protocol.FoldingRange, error)
 	}
 
 	if err := b.ensureDocumentOpen(client, normalizedURI, string(*language)); err != nil {
-		logger.Error("FoldingRange: Failed to open document", fmt.Sprintf("URI: %s, Error: %v", normalizedURI, err))
+		logger.ErrorContext(b.requestContext(), "FoldingRange: Failed to open document", "uri", normalizedURI, "error", err)
 	}
 
-	ranges, err := client.FoldingRange(normalizedURI)
+	ranges, err := b.bind(client).FoldingRange(normalizedURI)
 	if err != nil {
 		return nil, fmt.Errorf("folding range request failed: %w", err)
 	}
@@ -1023,10 +1196,10 @@ func (b *MCPLSPBridge) SelectionRange(uri string, positions []protocol.Position)
 	}
 
 	if err := b.ensureDocumentOpen(client, normalizedURI, string(*language)); err != nil {
-		logger.Error("SelectionRange: Failed to open document", fmt.Sprintf("URI: %s, Error: %v", normalizedURI, err))
+		logger.ErrorContext(b.requestContext(), "SelectionRange: Failed to open document", "uri", normalizedURI, "error", err)
 	}
 
-	ranges, err := client.SelectionRange(normalizedURI, positions)
+	ranges, err := b.bind(client).SelectionRange(normalizedURI, positions)
 	if err != nil {
 		return nil, fmt.Errorf("selection range request failed: %w", err)
 	}
@@ -1034,6 +1207,66 @@ func (b *MCPLSPBridge) SelectionRange(uri string, positions []protocol.Position)
 	return ranges, nil
 }
 
+// CodeLens returns the code lenses of a document as the server sends them, usually
+// without a command; see ResolveCodeLens.
+func (b *MCPLSPBridge) CodeLens(uri string) ([]protocol.CodeLens, error) {
+	normalizedURI := b.NormalizeURIForLSP(uri)
+
+	language, err := b.InferLanguage(normalizedURI)
+	if err != nil {
+		return nil, fmt.Errorf("failed to infer language: %w", err)
+	}
+
+	client, err := b.GetClientForLanguage(string(*language))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get client for language %s: %w", string(*language), err)
+	}
+
+	if err := b.ensureDocumentOpen(client, normalizedURI, string(*language)); err != nil {
+		logger.ErrorContext(b.requestContext(), "CodeLens: Failed to open document", "uri", normalizedURI, "error", err)
+	}
+
+	lenses, err := b.bind(client).CodeLens(normalizedURI)
+	if err != nil {
+		return nil, fmt.Errorf("code lens request failed: %w", err)
+	}
+
+	return lenses, nil
+}
+
+// ResolveCodeLens resolves the command of a code lens returned for uri. The lens is
+// returned as-is when it already has a command or the server does not resolve lenses.
+func (b *MCPLSPBridge) ResolveCodeLens(uri string, lens protocol.CodeLens) (*protocol.CodeLens, error) {
+	if lens.Command != nil {
+		return &lens, nil
+	}
+
+	normalizedURI := b.NormalizeURIForLSP(uri)
+
+	language, err := b.InferLanguage(normalizedURI)
+	if err != nil {
+		return nil, fmt.Errorf("failed to infer language: %w", err)
+	}
+
+	client, err := b.GetClientForLanguage(string(*language))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get client for language %s: %w", string(*language), err)
+	}
+
+	if provider := client.ServerCapabilities().CodeLensProvider; provider != nil && !provider.ResolveProvider {
+		return &lens, nil
+	}
+
+	resolved, err := b.bind(client).ResolveCodeLens(lens)
+	if err != nil {
+		return nil, err
+	}
+	if resolved == nil {
+		return &lens, nil
+	}
+	return resolved, nil
+}
+
 // DocumentLink returns document links for a document.
 func (b *MCPLSPBridge) DocumentLink(uri string) ([]protocol.DocumentLink, error) {
 	normalizedURI := b.NormalizeURIForLSP(uri)
@@ -1049,10 +1282,10 @@ func (b *MCPLSPBridge) DocumentLink(uri string) ([]protocol.DocumentLink, error)
 	}
 
 	if err := b.ensureDocumentOpen(client, normalizedURI, string(*language)); err != nil {
-		logger.Error("DocumentLink: Failed to open document", fmt.Sprintf("URI: %s, Error: %v", normalizedURI, err))
+		logger.ErrorContext(b.requestContext(), "DocumentLink: Failed to open document", "uri", normalizedURI, "error", err)
 	}
 
-	links, err := client.DocumentLink(normalizedURI)
+	links, err := b.bind(client).DocumentLink(normalizedURI)
 	if err != nil {
 		return nil, fmt.Errorf("document link request failed: %w", err)
 	}
@@ -1075,10 +1308,10 @@ func (b *MCPLSPBridge) DocumentColor(uri string) ([]protocol.ColorInformation, e
 	}
 
 	if err := b.ensureDocumentOpen(client, normalizedURI, string(*language)); err != nil {
-		logger.Error("DocumentColor: Failed to open document", fmt.Sprintf("URI: %s, Error: %v", normalizedURI, err))
+		logger.ErrorContext(b.requestContext(), "DocumentColor: Failed to open document", "uri", normalizedURI, "error", err)
 	}
 
-	colors, err := client.DocumentColor(normalizedURI)
+	colors, err := b.bind(client).DocumentColor(normalizedURI)
 	if err != nil {
 		return nil, fmt.Errorf("document color request failed: %w", err)
 	}
@@ -1101,10 +1334,10 @@ func (b *MCPLSPBridge) ColorPresentation(uri string, color protocol.Color, rng p
 	}
 
 	if err := b.ensureDocumentOpen(client, normalizedURI, string(*language)); err != nil {
-		logger.Error("ColorPresentation: Failed to open document", fmt.Sprintf("URI: %s, Error: %v", normalizedURI, err))
+		logger.ErrorContext(b.requestContext(), "ColorPresentation: Failed to open document", "uri", normalizedURI, "error", err)
 	}
 
-	presentations, err := client.ColorPresentation(normalizedURI, color, rng)
+	presentations, err := b.bind(client).ColorPresentation(normalizedURI, color, rng)
 	if err != nil {
 		return nil, fmt.Errorf("color presentation request failed: %w", err)
 	}
@@ -1134,7 +1367,7 @@ func (b *MCPLSPBridge) ApplyTextEdits(uri string, edits []protocol.TextEdit) err
 	}
 
 	// Apply edits to content
-	modifiedContent, err := applyTextEditsToContent(string(content), edits)
+	modifiedContent, err := applyTextEditsToContent(string(content), edits, b.PositionEncoding(uri))
 	if err != nil {
 		return fmt.Errorf("failed to apply text edits: %w", err)
 	}
@@ -1145,99 +1378,85 @@ func (b *MCPLSPBridge) ApplyTextEdits(uri string, edits []protocol.TextEdit) err
 		return fmt.Errorf("failed to write file %s: %w", filePath, err)
 	}
 
+	b.notifyDocumentSaved(filePath, modifiedContent)
+
 	return nil
 }
 
-// applyTextEditsToContent applies text edits to string content
-func applyTextEditsToContent(content string, edits []protocol.TextEdit) (string, error) {
+// applyTextEditsToContent applies text edits to string content. Positions are
+// counted in the position encoding of the server that computed the edits. Every
+// edit is checked first; one that cannot be applied fails the whole call.
+func applyTextEditsToContent(content string, edits []protocol.TextEdit, enc protocol.PositionEncodingKind) (string, error) {
 	if len(edits) == 0 {
 		return content, nil
 	}
 
-	// Split content into lines for easier manipulation. Use "\n" directly in the string
-	lines := strings.Split(content, "\n")
-
-	// Sort edits by position (reverse order to apply from end to beginning)
-	// This prevents position shifts from affecting subsequent edits
-	for i := 0; i < len(edits)-1; i++ {
-		for j := i + 1; j < len(edits); j++ {
-			edit1 := edits[i]
-			edit2 := edits[j]
-
-			// Compare positions (later positions first)
-			if edit1.Range.Start.Line < edit2.Range.Start.Line ||
-				(edit1.Range.Start.Line == edit2.Range.Start.Line &&
-					edit1.Range.Start.Character < edit2.Range.Start.Character) {
-				edits[i], edits[j] = edits[j], edits[i]
-			}
-		}
+	type span struct {
+		index      int
+		start, end int
+		text       string
 	}
 
-	// Apply edits in reverse order
-	for _, edit := range edits {
-		startLine := int(edit.Range.Start.Line)
-		startChar := int(edit.Range.Start.Character)
-		endLine := int(edit.Range.End.Line)
-		endChar := int(edit.Range.End.Character)
-
-		// Validate line indices
-		if startLine >= len(lines) || endLine >= len(lines) {
-			continue // Skip invalid edits
+	spans := make([]span, 0, len(edits))
+	var errs []error
+	for i, edit := range edits {
+		r := edit.Range
+		start, err := utils.PositionOffset(content, r.Start, enc)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("edit %d (%d:%d-%d:%d): invalid start: %w", i, r.Start.Line, r.Start.Character, r.End.Line, r.End.Character, err))
+			continue
 		}
+		end, err := utils.PositionOffset(content, r.End, enc)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("edit %d (%d:%d-%d:%d): invalid end: %w", i, r.Start.Line, r.Start.Character, r.End.Line, r.End.Character, err))
+			continue
+		}
+		if end < start {
+			errs = append(errs, fmt.Errorf("edit %d (%d:%d-%d:%d): end is before start", i, r.Start.Line, r.Start.Character, r.End.Line, r.End.Character))
+			continue
+		}
+		spans = append(spans, span{index: i, start: start, end: end, text: edit.NewText})
+	}
+	if len(errs) > 0 {
+		return "", errors.Join(errs...)
+	}
 
-		if startLine == endLine {
-			// Single line edit
-			line := lines[startLine]
-			if startChar > len(line) || endChar > len(line) {
-				continue // Skip invalid character positions
-			}
-
-			// Replace text within the line
-			newLine := line[:startChar] + edit.NewText + line[endChar:]
-			lines[startLine] = newLine
-		} else {
-			// Multi-line edit
-			if startChar > len(lines[startLine]) || endChar > len(lines[endLine]) {
-				continue // Skip invalid character positions
-			}
-
-			// Create new line combining start of first line + new text + end of last line
-			newLine := lines[startLine][:startChar] + edit.NewText + lines[endLine][endChar:]
-
-			// Remove the lines that were replaced
-			newLines := make([]string, 0, len(lines)-(endLine-startLine))
-			newLines = append(newLines, lines[:startLine]...)
-			newLines = append(newLines, newLine)
-
-			if endLine+1 < len(lines) {
-				newLines = append(newLines, lines[endLine+1:]...)
-			}
+	// Edits refer to the original content; inserts at the same position keep their order
+	sort.SliceStable(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
 
-			lines = newLines
+	var out strings.Builder
+	out.Grow(len(content))
+	offset := 0
+	for i, sp := range spans {
+		if i > 0 && sp.start < spans[i-1].end {
+			return "", fmt.Errorf("edits %d and %d overlap", spans[i-1].index, sp.index)
 		}
+		out.WriteString(content[offset:sp.start])
+		out.WriteString(sp.text)
+		offset = sp.end
 	}
+	out.WriteString(content[offset:])
 
-	// Use "\n" directly in the string
-	return strings.Join(lines, "\n"), nil
+	return out.String(), nil
 }
 
 // RenameSymbol renames a symbol with optional preview
 func (b *MCPLSPBridge) RenameSymbol(uri string, line, character uint32, newName string, preview bool) (*protocol.WorkspaceEdit, error) {
 	// Normalize URI to ensure proper file:// scheme
 	normalizedURI := b.NormalizeURIForLSP(uri)
-	logger.Debug(fmt.Sprintf("RenameSymbol: Starting rename request for URI: %s -> %s, Line: %d, Character: %d, NewName: %s", uri, normalizedURI, line, character, newName))
+	logger.DebugContext(b.requestContext(), "RenameSymbol: Starting rename request", "uri", uri, "normalized_uri", normalizedURI, "line", line, "character", character, "new_name", newName)
 
 	// Infer language from URI
 	language, err := b.InferLanguage(uri)
 	if err != nil {
-		logger.Error("RenameSymbol: Failed to infer language", fmt.Sprintf("URI: %s, Error: %v", uri, err))
+		logger.ErrorContext(b.requestContext(), "RenameSymbol: Failed to infer language", "uri", uri, "error", err)
 		return nil, fmt.Errorf("failed to infer language: %w", err)
 	}
 
 	// Get language client
 	client, err := b.GetClientForLanguage(string(*language))
 	if err != nil {
-		logger.Error("RenameSymbol: Failed to get language client", fmt.Sprintf("Language: %s, Error: %v", string(*language), err))
+		logger.ErrorContext(b.requestContext(), "RenameSymbol: Failed to get language client", "language", string(*language), "error", err)
 		return nil, fmt.Errorf("failed to get client for language %s: %w", string(*language), err)
 	}
 
@@ -1245,12 +1464,12 @@ func (b *MCPLSPBridge) RenameSymbol(uri string, line, character uint32, newName
 	err = b.ensureDocumentOpen(client, normalizedURI, string(*language))
 	if err != nil {
 		// Continue anyway, as some servers might still work without explicit didOpen
-		logger.Error("RenameSymbol: Failed to open document", fmt.Sprintf("URI: %s, Error: %v", normalizedURI, err))
+		logger.ErrorContext(b.requestContext(), "RenameSymbol: Failed to open document", "uri", normalizedURI, "error", err)
 	}
 
-	result, err := client.Rename(normalizedURI, line, character, newName)
+	result, err := b.bind(client).Rename(normalizedURI, line, character, newName)
 	if err != nil {
-		logger.Error("RenameSymbol: Failed to rename symbol", fmt.Sprintf("URI: %s, Line: %d, Character: %d, NewName: %s, Error: %v", normalizedURI, line, character, newName, err))
+		logger.ErrorContext(b.requestContext(), "RenameSymbol: Failed to rename symbol", "uri", normalizedURI, "line", line, "character", character, "new_name", newName, "error", err)
 		return nil, fmt.Errorf("failed to rename symbol: %w", err)
 	}
 
@@ -1268,7 +1487,7 @@ func (b *MCPLSPBridge) ExecuteCommand(language string, command string, arguments
 		return nil, fmt.Errorf("failed to get client for language %s: %w", language, err)
 	}
 
-	result, err := client.ExecuteCommand(command, arguments)
+	result, err := b.bind(client).ExecuteCommand(command, arguments)
 	if err != nil {
 		return nil, fmt.Errorf("execute command failed: %w", err)
 	}
@@ -1287,7 +1506,7 @@ func (b *MCPLSPBridge) DidChangeWatchedFiles(language string, changes []protocol
 		return fmt.Errorf("failed to get client for language %s: %w", language, err)
 	}
 
-	return client.DidChangeWatchedFiles(changes)
+	return b.bind(client).DidChangeWatchedFiles(changes)
 }
 
 // DidChangeConfiguration sends workspace/didChangeConfiguration notification.
@@ -1301,147 +1520,127 @@ func (b *MCPLSPBridge) DidChangeConfiguration(language string, settings any) err
 		return fmt.Errorf("failed to get client for language %s: %w", language, err)
 	}
 
-	return client.DidChangeConfiguration(settings)
+	return b.bind(client).DidChangeConfiguration(settings)
 }
 
-// ApplyWorkspaceEdit applies a workspace edit to multiple files
-func (b *MCPLSPBridge) ApplyWorkspaceEdit(workspaceEdit *protocol.WorkspaceEdit) error {
-	logger.Debug(fmt.Sprintf("ApplyWorkspaceEdit: Processing workspace edit. Changes: %+v, DocumentChanges: %+v", workspaceEdit.Changes, workspaceEdit.DocumentChanges))
+// FindImplementations finds implementations of a symbol
+func (b *MCPLSPBridge) FindImplementations(uri string, line, character uint32) ([]protocol.Location, error) {
+	// Normalize URI
+	normalizedURI := b.NormalizeURIForLSP(uri)
 
-	// Handle DocumentChanges format (preferred by most language servers)
-	if workspaceEdit.DocumentChanges != nil {
-		for _, docChange := range workspaceEdit.DocumentChanges {
-			logger.Debug(fmt.Sprintf("ApplyWorkspaceEdit: Processing document change of type: %T", docChange.Value))
+	// Infer language from URI
+	language, err := b.InferLanguage(normalizedURI)
+	if err != nil {
+		logger.ErrorContext(b.requestContext(), "FindImplementations: Language inference failed", "uri", normalizedURI, "error", err)
+		return nil, fmt.Errorf("failed to infer language: %w", err)
+	}
 
-			// DocumentChanges is []Or4[TextDocumentEdit, CreateFile, RenameFile, DeleteFile]
-			// We only handle TextDocumentEdit for now
-			if textDocEdit, ok := docChange.Value.(protocol.TextDocumentEdit); ok {
-				logger.Debug(fmt.Sprintf("ApplyWorkspaceEdit: Found TextDocumentEdit for URI: %s", textDocEdit.TextDocument.Uri))
+	// Get language client
+	client, err := b.GetClientForLanguage(string(*language))
+	if err != nil {
+		logger.ErrorContext(b.requestContext(), "FindImplementations: Client creation failed", "language", string(*language), "error", err)
+		return nil, fmt.Errorf("failed to get client for language %s: %w", string(*language), err)
+	}
 
-				// Convert protocol.TextEdit to []any for ApplyTextEdits
-				textEdits := make([]protocol.TextEdit, len(textDocEdit.Edits))
+	// Ensure document is open in the language server
+	err = b.ensureDocumentOpen(client, normalizedURI, string(*language))
+	if err != nil {
+		// Continue anyway, as some servers might still work without explicit didOpen
+		logger.ErrorContext(b.requestContext(), "FindImplementations: Failed to open document", "uri", normalizedURI, "error", err)
+	}
 
-				for i, edit := range textDocEdit.Edits {
-					// Edits might also be a union type, extract the actual TextEdit
-					if actualEdit, ok := edit.Value.(protocol.TextEdit); ok {
-						textEdits[i] = actualEdit
-						logger.Debug(fmt.Sprintf("ApplyWorkspaceEdit: Edit %d - Line %d:%d-%d:%d, NewText: '%s'",
-							i+1, actualEdit.Range.Start.Line, actualEdit.Range.Start.Character,
-							actualEdit.Range.End.Line, actualEdit.Range.End.Character, actualEdit.NewText))
-					} else {
-						logger.Error("ApplyWorkspaceEdit: Edit is not a TextEdit", fmt.Sprintf("Type: %T", edit.Value))
-						continue
-					}
-				}
+	// Execute implementation request using the LSP client method
+	implementations, err := b.bind(client).Implementation(normalizedURI, line, character)
+	if err != nil {
+		logger.ErrorContext(b.requestContext(), "FindImplementations: Request failed", "language", string(*language), "error", err)
+		return nil, fmt.Errorf("implementation request failed: %w", err)
+	}
 
-				// Apply the edits
-				if len(textEdits) > 0 {
-					logger.Debug(fmt.Sprintf("ApplyWorkspaceEdit: Applying %d text edits to %s", len(textEdits), textDocEdit.TextDocument.Uri))
+	logger.DebugContext(b.requestContext(), "FindImplementations: Found implementations", "count", len(implementations))
 
-					err := b.ApplyTextEdits(string(textDocEdit.TextDocument.Uri), textEdits)
-					if err != nil {
-						return fmt.Errorf("failed to apply document changes to %s: %w", textDocEdit.TextDocument.Uri, err)
-					}
-				}
-			} else if createFile, ok := docChange.Value.(protocol.CreateFile); ok {
-				logger.Debug(fmt.Sprintf("ApplyWorkspaceEdit: Found CreateFile for URI: %s", createFile.Uri))
-				filePath := utils.URIToFilePath(string(createFile.Uri))
-				filePath, err := b.IsAllowedDirectory(filePath)
-				if err != nil {
-					return fmt.Errorf("failed to create file %s: %w", filePath, err)
-				}
-				// Create the file with default permissions (e.g., 0600)
-				err = os.WriteFile(filePath, []byte{}, 0600)
-				if err != nil {
-					return fmt.Errorf("failed to create file %s: %w", filePath, err)
-				}
-				logger.Debug("ApplyWorkspaceEdit: Created file " + filePath)
-			} else if renameFile, ok := docChange.Value.(protocol.RenameFile); ok {
-				logger.Debug(fmt.Sprintf("ApplyWorkspaceEdit: Found RenameFile from %s to %s", renameFile.OldUri, renameFile.NewUri))
-				oldPath := utils.URIToFilePath(string(renameFile.OldUri))
-				newPath := utils.URIToFilePath(string(renameFile.NewUri))
+	return implementations, nil
+}
 
-				oldPath, err := b.IsAllowedDirectory(oldPath)
-				if err != nil {
-					return fmt.Errorf("failed to rename file (old path not allowed) %s: %w", oldPath, err)
-				}
-				newPath, err = b.IsAllowedDirectory(newPath)
-				if err != nil {
-					return fmt.Errorf("failed to rename file (new path not allowed) %s: %w", newPath, err)
-				}
+// FindDeclarations finds the declaration of the symbol at a position
+func (b *MCPLSPBridge) FindDeclarations(uri string, line, character uint32) ([]protocol.Or2[protocol.LocationLink, protocol.Location], error) {
+	normalizedURI := b.NormalizeURIForLSP(uri)
 
-				err = os.Rename(oldPath, newPath)
-				if err != nil {
-					return fmt.Errorf("failed to rename file from %s to %s: %w", oldPath, newPath, err)
-				}
-				logger.Debug(fmt.Sprintf("ApplyWorkspaceEdit: Renamed file from %s to %s", oldPath, newPath))
-			} else if deleteFile, ok := docChange.Value.(protocol.DeleteFile); ok {
-				logger.Debug(fmt.Sprintf("ApplyWorkspaceEdit: Found DeleteFile for URI: %s", deleteFile.Uri))
-				filePath := utils.URIToFilePath(string(deleteFile.Uri))
-				filePath, err := b.IsAllowedDirectory(filePath)
-				if err != nil {
-					return fmt.Errorf("failed to delete file %s: %w", filePath, err)
-				}
-				err = os.Remove(filePath)
-				if err != nil {
-					return fmt.Errorf("failed to delete file %s: %w", filePath, err)
-				}
-				logger.Debug("ApplyWorkspaceEdit: Deleted file " + filePath)
-			} else {
-				logger.Debug(fmt.Sprintf("ApplyWorkspaceEdit: Skipping unknown document change type: %T", docChange.Value))
-			}
-		}
+	language, err := b.InferLanguage(normalizedURI)
+	if err != nil {
+		return nil, fmt.Errorf("failed to infer language: %w", err)
 	}
 
-	// Apply changes map (alternative format)
-	if workspaceEdit.Changes != nil {
-		for uri, edits := range workspaceEdit.Changes {
-			err := b.ApplyTextEdits(string(uri), edits)
-			if err != nil {
-				return fmt.Errorf("failed to apply edits to %s: %w", uri, err)
-			}
-		}
+	client, err := b.GetClientForLanguage(string(*language))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get client for language %s: %w", string(*language), err)
 	}
 
-	return nil
+	if err := b.ensureDocumentOpen(client, normalizedURI, string(*language)); err != nil {
+		logger.ErrorContext(b.requestContext(), "FindDeclarations: Failed to open document", "uri", normalizedURI, "error", err)
+	}
+
+	declarations, err := b.bind(client).Declaration(normalizedURI, line, character)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.DebugContext(b.requestContext(), "FindDeclarations: declarations", "count", len(declarations), "uri", normalizedURI, "line", line, "character", character)
+	return declarations, nil
 }
 
-// FindImplementations finds implementations of a symbol
-func (b *MCPLSPBridge) FindImplementations(uri string, line, character uint32) ([]protocol.Location, error) {
-	// Normalize URI
+// FindTypeDefinitions finds the definition of the type of the symbol at a position
+func (b *MCPLSPBridge) FindTypeDefinitions(uri string, line, character uint32) ([]protocol.Or2[protocol.LocationLink, protocol.Location], error) {
 	normalizedURI := b.NormalizeURIForLSP(uri)
 
-	// Infer language from URI
 	language, err := b.InferLanguage(normalizedURI)
 	if err != nil {
-		logger.Error("FindImplementations: Language inference failed", fmt.Sprintf("URI: %s, Error: %v", normalizedURI, err))
 		return nil, fmt.Errorf("failed to infer language: %w", err)
 	}
 
-	// Get language client
 	client, err := b.GetClientForLanguage(string(*language))
 	if err != nil {
-		logger.Error("FindImplementations: Client creation failed", fmt.Sprintf("Language: %s, Error: %v", string(*language), err))
 		return nil, fmt.Errorf("failed to get client for language %s: %w", string(*language), err)
 	}
 
-	// Ensure document is open in the language server
-	err = b.ensureDocumentOpen(client, normalizedURI, string(*language))
+	if err := b.ensureDocumentOpen(client, normalizedURI, string(*language)); err != nil {
+		logger.ErrorContext(b.requestContext(), "FindTypeDefinitions: Failed to open document", "uri", normalizedURI, "error", err)
+	}
+
+	definitions, err := b.bind(client).TypeDefinition(normalizedURI, line, character)
 	if err != nil {
-		// Continue anyway, as some servers might still work without explicit didOpen
-		logger.Error("FindImplementations: Failed to open document", fmt.Sprintf("URI: %s, Error: %v", normalizedURI, err))
+		return nil, err
 	}
 
-	// Execute implementation request using the LSP client method
-	implementations, err := client.Implementation(normalizedURI, line, character)
+	logger.DebugContext(b.requestContext(), "FindTypeDefinitions: type definitions", "count", len(definitions), "uri", normalizedURI, "line", line, "character", character)
+	return definitions, nil
+}
+
+// DocumentHighlight returns the occurrences of the symbol at a position within the
+// document, with their read/write kind when the server reports it
+func (b *MCPLSPBridge) DocumentHighlight(uri string, line, character uint32) ([]protocol.DocumentHighlight, error) {
+	normalizedURI := b.NormalizeURIForLSP(uri)
+
+	language, err := b.InferLanguage(normalizedURI)
 	if err != nil {
-		logger.Error("FindImplementations: Request failed", fmt.Sprintf("Language: %s, Error: %v", string(*language), err))
-		return nil, fmt.Errorf("implementation request failed: %w", err)
+		return nil, fmt.Errorf("failed to infer language: %w", err)
 	}
 
-	logger.Debug(fmt.Sprintf("FindImplementations: Found %d implementations", len(implementations)))
+	client, err := b.GetClientForLanguage(string(*language))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get client for language %s: %w", string(*language), err)
+	}
 
-	return implementations, nil
+	if err := b.ensureDocumentOpen(client, normalizedURI, string(*language)); err != nil {
+		logger.ErrorContext(b.requestContext(), "DocumentHighlight: Failed to open document", "uri", normalizedURI, "error", err)
+	}
+
+	highlights, err := b.bind(client).DocumentHighlight(normalizedURI, line, character)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.DebugContext(b.requestContext(), "DocumentHighlight: highlights", "count", len(highlights), "uri", normalizedURI, "line", line, "character", character)
+	return highlights, nil
 }
 
 func (b *MCPLSPBridge) SemanticTokens(uri string, targetTypes []string, startLine, startCharacter, endLine, endCharacter uint32) ([]types.TokenPosition, error) {
@@ -1458,34 +1657,34 @@ func (b *MCPLSPBridge) SemanticTokens(uri string, targetTypes []string, startLin
 	err = b.ensureDocumentOpen(client, uri, string(*language))
 	if err != nil {
 		// Continue anyway, as some servers might still work without explicit didOpen
-		logger.Error("SemanticTokens: Failed to open document", fmt.Sprintf("URI: %s, Error: %v", uri, err))
+		logger.ErrorContext(b.requestContext(), "SemanticTokens: Failed to open document", "uri", uri, "error", err)
 	}
 
-	tokens, err := client.SemanticTokensRange(uri, startLine, startCharacter, endLine, endCharacter)
+	tokens, err := b.bind(client).SemanticTokensRange(uri, startLine, startCharacter, endLine, endCharacter)
 	if err != nil {
-		logger.Error(fmt.Sprintf("SemanticTokens: Failed to get raw semantic tokens from client: %v", err))
-		serverCommand := client.GetMetrics().GetCommand()
+		logger.ErrorContext(b.requestContext(), "SemanticTokens: Failed to get raw semantic tokens from client", "uri", uri, "error", err)
+		serverCommand := b.bind(client).GetMetrics().GetCommand()
 		return nil, fmt.Errorf("semantic tokens not supported by %s language server for %s files: %w", serverCommand, *language, err)
 	}
 
 	// Handle nil tokens response (server returned null)
 	if tokens == nil {
-		logger.Debug("SemanticTokens: Server returned null/no semantic tokens for this range")
+		logger.DebugContext(b.requestContext(), "SemanticTokens: Server returned null/no semantic tokens for this range", "uri", uri)
 		return []types.TokenPosition{}, nil
 	}
 
-	logger.Debug(fmt.Sprintf("SemanticTokens: Raw tokens from LSP client: %+v", tokens))
+	logger.DebugContext(b.requestContext(), "SemanticTokens: Raw tokens from LSP client", "tokens", fmt.Sprintf("%+v", tokens))
 
-	logger.Debug("SemanticTokens: About to get token parser")
+	logger.DebugContext(b.requestContext(), "SemanticTokens: About to get token parser")
 	parser := client.TokenParser()
-	logger.Debug(fmt.Sprintf("SemanticTokens: Got token parser: %v", parser != nil))
+	logger.DebugContext(b.requestContext(), "SemanticTokens: Got token parser", "found", parser != nil)
 
 	if parser == nil {
 		// If no token parser exists but the LSP request succeeded,
 		// the server supports semantic tokens but didn't advertise capabilities properly.
 		// This is common with some language servers like gopls.
-		serverCommand := client.GetMetrics().GetCommand()
-		logger.Debug(fmt.Sprintf("SemanticTokens: %s server for %s files supports semantic tokens but didn't advertise capabilities - creating fallback parser", serverCommand, *language))
+		serverCommand := b.bind(client).GetMetrics().GetCommand()
+		logger.DebugContext(b.requestContext(), "SemanticTokens: server supports semantic tokens but didn't advertise capabilities - creating fallback parser", "server", serverCommand, "language", *language)
 
 		// Create a fallback parser with common token types
 		fallbackTokenTypes := []string{
@@ -1506,7 +1705,7 @@ func (b *MCPLSPBridge) SemanticTokens(uri string, targetTypes []string, startLin
 			return nil, errors.New("failed to create fallback token parser")
 		}
 
-		logger.Debug("SemanticTokens: Created fallback token parser successfully")
+		logger.DebugContext(b.requestContext(), "SemanticTokens: Created fallback token parser successfully")
 	}
 
 	tokenRange := protocol.Range{
@@ -1527,11 +1726,28 @@ func (b *MCPLSPBridge) SemanticTokens(uri string, targetTypes []string, startLin
 		return nil, fmt.Errorf("failed to find tokens by types (%v): %w", targetTypes, err)
 	}
 
+	// Token lengths are counted in the negotiated encoding, not in bytes
+	if text, err := b.documentText(client, uri); err == nil {
+		enc := utils.ServerPositionEncoding(client.ServerCapabilities())
+		for i := range positions {
+			if tokenText, err := utils.RangeText(text, positions[i].Range, enc); err == nil {
+				positions[i].Text = tokenText
+			}
+		}
+	} else {
+		logger.DebugContext(b.requestContext(), "SemanticTokens: no document text", "uri", uri, "error", err)
+	}
+
 	return positions, nil
 }
 
 // PrepareCallHierarchy prepares call hierarchy items
 func (b *MCPLSPBridge) PrepareCallHierarchy(uri string, line, character uint32) ([]protocol.CallHierarchyItem, error) {
+	return b.PrepareCallHierarchyContext(b.requestContext(), uri, line, character)
+}
+
+// PrepareCallHierarchyContext is PrepareCallHierarchy that cancels the LSP request together with ctx
+func (b *MCPLSPBridge) PrepareCallHierarchyContext(ctx context.Context, uri string, line, character uint32) ([]protocol.CallHierarchyItem, error) {
 	normalizedURI := b.NormalizeURIForLSP(uri)
 
 	// Infer language from URI
@@ -1549,16 +1765,16 @@ func (b *MCPLSPBridge) PrepareCallHierarchy(uri string, line, character uint32)
 	// Ensure document is open before call hierarchy queries.
 	_ = b.ensureDocumentOpen(client, normalizedURI, string(*language))
 
-	result, err := client.PrepareCallHierarchy(normalizedURI, line, character)
-	if err != nil {
-		return nil, err
-	}
-
-	return result, nil
+	return client.PrepareCallHierarchyContext(ctx, normalizedURI, line, character)
 }
 
 // IncomingCalls gets incoming calls for a call hierarchy item
 func (b *MCPLSPBridge) IncomingCalls(item protocol.CallHierarchyItem) ([]protocol.CallHierarchyIncomingCall, error) {
+	return b.IncomingCallsContext(b.requestContext(), item)
+}
+
+// IncomingCallsContext is IncomingCalls that cancels the LSP request together with ctx
+func (b *MCPLSPBridge) IncomingCallsContext(ctx context.Context, item protocol.CallHierarchyItem) ([]protocol.CallHierarchyIncomingCall, error) {
 	// Infer language from the URI in the call hierarchy item
 	language, err := b.InferLanguage(string(item.Uri))
 	if err != nil {
@@ -1571,12 +1787,16 @@ func (b *MCPLSPBridge) IncomingCalls(item protocol.CallHierarchyItem) ([]protoco
 		return nil, fmt.Errorf("failed to get language client for %s: %w", *language, err)
 	}
 
-	// Call the language client's IncomingCalls method
-	return client.IncomingCalls(item)
+	return client.IncomingCallsContext(ctx, item)
 }
 
 // OutgoingCalls gets outgoing calls for a call hierarchy item
 func (b *MCPLSPBridge) OutgoingCalls(item protocol.CallHierarchyItem) ([]protocol.CallHierarchyOutgoingCall, error) {
+	return b.OutgoingCallsContext(b.requestContext(), item)
+}
+
+// OutgoingCallsContext is OutgoingCalls that cancels the LSP request together with ctx
+func (b *MCPLSPBridge) OutgoingCallsContext(ctx context.Context, item protocol.CallHierarchyItem) ([]protocol.CallHierarchyOutgoingCall, error) {
 	// Infer language from the URI in the call hierarchy item
 	language, err := b.InferLanguage(string(item.Uri))
 	if err != nil {
@@ -1589,8 +1809,40 @@ func (b *MCPLSPBridge) OutgoingCalls(item protocol.CallHierarchyItem) ([]protoco
 		return nil, fmt.Errorf("failed to get language client for %s: %w", *language, err)
 	}
 
-	// Call the language client's OutgoingCalls method
-	return client.OutgoingCalls(item)
+	return client.OutgoingCallsContext(ctx, item)
+}
+
+// IndexedIncomingCalls answers incoming calls from the persistent call graph index when the
+// language client has one (Session Manager). indexed is false when the caller must query
+// the server, e.g. for a direct stdio client or while files are being re-indexed.
+func (b *MCPLSPBridge) IndexedIncomingCalls(ctx context.Context, item protocol.CallHierarchyItem) ([]protocol.CallHierarchyIncomingCall, bool, error) {
+	client, err := b.callHierarchyClient(item)
+	if err != nil {
+		return nil, false, err
+	}
+	return client.IndexedIncomingCalls(ctx, item)
+}
+
+// IndexedOutgoingCalls is IndexedIncomingCalls for outgoing calls
+func (b *MCPLSPBridge) IndexedOutgoingCalls(ctx context.Context, item protocol.CallHierarchyItem) ([]protocol.CallHierarchyOutgoingCall, bool, error) {
+	client, err := b.callHierarchyClient(item)
+	if err != nil {
+		return nil, false, err
+	}
+	return client.IndexedOutgoingCalls(ctx, item)
+}
+
+// callHierarchyClient returns the language client responsible for a call hierarchy item
+func (b *MCPLSPBridge) callHierarchyClient(item protocol.CallHierarchyItem) (types.LanguageClientInterface, error) {
+	language, err := b.InferLanguage(string(item.Uri))
+	if err != nil {
+		return nil, fmt.Errorf("failed to infer language from URI %s: %w", item.Uri, err)
+	}
+	client, err := b.GetClientForLanguage(string(*language))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get language client for %s: %w", *language, err)
+	}
+	return client, nil
 }
 
 // GetWorkspaceDiagnostics gets diagnostics for entire workspace
@@ -1622,9 +1874,9 @@ func (b *MCPLSPBridge) GetWorkspaceDiagnostics(workspaceUri string, identifier s
 	for language, clientInterface := range clients {
 		client := clientInterface
 
-		report, err := client.WorkspaceDiagnostic(identifier)
+		report, err := b.bind(client).WorkspaceDiagnostic(identifier)
 		if err != nil {
-			logger.Warn(fmt.Sprintf("Workspace diagnostics failed for %s: %v", language, err))
+			logger.WarnContext(b.requestContext(), "Workspace diagnostics failed", "language", language, "error", err)
 			continue
 		}
 
@@ -1655,11 +1907,11 @@ func (b *MCPLSPBridge) GetDocumentDiagnostics(uri string, identifier string, pre
 	err = b.ensureDocumentOpen(client, normalizedURI, string(*language))
 	if err != nil {
 		// Continue anyway, as some servers might still work without explicit didOpen
-		logger.Warn(fmt.Sprintf("GetDocumentDiagnostics: Failed to open document %s: %v", normalizedURI, err))
+		logger.WarnContext(b.requestContext(), "GetDocumentDiagnostics: Failed to open document", "uri", normalizedURI, "error", err)
 	}
 
 	// Request document diagnostics
-	report, err := client.DocumentDiagnostics(normalizedURI, identifier, previousResultId)
+	report, err := b.bind(client).DocumentDiagnostics(normalizedURI, identifier, previousResultId)
 	if err != nil {
 		return nil, fmt.Errorf("document diagnostics request failed: %w", err)
 	}
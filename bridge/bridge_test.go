@@ -419,7 +419,7 @@ func TestGetSignatureHelp(t *testing.T) {
 		ActiveParameter: &activeParameter,
 	}
 
-	mockClient.On("SendNotification", "textDocument/didOpen", mock.AnythingOfType("protocol.DidOpenTextDocumentParams")).Return(nil)
+	mockClient.On("DidOpen", mock.Anything, mock.Anything, mock.Anything, int32(1)).Return(int32(1), nil)
 	mockClient.On("SignatureHelp", testURI, uint32(2), uint32(10)).Return(expectedSigHelp, nil)
 
 	result, err := bridge.GetSignatureHelp(testFile, 2, 10)
@@ -454,7 +454,7 @@ func TestGetHoverInformation(t *testing.T) {
 		},
 	}
 
-	mockClient.On("SendNotification", "textDocument/didOpen", mock.AnythingOfType("protocol.DidOpenTextDocumentParams")).Return(nil)
+	mockClient.On("DidOpen", mock.Anything, mock.Anything, mock.Anything, int32(1)).Return(int32(1), nil)
 
 	// Mock expects the URI format that the bridge code actually uses
 	mockClient.On("Hover", testURI, uint32(2), uint32(7)).Return(expectedHover, nil)
@@ -479,7 +479,7 @@ func TestApplyTextEditsToContent(t *testing.T) {
 		},
 	}
 
-	result, err := applyTextEditsToContent(content, edits)
+	result, err := applyTextEditsToContent(content, edits, protocol.PositionEncodingKindUTF16)
 
 	require.NoError(t, err)
 
@@ -501,7 +501,7 @@ func TestApplyTextEditsToContentMultiLine(t *testing.T) {
 		},
 	}
 
-	result, err := applyTextEditsToContent(content, edits)
+	result, err := applyTextEditsToContent(content, edits, protocol.PositionEncodingKindUTF16)
 
 	require.NoError(t, err)
 
@@ -509,6 +509,63 @@ func TestApplyTextEditsToContentMultiLine(t *testing.T) {
 	assert.Equal(t, expected, result)
 }
 
+func TestApplyTextEditsToContentPositionEncodings(t *testing.T) {
+	content := "Процедура Тест😀()\r\n\tСтарое = 1;\r\nКонецПроцедуры"
+	rename := func(enc protocol.PositionEncodingKind, start, end uint32) []protocol.TextEdit {
+		return []protocol.TextEdit{{
+			Range: protocol.Range{
+				Start: protocol.Position{Line: 1, Character: start},
+				End:   protocol.Position{Line: 1, Character: end},
+			},
+			NewText: "Новое",
+		}, {
+			Range: protocol.Range{
+				Start: protocol.Position{Line: 0, Character: 0},
+				End:   protocol.Position{Line: 0, Character: 0},
+			},
+			NewText: "// " + string(enc) + "\r\n",
+		}}
+	}
+
+	for _, tc := range []struct {
+		enc        protocol.PositionEncodingKind
+		start, end uint32
+	}{
+		{protocol.PositionEncodingKindUTF16, 1, 7},
+		{protocol.PositionEncodingKindUTF32, 1, 7},
+		{protocol.PositionEncodingKindUTF8, 1, 13},
+	} {
+		t.Run(string(tc.enc), func(t *testing.T) {
+			result, err := applyTextEditsToContent(content, rename(tc.enc, tc.start, tc.end), tc.enc)
+			require.NoError(t, err)
+			assert.Equal(t, "// "+string(tc.enc)+"\r\nПроцедура Тест😀()\r\n\tНовое = 1;\r\nКонецПроцедуры", result)
+		})
+	}
+
+	t.Run("invalid edits are reported, not skipped", func(t *testing.T) {
+		edits := []protocol.TextEdit{
+			{Range: protocol.Range{Start: protocol.Position{Line: 0, Character: 15}, End: protocol.Position{Line: 0, Character: 15}}, NewText: "x"},
+			{Range: protocol.Range{Start: protocol.Position{Line: 7, Character: 0}, End: protocol.Position{Line: 7, Character: 1}}, NewText: "y"},
+			{Range: protocol.Range{Start: protocol.Position{Line: 1, Character: 1}, End: protocol.Position{Line: 1, Character: 3}}, NewText: "z"},
+		}
+		_, err := applyTextEditsToContent(content, edits, protocol.PositionEncodingKindUTF16)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "edit 0 (0:15-0:15)")
+		assert.Contains(t, err.Error(), "splits the code point")
+		assert.Contains(t, err.Error(), "edit 1 (7:0-7:1)")
+		assert.NotContains(t, err.Error(), "edit 2")
+	})
+
+	t.Run("overlapping edits are rejected", func(t *testing.T) {
+		edits := []protocol.TextEdit{
+			{Range: protocol.Range{Start: protocol.Position{Line: 1, Character: 1}, End: protocol.Position{Line: 1, Character: 5}}, NewText: "a"},
+			{Range: protocol.Range{Start: protocol.Position{Line: 1, Character: 3}, End: protocol.Position{Line: 1, Character: 7}}, NewText: "b"},
+		}
+		_, err := applyTextEditsToContent(content, edits, protocol.PositionEncodingKindUTF16)
+		require.EqualError(t, err, "edits 0 and 1 overlap")
+	})
+}
+
 // Test RenameSymbol
 func TestRenameSymbol(t *testing.T) {
 	t.Run("successful rename", func(t *testing.T) {
@@ -537,7 +594,7 @@ func TestRenameSymbol(t *testing.T) {
 			},
 		}
 
-		mockClient.On("SendNotification", "textDocument/didOpen", mock.AnythingOfType("protocol.DidOpenTextDocumentParams")).Return(nil)
+		mockClient.On("DidOpen", mock.Anything, mock.Anything, mock.Anything, int32(1)).Return(int32(1), nil)
 		mockClient.On("Rename", testURI, uint32(2), uint32(7), "newMain").Return(&expectedWorkspaceEdit, nil)
 
 		result, err := bridge.RenameSymbol(testFile, 2, 7, "newMain", false)
@@ -599,7 +656,7 @@ func TestRenameSymbol(t *testing.T) {
 		}
 
 		// Simulate didOpen failure
-		mockClient.On("SendNotification", "textDocument/didOpen", mock.AnythingOfType("protocol.DidOpenTextDocumentParams")).Return(errors.New("failed to open document"))
+		mockClient.On("DidOpen", mock.Anything, mock.Anything, mock.Anything, int32(1)).Return(int32(0), errors.New("failed to open document"))
 		// But rename should still succeed
 		mockClient.On("Rename", testURI, uint32(2), uint32(7), "newMain").Return(&expectedWorkspaceEdit, nil)
 
@@ -623,7 +680,7 @@ func TestRenameSymbol(t *testing.T) {
 		mockClient.On("ProjectRoots").Return([]string{filepath.Dir(testFile)})
 		testURI := utils.NormalizeURI(testFile)
 
-		mockClient.On("SendNotification", "textDocument/didOpen", mock.AnythingOfType("protocol.DidOpenTextDocumentParams")).Return(nil)
+		mockClient.On("DidOpen", mock.Anything, mock.Anything, mock.Anything, int32(1)).Return(int32(1), nil)
 		// Simulate rename failure
 		mockClient.On("Rename", testURI, uint32(2), uint32(7), "newMain").Return((*protocol.WorkspaceEdit)(nil), errors.New("symbol not found"))
 
@@ -648,7 +705,7 @@ func TestRenameSymbol(t *testing.T) {
 		mockClient.On("ProjectRoots").Return([]string{filepath.Dir(testFile)})
 		testURI := utils.NormalizeURI(testFile)
 
-		mockClient.On("SendNotification", "textDocument/didOpen", mock.AnythingOfType("protocol.DidOpenTextDocumentParams")).Return(nil)
+		mockClient.On("DidOpen", mock.Anything, mock.Anything, mock.Anything, int32(1)).Return(int32(1), nil)
 		// Return nil result but no error (valid scenario)
 		mockClient.On("Rename", testURI, uint32(2), uint32(7), "newMain").Return((*protocol.WorkspaceEdit)(nil), nil)
 
@@ -697,7 +754,8 @@ func TestRenameSymbol(t *testing.T) {
 				mockClient.On("Context").Return(ctx)
 				mockClient.On("GetMetrics").Return(&lsp.ClientMetrics{Status: 3, Connected: true})
 				mockClient.On("ProjectRoots").Return([]string{filepath.Dir(testFile)})
-				mockClient.On("SendNotification", "textDocument/didOpen", mock.AnythingOfType("protocol.DidOpenTextDocumentParams")).Return(nil)
+				// Only the first URI variant opens the document, the rest map to the same open document
+				mockClient.On("DidOpen", mock.Anything, mock.Anything, mock.Anything, int32(1)).Return(int32(1), nil).Maybe()
 				mockClient.On("Rename", normalized, uint32(2), uint32(7), "newMain").Return(&expectedWorkspaceEdit, nil)
 
 				result, err := bridge.RenameSymbol(uriInput, 2, 7, "newMain", false)
@@ -764,7 +822,7 @@ func TestRenameSymbol(t *testing.T) {
 						},
 					}
 
-					mockClient.On("SendNotification", "textDocument/didOpen", mock.AnythingOfType("protocol.DidOpenTextDocumentParams")).Return(nil)
+					mockClient.On("DidOpen", mock.Anything, mock.Anything, mock.Anything, int32(1)).Return(int32(1), nil)
 					mockClient.On("Rename", testURI, uint32(0), uint32(0), "newName").Return(&expectedWorkspaceEdit, nil)
 
 					result, err := bridge.RenameSymbol(testFile, 0, 0, "newName", false)
@@ -811,7 +869,7 @@ func TestFindImplementations(t *testing.T) {
 		},
 	}
 
-	mockClient.On("SendNotification", "textDocument/didOpen", mock.AnythingOfType("protocol.DidOpenTextDocumentParams")).Return(nil)
+	mockClient.On("DidOpen", mock.Anything, mock.Anything, mock.Anything, int32(1)).Return(int32(1), nil)
 	mockClient.On("Implementation", testURI, uint32(2), uint32(7)).Return(expectedImpls, nil)
 
 	result, err := bridge.FindImplementations(testFile, 2, 7)
@@ -832,7 +890,7 @@ func TestPrepareCallHierarchy(t *testing.T) {
 	testFile := createTempFile(t, "test.go", "package main\n\nfunc main() {}")
 	testURI := utils.NormalizeURI(testFile)
 	mockClient.On("ProjectRoots").Return([]string{filepath.Dir(testFile)})
-	mockClient.On("SendNotification", "textDocument/didOpen", mock.AnythingOfType("protocol.DidOpenTextDocumentParams")).Return(nil)
+	mockClient.On("DidOpen", mock.Anything, mock.Anything, mock.Anything, int32(1)).Return(int32(1), nil)
 	expectedItems := []protocol.CallHierarchyItem{
 		{
 			Name: "main",
@@ -1999,3 +2057,41 @@ func TestDetectPrimaryProjectLanguage(t *testing.T) {
 		})
 	}
 }
+
+func TestResolveCompletionItemNeedsResolveProvider(t *testing.T) {
+	item := protocol.CompletionItem{Label: "Номенклатура"}
+	resolved := protocol.CompletionItem{Label: "Номенклатура", Detail: "СправочникМенеджер.Номенклатура"}
+
+	tests := []struct {
+		name         string
+		capabilities protocol.ServerCapabilities
+		want         string
+	}{
+		{name: "capabilities unknown", capabilities: protocol.ServerCapabilities{}},
+		{name: "no resolve provider", capabilities: protocol.ServerCapabilities{CompletionProvider: &protocol.CompletionOptions{}}},
+		{
+			name:         "resolve provider",
+			capabilities: protocol.ServerCapabilities{CompletionProvider: &protocol.CompletionOptions{ResolveProvider: true}},
+			want:         resolved.Detail,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bridge := createTestBridge([]string{"/"})
+			mockClient := &mocks.MockLanguageClient{}
+			mockClient.On("Context").Return(context.Background()).Maybe()
+			mockClient.On("GetMetrics").Return(&lsp.ClientMetrics{Status: 3, Connected: true}).Maybe()
+			mockClient.On("ServerCapabilities").Return(tt.capabilities)
+			mockClient.On("ResolveCompletionItem", item).Return(&resolved, nil).Maybe()
+			bridge.clients["gopls"] = mockClient
+
+			got, err := bridge.ResolveCompletionItem("file:///projects/main.go", item)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got.Detail)
+			if tt.want == "" {
+				mockClient.AssertNotCalled(t, "ResolveCompletionItem", mock.Anything)
+			}
+		})
+	}
+}
@@ -0,0 +1,367 @@
+package bridge
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"unicode/utf8"
+
+	"rockerboo/mcp-lsp-bridge/logger"
+	"rockerboo/mcp-lsp-bridge/types"
+	"rockerboo/mcp-lsp-bridge/utils"
+
+	"github.com/myleshyson/lsprotocol-go/protocol"
+)
+
+// trackedDocument is a document the bridge has opened on a language server
+type trackedDocument struct {
+	client   types.LanguageClientInterface
+	language string
+	version  int32
+	text     string
+	overlay  bool // content was pushed with UpdateBuffer and may differ from disk
+}
+
+// documentStore remembers what each language server has been told about a document,
+// so didOpen is sent once per client and later edits go out as versioned didChange.
+type documentStore struct {
+	mu    sync.Mutex
+	docs  map[string]*trackedDocument // keyed by server URI
+	locks map[string]*sync.Mutex      // per URI, held while its notifications are in flight
+}
+
+func newDocumentStore() *documentStore {
+	return &documentStore{
+		docs:  make(map[string]*trackedDocument),
+		locks: make(map[string]*sync.Mutex),
+	}
+}
+
+// lock serializes the notifications for one URI without holding up other documents
+func (s *documentStore) lock(uri string) func() {
+	s.mu.Lock()
+	l, ok := s.locks[uri]
+	if !ok {
+		l = &sync.Mutex{}
+		s.locks[uri] = l
+	}
+	s.mu.Unlock()
+
+	l.Lock()
+	return l.Unlock
+}
+
+// hasOverlay reports whether the client currently sees agent-supplied content for the URI
+func (s *documentStore) hasOverlay(client types.LanguageClientInterface, uri string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	doc, ok := s.docs[uri]
+	return ok && doc.client == client && doc.overlay
+}
+
+// snapshot returns a copy of the tracked state of the URI
+func (s *documentStore) snapshot(uri string) (trackedDocument, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	doc, ok := s.docs[uri]
+	if !ok {
+		return trackedDocument{}, false
+	}
+	return *doc, true
+}
+
+// put records the state of the URI
+func (s *documentStore) put(uri string, doc trackedDocument) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.docs[uri] = &doc
+}
+
+// forget drops the state of the URI
+func (s *documentStore) forget(uri string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.docs, uri)
+}
+
+// bindClient returns client with its requests started from ctx, or client itself
+// when ctx is nil. The store keys documents by the unbound client.
+func bindClient(ctx context.Context, client types.LanguageClientInterface) types.LanguageClientInterface {
+	if ctx == nil {
+		return client
+	}
+	return client.WithContext(ctx)
+}
+
+// sync makes the client see text for the URI and returns the document version.
+// Unknown documents (or documents opened on a different client) get a didOpen,
+// known ones get a didChange only when the text differs from the last one sent.
+// The version is the one the server reports, which a session shared with other
+// bridge processes may have moved past the one sent.
+func (s *documentStore) sync(ctx context.Context, client types.LanguageClientInterface, uri, language, text string, overlay bool) (int32, error) {
+	unlock := s.lock(uri)
+	defer unlock()
+
+	doc, ok := s.snapshot(uri)
+	if !ok || doc.client != client {
+		version, err := bindClient(ctx, client).DidOpen(uri, protocol.LanguageKind(language), text, 1)
+		if err != nil {
+			s.forget(uri)
+			return 0, fmt.Errorf("failed to send didOpen notification: %w", err)
+		}
+
+		s.put(uri, trackedDocument{
+			client:   client,
+			language: language,
+			version:  version,
+			text:     text,
+			overlay:  overlay,
+		})
+		return version, nil
+	}
+
+	doc.overlay = overlay
+	if doc.text == text {
+		s.put(uri, doc)
+		return doc.version, nil
+	}
+
+	caps := client.ServerCapabilities()
+	changes := contentChanges(doc.text, text, textDocumentSyncKind(caps), utils.ServerPositionEncoding(caps))
+	version, err := bindClient(ctx, client).DidChange(uri, doc.version+1, changes)
+	if err != nil {
+		// The server state is unknown now, reopen the document on next use
+		s.forget(uri)
+		return 0, fmt.Errorf("failed to send didChange notification: %w", err)
+	}
+
+	doc.version = version
+	doc.text = text
+	s.put(uri, doc)
+	return version, nil
+}
+
+// saved brings an open document in line with content just written to disk and
+// sends textDocument/didSave. Documents that were never opened are ignored.
+func (s *documentStore) saved(ctx context.Context, uri, text string) error {
+	doc, ok := s.snapshot(uri)
+	if !ok {
+		return nil
+	}
+
+	if _, err := s.sync(ctx, doc.client, uri, doc.language, text, false); err != nil {
+		return err
+	}
+
+	unlock := s.lock(uri)
+	defer unlock()
+	if err := bindClient(ctx, doc.client).DidSave(uri, nil); err != nil {
+		return fmt.Errorf("failed to send didSave notification: %w", err)
+	}
+	return nil
+}
+
+// closed sends textDocument/didClose for a document that no longer exists on disk
+func (s *documentStore) closed(ctx context.Context, uri string) error {
+	unlock := s.lock(uri)
+	defer unlock()
+
+	doc, ok := s.snapshot(uri)
+	s.forget(uri)
+	if !ok {
+		return nil
+	}
+	return bindClient(ctx, doc.client).DidClose(uri)
+}
+
+// notifyDocumentSaved tells the language server about a file the bridge has just written
+func (b *MCPLSPBridge) notifyDocumentSaved(filePath, content string) {
+	serverURI := utils.NormalizeURI(filePath)
+	if err := b.documents.saved(b.ctx, serverURI, content); err != nil {
+		logger.WarnContext(b.requestContext(), "Failed to sync saved document", "uri", serverURI, "error", err)
+	}
+}
+
+// UpdateBuffer replaces the language server's view of a document with text without
+// touching the file on disk. Subsequent hover, definition, references and diagnostics
+// requests see the overlay until RevertBuffer is called or the file is written.
+func (b *MCPLSPBridge) UpdateBuffer(uri, text string) (int32, error) {
+	client, absPath, language, err := b.documentTarget(uri)
+	if err != nil {
+		return 0, err
+	}
+	serverURI := utils.NormalizeURI(absPath)
+
+	version, err := b.documents.sync(b.ctx, client, serverURI, language, text, true)
+	if err != nil {
+		return 0, fmt.Errorf("failed to update buffer for %s: %w", uri, err)
+	}
+
+	logger.DebugContext(b.requestContext(), "UpdateBuffer: buffer updated", "uri", serverURI, "version", version)
+	return version, nil
+}
+
+// RevertBuffer drops the in-memory overlay for a document and resyncs it from disk
+func (b *MCPLSPBridge) RevertBuffer(uri string) (int32, error) {
+	client, absPath, language, err := b.documentTarget(uri)
+	if err != nil {
+		return 0, err
+	}
+	serverURI := utils.NormalizeURI(absPath)
+
+	content, err := os.ReadFile(absPath) // #nosec G304 - validated against project roots
+	if err != nil {
+		return 0, fmt.Errorf("failed to read file %s: %w", absPath, err)
+	}
+
+	version, err := b.documents.sync(b.ctx, client, serverURI, language, string(content), false)
+	if err != nil {
+		return 0, fmt.Errorf("failed to revert buffer for %s: %w", uri, err)
+	}
+
+	logger.DebugContext(b.requestContext(), "RevertBuffer: resynced from disk", "uri", serverURI, "version", version)
+	return version, nil
+}
+
+// CloseDocument sends textDocument/didClose for a document the bridge opened, so
+// temporary files do not stay open on the server. Unknown documents are ignored.
+func (b *MCPLSPBridge) CloseDocument(uri string) error {
+	_, absPath, _, err := b.documentTarget(uri)
+	if err != nil {
+		return err
+	}
+	return b.documents.closed(b.ctx, utils.NormalizeURI(absPath))
+}
+
+// BufferOverlay returns the content pushed with UpdateBuffer that the language server
+// sees for uri instead of the file on disk; ok is false when there is no overlay
+func (b *MCPLSPBridge) BufferOverlay(uri string) (text string, ok bool) {
+	client, absPath, _, err := b.documentTarget(uri)
+	if err != nil {
+		return "", false
+	}
+	doc, open := b.documents.snapshot(utils.NormalizeURI(absPath))
+	if !open || doc.client != client || !doc.overlay {
+		return "", false
+	}
+	return doc.text, true
+}
+
+// documentText returns the text the client has for uri: the last text synced to it,
+// or the file on disk for documents it has not been sent
+func (b *MCPLSPBridge) documentText(client types.LanguageClientInterface, uri string) (string, error) {
+	absPath, err := b.resolveDocumentPath(client, uri)
+	if err != nil {
+		return "", err
+	}
+	if doc, ok := b.documents.snapshot(utils.NormalizeURI(absPath)); ok && doc.client == client {
+		return doc.text, nil
+	}
+	content, err := os.ReadFile(absPath) // #nosec G304 - validated against project roots
+	if err != nil {
+		return "", fmt.Errorf("failed to read file %s: %w", absPath, err)
+	}
+	return string(content), nil
+}
+
+// documentTarget resolves the client, server-side path and language for a document operation
+func (b *MCPLSPBridge) documentTarget(uri string) (types.LanguageClientInterface, string, string, error) {
+	normalizedURI := b.NormalizeURIForLSP(uri)
+
+	language, err := b.InferLanguage(uri)
+	if err != nil {
+		return nil, "", "", fmt.Errorf("failed to infer language: %w", err)
+	}
+
+	client, err := b.GetClientForLanguage(string(*language))
+	if err != nil {
+		return nil, "", "", fmt.Errorf("failed to get client for language %s: %w", string(*language), err)
+	}
+
+	absPath, err := b.resolveDocumentPath(client, normalizedURI)
+	if err != nil {
+		return nil, "", "", err
+	}
+
+	return client, absPath, string(*language), nil
+}
+
+// textDocumentSyncKind returns how the server wants to receive document changes.
+// Servers that do not say are sent the full text, which every server accepts.
+func textDocumentSyncKind(caps protocol.ServerCapabilities) protocol.TextDocumentSyncKind {
+	if caps.TextDocumentSync == nil {
+		return protocol.TextDocumentSyncKindFull
+	}
+
+	switch v := caps.TextDocumentSync.Value.(type) {
+	case protocol.TextDocumentSyncKind:
+		if v == protocol.TextDocumentSyncKindIncremental {
+			return v
+		}
+	case protocol.TextDocumentSyncOptions:
+		if v.Change != nil && *v.Change == protocol.TextDocumentSyncKindIncremental {
+			return *v.Change
+		}
+	}
+	return protocol.TextDocumentSyncKindFull
+}
+
+// contentChanges builds the didChange payload turning oldText into newText.
+// For incremental sync a single range covering everything between the common
+// prefix and suffix is replaced, with positions counted in enc.
+func contentChanges(oldText, newText string, kind protocol.TextDocumentSyncKind, enc protocol.PositionEncodingKind) []protocol.TextDocumentContentChangeEvent {
+	if kind != protocol.TextDocumentSyncKindIncremental {
+		return []protocol.TextDocumentContentChangeEvent{
+			{Value: protocol.TextDocumentContentChangeWholeDocument{Text: newText}},
+		}
+	}
+
+	prefix := commonPrefixLen(oldText, newText)
+	suffix := commonSuffixLen(oldText[prefix:], newText[prefix:])
+
+	// Never split a rune or a CRLF pair, positions inside them are not addressable
+	for prefix > 0 && prefix < len(oldText) && (!utf8.RuneStart(oldText[prefix]) || (oldText[prefix-1] == '\r' && oldText[prefix] == '\n')) {
+		prefix--
+	}
+	for suffix > 0 {
+		start := len(oldText) - suffix
+		if utf8.RuneStart(oldText[start]) && !(start > 0 && oldText[start-1] == '\r' && oldText[start] == '\n') {
+			break
+		}
+		suffix--
+	}
+
+	oldEnd := len(oldText) - suffix
+	newEnd := len(newText) - suffix
+
+	return []protocol.TextDocumentContentChangeEvent{
+		{Value: protocol.TextDocumentContentChangePartial{
+			Range: protocol.Range{
+				Start: utils.OffsetPosition(oldText, prefix, enc),
+				End:   utils.OffsetPosition(oldText, oldEnd, enc),
+			},
+			Text: newText[prefix:newEnd],
+		}},
+	}
+}
+
+func commonPrefixLen(a, b string) int {
+	n := min(len(a), len(b))
+	i := 0
+	for i < n && a[i] == b[i] {
+		i++
+	}
+	return i
+}
+
+func commonSuffixLen(a, b string) int {
+	n := min(len(a), len(b))
+	i := 0
+	for i < n && a[len(a)-1-i] == b[len(b)-1-i] {
+		i++
+	}
+	return i
+}
@@ -0,0 +1,258 @@
+package bridge
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"rockerboo/mcp-lsp-bridge/lsp"
+	"rockerboo/mcp-lsp-bridge/mocks"
+	"rockerboo/mcp-lsp-bridge/utils"
+
+	"github.com/myleshyson/lsprotocol-go/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func incrementalCapabilities() protocol.ServerCapabilities {
+	return protocol.ServerCapabilities{
+		TextDocumentSync: &protocol.Or2[protocol.TextDocumentSyncOptions, protocol.TextDocumentSyncKind]{
+			Value: protocol.TextDocumentSyncKindIncremental,
+		},
+	}
+}
+
+func newDocumentTestClient(t *testing.T, dir string) *mocks.MockLanguageClient {
+	t.Helper()
+
+	mockClient := &mocks.MockLanguageClient{}
+	mockClient.On("Context").Return(context.Background()).Maybe()
+	mockClient.On("GetMetrics").Return(&lsp.ClientMetrics{Status: 3, Connected: true}).Maybe()
+	mockClient.On("ProjectRoots").Return([]string{dir})
+	mockClient.On("ServerCapabilities").Return(incrementalCapabilities())
+	return mockClient
+}
+
+func TestContentChanges(t *testing.T) {
+	testCases := []struct {
+		name     string
+		oldText  string
+		newText  string
+		expected protocol.TextDocumentContentChangePartial
+	}{
+		{
+			name:    "cyrillic identifier replaced",
+			oldText: "Процедура А()\nКонецПроцедуры",
+			newText: "Процедура Б()\nКонецПроцедуры",
+			expected: protocol.TextDocumentContentChangePartial{
+				Range: protocol.Range{
+					Start: protocol.Position{Line: 0, Character: 10},
+					End:   protocol.Position{Line: 0, Character: 11},
+				},
+				Text: "Б",
+			},
+		},
+		{
+			name:    "characters after surrogate pair counted in UTF-16",
+			oldText: "a😀b",
+			newText: "a😀c",
+			expected: protocol.TextDocumentContentChangePartial{
+				Range: protocol.Range{
+					Start: protocol.Position{Line: 0, Character: 3},
+					End:   protocol.Position{Line: 0, Character: 4},
+				},
+				Text: "c",
+			},
+		},
+		{
+			name:    "CRLF pair is not split",
+			oldText: "a\r\nb",
+			newText: "a\nb",
+			expected: protocol.TextDocumentContentChangePartial{
+				Range: protocol.Range{
+					Start: protocol.Position{Line: 0, Character: 1},
+					End:   protocol.Position{Line: 1, Character: 0},
+				},
+				Text: "\n",
+			},
+		},
+		{
+			name:    "line inserted",
+			oldText: "one\nthree\n",
+			newText: "one\ntwo\nthree\n",
+			expected: protocol.TextDocumentContentChangePartial{
+				Range: protocol.Range{
+					Start: protocol.Position{Line: 1, Character: 1},
+					End:   protocol.Position{Line: 1, Character: 1},
+				},
+				Text: "wo\nt",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			changes := contentChanges(tc.oldText, tc.newText, protocol.TextDocumentSyncKindIncremental, protocol.PositionEncodingKindUTF16)
+			require.Len(t, changes, 1)
+			assert.Equal(t, tc.expected, changes[0].Value)
+		})
+	}
+
+	t.Run("positions follow the negotiated encoding", func(t *testing.T) {
+		changes := contentChanges("Перем а😀б;", "Перем а😀в;", protocol.TextDocumentSyncKindIncremental, protocol.PositionEncodingKindUTF8)
+		require.Len(t, changes, 1)
+		assert.Equal(t, protocol.Position{Line: 0, Character: 17}, changes[0].Value.(protocol.TextDocumentContentChangePartial).Range.Start)
+
+		changes = contentChanges("Перем а😀б;", "Перем а😀в;", protocol.TextDocumentSyncKindIncremental, protocol.PositionEncodingKindUTF32)
+		assert.Equal(t, protocol.Position{Line: 0, Character: 8}, changes[0].Value.(protocol.TextDocumentContentChangePartial).Range.Start)
+	})
+
+	t.Run("full sync sends whole document", func(t *testing.T) {
+		changes := contentChanges("old", "new", protocol.TextDocumentSyncKindFull, protocol.PositionEncodingKindUTF16)
+		require.Len(t, changes, 1)
+		assert.Equal(t, protocol.TextDocumentContentChangeWholeDocument{Text: "new"}, changes[0].Value)
+	})
+}
+
+func TestEnsureDocumentOpenSendsDidChangeForModifiedFile(t *testing.T) {
+	bridge := createTestBridge([]string{"/"})
+	testFile := createTempFile(t, "test.go", "package main\n")
+	mockClient := newDocumentTestClient(t, filepath.Dir(testFile))
+	serverURI := utils.NormalizeURI(testFile)
+
+	mockClient.On("DidOpen", mock.Anything, mock.Anything, mock.Anything, int32(1)).Return(int32(1), nil).Once()
+	mockClient.On("DidChange", serverURI, int32(2), mock.Anything).Return(int32(2), nil).Once()
+
+	require.NoError(t, bridge.ensureDocumentOpen(mockClient, serverURI, "go"))
+	// Unchanged content is not sent again
+	require.NoError(t, bridge.ensureDocumentOpen(mockClient, serverURI, "go"))
+
+	require.NoError(t, os.WriteFile(testFile, []byte("package main\n\nfunc main() {}\n"), 0600))
+	require.NoError(t, bridge.ensureDocumentOpen(mockClient, serverURI, "go"))
+
+	mockClient.AssertExpectations(t)
+	mockClient.AssertNumberOfCalls(t, "DidOpen", 1)
+}
+
+func TestUpdateBufferOverlaysDiskContent(t *testing.T) {
+	bridge := createTestBridge([]string{"/"})
+	testFile := createTempFile(t, "test.go", "package main\n")
+	mockClient := newDocumentTestClient(t, filepath.Dir(testFile))
+	bridge.clients["gopls"] = mockClient
+	serverURI := utils.NormalizeURI(testFile)
+
+	mockClient.On("DidOpen", mock.Anything, mock.Anything, mock.Anything, int32(1)).Return(int32(1), nil).Once()
+	mockClient.On("DidChange", serverURI, int32(2), mock.Anything).Return(int32(2), nil).Once()
+
+	version, err := bridge.UpdateBuffer(testFile, "package main\n")
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), version)
+
+	version, err = bridge.UpdateBuffer(testFile, "package draft\n")
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), version)
+	overlay, ok := bridge.BufferOverlay(testFile)
+	assert.True(t, ok)
+	assert.Equal(t, "package draft\n", overlay)
+
+	// The overlay wins over the file on disk
+	require.NoError(t, bridge.ensureDocumentOpen(mockClient, serverURI, "go"))
+
+	mockClient.On("DidChange", serverURI, int32(3), []protocol.TextDocumentContentChangeEvent{
+		{Value: protocol.TextDocumentContentChangePartial{
+			Range: protocol.Range{
+				Start: protocol.Position{Line: 0, Character: 8},
+				End:   protocol.Position{Line: 0, Character: 13},
+			},
+			Text: "main",
+		}},
+	}).Return(int32(3), nil).Once()
+
+	version, err = bridge.RevertBuffer(testFile)
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), version)
+	assert.False(t, bridge.documents.hasOverlay(mockClient, serverURI))
+	_, ok = bridge.BufferOverlay(testFile)
+	assert.False(t, ok)
+
+	mockClient.AssertExpectations(t)
+}
+
+func TestApplyTextEditsNotifiesOpenDocument(t *testing.T) {
+	testFile := createTempFile(t, "test.go", "package main\n")
+	bridge := createTestBridge([]string{filepath.Dir(testFile)})
+	mockClient := newDocumentTestClient(t, filepath.Dir(testFile))
+	serverURI := utils.NormalizeURI(testFile)
+
+	mockClient.On("DidOpen", mock.Anything, mock.Anything, mock.Anything, int32(1)).Return(int32(1), nil).Once()
+	mockClient.On("DidChange", serverURI, int32(2), mock.Anything).Return(int32(2), nil).Once()
+	mockClient.On("DidSave", serverURI, (*string)(nil)).Return(nil).Once()
+
+	require.NoError(t, bridge.ensureDocumentOpen(mockClient, serverURI, "go"))
+
+	err := bridge.ApplyTextEdits(serverURI, []protocol.TextEdit{
+		{
+			Range: protocol.Range{
+				Start: protocol.Position{Line: 0, Character: 8},
+				End:   protocol.Position{Line: 0, Character: 12},
+			},
+			NewText: "lib",
+		},
+	})
+	require.NoError(t, err)
+
+	mockClient.AssertExpectations(t)
+}
+
+func TestDocumentSyncUsesServerVersions(t *testing.T) {
+	mockClient := &mocks.MockLanguageClient{}
+	mockClient.On("ServerCapabilities").Return(incrementalCapabilities()).Maybe()
+	uri := "file:///projects/Module.bsl"
+	store := newDocumentStore()
+
+	// A shared session had the document open already and numbers it on
+	mockClient.On("DidOpen", uri, protocol.LanguageKind("bsl"), "А = 1;", int32(1)).Return(int32(4), nil).Once()
+	mockClient.On("DidChange", uri, int32(5), mock.Anything).Return(int32(7), nil).Once()
+
+	version, err := store.sync(context.Background(), mockClient, uri, "bsl", "А = 1;", false)
+	require.NoError(t, err)
+	assert.Equal(t, int32(4), version)
+
+	version, err = store.sync(context.Background(), mockClient, uri, "bsl", "А = 2;", false)
+	require.NoError(t, err)
+	assert.Equal(t, int32(7), version)
+
+	doc, ok := store.snapshot(uri)
+	require.True(t, ok)
+	assert.Equal(t, int32(7), doc.version)
+	mockClient.AssertExpectations(t)
+}
+
+func TestDocumentSyncDoesNotBlockOtherDocuments(t *testing.T) {
+	mockClient := &mocks.MockLanguageClient{}
+	mockClient.On("ServerCapabilities").Return(incrementalCapabilities()).Maybe()
+	slowURI, fastURI := "file:///projects/Slow.bsl", "file:///projects/Fast.bsl"
+	store := newDocumentStore()
+
+	release := make(chan struct{})
+	mockClient.On("DidOpen", slowURI, mock.Anything, mock.Anything, int32(1)).
+		Run(func(mock.Arguments) { <-release }).Return(int32(1), nil).Once()
+	mockClient.On("DidOpen", fastURI, mock.Anything, mock.Anything, int32(1)).Return(int32(1), nil).Once()
+
+	slowDone := make(chan error, 1)
+	go func() {
+		_, err := store.sync(context.Background(), mockClient, slowURI, "bsl", "slow", false)
+		slowDone <- err
+	}()
+	require.Eventually(t, func() bool {
+		_, err := store.sync(context.Background(), mockClient, fastURI, "bsl", "fast", false)
+		return err == nil
+	}, time.Second, 10*time.Millisecond, "a slow didOpen holds up another document")
+
+	close(release)
+	require.NoError(t, <-slowDone)
+	mockClient.AssertExpectations(t)
+}
@@ -0,0 +1,264 @@
+package bridge
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"rockerboo/mcp-lsp-bridge/logger"
+	"rockerboo/mcp-lsp-bridge/lsp"
+	"rockerboo/mcp-lsp-bridge/types"
+	"rockerboo/mcp-lsp-bridge/utils"
+
+	"github.com/myleshyson/lsprotocol-go/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSessionManager answers Session Manager API requests with canned results per
+// method and records the parameters and correlation ids of every request
+type fakeSessionManager struct {
+	port int
+
+	mu      sync.Mutex
+	params  map[string][]json.RawMessage
+	corrIDs map[string][]string
+}
+
+func (f *fakeSessionManager) sent(method string) []json.RawMessage {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.params[method]
+}
+
+func (f *fakeSessionManager) correlationIDs(method string) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.corrIDs[method]
+}
+
+func serveFakeSessionManager(t *testing.T, results map[string]any) *fakeSessionManager {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+	f := &fakeSessionManager{
+		port:    listener.Addr().(*net.TCPAddr).Port,
+		params:  make(map[string][]json.RawMessage),
+		corrIDs: make(map[string][]string),
+	}
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer func() { _ = conn.Close() }()
+				reader := bufio.NewReader(conn)
+				for {
+					line, err := reader.ReadBytes('\n')
+					if err != nil {
+						return
+					}
+					var req struct {
+						ID            json.RawMessage `json:"id"`
+						Method        string          `json:"method"`
+						Params        json.RawMessage `json:"params"`
+						CorrelationID string          `json:"correlationId"`
+					}
+					if json.Unmarshal(line, &req) != nil || len(req.ID) == 0 {
+						continue
+					}
+					f.mu.Lock()
+					f.params[req.Method] = append(f.params[req.Method], req.Params)
+					f.corrIDs[req.Method] = append(f.corrIDs[req.Method], req.CorrelationID)
+					f.mu.Unlock()
+					resp := map[string]any{"jsonrpc": "2.0", "id": req.ID}
+					if result, ok := results[req.Method]; ok {
+						resp["result"] = result
+					} else {
+						resp["error"] = map[string]any{"code": -32601, "message": "unknown method: " + req.Method}
+					}
+					out, _ := json.Marshal(resp)
+					if _, err := conn.Write(append(out, '\n')); err != nil {
+						return
+					}
+				}
+			}()
+		}
+	}()
+
+	return f
+}
+
+func createSessionBridge(t *testing.T, port int, dir string) *MCPLSPBridge {
+	t.Helper()
+
+	config := &lsp.LSPServerConfig{
+		LanguageServers: map[types.LanguageServer]lsp.LanguageServerConfig{
+			"bsl-language-server": {Mode: "session", Host: "127.0.0.1", Port: port},
+		},
+		LanguageServerMap: map[types.LanguageServer][]types.Language{
+			"bsl-language-server": {"bsl"},
+		},
+		ExtensionLanguageMap: map[string]types.Language{".bsl": "bsl"},
+	}
+	b := NewMCPLSPBridge(config, []string{dir})
+	t.Cleanup(b.CloseAllClients)
+	return b
+}
+
+func TestSessionModeClientHasServerCapabilities(t *testing.T) {
+	fake := serveFakeSessionManager(t, map[string]any{
+		"session/status": map[string]any{"initialized": true},
+		"session/capabilities": map[string]any{
+			"hoverProvider":          true,
+			"implementationProvider": true,
+			"textDocumentSync":       map[string]any{"openClose": true, "change": 2},
+			"semanticTokensProvider": map[string]any{
+				"legend": map[string]any{"tokenTypes": []string{"keyword", "variable"}, "tokenModifiers": []string{}},
+				"full":   true,
+			},
+			// Not part of the protocol version the client knows
+			"experimentalBslFeature": map[string]any{"enabled": true},
+		},
+	})
+	b := createSessionBridge(t, fake.port, t.TempDir())
+
+	client, err := b.GetClientForLanguage("bsl")
+	require.NoError(t, err)
+
+	capabilities := client.ServerCapabilities()
+	assert.NotNil(t, capabilities.HoverProvider)
+	assert.NotNil(t, capabilities.ImplementationProvider)
+	assert.NotNil(t, capabilities.TextDocumentSync)
+	assert.NotNil(t, client.TokenParser(), "the token parser is built from the session legend")
+}
+
+func TestSessionModePositionEncoding(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "Module.bsl")
+	require.NoError(t, os.WriteFile(path, []byte("Сообщить(\"😀\" + Имя);\n"), 0o600))
+	uri := utils.FilePathToURI(path)
+
+	fake := serveFakeSessionManager(t, map[string]any{
+		"session/capabilities": map[string]any{
+			"positionEncoding": "utf-32",
+			"textDocumentSync": map[string]any{"openClose": true, "change": 2},
+		},
+		"textDocument/didOpen":   map[string]any{"ok": true},
+		"textDocument/didChange": map[string]any{"ok": true},
+		"textDocument/didSave":   map[string]any{"ok": true},
+	})
+	b := createSessionBridge(t, fake.port, dir)
+
+	_, err := b.GetClientForLanguage("bsl")
+	require.NoError(t, err)
+	assert.Equal(t, protocol.PositionEncodingKindUTF32, b.PositionEncoding(uri))
+
+	// The session manager applies incremental changes in the negotiated encoding:
+	// "Имя" starts at code point 15, the UTF-16 column would be 16
+	_, err = b.RevertBuffer(uri)
+	require.NoError(t, err)
+	_, err = b.UpdateBuffer(uri, "Сообщить(\"😀\" + Фамилия);\n")
+	require.NoError(t, err)
+	changes := fake.sent("textDocument/didChange")
+	require.Len(t, changes, 1)
+	var change protocol.DidChangeTextDocumentParams
+	require.NoError(t, json.Unmarshal(changes[0], &change))
+	require.Len(t, change.ContentChanges, 1)
+	partial, ok := change.ContentChanges[0].Value.(protocol.TextDocumentContentChangePartial)
+	require.True(t, ok, "got %T", change.ContentChanges[0].Value)
+	assert.Equal(t, protocol.Position{Line: 0, Character: 15}, partial.Range.Start)
+
+	// Edits from the server are counted in code points too
+	_, err = b.RevertBuffer(uri)
+	require.NoError(t, err)
+	require.NoError(t, b.ApplyTextEdits(uri, []protocol.TextEdit{{
+		Range:   protocol.Range{Start: protocol.Position{Line: 0, Character: 15}, End: protocol.Position{Line: 0, Character: 18}},
+		NewText: "Фамилия",
+	}}))
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "Сообщить(\"😀\" + Фамилия);\n", string(content))
+}
+
+func TestSessionModeBridgeViewCarriesCorrelationID(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "Module.bsl")
+	require.NoError(t, os.WriteFile(path, []byte("Процедура Тест()\nКонецПроцедуры\n"), 0o600))
+	uri := utils.FilePathToURI(path)
+
+	fake := serveFakeSessionManager(t, map[string]any{
+		"session/capabilities":      map[string]any{"textDocumentSync": map[string]any{"openClose": true, "change": 2}},
+		"textDocument/didOpen":      map[string]any{"ok": true},
+		"textDocument/foldingRange": []map[string]any{},
+	})
+	b := createSessionBridge(t, fake.port, dir)
+	logPath := filepath.Join(t.TempDir(), "bridge.log")
+	require.NoError(t, logger.InitLogger(logger.LoggerConfig{LogPath: logPath, LogLevel: "debug"}))
+	defer logger.Close()
+
+	ctx := logger.WithCorrelationID(context.Background(), "0123456789abcdef")
+	_, err := b.WithContext(ctx).FoldingRange(uri)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"0123456789abcdef"}, fake.correlationIDs("textDocument/didOpen"))
+	assert.Equal(t, []string{"0123456789abcdef"}, fake.correlationIDs("textDocument/foldingRange"))
+
+	// The bridge's own log of the request carries the id as well
+	content, err := os.ReadFile(logPath) // #nosec G304 - Test file path controlled by test
+	require.NoError(t, err)
+	var synced map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(string(content)), "\n") {
+		var record map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &record))
+		if record["msg"] == "Document synced with LSP server" {
+			synced = record
+		}
+	}
+	require.NotNil(t, synced, "%s", content)
+	assert.Equal(t, "0123456789abcdef", synced[logger.CorrelationKey])
+	assert.Equal(t, uri, synced["uri"])
+
+	// The view shares the client and the open documents of the bridge
+	_, err = b.FoldingRange(uri)
+	require.NoError(t, err)
+	assert.Len(t, fake.correlationIDs("textDocument/didOpen"), 1)
+	assert.Equal(t, []string{"0123456789abcdef", ""}, fake.correlationIDs("textDocument/foldingRange"))
+}
+
+func TestGetClientForLanguageConcurrentCallers(t *testing.T) {
+	fake := serveFakeSessionManager(t, map[string]any{
+		"session/capabilities": map[string]any{"hoverProvider": true},
+	})
+	b := createSessionBridge(t, fake.port, t.TempDir())
+
+	var wg sync.WaitGroup
+	clients := make([]types.LanguageClientInterface, 8)
+	for i := range clients {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			client, err := b.GetClientForLanguage("bsl")
+			assert.NoError(t, err)
+			clients[i] = client
+			_ = b.PositionEncoding("file:///projects/Module.bsl")
+			_ = b.ListConnectedClients()
+		}()
+	}
+	wg.Wait()
+
+	for _, client := range clients {
+		assert.Same(t, clients[0], client)
+	}
+	assert.Len(t, fake.sent("session/capabilities"), 1, "one session client is connected")
+}
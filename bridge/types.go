@@ -1,6 +1,7 @@
 package bridge
 
 import (
+	"context"
 	"sync"
 
 	"time"
@@ -13,12 +14,24 @@ import (
 
 // MCPLSPBridge combines MCP server capabilities with multiple LSP clients
 type MCPLSPBridge struct {
+	*bridgeState
+
+	// ctx is the context language server requests start from, set by WithContext
+	ctx context.Context
+}
+
+// bridgeState is shared by a bridge and the views WithContext returns
+type bridgeState struct {
 	server             *server.MCPServer
 	clients            map[types.LanguageServer]types.LanguageClientInterface
 	config             types.LSPServerConfigProvider
 	allowedDirectories []string
 	pathMapper         *utils.DockerPathMapper
-	mu                 sync.RWMutex
+	mu                 sync.RWMutex // guards clients
+	connectMu          sync.Mutex   // serializes starting and replacing clients
+
+	// Documents opened on language servers, with versions and in-memory overlays
+	documents *documentStore
 
 	// Auto-connect support: connect default language client(s) once, lazily.
 	autoConnectMu          sync.Mutex
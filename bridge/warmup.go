@@ -39,7 +39,8 @@ func (b *MCPLSPBridge) StartWarmup() {
 	}
 	b.warmupErr = ""
 
-	go b.runWarmup()
+	// Warm-up outlives the tool call that triggered it, so it does not use its context
+	go (&MCPLSPBridge{bridgeState: b.bridgeState}).runWarmup()
 }
 
 func (b *MCPLSPBridge) finishWarmup(err error) {
@@ -0,0 +1,506 @@
+package bridge
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"rockerboo/mcp-lsp-bridge/logger"
+	"rockerboo/mcp-lsp-bridge/types"
+	"rockerboo/mcp-lsp-bridge/utils"
+
+	"github.com/myleshyson/lsprotocol-go/protocol"
+)
+
+// renameFile is swapped in tests to simulate failures while committing
+var renameFile = os.Rename
+
+// ApplyWorkspaceEdit applies a workspace edit to multiple files as one transaction.
+//
+// All operations are first planned in memory: every path is validated, new contents
+// are computed and TextDocumentEdit versions are checked against the open documents.
+// Only then are files written, each through a temp file and rename, with the originals
+// kept as backups until every file is in place. Any failure restores the backups, so
+// the workspace is never left half-edited.
+func (b *MCPLSPBridge) ApplyWorkspaceEdit(workspaceEdit *protocol.WorkspaceEdit) (*types.WorkspaceEditResult, error) {
+	if workspaceEdit == nil {
+		return nil, errors.New("workspace edit is nil")
+	}
+
+	logger.Debug(fmt.Sprintf("ApplyWorkspaceEdit: Processing workspace edit. Changes: %d, DocumentChanges: %d", len(workspaceEdit.Changes), len(workspaceEdit.DocumentChanges)))
+
+	plan, err := b.planWorkspaceEdit(workspaceEdit)
+	if err != nil {
+		return nil, fmt.Errorf("workspace edit rejected, no files were changed: %w", err)
+	}
+
+	changed := plan.changedFiles()
+	if err := commitFiles(changed); err != nil {
+		return nil, err
+	}
+
+	b.notifyCommittedFiles(changed)
+
+	result := plan.result()
+	logger.Debug(fmt.Sprintf("ApplyWorkspaceEdit: Applied workspace edit to %d files", len(result.Files)))
+	return result, nil
+}
+
+// plannedFile is the in-memory state of one file while a workspace edit is planned
+type plannedFile struct {
+	path     string
+	existed  bool // on disk before the edit
+	original string
+	mode     fs.FileMode
+	exists   bool // after the edit
+	content  string
+	edits    int
+}
+
+func (f *plannedFile) changed() bool {
+	return f.existed != f.exists || (f.exists && f.content != f.original)
+}
+
+type workspaceEditPlan struct {
+	files       map[string]*plannedFile
+	order       []string
+	renamedFrom map[string]string // new path -> old path
+}
+
+func newWorkspaceEditPlan() *workspaceEditPlan {
+	return &workspaceEditPlan{
+		files:       make(map[string]*plannedFile),
+		renamedFrom: make(map[string]string),
+	}
+}
+
+// file returns the planned state of path, loading it from disk on first use
+func (p *workspaceEditPlan) file(path string) (*plannedFile, error) {
+	if f, ok := p.files[path]; ok {
+		return f, nil
+	}
+
+	f := &plannedFile{path: path}
+	info, err := os.Stat(path)
+	switch {
+	case err == nil:
+		if info.IsDir() {
+			return nil, fmt.Errorf("%s is a directory, only file operations are supported", path)
+		}
+		data, err := os.ReadFile(path) // #nosec G304 - validated with IsAllowedDirectory
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file %s: %w", path, err)
+		}
+		f.existed = true
+		f.exists = true
+		f.original = string(data)
+		f.content = f.original
+		f.mode = info.Mode().Perm()
+	case errors.Is(err, fs.ErrNotExist):
+		if _, err := os.Stat(filepath.Dir(path)); err != nil {
+			return nil, fmt.Errorf("parent directory of %s is not available: %w", path, err)
+		}
+		f.mode = newFileMode(filepath.Dir(path))
+	default:
+		return nil, fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+
+	p.files[path] = f
+	p.order = append(p.order, path)
+	return f, nil
+}
+
+// defaultNewFileMode is the mode of created files in a directory without files to go by
+const defaultNewFileMode fs.FileMode = 0o644
+
+// newFileMode returns the mode for a file created in dir: that of the regular files
+// next to it, so sources shared with a container or other users stay readable
+func newFileMode(dir string) fs.FileMode {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return defaultNewFileMode
+	}
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		if info, err := entry.Info(); err == nil {
+			return info.Mode().Perm()
+		}
+	}
+	return defaultNewFileMode
+}
+
+// changedFiles returns the files whose final state differs from disk, in first-touch order
+func (p *workspaceEditPlan) changedFiles() []*plannedFile {
+	changed := make([]*plannedFile, 0, len(p.order))
+	for _, path := range p.order {
+		if f := p.files[path]; f.changed() {
+			changed = append(changed, f)
+		}
+	}
+	return changed
+}
+
+// result describes every file the edit touched
+func (p *workspaceEditPlan) result() *types.WorkspaceEditResult {
+	renamedAway := make(map[string]bool, len(p.renamedFrom))
+	for _, oldPath := range p.renamedFrom {
+		renamedAway[oldPath] = true
+	}
+
+	result := &types.WorkspaceEditResult{Files: []types.TouchedFile{}}
+	for _, f := range p.changedFiles() {
+		touched := types.TouchedFile{Path: f.path, Edits: f.edits}
+
+		oldPath, isRenameTarget := p.renamedFrom[f.path]
+		switch {
+		case isRenameTarget && f.exists && p.files[oldPath].existed:
+			touched.Kind = types.FileChangeRenamed
+			touched.OldPath = oldPath
+		case !f.exists && renamedAway[f.path]:
+			continue // reported with the rename target
+		case !f.existed:
+			touched.Kind = types.FileChangeCreated
+		case !f.exists:
+			touched.Kind = types.FileChangeDeleted
+		default:
+			touched.Kind = types.FileChangeEdited
+		}
+
+		result.Files = append(result.Files, touched)
+	}
+	return result
+}
+
+// planWorkspaceEdit validates the edit and computes the final content of every file
+func (b *MCPLSPBridge) planWorkspaceEdit(workspaceEdit *protocol.WorkspaceEdit) (*workspaceEditPlan, error) {
+	plan := newWorkspaceEditPlan()
+
+	for i, docChange := range workspaceEdit.DocumentChanges {
+		var err error
+		switch change := docChange.Value.(type) {
+		case protocol.TextDocumentEdit:
+			err = b.planTextDocumentEdit(plan, change)
+		case protocol.CreateFile:
+			err = b.planCreateFile(plan, change)
+		case protocol.RenameFile:
+			err = b.planRenameFile(plan, change)
+		case protocol.DeleteFile:
+			err = b.planDeleteFile(plan, change)
+		default:
+			err = fmt.Errorf("unsupported document change type %T", docChange.Value)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("document change %d: %w", i, err)
+		}
+	}
+
+	// Changes map (alternative format), sorted so failures are reproducible
+	uris := make([]string, 0, len(workspaceEdit.Changes))
+	for uri := range workspaceEdit.Changes {
+		uris = append(uris, string(uri))
+	}
+	sort.Strings(uris)
+
+	for _, uri := range uris {
+		path, err := b.allowedEditPath(uri)
+		if err != nil {
+			return nil, err
+		}
+		f, err := plan.file(path)
+		if err != nil {
+			return nil, err
+		}
+		if err := b.planTextEdits(f, nil, workspaceEdit.Changes[protocol.DocumentUri(uri)]); err != nil {
+			return nil, fmt.Errorf("edits for %s: %w", uri, err)
+		}
+	}
+
+	return plan, nil
+}
+
+func (b *MCPLSPBridge) planTextDocumentEdit(plan *workspaceEditPlan, change protocol.TextDocumentEdit) error {
+	path, err := b.allowedEditPath(string(change.TextDocument.Uri))
+	if err != nil {
+		return err
+	}
+	f, err := plan.file(path)
+	if err != nil {
+		return err
+	}
+
+	edits := make([]protocol.TextEdit, 0, len(change.Edits))
+	for j, edit := range change.Edits {
+		switch e := edit.Value.(type) {
+		case protocol.TextEdit:
+			edits = append(edits, e)
+		case protocol.AnnotatedTextEdit:
+			edits = append(edits, protocol.TextEdit{Range: e.Range, NewText: e.NewText})
+		default:
+			return fmt.Errorf("edit %d for %s: unsupported edit type %T", j, change.TextDocument.Uri, edit.Value)
+		}
+	}
+
+	if err := b.planTextEdits(f, change.TextDocument.Version, edits); err != nil {
+		return fmt.Errorf("edits for %s: %w", change.TextDocument.Uri, err)
+	}
+	return nil
+}
+
+// planTextEdits applies edits to the planned content of f. When the document is open,
+// a requested version must match the version the language server has; edits for a
+// document with an in-memory overlay are applied on top of the overlay.
+func (b *MCPLSPBridge) planTextEdits(f *plannedFile, version *int32, edits []protocol.TextEdit) error {
+	if !f.exists {
+		return fmt.Errorf("file %s does not exist", f.path)
+	}
+
+	doc, open := b.documents.snapshot(utils.NormalizeURI(f.path))
+	if version != nil && open && doc.version != *version {
+		return fmt.Errorf("document %s changed since the edit was computed (edit is for version %d, current version is %d)", f.path, *version, doc.version)
+	}
+	if open && doc.overlay && f.edits == 0 && f.content == f.original {
+		f.content = doc.text
+	}
+
+	content, err := applyTextEditsToContent(f.content, edits, b.PositionEncoding(f.path))
+	if err != nil {
+		return err
+	}
+	f.content = content
+	f.edits += len(edits)
+	return nil
+}
+
+func (b *MCPLSPBridge) planCreateFile(plan *workspaceEditPlan, change protocol.CreateFile) error {
+	path, err := b.allowedEditPath(string(change.Uri))
+	if err != nil {
+		return err
+	}
+	f, err := plan.file(path)
+	if err != nil {
+		return err
+	}
+
+	if f.exists {
+		switch {
+		case change.Options != nil && change.Options.Overwrite:
+		case change.Options != nil && change.Options.IgnoreIfExists:
+			return nil
+		default:
+			return fmt.Errorf("cannot create %s: file already exists", path)
+		}
+	}
+
+	f.exists = true
+	f.content = ""
+	return nil
+}
+
+func (b *MCPLSPBridge) planRenameFile(plan *workspaceEditPlan, change protocol.RenameFile) error {
+	oldPath, err := b.allowedEditPath(string(change.OldUri))
+	if err != nil {
+		return fmt.Errorf("rename source: %w", err)
+	}
+	newPath, err := b.allowedEditPath(string(change.NewUri))
+	if err != nil {
+		return fmt.Errorf("rename target: %w", err)
+	}
+
+	src, err := plan.file(oldPath)
+	if err != nil {
+		return err
+	}
+	dst, err := plan.file(newPath)
+	if err != nil {
+		return err
+	}
+
+	if !src.exists {
+		return fmt.Errorf("cannot rename %s: file does not exist", oldPath)
+	}
+	if dst.exists {
+		switch {
+		case change.Options != nil && change.Options.Overwrite:
+		case change.Options != nil && change.Options.IgnoreIfExists:
+			return nil
+		default:
+			return fmt.Errorf("cannot rename %s to %s: target already exists", oldPath, newPath)
+		}
+	}
+
+	dst.exists = true
+	dst.content = src.content
+	dst.mode = src.mode
+	dst.edits += src.edits
+	src.exists = false
+	src.content = ""
+
+	if origin, ok := plan.renamedFrom[oldPath]; ok {
+		oldPath = origin
+	}
+	plan.renamedFrom[newPath] = oldPath
+	return nil
+}
+
+func (b *MCPLSPBridge) planDeleteFile(plan *workspaceEditPlan, change protocol.DeleteFile) error {
+	path, err := b.allowedEditPath(string(change.Uri))
+	if err != nil {
+		return err
+	}
+	f, err := plan.file(path)
+	if err != nil {
+		return err
+	}
+
+	if !f.exists {
+		if change.Options != nil && change.Options.IgnoreIfNotExists {
+			return nil
+		}
+		return fmt.Errorf("cannot delete %s: file does not exist", path)
+	}
+
+	f.exists = false
+	f.content = ""
+	return nil
+}
+
+// allowedEditPath converts a URI to a file path inside the allowed directories
+func (b *MCPLSPBridge) allowedEditPath(uri string) (string, error) {
+	filePath, err := b.IsAllowedDirectory(utils.URIToFilePath(uri))
+	if err != nil {
+		return "", fmt.Errorf("file path is not allowed: %s: %w", uri, err)
+	}
+	return filePath, nil
+}
+
+// commitStep records what was done to one file so it can be undone
+type commitStep struct {
+	path   string
+	backup string // original content moved aside, empty if the file did not exist
+}
+
+// commitFiles writes the planned files. New contents are staged in temp files next to
+// their targets first; originals are then moved to backups and the temp files renamed
+// into place. If anything fails, every step taken so far is rolled back.
+func commitFiles(files []*plannedFile) error {
+	staged := make(map[string]string, len(files))
+	defer func() {
+		for _, tmp := range staged {
+			_ = os.Remove(tmp)
+		}
+	}()
+
+	for _, f := range files {
+		if !f.exists {
+			continue
+		}
+		tmp, err := writeTempFile(f.path, f.content, f.mode)
+		if err != nil {
+			return fmt.Errorf("failed to stage %s, no files were changed: %w", f.path, err)
+		}
+		staged[f.path] = tmp
+	}
+
+	steps := make([]commitStep, 0, len(files))
+	fail := func(err error) error {
+		if rbErr := rollback(steps); rbErr != nil {
+			return fmt.Errorf("%w; rollback incomplete: %v", err, rbErr)
+		}
+		return fmt.Errorf("%w; all changes were rolled back", err)
+	}
+
+	for _, f := range files {
+		step := commitStep{path: f.path}
+		if f.existed {
+			step.backup = backupPath(f.path)
+			if err := renameFile(f.path, step.backup); err != nil {
+				return fail(fmt.Errorf("failed to back up %s: %w", f.path, err))
+			}
+		}
+		steps = append(steps, step)
+
+		if f.exists {
+			if err := renameFile(staged[f.path], f.path); err != nil {
+				return fail(fmt.Errorf("failed to write %s: %w", f.path, err))
+			}
+			delete(staged, f.path)
+		}
+	}
+
+	for _, step := range steps {
+		if step.backup == "" {
+			continue
+		}
+		if err := os.Remove(step.backup); err != nil {
+			logger.Warn(fmt.Sprintf("ApplyWorkspaceEdit: failed to remove backup %s: %v", step.backup, err))
+		}
+	}
+	return nil
+}
+
+// rollback undoes commit steps in reverse order
+func rollback(steps []commitStep) error {
+	var errs []error
+	for i := len(steps) - 1; i >= 0; i-- {
+		step := steps[i]
+		if err := os.Remove(step.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			errs = append(errs, fmt.Errorf("remove %s: %w", step.path, err))
+			continue
+		}
+		if step.backup == "" {
+			continue
+		}
+		if err := os.Rename(step.backup, step.path); err != nil {
+			errs = append(errs, fmt.Errorf("restore %s from %s: %w", step.path, step.backup, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// writeTempFile writes content to a temp file in the target's directory so the
+// final rename stays on one filesystem
+func writeTempFile(target, content string, mode fs.FileMode) (string, error) {
+	tmp, err := os.CreateTemp(filepath.Dir(target), "."+filepath.Base(target)+".tmp-*")
+	if err != nil {
+		return "", err
+	}
+
+	_, err = tmp.WriteString(content)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), mode)
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+		return "", err
+	}
+	return tmp.Name(), nil
+}
+
+func backupPath(path string) string {
+	return filepath.Join(filepath.Dir(path), fmt.Sprintf(".%s.bak-%d-%d", filepath.Base(path), os.Getpid(), time.Now().UnixNano()))
+}
+
+// notifyCommittedFiles syncs open documents with what was written to disk
+func (b *MCPLSPBridge) notifyCommittedFiles(files []*plannedFile) {
+	for _, f := range files {
+		if f.exists {
+			b.notifyDocumentSaved(f.path, f.content)
+			continue
+		}
+		if err := b.documents.closed(b.ctx, utils.NormalizeURI(f.path)); err != nil {
+			logger.Warn(fmt.Sprintf("ApplyWorkspaceEdit: failed to close removed document %s: %v", f.path, err))
+		}
+	}
+}
@@ -0,0 +1,209 @@
+package bridge
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"rockerboo/mcp-lsp-bridge/mocks"
+	"rockerboo/mcp-lsp-bridge/types"
+	"rockerboo/mcp-lsp-bridge/utils"
+
+	"github.com/myleshyson/lsprotocol-go/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func textDocumentEdit(path string, version *int32, edits ...protocol.TextEdit) protocol.Or4[protocol.TextDocumentEdit, protocol.CreateFile, protocol.RenameFile, protocol.DeleteFile] {
+	wrapped := make([]protocol.Or3[protocol.TextEdit, protocol.AnnotatedTextEdit, protocol.SnippetTextEdit], len(edits))
+	for i, edit := range edits {
+		wrapped[i] = protocol.Or3[protocol.TextEdit, protocol.AnnotatedTextEdit, protocol.SnippetTextEdit]{Value: edit}
+	}
+	return protocol.Or4[protocol.TextDocumentEdit, protocol.CreateFile, protocol.RenameFile, protocol.DeleteFile]{
+		Value: protocol.TextDocumentEdit{
+			TextDocument: protocol.OptionalVersionedTextDocumentIdentifier{
+				Uri:     protocol.DocumentUri(utils.NormalizeURI(path)),
+				Version: version,
+			},
+			Edits: wrapped,
+		},
+	}
+}
+
+func replaceEdit(line, startChar, endChar uint32, newText string) protocol.TextEdit {
+	return protocol.TextEdit{
+		Range: protocol.Range{
+			Start: protocol.Position{Line: line, Character: startChar},
+			End:   protocol.Position{Line: line, Character: endChar},
+		},
+		NewText: newText,
+	}
+}
+
+func writeWorkspaceFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0600))
+	}
+	return dir
+}
+
+func assertFileContent(t *testing.T, path, expected string) {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, expected, string(data))
+}
+
+func assertNoLeftovers(t *testing.T, dir string, expected int) {
+	t.Helper()
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, expected, "temp or backup files were left behind")
+}
+
+func TestApplyWorkspaceEditAppliesAllChanges(t *testing.T) {
+	dir := writeWorkspaceFiles(t, map[string]string{
+		"a.bsl":   "Procedure Old() Export\nEndProcedure",
+		"b.bsl":   "Old();",
+		"old.bsl": "Var A;",
+		"tmp.bsl": "",
+	})
+	bridge := createTestBridge([]string{dir})
+
+	a := filepath.Join(dir, "a.bsl")
+	b := filepath.Join(dir, "b.bsl")
+
+	result, err := bridge.ApplyWorkspaceEdit(&protocol.WorkspaceEdit{
+		DocumentChanges: []protocol.Or4[protocol.TextDocumentEdit, protocol.CreateFile, protocol.RenameFile, protocol.DeleteFile]{
+			textDocumentEdit(a, nil, replaceEdit(0, 10, 13, "Renamed")),
+			textDocumentEdit(b, nil, replaceEdit(0, 0, 3, "Renamed")),
+			{Value: protocol.RenameFile{
+				OldUri: protocol.DocumentUri(utils.NormalizeURI(filepath.Join(dir, "old.bsl"))),
+				NewUri: protocol.DocumentUri(utils.NormalizeURI(filepath.Join(dir, "new.bsl"))),
+			}},
+			{Value: protocol.CreateFile{Uri: protocol.DocumentUri(utils.NormalizeURI(filepath.Join(dir, "created.bsl")))}},
+			{Value: protocol.DeleteFile{Uri: protocol.DocumentUri(utils.NormalizeURI(filepath.Join(dir, "tmp.bsl")))}},
+		},
+	})
+	require.NoError(t, err)
+
+	assertFileContent(t, a, "Procedure Renamed() Export\nEndProcedure")
+	assertFileContent(t, b, "Renamed();")
+	assertFileContent(t, filepath.Join(dir, "new.bsl"), "Var A;")
+	assertFileContent(t, filepath.Join(dir, "created.bsl"), "")
+	assert.NoFileExists(t, filepath.Join(dir, "old.bsl"))
+	assert.NoFileExists(t, filepath.Join(dir, "tmp.bsl"))
+	assertNoLeftovers(t, dir, 4)
+
+	assert.Equal(t, []types.TouchedFile{
+		{Path: a, Kind: types.FileChangeEdited, Edits: 1},
+		{Path: b, Kind: types.FileChangeEdited, Edits: 1},
+		{Path: filepath.Join(dir, "new.bsl"), OldPath: filepath.Join(dir, "old.bsl"), Kind: types.FileChangeRenamed},
+		{Path: filepath.Join(dir, "created.bsl"), Kind: types.FileChangeCreated},
+		{Path: filepath.Join(dir, "tmp.bsl"), Kind: types.FileChangeDeleted},
+	}, result.Files)
+}
+
+func TestApplyWorkspaceEditRejectsWholeEditBeforeWriting(t *testing.T) {
+	dir := writeWorkspaceFiles(t, map[string]string{"a.bsl": "Old();"})
+	outside := writeWorkspaceFiles(t, map[string]string{"b.bsl": "Old();"})
+	bridge := createTestBridge([]string{dir})
+
+	_, err := bridge.ApplyWorkspaceEdit(&protocol.WorkspaceEdit{
+		DocumentChanges: []protocol.Or4[protocol.TextDocumentEdit, protocol.CreateFile, protocol.RenameFile, protocol.DeleteFile]{
+			textDocumentEdit(filepath.Join(dir, "a.bsl"), nil, replaceEdit(0, 0, 3, "Renamed")),
+			textDocumentEdit(filepath.Join(outside, "b.bsl"), nil, replaceEdit(0, 0, 3, "Renamed")),
+		},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no files were changed")
+
+	assertFileContent(t, filepath.Join(dir, "a.bsl"), "Old();")
+	assertFileContent(t, filepath.Join(outside, "b.bsl"), "Old();")
+}
+
+func TestApplyWorkspaceEditChecksDocumentVersion(t *testing.T) {
+	dir := writeWorkspaceFiles(t, map[string]string{"a.bsl": "Old();"})
+	bridge := createTestBridge([]string{dir})
+	path := filepath.Join(dir, "a.bsl")
+	serverURI := utils.NormalizeURI(path)
+
+	mockClient := &mocks.MockLanguageClient{}
+	mockClient.On("ServerCapabilities").Return(incrementalCapabilities())
+	mockClient.On("DidOpen", mock.Anything, mock.Anything, mock.Anything, int32(1)).Return(int32(1), nil)
+	mockClient.On("DidChange", serverURI, int32(2), mock.Anything).Return(int32(2), nil)
+
+	_, err := bridge.documents.sync(context.Background(), mockClient, serverURI, "bsl", "Old();", false)
+	require.NoError(t, err)
+	_, err = bridge.documents.sync(context.Background(), mockClient, serverURI, "bsl", "Old(1);", true)
+	require.NoError(t, err)
+
+	stale := int32(1)
+	_, err = bridge.ApplyWorkspaceEdit(&protocol.WorkspaceEdit{
+		DocumentChanges: []protocol.Or4[protocol.TextDocumentEdit, protocol.CreateFile, protocol.RenameFile, protocol.DeleteFile]{
+			textDocumentEdit(path, &stale, replaceEdit(0, 0, 3, "Renamed")),
+		},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "changed since the edit was computed")
+	assertFileContent(t, path, "Old();")
+
+	// The current version applies on top of the in-memory overlay and saves it
+	mockClient.On("DidChange", serverURI, int32(3), mock.Anything).Return(int32(3), nil)
+	mockClient.On("DidSave", serverURI, (*string)(nil)).Return(nil)
+
+	current := int32(2)
+	_, err = bridge.ApplyWorkspaceEdit(&protocol.WorkspaceEdit{
+		DocumentChanges: []protocol.Or4[protocol.TextDocumentEdit, protocol.CreateFile, protocol.RenameFile, protocol.DeleteFile]{
+			textDocumentEdit(path, &current, replaceEdit(0, 0, 3, "Renamed")),
+		},
+	})
+	require.NoError(t, err)
+	assertFileContent(t, path, "Renamed(1);")
+	assert.False(t, bridge.documents.hasOverlay(mockClient, serverURI))
+	mockClient.AssertExpectations(t)
+}
+
+func TestApplyWorkspaceEditRollsBackOnCommitFailure(t *testing.T) {
+	dir := writeWorkspaceFiles(t, map[string]string{
+		"a.bsl": "Old();",
+		"b.bsl": "Old();",
+		"c.bsl": "Old();",
+	})
+	bridge := createTestBridge([]string{dir})
+
+	// Fail when installing the third file, after two files were already replaced
+	calls := 0
+	renameFile = func(oldpath, newpath string) error {
+		calls++
+		if calls == 6 {
+			return errors.New("disk full")
+		}
+		return os.Rename(oldpath, newpath)
+	}
+	t.Cleanup(func() { renameFile = os.Rename })
+
+	_, err := bridge.ApplyWorkspaceEdit(&protocol.WorkspaceEdit{
+		Changes: map[protocol.DocumentUri][]protocol.TextEdit{
+			protocol.DocumentUri(utils.NormalizeURI(filepath.Join(dir, "a.bsl"))): {replaceEdit(0, 0, 3, "Renamed")},
+			protocol.DocumentUri(utils.NormalizeURI(filepath.Join(dir, "b.bsl"))): {replaceEdit(0, 0, 3, "Renamed")},
+			protocol.DocumentUri(utils.NormalizeURI(filepath.Join(dir, "c.bsl"))): {replaceEdit(0, 0, 3, "Renamed")},
+		},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "disk full")
+	assert.Contains(t, err.Error(), "rolled back")
+
+	for _, name := range []string{"a.bsl", "b.bsl", "c.bsl"} {
+		assertFileContent(t, filepath.Join(dir, name), "Old();")
+	}
+	assertNoLeftovers(t, dir, 3)
+}
@@ -0,0 +1,49 @@
+//go:build !windows
+
+package bridge
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"rockerboo/mcp-lsp-bridge/utils"
+
+	"github.com/myleshyson/lsprotocol-go/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyWorkspaceEditCreatedFileMode(t *testing.T) {
+	dir := t.TempDir()
+	shared := filepath.Join(dir, "Shared")
+	empty := filepath.Join(dir, "Empty")
+	require.NoError(t, os.MkdirAll(shared, 0o750))
+	require.NoError(t, os.MkdirAll(empty, 0o750))
+	require.NoError(t, os.WriteFile(filepath.Join(shared, "Module.bsl"), nil, 0o600))
+	// Group-writable sources, as in a checkout shared with a container
+	require.NoError(t, os.Chmod(filepath.Join(shared, "Module.bsl"), 0o664))
+	bridge := createTestBridge([]string{dir})
+
+	create := func(path string) protocol.Or4[protocol.TextDocumentEdit, protocol.CreateFile, protocol.RenameFile, protocol.DeleteFile] {
+		return protocol.Or4[protocol.TextDocumentEdit, protocol.CreateFile, protocol.RenameFile, protocol.DeleteFile]{
+			Value: protocol.CreateFile{Uri: protocol.DocumentUri(utils.NormalizeURI(path))},
+		}
+	}
+	_, err := bridge.ApplyWorkspaceEdit(&protocol.WorkspaceEdit{
+		DocumentChanges: []protocol.Or4[protocol.TextDocumentEdit, protocol.CreateFile, protocol.RenameFile, protocol.DeleteFile]{
+			create(filepath.Join(shared, "New.bsl")),
+			create(filepath.Join(empty, "New.bsl")),
+		},
+	})
+	require.NoError(t, err)
+
+	mode := func(path string) fs.FileMode {
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		return info.Mode().Perm()
+	}
+	assert.Equal(t, fs.FileMode(0o664), mode(filepath.Join(shared, "New.bsl")), "the mode of the files next to it")
+	assert.Equal(t, fs.FileMode(0o644), mode(filepath.Join(empty, "New.bsl")))
+}
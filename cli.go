@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"rockerboo/mcp-lsp-bridge/mcpserver/tools"
+)
+
+// subcommands run instead of the MCP server when named as the first argument
+var subcommands = map[string]func(args []string) int{
+	"callgraph-export": runCallGraphExport,
+	"tool":             runTool,
+	"diagnostics-gate": runDiagnosticsGate,
+	"diagnostics-diff": runDiagnosticsDiff,
+}
+
+// runCallGraphExport converts a call_graph JSON result, saved from the tool, into
+// another format offline: mcp-lsp-bridge callgraph-export -format dot < graph.json
+func runCallGraphExport(args []string) int {
+	fs := flag.NewFlagSet("callgraph-export", flag.ContinueOnError)
+	format := fs.String("format", tools.CallGraphFormatDOT, "Output format: "+strings.Join(tools.CallGraphFormats, ", "))
+	cluster := fs.String("cluster", tools.CallGraphClusterNone, "Group nodes: "+strings.Join(tools.CallGraphClusters, ", "))
+	in := fs.String("in", "-", "call_graph JSON result to read, - for stdin")
+	out := fs.String("out", "-", "File to write, - for stdout")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	var src io.Reader = os.Stdin
+	if *in != "-" {
+		f, err := os.Open(*in) // #nosec G304 -- path given on the command line
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+			return 1
+		}
+		defer f.Close()
+		src = f
+	}
+
+	var result tools.CallGraphResult
+	if err := json.NewDecoder(src).Decode(&result); err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: failed to read call graph JSON: %v\n", err)
+		return 1
+	}
+	if result.Root == nil {
+		fmt.Fprintln(os.Stderr, "ERROR: input has no root node; expected the JSON output of call_graph")
+		return 1
+	}
+
+	output, err := tools.ExportCallGraph(&result, *format, *cluster)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		return 2
+	}
+
+	if *out == "-" {
+		fmt.Print(output)
+		return 0
+	}
+	if err := os.WriteFile(*out, []byte(output), 0o644); err != nil { // #nosec G306 -- an export meant to be shared
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,292 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"rockerboo/mcp-lsp-bridge/logger"
+	"rockerboo/mcp-lsp-bridge/mcpserver/tools"
+	"rockerboo/mcp-lsp-bridge/report"
+	"rockerboo/mcp-lsp-bridge/utils"
+)
+
+// maxPrintedFindings limits the new findings echoed to the job log
+const maxPrintedFindings = 50
+
+// runDiagnosticsGate runs workspace diagnostics once, writes CI reports and fails
+// when there are diagnostics that the baseline does not cover:
+// mcp-lsp-bridge diagnostics-gate -baseline .bsl-baseline.json -sarif bsl.sarif
+//
+// Exit codes: 0 no new diagnostics, 1 new diagnostics, 2 usage error or the scan failed.
+func runDiagnosticsGate(args []string) int {
+	opts, err := defaultBridgeOptions()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		return 2
+	}
+
+	fs := flag.NewFlagSet("diagnostics-gate", flag.ContinueOnError)
+	opts.registerFlags(fs)
+	workspace := fs.String("workspace", "", "Workspace to scan (default: WORKSPACE_ROOT or the current directory)")
+	source := fs.String("source", "auto", "Where diagnostics come from: auto, store or pull")
+	severity := fs.String("severity", "warning", "Minimum severity to report: error, warning, information or hint")
+	codes := fs.String("code", "", "Comma-separated diagnostic codes to include")
+	path := fs.String("path", "", "Glob over file paths to include")
+	baselinePath := fs.String("baseline", "", "Baseline file; only diagnostics missing from it fail the gate")
+	updateBaseline := fs.Bool("update-baseline", false, "Write every current diagnostic to -baseline and pass")
+	sarifPath := fs.String("sarif", "", "Write a SARIF 2.1.0 report to this file")
+	junitPath := fs.String("junit", "", "Write a JUnit XML report to this file")
+	codeQualityPath := fs.String("codequality", "", "Write a GitLab Code Quality report to this file")
+	timeout := fs.Duration("timeout", 30*time.Minute, "Maximum time for language server start and the scan")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(os.Stderr, "ERROR: unexpected arguments: %v\n", fs.Args())
+		return 2
+	}
+	if *source != "auto" && *source != "store" && *source != "pull" {
+		fmt.Fprintf(os.Stderr, "ERROR: invalid -source %q: expected auto, store or pull\n", *source)
+		return 2
+	}
+	if *updateBaseline && *baselinePath == "" {
+		fmt.Fprintln(os.Stderr, "ERROR: -update-baseline needs -baseline")
+		return 2
+	}
+
+	filter, err := tools.ParseDiagnosticsFilter(*severity, *codes, *path, "")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		return 2
+	}
+
+	var baseline *report.Baseline
+	if *baselinePath != "" && !*updateBaseline {
+		if baseline, err = report.LoadBaseline(*baselinePath); err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+			return 2
+		}
+	}
+
+	root := *workspace
+	if root == "" {
+		root = os.Getenv("WORKSPACE_ROOT")
+	}
+	if root == "" {
+		root = "."
+	}
+	if root, err = filepath.Abs(root); err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		return 2
+	}
+
+	bridgeInstance, err := newBridge(opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		return 2
+	}
+	defer logger.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	ctx, cancel := context.WithTimeout(ctx, *timeout)
+	defer cancel()
+
+	if err := bridgeInstance.SyncAutoConnect(); err != nil {
+		logger.Warn("Some language servers failed to connect: " + err.Error())
+	}
+	defer bridgeInstance.CloseAllClients()
+
+	if err := tools.WaitReady(ctx, bridgeInstance); err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		return 2
+	}
+
+	documents, err := tools.CollectWorkspaceDiagnostics(ctx, bridgeInstance, utils.FilePathToURI(root), *source, filter)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		return 2
+	}
+
+	findings := report.NewFindings(root, documents)
+	if *updateBaseline {
+		if err := writeReportFile(*baselinePath, func(w io.Writer) error {
+			return report.WriteBaseline(w, report.NewBaseline(findings))
+		}); err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+			return 2
+		}
+		fmt.Printf("Baseline written to %s with %d diagnostic(s)\n", *baselinePath, len(findings))
+		return 0
+	}
+	baseline.Apply(findings)
+
+	outputs := []struct {
+		path  string
+		write func(io.Writer) error
+	}{
+		{*sarifPath, func(w io.Writer) error {
+			return report.WriteSARIF(w, findings, report.SARIFOptions{
+				ToolName: "bsl-language-server",
+				RootURI:  utils.FilePathToURI(root),
+				Baseline: baseline != nil,
+			})
+		}},
+		{*junitPath, func(w io.Writer) error { return report.WriteJUnit(w, "bsl-diagnostics", findings) }},
+		{*codeQualityPath, func(w io.Writer) error { return report.WriteCodeQuality(w, findings) }},
+	}
+	for _, output := range outputs {
+		if output.path == "" {
+			continue
+		}
+		if err := writeReportFile(output.path, output.write); err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+			return 2
+		}
+	}
+
+	newCount := report.CountNew(findings)
+	fmt.Printf("%d diagnostic(s), %d new\n", len(findings), newCount)
+	printed := 0
+	for _, f := range findings {
+		if !f.New {
+			continue
+		}
+		if printed == maxPrintedFindings {
+			fmt.Printf("... and %d more\n", newCount-printed)
+			break
+		}
+		fmt.Printf("%s:%d:%d: %s [%s] %s\n", f.Path, f.Line, f.Column, f.Severity, f.Code, f.Message)
+		printed++
+	}
+
+	if newCount > 0 {
+		return 1
+	}
+	return 0
+}
+
+// writeReportFile creates path and writes a report into it
+func writeReportFile(path string, write func(io.Writer) error) error {
+	f, err := os.Create(path) // #nosec G304 -- path given on the command line
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return f.Close()
+}
+
+// runDiagnosticsDiff compares the diagnostics of two git revisions:
+// mcp-lsp-bridge diagnostics-diff -base main -head feature/x
+//
+// Exit codes: 0 head adds no diagnostics, 1 head adds diagnostics, 2 usage error or the diff failed.
+func runDiagnosticsDiff(args []string) int {
+	opts, err := defaultBridgeOptions()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		return 2
+	}
+
+	fs := flag.NewFlagSet("diagnostics-diff", flag.ContinueOnError)
+	opts.registerFlags(fs)
+	base := fs.String("base", "", "Base revision to compare against (required)")
+	head := fs.String("head", "HEAD", "Head revision under review")
+	repo := fs.String("repo", "", "Directory inside the git repository (default: WORKSPACE_ROOT or the current directory)")
+	severity := fs.String("severity", "", "Minimum severity to include: error, warning, information or hint")
+	codes := fs.String("code", "", "Comma-separated diagnostic codes to include")
+	path := fs.String("path", "", "Glob over changed file paths to include")
+	maxFiles := fs.Int("max-files", tools.DefaultDiagnosticsDiffFiles, "Changed files to check")
+	format := fs.String("format", "text", "Output format: text or json")
+	timeout := fs.Duration("timeout", 30*time.Minute, "Maximum time for language server start and the diff")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *base == "" || fs.NArg() > 0 {
+		fmt.Fprintln(os.Stderr, "Usage: mcp-lsp-bridge diagnostics-diff -base <revision> [-head <revision>] [flags]")
+		return 2
+	}
+	if *format != "text" && *format != "json" {
+		fmt.Fprintf(os.Stderr, "ERROR: invalid -format %q: expected text or json\n", *format)
+		return 2
+	}
+
+	filter, err := tools.ParseDiagnosticsFilter(*severity, *codes, "", "")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		return 2
+	}
+
+	root := *repo
+	if root == "" {
+		root = os.Getenv("WORKSPACE_ROOT")
+	}
+	if root == "" {
+		root = "."
+	}
+	if root, err = filepath.Abs(root); err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		return 2
+	}
+
+	bridgeInstance, err := newBridge(opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		return 2
+	}
+	defer logger.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	ctx, cancel := context.WithTimeout(ctx, *timeout)
+	defer cancel()
+
+	if err := bridgeInstance.SyncAutoConnect(); err != nil {
+		logger.Warn("Some language servers failed to connect: " + err.Error())
+	}
+	defer bridgeInstance.CloseAllClients()
+
+	if err := tools.WaitReady(ctx, bridgeInstance); err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		return 2
+	}
+
+	result, err := tools.DiffDiagnostics(ctx, bridgeInstance, tools.DiagnosticsDiffOptions{
+		Repo:     root,
+		Base:     *base,
+		Head:     *head,
+		Path:     *path,
+		Filter:   filter,
+		MaxFiles: *maxFiles,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		return 2
+	}
+
+	if *format == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(result); err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+			return 2
+		}
+	} else {
+		fmt.Print(tools.FormatDiagnosticsDiff(result))
+	}
+
+	if len(result.Added) > 0 {
+		return 1
+	}
+	return 0
+}
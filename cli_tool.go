@@ -0,0 +1,273 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"rockerboo/mcp-lsp-bridge/logger"
+	"rockerboo/mcp-lsp-bridge/mcpserver"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// Output formats of the tool subcommand
+const (
+	toolOutputText = "text"
+	toolOutputJSON = "json"
+)
+
+// toolRegistry collects the MCP tools so the CLI can call their handlers directly
+type toolRegistry struct {
+	tools map[string]server.ServerTool
+	names []string
+}
+
+func newToolRegistry() *toolRegistry {
+	return &toolRegistry{tools: make(map[string]server.ServerTool)}
+}
+
+// AddTool implements tools.ToolServer
+func (r *toolRegistry) AddTool(tool mcp.Tool, handler server.ToolHandlerFunc) {
+	if _, ok := r.tools[tool.Name]; !ok {
+		r.names = append(r.names, tool.Name)
+	}
+	r.tools[tool.Name] = server.ServerTool{Tool: tool, Handler: handler}
+}
+
+// argList is a repeatable --arg key=value flag
+type argList []string
+
+func (a *argList) String() string { return strings.Join(*a, ",") }
+
+func (a *argList) Set(value string) error {
+	if !strings.Contains(value, "=") {
+		return fmt.Errorf("expected key=value, got %q", value)
+	}
+	*a = append(*a, value)
+	return nil
+}
+
+// toolArguments builds the call arguments from --args-json and the --arg pairs.
+// Pairs win over the JSON object and are converted using the tool input schema.
+func toolArguments(tool mcp.Tool, argsJSON string, pairs []string) (map[string]any, error) {
+	arguments := map[string]any{}
+	if argsJSON != "" {
+		if err := json.Unmarshal([]byte(argsJSON), &arguments); err != nil {
+			return nil, fmt.Errorf("invalid --args-json: %w", err)
+		}
+	}
+
+	for _, pair := range pairs {
+		key, raw, _ := strings.Cut(pair, "=")
+		value, err := convertToolArgument(tool, key, raw)
+		if err != nil {
+			return nil, err
+		}
+		arguments[key] = value
+	}
+
+	for _, name := range tool.InputSchema.Required {
+		if _, ok := arguments[name]; !ok {
+			return nil, fmt.Errorf("missing required argument %q", name)
+		}
+	}
+
+	return arguments, nil
+}
+
+// convertToolArgument parses raw according to the JSON schema type of the property
+func convertToolArgument(tool mcp.Tool, key, raw string) (any, error) {
+	property, ok := tool.InputSchema.Properties[key].(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("tool %s has no argument %q", tool.Name, key)
+	}
+
+	kind, _ := property["type"].(string)
+	switch kind {
+	case "number", "integer":
+		value, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, fmt.Errorf("argument %q: expected a number, got %q", key, raw)
+		}
+		return value, nil
+	case "boolean":
+		value, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("argument %q: expected true or false, got %q", key, raw)
+		}
+		return value, nil
+	case "array", "object":
+		var value any
+		if err := json.Unmarshal([]byte(raw), &value); err != nil {
+			return nil, fmt.Errorf("argument %q: expected JSON %s: %w", key, kind, err)
+		}
+		return value, nil
+	default:
+		return raw, nil
+	}
+}
+
+// writeToolResult prints the text content of result, or the whole result as JSON
+func writeToolResult(w io.Writer, result *mcp.CallToolResult, output string) error {
+	if output == toolOutputJSON {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(result)
+	}
+
+	for _, content := range result.Content {
+		switch c := content.(type) {
+		case mcp.TextContent:
+			if _, err := fmt.Fprintln(w, c.Text); err != nil {
+				return err
+			}
+		default:
+			data, err := json.Marshal(c)
+			if err != nil {
+				return err
+			}
+			if _, err := fmt.Fprintln(w, string(data)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// runTool calls one MCP tool without an MCP client:
+// mcp-lsp-bridge tool hover --arg uri=file:///p/Module.bsl --arg line=10 --arg character=4
+//
+// Exit codes: 0 success, 1 the tool returned an error, 2 usage error.
+func runTool(args []string) int {
+	opts, err := defaultBridgeOptions()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		return 1
+	}
+
+	fs := flag.NewFlagSet("tool", flag.ContinueOnError)
+	opts.registerFlags(fs)
+	var pairs argList
+	fs.Var(&pairs, "arg", "Tool argument as key=value, repeatable; values follow the tool schema types")
+	argsJSON := fs.String("args-json", "", "Tool arguments as a JSON object")
+	output := fs.String("output", toolOutputText, "Output format: text or json")
+	timeout := fs.Duration("timeout", 10*time.Minute, "Maximum time for the call, including language server start")
+	list := fs.Bool("list", false, "List the available tools and exit")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: mcp-lsp-bridge tool [flags] <name> [--arg key=value ...]")
+		fs.PrintDefaults()
+	}
+
+	// Flags are accepted both before and after the tool name
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	var name string
+	if fs.NArg() > 0 {
+		name = fs.Arg(0)
+		if err := fs.Parse(fs.Args()[1:]); err != nil {
+			return 2
+		}
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(os.Stderr, "ERROR: unexpected arguments: %s\n", strings.Join(fs.Args(), " "))
+		return 2
+	}
+	if *output != toolOutputText && *output != toolOutputJSON {
+		fmt.Fprintf(os.Stderr, "ERROR: invalid --output %q: expected text or json\n", *output)
+		return 2
+	}
+
+	bridgeInstance, err := newBridge(opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		return 1
+	}
+	defer logger.Close()
+
+	registry := newToolRegistry()
+	mcpserver.RegisterAllTools(registry, bridgeInstance)
+
+	if *list {
+		names := append([]string(nil), registry.names...)
+		sort.Strings(names)
+		for _, n := range names {
+			fmt.Printf("%s\t%s\n", n, firstLine(registry.tools[n].Tool.Description))
+		}
+		return 0
+	}
+	if name == "" {
+		fs.Usage()
+		return 2
+	}
+
+	tool, ok := registry.tools[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "ERROR: unknown tool %q; use --list to see the available tools\n", name)
+		return 2
+	}
+
+	arguments, err := toolArguments(tool.Tool, *argsJSON, pairs)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		return 2
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	ctx, cancel := context.WithTimeout(ctx, *timeout)
+	defer cancel()
+
+	if err := bridgeInstance.SyncAutoConnect(); err != nil {
+		logger.Warn("Some language servers failed to connect: " + err.Error())
+	}
+	defer bridgeInstance.CloseAllClients()
+
+	handler := mcpserver.ToolCallMiddleware(mcpserver.HostPathMiddleware(bridgeInstance)(tool.Handler))
+	result, err := handler(ctx, mcp.CallToolRequest{
+		Request: mcp.Request{Method: "tools/call"},
+		Params:  mcp.CallToolParams{Name: name, Arguments: arguments},
+	})
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			err = fmt.Errorf("%s timed out after %s", name, *timeout)
+		}
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		return 1
+	}
+	if result == nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %s returned no result\n", name)
+		return 1
+	}
+
+	out := io.Writer(os.Stdout)
+	if result.IsError && *output == toolOutputText {
+		out = os.Stderr
+	}
+	if err := writeToolResult(out, result, *output); err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		return 1
+	}
+	if result.IsError {
+		return 1
+	}
+	return 0
+}
+
+// firstLine returns the first line of a tool description for --list
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(s), "\n")
+	return line
+}
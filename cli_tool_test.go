@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToolArguments(t *testing.T) {
+	tool := mcp.NewTool("sample",
+		mcp.WithString("uri", mcp.Required()),
+		mcp.WithNumber("line"),
+		mcp.WithBoolean("apply"),
+		mcp.WithArray("kinds"),
+	)
+
+	args, err := toolArguments(tool, `{"uri":"file:///a.bsl","line":1}`,
+		[]string{"line=10", "apply=true", `kinds=["method"]`, "uri=file:///b.bsl?x=1"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		"uri":   "file:///b.bsl?x=1",
+		"line":  float64(10),
+		"apply": true,
+		"kinds": []any{"method"},
+	}, args)
+
+	_, err = toolArguments(tool, "", []string{"line=ten", "uri=x"})
+	assert.ErrorContains(t, err, "expected a number")
+
+	_, err = toolArguments(tool, "", []string{"depth=2", "uri=x"})
+	assert.ErrorContains(t, err, "has no argument")
+
+	_, err = toolArguments(tool, "", nil)
+	assert.ErrorContains(t, err, `missing required argument "uri"`)
+}
+
+func TestToolRegistryAndOutput(t *testing.T) {
+	registry := newToolRegistry()
+	registry.AddTool(mcp.NewTool("b"), nil)
+	registry.AddTool(mcp.NewTool("a"), nil)
+	registry.AddTool(mcp.NewTool("b"), nil)
+	assert.Equal(t, []string{"b", "a"}, registry.names)
+
+	var text bytes.Buffer
+	require.NoError(t, writeToolResult(&text, mcp.NewToolResultText("ok"), toolOutputText))
+	assert.Equal(t, "ok\n", text.String())
+
+	var js bytes.Buffer
+	require.NoError(t, writeToolResult(&js, mcp.NewToolResultError("boom"), toolOutputJSON))
+	assert.Contains(t, js.String(), `"isError": true`)
+}
@@ -0,0 +1,264 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"strings"
+	"sync"
+
+	"rockerboo/mcp-lsp-bridge/logger"
+)
+
+// defaultMaxConcurrentRequests limits in-flight requests per API connection
+const defaultMaxConcurrentRequests = 16
+
+// codeRequestCancelled is the LSP error code for a request cancelled by the client
+const codeRequestCancelled = -32800
+
+// apiRequest is one newline-delimited JSON-RPC request from mcp-lsp-bridge
+type apiRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      JSONRPCID       `json:"id"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+
+	// CorrelationID identifies the MCP tool call that sent the request (not JSON-RPC)
+	CorrelationID string `json:"correlationId,omitempty"`
+}
+
+// apiLogSampler thins out the log of polled and typing-rate methods; failures are always logged
+var apiLogSampler = logger.NewSampler(logger.DefaultSampling)
+
+// apiConn is an API client connection. Requests are dispatched concurrently
+// and answered out of order; responses are matched by id on the client side.
+type apiConn struct {
+	conn    net.Conn
+	writeMu sync.Mutex    // serializes response lines
+	slots   chan struct{} // per-connection concurrency limit
+	wg      sync.WaitGroup
+
+	inflightMu sync.Mutex
+	inflight   map[string]context.CancelFunc // request id -> cancel of its handler
+}
+
+func newAPIConn(conn net.Conn, maxConcurrent int) *apiConn {
+	if maxConcurrent < 1 {
+		maxConcurrent = 1
+	}
+	return &apiConn{
+		conn:     conn,
+		slots:    make(chan struct{}, maxConcurrent),
+		inflight: make(map[string]context.CancelFunc),
+	}
+}
+
+// track registers a request so $/cancelRequest can find it; the returned func unregisters it
+func (c *apiConn) track(ctx context.Context, id JSONRPCID) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	if !id.IsSet() {
+		return ctx, cancel
+	}
+
+	key := id.String()
+	c.inflightMu.Lock()
+	c.inflight[key] = cancel
+	c.inflightMu.Unlock()
+
+	return ctx, func() {
+		c.inflightMu.Lock()
+		delete(c.inflight, key)
+		c.inflightMu.Unlock()
+		cancel()
+	}
+}
+
+// cancel cancels an in-flight request by its client id. The handler then forwards
+// $/cancelRequest upstream with the id it used towards the language server.
+func (c *apiConn) cancel(params json.RawMessage) {
+	var p struct {
+		ID JSONRPCID `json:"id"`
+	}
+	if err := json.Unmarshal(params, &p); err != nil || !p.ID.IsSet() {
+		slog.Warn("Ignoring $/cancelRequest with invalid params", "params", string(params))
+		return
+	}
+
+	c.inflightMu.Lock()
+	cancel, ok := c.inflight[p.ID.String()]
+	c.inflightMu.Unlock()
+
+	if !ok {
+		// Already answered, the response and the cancel crossed on the wire
+		return
+	}
+	slog.Info("Cancelling request", "id", p.ID.String())
+	cancel()
+}
+
+// write sends one response line; concurrent handlers never interleave their output
+func (c *apiConn) write(resp map[string]interface{}) {
+	respJSON, err := json.Marshal(resp)
+	if err != nil {
+		slog.Error("Error marshaling response", "error", err)
+		return
+	}
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	n, err := c.conn.Write(append(respJSON, '\n'))
+	if err != nil {
+		slog.Warn("Error writing response", "remote", c.conn.RemoteAddr().String(), "error", err)
+		return
+	}
+	slog.Debug("Sent response", "remote", c.conn.RemoteAddr().String(), "bytes", n, "id", fmt.Sprint(resp["id"]))
+}
+
+// isOrderedMethod reports whether a method must be handled before the next line is read.
+// Document sync and other notifications change server state, so a request sent after
+// them on the same connection must observe their effect.
+func isOrderedMethod(method string) bool {
+	switch method {
+	case "textDocument/didOpen", "textDocument/didChange", "textDocument/didSave", "textDocument/didClose":
+		return true
+	}
+	return forwardedNotifications[method]
+}
+
+// HandleClient handles an API client connection
+func (sm *SessionManager) HandleClient(conn net.Conn) {
+	// Abandon upstream requests of this client once it disconnects
+	ctx, cancel := context.WithCancel(context.Background())
+
+	c := newAPIConn(conn, sm.maxConcurrent)
+	defer func() {
+		cancel()
+		c.wg.Wait()
+		conn.Close()
+	}()
+	slog.Info("API client connected", "remote", conn.RemoteAddr().String())
+
+	reader := bufio.NewReader(conn)
+
+	for {
+		// Read JSON-RPC request (newline-delimited)
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			if err != io.EOF {
+				slog.Warn("Client read error", "remote", conn.RemoteAddr().String(), "error", err)
+			} else {
+				slog.Info("Client closed connection", "remote", conn.RemoteAddr().String())
+			}
+			break
+		}
+
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		var req apiRequest
+		if err := json.Unmarshal([]byte(line), &req); err != nil {
+			slog.Warn("Parse error for request", "remote", conn.RemoteAddr().String(), "bytes", len(line), "error", err)
+			c.write(apiErrorResponse(JSONRPCID{}, -32700, "Parse error"))
+			continue
+		}
+
+		if apiLogSampler.Allow(req.Method) {
+			// Params are redacted: didOpen and didChange carry whole documents
+			slog.Debug("Received request", "remote", conn.RemoteAddr().String(), "method", req.Method,
+				"id", req.ID.String(), logger.CorrelationKey, req.CorrelationID, "params", logger.RedactJSON(req.Params))
+		}
+
+		if req.Method == "$/cancelRequest" {
+			c.cancel(req.Params)
+			continue
+		}
+
+		// Register before dispatching so a $/cancelRequest on the next line finds the request
+		reqCtx, done := c.track(ctx, req.ID)
+
+		if isOrderedMethod(req.Method) {
+			sm.serveAPIRequest(reqCtx, c, req)
+			done()
+			continue
+		}
+
+		// The slot is acquired by the handler, so the loop keeps reading cancels and
+		// document sync while the connection is at its limit
+		c.wg.Add(1)
+		go func() {
+			defer c.wg.Done()
+			defer done()
+
+			apiQueueDepth.Add(1)
+			select {
+			case c.slots <- struct{}{}:
+				apiQueueDepth.Add(-1)
+			case <-reqCtx.Done():
+				apiQueueDepth.Add(-1)
+				// Nobody reads the answer once the client is gone
+				if req.ID.IsSet() && ctx.Err() == nil {
+					c.write(apiErrorResponse(req.ID, codeRequestCancelled, "Request cancelled"))
+				}
+				return
+			}
+			defer func() { <-c.slots }()
+			sm.serveAPIRequest(reqCtx, c, req)
+		}()
+	}
+
+	slog.Info("API client disconnected", "remote", conn.RemoteAddr().String())
+}
+
+// serveAPIRequest handles one request and writes its response. ctx is the one
+// returned by apiConn.track, so $/cancelRequest reaches the handler.
+func (sm *SessionManager) serveAPIRequest(ctx context.Context, c *apiConn, req apiRequest) {
+	ctx = logger.WithCorrelationID(ctx, req.CorrelationID)
+	apiInflight.Add(1)
+	defer apiInflight.Add(-1)
+
+	result, err := sm.handleAPIRequest(ctx, req.Method, req.Params)
+
+	// Requests without an id are notifications and get no response
+	if !req.ID.IsSet() {
+		if err != nil {
+			slog.WarnContext(ctx, "Error handling notification", "method", req.Method, "error", err)
+		}
+		return
+	}
+
+	if err != nil {
+		slog.WarnContext(ctx, "Error handling request", "method", req.Method, "id", req.ID.String(), "error", err)
+		if errors.Is(err, context.Canceled) && ctx.Err() != nil {
+			c.write(apiErrorResponse(req.ID, codeRequestCancelled, "Request cancelled"))
+			return
+		}
+		c.write(apiErrorResponse(req.ID, -32603, err.Error()))
+		return
+	}
+
+	c.write(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      req.ID,
+		"result":  result,
+	})
+}
+
+// apiErrorResponse builds an error response for an API client
+func apiErrorResponse(id JSONRPCID, code int, message string) map[string]interface{} {
+	return map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      id,
+		"error": map[string]interface{}{
+			"code":    code,
+			"message": message,
+		},
+	}
+}
@@ -0,0 +1,852 @@
+package main
+
+import (
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"rockerboo/mcp-lsp-bridge/directories"
+	"rockerboo/mcp-lsp-bridge/utils"
+)
+
+// callGraphIndexVersion is bumped whenever the on-disk format changes; older files are rebuilt
+const callGraphIndexVersion = 1
+
+// Call graph index states reported in session/status
+const (
+	callGraphStateEmpty    = "empty"
+	callGraphStateBuilding = "building"
+	callGraphStateReady    = "ready"
+)
+
+const (
+	callGraphIndexWorkers   = 2                      // leave room for interactive requests
+	callGraphReindexQuiet   = 3 * time.Second        // let the server reparse a changed file first
+	callGraphReindexTick    = 2 * time.Second        // how often dirty files are checked
+	callGraphSaveEvery      = 500                    // files indexed between intermediate saves
+	callGraphRequestTimeout = 60 * time.Second       // per LSP request while indexing
+	callGraphStartupDelay   = 500 * time.Millisecond // between indexing-finished checks
+	callGraphMaxAttempts    = 3                      // a file failing this often is left out
+	callGraphPatchFiles     = 8                      // dirty files re-indexed to complete an incoming query
+)
+
+// CallGraphIndex keeps the outgoing calls of every method in the workspace,
+// as reported by callHierarchy/outgoingCalls, plus the reverse (incoming) edges.
+//
+// Methods are keyed by file path and lower-cased name: BSL names are case-insensitive
+// and unique within a module, and the key survives edits that only move lines.
+// A file changed on disk or through the API loses its edges and stays dirty until
+// it is indexed again. Outgoing queries for a dirty file are answered live by the
+// caller; incoming queries re-index the dirty files to find their callers.
+type CallGraphIndex struct {
+	path string // gzipped JSON on disk
+
+	mu       sync.RWMutex
+	files    map[string]*indexedFile    // file path -> methods and their calls
+	incoming map[string][]indexedCaller // callee key -> callers
+	dirty    map[string]time.Time       // file path -> last change
+	attempts map[string]int             // file path -> failed indexing attempts
+	state    string
+	builtAt  time.Time
+	total    int // files to index in the current build
+	indexed  int
+	failed   int
+}
+
+// indexedFile is the call graph data of one module
+type indexedFile struct {
+	ModTime int64           `json:"mtime"` // 0 when indexed from an unsaved buffer
+	Size    int64           `json:"size"`
+	Methods []indexedMethod `json:"methods"`
+}
+
+// indexedMethod is a method with the CallHierarchyItem the server returned for it
+type indexedMethod struct {
+	Key   string          `json:"key"`
+	Item  json.RawMessage `json:"item"`
+	Calls []indexedCall   `json:"calls,omitempty"`
+}
+
+// indexedCall is one callHierarchy/outgoingCalls entry
+type indexedCall struct {
+	To         json.RawMessage `json:"to"`
+	ToKey      string          `json:"toKey"`
+	FromRanges json.RawMessage `json:"fromRanges"`
+}
+
+// indexedCaller is the reverse of indexedCall, built in memory
+type indexedCaller struct {
+	File       string
+	From       json.RawMessage
+	FromRanges json.RawMessage
+}
+
+// callGraphIndexFile is the on-disk representation
+type callGraphIndexFile struct {
+	Version   int                     `json:"version"`
+	Workspace string                  `json:"workspace"`
+	BuiltAt   time.Time               `json:"builtAt"`
+	Files     map[string]*indexedFile `json:"files"`
+}
+
+// callGraphQuery is the params object of session/callGraph
+type callGraphQuery struct {
+	Direction string          `json:"direction"` // incoming or outgoing
+	Item      json.RawMessage `json:"item"`      // CallHierarchyItem
+}
+
+// NewCallGraphIndex creates an empty index persisted at path ("" keeps it in memory only)
+func NewCallGraphIndex(path string) *CallGraphIndex {
+	return &CallGraphIndex{
+		path:     path,
+		files:    make(map[string]*indexedFile),
+		incoming: make(map[string][]indexedCaller),
+		dirty:    make(map[string]time.Time),
+		attempts: make(map[string]int),
+		state:    callGraphStateEmpty,
+	}
+}
+
+// callGraphKey identifies a method across files
+func callGraphKey(uri, name string) string {
+	return utils.URIToFilePath(uri) + "#" + strings.ToLower(name)
+}
+
+// callGraphItemKey extracts the key of a CallHierarchyItem
+func callGraphItemKey(item json.RawMessage) (key string, path string, err error) {
+	var it struct {
+		Name string `json:"name"`
+		URI  string `json:"uri"`
+	}
+	if err := json.Unmarshal(item, &it); err != nil {
+		return "", "", err
+	}
+	if it.URI == "" || it.Name == "" {
+		return "", "", fmt.Errorf("call hierarchy item without uri or name")
+	}
+	return callGraphKey(it.URI, it.Name), utils.URIToFilePath(it.URI), nil
+}
+
+// Load reads a previously saved index. Files are kept as they were saved;
+// buildCallGraphIndex decides which of them are still up to date.
+func (ix *CallGraphIndex) Load(workspace string) error {
+	if ix.path == "" {
+		return nil
+	}
+	f, err := os.Open(ix.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer f.Close()
+
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+
+	var data callGraphIndexFile
+	if err := json.NewDecoder(zr).Decode(&data); err != nil {
+		return err
+	}
+	if data.Version != callGraphIndexVersion || data.Workspace != workspace {
+		slog.Info("Call graph index: ignoring stale file", "path", ix.path, "version", data.Version, "workspace", data.Workspace)
+		return nil
+	}
+
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	for path, file := range data.Files {
+		ix.putFileLocked(path, file)
+	}
+	ix.builtAt = data.BuiltAt
+	return nil
+}
+
+// Save writes the index atomically
+func (ix *CallGraphIndex) Save(workspace string) error {
+	if ix.path == "" {
+		return nil
+	}
+
+	ix.mu.RLock()
+	data := callGraphIndexFile{
+		Version:   callGraphIndexVersion,
+		Workspace: workspace,
+		BuiltAt:   ix.builtAt,
+		Files:     make(map[string]*indexedFile, len(ix.files)),
+	}
+	for path, file := range ix.files {
+		data.Files[path] = file
+	}
+	ix.mu.RUnlock()
+
+	if err := os.MkdirAll(filepath.Dir(ix.path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(ix.path), ".callgraph-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	zw := gzip.NewWriter(tmp)
+	if err := json.NewEncoder(zw).Encode(&data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), ix.path)
+}
+
+// putFileLocked stores a file and its reverse edges, replacing any previous version
+func (ix *CallGraphIndex) putFileLocked(path string, file *indexedFile) {
+	ix.removeFileLocked(path)
+	ix.files[path] = file
+	for _, m := range file.Methods {
+		for _, c := range m.Calls {
+			ix.incoming[c.ToKey] = append(ix.incoming[c.ToKey], indexedCaller{File: path, From: m.Item, FromRanges: c.FromRanges})
+		}
+	}
+}
+
+// removeFileLocked drops a file and the incoming edges it contributed
+func (ix *CallGraphIndex) removeFileLocked(path string) {
+	old, ok := ix.files[path]
+	if !ok {
+		return
+	}
+	delete(ix.files, path)
+
+	for _, m := range old.Methods {
+		for _, c := range m.Calls {
+			callers := ix.incoming[c.ToKey]
+			kept := callers[:0]
+			for _, caller := range callers {
+				if caller.File != path {
+					kept = append(kept, caller)
+				}
+			}
+			if len(kept) == 0 {
+				delete(ix.incoming, c.ToKey)
+			} else {
+				ix.incoming[c.ToKey] = kept
+			}
+		}
+	}
+}
+
+// Put stores freshly indexed data for a file and clears its dirty mark,
+// unless the file changed again after indexing started
+func (ix *CallGraphIndex) Put(path string, file *indexedFile, startedAt time.Time) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	if changedAt, ok := ix.dirty[path]; ok && changedAt.After(startedAt) {
+		return
+	}
+	delete(ix.dirty, path)
+	delete(ix.attempts, path)
+	ix.putFileLocked(path, file)
+}
+
+// Fail records a failed indexing attempt. The file stays dirty and is retried
+// until callGraphMaxAttempts; after that it is reported as failed and its callers
+// are missing from incoming results.
+func (ix *CallGraphIndex) Fail(path string, now time.Time) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	ix.removeFileLocked(path)
+	ix.attempts[path]++
+	if ix.attempts[path] >= callGraphMaxAttempts {
+		delete(ix.dirty, path)
+		return
+	}
+	ix.dirty[path] = now
+}
+
+// Remove forgets a deleted file
+func (ix *CallGraphIndex) Remove(path string) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	delete(ix.dirty, path)
+	delete(ix.attempts, path)
+	ix.removeFileLocked(path)
+}
+
+// Invalidate drops the edges of a changed file and marks it dirty until it is indexed again
+func (ix *CallGraphIndex) Invalidate(path string, now time.Time) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	ix.removeFileLocked(path)
+	delete(ix.attempts, path)
+	ix.dirty[path] = now
+}
+
+// Dirty returns the dirty files whose last change is older than quiet
+func (ix *CallGraphIndex) Dirty(now time.Time, quiet time.Duration) []string {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	var paths []string
+	for path, changedAt := range ix.dirty {
+		if now.Sub(changedAt) >= quiet {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// Outgoing returns the indexed outgoing calls of item. ok is false when the
+// caller has to ask the language server: the file is dirty or not indexed yet.
+func (ix *CallGraphIndex) Outgoing(item json.RawMessage) (calls []map[string]interface{}, ok bool, err error) {
+	key, path, err := callGraphItemKey(item)
+	if err != nil {
+		return nil, false, err
+	}
+
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	// Before the first build, files loaded from disk may be outdated
+	if ix.state == callGraphStateEmpty {
+		return nil, false, nil
+	}
+	if _, dirty := ix.dirty[path]; dirty {
+		return nil, false, nil
+	}
+	file, indexed := ix.files[path]
+	if !indexed {
+		return nil, false, nil
+	}
+
+	calls = []map[string]interface{}{}
+	for _, m := range file.Methods {
+		if m.Key != key {
+			continue
+		}
+		for _, c := range m.Calls {
+			calls = append(calls, map[string]interface{}{"to": c.To, "fromRanges": c.FromRanges})
+		}
+		break
+	}
+	return calls, true, nil
+}
+
+// Incoming returns the indexed callers of item and the dirty files, whose callers
+// are missing from calls. ok is false while the workspace is not fully indexed.
+func (ix *CallGraphIndex) Incoming(item json.RawMessage) (calls []map[string]interface{}, dirty []string, ok bool, err error) {
+	key, _, err := callGraphItemKey(item)
+	if err != nil {
+		return nil, nil, false, err
+	}
+
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	if ix.state != callGraphStateReady {
+		return nil, nil, false, nil
+	}
+
+	calls = []map[string]interface{}{}
+	for _, caller := range ix.incoming[key] {
+		calls = append(calls, map[string]interface{}{"from": caller.From, "fromRanges": caller.FromRanges})
+	}
+	for path := range ix.dirty {
+		dirty = append(dirty, path)
+	}
+	sort.Strings(dirty)
+	return calls, dirty, true, nil
+}
+
+// incomingCallers returns the calls to key made by the methods of file
+func incomingCallers(file *indexedFile, key string) []map[string]interface{} {
+	var calls []map[string]interface{}
+	for _, m := range file.Methods {
+		for _, c := range m.Calls {
+			if c.ToKey == key {
+				calls = append(calls, map[string]interface{}{"from": m.Item, "fromRanges": c.FromRanges})
+			}
+		}
+	}
+	return calls
+}
+
+// Status summarizes the index for session/status
+func (ix *CallGraphIndex) Status() map[string]interface{} {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	failed := 0
+	for _, n := range ix.attempts {
+		if n >= callGraphMaxAttempts {
+			failed++
+		}
+	}
+
+	methods, edges := 0, 0
+	for _, file := range ix.files {
+		methods += len(file.Methods)
+		for _, m := range file.Methods {
+			edges += len(m.Calls)
+		}
+	}
+
+	status := map[string]interface{}{
+		"state":      ix.state,
+		"files":      len(ix.files),
+		"methods":    methods,
+		"edges":      edges,
+		"dirtyFiles": len(ix.dirty),
+	}
+	if failed > 0 {
+		status["failedFiles"] = failed
+	}
+	if ix.state == callGraphStateBuilding {
+		status["progress"] = map[string]interface{}{"indexed": ix.indexed, "total": ix.total, "failed": ix.failed}
+	}
+	if !ix.builtAt.IsZero() {
+		status["builtAt"] = ix.builtAt.UTC().Format(time.RFC3339)
+	}
+	return status
+}
+
+func (ix *CallGraphIndex) setState(state string) {
+	ix.mu.Lock()
+	ix.state = state
+	if state == callGraphStateReady {
+		ix.builtAt = time.Now()
+	}
+	ix.mu.Unlock()
+}
+
+// upToDate reports whether path was indexed from the same file on disk
+func (ix *CallGraphIndex) upToDate(path string, info os.FileInfo) bool {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	if _, dirty := ix.dirty[path]; dirty {
+		return false
+	}
+	file, ok := ix.files[path]
+	return ok && file.ModTime != 0 && file.ModTime == info.ModTime().UnixNano() && file.Size == info.Size()
+}
+
+// indexedPaths returns the files currently in the index
+func (ix *CallGraphIndex) indexedPaths() []string {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	paths := make([]string, 0, len(ix.files))
+	for path := range ix.files {
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+// callGraphIndexPath returns the index file in the per-workspace cache directory.
+// An empty cacheDir resolves to the user cache directory of mcp-lsp-bridge.
+func callGraphIndexPath(cacheDir, workspace string) (string, error) {
+	if cacheDir == "" {
+		resolver := directories.NewDirectoryResolver("mcp-lsp-bridge", directories.DefaultUserProvider{}, directories.DefaultEnvProvider{}, false)
+		dir, err := resolver.GetCacheDirectory()
+		if err != nil {
+			return "", err
+		}
+		cacheDir = dir
+	}
+
+	abs, err := filepath.Abs(workspace)
+	if err != nil {
+		abs = workspace
+	}
+	sum := sha256.Sum256([]byte(abs))
+	name := filepath.Base(abs) + "-" + hex.EncodeToString(sum[:])[:12]
+	return filepath.Join(cacheDir, "workspaces", name, "callgraph.json.gz"), nil
+}
+
+// runCallGraphIndexer indexes the workspace once the server finished its own indexing,
+// then keeps re-indexing files invalidated by watcher events and document edits.
+func (sm *SessionManager) runCallGraphIndexer(ctx context.Context) {
+	ix := sm.callGraph
+	if err := ix.Load(sm.workspaceDir); err != nil {
+		slog.Warn("Call graph index: failed to load", "path", ix.path, "error", err)
+	}
+
+	// The server answers call hierarchy from its own index, so wait for it
+	for sm.IsIndexing() || sm.processReady() != nil {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(callGraphStartupDelay):
+		}
+	}
+
+	if err := sm.buildCallGraphIndex(ctx); err != nil {
+		slog.Warn("Call graph index: build stopped", "error", err)
+		return
+	}
+
+	ticker := time.NewTicker(callGraphReindexTick)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if sm.IsIndexing() || sm.processReady() != nil || sm.changeJournal.Len() > 0 {
+			continue
+		}
+
+		paths := ix.Dirty(time.Now(), callGraphReindexQuiet)
+		if len(paths) == 0 {
+			continue
+		}
+		for _, path := range paths {
+			sm.reindexCallGraphFile(ctx, path)
+		}
+		if err := ix.Save(sm.workspaceDir); err != nil {
+			slog.Warn("Call graph index: save failed", "path", ix.path, "error", err)
+		}
+	}
+}
+
+// buildCallGraphIndex brings the index in line with the workspace on disk,
+// indexing only files that are new or changed since the index was saved
+func (sm *SessionManager) buildCallGraphIndex(ctx context.Context) error {
+	ix := sm.callGraph
+	started := time.Now()
+
+	onDisk := make(map[string]bool)
+	var queue []string
+	err := filepath.Walk(sm.workspaceDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info.IsDir() {
+			name := info.Name()
+			if path != sm.workspaceDir && (strings.HasPrefix(name, ".") || name == "node_modules" || name == "vendor") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		ext := strings.ToLower(filepath.Ext(path))
+		if ext != ".bsl" && ext != ".os" {
+			return nil
+		}
+		onDisk[path] = true
+		if !ix.upToDate(path, info) {
+			queue = append(queue, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, path := range ix.indexedPaths() {
+		if !onDisk[path] {
+			ix.Remove(path)
+		}
+	}
+	// Outdated entries must not be served while the build catches up
+	for _, path := range queue {
+		ix.Invalidate(path, started)
+	}
+
+	ix.mu.Lock()
+	ix.state = callGraphStateBuilding
+	ix.total, ix.indexed, ix.failed = len(queue), 0, 0
+	ix.mu.Unlock()
+	slog.Info("Call graph index: scanned workspace", "up_to_date", len(onDisk)-len(queue), "to_index", len(queue))
+
+	jobs := make(chan string)
+	var wg sync.WaitGroup
+	for i := 0; i < callGraphIndexWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for path := range jobs {
+				_, ok := sm.reindexCallGraphFile(ctx, path)
+
+				ix.mu.Lock()
+				ix.indexed++
+				if !ok {
+					ix.failed++
+				}
+				done := ix.indexed
+				ix.mu.Unlock()
+
+				if done%callGraphSaveEvery == 0 {
+					if err := ix.Save(sm.workspaceDir); err != nil {
+						slog.Warn("Call graph index: save failed", "path", ix.path, "error", err)
+					}
+				}
+			}
+		}()
+	}
+
+feed:
+	for _, path := range queue {
+		select {
+		case jobs <- path:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	ix.setState(callGraphStateReady)
+	if err := ix.Save(sm.workspaceDir); err != nil {
+		slog.Warn("Call graph index: save failed", "path", ix.path, "error", err)
+	}
+	slog.Info("Call graph index: ready", "duration_ms", time.Since(started).Milliseconds(), "status", ix.Status())
+	return nil
+}
+
+// reindexCallGraphFile indexes one file and stores the result. Files that fail
+// stay dirty (or unindexed) so their queries keep going to the server.
+// file is nil when the file was deleted.
+func (sm *SessionManager) reindexCallGraphFile(ctx context.Context, path string) (file *indexedFile, ok bool) {
+	startedAt := time.Now()
+
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		sm.callGraph.Remove(path)
+		return nil, true
+	}
+	if err != nil {
+		slog.Warn("Call graph index: failed to index file", "path", path, "error", err)
+		sm.callGraph.Fail(path, time.Now())
+		return nil, false
+	}
+
+	uri := "file://" + filepath.ToSlash(path)
+	file, err = sm.indexCallGraphFile(ctx, uri)
+	if err != nil {
+		if ctx.Err() == nil {
+			slog.Warn("Call graph index: failed to index file", "path", path, "error", err)
+			sm.callGraph.Fail(path, time.Now())
+		}
+		return nil, false
+	}
+
+	file.ModTime = info.ModTime().UnixNano()
+	file.Size = info.Size()
+	// An open buffer may differ from the disk, index it again after the next restart
+	sm.openDocsMu.Lock()
+	if _, open := sm.openDocs[uri]; open {
+		file.ModTime = 0
+	}
+	sm.openDocsMu.Unlock()
+
+	sm.callGraph.Put(path, file, startedAt)
+	return file, true
+}
+
+// indexCallGraphFile collects the outgoing calls of every method in a document
+func (sm *SessionManager) indexCallGraphFile(ctx context.Context, uri string) (*indexedFile, error) {
+	doc := map[string]interface{}{"uri": uri}
+
+	raw, err := sm.callGraphRequest(ctx, "textDocument/documentSymbol", map[string]interface{}{"textDocument": doc})
+	if err != nil {
+		return nil, fmt.Errorf("documentSymbol: %w", err)
+	}
+	var symbols []documentSymbolEntry
+	if len(raw) > 0 && string(raw) != "null" {
+		if err := json.Unmarshal(raw, &symbols); err != nil {
+			return nil, fmt.Errorf("documentSymbol: %w", err)
+		}
+	}
+
+	file := &indexedFile{Methods: []indexedMethod{}}
+	for _, pos := range methodPositions(symbols) {
+		raw, err := sm.callGraphRequest(ctx, "textDocument/prepareCallHierarchy", map[string]interface{}{
+			"textDocument": doc,
+			"position":     pos,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("prepareCallHierarchy: %w", err)
+		}
+		var items []json.RawMessage
+		if len(raw) > 0 && string(raw) != "null" {
+			if err := json.Unmarshal(raw, &items); err != nil {
+				return nil, fmt.Errorf("prepareCallHierarchy: %w", err)
+			}
+		}
+		if len(items) == 0 {
+			continue
+		}
+		item := items[0]
+		key, _, err := callGraphItemKey(item)
+		if err != nil {
+			continue
+		}
+
+		raw, err = sm.callGraphRequest(ctx, "callHierarchy/outgoingCalls", map[string]interface{}{"item": item})
+		if err != nil {
+			return nil, fmt.Errorf("outgoingCalls: %w", err)
+		}
+		var outgoing []struct {
+			To         json.RawMessage `json:"to"`
+			FromRanges json.RawMessage `json:"fromRanges"`
+		}
+		if len(raw) > 0 && string(raw) != "null" {
+			if err := json.Unmarshal(raw, &outgoing); err != nil {
+				return nil, fmt.Errorf("outgoingCalls: %w", err)
+			}
+		}
+
+		method := indexedMethod{Key: key, Item: item}
+		for _, call := range outgoing {
+			toKey, _, err := callGraphItemKey(call.To)
+			if err != nil {
+				continue
+			}
+			method.Calls = append(method.Calls, indexedCall{To: call.To, ToKey: toKey, FromRanges: call.FromRanges})
+		}
+		file.Methods = append(file.Methods, method)
+	}
+	return file, nil
+}
+
+func (sm *SessionManager) callGraphRequest(ctx context.Context, method string, params interface{}) (json.RawMessage, error) {
+	ctx, cancel := context.WithTimeout(ctx, callGraphRequestTimeout)
+	defer cancel()
+	return sm.sendRequest(ctx, method, params)
+}
+
+// documentSymbolEntry covers both DocumentSymbol and SymbolInformation results
+type documentSymbolEntry struct {
+	Kind           int                   `json:"kind"`
+	SelectionRange *lspRange             `json:"selectionRange"`
+	Location       *symbolLocation       `json:"location"`
+	Children       []documentSymbolEntry `json:"children"`
+}
+
+type symbolLocation struct {
+	Range lspRange `json:"range"`
+}
+
+type lspRange struct {
+	Start lspPosition `json:"start"`
+	End   lspPosition `json:"end"`
+}
+
+// LSP SymbolKind values of BSL procedures and functions
+const (
+	symbolKindMethod   = 6
+	symbolKindFunction = 12
+)
+
+// methodPositions returns the name position of every method symbol
+func methodPositions(symbols []documentSymbolEntry) []lspPosition {
+	var positions []lspPosition
+	for _, s := range symbols {
+		if s.Kind == symbolKindMethod || s.Kind == symbolKindFunction {
+			switch {
+			case s.SelectionRange != nil:
+				positions = append(positions, s.SelectionRange.Start)
+			case s.Location != nil:
+				positions = append(positions, s.Location.Range.Start)
+			}
+		}
+		positions = append(positions, methodPositions(s.Children)...)
+	}
+	return positions
+}
+
+// invalidateCallGraph marks files changed on disk or through the API
+func (sm *SessionManager) invalidateCallGraph(uris ...string) {
+	if sm.callGraph == nil {
+		return
+	}
+	now := time.Now()
+	for _, uri := range uris {
+		sm.callGraph.Invalidate(utils.URIToFilePath(uri), now)
+	}
+}
+
+// handleCallGraphQuery serves session/callGraph from the index
+func (sm *SessionManager) handleCallGraphQuery(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var q callGraphQuery
+	if err := json.Unmarshal(params, &q); err != nil {
+		return nil, fmt.Errorf("invalid session/callGraph params: %w", err)
+	}
+	if sm.callGraph == nil {
+		return map[string]interface{}{"indexed": false, "state": "disabled"}, nil
+	}
+
+	var (
+		calls   []map[string]interface{}
+		indexed bool
+		err     error
+	)
+	switch q.Direction {
+	case "incoming":
+		calls, indexed, err = sm.incomingCallGraph(ctx, q.Item)
+	case "outgoing":
+		calls, indexed, err = sm.callGraph.Outgoing(q.Item)
+	default:
+		return nil, fmt.Errorf("invalid direction %q: expected incoming or outgoing", q.Direction)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid session/callGraph item: %w", err)
+	}
+
+	result := map[string]interface{}{"indexed": indexed}
+	if indexed {
+		result["calls"] = calls
+	}
+	return result, nil
+}
+
+// incomingCallGraph answers incoming calls from the index and completes the answer by
+// re-indexing the dirty files. With more than callGraphPatchFiles dirty files, or when
+// one of them fails, the caller asks the server instead.
+func (sm *SessionManager) incomingCallGraph(ctx context.Context, item json.RawMessage) ([]map[string]interface{}, bool, error) {
+	calls, dirty, indexed, err := sm.callGraph.Incoming(item)
+	if err != nil || !indexed || len(dirty) == 0 {
+		return calls, indexed, err
+	}
+	if len(dirty) > callGraphPatchFiles || sm.processReady() != nil {
+		return nil, false, nil
+	}
+
+	key, _, _ := callGraphItemKey(item)
+	for _, path := range dirty {
+		file, ok := sm.reindexCallGraphFile(ctx, path)
+		if !ok {
+			return nil, false, nil
+		}
+		if file != nil {
+			calls = append(calls, incomingCallers(file, key)...)
+		}
+	}
+	return calls, true, nil
+}
@@ -0,0 +1,294 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// callItem builds the CallHierarchyItem JSON of a method
+func callItem(path, name string, line int) json.RawMessage {
+	return json.RawMessage(fmt.Sprintf(`{"name":%q,"kind":12,"uri":%q,"range":{"start":{"line":%d,"character":0},"end":{"line":%d,"character":0}}}`,
+		name, "file://"+filepath.ToSlash(path), line, line+3))
+}
+
+// callerFile is an indexed module with one method calling callee
+func callerFile(path, name string, callee json.RawMessage) *indexedFile {
+	from := callItem(path, name, 0)
+	key, _, _ := callGraphItemKey(from)
+	toKey, _, _ := callGraphItemKey(callee)
+	return &indexedFile{Methods: []indexedMethod{{
+		Key:   key,
+		Item:  from,
+		Calls: []indexedCall{{To: callee, ToKey: toKey, FromRanges: json.RawMessage(`[]`)}},
+	}}}
+}
+
+func callerNames(t *testing.T, calls []map[string]interface{}) []string {
+	t.Helper()
+	var names []string
+	for _, c := range calls {
+		var item struct {
+			Name string `json:"name"`
+		}
+		require.NoError(t, json.Unmarshal(c["from"].(json.RawMessage), &item))
+		names = append(names, item.Name)
+	}
+	return names
+}
+
+func TestCallGraphIndexOutgoingAndIncoming(t *testing.T) {
+	ix := NewCallGraphIndex("")
+	helper := callItem("/ws/Common.bsl", "Helper", 40)
+	main := callItem("/ws/Form.bsl", "Main", 0)
+	ix.Put("/ws/Form.bsl", callerFile("/ws/Form.bsl", "Main", helper), time.Now())
+
+	// Before the first build finishes the index does not answer
+	_, ok, err := ix.Outgoing(main)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	_, _, ok, err = ix.Incoming(helper)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	ix.setState(callGraphStateReady)
+
+	calls, ok, err := ix.Outgoing(main)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Len(t, calls, 1)
+	assert.JSONEq(t, string(helper), string(calls[0]["to"].(json.RawMessage)))
+
+	// Method names are case-insensitive
+	calls, dirty, ok, err := ix.Incoming(callItem("/ws/Common.bsl", "HELPER", 40))
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Empty(t, dirty)
+	assert.Equal(t, []string{"Main"}, callerNames(t, calls))
+
+	// Files that were never indexed go to the server
+	_, ok, err = ix.Outgoing(helper)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	_, _, err = ix.Outgoing(json.RawMessage(`{"name":"Main"}`))
+	assert.Error(t, err)
+}
+
+func TestCallGraphIndexInvalidate(t *testing.T) {
+	ix := NewCallGraphIndex("")
+	helper := callItem("/ws/Common.bsl", "Helper", 40)
+	ix.Put("/ws/Form.bsl", callerFile("/ws/Form.bsl", "Main", helper), time.Now())
+	ix.setState(callGraphStateReady)
+
+	changedAt := time.Now()
+	ix.Invalidate("/ws/Form.bsl", changedAt)
+
+	_, ok, err := ix.Outgoing(callItem("/ws/Form.bsl", "Main", 0))
+	require.NoError(t, err)
+	assert.False(t, ok, "a dirty file is answered live")
+
+	calls, dirty, ok, err := ix.Incoming(helper)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Empty(t, calls, "the edges of a dirty file are dropped")
+	assert.Equal(t, []string{"/ws/Form.bsl"}, dirty)
+
+	assert.Empty(t, ix.Dirty(changedAt.Add(time.Second), callGraphReindexQuiet), "still within the quiet period")
+	assert.Equal(t, []string{"/ws/Form.bsl"}, ix.Dirty(changedAt.Add(callGraphReindexQuiet), callGraphReindexQuiet))
+
+	// Indexing that started before the change does not clear it
+	ix.Put("/ws/Form.bsl", callerFile("/ws/Form.bsl", "Main", helper), changedAt.Add(-time.Second))
+	_, dirty, _, _ = ix.Incoming(helper)
+	assert.Equal(t, []string{"/ws/Form.bsl"}, dirty)
+
+	ix.Put("/ws/Form.bsl", callerFile("/ws/Form.bsl", "Main", helper), changedAt.Add(time.Second))
+	calls, dirty, _, _ = ix.Incoming(helper)
+	assert.Empty(t, dirty)
+	assert.Equal(t, []string{"Main"}, callerNames(t, calls))
+}
+
+func TestCallGraphIndexFailGivesUp(t *testing.T) {
+	ix := NewCallGraphIndex("")
+	ix.setState(callGraphStateReady)
+	now := time.Now()
+
+	for i := 1; i < callGraphMaxAttempts; i++ {
+		ix.Fail("/ws/Broken.bsl", now)
+		assert.Equal(t, []string{"/ws/Broken.bsl"}, ix.Dirty(now, 0), "attempt %d is retried", i)
+	}
+	assert.NotContains(t, ix.Status(), "failedFiles")
+
+	ix.Fail("/ws/Broken.bsl", now)
+	assert.Empty(t, ix.Dirty(now, 0), "the file is left out after callGraphMaxAttempts")
+	assert.Equal(t, 1, ix.Status()["failedFiles"])
+
+	// A change gives the file a new chance
+	ix.Invalidate("/ws/Broken.bsl", now)
+	assert.Equal(t, []string{"/ws/Broken.bsl"}, ix.Dirty(now, 0))
+	assert.NotContains(t, ix.Status(), "failedFiles")
+}
+
+func TestCallGraphIndexSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "workspaces", "ws", "callgraph.json.gz")
+	helper := callItem("/ws/Common.bsl", "Helper", 40)
+
+	saved := NewCallGraphIndex(path)
+	saved.Put("/ws/Form.bsl", callerFile("/ws/Form.bsl", "Main", helper), time.Now())
+	saved.setState(callGraphStateReady)
+	require.NoError(t, saved.Save("/ws"))
+
+	loaded := NewCallGraphIndex(path)
+	require.NoError(t, loaded.Load("/ws"))
+	assert.Equal(t, []string{"/ws/Form.bsl"}, loaded.indexedPaths())
+	assert.Equal(t, saved.Status()["builtAt"], loaded.Status()["builtAt"])
+
+	// Loaded files answer once a build confirmed them
+	loaded.setState(callGraphStateReady)
+	calls, _, ok, err := loaded.Incoming(helper)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, []string{"Main"}, callerNames(t, calls))
+
+	// An index saved for another workspace is ignored
+	other := NewCallGraphIndex(path)
+	require.NoError(t, other.Load("/elsewhere"))
+	assert.Empty(t, other.indexedPaths())
+
+	// A missing file is an empty index, a corrupt one an error
+	require.NoError(t, NewCallGraphIndex(filepath.Join(t.TempDir(), "missing.json.gz")).Load("/ws"))
+	require.NoError(t, os.WriteFile(path, []byte("not gzip"), 0o644))
+	assert.Error(t, NewCallGraphIndex(path).Load("/ws"))
+}
+
+// fakeCallGraphServer connects sm to an in-process language server that answers
+// the requests made while indexing a file; answer returns the result or an error
+func fakeCallGraphServer(t *testing.T, sm *SessionManager, answer func(method string, params json.RawMessage) (interface{}, error)) {
+	t.Helper()
+	clientIn, serverOut := io.Pipe()
+	serverIn, clientOut := io.Pipe()
+	sm.stdin = clientOut
+	sm.processState = processStateRunning
+	t.Cleanup(func() {
+		clientOut.Close()
+		serverOut.Close()
+	})
+
+	go func() { _ = sm.readResponses(clientIn) }()
+	go func() {
+		reader := bufio.NewReader(serverIn)
+		for {
+			msg, err := readLSPMessage(reader)
+			if err != nil {
+				return
+			}
+			var req struct {
+				ID     int64           `json:"id"`
+				Method string          `json:"method"`
+				Params json.RawMessage `json:"params"`
+			}
+			if json.Unmarshal(msg, &req) != nil || req.ID == 0 {
+				continue
+			}
+			resp := map[string]interface{}{"jsonrpc": "2.0", "id": req.ID}
+			if result, err := answer(req.Method, req.Params); err != nil {
+				resp["error"] = map[string]interface{}{"code": -32603, "message": err.Error()}
+			} else {
+				resp["result"] = result
+			}
+			body, _ := json.Marshal(resp)
+			if _, err := fmt.Fprintf(serverOut, "Content-Length: %d\r\n\r\n%s", len(body), body); err != nil {
+				return
+			}
+		}
+	}()
+}
+
+// dirtyCallGraph sets up a ready index where Main calls Helper and Other.bsl,
+// which also calls Helper, is dirty
+func dirtyCallGraph(t *testing.T) (sm *SessionManager, helper json.RawMessage, other string) {
+	t.Helper()
+	dir := t.TempDir()
+	other = filepath.Join(dir, "Other.bsl")
+	require.NoError(t, os.WriteFile(other, []byte("Процедура Other()\n\tHelper();\nКонецПроцедуры\n"), 0o644))
+
+	sm = NewSessionManager("", nil, dir)
+	sm.callGraph = NewCallGraphIndex("")
+	helper = callItem(filepath.Join(dir, "Common.bsl"), "Helper", 40)
+	sm.callGraph.Put(filepath.Join(dir, "Form.bsl"), callerFile(filepath.Join(dir, "Form.bsl"), "Main", helper), time.Now())
+	sm.callGraph.setState(callGraphStateReady)
+	sm.callGraph.Invalidate(other, time.Now())
+	return sm, helper, other
+}
+
+func TestIncomingCallGraphPatchesDirtyFiles(t *testing.T) {
+	sm, helper, other := dirtyCallGraph(t)
+	fakeCallGraphServer(t, sm, func(method string, params json.RawMessage) (interface{}, error) {
+		switch method {
+		case "textDocument/documentSymbol":
+			return []map[string]interface{}{{
+				"name": "Other", "kind": symbolKindMethod,
+				"range":          map[string]interface{}{"start": lspPosition{}, "end": lspPosition{Line: 2}},
+				"selectionRange": map[string]interface{}{"start": lspPosition{Character: 10}, "end": lspPosition{Character: 15}},
+			}}, nil
+		case "textDocument/prepareCallHierarchy":
+			return []json.RawMessage{callItem(other, "Other", 0)}, nil
+		case "callHierarchy/outgoingCalls":
+			return []map[string]interface{}{{"to": helper, "fromRanges": []interface{}{}}}, nil
+		}
+		return nil, fmt.Errorf("unexpected %s", method)
+	})
+
+	calls, indexed, err := sm.incomingCallGraph(context.Background(), helper)
+	require.NoError(t, err)
+	require.True(t, indexed)
+	assert.ElementsMatch(t, []string{"Main", "Other"}, callerNames(t, calls))
+
+	// The patched file is indexed now
+	_, dirty, _, _ := sm.callGraph.Incoming(helper)
+	assert.Empty(t, dirty)
+}
+
+func TestIncomingCallGraphFallsBackToServer(t *testing.T) {
+	t.Run("patch fails", func(t *testing.T) {
+		sm, helper, other := dirtyCallGraph(t)
+		fakeCallGraphServer(t, sm, func(method string, params json.RawMessage) (interface{}, error) {
+			return nil, fmt.Errorf("server busy")
+		})
+
+		_, indexed, err := sm.incomingCallGraph(context.Background(), helper)
+		require.NoError(t, err)
+		assert.False(t, indexed)
+		assert.Equal(t, []string{other}, sm.callGraph.Dirty(time.Now(), 0), "the file stays dirty for a retry")
+	})
+
+	t.Run("too many dirty files", func(t *testing.T) {
+		sm, helper, _ := dirtyCallGraph(t)
+		sm.processState = processStateRunning
+		for i := 0; i < callGraphPatchFiles; i++ {
+			sm.callGraph.Invalidate(fmt.Sprintf("/ws/Changed%d.bsl", i), time.Now())
+		}
+
+		_, indexed, err := sm.incomingCallGraph(context.Background(), helper)
+		require.NoError(t, err)
+		assert.False(t, indexed)
+	})
+
+	t.Run("server restarting", func(t *testing.T) {
+		sm, helper, _ := dirtyCallGraph(t)
+		sm.processState = processStateRestarting
+
+		_, indexed, err := sm.incomingCallGraph(context.Background(), helper)
+		require.NoError(t, err)
+		assert.False(t, indexed)
+	})
+}
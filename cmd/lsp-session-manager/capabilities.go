@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// methodCapabilities maps forwardable LSP requests to the ServerCapabilities
+// field that advertises them. Requests whose provider is missing (or false)
+// in the initialize result are rejected instead of being sent to the server.
+var methodCapabilities = map[string]string{
+	"textDocument/hover":                     "hoverProvider",
+	"textDocument/completion":                "completionProvider",
+	"completionItem/resolve":                 "completionProvider",
+	"textDocument/signatureHelp":             "signatureHelpProvider",
+	"textDocument/declaration":               "declarationProvider",
+	"textDocument/definition":                "definitionProvider",
+	"textDocument/typeDefinition":            "typeDefinitionProvider",
+	"textDocument/implementation":            "implementationProvider",
+	"textDocument/references":                "referencesProvider",
+	"textDocument/documentHighlight":         "documentHighlightProvider",
+	"textDocument/documentSymbol":            "documentSymbolProvider",
+	"textDocument/codeAction":                "codeActionProvider",
+	"codeAction/resolve":                     "codeActionProvider",
+	"textDocument/codeLens":                  "codeLensProvider",
+	"codeLens/resolve":                       "codeLensProvider",
+	"textDocument/documentLink":              "documentLinkProvider",
+	"documentLink/resolve":                   "documentLinkProvider",
+	"textDocument/documentColor":             "colorProvider",
+	"textDocument/colorPresentation":         "colorProvider",
+	"textDocument/formatting":                "documentFormattingProvider",
+	"textDocument/rangeFormatting":           "documentRangeFormattingProvider",
+	"textDocument/onTypeFormatting":          "documentOnTypeFormattingProvider",
+	"textDocument/rename":                    "renameProvider",
+	"textDocument/prepareRename":             "renameProvider",
+	"textDocument/foldingRange":              "foldingRangeProvider",
+	"textDocument/selectionRange":            "selectionRangeProvider",
+	"textDocument/linkedEditingRange":        "linkedEditingRangeProvider",
+	"textDocument/moniker":                   "monikerProvider",
+	"textDocument/inlayHint":                 "inlayHintProvider",
+	"textDocument/inlineValue":               "inlineValueProvider",
+	"textDocument/prepareCallHierarchy":      "callHierarchyProvider",
+	"callHierarchy/incomingCalls":            "callHierarchyProvider",
+	"callHierarchy/outgoingCalls":            "callHierarchyProvider",
+	"textDocument/prepareTypeHierarchy":      "typeHierarchyProvider",
+	"typeHierarchy/supertypes":               "typeHierarchyProvider",
+	"typeHierarchy/subtypes":                 "typeHierarchyProvider",
+	"textDocument/semanticTokens/full":       "semanticTokensProvider",
+	"textDocument/semanticTokens/full/delta": "semanticTokensProvider",
+	"textDocument/semanticTokens/range":      "semanticTokensProvider",
+	"textDocument/diagnostic":                "diagnosticProvider",
+	"workspace/diagnostic":                   "diagnosticProvider",
+	"workspace/symbol":                       "workspaceSymbolProvider",
+	"workspace/executeCommand":               "executeCommandProvider",
+}
+
+// forwardedNotifications are client->server notifications that are passed
+// through as-is and acknowledged with {"ok": true}
+var forwardedNotifications = map[string]bool{
+	"workspace/didChangeWatchedFiles":     true,
+	"workspace/didChangeConfiguration":    true,
+	"workspace/didChangeWorkspaceFolders": true,
+	"workspace/didCreateFiles":            true,
+	"workspace/didRenameFiles":            true,
+	"workspace/didDeleteFiles":            true,
+	"textDocument/willSave":               true,
+}
+
+// isForwardableMethod reports whether a request may be passed through to the LSP
+// server. Only requests whose provider can be checked in the initialize result
+// qualify; newer or experimental methods have to be added to methodCapabilities.
+func isForwardableMethod(method string) bool {
+	_, ok := methodCapabilities[method]
+	return ok
+}
+
+// serverSupports checks the initialize result for the provider backing the method.
+// A method without a known provider cannot be verified and is not supported.
+func serverSupports(capabilities json.RawMessage, method string) bool {
+	provider, ok := methodCapabilities[method]
+	if !ok {
+		return false
+	}
+
+	var caps map[string]json.RawMessage
+	if err := json.Unmarshal(capabilities, &caps); err != nil {
+		return false
+	}
+
+	value, ok := caps[provider]
+	if !ok {
+		return false
+	}
+	switch strings.TrimSpace(string(value)) {
+	case "", "null", "false":
+		return false
+	}
+	return true
+}
+
+// requestTimeout returns how long to wait for the LSP server to answer a request
+func requestTimeout(method string) time.Duration {
+	switch method {
+	// Long operations
+	case "workspace/diagnostic":
+		return 10 * time.Minute
+	case "textDocument/diagnostic", "textDocument/formatting", "textDocument/rangeFormatting":
+		return 5 * time.Minute
+	case "textDocument/rename", "textDocument/prepareRename", "workspace/executeCommand":
+		return 2 * time.Minute
+	// Interactive requests: a stale answer is of no use
+	case "textDocument/completion", "completionItem/resolve", "textDocument/documentHighlight":
+		return 30 * time.Second
+	}
+	return 90 * time.Second
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"log/slog"
+	"sync"
+)
+
+// LSP FileChangeType values
+const (
+	FileChangeCreated = 1
+	FileChangeChanged = 2
+	FileChangeDeleted = 3
+)
+
+// ChangeJournal накапливает изменения файлов, которые ещё не отправлены в LSP.
+// Изменения по одному URI схлопываются, поэтому правки, сделанные во время
+// индексации, не теряются и уходят одним пакетом didChangeWatchedFiles.
+// Используется и polling, и fsnotify watcher'ом.
+type ChangeJournal struct {
+	mu      sync.Mutex
+	pending map[string]int // uri -> FileChangeType
+	order   []string       // порядок первого появления URI
+}
+
+// NewChangeJournal создаёт пустой журнал
+func NewChangeJournal() *ChangeJournal {
+	return &ChangeJournal{
+		pending: make(map[string]int),
+	}
+}
+
+// Record добавляет изменения, объединяя их с уже накопленными по тому же URI
+func (j *ChangeJournal) Record(changes ...FileChange) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	for _, c := range changes {
+		prev, exists := j.pending[c.URI]
+		if !exists {
+			j.pending[c.URI] = c.Type
+			j.order = append(j.order, c.URI)
+			continue
+		}
+
+		switch {
+		case prev == FileChangeCreated && c.Type == FileChangeDeleted:
+			// Файл появился и исчез до отправки - сервер о нём не узнает
+			delete(j.pending, c.URI)
+		case prev == FileChangeCreated:
+			// Created + Changed остаётся Created
+		case prev == FileChangeDeleted && c.Type != FileChangeDeleted:
+			// Удалён и создан заново - для сервера это изменение
+			j.pending[c.URI] = FileChangeChanged
+		default:
+			j.pending[c.URI] = c.Type
+		}
+	}
+}
+
+// Drain возвращает накопленные изменения и очищает журнал
+func (j *ChangeJournal) Drain() []FileChange {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	changes := make([]FileChange, 0, len(j.pending))
+	for _, uri := range j.order {
+		changeType, ok := j.pending[uri]
+		if !ok {
+			continue
+		}
+		changes = append(changes, FileChange{URI: uri, Type: changeType})
+		delete(j.pending, uri) // защита от дублей в order
+	}
+
+	j.pending = make(map[string]int)
+	j.order = nil
+	return changes
+}
+
+// Len возвращает количество URI, ожидающих отправки
+func (j *ChangeJournal) Len() int {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return len(j.pending)
+}
+
+// Flush отправляет накопленные изменения одним пакетом, если LSP не индексирует.
+// При ошибке отправки изменения возвращаются в журнал.
+func (j *ChangeJournal) Flush(isIndexing func() bool, send func([]FileChange) error) (int, error) {
+	if send == nil || (isIndexing != nil && isIndexing()) {
+		return 0, nil
+	}
+
+	changes := j.Drain()
+	if len(changes) == 0 {
+		return 0, nil
+	}
+
+	if err := send(changes); err != nil {
+		j.requeue(changes)
+		return 0, err
+	}
+
+	slog.Info("Flushed pending file changes", "changes", len(changes))
+	return len(changes), nil
+}
+
+// requeue возвращает неотправленные изменения в начало журнала,
+// чтобы более новые события по тем же URI применились поверх них
+func (j *ChangeJournal) requeue(changes []FileChange) {
+	newer := j.Drain()
+	j.Record(changes...)
+	j.Record(newer...)
+}
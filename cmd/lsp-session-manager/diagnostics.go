@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"rockerboo/mcp-lsp-bridge/lsp"
+
+	"github.com/myleshyson/lsprotocol-go/protocol"
+)
+
+// Diagnostics pushed by the server are kept in an lsp.DiagnosticsStore, the same
+// store the bridge uses for stdio servers.
+//
+// BSL LS pushes diagnostics for every file it analyses while indexing, so after
+// indexing the store already holds workspace-wide results that would otherwise
+// require a slow workspace/diagnostic pull.
+
+// diagnosticsQuery is the params object of session/diagnostics
+type diagnosticsQuery struct {
+	URI      string   `json:"uri,omitempty"`      // exact document URI
+	Severity int      `json:"severity,omitempty"` // keep severities <= this (1=error ... 4=hint)
+	Code     []string `json:"code,omitempty"`     // keep only these diagnostic codes
+	Path     string   `json:"path,omitempty"`     // glob over the file path, see utils.MatchGlob
+	Since    string   `json:"since,omitempty"`    // RFC3339, keep documents published at or after it
+}
+
+// filter converts the query to the filter of the store
+func (q diagnosticsQuery) filter() (lsp.DiagnosticsFilter, error) {
+	filter := lsp.DiagnosticsFilter{URI: q.URI, Severity: q.Severity, Code: q.Code, Path: q.Path}
+	if q.Since != "" {
+		since, err := time.Parse(time.RFC3339, q.Since)
+		if err != nil {
+			return filter, fmt.Errorf("invalid since %q: expected RFC3339 timestamp", q.Since)
+		}
+		filter.Since = since
+	}
+	return filter, nil
+}
+
+// publishDiagnostics records a textDocument/publishDiagnostics notification
+func (sm *SessionManager) publishDiagnostics(params json.RawMessage, now time.Time) error {
+	var p protocol.PublishDiagnosticsParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return err
+	}
+	if p.Uri == "" {
+		return fmt.Errorf("publishDiagnostics without uri")
+	}
+	sm.diagnostics.Publish(p, now)
+	return nil
+}
+
+// handleDiagnosticsQuery serves session/diagnostics from the store
+func (sm *SessionManager) handleDiagnosticsQuery(params json.RawMessage) (interface{}, error) {
+	var q diagnosticsQuery
+	if len(params) > 0 && string(params) != "null" {
+		if err := json.Unmarshal(params, &q); err != nil {
+			return nil, fmt.Errorf("invalid session/diagnostics params: %w", err)
+		}
+	}
+	filter, err := q.filter()
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := sm.diagnostics.Query(filter)
+	result := map[string]interface{}{
+		"documents":        snapshot.Documents,
+		"total":            snapshot.Total,
+		"trackedDocuments": snapshot.Tracked,
+		"fresh":            sm.diagnosticsFresh(),
+	}
+	if !snapshot.UpdatedAt.IsZero() {
+		result["updatedAt"] = snapshot.UpdatedAt.UTC().Format(time.RFC3339Nano)
+	}
+	return result, nil
+}
+
+// diagnosticsFresh reports whether the store reflects the workspace on disk:
+// the server is running, indexing has finished and no file changes are queued.
+func (sm *SessionManager) diagnosticsFresh() bool {
+	if sm.processReady() != nil || sm.IsIndexing() || sm.changeJournal.Len() > 0 {
+		return false
+	}
+	return sm.diagnostics.Len() > 0
+}
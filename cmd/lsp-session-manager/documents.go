@@ -0,0 +1,167 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"rockerboo/mcp-lsp-bridge/utils"
+
+	"github.com/myleshyson/lsprotocol-go/protocol"
+)
+
+// contentChange is one entry of textDocument/didChange contentChanges.
+// A nil Range replaces the whole document.
+type contentChange struct {
+	Range *struct {
+		Start lspPosition `json:"start"`
+		End   lspPosition `json:"end"`
+	} `json:"range,omitempty"`
+	Text string `json:"text"`
+}
+
+type lspPosition struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+// nextVersion keeps document versions strictly increasing for the LSP server,
+// even when several bridge processes count versions independently
+func nextVersion(requested, current int) int {
+	if requested <= current {
+		return current + 1
+	}
+	return requested
+}
+
+// handleDidChange handles textDocument/didChange. The stored text is updated so
+// the document can be replayed after a restart, then the change is forwarded.
+func (sm *SessionManager) handleDidChange(params json.RawMessage) (interface{}, error) {
+	var p struct {
+		TextDocument struct {
+			URI     string `json:"uri"`
+			Version int    `json:"version"`
+		} `json:"textDocument"`
+		ContentChanges []contentChange `json:"contentChanges"`
+	}
+
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+
+	sm.mu.RLock()
+	enc := sm.positionEncoding
+	sm.mu.RUnlock()
+
+	sm.openDocsMu.Lock()
+	doc, ok := sm.openDocs[p.TextDocument.URI]
+	if !ok {
+		sm.openDocsMu.Unlock()
+		return nil, fmt.Errorf("document %s is not open", p.TextDocument.URI)
+	}
+
+	text := doc.Text
+	for i, change := range p.ContentChanges {
+		updated, err := applyContentChange(text, change, enc)
+		if err != nil {
+			sm.openDocsMu.Unlock()
+			return nil, fmt.Errorf("didChange for %s: change %d: %w", p.TextDocument.URI, i, err)
+		}
+		text = updated
+	}
+
+	doc.Version = nextVersion(p.TextDocument.Version, doc.Version)
+	doc.Text = text
+	sm.openDocs[p.TextDocument.URI] = doc
+	sm.openDocsMu.Unlock()
+
+	forward := map[string]interface{}{
+		"textDocument": map[string]interface{}{
+			"uri":     p.TextDocument.URI,
+			"version": doc.Version,
+		},
+		"contentChanges": p.ContentChanges,
+	}
+	if err := sm.sendNotification("textDocument/didChange", forward); err != nil {
+		return nil, err
+	}
+	sm.invalidateCallGraph(p.TextDocument.URI)
+
+	return map[string]interface{}{"version": doc.Version}, nil
+}
+
+// handleDidSave handles textDocument/didSave
+func (sm *SessionManager) handleDidSave(params json.RawMessage) (interface{}, error) {
+	var p struct {
+		TextDocument struct {
+			URI string `json:"uri"`
+		} `json:"textDocument"`
+		Text *string `json:"text,omitempty"`
+	}
+
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+
+	if p.Text != nil {
+		sm.openDocsMu.Lock()
+		if doc, ok := sm.openDocs[p.TextDocument.URI]; ok {
+			doc.Text = *p.Text
+			sm.openDocs[p.TextDocument.URI] = doc
+		}
+		sm.openDocsMu.Unlock()
+	}
+
+	if err := sm.sendNotification("textDocument/didSave", p); err != nil {
+		return nil, err
+	}
+	sm.invalidateCallGraph(p.TextDocument.URI)
+	return nil, nil
+}
+
+// applyContentChange applies one didChange entry to text.
+// Positions are line/character pairs with characters counted in enc, the
+// position encoding negotiated at initialize.
+func applyContentChange(text string, change contentChange, enc protocol.PositionEncodingKind) (string, error) {
+	if change.Range == nil {
+		return change.Text, nil
+	}
+
+	start, err := positionToOffset(text, change.Range.Start, enc)
+	if err != nil {
+		return "", fmt.Errorf("invalid range start: %w", err)
+	}
+	end, err := positionToOffset(text, change.Range.End, enc)
+	if err != nil {
+		return "", fmt.Errorf("invalid range end: %w", err)
+	}
+	if end < start {
+		return "", fmt.Errorf("range end %d:%d is before start %d:%d",
+			change.Range.End.Line, change.Range.End.Character, change.Range.Start.Line, change.Range.Start.Character)
+	}
+
+	return text[:start] + change.Text + text[end:], nil
+}
+
+// positionToOffset converts an LSP position into a byte offset in text.
+// Positions past the end of a line or of the document are clamped, as the spec requires.
+func positionToOffset(text string, pos lspPosition, enc protocol.PositionEncodingKind) (int, error) {
+	if pos.Line < 0 || pos.Character < 0 {
+		return 0, fmt.Errorf("negative position %d:%d", pos.Line, pos.Character)
+	}
+
+	lines := utils.SplitLines(text)
+	if pos.Line >= len(lines) {
+		return len(text), nil
+	}
+	line := lines[pos.Line]
+
+	offset, err := utils.CharacterOffset(line.Text, uint32(pos.Character), enc) // #nosec G115 - checked non-negative above
+	if errors.Is(err, utils.ErrCharacterPastLineEnd) {
+		return line.End, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return line.Start + offset, nil
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"rockerboo/mcp-lsp-bridge/logger"
+)
+
+// lspLogSampler thins out the log of messages exchanged with the language server
+var lspLogSampler = logger.NewSampler(logger.DefaultSampling)
+
+// logForwarded logs a request (lspID > 0) or notification forwarded to the language
+// server. The LSP id ties the correlation id to the language server's own log.
+// Failures are always logged, successes of high-volume methods are sampled.
+func logForwarded(ctx context.Context, msg, method string, lspID int64, elapsed time.Duration, err error) {
+	attrs := []any{"method", method, "duration_ms", elapsed.Milliseconds()}
+	if lspID > 0 {
+		attrs = append(attrs, "lsp_id", lspID)
+	}
+	if err != nil {
+		slog.WarnContext(ctx, msg, append(attrs, "outcome", requestOutcome(err), "error", err)...)
+		return
+	}
+	// Internal requests (call graph indexing) have no correlation id and are logged at debug level
+	level := slog.LevelDebug
+	if logger.CorrelationID(ctx) != "" {
+		level = slog.LevelInfo
+	}
+	if slog.Default().Enabled(ctx, level) && lspLogSampler.Allow(method) {
+		slog.Log(ctx, level, msg, attrs...)
+	}
+}
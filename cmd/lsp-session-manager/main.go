@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"log/slog"
 	"net"
 	"os"
 	"os/exec"
@@ -30,13 +31,26 @@ import (
 	"syscall"
 	"time"
 
+	"rockerboo/mcp-lsp-bridge/logger"
+	"rockerboo/mcp-lsp-bridge/lsp"
+	"rockerboo/mcp-lsp-bridge/metrics"
+	"rockerboo/mcp-lsp-bridge/utils"
+
 	"github.com/fsnotify/fsnotify"
+	"github.com/myleshyson/lsprotocol-go/protocol"
 )
 
 var (
 	port         = flag.Int("port", 9999, "TCP port to listen on")
 	command      = flag.String("command", "", "LSP server command to run")
 	workspaceDir = flag.String("workspace", "/projects", "Workspace directory for LSP")
+	configPath   = flag.String("config", "/etc/mcp-lsp-bridge/lsp_config.json", "Bridge config file (global.max_restart_attempts, global.restart_delay_ms)")
+	maxRequests  = flag.Int("max-concurrent", defaultMaxConcurrentRequests, "Maximum in-flight requests per API connection")
+	callGraphIdx = flag.Bool("call-graph-index", true, "Build a persistent call graph index after indexing")
+	cacheDir     = flag.String("cache-dir", "", "Cache directory for per-workspace data (default: user cache dir of mcp-lsp-bridge)")
+	logFormat    = flag.String("log-format", "json", "Log format: json or text")
+	logLevel     = flag.String("log-level", "info", "Log level: debug, info, warn or error")
+	metricsAddr  = flag.String("metrics-addr", "", "Serve Prometheus metrics on this address, e.g. :9464 (disabled when empty)")
 )
 
 // JSONRPCID handles JSON-RPC 2.0 id field which can be string, number, or null
@@ -73,14 +87,26 @@ func (id *JSONRPCID) UnmarshalJSON(data []byte) error {
 	return fmt.Errorf("id must be string, number, or null, got: %s", string(data))
 }
 
+// MarshalJSON writes the id back in the form it was received, so string ids stay strings
 func (id JSONRPCID) MarshalJSON() ([]byte, error) {
+	if id.strValue != nil {
+		return json.Marshal(*id.strValue)
+	}
 	if id.intValue != nil {
 		return json.Marshal(*id.intValue)
 	}
+	return []byte("null"), nil
+}
+
+// String formats the id for logging
+func (id JSONRPCID) String() string {
 	if id.strValue != nil {
-		return json.Marshal(*id.strValue)
+		return strconv.Quote(*id.strValue)
 	}
-	return []byte("null"), nil
+	if id.intValue != nil {
+		return strconv.FormatInt(*id.intValue, 10)
+	}
+	return "null"
 }
 
 // AsInt64 returns the id as int64 for map lookups (our internal pending map uses int64 keys)
@@ -102,28 +128,55 @@ func main() {
 	if *command == "" {
 		log.Fatal("--command is required")
 	}
+	if *logFormat != "json" && *logFormat != "text" {
+		log.Fatalf("invalid --log-format %q: expected json or text", *logFormat)
+	}
+	// Log output of dependencies that use the log package goes through the same handler
+	slog.SetDefault(slog.New(logger.NewHandler(os.Stderr, *logFormat, logger.ParseLevel(*logLevel))))
 
 	cmdArgs := flag.Args()
 
-	log.Printf("Starting LSP Session Manager on port %d", *port)
-	log.Printf("Workspace: %s", *workspaceDir)
-	log.Printf("LSP command: %s %v", *command, cmdArgs)
+	slog.Info("Starting LSP Session Manager", "port", *port)
+	slog.Info("Workspace", "path", *workspaceDir)
+	slog.Info("LSP command", "command", *command, "args", cmdArgs)
 
 	// Create session manager
 	sm := NewSessionManager(*command, cmdArgs, *workspaceDir)
+	sm.restartPolicy = loadRestartPolicy(*configPath)
+	sm.maxConcurrent = *maxRequests
+	if *callGraphIdx {
+		indexPath, err := callGraphIndexPath(*cacheDir, *workspaceDir)
+		if err != nil {
+			slog.Warn("Call graph index is not persisted", "error", err)
+		}
+		sm.callGraph = NewCallGraphIndex(indexPath)
+		slog.Info("Call graph index", "path", indexPath)
+	}
+	slog.Info("Restart policy", "max_attempts", sm.restartPolicy.MaxAttempts, "delay", sm.restartPolicy.Delay.String())
 
 	// Start LSP server and initialize session
 	if err := sm.Start(); err != nil {
-		log.Fatalf("Failed to start LSP session: %v", err)
+		slog.Error("Failed to start LSP session", "error", err)
+		os.Exit(1)
+	}
+
+	if *metricsAddr != "" {
+		metrics.Default.OnCollect(func() { sm.metricsStatus() })
+		if _, err := metrics.Serve(*metricsAddr, metrics.Default); err != nil {
+			slog.Warn("Metrics endpoint disabled", "error", err)
+		} else {
+			slog.Info("Metrics listening", "addr", *metricsAddr, "path", "/metrics")
+		}
 	}
 
 	// Start TCP listener for API requests
 	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", *port))
 	if err != nil {
-		log.Fatalf("Failed to listen on port %d: %v", *port, err)
+		slog.Error("Failed to listen", "port", *port, "error", err)
+		os.Exit(1)
 	}
 	defer listener.Close()
-	log.Printf("API listening on port %d", *port)
+	slog.Info("API listening", "port", *port)
 
 	// Handle shutdown
 	sigCh := make(chan os.Signal, 1)
@@ -131,7 +184,7 @@ func main() {
 
 	go func() {
 		<-sigCh
-		log.Println("Shutting down...")
+		slog.Info("Shutting down")
 		sm.Stop()
 		listener.Close()
 		os.Exit(0)
@@ -141,7 +194,7 @@ func main() {
 	for {
 		conn, err := listener.Accept()
 		if err != nil {
-			log.Printf("Accept error: %v", err)
+			slog.Warn("Accept error", "error", err)
 			continue
 		}
 		go sm.HandleClient(conn)
@@ -159,19 +212,29 @@ type SessionManager struct {
 	stdin  io.WriteCloser
 	stdout io.Reader
 
-	initialized  bool
-	initResult   json.RawMessage
-	capabilities json.RawMessage
+	initialized      bool
+	initResult       json.RawMessage
+	capabilities     json.RawMessage
+	positionEncoding protocol.PositionEncodingKind // picked by the server at initialize
 
 	// Request/response handling
 	requestID int64
 	pending   map[int64]chan lspResponse
 	pendingMu sync.Mutex
 
-	// Document tracking
-	openDocs   map[string]bool
+	// Document tracking (full text is kept so documents can be replayed after a restart)
+	openDocs   map[string]openDocument
 	openDocsMu sync.Mutex
 
+	// Process supervision (see supervisor.go)
+	restartPolicy  RestartPolicy
+	procMu         sync.Mutex
+	processState   string
+	stopping       bool
+	restartCount   int
+	lastExitReason string
+	lastExitAt     time.Time
+
 	// Indexing progress tracking
 	indexingMu             sync.RWMutex
 	indexingActive         bool
@@ -190,62 +253,83 @@ type SessionManager struct {
 	watcherStop    chan struct{}
 	pollingWatcher *PollingWatcher
 	watcherMode    FileWatcherMode
+	changeJournal  *ChangeJournal // changes held back while indexing, shared by both watcher modes
+
+	// Per-connection limit of concurrently handled API requests (see api_conn.go)
+	maxConcurrent int
+
+	// Diagnostics pushed by the server (see diagnostics.go)
+	diagnostics *lsp.DiagnosticsStore
+
+	// Call graph built in the background after indexing (see callgraph_index.go); nil when disabled
+	callGraph       *CallGraphIndex
+	callGraphCancel context.CancelFunc
 }
 
 type lspResponse struct {
 	Result json.RawMessage
-	Err    *struct {
-		Code    int    `json:"code"`
-		Message string `json:"message"`
-	}
+	Err    *lspError
+}
+
+type lspError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// openDocument is a document opened through the API
+type openDocument struct {
+	URI        string
+	LanguageID string
+	Version    int
+	Text       string
 }
 
 // NewSessionManager creates a new session manager
 func NewSessionManager(command string, args []string, workspaceDir string) *SessionManager {
 	return &SessionManager{
-		command:      command,
-		args:         args,
-		workspaceDir: workspaceDir,
-		pending:      make(map[int64]chan lspResponse),
-		openDocs:     make(map[string]bool),
+		command:       command,
+		args:          args,
+		workspaceDir:  workspaceDir,
+		pending:       make(map[int64]chan lspResponse),
+		openDocs:      make(map[string]openDocument),
+		changeJournal: NewChangeJournal(),
+		diagnostics:   lsp.NewDiagnosticsStore(),
+		maxConcurrent: defaultMaxConcurrentRequests,
+		restartPolicy: RestartPolicy{
+			MaxAttempts: defaultMaxRestartAttempts,
+			Delay:       defaultRestartDelay,
+		},
+		processState: processStateStarting,
 	}
 }
 
 // Start starts the LSP server and initializes the session
 func (sm *SessionManager) Start() error {
-	log.Println("Starting LSP server...")
+	slog.Info("Starting LSP server")
 
-	sm.cmd = exec.Command(sm.command, sm.args...)
-
-	var err error
-	sm.stdin, err = sm.cmd.StdinPipe()
+	proc, err := sm.spawn()
 	if err != nil {
-		return fmt.Errorf("failed to get stdin pipe: %w", err)
-	}
-
-	sm.stdout, err = sm.cmd.StdoutPipe()
-	if err != nil {
-		return fmt.Errorf("failed to get stdout pipe: %w", err)
-	}
-
-	sm.cmd.Stderr = os.Stderr
-
-	if err := sm.cmd.Start(); err != nil {
-		return fmt.Errorf("failed to start LSP server: %w", err)
+		return err
 	}
-	log.Printf("LSP server started with PID %d", sm.cmd.Process.Pid)
-
-	// Start response reader
-	go sm.readResponses()
 
 	// Initialize LSP session
 	if err := sm.initialize(); err != nil {
 		return fmt.Errorf("failed to initialize LSP session: %w", err)
 	}
+	sm.setProcessState(processStateRunning)
+
+	// Respawn the server if it crashes (OOM on large configurations)
+	go sm.supervise(proc)
 
 	// Start file watcher AFTER indexing completes to avoid resource contention
 	go sm.startFileWatcherAfterIndexing()
 
+	if sm.callGraph != nil {
+		ctx, cancel := context.WithCancel(context.Background())
+		sm.callGraphCancel = cancel
+		go sm.runCallGraphIndexer(ctx)
+	}
+
 	return nil
 }
 
@@ -262,20 +346,28 @@ func (sm *SessionManager) startFileWatcherAfterIndexing() {
 		if !isActive {
 			break
 		}
-		log.Println("File watcher: waiting for indexing to complete...")
+		slog.Info("File watcher: waiting for indexing to complete")
 	}
 
-	log.Println("File watcher: indexing complete, starting watcher")
+	slog.Info("File watcher: indexing complete, starting watcher")
 
 	// Start file watcher for automatic didChangeWatchedFiles
 	if err := sm.startFileWatcher(); err != nil {
-		log.Printf("Warning: failed to start file watcher: %v", err)
+		slog.Warn("Failed to start file watcher", "error", err)
 		// Non-fatal - continue without file watching
 	}
 }
 
 // Stop stops the LSP server
 func (sm *SessionManager) Stop() {
+	sm.procMu.Lock()
+	sm.stopping = true
+	sm.procMu.Unlock()
+
+	if sm.callGraphCancel != nil {
+		sm.callGraphCancel()
+	}
+
 	// Stop file watchers
 	if sm.pollingWatcher != nil {
 		sm.pollingWatcher.Stop()
@@ -287,20 +379,24 @@ func (sm *SessionManager) Stop() {
 		sm.watcher.Close()
 	}
 
-	if sm.cmd != nil && sm.cmd.Process != nil {
+	sm.mu.RLock()
+	cmd := sm.cmd
+	sm.mu.RUnlock()
+
+	if cmd != nil && cmd.Process != nil {
 		sm.sendNotification("exit", nil)
-		sm.cmd.Process.Kill()
+		cmd.Process.Kill()
 	}
 }
 
 // startFileWatcher starts watching workspace for .bsl and .os file changes
 func (sm *SessionManager) startFileWatcher() error {
 	sm.watcherMode = GetFileWatcherMode()
-	log.Printf("File watcher mode: %s", sm.watcherMode)
+	slog.Info("File watcher mode", "mode", sm.watcherMode)
 
 	switch sm.watcherMode {
 	case WatcherModeOff:
-		log.Println("File watcher disabled - use did_change_watched_files tool manually")
+		slog.Info("File watcher disabled - use did_change_watched_files tool manually")
 		return nil
 
 	case WatcherModePolling:
@@ -313,13 +409,13 @@ func (sm *SessionManager) startFileWatcher() error {
 		// Try fsnotify first, fallback to polling if it doesn't detect changes
 		// For now, on Docker/Windows, fsnotify won't work, so we detect and use polling
 		if err := sm.startFsnotifyWatcher(); err != nil {
-			log.Printf("fsnotify failed (%v), falling back to polling", err)
+			slog.Warn("fsnotify failed, falling back to polling", "error", err)
 			return sm.startPollingWatcher()
 		}
 		return nil
 
 	default:
-		log.Printf("Unknown watcher mode '%s', using polling", sm.watcherMode)
+		slog.Warn("Unknown watcher mode, using polling", "mode", sm.watcherMode)
 		return sm.startPollingWatcher()
 	}
 }
@@ -333,26 +429,44 @@ func (sm *SessionManager) startPollingWatcher() error {
 		sm.workspaceDir,
 		interval,
 		workers,
-		func(changes []FileChange) error {
-			// Convert to LSP format and send notification
-			lspChanges := make([]map[string]interface{}, len(changes))
-			for i, c := range changes {
-				lspChanges[i] = map[string]interface{}{
-					"uri":  c.URI,
-					"type": c.Type,
-				}
-			}
-			params := map[string]interface{}{
-				"changes": lspChanges,
-			}
-			return sm.sendNotification("workspace/didChangeWatchedFiles", params)
-		},
+		sm.changeJournal,
+		sm.sendFileChanges,
 		sm.IsIndexing, // Pass indexing check function
 	)
 
 	return sm.pollingWatcher.Start()
 }
 
+// sendFileChanges sends one workspace/didChangeWatchedFiles notification for the batch
+func (sm *SessionManager) sendFileChanges(changes []FileChange) error {
+	// Convert to LSP format and send notification
+	lspChanges := make([]map[string]interface{}, len(changes))
+	for i, c := range changes {
+		lspChanges[i] = map[string]interface{}{
+			"uri":  c.URI,
+			"type": c.Type,
+		}
+	}
+	params := map[string]interface{}{
+		"changes": lspChanges,
+	}
+	if err := sm.sendNotification("workspace/didChangeWatchedFiles", params); err != nil {
+		return err
+	}
+
+	for _, c := range changes {
+		sm.invalidateCallGraph(c.URI)
+	}
+	return nil
+}
+
+// flushFileChanges sends the pending change journal unless the LSP is indexing
+func (sm *SessionManager) flushFileChanges() {
+	if _, err := sm.changeJournal.Flush(sm.IsIndexing, sm.sendFileChanges); err != nil {
+		slog.Warn("Error sending didChangeWatchedFiles", "error", err)
+	}
+}
+
 // IsIndexing returns true if LSP is currently indexing
 func (sm *SessionManager) IsIndexing() bool {
 	sm.indexingMu.RLock()
@@ -381,7 +495,7 @@ func (sm *SessionManager) startFsnotifyWatcher() error {
 				return filepath.SkipDir
 			}
 			if err := watcher.Add(path); err != nil {
-				log.Printf("Warning: failed to watch directory %s: %v", path, err)
+				slog.Warn("Failed to watch directory", "path", path, "error", err)
 			}
 		}
 		return nil
@@ -390,7 +504,7 @@ func (sm *SessionManager) startFsnotifyWatcher() error {
 		return fmt.Errorf("failed to walk workspace: %w", err)
 	}
 
-	log.Printf("fsnotify watcher started for workspace: %s", sm.workspaceDir)
+	slog.Info("fsnotify watcher started", "workspace", sm.workspaceDir)
 
 	// Start watcher goroutine
 	go sm.runFsnotifyWatcher()
@@ -405,13 +519,11 @@ func (sm *SessionManager) runFsnotifyWatcher() {
 	if !debounceTimer.Stop() {
 		<-debounceTimer.C
 	}
-	pendingChanges := make(map[string]int) // uri -> FileChangeType (1=Created, 2=Changed, 3=Deleted)
-	var pendingMu sync.Mutex
 
 	for {
 		select {
 		case <-sm.watcherStop:
-			log.Println("fsnotify watcher stopped")
+			slog.Info("fsnotify watcher stopped")
 			return
 
 		case event, ok := <-sm.watcher.Events:
@@ -428,7 +540,7 @@ func (sm *SessionManager) runFsnotifyWatcher() {
 						name := info.Name()
 						if !strings.HasPrefix(name, ".") && name != "node_modules" && name != "vendor" {
 							sm.watcher.Add(event.Name)
-							log.Printf("Added new directory to watch: %s", event.Name)
+							slog.Debug("Added new directory to watch", "path", event.Name)
 						}
 					}
 				}
@@ -438,65 +550,43 @@ func (sm *SessionManager) runFsnotifyWatcher() {
 			// Convert to file URI
 			uri := "file://" + filepath.ToSlash(event.Name)
 
-			pendingMu.Lock()
-			// Map fsnotify events to LSP FileChangeType
+			// Map fsnotify events to LSP FileChangeType; the journal coalesces
+			// repeated events for the same file
 			switch {
 			case event.Has(fsnotify.Create):
-				pendingChanges[uri] = 1 // Created
-				log.Printf("File created: %s", event.Name)
+				sm.changeJournal.Record(FileChange{URI: uri, Type: FileChangeCreated})
+				slog.Debug("File created", "path", event.Name)
 			case event.Has(fsnotify.Write):
-				// Only mark as changed if not already marked as created
-				if _, exists := pendingChanges[uri]; !exists {
-					pendingChanges[uri] = 2 // Changed
-				}
+				sm.changeJournal.Record(FileChange{URI: uri, Type: FileChangeChanged})
 			case event.Has(fsnotify.Remove) || event.Has(fsnotify.Rename):
-				pendingChanges[uri] = 3 // Deleted
-				log.Printf("File deleted/renamed: %s", event.Name)
+				sm.changeJournal.Record(FileChange{URI: uri, Type: FileChangeDeleted})
+				slog.Debug("File deleted or renamed", "path", event.Name)
 			}
-			pendingMu.Unlock()
 
 			// Reset debounce timer
 			debounceTimer.Reset(500 * time.Millisecond)
 
 		case <-debounceTimer.C:
-			pendingMu.Lock()
-			if len(pendingChanges) > 0 {
-				// Build FileEvent array
-				changes := make([]map[string]interface{}, 0, len(pendingChanges))
-				for uri, changeType := range pendingChanges {
-					changes = append(changes, map[string]interface{}{
-						"uri":  uri,
-						"type": changeType,
-					})
-				}
-				pendingChanges = make(map[string]int) // Clear pending
-				pendingMu.Unlock()
-
-				// Send didChangeWatchedFiles notification
-				params := map[string]interface{}{
-					"changes": changes,
-				}
-				if err := sm.sendNotification("workspace/didChangeWatchedFiles", params); err != nil {
-					log.Printf("Error sending didChangeWatchedFiles: %v", err)
-				} else {
-					log.Printf("Sent didChangeWatchedFiles with %d changes", len(changes))
-				}
-			} else {
-				pendingMu.Unlock()
+			// While LSP is indexing the changes stay in the journal and are
+			// flushed when the $/progress "end" notification arrives
+			if sm.IsIndexing() {
+				slog.Info("fsnotify watcher: LSP is indexing, changes deferred", "pending", sm.changeJournal.Len())
+				continue
 			}
+			sm.flushFileChanges()
 
 		case err, ok := <-sm.watcher.Errors:
 			if !ok {
 				return
 			}
-			log.Printf("fsnotify watcher error: %v", err)
+			slog.Warn("fsnotify watcher error", "error", err)
 		}
 	}
 }
 
 // initialize sends initialize request and waits for response
 func (sm *SessionManager) initialize() error {
-	log.Println("Initializing LSP session...")
+	slog.Info("Initializing LSP session")
 
 	// Build workspace folders
 	workspaceFolders := []map[string]string{
@@ -509,6 +599,10 @@ func (sm *SessionManager) initialize() error {
 	params := map[string]interface{}{
 		"processId": nil, // Don't monitor parent process
 		"capabilities": map[string]interface{}{
+			// Document replay converts whichever encoding the server picks
+			"general": map[string]interface{}{
+				"positionEncodings": utils.ClientPositionEncodings,
+			},
 			"textDocument": map[string]interface{}{
 				"hover": map[string]interface{}{
 					"contentFormat": []string{"markdown", "plaintext"},
@@ -516,6 +610,28 @@ func (sm *SessionManager) initialize() error {
 				"definition": map[string]interface{}{
 					"linkSupport": true,
 				},
+				"declaration": map[string]interface{}{
+					"linkSupport": true,
+				},
+				"typeDefinition": map[string]interface{}{
+					"linkSupport": true,
+				},
+				"documentHighlight": map[string]interface{}{},
+				"completion": map[string]interface{}{
+					"completionItem": map[string]interface{}{
+						"documentationFormat": []string{"markdown", "plaintext"},
+						"labelDetailsSupport": true,
+						"deprecatedSupport":   true,
+						"resolveSupport": map[string]interface{}{
+							"properties": []string{"documentation", "detail"},
+						},
+					},
+				},
+				"codeLens": map[string]interface{}{
+					"resolveSupport": map[string]interface{}{
+						"properties": []string{"command"},
+					},
+				},
 				"references":     map[string]interface{}{},
 				"callHierarchy":  map[string]interface{}{},
 				"documentSymbol": map[string]interface{}{},
@@ -550,19 +666,26 @@ func (sm *SessionManager) initialize() error {
 		Capabilities json.RawMessage `json:"capabilities"`
 	}
 	if err := json.Unmarshal(result, &initResp); err == nil {
+		var caps struct {
+			PositionEncoding protocol.PositionEncodingKind `json:"positionEncoding"`
+		}
+		_ = json.Unmarshal(initResp.Capabilities, &caps)
+		enc := utils.NormalizePositionEncoding(caps.PositionEncoding)
 		sm.mu.Lock()
 		sm.capabilities = initResp.Capabilities
+		sm.positionEncoding = enc
 		sm.mu.Unlock()
+		slog.Info("Position encoding", "encoding", string(enc))
 	}
 
-	log.Println("LSP session initialized successfully")
+	slog.Info("LSP session initialized successfully")
 
 	// Send initialized notification
 	if err := sm.sendNotification("initialized", map[string]interface{}{}); err != nil {
-		log.Printf("Warning: failed to send initialized notification: %v", err)
+		slog.Warn("Failed to send initialized notification", "error", err)
 	}
 
-	log.Println("Waiting for indexing to complete...")
+	slog.Info("Waiting for indexing to complete")
 	// Give BSL LS time to index - we'll track progress via $/progress notifications
 	time.Sleep(5 * time.Second)
 
@@ -591,6 +714,7 @@ func (sm *SessionManager) sendRequest(ctx context.Context, method string, params
 		"params":  params,
 	}
 
+	start := time.Now()
 	if err := sm.writeMessage(req); err != nil {
 		return nil, err
 	}
@@ -598,10 +722,18 @@ func (sm *SessionManager) sendRequest(ctx context.Context, method string, params
 	select {
 	case resp := <-respCh:
 		if resp.Err != nil {
-			return nil, fmt.Errorf("lsp error %d: %s", resp.Err.Code, resp.Err.Message)
+			err := fmt.Errorf("lsp error %d: %s", resp.Err.Code, resp.Err.Message)
+			logForwarded(ctx, "LSP request finished", method, id, time.Since(start), err)
+			return nil, err
 		}
+		logForwarded(ctx, "LSP request finished", method, id, time.Since(start), nil)
 		return resp.Result, nil
 	case <-ctx.Done():
+		// The caller gave up (cancel, timeout or disconnect): let the server stop working on it
+		if err := sm.sendNotification("$/cancelRequest", map[string]interface{}{"id": id}); err != nil {
+			slog.WarnContext(ctx, "Failed to forward $/cancelRequest", "method", method, "lsp_id", id, "error", err)
+		}
+		logForwarded(ctx, "LSP request abandoned", method, id, time.Since(start), ctx.Err())
 		return nil, ctx.Err()
 	}
 }
@@ -637,15 +769,15 @@ func (sm *SessionManager) writeMessage(msg interface{}) error {
 	return nil
 }
 
-// readResponses reads responses from LSP server
-func (sm *SessionManager) readResponses() {
-	reader := bufio.NewReader(sm.stdout)
+// readResponses reads responses from LSP server until stdout breaks
+func (sm *SessionManager) readResponses(stdout io.Reader) error {
+	reader := bufio.NewReader(stdout)
 
 	for {
 		msg, err := readLSPMessage(reader)
 		if err != nil {
-			log.Printf("LSP read error: %v", err)
-			return
+			slog.Error("LSP read error", "error", err)
+			return err
 		}
 
 		// Parse message
@@ -653,14 +785,11 @@ func (sm *SessionManager) readResponses() {
 			ID     *JSONRPCID      `json:"id"`
 			Method string          `json:"method"`
 			Result json.RawMessage `json:"result"`
-			Error  *struct {
-				Code    int    `json:"code"`
-				Message string `json:"message"`
-			} `json:"error"`
+			Error  *lspError       `json:"error"`
 		}
 
 		if err := json.Unmarshal(msg, &baseMsg); err != nil {
-			log.Printf("Failed to parse LSP message: %v (raw: %s)", err, string(msg)[:min(200, len(msg))])
+			slog.Warn("Failed to parse LSP message", "error", err, "bytes", len(msg))
 			continue
 		}
 
@@ -707,8 +836,8 @@ func (sm *SessionManager) handleNotification(method string, msg []byte) {
 			message := progress.Params.Value.Message
 			percentage := progress.Params.Value.Percentage
 
-			if kind != "" {
-				log.Printf("Progress [%s]: %s %s (%d%%)", kind, title, message, percentage)
+			if kind != "" && (kind != "report" || lspLogSampler.Allow("$/progress")) {
+				slog.Info("Progress", "kind", kind, "title", title, "message", message, "percentage", percentage)
 			}
 
 			// Update indexing state
@@ -772,10 +901,24 @@ func (sm *SessionManager) handleNotification(method string, msg []byte) {
 				// Keep indexingFirstStartedAt for displaying total elapsed time
 			}
 			sm.indexingMu.Unlock()
+
+			if kind == "end" {
+				// Replay file changes that arrived while the server was indexing
+				sm.flushFileChanges()
+			}
 		}
 
 	case "textDocument/publishDiagnostics":
-		// Could cache diagnostics here
+		var notification struct {
+			Params json.RawMessage `json:"params"`
+		}
+		err := json.Unmarshal(msg, &notification)
+		if err == nil {
+			err = sm.publishDiagnostics(notification.Params, time.Now())
+		}
+		if err != nil {
+			slog.Warn("Failed to store diagnostics", "error", err)
+		}
 
 	case "window/logMessage":
 		// Log server messages
@@ -786,11 +929,11 @@ func (sm *SessionManager) handleNotification(method string, msg []byte) {
 			} `json:"params"`
 		}
 		if json.Unmarshal(msg, &logMsg) == nil {
-			log.Printf("LSP Log [type=%d]: %s", logMsg.Params.Type, logMsg.Params.Message)
+			slog.Info("LSP log message", "type", logMsg.Params.Type, "message", logMsg.Params.Message)
 		}
 
 	default:
-		log.Printf("Notification: %s", method)
+		slog.Debug("Notification", "method", method)
 	}
 }
 
@@ -811,109 +954,11 @@ func parseProgressMessage(msg string) (current, total int) {
 	return 0, 0
 }
 
-// HandleClient handles an API client connection
-func (sm *SessionManager) HandleClient(conn net.Conn) {
-	defer conn.Close()
-	log.Printf("API client connected: %s", conn.RemoteAddr())
-
-	reader := bufio.NewReader(conn)
-
-	for {
-		// Read JSON-RPC request (newline-delimited)
-		log.Printf("Waiting for request from %s...", conn.RemoteAddr())
-		line, err := reader.ReadString('\n')
-		if err != nil {
-			if err != io.EOF {
-				log.Printf("Client read error: %v", err)
-			} else {
-				log.Printf("Client %s closed connection (EOF)", conn.RemoteAddr())
-			}
-			break
-		}
-
-		line = strings.TrimSpace(line)
-		if line == "" {
-			continue
-		}
-
-		log.Printf("Received request from %s: %s", conn.RemoteAddr(), line)
-
-		// Parse request
-		var req struct {
-			JSONRPC string          `json:"jsonrpc"`
-			ID      int64           `json:"id"`
-			Method  string          `json:"method"`
-			Params  json.RawMessage `json:"params"`
-		}
-
-		if err := json.Unmarshal([]byte(line), &req); err != nil {
-			log.Printf("Parse error for request: %v", err)
-			sm.sendAPIError(conn, 0, -32700, "Parse error")
-			continue
-		}
-
-		log.Printf("Handling method: %s (id=%d)", req.Method, req.ID)
-
-		// Handle request
-		result, err := sm.handleAPIRequest(req.Method, req.Params)
-		if err != nil {
-			log.Printf("Error handling %s: %v", req.Method, err)
-			sm.sendAPIError(conn, req.ID, -32603, err.Error())
-			continue
-		}
-
-		log.Printf("Method %s completed successfully", req.Method)
-
-		// Send response
-		resp := map[string]interface{}{
-			"jsonrpc": "2.0",
-			"id":      req.ID,
-			"result":  result,
-		}
-		respJSON, err := json.Marshal(resp)
-		if err != nil {
-			log.Printf("Error marshaling response: %v", err)
-			continue
-		}
-		n, err := conn.Write(append(respJSON, '\n'))
-		if err != nil {
-			log.Printf("Error writing response: %v", err)
-		} else {
-			log.Printf("Sent response to %s: %d bytes (id=%d)", conn.RemoteAddr(), n, req.ID)
-		}
-	}
-
-	log.Printf("API client disconnected: %s", conn.RemoteAddr())
-}
-
-// sendAPIError sends an error response to API client
-func (sm *SessionManager) sendAPIError(conn net.Conn, id int64, code int, message string) {
-	resp := map[string]interface{}{
-		"jsonrpc": "2.0",
-		"id":      id,
-		"error": map[string]interface{}{
-			"code":    code,
-			"message": message,
-		},
-	}
-	respJSON, _ := json.Marshal(resp)
-	conn.Write(append(respJSON, '\n'))
-}
-
 // handleAPIRequest handles an API request from mcp-lsp-bridge
-func (sm *SessionManager) handleAPIRequest(method string, params json.RawMessage) (interface{}, error) {
-	timeout := 90 * time.Second
-	switch method {
-	// Long operations
-	case "workspace/diagnostic":
-		timeout = 10 * time.Minute
-	case "textDocument/diagnostic", "textDocument/formatting":
-		timeout = 5 * time.Minute
-	case "textDocument/rename", "textDocument/prepareRename":
-		timeout = 2 * time.Minute
-	}
+func (sm *SessionManager) handleAPIRequest(ctx context.Context, method string, params json.RawMessage) (result interface{}, err error) {
+	defer func(start time.Time) { observeAPIRequest(method, time.Since(start), err) }(time.Now())
 
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout(method))
 	defer cancel()
 
 	switch method {
@@ -926,93 +971,105 @@ func (sm *SessionManager) handleAPIRequest(method string, params json.RawMessage
 		sm.mu.RUnlock()
 		return caps, nil
 
+	case "session/diagnostics":
+		return sm.handleDiagnosticsQuery(params)
+
+	case "session/callGraph":
+		return sm.handleCallGraphQuery(ctx, params)
+	}
+
+	// Everything below talks to the LSP server
+	if err := sm.processReady(); err != nil {
+		return nil, err
+	}
+
+	switch method {
 	case "textDocument/didOpen":
 		return sm.handleDidOpen(params)
 
+	case "textDocument/didChange":
+		return sm.handleDidChange(params)
+
+	case "textDocument/didSave":
+		return sm.handleDidSave(params)
+
 	case "textDocument/didClose":
 		return sm.handleDidClose(params)
+	}
 
-	case "textDocument/hover",
-		"textDocument/definition",
-		"textDocument/references",
-		"textDocument/documentSymbol",
-		"textDocument/diagnostic",
-		// NOTE: BSL LS doesn't provide meaningful implementations/signatureHelp for our use cases,
-		// but we keep forwarding for compatibility if requested.
-		"textDocument/implementation",
-		"textDocument/codeAction",
-		"textDocument/formatting",
-		"textDocument/rename",
-		"textDocument/prepareRename",
-		"textDocument/prepareCallHierarchy":
-		// Forward directly to LSP server
-		var p interface{}
-		json.Unmarshal(params, &p)
-		start := time.Now()
-		res, err := sm.sendRequest(ctx, method, p)
-		log.Printf("Method %s finished in %s (err=%v)", method, time.Since(start), err)
-		return res, err
-
-	case "callHierarchy/incomingCalls",
-		"callHierarchy/outgoingCalls":
-		var p interface{}
-		json.Unmarshal(params, &p)
-		return sm.sendRequest(ctx, method, p)
+	var p interface{}
+	json.Unmarshal(params, &p)
 
-	case "workspace/didChangeWatchedFiles":
+	if forwardedNotifications[method] {
 		// Notification (no result) in LSP, but our API is request/response.
 		// Forward as notification to the underlying LSP server and return an "ok" ack.
-		var p interface{}
-		json.Unmarshal(params, &p)
 		start := time.Now()
 		err := sm.sendNotification(method, p)
-		log.Printf("Notification %s sent in %s (err=%v)", method, time.Since(start), err)
+		logForwarded(ctx, "Notification sent", method, 0, time.Since(start), err)
+		if err == nil && method == "workspace/didChangeWatchedFiles" {
+			var changes struct {
+				Changes []FileChange `json:"changes"`
+			}
+			if json.Unmarshal(params, &changes) == nil {
+				for _, c := range changes.Changes {
+					sm.invalidateCallGraph(c.URI)
+				}
+			}
+		}
 		return map[string]interface{}{"ok": err == nil}, err
+	}
 
-	case "workspace/symbol":
-		var p interface{}
-		json.Unmarshal(params, &p)
-		start := time.Now()
-		res, err := sm.sendRequest(ctx, method, p)
-		log.Printf("Method %s finished in %s (err=%v)", method, time.Since(start), err)
-		return res, err
+	if !isForwardableMethod(method) {
+		return nil, fmt.Errorf("unknown method: %s", method)
+	}
 
-	case "workspace/diagnostic":
-		var p interface{}
-		json.Unmarshal(params, &p)
-		start := time.Now()
-		res, err := sm.sendRequest(ctx, method, p)
-		log.Printf("Method %s finished in %s (err=%v)", method, time.Since(start), err)
-		return res, err
+	sm.mu.RLock()
+	caps := sm.capabilities
+	sm.mu.RUnlock()
+	if !serverSupports(caps, method) {
+		return nil, fmt.Errorf("method %s is not supported by the language server (%s not advertised)", method, methodCapabilities[method])
+	}
 
-	default:
-		return nil, fmt.Errorf("unknown method: %s", method)
+	// Forward directly to LSP server
+	return sm.sendRequest(ctx, method, p)
+}
+
+// indexingStateLocked returns "idle", "indexing" or "complete"; the caller holds indexingMu
+func (sm *SessionManager) indexingStateLocked() string {
+	switch {
+	case sm.indexingActive || (sm.indexingTotal > 0 && sm.indexingCurrent < sm.indexingTotal):
+		return "indexing"
+	case sm.indexingTotal > 0 && sm.indexingCurrent >= sm.indexingTotal:
+		return "complete"
 	}
+	return "idle"
 }
 
 // getStatus returns current session status
 func (sm *SessionManager) getStatus() map[string]interface{} {
 	sm.mu.RLock()
 	initialized := sm.initialized
+	pid := 0
+	if sm.cmd != nil && sm.cmd.Process != nil {
+		pid = sm.cmd.Process.Pid
+	}
 	sm.mu.RUnlock()
 
 	sm.openDocsMu.Lock()
 	openDocsCount := len(sm.openDocs)
 	sm.openDocsMu.Unlock()
 
+	fileWatcher := map[string]interface{}{
+		"mode":           string(sm.watcherMode),
+		"pendingChanges": sm.changeJournal.Len(),
+	}
+
 	// Get indexing progress (minimal structure)
 	sm.indexingMu.RLock()
 
-	// Determine state: "idle" | "indexing" | "complete"
-	isActive := sm.indexingActive || (sm.indexingTotal > 0 && sm.indexingCurrent < sm.indexingTotal)
-	isComplete := sm.indexingTotal > 0 && sm.indexingCurrent >= sm.indexingTotal
-
-	state := "idle"
-	if isActive {
-		state = "indexing"
-	} else if isComplete {
-		state = "complete"
-	}
+	state := sm.indexingStateLocked()
+	isActive := state == "indexing"
+	isComplete := state == "complete"
 
 	indexing := map[string]interface{}{
 		"state":   state,
@@ -1041,12 +1098,23 @@ func (sm *SessionManager) getStatus() map[string]interface{} {
 	}
 	sm.indexingMu.RUnlock()
 
-	return map[string]interface{}{
+	status := map[string]interface{}{
 		"initialized":   initialized,
 		"openDocuments": openDocsCount,
-		"pid":           sm.cmd.Process.Pid,
+		"pid":           pid,
 		"indexing":      indexing,
+		"supervisor":    sm.supervisorStatus(),
+		"fileWatcher":   fileWatcher,
+		"diagnostics": map[string]interface{}{
+			"documents": sm.diagnostics.Len(),
+			"fresh":     sm.diagnosticsFresh(),
+		},
+		"metrics": sm.metricsStatus(),
 	}
+	if sm.callGraph != nil {
+		status["callGraphIndex"] = sm.callGraph.Status()
+	}
+	return status
 }
 
 // handleDidOpen handles textDocument/didOpen
@@ -1065,8 +1133,17 @@ func (sm *SessionManager) handleDidOpen(params json.RawMessage) (interface{}, er
 	}
 
 	sm.openDocsMu.Lock()
-	alreadyOpen := sm.openDocs[p.TextDocument.URI]
-	sm.openDocs[p.TextDocument.URI] = true
+	prev, alreadyOpen := sm.openDocs[p.TextDocument.URI]
+	if alreadyOpen {
+		// Another bridge process may have advanced the version already
+		p.TextDocument.Version = nextVersion(p.TextDocument.Version, prev.Version)
+	}
+	sm.openDocs[p.TextDocument.URI] = openDocument{
+		URI:        p.TextDocument.URI,
+		LanguageID: p.TextDocument.LanguageID,
+		Version:    p.TextDocument.Version,
+		Text:       p.TextDocument.Text,
+	}
 	sm.openDocsMu.Unlock()
 
 	if alreadyOpen {
@@ -1082,7 +1159,11 @@ func (sm *SessionManager) handleDidOpen(params json.RawMessage) (interface{}, er
 	}
 
 	// Send to LSP server (either first open or reopen after close)
-	return nil, sm.sendNotification("textDocument/didOpen", p)
+	if err := sm.sendNotification("textDocument/didOpen", p); err != nil {
+		return nil, err
+	}
+	// The bridge keeps counting from the version the server got
+	return map[string]interface{}{"version": p.TextDocument.Version}, nil
 }
 
 // handleDidClose handles textDocument/didClose
@@ -1101,7 +1182,12 @@ func (sm *SessionManager) handleDidClose(params json.RawMessage) (interface{}, e
 	delete(sm.openDocs, p.TextDocument.URI)
 	sm.openDocsMu.Unlock()
 
-	return nil, sm.sendNotification("textDocument/didClose", p)
+	if err := sm.sendNotification("textDocument/didClose", p); err != nil {
+		return nil, err
+	}
+	// The server goes back to the text on disk
+	sm.invalidateCallGraph(p.TextDocument.URI)
+	return nil, nil
 }
 
 // readLSPMessage reads a complete LSP message
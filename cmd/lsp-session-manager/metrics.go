@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"rockerboo/mcp-lsp-bridge/metrics"
+)
+
+// indexingStates are the values of the "state" label of indexingStateGauge
+var indexingStates = []string{"idle", "indexing", "complete"}
+
+var (
+	apiRequests = metrics.Default.NewCounter("mcp_lsp_session_requests_total",
+		"API requests handled by the session manager by method and outcome (ok, error, cancelled, timeout).",
+		"method", "outcome")
+	apiLatency = metrics.Default.NewHistogram("mcp_lsp_session_request_duration_seconds",
+		"Time to handle an API request, including the language server round trip.",
+		metrics.DefaultLatencyBuckets, "method")
+	apiQueueDepth = metrics.Default.NewGauge("mcp_lsp_session_queue_depth",
+		"API requests read from a connection and waiting for a free concurrency slot.")
+	apiInflight = metrics.Default.NewGauge("mcp_lsp_session_inflight_requests",
+		"API requests being handled.")
+	lspPendingGauge = metrics.Default.NewGauge("mcp_lsp_session_pending_lsp_requests",
+		"Requests sent to the language server and waiting for a response.")
+	lspRSSGauge = metrics.Default.NewGauge("mcp_lsp_session_lsp_process_resident_memory_bytes",
+		"Resident memory of the language server process (the JVM for BSL LS), 0 when it is not running.")
+	lspRestartsGauge = metrics.Default.NewGauge("mcp_lsp_session_lsp_process_restarts",
+		"Times the supervisor restarted the language server.")
+	indexingStateGauge = metrics.Default.NewGauge("mcp_lsp_session_indexing_state",
+		"1 for the current indexing state of the workspace, 0 for the others.",
+		"state")
+	indexingFilesGauge = metrics.Default.NewGauge("mcp_lsp_session_indexing_files",
+		"Indexing progress: files indexed so far (current) and files to index (total).",
+		"kind")
+)
+
+// metricMethod keeps the method label bounded: unknown methods share one series
+func metricMethod(method string) string {
+	switch {
+	case strings.HasPrefix(method, "session/"),
+		isOrderedMethod(method),
+		isForwardableMethod(method):
+		return method
+	}
+	return "other"
+}
+
+// requestOutcome classifies the error of handleAPIRequest
+func requestOutcome(err error) string {
+	switch {
+	case err == nil:
+		return "ok"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.Is(err, context.Canceled):
+		return "cancelled"
+	default:
+		return "error"
+	}
+}
+
+// observeAPIRequest records one handled API request
+func observeAPIRequest(method string, elapsed time.Duration, err error) {
+	method = metricMethod(method)
+	apiRequests.Inc(method, requestOutcome(err))
+	apiLatency.Observe(elapsed.Seconds(), method)
+}
+
+// metricsStatus refreshes the gauges that are read on demand and returns them
+// for session/status. It runs before every /metrics scrape.
+func (sm *SessionManager) metricsStatus() map[string]interface{} {
+	sm.pendingMu.Lock()
+	pending := len(sm.pending)
+	sm.pendingMu.Unlock()
+	lspPendingGauge.Set(float64(pending))
+
+	sm.mu.RLock()
+	pid := 0
+	if sm.cmd != nil && sm.cmd.Process != nil {
+		pid = sm.cmd.Process.Pid
+	}
+	sm.mu.RUnlock()
+
+	var rss int64
+	if pid != 0 {
+		// An exited (or zombie) process has no VmRSS; report 0 then
+		rss, _ = metrics.ProcessRSS(pid)
+	}
+	lspRSSGauge.Set(float64(rss))
+
+	sm.procMu.Lock()
+	restarts := sm.restartCount
+	sm.procMu.Unlock()
+	lspRestartsGauge.Set(float64(restarts))
+
+	sm.indexingMu.RLock()
+	state := sm.indexingStateLocked()
+	current, total := sm.indexingCurrent, sm.indexingTotal
+	sm.indexingMu.RUnlock()
+	for _, s := range indexingStates {
+		value := 0.0
+		if s == state {
+			value = 1
+		}
+		indexingStateGauge.Set(value, s)
+	}
+	indexingFilesGauge.Set(float64(current), "current")
+	indexingFilesGauge.Set(float64(total), "total")
+
+	return map[string]interface{}{
+		"queueDepth":         int(apiQueueDepth.Value()),
+		"inflightRequests":   int(apiInflight.Value()),
+		"pendingLspRequests": pending,
+		"lspRssBytes":        rss,
+	}
+}
@@ -1,7 +1,7 @@
 package main
 
 import (
-	"log"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -32,7 +32,8 @@ type PollingWatcher struct {
 	interval       time.Duration
 	workers        int
 	sendNotifyFunc func(changes []FileChange) error
-	isIndexingFunc func() bool // Check if LSP is currently indexing
+	isIndexingFunc func() bool    // Check if LSP is currently indexing
+	journal        *ChangeJournal // Изменения, ожидающие отправки (общий с fsnotify)
 
 	mu       sync.RWMutex
 	fileMap  map[string]int64 // path -> mtime
@@ -47,7 +48,10 @@ type FileChange struct {
 }
 
 // NewPollingWatcher создаёт новый polling watcher
-func NewPollingWatcher(workspaceDir string, interval time.Duration, workers int, notifyFunc func([]FileChange) error, isIndexingFunc func() bool) *PollingWatcher {
+func NewPollingWatcher(workspaceDir string, interval time.Duration, workers int, journal *ChangeJournal, notifyFunc func([]FileChange) error, isIndexingFunc func() bool) *PollingWatcher {
+	if journal == nil {
+		journal = NewChangeJournal()
+	}
 	return &PollingWatcher{
 		workspaceDir:   workspaceDir,
 		extensions:     []string{".bsl", ".os"},
@@ -55,6 +59,7 @@ func NewPollingWatcher(workspaceDir string, interval time.Duration, workers int,
 		workers:        workers,
 		sendNotifyFunc: notifyFunc,
 		isIndexingFunc: isIndexingFunc,
+		journal:        journal,
 		fileMap:        make(map[string]int64),
 		stopChan:       make(chan struct{}),
 	}
@@ -70,7 +75,7 @@ func (pw *PollingWatcher) Start() error {
 	pw.running = true
 	pw.mu.Unlock()
 
-	log.Printf("Polling watcher starting (interval: %v, workers: %d)", pw.interval, pw.workers)
+	slog.Info("Polling watcher starting", "interval", pw.interval.String(), "workers", pw.workers)
 
 	// Первоначальное сканирование
 	start := time.Now()
@@ -81,7 +86,7 @@ func (pw *PollingWatcher) Start() error {
 	pw.fileMap = initialFiles
 	pw.mu.Unlock()
 
-	log.Printf("Polling watcher initial scan: %d files in %v", len(initialFiles), elapsed)
+	slog.Info("Polling watcher initial scan", "files", len(initialFiles), "duration_ms", elapsed.Milliseconds())
 
 	// Запуск периодического сканирования
 	go pw.runPollingLoop()
@@ -100,7 +105,7 @@ func (pw *PollingWatcher) Stop() {
 	pw.mu.Unlock()
 
 	close(pw.stopChan)
-	log.Println("Polling watcher stopped")
+	slog.Info("Polling watcher stopped")
 }
 
 // runPollingLoop выполняет периодическое сканирование
@@ -139,13 +144,13 @@ func (pw *PollingWatcher) checkForChanges() {
 			// Новый файл
 			changes = append(changes, FileChange{
 				URI:  pathToURI(path),
-				Type: 1, // Created
+				Type: FileChangeCreated,
 			})
 		} else if newMtime != oldMtime {
 			// Изменённый файл
 			changes = append(changes, FileChange{
 				URI:  pathToURI(path),
-				Type: 2, // Changed
+				Type: FileChangeChanged,
 			})
 		}
 	}
@@ -155,37 +160,39 @@ func (pw *PollingWatcher) checkForChanges() {
 		if _, exists := newFiles[path]; !exists {
 			changes = append(changes, FileChange{
 				URI:  pathToURI(path),
-				Type: 3, // Deleted
+				Type: FileChangeDeleted,
 			})
 		}
 	}
 
 	if len(changes) > 0 {
-		log.Printf("Polling watcher detected %d changes (scan: %v)", len(changes), scanTime)
+		slog.Info("Polling watcher detected changes", "changes", len(changes), "scan_ms", scanTime.Milliseconds())
 		for _, c := range changes {
 			changeType := "?"
 			switch c.Type {
-			case 1:
+			case FileChangeCreated:
 				changeType = "created"
-			case 2:
+			case FileChangeChanged:
 				changeType = "changed"
-			case 3:
+			case FileChangeDeleted:
 				changeType = "deleted"
 			}
-			log.Printf("  %s: %s", changeType, c.URI)
+			slog.Debug("Polling watcher change", "type", changeType, "uri", c.URI)
 		}
 
-		// Skip sending notifications while LSP is indexing
-		if pw.isIndexingFunc != nil && pw.isIndexingFunc() {
-			log.Printf("Polling watcher: skipping notification (LSP is indexing)")
-			return
-		}
+		pw.journal.Record(changes...)
+	}
 
-		if pw.sendNotifyFunc != nil {
-			if err := pw.sendNotifyFunc(changes); err != nil {
-				log.Printf("Error sending file changes notification: %v", err)
-			}
+	// Пока LSP индексирует, изменения копятся в журнале и уходят после окончания индексации
+	if pw.isIndexingFunc != nil && pw.isIndexingFunc() {
+		if pending := pw.journal.Len(); pending > 0 {
+			slog.Info("Polling watcher: LSP is indexing, changes deferred", "pending", pending)
 		}
+		return
+	}
+
+	if _, err := pw.journal.Flush(pw.isIndexingFunc, pw.sendNotifyFunc); err != nil {
+		slog.Warn("Error sending file changes notification", "error", err)
 	}
 }
 
@@ -297,7 +304,7 @@ func GetFileWatcherMode() FileWatcherMode {
 	case "auto", "":
 		return WatcherModeAuto
 	default:
-		log.Printf("Unknown FILE_WATCHER_MODE '%s', using 'auto'", mode)
+		slog.Warn("Unknown FILE_WATCHER_MODE, using auto", "mode", mode)
 		return WatcherModeAuto
 	}
 }
@@ -310,7 +317,7 @@ func GetPollingInterval() time.Duration {
 	}
 	interval, err := time.ParseDuration(intervalStr)
 	if err != nil {
-		log.Printf("Invalid FILE_WATCHER_INTERVAL '%s', using 30s", intervalStr)
+		slog.Warn("Invalid FILE_WATCHER_INTERVAL, using 30s", "value", intervalStr)
 		return 30 * time.Second
 	}
 	return interval
@@ -324,7 +331,7 @@ func GetPollingWorkers() int {
 	}
 	workers, err := strconv.Atoi(workersStr)
 	if err != nil || workers < 1 {
-		log.Printf("Invalid FILE_WATCHER_WORKERS '%s', using 8", workersStr)
+		slog.Warn("Invalid FILE_WATCHER_WORKERS, using 8", "value", workersStr)
 		return 8
 	}
 	return workers
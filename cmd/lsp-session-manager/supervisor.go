@@ -0,0 +1,332 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"time"
+)
+
+// Supervisor states reported in session/status
+const (
+	processStateStarting   = "starting"
+	processStateRunning    = "running"
+	processStateRestarting = "restarting"
+	processStateFailed     = "failed"
+	processStateStopped    = "stopped"
+)
+
+const (
+	// defaultMaxRestartAttempts and defaultRestartDelay are used when the
+	// bridge config does not set global.max_restart_attempts / restart_delay_ms
+	defaultMaxRestartAttempts = 3
+	defaultRestartDelay       = 2 * time.Second
+
+	// maxRestartDelay caps the exponential backoff between restart attempts
+	maxRestartDelay = time.Minute
+
+	// restartStableAfter resets the attempt counter once a restarted server
+	// has stayed up this long, so an occasional OOM does not exhaust the budget
+	restartStableAfter = 10 * time.Minute
+)
+
+// RestartPolicy controls how the supervisor respawns a crashed LSP server
+type RestartPolicy struct {
+	MaxAttempts int
+	Delay       time.Duration
+}
+
+// loadRestartPolicy reads global.max_restart_attempts and global.restart_delay_ms
+// from the bridge config file. Missing file or fields fall back to defaults.
+func loadRestartPolicy(path string) RestartPolicy {
+	policy := RestartPolicy{
+		MaxAttempts: defaultMaxRestartAttempts,
+		Delay:       defaultRestartDelay,
+	}
+	if path == "" {
+		return policy
+	}
+
+	data, err := os.ReadFile(path) // #nosec G304 - path comes from a command line flag
+	if err != nil {
+		slog.Warn("Restart policy: config not readable, using defaults", "path", path, "error", err)
+		return policy
+	}
+
+	var cfg struct {
+		Global struct {
+			MaxRestartAttempts int `json:"max_restart_attempts"`
+			RestartDelayMs     int `json:"restart_delay_ms"`
+		} `json:"global"`
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		slog.Warn("Restart policy: failed to parse config, using defaults", "path", path, "error", err)
+		return policy
+	}
+
+	if cfg.Global.MaxRestartAttempts > 0 {
+		policy.MaxAttempts = cfg.Global.MaxRestartAttempts
+	}
+	if cfg.Global.RestartDelayMs > 0 {
+		policy.Delay = time.Duration(cfg.Global.RestartDelayMs) * time.Millisecond
+	}
+	return policy
+}
+
+// backoff returns the delay before the given (1-based) restart attempt
+func (p RestartPolicy) backoff(attempt int) time.Duration {
+	delay := p.Delay
+	for i := 1; i < attempt && delay < maxRestartDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, maxRestartDelay)
+}
+
+// lspProcess is one generation of the spawned LSP server
+type lspProcess struct {
+	cmd        *exec.Cmd
+	startedAt  time.Time
+	readerErr  error         // set before readerDone is closed
+	readerDone chan struct{} // closed when readResponses returns
+}
+
+// wait blocks until the process is gone and returns a human readable exit reason.
+// Stdout must be drained before cmd.Wait, so the reader is awaited first.
+func (p *lspProcess) wait() string {
+	<-p.readerDone
+	waitErr := p.cmd.Wait()
+
+	reason := "exited with status 0"
+	if waitErr != nil {
+		reason = waitErr.Error()
+	}
+	if p.readerErr != nil && !errors.Is(p.readerErr, io.EOF) {
+		reason = fmt.Sprintf("stdout broken (%v), %s", p.readerErr, reason)
+	}
+	return reason
+}
+
+// spawn starts a new LSP server process and its response reader
+func (sm *SessionManager) spawn() (*lspProcess, error) {
+	cmd := exec.Command(sm.command, sm.args...)
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stdin pipe: %w", err)
+	}
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stdout pipe: %w", err)
+	}
+
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start LSP server: %w", err)
+	}
+	slog.Info("LSP server started", "pid", cmd.Process.Pid)
+
+	sm.mu.Lock()
+	sm.cmd = cmd
+	sm.stdin = stdin
+	sm.stdout = stdout
+	sm.mu.Unlock()
+
+	proc := &lspProcess{
+		cmd:        cmd,
+		startedAt:  time.Now(),
+		readerDone: make(chan struct{}),
+	}
+
+	go func() {
+		defer close(proc.readerDone)
+		proc.readerErr = sm.readResponses(stdout)
+		// A broken stdout leaves the process unusable even if it is still alive
+		_ = cmd.Process.Kill()
+	}()
+
+	return proc, nil
+}
+
+// supervise waits for the LSP server to exit and respawns it with backoff
+func (sm *SessionManager) supervise(proc *lspProcess) {
+	attempts := 0
+
+	for {
+		reason := proc.wait()
+
+		if sm.isStopping() {
+			sm.setProcessState(processStateStopped)
+			return
+		}
+
+		slog.Error("LSP server died", "pid", proc.cmd.Process.Pid, "reason", reason)
+
+		sm.mu.Lock()
+		sm.initialized = false
+		sm.mu.Unlock()
+
+		sm.procMu.Lock()
+		sm.processState = processStateRestarting
+		sm.lastExitReason = reason
+		sm.lastExitAt = time.Now()
+		sm.procMu.Unlock()
+
+		sm.failAllPending(fmt.Errorf("LSP server exited: %s", reason))
+
+		if time.Since(proc.startedAt) >= restartStableAfter {
+			attempts = 0
+		}
+
+		next, err := sm.restart(&attempts)
+		if err != nil {
+			slog.Error("LSP server supervisor giving up", "error", err)
+			sm.setProcessState(processStateFailed)
+			return
+		}
+		proc = next
+	}
+}
+
+// restart respawns and re-initializes the LSP server, retrying with exponential backoff
+func (sm *SessionManager) restart(attempts *int) (*lspProcess, error) {
+	for *attempts < sm.restartPolicy.MaxAttempts {
+		*attempts++
+		delay := sm.restartPolicy.backoff(*attempts)
+		slog.Info("Restarting LSP server", "delay", delay.String(), "attempt", *attempts, "max_attempts", sm.restartPolicy.MaxAttempts)
+		time.Sleep(delay)
+
+		if sm.isStopping() {
+			return nil, fmt.Errorf("session manager is stopping")
+		}
+
+		// Clear what belongs to the crashed server before the new one can publish:
+		// it indexes the workspace from disk, so queued file events are stale, and it
+		// publishes diagnostics again once the documents are replayed
+		if dropped := len(sm.changeJournal.Drain()); dropped > 0 {
+			slog.Info("Dropped pending file changes after restart", "changes", dropped)
+		}
+		sm.diagnostics.Reset()
+
+		proc, err := sm.spawn()
+		if err != nil {
+			slog.Warn("Restart attempt failed", "attempt", *attempts, "error", err)
+			continue
+		}
+
+		if err := sm.initialize(); err != nil {
+			slog.Warn("Restart attempt failed to initialize", "attempt", *attempts, "error", err)
+			_ = proc.cmd.Process.Kill()
+			proc.wait()
+			sm.failAllPending(fmt.Errorf("LSP server restart failed: %w", err))
+			continue
+		}
+
+		sm.replayOpenDocuments()
+
+		sm.procMu.Lock()
+		sm.restartCount++
+		sm.processState = processStateRunning
+		sm.procMu.Unlock()
+
+		slog.Info("LSP server restarted", "pid", proc.cmd.Process.Pid)
+		return proc, nil
+	}
+
+	return nil, fmt.Errorf("exhausted %d restart attempts", sm.restartPolicy.MaxAttempts)
+}
+
+// replayOpenDocuments re-sends didOpen for every document the clients had open
+func (sm *SessionManager) replayOpenDocuments() {
+	sm.openDocsMu.Lock()
+	docs := make([]openDocument, 0, len(sm.openDocs))
+	for _, doc := range sm.openDocs {
+		docs = append(docs, doc)
+	}
+	sm.openDocsMu.Unlock()
+
+	for _, doc := range docs {
+		params := map[string]interface{}{
+			"textDocument": map[string]interface{}{
+				"uri":        doc.URI,
+				"languageId": doc.LanguageID,
+				"version":    doc.Version,
+				"text":       doc.Text,
+			},
+		}
+		if err := sm.sendNotification("textDocument/didOpen", params); err != nil {
+			slog.Warn("Failed to replay didOpen", "uri", doc.URI, "error", err)
+		}
+	}
+
+	if len(docs) > 0 {
+		slog.Info("Replayed open documents after restart", "documents", len(docs))
+	}
+}
+
+// failAllPending fails every in-flight LSP request with the given error
+func (sm *SessionManager) failAllPending(err error) {
+	sm.pendingMu.Lock()
+	defer sm.pendingMu.Unlock()
+
+	for id, ch := range sm.pending {
+		select {
+		case ch <- lspResponse{Err: &lspError{Code: -32000, Message: err.Error()}}:
+		default:
+		}
+		delete(sm.pending, id)
+	}
+}
+
+// isStopping returns true once Stop has been called
+func (sm *SessionManager) isStopping() bool {
+	sm.procMu.Lock()
+	defer sm.procMu.Unlock()
+	return sm.stopping
+}
+
+// setProcessState updates the supervisor state reported in session/status
+func (sm *SessionManager) setProcessState(state string) {
+	sm.procMu.Lock()
+	sm.processState = state
+	sm.procMu.Unlock()
+}
+
+// processReady returns an error while the LSP server is not accepting requests
+func (sm *SessionManager) processReady() error {
+	sm.procMu.Lock()
+	defer sm.procMu.Unlock()
+
+	switch sm.processState {
+	case processStateRunning:
+		return nil
+	case processStateRestarting:
+		return fmt.Errorf("LSP server is restarting after crash: %s", sm.lastExitReason)
+	case processStateFailed:
+		return fmt.Errorf("LSP server is down after %d restart attempts: %s", sm.restartPolicy.MaxAttempts, sm.lastExitReason)
+	default:
+		return fmt.Errorf("LSP server is %s", sm.processState)
+	}
+}
+
+// supervisorStatus returns restart information for session/status
+func (sm *SessionManager) supervisorStatus() map[string]interface{} {
+	sm.procMu.Lock()
+	defer sm.procMu.Unlock()
+
+	status := map[string]interface{}{
+		"state":              sm.processState,
+		"restarts":           sm.restartCount,
+		"maxRestartAttempts": sm.restartPolicy.MaxAttempts,
+	}
+	if sm.lastExitReason != "" {
+		status["lastExitReason"] = sm.lastExitReason
+		status["lastExitAt"] = sm.lastExitAt.Format(time.RFC3339)
+	}
+	return status
+}
@@ -12,12 +12,8 @@ echo "Workspace: ${WORKSPACE_ROOT:-/projects}"
 prewarm() {
   echo "Pre-warming BSL Language Server (this may take a while for large projects)..."
   
-  # Send initialize + lsp_status request to trigger LSP connection
-  INIT_REQ='{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2024-11-05","clientInfo":{"name":"prewarm","version":"1.0"},"capabilities":{}}}'
-  STATUS_REQ='{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"lsp_status","arguments":{}}}'
-  
-  # Run mcp-lsp-bridge with timeout, send requests
-  printf '%s\n%s\n' "$INIT_REQ" "$STATUS_REQ" | timeout 120 mcp-lsp-bridge 2>/dev/null || true
+  # Call lsp_status directly; the tool subcommand connects the language servers first
+  timeout 120 mcp-lsp-bridge tool lsp_status >/dev/null 2>&1 || true
   
   echo "Pre-warming complete."
 }
@@ -1,6 +1,7 @@
 package interfaces
 
 import (
+	"context"
 	"encoding/json"
 
 	"rockerboo/mcp-lsp-bridge/types"
@@ -24,21 +25,40 @@ type BridgeInterface interface {
 	CodeInspector
 	CallHierarchyProvider
 	PathMapperProvider
+
+	// WithContext returns a bridge whose language server requests start from ctx, so
+	// they carry its deadline and correlation id. It shares the clients and documents
+	// of the bridge.
+	WithContext(ctx context.Context) BridgeInterface
 }
 
 type InformationProvider interface {
 	SemanticTokens(uri string, targetTypes []string, startLine, startCharacter, endLine, endCharacter uint32) ([]types.TokenPosition, error)
+	// PositionEncoding is how the language server for uri counts characters in positions
+	PositionEncoding(uri string) protocol.PositionEncodingKind
 	GetCodeActions(uri string, line, character, endLine, endCharacter uint32) ([]protocol.CodeAction, error)
 }
 type CallHierarchyProvider interface {
 	PrepareCallHierarchy(uri string, line, character uint32) ([]protocol.CallHierarchyItem, error)
 	IncomingCalls(item protocol.CallHierarchyItem) ([]protocol.CallHierarchyIncomingCall, error)
 	OutgoingCalls(item protocol.CallHierarchyItem) ([]protocol.CallHierarchyOutgoingCall, error)
+
+	// The Context variants cancel the LSP request together with ctx
+	PrepareCallHierarchyContext(ctx context.Context, uri string, line, character uint32) ([]protocol.CallHierarchyItem, error)
+	IncomingCallsContext(ctx context.Context, item protocol.CallHierarchyItem) ([]protocol.CallHierarchyIncomingCall, error)
+	OutgoingCallsContext(ctx context.Context, item protocol.CallHierarchyItem) ([]protocol.CallHierarchyOutgoingCall, error)
+
+	// IndexedIncomingCalls and IndexedOutgoingCalls answer from the call graph index of the
+	// language client. indexed is false when the caller must query the server instead.
+	IndexedIncomingCalls(ctx context.Context, item protocol.CallHierarchyItem) (calls []protocol.CallHierarchyIncomingCall, indexed bool, err error)
+	IndexedOutgoingCalls(ctx context.Context, item protocol.CallHierarchyItem) (calls []protocol.CallHierarchyOutgoingCall, indexed bool, err error)
 }
 
 type CodeInspector interface {
 	GetHoverInformation(uri string, line, character uint32) (*protocol.Hover, error)
 	GetSignatureHelp(uri string, line, character uint32) (*protocol.SignatureHelp, error)
+	Completion(uri string, line, character uint32) (*protocol.CompletionList, error)
+	ResolveCompletionItem(uri string, item protocol.CompletionItem) (*protocol.CompletionItem, error)
 }
 
 type EditProvider interface {
@@ -47,13 +67,15 @@ type EditProvider interface {
 	ApplyTextEdits(uri string, edits []protocol.TextEdit) error
 	RenameSymbol(uri string, line, character uint32, newName string, preview bool) (*protocol.WorkspaceEdit, error)
 	PrepareRename(uri string, line, character uint32) (*protocol.PrepareRenameResult, error)
-	ApplyWorkspaceEdit(edit *protocol.WorkspaceEdit) error
+	ApplyWorkspaceEdit(edit *protocol.WorkspaceEdit) (*types.WorkspaceEditResult, error)
 }
 
 type DocumentFeaturesProvider interface {
 	FoldingRange(uri string) ([]protocol.FoldingRange, error)
 	SelectionRange(uri string, positions []protocol.Position) ([]protocol.SelectionRange, error)
 	DocumentLink(uri string) ([]protocol.DocumentLink, error)
+	CodeLens(uri string) ([]protocol.CodeLens, error)
+	ResolveCodeLens(uri string, lens protocol.CodeLens) (*protocol.CodeLens, error)
 	DocumentColor(uri string) ([]protocol.ColorInformation, error)
 	ColorPresentation(uri string, color protocol.Color, rng protocol.Range) ([]protocol.ColorPresentation, error)
 }
@@ -76,6 +98,9 @@ type SymbolNavigator interface {
 	FindSymbolReferences(language, uri string, line, character uint32, includeDeclaration bool) ([]protocol.Location, error)
 	FindSymbolDefinitions(language, uri string, line, character uint32) ([]protocol.Or2[protocol.LocationLink, protocol.Location], error)
 	FindImplementations(uri string, line, character uint32) ([]protocol.Location, error)
+	FindDeclarations(uri string, line, character uint32) ([]protocol.Or2[protocol.LocationLink, protocol.Location], error)
+	FindTypeDefinitions(uri string, line, character uint32) ([]protocol.Or2[protocol.LocationLink, protocol.Location], error)
+	DocumentHighlight(uri string, line, character uint32) ([]protocol.DocumentHighlight, error)
 }
 
 type DirectoryManager interface {
@@ -0,0 +1,92 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandlerAddsCorrelationID(t *testing.T) {
+	var buf bytes.Buffer
+	log := slog.New(NewHandler(&buf, "json", slog.LevelDebug))
+
+	ctx := WithCorrelationID(context.Background(), "abc123")
+	log.InfoContext(ctx, "tool call started", "tool", "hover")
+	log.Info("no id")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+
+	var first map[string]any
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &first))
+	assert.Equal(t, "abc123", first[CorrelationKey])
+	assert.Equal(t, "hover", first["tool"])
+	assert.Regexp(t, `^correlation_test\.go:\d+$`, first["source"])
+	assert.NotContains(t, lines[1], CorrelationKey)
+}
+
+func TestCorrelationID(t *testing.T) {
+	assert.Equal(t, "", CorrelationID(context.Background()))
+
+	ctx := WithCorrelationID(context.Background(), "outer")
+	assert.Equal(t, "outer", CorrelationID(ctx))
+	assert.Equal(t, "inner", CorrelationID(WithCorrelationID(ctx, "inner")))
+	assert.Equal(t, "outer", CorrelationID(WithCorrelationID(ctx, "")), "an empty id keeps the outer one")
+	assert.Len(t, NewCorrelationID(), 16)
+}
+
+func TestLoggerWritesJSON(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "json.log")
+	require.NoError(t, InitLogger(LoggerConfig{LogPath: logPath, LogLevel: "debug"}))
+	defer Close()
+
+	Debug("plain", "message")
+	DebugContext(WithCorrelationID(context.Background(), "feedbeef"), "structured", "method", "textDocument/hover")
+
+	content, err := os.ReadFile(logPath) // #nosec G304 - Test file path controlled by test
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	require.Len(t, lines, 2)
+
+	var plain, structured map[string]any
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &plain))
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &structured))
+	assert.Equal(t, "plain message", plain["msg"])
+	assert.Equal(t, "DEBUG", plain["level"])
+	assert.Regexp(t, `^correlation_test\.go:\d+$`, plain["source"], "the caller, not the logger, is the source")
+	assert.Equal(t, "textDocument/hover", structured["method"])
+	assert.Equal(t, "feedbeef", structured[CorrelationKey])
+}
+
+func TestRedactJSON(t *testing.T) {
+	raw := `{"textDocument":{"uri":"file:///a.bsl","text":"Процедура А()"},"contentChanges":[{"text":"x"}],"edits":[{"newText":"yy"}]}`
+	redacted := RedactJSON([]byte(raw))
+	assert.NotContains(t, redacted, "Процедура")
+	assert.Contains(t, redacted, `"text":"<redacted 23 bytes>"`)
+	assert.Contains(t, redacted, `"newText":"<redacted 2 bytes>"`)
+	assert.Contains(t, redacted, "file:///a.bsl")
+
+	long := RedactJSON([]byte(`{"query":"` + strings.Repeat("a", 3*MaxLoggedPayload) + `"}`))
+	assert.Less(t, len(long), MaxLoggedPayload+64)
+	assert.Equal(t, "<3 bytes, not JSON>", RedactJSON([]byte("{{{")))
+	assert.Equal(t, "", RedactJSON(nil))
+}
+
+func TestSampler(t *testing.T) {
+	s := NewSampler(map[string]uint64{"session/status": 3})
+	var allowed []bool
+	for i := 0; i < 7; i++ {
+		allowed = append(allowed, s.Allow("session/status"))
+	}
+	assert.Equal(t, []bool{true, false, false, true, false, false, true}, allowed)
+	assert.True(t, s.Allow("textDocument/hover"))
+	assert.True(t, s.Allow("textDocument/hover"))
+}
@@ -1,25 +1,31 @@
 package logger
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"runtime"
 	"sort"
+	"strings"
 	"sync"
+	"time"
 )
 
 type LoggerConfig struct {
 	LogPath     string
 	LogLevel    string // "info", "debug", "error"
 	MaxLogFiles int    // Maximum number of log files to keep
+	Format      string // "json" (default) or "text"
 }
 
 var (
 	config      LoggerConfig
-	infoLogger  *log.Logger
-	errorLogger *log.Logger
-	debugLogger *log.Logger
+	infoLogger  *slog.Logger
+	errorLogger *slog.Logger
+	debugLogger *slog.Logger
 	logFile     *os.File
 	logMutex    sync.Mutex
 )
@@ -62,10 +68,11 @@ func InitLogger(cfg LoggerConfig) error {
 	// Store configuration
 	config = cfg
 
-	// Create loggers with timestamps
-	infoLogger = log.New(file, "INFO: ", log.Ldate|log.Ltime|log.Lshortfile)
-	errorLogger = log.New(file, "ERROR: ", log.Ldate|log.Ltime|log.Lshortfile)
-	debugLogger = log.New(file, "DEBUG: ", log.Ldate|log.Ltime|log.Lshortfile)
+	// One structured logger; the level functions below decide what is written
+	structured := slog.New(NewHandler(file, cfg.Format, slog.LevelDebug))
+	infoLogger = structured
+	errorLogger = structured
+	debugLogger = structured
 
 	return nil
 }
@@ -100,37 +107,78 @@ func rotateLogFiles(cfg LoggerConfig) {
 	}
 }
 
+// Level checks keep the historical semantics of log_level: "warn" and "info"
+// include warnings, "debug" and "info" include info messages, errors always pass.
+func infoEnabled() bool  { return config.LogLevel == "info" || config.LogLevel == "debug" }
+func warnEnabled() bool  { return config.LogLevel == "info" || config.LogLevel == "warn" }
+func debugEnabled() bool { return config.LogLevel == "debug" }
+
+// DebugEnabled reports whether debug records are written, to skip building expensive attributes
+func DebugEnabled() bool {
+	return debugEnabled() && debugLogger != nil
+}
+
+// output writes one record with the caller of the exported logging function as source
+func output(ctx context.Context, l *slog.Logger, level slog.Level, msg string, args ...any) {
+	if l == nil {
+		return
+	}
+	var pcs [1]uintptr
+	runtime.Callers(3, pcs[:]) // skip Callers, output and the logging function
+	r := slog.NewRecord(time.Now(), level, strings.TrimSuffix(msg, "\n"), pcs[0])
+	r.Add(args...)
+	_ = l.Handler().Handle(ctx, r)
+}
+
 // Info logs an informational message with caller context
 func Info(v ...any) {
-	if config.LogLevel == "info" || config.LogLevel == "debug" {
-		if infoLogger != nil {
-			_ = infoLogger.Output(2, fmt.Sprintln(v...))
-		}
+	if infoEnabled() {
+		output(context.Background(), infoLogger, slog.LevelInfo, fmt.Sprintln(v...))
 	}
 }
 
 // Warn logs a warning message with caller context
 func Warn(v ...any) {
-	if config.LogLevel == "info" || config.LogLevel == "warn" {
-		if infoLogger != nil {
-			_ = infoLogger.Output(2, fmt.Sprintln(v...))
-		}
+	if warnEnabled() {
+		output(context.Background(), infoLogger, slog.LevelWarn, fmt.Sprintln(v...))
 	}
 }
 
 // Error logs an error message with caller context
 func Error(v ...any) {
-	if errorLogger != nil {
-		_ = errorLogger.Output(2, fmt.Sprintln(v...))
-	}
+	output(context.Background(), errorLogger, slog.LevelError, fmt.Sprintln(v...))
 }
 
 // Debug logs a debug message with caller context
 func Debug(v ...any) {
-	if config.LogLevel == "debug" {
-		if debugLogger != nil {
-			_ = debugLogger.Output(2, fmt.Sprintln(v...))
-		}
+	if debugEnabled() {
+		output(context.Background(), debugLogger, slog.LevelDebug, fmt.Sprintln(v...))
+	}
+}
+
+// InfoContext logs msg with key-value attributes and the correlation id of ctx
+func InfoContext(ctx context.Context, msg string, args ...any) {
+	if infoEnabled() {
+		output(ctx, infoLogger, slog.LevelInfo, msg, args...)
+	}
+}
+
+// WarnContext logs a warning with key-value attributes and the correlation id of ctx
+func WarnContext(ctx context.Context, msg string, args ...any) {
+	if warnEnabled() {
+		output(ctx, infoLogger, slog.LevelWarn, msg, args...)
+	}
+}
+
+// ErrorContext logs an error with key-value attributes and the correlation id of ctx
+func ErrorContext(ctx context.Context, msg string, args ...any) {
+	output(ctx, errorLogger, slog.LevelError, msg, args...)
+}
+
+// DebugContext logs a debug message with key-value attributes and the correlation id of ctx
+func DebugContext(ctx context.Context, msg string, args ...any) {
+	if debugEnabled() {
+		output(ctx, debugLogger, slog.LevelDebug, msg, args...)
 	}
 }
 
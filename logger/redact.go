@@ -0,0 +1,104 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// MaxLoggedPayload caps the JSON written for one request or response
+const MaxLoggedPayload = 2048
+
+// redactedKeys hold document text in LSP messages: didOpen textDocument.text,
+// didChange contentChanges[].text and newText of text edits
+var redactedKeys = map[string]bool{"text": true, "newText": true}
+
+// RedactJSON returns a JSON message for logging with document text replaced by
+// its size and the result truncated to MaxLoggedPayload bytes
+func RedactJSON(raw []byte) string {
+	if len(raw) == 0 {
+		return ""
+	}
+	var v any
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return fmt.Sprintf("<%d bytes, not JSON>", len(raw))
+	}
+	// Without HTML escaping the "<redacted N bytes>" markers stay readable
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(redact(v)); err != nil {
+		return fmt.Sprintf("<%d bytes>", len(raw))
+	}
+	out := bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+	if len(out) > MaxLoggedPayload {
+		return fmt.Sprintf("%s...<%d bytes>", out[:MaxLoggedPayload], len(out))
+	}
+	return string(out)
+}
+
+// Redact marshals v and redacts it like RedactJSON
+func Redact(v any) string {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("<%T>", v)
+	}
+	return RedactJSON(raw)
+}
+
+func redact(v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		for k, val := range t {
+			if s, ok := val.(string); ok && redactedKeys[k] {
+				t[k] = fmt.Sprintf("<redacted %d bytes>", len(s))
+				continue
+			}
+			t[k] = redact(val)
+		}
+	case []any:
+		for i := range t {
+			t[i] = redact(t[i])
+		}
+	}
+	return v
+}
+
+// DefaultSampling logs one in n successful calls of methods that are sent
+// continuously: status polling, edits while typing, file watcher batches, progress
+var DefaultSampling = map[string]uint64{
+	"session/status":                  20,
+	"session/diagnostics":             10,
+	"textDocument/didChange":          10,
+	"workspace/didChangeWatchedFiles": 10,
+	"textDocument/publishDiagnostics": 20,
+	"$/progress":                      50,
+}
+
+// Sampler lets through the first and then every n-th record per key. Keys
+// without a rate always pass; errors should be logged without asking it.
+type Sampler struct {
+	every map[string]uint64
+
+	mu   sync.Mutex
+	seen map[string]uint64
+}
+
+// NewSampler creates a sampler with a rate per key
+func NewSampler(every map[string]uint64) *Sampler {
+	return &Sampler{every: every, seen: make(map[string]uint64)}
+}
+
+// Allow reports whether the next record for key should be written
+func (s *Sampler) Allow(key string) bool {
+	n, ok := s.every[key]
+	if !ok || n <= 1 {
+		return true
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	seen := s.seen[key]
+	s.seen[key] = seen + 1
+	return seen%n == 0
+}
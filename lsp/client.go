@@ -162,8 +162,12 @@ func (lc *LanguageClient) Connect() (types.LanguageClientInterface, error) {
 	if lc.progress == nil {
 		lc.progress = NewProgressTracker()
 	}
+	if lc.diagnostics == nil {
+		lc.diagnostics = NewDiagnosticsStore()
+	}
 	handler := &ClientHandler{
-		progress: lc.progress,
+		progress:    lc.progress,
+		diagnostics: lc.diagnostics,
 	}
 
 	// Create JSON-RPC connection using VSCode Object Codec for LSP headers
@@ -361,6 +365,13 @@ func (lc *LanguageClient) SetServerCapabilities(capabilities protocol.ServerCapa
 
 // SendRequest sends a request with timeout
 func (lc *LanguageClient) SendRequest(method string, params any, result any, timeout time.Duration) error {
+	return lc.SendRequestContext(context.Background(), method, params, result, timeout)
+}
+
+// SendRequestContext sends a request bounded by both ctx and timeout.
+// When the request is abandoned while the connection is alive, the server gets
+// a $/cancelRequest for it so it can stop the work.
+func (lc *LanguageClient) SendRequestContext(ctx context.Context, method string, params any, result any, timeout time.Duration) error {
 
 	// Increment total requests
 	atomic.AddInt64(&lc.totalRequests, 1)
@@ -391,9 +402,17 @@ func (lc *LanguageClient) SendRequest(method string, params any, result any, tim
 	default:
 	}
 
-	reqCtx, cancel := context.WithTimeout(lc.ctx, timeout)
+	reqCtx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
-	err := lc.conn.Call(reqCtx, method, params, result)
+	// Closing the client aborts the call too
+	stop := context.AfterFunc(lc.ctx, cancel)
+	defer stop()
+
+	id := jsonrpc2.ID{Num: uint64(atomic.AddInt64(&lc.requestSeq, 1))}
+	err := lc.conn.Call(reqCtx, method, params, result, jsonrpc2.PickID(id))
+	if err != nil && reqCtx.Err() != nil && lc.ctx.Err() == nil {
+		lc.cancelRequest(method, id)
+	}
 
 	// Update status and metrics with brief locks
 	if err != nil {
@@ -424,6 +443,13 @@ func (lc *LanguageClient) SendRequest(method string, params any, result any, tim
 	return err
 }
 
+// cancelRequest notifies the server that the response for id is no longer needed
+func (lc *LanguageClient) cancelRequest(method string, id jsonrpc2.ID) {
+	if err := lc.conn.Notify(lc.ctx, "$/cancelRequest", protocol.CancelParams{Id: protocol.Or2[int32, string]{Value: int32(id.Num)}}); err != nil {
+		logger.Warn(fmt.Sprintf("Failed to send $/cancelRequest for %s (id=%d): %v", method, id.Num, err))
+	}
+}
+
 // SendNotification sends a notification
 func (lc *LanguageClient) SendNotification(method string, params any) error {
 	if len(method) == 0 {
@@ -438,6 +464,12 @@ func (lc *LanguageClient) Context() context.Context {
 	return lc.ctx
 }
 
+// WithContext returns lc: requests go straight to the language server, which has
+// no use for a correlation id, and time out on their own
+func (lc *LanguageClient) WithContext(ctx context.Context) types.LanguageClientInterface {
+	return lc
+}
+
 // GetMetrics returns the current metrics for the language client
 func (lc *LanguageClient) GetMetrics() types.ClientMetricsProvider {
 	lc.mu.RLock()
@@ -489,3 +521,14 @@ func (lc *LanguageClient) ProgressSnapshot() ProgressSnapshot {
 	}
 	return lc.progress.Snapshot()
 }
+
+// PublishedDiagnostics returns diagnostics pushed by the server via textDocument/publishDiagnostics.
+// Like ProgressSnapshot it is not part of the public interface; use type assertions.
+func (lc *LanguageClient) PublishedDiagnostics(filter DiagnosticsFilter) (*DiagnosticsSnapshot, error) {
+	if lc.diagnostics == nil {
+		return &DiagnosticsSnapshot{Documents: []PublishedDiagnostics{}}, nil
+	}
+	snapshot := lc.diagnostics.Query(filter)
+	snapshot.Fresh = snapshot.Tracked > 0 && len(lc.ProgressSnapshot().Active) == 0
+	return &snapshot, nil
+}
@@ -0,0 +1,172 @@
+package lsp
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"rockerboo/mcp-lsp-bridge/utils"
+
+	"github.com/myleshyson/lsprotocol-go/protocol"
+)
+
+// PublishedDiagnostics is the latest textDocument/publishDiagnostics for one document.
+type PublishedDiagnostics struct {
+	URI         string                `json:"uri"`
+	Version     int32                 `json:"version,omitempty"`
+	ReceivedAt  time.Time             `json:"receivedAt"`
+	Diagnostics []protocol.Diagnostic `json:"diagnostics"`
+}
+
+// DiagnosticsFilter selects published diagnostics. Zero values match everything.
+type DiagnosticsFilter struct {
+	URI      string    // exact document URI
+	Severity int       // keep severities <= this (1=error ... 4=hint)
+	Code     []string  // keep only these diagnostic codes
+	Path     string    // glob over the file path, see utils.MatchGlob
+	Since    time.Time // keep documents published at or after this time
+}
+
+// DiagnosticsSnapshot is the result of querying published diagnostics.
+type DiagnosticsSnapshot struct {
+	Documents []PublishedDiagnostics
+	Total     int       // number of diagnostics in Documents
+	Tracked   int       // documents with any published result, including empty ones
+	UpdatedAt time.Time // when the last publishDiagnostics arrived
+	Fresh     bool      // the server is idle and the results reflect the workspace
+}
+
+// DiagnosticsStore keeps diagnostics pushed by the server, keyed by URI.
+// It is fed by textDocument/publishDiagnostics notifications.
+type DiagnosticsStore struct {
+	mu        sync.RWMutex
+	docs      map[string]PublishedDiagnostics
+	updatedAt time.Time
+}
+
+func NewDiagnosticsStore() *DiagnosticsStore {
+	return &DiagnosticsStore{
+		docs: make(map[string]PublishedDiagnostics),
+	}
+}
+
+// Publish replaces the diagnostics for params.Uri unless they are for an older document version.
+func (ds *DiagnosticsStore) Publish(params protocol.PublishDiagnosticsParams, now time.Time) {
+	uri := string(params.Uri)
+
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+
+	if prev, ok := ds.docs[uri]; ok && prev.Version > 0 && params.Version > 0 && params.Version < prev.Version {
+		return
+	}
+	ds.docs[uri] = PublishedDiagnostics{
+		URI:         uri,
+		Version:     params.Version,
+		ReceivedAt:  now,
+		Diagnostics: params.Diagnostics,
+	}
+	ds.updatedAt = now
+}
+
+// Reset forgets all published diagnostics, used when a restarted server publishes again.
+func (ds *DiagnosticsStore) Reset() {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+
+	ds.docs = make(map[string]PublishedDiagnostics)
+	ds.updatedAt = time.Time{}
+}
+
+// Len returns the number of documents with a published result, including empty ones.
+func (ds *DiagnosticsStore) Len() int {
+	ds.mu.RLock()
+	defer ds.mu.RUnlock()
+	return len(ds.docs)
+}
+
+// Query returns documents with at least one diagnostic matching the filter, sorted by URI.
+func (ds *DiagnosticsStore) Query(filter DiagnosticsFilter) DiagnosticsSnapshot {
+	ds.mu.RLock()
+	defer ds.mu.RUnlock()
+
+	snapshot := DiagnosticsSnapshot{
+		Documents: []PublishedDiagnostics{},
+		Tracked:   len(ds.docs),
+		UpdatedAt: ds.updatedAt,
+	}
+
+	uris := make([]string, 0, len(ds.docs))
+	for uri := range ds.docs {
+		uris = append(uris, uri)
+	}
+	sort.Strings(uris)
+
+	for _, uri := range uris {
+		doc := ds.docs[uri]
+		if !filter.MatchesURI(doc.URI) || (!filter.Since.IsZero() && doc.ReceivedAt.Before(filter.Since)) {
+			continue
+		}
+
+		var matched []protocol.Diagnostic
+		for _, d := range doc.Diagnostics {
+			if filter.MatchesDiagnostic(d) {
+				matched = append(matched, d)
+			}
+		}
+		if len(matched) == 0 {
+			continue
+		}
+
+		doc.Diagnostics = matched
+		snapshot.Documents = append(snapshot.Documents, doc)
+		snapshot.Total += len(matched)
+	}
+
+	return snapshot
+}
+
+// MatchesURI applies the URI and Path filters to a document URI.
+func (f DiagnosticsFilter) MatchesURI(uri string) bool {
+	if f.URI != "" && f.URI != uri {
+		return false
+	}
+	if f.Path != "" && !utils.MatchGlob(f.Path, utils.URIToFilePath(uri)) {
+		return false
+	}
+	return true
+}
+
+// MatchesDiagnostic applies the Severity and Code filters to a single diagnostic.
+func (f DiagnosticsFilter) MatchesDiagnostic(d protocol.Diagnostic) bool {
+	// Severity is optional in LSP; clients treat a missing one as an error
+	if f.Severity > 0 && d.Severity != nil && int(*d.Severity) > f.Severity {
+		return false
+	}
+	if len(f.Code) > 0 {
+		code := DiagnosticCode(d)
+		for _, c := range f.Code {
+			if c == code {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// DiagnosticCode returns the diagnostic code as a string ("" when absent).
+func DiagnosticCode(d protocol.Diagnostic) string {
+	if d.Code == nil {
+		return ""
+	}
+	switch v := d.Code.Value.(type) {
+	case string:
+		return v
+	case int32:
+		return fmt.Sprintf("%d", v)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
@@ -0,0 +1,126 @@
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/myleshyson/lsprotocol-go/protocol"
+	"github.com/sourcegraph/jsonrpc2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func diagnostic(severity protocol.DiagnosticSeverity, code string, message string) protocol.Diagnostic {
+	return protocol.Diagnostic{
+		Severity: &severity,
+		Code:     &protocol.Or2[int32, string]{Value: code},
+		Message:  message,
+	}
+}
+
+func TestDiagnosticsStoreQuery(t *testing.T) {
+	store := NewDiagnosticsStore()
+	early := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	late := early.Add(time.Hour)
+
+	store.Publish(protocol.PublishDiagnosticsParams{
+		Uri: "file:///projects/CommonModules/Common/Ext/Module.bsl",
+		Diagnostics: []protocol.Diagnostic{
+			diagnostic(protocol.DiagnosticSeverityError, "ParseError", "Ошибка разбора"),
+			diagnostic(protocol.DiagnosticSeverityHint, "LineLength", "Line is too long"),
+		},
+	}, early)
+	store.Publish(protocol.PublishDiagnosticsParams{
+		Uri: "file:///projects/Catalogs/Items/Ext/ObjectModule.bsl",
+		Diagnostics: []protocol.Diagnostic{
+			diagnostic(protocol.DiagnosticSeverityWarning, "UnusedLocalVariable", "Unused variable"),
+		},
+	}, late)
+	store.Publish(protocol.PublishDiagnosticsParams{Uri: "file:///projects/Clean.bsl"}, late)
+
+	t.Run("no filter skips documents without diagnostics", func(t *testing.T) {
+		snapshot := store.Query(DiagnosticsFilter{})
+		assert.Equal(t, 3, snapshot.Tracked)
+		assert.Equal(t, 3, snapshot.Total)
+		require.Len(t, snapshot.Documents, 2)
+		assert.Equal(t, "file:///projects/Catalogs/Items/Ext/ObjectModule.bsl", snapshot.Documents[0].URI)
+		assert.Equal(t, late, snapshot.UpdatedAt)
+	})
+
+	t.Run("severity keeps more severe levels", func(t *testing.T) {
+		snapshot := store.Query(DiagnosticsFilter{Severity: int(protocol.DiagnosticSeverityWarning)})
+		assert.Equal(t, 2, snapshot.Total)
+	})
+
+	t.Run("code", func(t *testing.T) {
+		snapshot := store.Query(DiagnosticsFilter{Code: []string{"LineLength"}})
+		require.Len(t, snapshot.Documents, 1)
+		assert.Equal(t, "Line is too long", snapshot.Documents[0].Diagnostics[0].Message)
+	})
+
+	t.Run("path glob", func(t *testing.T) {
+		snapshot := store.Query(DiagnosticsFilter{Path: "CommonModules/**"})
+		require.Len(t, snapshot.Documents, 1)
+		assert.Equal(t, 2, snapshot.Total)
+	})
+
+	t.Run("since", func(t *testing.T) {
+		snapshot := store.Query(DiagnosticsFilter{Since: early.Add(time.Minute)})
+		require.Len(t, snapshot.Documents, 1)
+		assert.Equal(t, "Unused variable", snapshot.Documents[0].Diagnostics[0].Message)
+	})
+}
+
+func TestDiagnosticsStoreIgnoresOlderVersions(t *testing.T) {
+	store := NewDiagnosticsStore()
+	uri := protocol.DocumentUri("file:///projects/Module.bsl")
+	now := time.Now()
+
+	store.Publish(protocol.PublishDiagnosticsParams{
+		Uri:         uri,
+		Version:     3,
+		Diagnostics: []protocol.Diagnostic{diagnostic(protocol.DiagnosticSeverityError, "A", "current")},
+	}, now)
+	store.Publish(protocol.PublishDiagnosticsParams{
+		Uri:         uri,
+		Version:     2,
+		Diagnostics: []protocol.Diagnostic{diagnostic(protocol.DiagnosticSeverityError, "A", "stale")},
+	}, now.Add(time.Second))
+
+	snapshot := store.Query(DiagnosticsFilter{URI: string(uri)})
+	require.Len(t, snapshot.Documents, 1)
+	assert.Equal(t, int32(3), snapshot.Documents[0].Version)
+	assert.Equal(t, "current", snapshot.Documents[0].Diagnostics[0].Message)
+
+	// An empty publish clears the document
+	store.Publish(protocol.PublishDiagnosticsParams{Uri: uri, Version: 4}, now.Add(2*time.Second))
+	assert.Empty(t, store.Query(DiagnosticsFilter{}).Documents)
+}
+
+func TestDiagnosticsStoreReset(t *testing.T) {
+	store := NewDiagnosticsStore()
+	store.Publish(protocol.PublishDiagnosticsParams{Uri: "file:///projects/Module.bsl"}, time.Now())
+	assert.Equal(t, 1, store.Len(), "empty results are tracked")
+
+	store.Reset()
+	assert.Equal(t, 0, store.Len())
+	assert.True(t, store.Query(DiagnosticsFilter{}).UpdatedAt.IsZero())
+}
+
+func TestClientHandlerStoresPublishedDiagnostics(t *testing.T) {
+	store := NewDiagnosticsStore()
+	handler := &ClientHandler{diagnostics: store}
+
+	params := json.RawMessage(`{"uri":"file:///projects/Module.bsl","version":1,"diagnostics":[{"range":{"start":{"line":0,"character":0},"end":{"line":0,"character":5}},"severity":2,"code":"UnusedLocalVariable","message":"Unused variable"}]}`)
+	handler.Handle(context.Background(), nil, &jsonrpc2.Request{
+		Method: "textDocument/publishDiagnostics",
+		Params: &params,
+		Notif:  true,
+	})
+
+	snapshot := store.Query(DiagnosticsFilter{})
+	require.Len(t, snapshot.Documents, 1)
+	assert.Equal(t, "UnusedLocalVariable", DiagnosticCode(snapshot.Documents[0].Diagnostics[0]))
+}
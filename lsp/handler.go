@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/myleshyson/lsprotocol-go/protocol"
 	"github.com/sourcegraph/jsonrpc2"
@@ -12,7 +13,8 @@ import (
 
 // ClientHandler handles incoming messages from the language server
 type ClientHandler struct {
-	progress *ProgressTracker
+	progress    *ProgressTracker
+	diagnostics *DiagnosticsStore
 }
 
 func (h *ClientHandler) Handle(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) {
@@ -50,10 +52,18 @@ func (h *ClientHandler) Handle(ctx context.Context, conn *jsonrpc2.Conn, req *js
 		return
 
 	case "textDocument/publishDiagnostics":
-		// Handle diagnostics
-		var params any
-		if err := json.Unmarshal(*req.Params, &params); err == nil {
-			logger.Debug(fmt.Sprintf("Diagnostics: %+v\n", params))
+		// Keep the latest diagnostics per document for workspace_diagnostics
+		if req.Params == nil {
+			return
+		}
+		var params protocol.PublishDiagnosticsParams
+		if err := json.Unmarshal(*req.Params, &params); err != nil {
+			logger.Debug(fmt.Sprintf("Failed to unmarshal diagnostics params: %v\n", err))
+			return
+		}
+		logger.Debug(fmt.Sprintf("Diagnostics: %d for %s (version %d)\n", len(params.Diagnostics), params.Uri, params.Version))
+		if h.diagnostics != nil {
+			h.diagnostics.Publish(params, time.Now())
 		}
 
 	case "window/showMessage":
@@ -1,6 +1,8 @@
 package lsp
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -51,7 +53,7 @@ func (lc *LanguageClient) Exit() error {
 }
 
 // DidOpen sends a textDocument/didOpen notification
-func (lc *LanguageClient) DidOpen(uri string, languageId protocol.LanguageKind, text string, version int32) error {
+func (lc *LanguageClient) DidOpen(uri string, languageId protocol.LanguageKind, text string, version int32) (int32, error) {
 	params := protocol.DidOpenTextDocumentParams{
 		TextDocument: protocol.TextDocumentItem{
 			Uri:        protocol.DocumentUri(uri),
@@ -61,11 +63,14 @@ func (lc *LanguageClient) DidOpen(uri string, languageId protocol.LanguageKind,
 		},
 	}
 
-	return lc.SendNotification("textDocument/didOpen", params)
+	if err := lc.SendNotification("textDocument/didOpen", params); err != nil {
+		return 0, err
+	}
+	return version, nil
 }
 
 // DidChange sends a textDocument/didChange notification
-func (lc *LanguageClient) DidChange(uri string, version int32, changes []protocol.TextDocumentContentChangeEvent) error {
+func (lc *LanguageClient) DidChange(uri string, version int32, changes []protocol.TextDocumentContentChangeEvent) (int32, error) {
 	params := protocol.DidChangeTextDocumentParams{
 		TextDocument: protocol.VersionedTextDocumentIdentifier{
 			Uri:     protocol.DocumentUri(uri),
@@ -74,7 +79,10 @@ func (lc *LanguageClient) DidChange(uri string, version int32, changes []protoco
 		ContentChanges: changes,
 	}
 
-	return lc.SendNotification("textDocument/didChange", params)
+	if err := lc.SendNotification("textDocument/didChange", params); err != nil {
+		return 0, err
+	}
+	return version, nil
 }
 
 // DidSave sends a textDocument/didSave notification
@@ -263,6 +271,80 @@ func (lc *LanguageClient) Implementation(uri string, line, character uint32) ([]
 	return result, nil
 }
 
+// Declaration finds the declaration of the symbol at a position
+func (lc *LanguageClient) Declaration(uri string, line, character uint32) ([]protocol.Or2[protocol.LocationLink, protocol.Location], error) {
+	params := protocol.DeclarationParams{
+		TextDocument: protocol.TextDocumentIdentifier{Uri: protocol.DocumentUri(uri)},
+		Position:     protocol.Position{Line: line, Character: character},
+	}
+
+	var rawResponse json.RawMessage
+
+	err := lc.SendRequest("textDocument/declaration", params, &rawResponse, 30*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("declaration request failed: %w", err)
+	}
+
+	return decodeLocationResult(rawResponse)
+}
+
+// TypeDefinition finds the definition of the type of the symbol at a position
+func (lc *LanguageClient) TypeDefinition(uri string, line, character uint32) ([]protocol.Or2[protocol.LocationLink, protocol.Location], error) {
+	params := protocol.TypeDefinitionParams{
+		TextDocument: protocol.TextDocumentIdentifier{Uri: protocol.DocumentUri(uri)},
+		Position:     protocol.Position{Line: line, Character: character},
+	}
+
+	var rawResponse json.RawMessage
+
+	err := lc.SendRequest("textDocument/typeDefinition", params, &rawResponse, 30*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("type definition request failed: %w", err)
+	}
+
+	return decodeLocationResult(rawResponse)
+}
+
+// DocumentHighlight returns the occurrences of the symbol at a position within its
+// document, marked as read or write where the server can tell
+func (lc *LanguageClient) DocumentHighlight(uri string, line, character uint32) ([]protocol.DocumentHighlight, error) {
+	params := protocol.DocumentHighlightParams{
+		TextDocument: protocol.TextDocumentIdentifier{Uri: protocol.DocumentUri(uri)},
+		Position:     protocol.Position{Line: line, Character: character},
+	}
+
+	var result []protocol.DocumentHighlight
+
+	err := lc.SendRequest("textDocument/documentHighlight", params, &result, 15*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("document highlight request failed: %w", err)
+	}
+
+	return result, nil
+}
+
+// decodeLocationResult decodes Location | Location[] | LocationLink[] | null
+func decodeLocationResult(raw json.RawMessage) ([]protocol.Or2[protocol.LocationLink, protocol.Location], error) {
+	trimmed := bytes.TrimSpace(raw)
+	if len(trimmed) == 0 || string(trimmed) == "null" {
+		return []protocol.Or2[protocol.LocationLink, protocol.Location]{}, nil
+	}
+
+	if trimmed[0] != '[' {
+		var location protocol.Location
+		if err := json.Unmarshal(trimmed, &location); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal location: %w", err)
+		}
+		return []protocol.Or2[protocol.LocationLink, protocol.Location]{{Value: location}}, nil
+	}
+
+	var locations []protocol.Or2[protocol.LocationLink, protocol.Location]
+	if err := json.Unmarshal(trimmed, &locations); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal locations: %w", err)
+	}
+	return locations, nil
+}
+
 // SignatureHelp provides signature help at a given position
 func (lc *LanguageClient) SignatureHelp(uri string, line, character uint32) (*protocol.SignatureHelp, error) {
 	params := protocol.SignatureHelpParams{
@@ -297,6 +379,88 @@ func (lc *LanguageClient) SignatureHelp(uri string, line, character uint32) (*pr
 	return &result, nil
 }
 
+// Completion requests completion items at a position. Servers answer with a
+// CompletionList or a bare item array; both are returned as a list.
+func (lc *LanguageClient) Completion(uri string, line, character uint32) (*protocol.CompletionList, error) {
+	params := protocol.CompletionParams{
+		TextDocument: protocol.TextDocumentIdentifier{Uri: protocol.DocumentUri(uri)},
+		Position:     protocol.Position{Line: line, Character: character},
+		Context:      &protocol.CompletionContext{TriggerKind: protocol.CompletionTriggerKindInvoked},
+	}
+
+	var rawResponse json.RawMessage
+
+	err := lc.SendRequest("textDocument/completion", params, &rawResponse, 15*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("completion request failed: %w", err)
+	}
+
+	return decodeCompletionResult(rawResponse)
+}
+
+// ResolveCompletionItem fills in the documentation and detail of a completion item
+func (lc *LanguageClient) ResolveCompletionItem(item protocol.CompletionItem) (*protocol.CompletionItem, error) {
+	var result protocol.CompletionItem
+
+	err := lc.SendRequest("completionItem/resolve", item, &result, 10*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("completion item resolve failed: %w", err)
+	}
+
+	return &result, nil
+}
+
+// decodeCompletionResult decodes CompletionItem[] | CompletionList | null
+func decodeCompletionResult(raw json.RawMessage) (*protocol.CompletionList, error) {
+	trimmed := bytes.TrimSpace(raw)
+	if len(trimmed) == 0 || string(trimmed) == "null" {
+		return &protocol.CompletionList{Items: []protocol.CompletionItem{}}, nil
+	}
+
+	if trimmed[0] == '[' {
+		var items []protocol.CompletionItem
+		if err := json.Unmarshal(trimmed, &items); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal completion items: %w", err)
+		}
+		return &protocol.CompletionList{Items: items}, nil
+	}
+
+	var list protocol.CompletionList
+	if err := json.Unmarshal(trimmed, &list); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal completion list: %w", err)
+	}
+	return &list, nil
+}
+
+// CodeLens requests the code lenses of a document. Lenses usually come without a
+// command; ResolveCodeLens fills it in.
+func (lc *LanguageClient) CodeLens(uri string) ([]protocol.CodeLens, error) {
+	params := protocol.CodeLensParams{
+		TextDocument: protocol.TextDocumentIdentifier{Uri: protocol.DocumentUri(uri)},
+	}
+
+	var result []protocol.CodeLens
+
+	err := lc.SendRequest("textDocument/codeLens", params, &result, 30*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("code lens request failed: %w", err)
+	}
+
+	return result, nil
+}
+
+// ResolveCodeLens fills in the command of a code lens
+func (lc *LanguageClient) ResolveCodeLens(lens protocol.CodeLens) (*protocol.CodeLens, error) {
+	var result protocol.CodeLens
+
+	err := lc.SendRequest("codeLens/resolve", lens, &result, 10*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("code lens resolve failed: %w", err)
+	}
+
+	return &result, nil
+}
+
 func (lc *LanguageClient) CodeActions(uri string, line, character, endLine, endCharacter uint32) ([]protocol.CodeAction, error) {
 
 	params := protocol.CodeActionParams{
@@ -341,6 +505,11 @@ func (lc *LanguageClient) Rename(uri string, line, character uint32, newName str
 }
 
 func (lc *LanguageClient) WorkspaceDiagnostic(identifier string) (*protocol.WorkspaceDiagnosticReport, error) {
+	return lc.WorkspaceDiagnosticContext(context.Background(), identifier)
+}
+
+// WorkspaceDiagnosticContext is WorkspaceDiagnostic that is cancelled together with ctx
+func (lc *LanguageClient) WorkspaceDiagnosticContext(ctx context.Context, identifier string) (*protocol.WorkspaceDiagnosticReport, error) {
 	params := protocol.WorkspaceDiagnosticParams{
 		Identifier:        identifier,
 		PreviousResultIds: []protocol.PreviousResultId{}, // Empty for first request
@@ -348,7 +517,7 @@ func (lc *LanguageClient) WorkspaceDiagnostic(identifier string) (*protocol.Work
 
 	var result protocol.WorkspaceDiagnosticReport
 
-	err := lc.SendRequest("workspace/diagnostic", params, &result, 120*time.Second) // Extended timeout for large projects
+	err := lc.SendRequestContext(ctx, "workspace/diagnostic", params, &result, 120*time.Second) // Extended timeout for large projects
 	if err != nil {
 		return nil, fmt.Errorf("workspace diagnostic request failed: %w", err)
 	}
@@ -528,6 +697,11 @@ func (lc *LanguageClient) DidChangeConfiguration(settings any) error {
 }
 
 func (lc *LanguageClient) PrepareCallHierarchy(uri string, line, character uint32) ([]protocol.CallHierarchyItem, error) {
+	return lc.PrepareCallHierarchyContext(context.Background(), uri, line, character)
+}
+
+// PrepareCallHierarchyContext is PrepareCallHierarchy that is cancelled together with ctx
+func (lc *LanguageClient) PrepareCallHierarchyContext(ctx context.Context, uri string, line, character uint32) ([]protocol.CallHierarchyItem, error) {
 	params := protocol.CallHierarchyPrepareParams{
 		TextDocument: protocol.TextDocumentIdentifier{Uri: protocol.DocumentUri(uri)},
 		Position: protocol.Position{
@@ -539,7 +713,7 @@ func (lc *LanguageClient) PrepareCallHierarchy(uri string, line, character uint3
 	var result []protocol.CallHierarchyItem
 
 	// BSL LS может долго индексировать проект; call hierarchy часто требует больше времени.
-	err := lc.SendRequest("textDocument/prepareCallHierarchy", params, &result, 60*time.Second)
+	err := lc.SendRequestContext(ctx, "textDocument/prepareCallHierarchy", params, &result, 60*time.Second)
 	if err != nil {
 		return nil, fmt.Errorf("prepare call hierarchy request failed: %w", err)
 	}
@@ -616,13 +790,18 @@ func (lc *LanguageClient) SemanticTokensRange(uri string, startLine, startCharac
 
 // IncomingCalls retrieves incoming calls for a given Call Hierarchy Item
 func (lc *LanguageClient) IncomingCalls(item protocol.CallHierarchyItem) ([]protocol.CallHierarchyIncomingCall, error) {
+	return lc.IncomingCallsContext(context.Background(), item)
+}
+
+// IncomingCallsContext is IncomingCalls that is cancelled together with ctx
+func (lc *LanguageClient) IncomingCallsContext(ctx context.Context, item protocol.CallHierarchyItem) ([]protocol.CallHierarchyIncomingCall, error) {
 	params := protocol.CallHierarchyIncomingCallsParams{
 		Item: item,
 	}
 
 	var result []protocol.CallHierarchyIncomingCall
 
-	err := lc.SendRequest("callHierarchy/incomingCalls", params, &result, 60*time.Second)
+	err := lc.SendRequestContext(ctx, "callHierarchy/incomingCalls", params, &result, 60*time.Second)
 	if err != nil {
 		return nil, fmt.Errorf("incoming calls request failed: %w", err)
 	}
@@ -632,13 +811,18 @@ func (lc *LanguageClient) IncomingCalls(item protocol.CallHierarchyItem) ([]prot
 
 // OutgoingCalls retrieves outgoing calls for a given Call Hierarchy Item
 func (lc *LanguageClient) OutgoingCalls(item protocol.CallHierarchyItem) ([]protocol.CallHierarchyOutgoingCall, error) {
+	return lc.OutgoingCallsContext(context.Background(), item)
+}
+
+// OutgoingCallsContext is OutgoingCalls that is cancelled together with ctx
+func (lc *LanguageClient) OutgoingCallsContext(ctx context.Context, item protocol.CallHierarchyItem) ([]protocol.CallHierarchyOutgoingCall, error) {
 	params := protocol.CallHierarchyOutgoingCallsParams{
 		Item: item,
 	}
 
 	var result []protocol.CallHierarchyOutgoingCall
 
-	err := lc.SendRequest("callHierarchy/outgoingCalls", params, &result, 60*time.Second)
+	err := lc.SendRequestContext(ctx, "callHierarchy/outgoingCalls", params, &result, 60*time.Second)
 	if err != nil {
 		return nil, fmt.Errorf("outgoing calls request failed: %w", err)
 	}
@@ -646,6 +830,16 @@ func (lc *LanguageClient) OutgoingCalls(item protocol.CallHierarchyItem) ([]prot
 	return result, nil
 }
 
+// IndexedIncomingCalls reports indexed=false: a direct client has no call graph index
+func (lc *LanguageClient) IndexedIncomingCalls(ctx context.Context, item protocol.CallHierarchyItem) ([]protocol.CallHierarchyIncomingCall, bool, error) {
+	return nil, false, nil
+}
+
+// IndexedOutgoingCalls reports indexed=false: a direct client has no call graph index
+func (lc *LanguageClient) IndexedOutgoingCalls(ctx context.Context, item protocol.CallHierarchyItem) ([]protocol.CallHierarchyOutgoingCall, bool, error) {
+	return nil, false, nil
+}
+
 // DocumentDiagnostics gets diagnostics for a specific document using LSP 3.17+ textDocument/diagnostic method
 func (lc *LanguageClient) DocumentDiagnostics(uri string, identifier string, previousResultId string) (*protocol.DocumentDiagnosticReport, error) {
 	params := protocol.DocumentDiagnosticParams{
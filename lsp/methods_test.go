@@ -125,8 +125,9 @@ func TestDidOpen(t *testing.T) {
 		ctx:  ctx,
 	}
 
-	err := client.DidOpen("file:///test.go", "go", "test content", 1)
+	version, err := client.DidOpen("file:///test.go", "go", "test content", 1)
 	require.NoError(t, err)
+	assert.Equal(t, int32(1), version)
 
 	mockConn.AssertExpectations(t)
 }
@@ -156,8 +157,9 @@ func TestDidChange(t *testing.T) {
 			},
 		},
 	}
-	err := client.DidChange("file:///test.go", 2, changes)
+	version, err := client.DidChange("file:///test.go", 2, changes)
 	require.NoError(t, err)
+	assert.Equal(t, int32(2), version)
 
 	mockConn.AssertExpectations(t)
 }
@@ -597,6 +599,31 @@ func TestIncomingCalls(t *testing.T) {
 	mockConn.AssertExpectations(t)
 }
 
+func TestIncomingCallsContextCancelSendsCancelRequest(t *testing.T) {
+	mockConn := new(mocks.MockLSPConnectionInterface)
+
+	ctx := t.Context()
+	reqCtx, cancel := context.WithCancel(ctx)
+
+	// The server never answers; the call returns once the caller cancels
+	mockConn.On("Call", mock.Anything, "callHierarchy/incomingCalls", mock.AnythingOfType("protocol.CallHierarchyIncomingCallsParams"), mock.AnythingOfType("*[]protocol.CallHierarchyIncomingCall"), mock.AnythingOfType("[]jsonrpc2.CallOption")).Run(func(args mock.Arguments) {
+		cancel()
+		<-args.Get(0).(context.Context).Done()
+	}).Return(context.Canceled)
+	mockConn.On("Notify", mock.Anything, "$/cancelRequest", protocol.CancelParams{Id: protocol.Or2[int32, string]{Value: int32(1)}}, mock.AnythingOfType("[]jsonrpc2.CallOption")).Return(nil)
+	mockConn.On("DisconnectNotify").Return(ctx.Done())
+
+	client := &LanguageClient{
+		conn: mockConn,
+		ctx:  ctx,
+	}
+
+	_, err := client.IncomingCallsContext(reqCtx, protocol.CallHierarchyItem{Name: "testFunction", Uri: "file:///test.go"})
+	require.ErrorIs(t, err, context.Canceled)
+
+	mockConn.AssertExpectations(t)
+}
+
 func TestOutgoingCalls(t *testing.T) {
 	mockConn := new(mocks.MockLSPConnectionInterface)
 
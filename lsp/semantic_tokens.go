@@ -41,7 +41,10 @@ func NewSemanticTokenParser(tokenTypes, tokenModifiers []string) types.SemanticT
 	}
 }
 
-// FindTokensByType finds all tokens of specified types from a semantic tokens response
+// FindTokensByType finds all tokens of specified types from a semantic tokens response.
+// Token positions are relative to the start of the document even for range requests;
+// tokens on lines outside baseRange are dropped. Characters and lengths are in the
+// position encoding negotiated with the server.
 func (p *SemanticTokenParser) FindTokensByType(
 	tokens *protocol.SemanticTokens,
 	targetTypes []string,
@@ -54,12 +57,11 @@ func (p *SemanticTokenParser) FindTokensByType(
 		targetTypeSet[t] = true
 	}
 
-	currentLine := baseRange.Start.Line
-	currentChar := baseRange.Start.Character
+	var currentLine, currentChar uint32
 
 	data := tokens.Data
 
-	for i := 0; i < len(data); i += 5 {
+	for i := 0; i+4 < len(data); i += 5 {
 		deltaLine := data[i]
 		deltaStart := data[i+1]
 		tokenLength := data[i+2]
@@ -74,6 +76,10 @@ func (p *SemanticTokenParser) FindTokensByType(
 			currentChar += deltaStart
 		}
 
+		if currentLine < baseRange.Start.Line || currentLine > baseRange.End.Line {
+			continue
+		}
+
 		// Get token type name
 		if int(tokenTypeIndex) >= len(p.tokenTypes) {
 			continue // Skip invalid token types
@@ -207,6 +207,32 @@ func TestFindTokensByType_ComplexDeltas(t *testing.T) {
 	assert.Equal(t, uint32(0), results[3].Range.Start.Character)
 }
 
+func TestFindTokensByType_RangeResponse(t *testing.T) {
+	parser := NewSemanticTokenParser([]string{"function", "variable"}, []string{})
+
+	// A range response for line 5 still counts from the start of the document
+	semanticTokens := &protocol.SemanticTokens{
+		Data: []uint32{
+			4, 2, 3, 1, 0, // variable on line 4, outside the range
+			1, 10, 9, 0, 0, // function on line 5
+			1, 0, 4, 0, 0, // function on line 6, outside the range
+		},
+	}
+
+	baseRange := protocol.Range{
+		Start: protocol.Position{Line: 5, Character: 0},
+		End:   protocol.Position{Line: 5, Character: 1000},
+	}
+
+	results, err := parser.FindTokensByType(semanticTokens, []string{"function", "variable"}, baseRange)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, protocol.Range{
+		Start: protocol.Position{Line: 5, Character: 10},
+		End:   protocol.Position{Line: 5, Character: 19},
+	}, results[0].Range)
+}
+
 func TestFindFunctionNames(t *testing.T) {
 	tokenTypes := []string{"function", "method", "variable"}
 	parser := NewSemanticTokenParser(tokenTypes, []string{})
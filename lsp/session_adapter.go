@@ -8,7 +8,10 @@ package lsp
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"sort"
+	"sync"
 	"time"
 
 	"rockerboo/mcp-lsp-bridge/logger"
@@ -19,18 +22,63 @@ import (
 
 // SessionAdapter adapts SessionClient to LanguageClientInterface
 type SessionAdapter struct {
+	*sessionAdapterState
+
+	// ctx is the context requests start from, set by WithContext
+	ctx context.Context
+}
+
+// sessionAdapterState is shared by an adapter and the views WithContext returns
+type sessionAdapterState struct {
 	client       *SessionClient
 	projectRoots []string
 	connected    bool
 	lastError    string
+
+	// capsMu guards the capabilities and the token parser built from them
+	capsMu             sync.RWMutex
+	serverCapabilities protocol.ServerCapabilities
+	capsLoaded         bool
+	capsAttempt        time.Time
+	tokenParser        types.SemanticTokensParserProvider
+}
+
+// capabilitiesRetryInterval spaces out refetching capabilities the Session Manager
+// did not have yet because BSL LS was still initializing
+const capabilitiesRetryInterval = 5 * time.Second
+
+// sessionRequestTimeouts are per-method timeouts for requests forwarded
+// through the Session Manager; other methods use defaultSessionRequestTimeout.
+var sessionRequestTimeouts = map[string]time.Duration{
+	"textDocument/semanticTokens/full":  60 * time.Second,
+	"textDocument/semanticTokens/range": 30 * time.Second,
+	"textDocument/foldingRange":         30 * time.Second,
+	"textDocument/selectionRange":       30 * time.Second,
+	"textDocument/documentLink":         30 * time.Second,
+	"textDocument/documentColor":        30 * time.Second,
+	"textDocument/colorPresentation":    30 * time.Second,
+	"textDocument/signatureHelp":        15 * time.Second,
+	"textDocument/completion":           15 * time.Second,
+	"completionItem/resolve":            10 * time.Second,
+	"textDocument/codeLens":             30 * time.Second,
+	"codeLens/resolve":                  10 * time.Second,
+	"textDocument/implementation":       30 * time.Second,
+	"textDocument/declaration":          30 * time.Second,
+	"textDocument/typeDefinition":       30 * time.Second,
+	"textDocument/documentHighlight":    15 * time.Second,
+	"textDocument/codeAction":           60 * time.Second,
+	"textDocument/rangeFormatting":      5 * time.Minute,
+	"workspace/executeCommand":          2 * time.Minute,
 }
 
+const defaultSessionRequestTimeout = 60 * time.Second
+
 // NewSessionAdapter creates a new session adapter
 func NewSessionAdapter(host string, port int) (*SessionAdapter, error) {
 	client := NewSessionClient(host, port)
 
 	return &SessionAdapter{
-		client: client,
+		sessionAdapterState: &sessionAdapterState{client: client},
 	}, nil
 }
 
@@ -41,9 +89,78 @@ func (sa *SessionAdapter) Connect() (types.LanguageClientInterface, error) {
 		return nil, err
 	}
 	sa.connected = true
+
+	// The Session Manager initialized BSL LS itself; its initialize result is the
+	// only source of the capabilities the bridge gates features on
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := sa.loadCapabilities(ctx); err != nil {
+		logger.Warn(fmt.Sprintf("SessionAdapter: %v; retrying on demand", err))
+	}
 	return sa, nil
 }
 
+// loadCapabilities fetches the capabilities BSL LS advertised to the Session Manager,
+// stores them and builds the semantic token parser from their legend
+func (sa *SessionAdapter) loadCapabilities(ctx context.Context) error {
+	sa.capsMu.Lock()
+	sa.capsAttempt = time.Now()
+	sa.capsMu.Unlock()
+
+	raw, err := sa.client.Capabilities(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get server capabilities: %w", err)
+	}
+	if len(raw) == 0 || string(raw) == "null" {
+		return errors.New("Session Manager has no server capabilities yet")
+	}
+	capabilities, err := decodeServerCapabilities(raw)
+	if err != nil {
+		return fmt.Errorf("failed to parse server capabilities: %w", err)
+	}
+
+	sa.SetServerCapabilities(capabilities)
+	if capabilities.SemanticTokensProvider != nil {
+		if err := sa.SetupSemanticTokens(); err != nil {
+			logger.Warn(fmt.Sprintf("SessionAdapter: semantic tokens unavailable: %v", err))
+		}
+	}
+	return nil
+}
+
+// decodeServerCapabilities decodes an initialize result's capabilities. The protocol
+// types reject unknown fields, so a capability this client does not know is dropped
+// on its own instead of losing all of them.
+func decodeServerCapabilities(raw json.RawMessage) (protocol.ServerCapabilities, error) {
+	var capabilities protocol.ServerCapabilities
+	err := json.Unmarshal(raw, &capabilities)
+	if err == nil {
+		return capabilities, nil
+	}
+
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(raw, &fields) != nil {
+		return capabilities, err
+	}
+	var dropped []string
+	for name, value := range fields {
+		single, _ := json.Marshal(map[string]json.RawMessage{name: value})
+		var probe protocol.ServerCapabilities
+		if json.Unmarshal(single, &probe) != nil {
+			dropped = append(dropped, name)
+			delete(fields, name)
+		}
+	}
+	sort.Strings(dropped)
+	logger.Warn(fmt.Sprintf("SessionAdapter: ignoring server capabilities this client cannot decode: %v", dropped))
+
+	filtered, _ := json.Marshal(fields)
+	if err := json.Unmarshal(filtered, &capabilities); err != nil {
+		return protocol.ServerCapabilities{}, err
+	}
+	return capabilities, nil
+}
+
 // Close closes the connection
 func (sa *SessionAdapter) Close() error {
 	sa.connected = false
@@ -55,11 +172,21 @@ func (sa *SessionAdapter) IsConnected() bool {
 	return sa.connected && sa.client.IsConnected()
 }
 
-// Context returns a background context (Session Manager manages its own context)
+// Context returns the context requests start from: the one given to WithContext,
+// or a background context (Session Manager manages its own context)
 func (sa *SessionAdapter) Context() context.Context {
+	if sa.ctx != nil {
+		return sa.ctx
+	}
 	return context.Background()
 }
 
+// WithContext returns a view of the adapter whose requests start from ctx. The
+// Session Manager gets the correlation id of ctx with every request.
+func (sa *SessionAdapter) WithContext(ctx context.Context) types.LanguageClientInterface {
+	return &SessionAdapter{sessionAdapterState: sa.sessionAdapterState, ctx: ctx}
+}
+
 // SetProjectRoots sets the project roots
 func (sa *SessionAdapter) SetProjectRoots(roots []string) {
 	sa.projectRoots = roots
@@ -87,11 +214,15 @@ func (sa *SessionAdapter) Initialize(params protocol.InitializeParams) (*protoco
 		return nil, fmt.Errorf("Session Manager not initialized")
 	}
 
-	// Return minimal result - actual capabilities are in Session Manager
-	// We return an empty capabilities struct - the bridge doesn't really use this
-	return &protocol.InitializeResult{
-		Capabilities: protocol.ServerCapabilities{},
-	}, nil
+	// Report the capabilities BSL LS advertised to the Session Manager so the
+	// bridge can enable optional features (semantic tokens etc.)
+	if err := sa.loadCapabilities(ctx); err != nil {
+		logger.Warn(fmt.Sprintf("SessionAdapter: %v", err))
+	}
+
+	sa.capsMu.RLock()
+	defer sa.capsMu.RUnlock()
+	return &protocol.InitializeResult{Capabilities: sa.serverCapabilities}, nil
 }
 
 // Initialized - no-op for Session Manager
@@ -112,35 +243,39 @@ func (sa *SessionAdapter) Exit() error {
 	return nil
 }
 
-// DidOpen opens a document
-func (sa *SessionAdapter) DidOpen(uri string, languageId protocol.LanguageKind, text string, version int32) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+// DidOpen opens a document. The Session Manager returns the version it sent to the
+// server, which is past version when another bridge process had the document open.
+func (sa *SessionAdapter) DidOpen(uri string, languageId protocol.LanguageKind, text string, version int32) (int32, error) {
+	ctx, cancel := context.WithTimeout(sa.Context(), 10*time.Second)
 	defer cancel()
-	return sa.client.DidOpen(ctx, uri, string(languageId), text)
+	return sa.client.DidOpen(ctx, uri, string(languageId), text, version)
 }
 
-// DidChange - not implemented yet
-func (sa *SessionAdapter) DidChange(uri string, version int32, changes []protocol.TextDocumentContentChangeEvent) error {
-	// TODO: implement if needed
-	return nil
+// DidChange sends document changes; the Session Manager keeps its copy in sync for replay
+// and returns the version it sent to the server
+func (sa *SessionAdapter) DidChange(uri string, version int32, changes []protocol.TextDocumentContentChangeEvent) (int32, error) {
+	ctx, cancel := context.WithTimeout(sa.Context(), 10*time.Second)
+	defer cancel()
+	return sa.client.DidChange(ctx, uri, version, changes)
 }
 
-// DidSave - not implemented yet
+// DidSave notifies that a document was written to disk
 func (sa *SessionAdapter) DidSave(uri string, text *string) error {
-	// TODO: implement if needed
-	return nil
+	ctx, cancel := context.WithTimeout(sa.Context(), 10*time.Second)
+	defer cancel()
+	return sa.client.DidSave(ctx, uri, text)
 }
 
 // DidClose closes a document
 func (sa *SessionAdapter) DidClose(uri string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(sa.Context(), 10*time.Second)
 	defer cancel()
 	return sa.client.DidClose(ctx, uri)
 }
 
 // Hover gets hover information
 func (sa *SessionAdapter) Hover(uri string, line, character uint32) (*protocol.Hover, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(sa.Context(), 30*time.Second)
 	defer cancel()
 
 	result, err := sa.client.Hover(ctx, uri, line, character)
@@ -162,7 +297,7 @@ func (sa *SessionAdapter) Hover(uri string, line, character uint32) (*protocol.H
 
 // Definition gets definition locations
 func (sa *SessionAdapter) Definition(uri string, line, character uint32) ([]protocol.Or2[protocol.LocationLink, protocol.Location], error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(sa.Context(), 30*time.Second)
 	defer cancel()
 
 	result, err := sa.client.Definition(ctx, uri, line, character)
@@ -184,7 +319,7 @@ func (sa *SessionAdapter) Definition(uri string, line, character uint32) ([]prot
 
 // References finds all references
 func (sa *SessionAdapter) References(uri string, line, character uint32, includeDeclaration bool) ([]protocol.Location, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	ctx, cancel := context.WithTimeout(sa.Context(), 60*time.Second)
 	defer cancel()
 
 	result, err := sa.client.References(ctx, uri, line, character, includeDeclaration)
@@ -206,7 +341,7 @@ func (sa *SessionAdapter) References(uri string, line, character uint32, include
 
 // DocumentSymbols gets document symbols
 func (sa *SessionAdapter) DocumentSymbols(uri string) ([]protocol.DocumentSymbol, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	ctx, cancel := context.WithTimeout(sa.Context(), 60*time.Second)
 	defer cancel()
 
 	result, err := sa.client.DocumentSymbols(ctx, uri)
@@ -228,7 +363,7 @@ func (sa *SessionAdapter) DocumentSymbols(uri string) ([]protocol.DocumentSymbol
 
 // WorkspaceSymbols searches for symbols
 func (sa *SessionAdapter) WorkspaceSymbols(query string) ([]protocol.WorkspaceSymbol, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	ctx, cancel := context.WithTimeout(sa.Context(), 60*time.Second)
 	defer cancel()
 
 	result, err := sa.client.WorkspaceSymbol(ctx, query)
@@ -250,7 +385,12 @@ func (sa *SessionAdapter) WorkspaceSymbols(query string) ([]protocol.WorkspaceSy
 
 // PrepareCallHierarchy prepares call hierarchy
 func (sa *SessionAdapter) PrepareCallHierarchy(uri string, line, character uint32) ([]protocol.CallHierarchyItem, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	return sa.PrepareCallHierarchyContext(sa.Context(), uri, line, character)
+}
+
+// PrepareCallHierarchyContext is PrepareCallHierarchy that is cancelled together with ctx
+func (sa *SessionAdapter) PrepareCallHierarchyContext(ctx context.Context, uri string, line, character uint32) ([]protocol.CallHierarchyItem, error) {
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
 	defer cancel()
 
 	result, err := sa.client.PrepareCallHierarchy(ctx, uri, line, character)
@@ -272,7 +412,12 @@ func (sa *SessionAdapter) PrepareCallHierarchy(uri string, line, character uint3
 
 // IncomingCalls gets incoming calls
 func (sa *SessionAdapter) IncomingCalls(item protocol.CallHierarchyItem) ([]protocol.CallHierarchyIncomingCall, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 120*time.Second)
+	return sa.IncomingCallsContext(sa.Context(), item)
+}
+
+// IncomingCallsContext is IncomingCalls that is cancelled together with ctx
+func (sa *SessionAdapter) IncomingCallsContext(ctx context.Context, item protocol.CallHierarchyItem) ([]protocol.CallHierarchyIncomingCall, error) {
+	ctx, cancel := context.WithTimeout(ctx, 120*time.Second)
 	defer cancel()
 
 	itemJSON, err := json.Marshal(item)
@@ -299,7 +444,12 @@ func (sa *SessionAdapter) IncomingCalls(item protocol.CallHierarchyItem) ([]prot
 
 // OutgoingCalls gets outgoing calls
 func (sa *SessionAdapter) OutgoingCalls(item protocol.CallHierarchyItem) ([]protocol.CallHierarchyOutgoingCall, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 120*time.Second)
+	return sa.OutgoingCallsContext(sa.Context(), item)
+}
+
+// OutgoingCallsContext is OutgoingCalls that is cancelled together with ctx
+func (sa *SessionAdapter) OutgoingCallsContext(ctx context.Context, item protocol.CallHierarchyItem) ([]protocol.CallHierarchyOutgoingCall, error) {
+	ctx, cancel := context.WithTimeout(ctx, 120*time.Second)
 	defer cancel()
 
 	itemJSON, err := json.Marshal(item)
@@ -326,23 +476,147 @@ func (sa *SessionAdapter) OutgoingCalls(item protocol.CallHierarchyItem) ([]prot
 
 // Implementation finds implementations
 func (sa *SessionAdapter) Implementation(uri string, line, character uint32) ([]protocol.Location, error) {
-	// Forward as definition for now - BSL doesn't really have interfaces
-	return sa.References(uri, line, character, true)
+	if sa.ServerCapabilities().ImplementationProvider == nil {
+		return nil, errors.New("server does not support implementation")
+	}
+
+	params := protocol.ImplementationParams{
+		TextDocument: protocol.TextDocumentIdentifier{Uri: protocol.DocumentUri(uri)},
+		Position:     protocol.Position{Line: line, Character: character},
+	}
+
+	var locations []protocol.Location
+	if err := sa.forward("textDocument/implementation", params, &locations); err != nil {
+		return nil, err
+	}
+	return locations, nil
+}
+
+// Declaration finds the declaration of the symbol at a position
+func (sa *SessionAdapter) Declaration(uri string, line, character uint32) ([]protocol.Or2[protocol.LocationLink, protocol.Location], error) {
+	params := protocol.DeclarationParams{
+		TextDocument: protocol.TextDocumentIdentifier{Uri: protocol.DocumentUri(uri)},
+		Position:     protocol.Position{Line: line, Character: character},
+	}
+
+	var raw json.RawMessage
+	if err := sa.forward("textDocument/declaration", params, &raw); err != nil {
+		return nil, fmt.Errorf("declaration request failed: %w", err)
+	}
+	return decodeLocationResult(raw)
+}
+
+// TypeDefinition finds the definition of the type of the symbol at a position
+func (sa *SessionAdapter) TypeDefinition(uri string, line, character uint32) ([]protocol.Or2[protocol.LocationLink, protocol.Location], error) {
+	params := protocol.TypeDefinitionParams{
+		TextDocument: protocol.TextDocumentIdentifier{Uri: protocol.DocumentUri(uri)},
+		Position:     protocol.Position{Line: line, Character: character},
+	}
+
+	var raw json.RawMessage
+	if err := sa.forward("textDocument/typeDefinition", params, &raw); err != nil {
+		return nil, fmt.Errorf("type definition request failed: %w", err)
+	}
+	return decodeLocationResult(raw)
 }
 
-// SignatureHelp - not implemented yet
+// DocumentHighlight gets the read and write occurrences of the symbol at a position
+func (sa *SessionAdapter) DocumentHighlight(uri string, line, character uint32) ([]protocol.DocumentHighlight, error) {
+	params := protocol.DocumentHighlightParams{
+		TextDocument: protocol.TextDocumentIdentifier{Uri: protocol.DocumentUri(uri)},
+		Position:     protocol.Position{Line: line, Character: character},
+	}
+
+	var highlights []protocol.DocumentHighlight
+	if err := sa.forward("textDocument/documentHighlight", params, &highlights); err != nil {
+		return nil, fmt.Errorf("document highlight request failed: %w", err)
+	}
+	return highlights, nil
+}
+
+// SignatureHelp provides signature help at a given position
 func (sa *SessionAdapter) SignatureHelp(uri string, line, character uint32) (*protocol.SignatureHelp, error) {
-	return nil, nil
+	params := protocol.SignatureHelpParams{
+		TextDocument: protocol.TextDocumentIdentifier{Uri: protocol.DocumentUri(uri)},
+		Position:     protocol.Position{Line: line, Character: character},
+	}
+
+	var help *protocol.SignatureHelp
+	if err := sa.forward("textDocument/signatureHelp", params, &help); err != nil {
+		return nil, err
+	}
+	return help, nil
+}
+
+// Completion gets completion items at a position
+func (sa *SessionAdapter) Completion(uri string, line, character uint32) (*protocol.CompletionList, error) {
+	params := protocol.CompletionParams{
+		TextDocument: protocol.TextDocumentIdentifier{Uri: protocol.DocumentUri(uri)},
+		Position:     protocol.Position{Line: line, Character: character},
+		Context:      &protocol.CompletionContext{TriggerKind: protocol.CompletionTriggerKindInvoked},
+	}
+
+	var raw json.RawMessage
+	if err := sa.forward("textDocument/completion", params, &raw); err != nil {
+		return nil, fmt.Errorf("completion request failed: %w", err)
+	}
+	return decodeCompletionResult(raw)
+}
+
+// ResolveCompletionItem fills in the documentation and detail of a completion item
+func (sa *SessionAdapter) ResolveCompletionItem(item protocol.CompletionItem) (*protocol.CompletionItem, error) {
+	var resolved protocol.CompletionItem
+	if err := sa.forward("completionItem/resolve", item, &resolved); err != nil {
+		return nil, fmt.Errorf("completion item resolve failed: %w", err)
+	}
+	return &resolved, nil
+}
+
+// CodeLens gets the code lenses of a document
+func (sa *SessionAdapter) CodeLens(uri string) ([]protocol.CodeLens, error) {
+	params := protocol.CodeLensParams{
+		TextDocument: protocol.TextDocumentIdentifier{Uri: protocol.DocumentUri(uri)},
+	}
+
+	var lenses []protocol.CodeLens
+	if err := sa.forward("textDocument/codeLens", params, &lenses); err != nil {
+		return nil, fmt.Errorf("code lens request failed: %w", err)
+	}
+	return lenses, nil
+}
+
+// ResolveCodeLens fills in the command of a code lens
+func (sa *SessionAdapter) ResolveCodeLens(lens protocol.CodeLens) (*protocol.CodeLens, error) {
+	var resolved protocol.CodeLens
+	if err := sa.forward("codeLens/resolve", lens, &resolved); err != nil {
+		return nil, fmt.Errorf("code lens resolve failed: %w", err)
+	}
+	return &resolved, nil
 }
 
-// CodeActions - not implemented yet
+// CodeActions gets code actions for a range
 func (sa *SessionAdapter) CodeActions(uri string, line, character, endLine, endCharacter uint32) ([]protocol.CodeAction, error) {
-	return nil, nil
+	params := protocol.CodeActionParams{
+		TextDocument: protocol.TextDocumentIdentifier{Uri: protocol.DocumentUri(uri)},
+		Range: protocol.Range{
+			Start: protocol.Position{Line: line, Character: character},
+			End:   protocol.Position{Line: endLine, Character: endCharacter},
+		},
+		Context: protocol.CodeActionContext{
+			Diagnostics: []protocol.Diagnostic{},
+		},
+	}
+
+	var actions []protocol.CodeAction
+	if err := sa.forward("textDocument/codeAction", params, &actions); err != nil {
+		return nil, fmt.Errorf("code action request failed: %w", err)
+	}
+	return actions, nil
 }
 
 // Rename - not implemented yet
 func (sa *SessionAdapter) Rename(uri string, line, character uint32, newName string) (*protocol.WorkspaceEdit, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	ctx, cancel := context.WithTimeout(sa.Context(), 2*time.Minute)
 	defer cancel()
 
 	result, err := sa.client.Rename(ctx, uri, line, character, newName)
@@ -362,7 +636,7 @@ func (sa *SessionAdapter) Rename(uri string, line, character uint32, newName str
 
 // Formatting - not implemented yet
 func (sa *SessionAdapter) Formatting(uri string, tabSize uint32, insertSpaces bool) ([]protocol.TextEdit, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	ctx, cancel := context.WithTimeout(sa.Context(), 5*time.Minute)
 	defer cancel()
 
 	result, err := sa.client.Formatting(ctx, uri, tabSize, insertSpaces)
@@ -380,15 +654,36 @@ func (sa *SessionAdapter) Formatting(uri string, tabSize uint32, insertSpaces bo
 	return edits, nil
 }
 
-// RangeFormatting - not implemented yet
+// RangeFormatting formats a range of a document
 func (sa *SessionAdapter) RangeFormatting(uri string, startLine, startCharacter, endLine, endCharacter uint32, tabSize uint32, insertSpaces bool) ([]protocol.TextEdit, error) {
-	return nil, fmt.Errorf("range formatting not implemented in session mode")
+	params := protocol.DocumentRangeFormattingParams{
+		TextDocument: protocol.TextDocumentIdentifier{Uri: protocol.DocumentUri(uri)},
+		Range: protocol.Range{
+			Start: protocol.Position{Line: startLine, Character: startCharacter},
+			End:   protocol.Position{Line: endLine, Character: endCharacter},
+		},
+		Options: protocol.FormattingOptions{
+			TabSize:      tabSize,
+			InsertSpaces: insertSpaces,
+		},
+	}
+
+	var edits []protocol.TextEdit
+	if err := sa.forward("textDocument/rangeFormatting", params, &edits); err != nil {
+		return nil, fmt.Errorf("range formatting request failed: %w", err)
+	}
+	return edits, nil
 }
 
 // WorkspaceDiagnostic - not implemented yet
 func (sa *SessionAdapter) WorkspaceDiagnostic(identifier string) (*protocol.WorkspaceDiagnosticReport, error) {
+	return sa.WorkspaceDiagnosticContext(sa.Context(), identifier)
+}
+
+// WorkspaceDiagnosticContext is WorkspaceDiagnostic that is cancelled together with ctx
+func (sa *SessionAdapter) WorkspaceDiagnosticContext(ctx context.Context, identifier string) (*protocol.WorkspaceDiagnosticReport, error) {
 	// Workspace diagnostics can be extremely heavy on BSL projects (10k LOC modules, 20k+ files).
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Minute)
 	defer cancel()
 
 	result, err := sa.client.WorkspaceDiagnostic(ctx, identifier)
@@ -406,10 +701,92 @@ func (sa *SessionAdapter) WorkspaceDiagnostic(identifier string) (*protocol.Work
 	return &report, nil
 }
 
+// PublishedDiagnostics returns diagnostics the server pushed to the Session Manager.
+// Not part of LanguageClientInterface; see LanguageClient.PublishedDiagnostics.
+func (sa *SessionAdapter) PublishedDiagnostics(filter DiagnosticsFilter) (*DiagnosticsSnapshot, error) {
+	ctx, cancel := context.WithTimeout(sa.Context(), 30*time.Second)
+	defer cancel()
+
+	result, err := sa.client.Diagnostics(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Documents        []PublishedDiagnostics `json:"documents"`
+		Total            int                    `json:"total"`
+		TrackedDocuments int                    `json:"trackedDocuments"`
+		UpdatedAt        time.Time              `json:"updatedAt"`
+		Fresh            bool                   `json:"fresh"`
+	}
+	if err := json.Unmarshal(result, &response); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal session diagnostics: %w", err)
+	}
+
+	if response.Documents == nil {
+		response.Documents = []PublishedDiagnostics{}
+	}
+	return &DiagnosticsSnapshot{
+		Documents: response.Documents,
+		Total:     response.Total,
+		Tracked:   response.TrackedDocuments,
+		UpdatedAt: response.UpdatedAt,
+		Fresh:     response.Fresh,
+	}, nil
+}
+
+// IndexedIncomingCalls answers callHierarchy/incomingCalls from the call graph index of
+// Session Manager, which re-indexes files changed since indexing to complete the answer.
+// indexed is false when the index cannot answer (still building, or too many changed
+// files) and the caller should ask the server instead.
+func (sa *SessionAdapter) IndexedIncomingCalls(ctx context.Context, item protocol.CallHierarchyItem) (calls []protocol.CallHierarchyIncomingCall, indexed bool, err error) {
+	indexed, err = sa.indexedCalls(ctx, "incoming", item, &calls)
+	return calls, indexed, err
+}
+
+// IndexedOutgoingCalls answers callHierarchy/outgoingCalls from the call graph index.
+// indexed is false when the file of item is not indexed or changed since indexing.
+func (sa *SessionAdapter) IndexedOutgoingCalls(ctx context.Context, item protocol.CallHierarchyItem) (calls []protocol.CallHierarchyOutgoingCall, indexed bool, err error) {
+	indexed, err = sa.indexedCalls(ctx, "outgoing", item, &calls)
+	return calls, indexed, err
+}
+
+func (sa *SessionAdapter) indexedCalls(ctx context.Context, direction string, item protocol.CallHierarchyItem, calls any) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	itemJSON, err := json.Marshal(item)
+	if err != nil {
+		return false, err
+	}
+
+	result, err := sa.client.CallGraph(ctx, direction, itemJSON)
+	if err != nil {
+		return false, err
+	}
+
+	var response struct {
+		Indexed bool            `json:"indexed"`
+		Calls   json.RawMessage `json:"calls"`
+	}
+	if err := json.Unmarshal(result, &response); err != nil {
+		return false, fmt.Errorf("failed to unmarshal call graph response: %w", err)
+	}
+	if !response.Indexed {
+		return false, nil
+	}
+	if len(response.Calls) > 0 {
+		if err := json.Unmarshal(response.Calls, calls); err != nil {
+			return false, fmt.Errorf("failed to unmarshal indexed %s calls: %w", direction, err)
+		}
+	}
+	return true, nil
+}
+
 // DocumentDiagnostics gets diagnostics for a document
 func (sa *SessionAdapter) DocumentDiagnostics(uri string, identifier string, previousResultId string) (*protocol.DocumentDiagnosticReport, error) {
 	// Document diagnostics can be slow on large BSL workspaces.
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	ctx, cancel := context.WithTimeout(sa.Context(), 5*time.Minute)
 	defer cancel()
 
 	result, err := sa.client.Diagnostic(ctx, uri, identifier, previousResultId)
@@ -429,19 +806,39 @@ func (sa *SessionAdapter) DocumentDiagnostics(uri string, identifier string, pre
 	return &report, nil
 }
 
-// SemanticTokens - not implemented
+// SemanticTokens gets semantic tokens for a whole document
 func (sa *SessionAdapter) SemanticTokens(uri string) (*protocol.SemanticTokens, error) {
-	return nil, nil
+	params := protocol.SemanticTokensParams{
+		TextDocument: protocol.TextDocumentIdentifier{Uri: protocol.DocumentUri(uri)},
+	}
+
+	var tokens *protocol.SemanticTokens
+	if err := sa.forward("textDocument/semanticTokens/full", params, &tokens); err != nil {
+		return nil, err
+	}
+	return tokens, nil
 }
 
-// SemanticTokensRange - not implemented
+// SemanticTokensRange gets semantic tokens for a range of a document
 func (sa *SessionAdapter) SemanticTokensRange(uri string, startLine, startCharacter, endLine, endCharacter uint32) (*protocol.SemanticTokens, error) {
-	return nil, nil
+	params := protocol.SemanticTokensRangeParams{
+		TextDocument: protocol.TextDocumentIdentifier{Uri: protocol.DocumentUri(uri)},
+		Range: protocol.Range{
+			Start: protocol.Position{Line: startLine, Character: startCharacter},
+			End:   protocol.Position{Line: endLine, Character: endCharacter},
+		},
+	}
+
+	var tokens *protocol.SemanticTokens
+	if err := sa.forward("textDocument/semanticTokens/range", params, &tokens); err != nil {
+		return nil, err
+	}
+	return tokens, nil
 }
 
 // PrepareRename - not implemented yet
 func (sa *SessionAdapter) PrepareRename(uri string, line, character uint32) (*protocol.PrepareRenameResult, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	ctx, cancel := context.WithTimeout(sa.Context(), 2*time.Minute)
 	defer cancel()
 
 	result, err := sa.client.PrepareRename(ctx, uri, line, character)
@@ -459,46 +856,129 @@ func (sa *SessionAdapter) PrepareRename(uri string, line, character uint32) (*pr
 	return &pr, nil
 }
 
-// FoldingRange - not implemented yet
+// FoldingRange gets folding ranges for a document
 func (sa *SessionAdapter) FoldingRange(uri string) ([]protocol.FoldingRange, error) {
-	return nil, nil
+	params := protocol.FoldingRangeParams{
+		TextDocument: protocol.TextDocumentIdentifier{Uri: protocol.DocumentUri(uri)},
+	}
+
+	var ranges []protocol.FoldingRange
+	if err := sa.forward("textDocument/foldingRange", params, &ranges); err != nil {
+		return nil, fmt.Errorf("folding range request failed: %w", err)
+	}
+	return ranges, nil
 }
 
-// SelectionRange - not implemented yet
+// SelectionRange gets selection ranges for the given positions
 func (sa *SessionAdapter) SelectionRange(uri string, positions []protocol.Position) ([]protocol.SelectionRange, error) {
-	return nil, nil
+	params := protocol.SelectionRangeParams{
+		TextDocument: protocol.TextDocumentIdentifier{Uri: protocol.DocumentUri(uri)},
+		Positions:    positions,
+	}
+
+	var ranges []protocol.SelectionRange
+	if err := sa.forward("textDocument/selectionRange", params, &ranges); err != nil {
+		return nil, fmt.Errorf("selection range request failed: %w", err)
+	}
+	return ranges, nil
 }
 
-// DocumentLink - not implemented yet
+// DocumentLink gets links in a document
 func (sa *SessionAdapter) DocumentLink(uri string) ([]protocol.DocumentLink, error) {
-	return nil, nil
+	params := protocol.DocumentLinkParams{
+		TextDocument: protocol.TextDocumentIdentifier{Uri: protocol.DocumentUri(uri)},
+	}
+
+	var links []protocol.DocumentLink
+	if err := sa.forward("textDocument/documentLink", params, &links); err != nil {
+		return nil, fmt.Errorf("document link request failed: %w", err)
+	}
+	return links, nil
 }
 
-// DocumentColor - not implemented yet
+// DocumentColor gets color references in a document
 func (sa *SessionAdapter) DocumentColor(uri string) ([]protocol.ColorInformation, error) {
-	return nil, nil
+	params := protocol.DocumentColorParams{
+		TextDocument: protocol.TextDocumentIdentifier{Uri: protocol.DocumentUri(uri)},
+	}
+
+	var colors []protocol.ColorInformation
+	if err := sa.forward("textDocument/documentColor", params, &colors); err != nil {
+		return nil, fmt.Errorf("document color request failed: %w", err)
+	}
+	return colors, nil
 }
 
-// ColorPresentation - not implemented yet
+// ColorPresentation gets presentations for a color
 func (sa *SessionAdapter) ColorPresentation(uri string, color protocol.Color, rng protocol.Range) ([]protocol.ColorPresentation, error) {
-	return nil, nil
+	params := protocol.ColorPresentationParams{
+		TextDocument: protocol.TextDocumentIdentifier{Uri: protocol.DocumentUri(uri)},
+		Color:        color,
+		Range:        rng,
+	}
+
+	var presentations []protocol.ColorPresentation
+	if err := sa.forward("textDocument/colorPresentation", params, &presentations); err != nil {
+		return nil, fmt.Errorf("color presentation request failed: %w", err)
+	}
+	return presentations, nil
 }
 
-// ExecuteCommand - not implemented yet
+// ExecuteCommand executes a server command
 func (sa *SessionAdapter) ExecuteCommand(command string, args []any) (json.RawMessage, error) {
-	return nil, fmt.Errorf("execute command not implemented in session mode")
+	params := protocol.ExecuteCommandParams{
+		Command:   command,
+		Arguments: args,
+	}
+
+	var result json.RawMessage
+	if err := sa.forward("workspace/executeCommand", params, &result); err != nil {
+		return nil, fmt.Errorf("execute command request failed: %w", err)
+	}
+	return result, nil
+}
+
+// forward sends any LSP request through the Session Manager's generic
+// passthrough using the per-method timeout. A null result leaves out untouched.
+func (sa *SessionAdapter) forward(method string, params any, out any) error {
+	timeout, ok := sessionRequestTimeouts[method]
+	if !ok {
+		timeout = defaultSessionRequestTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(sa.Context(), timeout)
+	defer cancel()
+
+	var result json.RawMessage
+	if err := sa.client.Call(ctx, method, params, &result); err != nil {
+		return err
+	}
+	if len(result) == 0 || string(result) == "null" {
+		return nil
+	}
+
+	if err := json.Unmarshal(result, out); err != nil {
+		return fmt.Errorf("failed to unmarshal %s result: %w", method, err)
+	}
+	return nil
 }
 
 // SendRequest sends a raw request (for compatibility)
 func (sa *SessionAdapter) SendRequest(method string, params any, result any, timeout time.Duration) error {
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	return sa.SendRequestContext(sa.Context(), method, params, result, timeout)
+}
+
+// SendRequestContext sends a request bounded by both ctx and timeout.
+// Cancelling ctx sends $/cancelRequest through Session Manager.
+func (sa *SessionAdapter) SendRequestContext(ctx context.Context, method string, params any, result any, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 	return sa.client.Call(ctx, method, params, result)
 }
 
 // SendNotification sends a notification (for compatibility)
 func (sa *SessionAdapter) SendNotification(method string, params any) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(sa.Context(), 10*time.Second)
 	defer cancel()
 	var result interface{}
 	return sa.client.Call(ctx, method, params, &result)
@@ -517,7 +997,7 @@ func (sa *SessionAdapter) ConnectInterface() (interface{}, error) {
 	return sa.Connect()
 }
 
-// GetMetrics returns client metrics (stub for now)
+// GetMetrics returns the request counters of the Session Manager connection
 func (sa *SessionAdapter) GetMetrics() types.ClientMetricsProvider {
 	status := int(StatusUninitialized)
 	if sa.connected && sa.client.IsConnected() {
@@ -525,7 +1005,27 @@ func (sa *SessionAdapter) GetMetrics() types.ClientMetricsProvider {
 	} else if !sa.connected {
 		status = int(StatusDisconnected)
 	}
-	return &sessionMetrics{connected: sa.connected, status: status}
+
+	stats := &sa.client.stats
+	total, failed := stats.total.Load(), stats.failed.Load()
+	stats.mu.Lock()
+	defer stats.mu.Unlock()
+
+	lastError := stats.lastError
+	if lastError == "" {
+		lastError = sa.lastError
+	}
+	return &sessionMetrics{
+		connected:       sa.connected,
+		status:          status,
+		totalRequests:   total,
+		successful:      total - failed,
+		failed:          failed,
+		lastInitialized: stats.connectedAt,
+		lastErrorTime:   stats.lastErrorAt,
+		lastError:       lastError,
+		processID:       stats.pid.Load(),
+	}
 }
 
 // Status returns connection status as int
@@ -546,56 +1046,94 @@ func (sa *SessionAdapter) ClientCapabilities() protocol.ClientCapabilities {
 	return protocol.ClientCapabilities{}
 }
 
-// ServerCapabilities returns server capabilities
+// ServerCapabilities returns the capabilities reported by the Session Manager. When
+// they were not available at connect they are fetched again, at most every
+// capabilitiesRetryInterval.
 func (sa *SessionAdapter) ServerCapabilities() protocol.ServerCapabilities {
-	return protocol.ServerCapabilities{}
+	sa.capsMu.RLock()
+	retry := !sa.capsLoaded && time.Since(sa.capsAttempt) >= capabilitiesRetryInterval
+	sa.capsMu.RUnlock()
+
+	if retry && sa.client.IsConnected() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		if err := sa.loadCapabilities(ctx); err != nil {
+			logger.DebugContext(sa.Context(), "SessionAdapter: server capabilities still unavailable", "error", err)
+		}
+		cancel()
+	}
+
+	sa.capsMu.RLock()
+	defer sa.capsMu.RUnlock()
+	return sa.serverCapabilities
 }
 
-// SetServerCapabilities sets server capabilities (no-op for session mode)
+// SetServerCapabilities sets server capabilities
 func (sa *SessionAdapter) SetServerCapabilities(capabilities protocol.ServerCapabilities) {
-	// No-op - Session Manager handles this
+	sa.capsMu.Lock()
+	defer sa.capsMu.Unlock()
+	sa.serverCapabilities = capabilities
+	sa.capsLoaded = true
 }
 
-// SetupSemanticTokens sets up semantic tokens (no-op)
+// SetupSemanticTokens builds the token parser from the server legend
 func (sa *SessionAdapter) SetupSemanticTokens() error {
+	sa.capsMu.Lock()
+	defer sa.capsMu.Unlock()
+
+	tokenTypes, tokenModifiers, err := GetTokenTypeFromServerCapabilities(&sa.serverCapabilities)
+	if err != nil {
+		return err
+	}
+
+	sa.tokenParser = NewSemanticTokenParser(tokenTypes, tokenModifiers)
 	return nil
 }
 
-// TokenParser returns semantic token parser (nil for now)
+// TokenParser returns the semantic token parser (nil until SetupSemanticTokens succeeds)
 func (sa *SessionAdapter) TokenParser() types.SemanticTokensParserProvider {
-	return nil
+	sa.capsMu.RLock()
+	defer sa.capsMu.RUnlock()
+	return sa.tokenParser
 }
 
-// sessionMetrics implements ClientMetricsProvider for SessionAdapter
+// sessionMetrics is a snapshot of the SessionClient counters that implements
+// ClientMetricsProvider; the process ID is the language server behind the Session Manager
 type sessionMetrics struct {
-	connected bool
-	command   string
-	status    int
+	connected       bool
+	command         string
+	status          int
+	totalRequests   int64
+	successful      int64
+	failed          int64
+	lastInitialized time.Time
+	lastErrorTime   time.Time
+	lastError       string
+	processID       int32
 }
 
 func (m *sessionMetrics) GetCommand() string                     { return m.command }
 func (m *sessionMetrics) SetCommand(command string)              { m.command = command }
 func (m *sessionMetrics) GetStatus() int                         { return m.status }
 func (m *sessionMetrics) SetStatus(status int)                   { m.status = status }
-func (m *sessionMetrics) GetTotalRequests() int64                { return 0 }
-func (m *sessionMetrics) SetTotalRequests(total int64)           {}
-func (m *sessionMetrics) IncrementTotalRequests()                {}
-func (m *sessionMetrics) GetSuccessfulRequests() int64           { return 0 }
-func (m *sessionMetrics) SetSuccessfulRequests(successful int64) {}
-func (m *sessionMetrics) IncrementSuccessfulRequests()           {}
-func (m *sessionMetrics) GetFailedRequests() int64               { return 0 }
-func (m *sessionMetrics) SetFailedRequests(failed int64)         {}
-func (m *sessionMetrics) IncrementFailedRequests()               {}
-func (m *sessionMetrics) GetLastInitialized() time.Time          { return time.Time{} }
-func (m *sessionMetrics) SetLastInitialized(t time.Time)         {}
-func (m *sessionMetrics) GetLastErrorTime() time.Time            { return time.Time{} }
-func (m *sessionMetrics) SetLastErrorTime(t time.Time)           {}
-func (m *sessionMetrics) GetLastError() string                   { return "" }
-func (m *sessionMetrics) SetLastError(err string)                {}
+func (m *sessionMetrics) GetTotalRequests() int64                { return m.totalRequests }
+func (m *sessionMetrics) SetTotalRequests(total int64)           { m.totalRequests = total }
+func (m *sessionMetrics) IncrementTotalRequests()                { m.totalRequests++ }
+func (m *sessionMetrics) GetSuccessfulRequests() int64           { return m.successful }
+func (m *sessionMetrics) SetSuccessfulRequests(successful int64) { m.successful = successful }
+func (m *sessionMetrics) IncrementSuccessfulRequests()           { m.successful++ }
+func (m *sessionMetrics) GetFailedRequests() int64               { return m.failed }
+func (m *sessionMetrics) SetFailedRequests(failed int64)         { m.failed = failed }
+func (m *sessionMetrics) IncrementFailedRequests()               { m.failed++ }
+func (m *sessionMetrics) GetLastInitialized() time.Time          { return m.lastInitialized }
+func (m *sessionMetrics) SetLastInitialized(t time.Time)         { m.lastInitialized = t }
+func (m *sessionMetrics) GetLastErrorTime() time.Time            { return m.lastErrorTime }
+func (m *sessionMetrics) SetLastErrorTime(t time.Time)           { m.lastErrorTime = t }
+func (m *sessionMetrics) GetLastError() string                   { return m.lastError }
+func (m *sessionMetrics) SetLastError(err string)                { m.lastError = err }
 func (m *sessionMetrics) IsConnected() bool                      { return m.connected }
 func (m *sessionMetrics) SetConnected(connected bool)            { m.connected = connected }
-func (m *sessionMetrics) GetProcessID() int32                    { return 0 }
-func (m *sessionMetrics) SetProcessID(pid int32)                 {}
+func (m *sessionMetrics) GetProcessID() int32                    { return m.processID }
+func (m *sessionMetrics) SetProcessID(pid int32)                 { m.processID = pid }
 
 // DidChangeWatchedFiles notifies about file changes
 func (sa *SessionAdapter) DidChangeWatchedFiles(changes []protocol.FileEvent) error {
@@ -605,9 +1143,12 @@ func (sa *SessionAdapter) DidChangeWatchedFiles(changes []protocol.FileEvent) er
 	return sa.SendNotification("workspace/didChangeWatchedFiles", params)
 }
 
-// DidChangeConfiguration notifies about config changes (no-op)
+// DidChangeConfiguration notifies about config changes
 func (sa *SessionAdapter) DidChangeConfiguration(settings any) error {
-	return nil
+	params := protocol.DidChangeConfigurationParams{
+		Settings: settings,
+	}
+	return sa.SendNotification("workspace/didChangeConfiguration", params)
 }
 
 // IndexingStatus represents the current indexing progress from session manager (minimal)
@@ -618,6 +1159,12 @@ type IndexingStatus struct {
 	ETASeconds     int    `json:"eta_seconds,omitempty"`
 	ElapsedSeconds int    `json:"elapsed_seconds,omitempty"`
 	Message        string `json:"message,omitempty"`
+
+	// CallGraph is the state of the session manager's call graph index, nil when disabled
+	CallGraph map[string]interface{} `json:"call_graph,omitempty"`
+
+	// Metrics are the session manager gauges: queue depth, pending LSP requests, JVM RSS
+	Metrics map[string]interface{} `json:"metrics,omitempty"`
 }
 
 // GetSessionStatus returns the full session status including indexing progress
@@ -660,6 +1207,12 @@ func (sa *SessionAdapter) GetIndexingStatus() *IndexingStatus {
 	if v, ok := indexingData["message"].(string); ok {
 		result.Message = v
 	}
+	if v, ok := status["callGraphIndex"].(map[string]interface{}); ok {
+		result.CallGraph = v
+	}
+	if v, ok := status["metrics"].(map[string]interface{}); ok {
+		result.Metrics = v
+	}
 
 	return result
 }
@@ -0,0 +1,620 @@
+package lsp_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"rockerboo/mcp-lsp-bridge/logger"
+	"rockerboo/mcp-lsp-bridge/lsp"
+
+	"github.com/myleshyson/lsprotocol-go/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSessionManager answers newline-delimited JSON-RPC requests the way
+// lsp-session-manager does, using a canned result per method.
+type fakeSessionManager struct {
+	listener net.Listener
+	results  map[string]any
+
+	mu      sync.Mutex
+	methods []string
+	params  map[string]json.RawMessage
+	ids     map[string]json.RawMessage
+	corrIDs map[string]string
+}
+
+// noResponse as a result makes the fake manager leave the request unanswered
+type noResponse struct{}
+
+func newFakeSessionManager(t *testing.T, results map[string]any) *fakeSessionManager {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	f := &fakeSessionManager{
+		listener: listener,
+		results:  results,
+		params:   make(map[string]json.RawMessage),
+		ids:      make(map[string]json.RawMessage),
+		corrIDs:  make(map[string]string),
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+
+	go f.serve()
+	return f
+}
+
+func (f *fakeSessionManager) serve() {
+	for {
+		conn, err := f.listener.Accept()
+		if err != nil {
+			return
+		}
+		go f.handle(conn)
+	}
+}
+
+func (f *fakeSessionManager) handle(conn net.Conn) {
+	defer func() { _ = conn.Close() }()
+
+	reader := bufio.NewReader(conn)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err != nil {
+			return
+		}
+
+		var req struct {
+			ID            json.RawMessage `json:"id"`
+			Method        string          `json:"method"`
+			Params        json.RawMessage `json:"params"`
+			CorrelationID string          `json:"correlationId"`
+		}
+		if err := json.Unmarshal(line, &req); err != nil {
+			return
+		}
+
+		f.mu.Lock()
+		f.methods = append(f.methods, req.Method)
+		f.params[req.Method] = req.Params
+		f.ids[req.Method] = req.ID
+		f.corrIDs[req.Method] = req.CorrelationID
+		f.mu.Unlock()
+
+		if len(req.ID) == 0 {
+			continue // notification
+		}
+		if _, stall := f.results[req.Method].(noResponse); stall {
+			continue
+		}
+
+		resp := map[string]any{"jsonrpc": "2.0", "id": req.ID}
+		if result, ok := f.results[req.Method]; ok {
+			resp["result"] = result
+		} else {
+			resp["error"] = map[string]any{"code": -32603, "message": "unknown method: " + req.Method}
+		}
+
+		out, _ := json.Marshal(resp)
+		if _, err := conn.Write(append(out, '\n')); err != nil {
+			return
+		}
+	}
+}
+
+func (f *fakeSessionManager) port() int {
+	return f.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (f *fakeSessionManager) paramsFor(method string) json.RawMessage {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.params[method]
+}
+
+func (f *fakeSessionManager) idFor(method string) json.RawMessage {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.ids[method]
+}
+
+func (f *fakeSessionManager) correlationIDFor(method string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.corrIDs[method]
+}
+
+func connectFakeSession(t *testing.T, results map[string]any) (*lsp.SessionAdapter, *fakeSessionManager) {
+	t.Helper()
+
+	fake := newFakeSessionManager(t, results)
+	adapter, err := lsp.NewSessionAdapter("127.0.0.1", fake.port())
+	require.NoError(t, err)
+
+	_, err = adapter.Connect()
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = adapter.Close() })
+
+	return adapter, fake
+}
+
+func TestSessionAdapterInitializeReportsCapabilities(t *testing.T) {
+	adapter, _ := connectFakeSession(t, map[string]any{
+		"session/status": map[string]any{"initialized": true},
+		"session/capabilities": map[string]any{
+			"hoverProvider":        true,
+			"foldingRangeProvider": true,
+			"semanticTokensProvider": map[string]any{
+				"legend": map[string]any{
+					"tokenTypes":     []string{"keyword", "variable"},
+					"tokenModifiers": []string{},
+				},
+				"full": true,
+			},
+		},
+	})
+
+	result, err := adapter.Initialize(protocol.InitializeParams{})
+	require.NoError(t, err)
+	require.NotNil(t, result.Capabilities.SemanticTokensProvider)
+
+	adapter.SetServerCapabilities(result.Capabilities)
+	require.NoError(t, adapter.SetupSemanticTokens())
+	assert.NotNil(t, adapter.TokenParser())
+}
+
+func TestSessionAdapterForwardsPreviouslyStubbedMethods(t *testing.T) {
+	adapter, fake := connectFakeSession(t, map[string]any{
+		"textDocument/foldingRange": []map[string]any{
+			{"startLine": 1, "endLine": 10},
+		},
+		"textDocument/semanticTokens/full": map[string]any{
+			"data": []uint32{0, 0, 5, 0, 0},
+		},
+		"textDocument/codeAction": []map[string]any{
+			{"title": "Add missing Export"},
+		},
+		"textDocument/signatureHelp": nil,
+		"textDocument/rangeFormatting": []map[string]any{
+			{
+				"range": map[string]any{
+					"start": map[string]any{"line": 0, "character": 0},
+					"end":   map[string]any{"line": 0, "character": 4},
+				},
+				"newText": "Если",
+			},
+		},
+		"workspace/executeCommand": map[string]any{"applied": true},
+	})
+
+	uri := "file:///projects/CommonModules/Common/Ext/Module.bsl"
+
+	folding, err := adapter.FoldingRange(uri)
+	require.NoError(t, err)
+	require.Len(t, folding, 1)
+	assert.Equal(t, uint32(10), folding[0].EndLine)
+
+	tokens, err := adapter.SemanticTokens(uri)
+	require.NoError(t, err)
+	require.NotNil(t, tokens)
+	assert.Len(t, tokens.Data, 5)
+
+	actions, err := adapter.CodeActions(uri, 1, 0, 1, 5)
+	require.NoError(t, err)
+	require.Len(t, actions, 1)
+	assert.Equal(t, "Add missing Export", actions[0].Title)
+
+	help, err := adapter.SignatureHelp(uri, 1, 0)
+	require.NoError(t, err)
+	assert.Nil(t, help)
+
+	edits, err := adapter.RangeFormatting(uri, 0, 0, 0, 4, 4, true)
+	require.NoError(t, err)
+	require.Len(t, edits, 1)
+	assert.Equal(t, "Если", edits[0].NewText)
+
+	raw, err := adapter.ExecuteCommand("bsl.refresh", []any{"x"})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"applied":true}`, string(raw))
+
+	var sent struct {
+		Command   string `json:"command"`
+		Arguments []any  `json:"arguments"`
+	}
+	require.NoError(t, json.Unmarshal(fake.paramsFor("workspace/executeCommand"), &sent))
+	assert.Equal(t, "bsl.refresh", sent.Command)
+	assert.Equal(t, []any{"x"}, sent.Arguments)
+}
+
+func TestSessionAdapterForwardPropagatesErrors(t *testing.T) {
+	adapter, _ := connectFakeSession(t, map[string]any{})
+
+	_, err := adapter.DocumentLink("file:///projects/Module.bsl")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown method: textDocument/documentLink")
+}
+
+func TestSessionAdapterImplementationWithoutProvider(t *testing.T) {
+	adapter, fake := connectFakeSession(t, map[string]any{
+		"textDocument/references": []map[string]any{
+			{
+				"uri": "file:///projects/Module.bsl",
+				"range": map[string]any{
+					"start": map[string]any{"line": 3, "character": 0},
+					"end":   map[string]any{"line": 3, "character": 8},
+				},
+			},
+		},
+	})
+
+	// References are not implementations: without the capability there is no answer
+	locations, err := adapter.Implementation("file:///projects/Module.bsl", 3, 2)
+	require.EqualError(t, err, "server does not support implementation")
+	assert.Empty(t, locations)
+	assert.Nil(t, fake.paramsFor("textDocument/implementation"))
+	assert.Nil(t, fake.paramsFor("textDocument/references"))
+}
+
+func TestSessionAdapterDidChangeAndDidSaveReachSessionManager(t *testing.T) {
+	adapter, fake := connectFakeSession(t, map[string]any{
+		"textDocument/didOpen":   map[string]any{"version": 4},
+		"textDocument/didChange": map[string]any{"version": 5},
+		"textDocument/didSave":   nil,
+	})
+
+	uri := "file:///projects/CommonModules/Common/Ext/Module.bsl"
+	// Another bridge process had the document open at version 3
+	version, err := adapter.DidOpen(uri, "bsl", "Процедура А() КонецПроцедуры", 1)
+	require.NoError(t, err)
+	assert.Equal(t, int32(4), version, "the version the Session Manager sent is used")
+	assert.Contains(t, string(fake.paramsFor("textDocument/didOpen")), `"version":1`)
+
+	version, err = adapter.DidChange(uri, 2, []protocol.TextDocumentContentChangeEvent{
+		{Value: protocol.TextDocumentContentChangePartial{
+			Range: protocol.Range{
+				Start: protocol.Position{Line: 0, Character: 10},
+				End:   protocol.Position{Line: 0, Character: 11},
+			},
+			Text: "Б",
+		}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int32(5), version)
+
+	var sent struct {
+		TextDocument struct {
+			URI     string `json:"uri"`
+			Version int    `json:"version"`
+		} `json:"textDocument"`
+		ContentChanges []struct {
+			Range map[string]any `json:"range"`
+			Text  string         `json:"text"`
+		} `json:"contentChanges"`
+	}
+	require.NoError(t, json.Unmarshal(fake.paramsFor("textDocument/didChange"), &sent))
+	assert.Equal(t, uri, sent.TextDocument.URI)
+	assert.Equal(t, 2, sent.TextDocument.Version)
+	require.Len(t, sent.ContentChanges, 1)
+	assert.Equal(t, "Б", sent.ContentChanges[0].Text)
+	assert.NotNil(t, sent.ContentChanges[0].Range)
+
+	require.NoError(t, adapter.DidSave(uri, nil))
+	assert.JSONEq(t, `{"textDocument":{"uri":"`+uri+`"}}`, string(fake.paramsFor("textDocument/didSave")))
+}
+
+func TestSessionAdapterPublishedDiagnosticsQueriesSessionManager(t *testing.T) {
+	adapter, fake := connectFakeSession(t, map[string]any{
+		"session/diagnostics": map[string]any{
+			"documents": []any{
+				map[string]any{
+					"uri":        "file:///projects/CommonModules/Common/Ext/Module.bsl",
+					"version":    2,
+					"receivedAt": "2026-01-01T10:00:00Z",
+					"diagnostics": []any{
+						map[string]any{
+							"range":    map[string]any{"start": map[string]any{"line": 0, "character": 0}, "end": map[string]any{"line": 0, "character": 5}},
+							"severity": 1,
+							"code":     "ParseError",
+							"message":  "Ошибка разбора",
+						},
+					},
+				},
+			},
+			"total":            1,
+			"trackedDocuments": 10,
+			"fresh":            true,
+			"updatedAt":        "2026-01-01T10:00:00Z",
+		},
+	})
+
+	snapshot, err := adapter.PublishedDiagnostics(lsp.DiagnosticsFilter{
+		Severity: 1,
+		Code:     []string{"ParseError"},
+		Path:     "CommonModules/**",
+	})
+	require.NoError(t, err)
+
+	assert.True(t, snapshot.Fresh)
+	assert.Equal(t, 10, snapshot.Tracked)
+	require.Len(t, snapshot.Documents, 1)
+	assert.Equal(t, int32(2), snapshot.Documents[0].Version)
+	assert.Equal(t, "ParseError", lsp.DiagnosticCode(snapshot.Documents[0].Diagnostics[0]))
+
+	assert.JSONEq(t, `{"severity":1,"code":["ParseError"],"path":"CommonModules/**"}`, string(fake.paramsFor("session/diagnostics")))
+}
+
+func TestSessionAdapterCancelSendsCancelRequest(t *testing.T) {
+	adapter, fake := connectFakeSession(t, map[string]any{
+		"callHierarchy/incomingCalls": noResponse{},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := adapter.IncomingCallsContext(ctx, protocol.CallHierarchyItem{Name: "Процедура", Uri: "file:///w/Module.bsl"})
+		errCh <- err
+	}()
+
+	require.Eventually(t, func() bool { return fake.idFor("callHierarchy/incomingCalls") != nil }, 2*time.Second, 10*time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-errCh:
+		require.ErrorIs(t, err, context.Canceled)
+	case <-time.After(2 * time.Second):
+		t.Fatal("IncomingCallsContext did not return after cancel")
+	}
+
+	require.Eventually(t, func() bool { return fake.paramsFor("$/cancelRequest") != nil }, 2*time.Second, 10*time.Millisecond)
+
+	var cancelParams struct {
+		ID json.RawMessage `json:"id"`
+	}
+	require.NoError(t, json.Unmarshal(fake.paramsFor("$/cancelRequest"), &cancelParams))
+	assert.JSONEq(t, string(fake.idFor("callHierarchy/incomingCalls")), string(cancelParams.ID))
+}
+
+func TestSessionAdapterIndexedCalls(t *testing.T) {
+	item := protocol.CallHierarchyItem{Name: "Провести", Kind: protocol.SymbolKindMethod, Uri: "file:///w/Documents/Order/Ext/ObjectModule.bsl"}
+
+	adapter, fake := connectFakeSession(t, map[string]any{
+		"session/callGraph": map[string]any{
+			"indexed": true,
+			"calls": []map[string]any{
+				{
+					"to": map[string]any{
+						"name":           "Записать",
+						"kind":           6,
+						"uri":            "file:///w/CommonModules/Common/Ext/Module.bsl",
+						"range":          map[string]any{"start": map[string]any{"line": 10, "character": 0}, "end": map[string]any{"line": 20, "character": 14}},
+						"selectionRange": map[string]any{"start": map[string]any{"line": 10, "character": 10}, "end": map[string]any{"line": 10, "character": 18}},
+					},
+					"fromRanges": []any{},
+				},
+			},
+		},
+	})
+
+	calls, indexed, err := adapter.IndexedOutgoingCalls(context.Background(), item)
+	require.NoError(t, err)
+	require.True(t, indexed)
+	require.Len(t, calls, 1)
+	assert.Equal(t, "Записать", calls[0].To.Name)
+
+	var params struct {
+		Direction string                     `json:"direction"`
+		Item      protocol.CallHierarchyItem `json:"item"`
+	}
+	require.NoError(t, json.Unmarshal(fake.paramsFor("session/callGraph"), &params))
+	assert.Equal(t, "outgoing", params.Direction)
+	assert.Equal(t, item.Uri, params.Item.Uri)
+}
+
+func TestSessionAdapterIndexedCallsNotIndexed(t *testing.T) {
+	adapter, _ := connectFakeSession(t, map[string]any{
+		"session/callGraph": map[string]any{"indexed": false},
+	})
+
+	calls, indexed, err := adapter.IndexedIncomingCalls(context.Background(), protocol.CallHierarchyItem{Name: "Провести", Kind: protocol.SymbolKindMethod, Uri: "file:///w/Module.bsl"})
+	require.NoError(t, err)
+	assert.False(t, indexed)
+	assert.Empty(t, calls)
+}
+
+func TestSessionAdapterMetrics(t *testing.T) {
+	adapter, _ := connectFakeSession(t, map[string]any{
+		"textDocument/foldingRange": []map[string]any{},
+		"session/capabilities":      map[string]any{"foldingRangeProvider": true},
+		"session/status": map[string]any{
+			"initialized": true,
+			"pid":         4242,
+			"indexing":    map[string]any{"state": "complete", "current": 10, "total": 10},
+			"metrics":     map[string]any{"queueDepth": 0, "lspRssBytes": 1 << 30},
+		},
+	})
+
+	_, err := adapter.FoldingRange("file:///projects/Module.bsl")
+	require.NoError(t, err)
+	_, err = adapter.DocumentLink("file:///projects/Module.bsl")
+	require.Error(t, err)
+	status := adapter.GetIndexingStatus()
+	require.NotNil(t, status)
+	assert.Equal(t, float64(1<<30), status.Metrics["lspRssBytes"])
+
+	// Connect fetched the capabilities
+	metrics := adapter.GetMetrics()
+	assert.Equal(t, int64(4), metrics.GetTotalRequests())
+	assert.Equal(t, int64(3), metrics.GetSuccessfulRequests())
+	assert.Equal(t, int64(1), metrics.GetFailedRequests())
+	assert.Contains(t, metrics.GetLastError(), "unknown method: textDocument/documentLink")
+	assert.False(t, metrics.GetLastErrorTime().IsZero())
+	assert.False(t, metrics.GetLastInitialized().IsZero())
+	assert.Equal(t, int32(4242), metrics.GetProcessID())
+
+	byMethod := map[string]lsp.SessionRequestStats{}
+	for _, s := range lsp.SessionRequestSummary() {
+		byMethod[s.Method] = s
+	}
+	assert.GreaterOrEqual(t, byMethod["textDocument/foldingRange"].Count, uint64(1))
+	assert.GreaterOrEqual(t, byMethod["textDocument/documentLink"].Errors, uint64(1))
+	assert.Equal(t, 0, lsp.SessionPendingRequests())
+}
+
+func TestSessionAdapterPropagatesCorrelationID(t *testing.T) {
+	adapter, fake := connectFakeSession(t, map[string]any{
+		"textDocument/foldingRange": []map[string]any{},
+		"textDocument/hover":        nil,
+	})
+
+	_, err := adapter.FoldingRange("file:///projects/Module.bsl")
+	require.NoError(t, err)
+	assert.Empty(t, fake.correlationIDFor("textDocument/foldingRange"))
+
+	// Tool handlers bind the client to the context of the tool call
+	ctx := logger.WithCorrelationID(context.Background(), "0123456789abcdef")
+	_, err = adapter.WithContext(ctx).Hover("file:///projects/Module.bsl", 1, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "0123456789abcdef", fake.correlationIDFor("textDocument/hover"))
+
+	// The view shares the connection; the adapter itself stays unbound
+	_, err = adapter.FoldingRange("file:///projects/Module.bsl")
+	require.NoError(t, err)
+	assert.Empty(t, fake.correlationIDFor("textDocument/foldingRange"))
+}
+
+func TestSessionAdapterCompletion(t *testing.T) {
+	uri := "file:///projects/CommonModules/Common/Ext/Module.bsl"
+
+	t.Run("list", func(t *testing.T) {
+		adapter, fake := connectFakeSession(t, map[string]any{
+			"textDocument/completion": map[string]any{
+				"isIncomplete": true,
+				"items":        []map[string]any{{"label": "Номенклатура", "kind": 9, "data": map[string]any{"id": 7}}},
+			},
+			"completionItem/resolve": map[string]any{
+				"label":         "Номенклатура",
+				"detail":        "СправочникМенеджер.Номенклатура",
+				"documentation": map[string]any{"kind": "markdown", "value": "Справочник номенклатуры"},
+			},
+		})
+
+		list, err := adapter.Completion(uri, 3, 12)
+		require.NoError(t, err)
+		assert.True(t, list.IsIncomplete)
+		require.Len(t, list.Items, 1)
+
+		var sent protocol.CompletionParams
+		require.NoError(t, json.Unmarshal(fake.paramsFor("textDocument/completion"), &sent))
+		assert.Equal(t, protocol.Position{Line: 3, Character: 12}, sent.Position)
+
+		resolved, err := adapter.ResolveCompletionItem(list.Items[0])
+		require.NoError(t, err)
+		assert.Equal(t, "СправочникМенеджер.Номенклатура", resolved.Detail)
+		assert.JSONEq(t, `{"label":"Номенклатура","kind":9,"data":{"id":7}}`, string(fake.paramsFor("completionItem/resolve")),
+			"the item goes back to the server unchanged, data included")
+	})
+
+	t.Run("bare array and null", func(t *testing.T) {
+		adapter, _ := connectFakeSession(t, map[string]any{
+			"textDocument/completion": []map[string]any{{"label": "Сообщить"}, {"label": "СокрЛП"}},
+		})
+		list, err := adapter.Completion(uri, 0, 0)
+		require.NoError(t, err)
+		assert.False(t, list.IsIncomplete)
+		assert.Len(t, list.Items, 2)
+
+		adapter, _ = connectFakeSession(t, map[string]any{"textDocument/completion": nil})
+		list, err = adapter.Completion(uri, 0, 0)
+		require.NoError(t, err)
+		assert.Empty(t, list.Items)
+	})
+}
+
+func TestSessionAdapterCodeLens(t *testing.T) {
+	uri := "file:///projects/CommonModules/Common/Ext/Module.bsl"
+	data := map[string]any{"id": "cognitiveComplexity", "uri": uri, "methodName": "Провести"}
+	adapter, fake := connectFakeSession(t, map[string]any{
+		"textDocument/codeLens": []map[string]any{{
+			"range": map[string]any{"start": map[string]any{"line": 4, "character": 10}, "end": map[string]any{"line": 4, "character": 18}},
+			"data":  data,
+		}},
+		"codeLens/resolve": map[string]any{
+			"range":   map[string]any{"start": map[string]any{"line": 4, "character": 10}, "end": map[string]any{"line": 4, "character": 18}},
+			"command": map[string]any{"title": "Когнитивная сложность 17", "command": ""},
+			"data":    data,
+		},
+	})
+
+	lenses, err := adapter.CodeLens(uri)
+	require.NoError(t, err)
+	require.Len(t, lenses, 1)
+	assert.Nil(t, lenses[0].Command)
+
+	var sent protocol.CodeLensParams
+	require.NoError(t, json.Unmarshal(fake.paramsFor("textDocument/codeLens"), &sent))
+	assert.Equal(t, protocol.DocumentUri(uri), sent.TextDocument.Uri)
+
+	resolved, err := adapter.ResolveCodeLens(lenses[0])
+	require.NoError(t, err)
+	require.NotNil(t, resolved.Command)
+	assert.Equal(t, "Когнитивная сложность 17", resolved.Command.Title)
+	assert.JSONEq(t, `{"range":{"start":{"line":4,"character":10},"end":{"line":4,"character":18}},"data":{"id":"cognitiveComplexity","uri":"`+uri+`","methodName":"Провести"}}`,
+		string(fake.paramsFor("codeLens/resolve")), "the lens goes back with its data")
+}
+
+func TestSessionAdapterDeclarationTypeDefinitionAndHighlight(t *testing.T) {
+	uri := "file:///projects/CommonModules/Common/Ext/Module.bsl"
+	span := map[string]any{"start": map[string]any{"line": 3, "character": 5}, "end": map[string]any{"line": 3, "character": 12}}
+	adapter, fake := connectFakeSession(t, map[string]any{
+		// A single Location instead of an array is a valid answer
+		"textDocument/declaration": map[string]any{"uri": uri, "range": span},
+		"textDocument/typeDefinition": []map[string]any{{
+			"targetUri": uri, "targetRange": span, "targetSelectionRange": span,
+		}},
+		"textDocument/documentHighlight": []map[string]any{
+			{"range": span, "kind": 3},
+			{"range": map[string]any{"start": map[string]any{"line": 9, "character": 1}, "end": map[string]any{"line": 9, "character": 8}}, "kind": 2},
+		},
+	})
+
+	declarations, err := adapter.Declaration(uri, 9, 3)
+	require.NoError(t, err)
+	require.Len(t, declarations, 1)
+	location, ok := declarations[0].Value.(protocol.Location)
+	require.True(t, ok, "got %T", declarations[0].Value)
+	assert.Equal(t, uint32(3), location.Range.Start.Line)
+
+	var sent protocol.DeclarationParams
+	require.NoError(t, json.Unmarshal(fake.paramsFor("textDocument/declaration"), &sent))
+	assert.Equal(t, protocol.DocumentUri(uri), sent.TextDocument.Uri)
+	assert.Equal(t, protocol.Position{Line: 9, Character: 3}, sent.Position)
+
+	typeDefinitions, err := adapter.TypeDefinition(uri, 9, 3)
+	require.NoError(t, err)
+	require.Len(t, typeDefinitions, 1)
+	_, ok = typeDefinitions[0].Value.(protocol.LocationLink)
+	assert.True(t, ok, "got %T", typeDefinitions[0].Value)
+
+	highlights, err := adapter.DocumentHighlight(uri, 9, 3)
+	require.NoError(t, err)
+	require.Len(t, highlights, 2)
+	require.NotNil(t, highlights[0].Kind)
+	assert.Equal(t, protocol.DocumentHighlightKindWrite, *highlights[0].Kind)
+	assert.Equal(t, protocol.DocumentHighlightKindRead, *highlights[1].Kind)
+}
+
+func TestSessionAdapterDeclarationNullResult(t *testing.T) {
+	adapter, _ := connectFakeSession(t, map[string]any{"textDocument/declaration": nil})
+
+	declarations, err := adapter.Declaration("file:///projects/Module.bsl", 0, 0)
+	require.NoError(t, err)
+	assert.Empty(t, declarations)
+}
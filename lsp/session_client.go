@@ -11,11 +11,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"net"
+	"strconv"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"rockerboo/mcp-lsp-bridge/logger"
+
+	"github.com/myleshyson/lsprotocol-go/protocol"
 )
 
 // SessionClient connects to LSP Session Manager
@@ -29,6 +32,8 @@ type SessionClient struct {
 	reqID   int64
 	pending map[int64]chan sessionResponse
 	closed  bool // true if explicitly closed (not error)
+
+	stats sessionCallStats // see session_metrics.go
 }
 
 type sessionResponse struct {
@@ -52,8 +57,8 @@ func NewSessionClient(host string, port int) *SessionClient {
 
 // Connect establishes connection to Session Manager
 func (sc *SessionClient) Connect() error {
-	addr := fmt.Sprintf("%s:%d", sc.host, sc.port)
-	logger.Info(fmt.Sprintf("Connecting to Session Manager at %s", addr))
+	addr := net.JoinHostPort(sc.host, strconv.Itoa(sc.port))
+	logger.InfoContext(context.Background(), "Connecting to Session Manager", "addr", addr)
 
 	var conn net.Conn
 	var err error
@@ -64,7 +69,7 @@ func (sc *SessionClient) Connect() error {
 		if err == nil {
 			break
 		}
-		logger.Debug(fmt.Sprintf("Connection attempt %d failed: %v", i+1, err))
+		logger.DebugContext(context.Background(), "Connection attempt failed", "addr", addr, "attempt", i+1, "error", err)
 		time.Sleep(time.Second)
 	}
 
@@ -76,11 +81,12 @@ func (sc *SessionClient) Connect() error {
 	sc.conn = conn
 	sc.reader = bufio.NewReader(conn)
 	sc.mu.Unlock()
+	sc.stats.connected()
 
 	// Start response reader
 	go sc.readResponses()
 
-	logger.Info("Connected to Session Manager")
+	logger.InfoContext(context.Background(), "Connected to Session Manager", "addr", addr)
 	return nil
 }
 
@@ -107,6 +113,52 @@ func (sc *SessionClient) IsConnected() bool {
 func (sc *SessionClient) GetStatus(ctx context.Context) (map[string]interface{}, error) {
 	var result map[string]interface{}
 	err := sc.Call(ctx, "session/status", nil, &result)
+	if pid, ok := result["pid"].(float64); ok {
+		sc.stats.pid.Store(int32(pid))
+	}
+	return result, err
+}
+
+// Capabilities gets the server capabilities from the initialize result
+func (sc *SessionClient) Capabilities(ctx context.Context) (json.RawMessage, error) {
+	var result json.RawMessage
+	err := sc.Call(ctx, "session/capabilities", nil, &result)
+	return result, err
+}
+
+// Diagnostics queries diagnostics the server pushed to the Session Manager
+func (sc *SessionClient) Diagnostics(ctx context.Context, filter DiagnosticsFilter) (json.RawMessage, error) {
+	params := map[string]interface{}{}
+	if filter.URI != "" {
+		params["uri"] = filter.URI
+	}
+	if filter.Severity > 0 {
+		params["severity"] = filter.Severity
+	}
+	if len(filter.Code) > 0 {
+		params["code"] = filter.Code
+	}
+	if filter.Path != "" {
+		params["path"] = filter.Path
+	}
+	if !filter.Since.IsZero() {
+		params["since"] = filter.Since.UTC().Format(time.RFC3339)
+	}
+
+	var result json.RawMessage
+	err := sc.Call(ctx, "session/diagnostics", params, &result)
+	return result, err
+}
+
+// CallGraph queries the call graph index of Session Manager.
+// direction is "incoming" or "outgoing"; item is a CallHierarchyItem.
+func (sc *SessionClient) CallGraph(ctx context.Context, direction string, item json.RawMessage) (json.RawMessage, error) {
+	params := map[string]interface{}{
+		"direction": direction,
+		"item":      item,
+	}
+	var result json.RawMessage
+	err := sc.Call(ctx, "session/callGraph", params, &result)
 	return result, err
 }
 
@@ -229,18 +281,60 @@ func (sc *SessionClient) OutgoingCalls(ctx context.Context, item json.RawMessage
 }
 
 // DidOpen sends textDocument/didOpen notification
-func (sc *SessionClient) DidOpen(ctx context.Context, uri, languageID, text string) error {
+func (sc *SessionClient) DidOpen(ctx context.Context, uri, languageID, text string, version int32) (int32, error) {
 	params := map[string]interface{}{
 		"textDocument": map[string]interface{}{
 			"uri":        uri,
 			"languageId": languageID,
-			"version":    1,
+			"version":    version,
 			"text":       text,
 		},
 	}
 
+	return sc.documentVersion(ctx, "textDocument/didOpen", params, version)
+}
+
+// DidChange sends textDocument/didChange notification
+func (sc *SessionClient) DidChange(ctx context.Context, uri string, version int32, changes []protocol.TextDocumentContentChangeEvent) (int32, error) {
+	params := protocol.DidChangeTextDocumentParams{
+		TextDocument: protocol.VersionedTextDocumentIdentifier{
+			Uri:     protocol.DocumentUri(uri),
+			Version: version,
+		},
+		ContentChanges: changes,
+	}
+
+	return sc.documentVersion(ctx, "textDocument/didChange", params, version)
+}
+
+// documentVersion sends a document notification and returns the version the Session
+// Manager forwarded to the server; older managers do not report it, then it is sent
+func (sc *SessionClient) documentVersion(ctx context.Context, method string, params any, sent int32) (int32, error) {
+	var result struct {
+		Version *int32 `json:"version"`
+	}
+	if err := sc.Call(ctx, method, params, &result); err != nil {
+		return 0, err
+	}
+	if result.Version == nil {
+		return sent, nil
+	}
+	return *result.Version, nil
+}
+
+// DidSave sends textDocument/didSave notification
+func (sc *SessionClient) DidSave(ctx context.Context, uri string, text *string) error {
+	params := map[string]interface{}{
+		"textDocument": map[string]interface{}{
+			"uri": uri,
+		},
+	}
+	if text != nil {
+		params["text"] = *text
+	}
+
 	var result interface{}
-	return sc.Call(ctx, "textDocument/didOpen", params, &result)
+	return sc.Call(ctx, "textDocument/didSave", params, &result)
 }
 
 // DidClose sends textDocument/didClose notification
@@ -347,12 +441,20 @@ func (sc *SessionClient) WorkspaceSymbol(ctx context.Context, query string) (jso
 }
 
 // Call makes a JSON-RPC call to Session Manager
-func (sc *SessionClient) Call(ctx context.Context, method string, params interface{}, result interface{}) error {
+func (sc *SessionClient) Call(ctx context.Context, method string, params interface{}, result interface{}) (err error) {
+	correlationID := logger.CorrelationID(ctx)
+	var id int64
+	defer func(start time.Time) {
+		elapsed := time.Since(start)
+		sc.stats.record(method, elapsed, err)
+		logSessionCall(ctx, method, id, elapsed, err)
+	}(time.Now())
+
 	// Check connection and try to reconnect if needed
 	sc.mu.Lock()
 	if sc.conn == nil && !sc.closed {
 		sc.mu.Unlock()
-		if err := sc.reconnect(); err != nil {
+		if err := sc.reconnect(ctx); err != nil {
 			return fmt.Errorf("not connected to Session Manager and reconnect failed: %w", err)
 		}
 		// Start reader goroutine after reconnect
@@ -365,15 +467,17 @@ func (sc *SessionClient) Call(ctx context.Context, method string, params interfa
 		return fmt.Errorf("not connected to Session Manager")
 	}
 
-	id := atomic.AddInt64(&sc.reqID, 1)
+	id = atomic.AddInt64(&sc.reqID, 1)
 	respCh := make(chan sessionResponse, 1)
 	sc.pending[id] = respCh
 	sc.mu.Unlock()
+	sessionPending.Add(1)
 
 	defer func() {
 		sc.mu.Lock()
 		delete(sc.pending, id)
 		sc.mu.Unlock()
+		sessionPending.Add(-1)
 	}()
 
 	// Build request
@@ -383,6 +487,10 @@ func (sc *SessionClient) Call(ctx context.Context, method string, params interfa
 		"method":  method,
 		"params":  params,
 	}
+	if correlationID != "" {
+		// Not part of JSON-RPC; the session manager logs it with every hop of the request
+		req["correlationId"] = correlationID
+	}
 
 	reqJSON, err := json.Marshal(req)
 	if err != nil {
@@ -419,10 +527,28 @@ func (sc *SessionClient) Call(ctx context.Context, method string, params interfa
 		}
 		return nil
 	case <-ctx.Done():
+		sc.cancelRequest(ctx, conn, id)
 		return ctx.Err()
 	}
 }
 
+// cancelRequest tells Session Manager to abandon an in-flight request. The manager
+// forwards $/cancelRequest to the language server with its own request id.
+// Best effort: a late response is dropped because the id is no longer pending.
+func (sc *SessionClient) cancelRequest(ctx context.Context, conn net.Conn, id int64) {
+	msg, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"method":  "$/cancelRequest",
+		"params":  map[string]interface{}{"id": id},
+	})
+	if err != nil {
+		return
+	}
+	if _, err := conn.Write(append(msg, '\n')); err != nil {
+		logger.DebugContext(ctx, "Failed to send $/cancelRequest", "request_id", id, "error", err)
+	}
+}
+
 // readResponses reads responses from Session Manager
 func (sc *SessionClient) readResponses() {
 	for {
@@ -437,7 +563,7 @@ func (sc *SessionClient) readResponses() {
 
 		line, err := reader.ReadString('\n')
 		if err != nil {
-			logger.Error(fmt.Sprintf("Session Manager read error: %v", err))
+			logger.ErrorContext(context.Background(), "Session Manager read error", "error", err)
 
 			// Fail all pending requests
 			sc.failAllPending(fmt.Errorf("connection lost: %w", err))
@@ -448,13 +574,12 @@ func (sc *SessionClient) readResponses() {
 			sc.mu.Unlock()
 
 			if !wasClosed {
-				logger.Info("Attempting to reconnect to Session Manager...")
-				if reconnErr := sc.reconnect(); reconnErr != nil {
-					logger.Error(fmt.Sprintf("Reconnect failed: %v", reconnErr))
+				logger.InfoContext(context.Background(), "Attempting to reconnect to Session Manager")
+				if reconnErr := sc.reconnect(context.Background()); reconnErr != nil {
+					logger.ErrorContext(context.Background(), "Reconnect failed", "error", reconnErr)
 					return
 				}
 				// Reconnect succeeded, continue reading
-				logger.Info("Reconnected to Session Manager")
 				continue
 			}
 			return
@@ -468,7 +593,7 @@ func (sc *SessionClient) readResponses() {
 		}
 
 		if err := json.Unmarshal([]byte(line), &resp); err != nil {
-			logger.Error(fmt.Sprintf("Failed to parse response: %v", err))
+			logger.ErrorContext(context.Background(), "Failed to parse Session Manager response", "error", err)
 			continue
 		}
 
@@ -498,8 +623,9 @@ func (sc *SessionClient) failAllPending(err error) {
 	}
 }
 
-// reconnect attempts to reconnect to Session Manager
-func (sc *SessionClient) reconnect() error {
+// reconnect attempts to reconnect to Session Manager. ctx is the request that found
+// the connection gone, for the log; reconnecting is not cancelled with it.
+func (sc *SessionClient) reconnect(ctx context.Context) error {
 	sc.mu.Lock()
 	// Close existing connection
 	if sc.conn != nil {
@@ -509,8 +635,8 @@ func (sc *SessionClient) reconnect() error {
 	}
 	sc.mu.Unlock()
 
-	addr := fmt.Sprintf("%s:%d", sc.host, sc.port)
-	logger.Info(fmt.Sprintf("Reconnecting to Session Manager at %s", addr))
+	addr := net.JoinHostPort(sc.host, strconv.Itoa(sc.port))
+	logger.InfoContext(ctx, "Reconnecting to Session Manager", "addr", addr)
 
 	var conn net.Conn
 	var err error
@@ -521,7 +647,7 @@ func (sc *SessionClient) reconnect() error {
 		if err == nil {
 			break
 		}
-		logger.Debug(fmt.Sprintf("Reconnect attempt %d failed: %v", i+1, err))
+		logger.DebugContext(ctx, "Reconnect attempt failed", "addr", addr, "attempt", i+1, "error", err)
 		time.Sleep(time.Duration(i+1) * time.Second) // Exponential backoff
 	}
 
@@ -533,7 +659,8 @@ func (sc *SessionClient) reconnect() error {
 	sc.conn = conn
 	sc.reader = bufio.NewReader(conn)
 	sc.mu.Unlock()
+	sc.stats.connected()
 
-	logger.Info("Reconnected to Session Manager")
+	logger.InfoContext(ctx, "Reconnected to Session Manager", "addr", addr)
 	return nil
 }
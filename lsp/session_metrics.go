@@ -0,0 +1,139 @@
+package lsp
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"rockerboo/mcp-lsp-bridge/logger"
+	"rockerboo/mcp-lsp-bridge/metrics"
+)
+
+// Outcomes of a Session Manager call, the "outcome" label of sessionRequests
+const (
+	outcomeOK        = "ok"
+	outcomeError     = "error"
+	outcomeCancelled = "cancelled"
+	outcomeTimeout   = "timeout"
+)
+
+var (
+	sessionRequests = metrics.Default.NewCounter("mcp_lsp_bridge_session_requests_total",
+		"Requests sent to the LSP Session Manager by method and outcome (ok, error, cancelled, timeout).",
+		"method", "outcome")
+	sessionLatency = metrics.Default.NewHistogram("mcp_lsp_bridge_session_request_duration_seconds",
+		"Round trip time of requests sent to the LSP Session Manager.",
+		metrics.DefaultLatencyBuckets, "method")
+	sessionPending = metrics.Default.NewGauge("mcp_lsp_bridge_session_pending_requests",
+		"Requests sent to the LSP Session Manager and waiting for a response.")
+)
+
+// SessionRequestStats summarizes the calls of one method for lsp_status
+type SessionRequestStats struct {
+	Method string  `json:"method"`
+	Count  uint64  `json:"count"`
+	Errors uint64  `json:"errors"`
+	MeanMs float64 `json:"mean_ms"`
+	P95Ms  float64 `json:"p95_ms"`
+}
+
+// SessionRequestSummary returns per-method counters and latencies of every
+// Session Manager call made by this process, ordered by method
+func SessionRequestSummary() []SessionRequestStats {
+	snapshots := sessionLatency.Snapshot()
+	stats := make([]SessionRequestStats, 0, len(snapshots))
+	for _, s := range snapshots {
+		method := s.Labels[0]
+		failed := sessionRequests.Value(method, outcomeError) +
+			sessionRequests.Value(method, outcomeCancelled) +
+			sessionRequests.Value(method, outcomeTimeout)
+		stats = append(stats, SessionRequestStats{
+			Method: method,
+			Count:  s.Count,
+			Errors: uint64(failed),
+			MeanMs: roundMs(s.Mean()),
+			P95Ms:  roundMs(s.Quantile(0.95)),
+		})
+	}
+	return stats
+}
+
+// SessionPendingRequests returns the number of calls waiting for a response
+func SessionPendingRequests() int {
+	return int(sessionPending.Value())
+}
+
+func roundMs(seconds float64) float64 {
+	return float64(int64(seconds*10000+0.5)) / 10
+}
+
+// callOutcome classifies the error returned by SessionClient.Call
+func callOutcome(err error) string {
+	switch {
+	case err == nil:
+		return outcomeOK
+	case errors.Is(err, context.DeadlineExceeded):
+		return outcomeTimeout
+	case errors.Is(err, context.Canceled):
+		return outcomeCancelled
+	default:
+		return outcomeError
+	}
+}
+
+// callLogSampler thins out the debug log of polled and typing-rate methods
+var callLogSampler = logger.NewSampler(logger.DefaultSampling)
+
+// logSessionCall logs a finished call with the correlation id of the tool call
+// that made it; failures are always logged, successes only at debug level
+func logSessionCall(ctx context.Context, method string, id int64, elapsed time.Duration, err error) {
+	if err != nil {
+		logger.WarnContext(ctx, "session manager call failed", "method", method, "request_id", id,
+			"duration_ms", elapsed.Milliseconds(), "outcome", callOutcome(err), "error", err)
+		return
+	}
+	if logger.DebugEnabled() && callLogSampler.Allow(method) {
+		logger.DebugContext(ctx, "session manager call", "method", method, "request_id", id,
+			"duration_ms", elapsed.Milliseconds())
+	}
+}
+
+// sessionCallStats are the per-client totals reported through GetMetrics
+type sessionCallStats struct {
+	total  atomic.Int64
+	failed atomic.Int64
+	pid    atomic.Int32 // language server PID from the last session/status
+
+	mu          sync.Mutex
+	connectedAt time.Time
+	lastError   string
+	lastErrorAt time.Time
+}
+
+// record counts one finished call in the client totals and the process-wide metrics
+func (s *sessionCallStats) record(method string, elapsed time.Duration, err error) {
+	outcome := callOutcome(err)
+	sessionRequests.Inc(method, outcome)
+	sessionLatency.Observe(elapsed.Seconds(), method)
+
+	s.total.Add(1)
+	if err == nil {
+		return
+	}
+	s.failed.Add(1)
+	s.mu.Lock()
+	s.lastError = err.Error()
+	s.lastErrorAt = time.Now()
+	s.mu.Unlock()
+}
+
+// connected starts a new connection; errors of the previous one no longer
+// describe it and would otherwise keep lsp_status in the error state
+func (s *sessionCallStats) connected() {
+	s.mu.Lock()
+	s.connectedAt = time.Now()
+	s.lastError = ""
+	s.mu.Unlock()
+}
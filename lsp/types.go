@@ -19,6 +19,7 @@ type Language string
 type GlobalConfig struct {
 	LogPath            string `json:"log_file_path"`
 	LogLevel           string `json:"log_level"`
+	LogFormat          string `json:"log_format"`
 	MaxLogFiles        int    `json:"max_log_files"`
 	MaxRestartAttempts int    `json:"max_restart_attempts"`
 	RestartDelayMs     int    `json:"restart_delay_ms"`
@@ -106,6 +107,7 @@ type LanguageClient struct {
 
 	tokenParser types.SemanticTokensParserProvider
 	progress    *ProgressTracker
+	diagnostics *DiagnosticsStore
 
 	workspacePaths []string
 
@@ -121,6 +123,9 @@ type LanguageClient struct {
 	tcpAddress string   // For TCP mode: "host:port"
 	tcpConn    net.Conn // Active TCP connection
 
+	// Request ids picked by SendRequestContext, so they can be cancelled
+	requestSeq int64
+
 	// Metrics
 	totalRequests      int64
 	successfulRequests int64
@@ -14,12 +14,17 @@
 package main
 
 import (
+	"context"
 	"errors"
 	"flag"
 	"fmt"
 	"log"
+	"net"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"strconv"
+	"syscall"
 	"time"
 
 	"rockerboo/mcp-lsp-bridge/bridge"
@@ -27,10 +32,9 @@ import (
 	"rockerboo/mcp-lsp-bridge/logger"
 	"rockerboo/mcp-lsp-bridge/lsp"
 	"rockerboo/mcp-lsp-bridge/mcpserver"
+	"rockerboo/mcp-lsp-bridge/metrics"
 	"rockerboo/mcp-lsp-bridge/security"
 	"rockerboo/mcp-lsp-bridge/types"
-
-	"github.com/mark3labs/mcp-go/server"
 )
 
 // tryLoadConfig attempts to load configuration from multiple locations with security validation
@@ -103,53 +107,64 @@ func validateCommandLineArgs(confPath, logPath, configDir, logDir string) error
 	return nil
 }
 
-func main() {
-	// Initialize directory resolver
+// bridgeOptions holds the flags shared by the MCP server and the subcommands that
+// build a bridge of their own
+type bridgeOptions struct {
+	confPath  string
+	logPath   string
+	logLevel  string
+	logFormat string
+	configDir string
+	logDir    string
+}
+
+// defaultBridgeOptions resolves the per-user config and log directories
+func defaultBridgeOptions() (bridgeOptions, error) {
 	dirResolver := directories.NewDirectoryResolver("mcp-lsp-bridge", directories.DefaultUserProvider{}, directories.DefaultEnvProvider{}, true)
 
-	// Get default directories
 	configDir, err := dirResolver.GetConfigDirectory()
 	if err != nil {
-		log.Fatalf("Failed to get config directory: %v", err)
+		return bridgeOptions{}, fmt.Errorf("failed to get config directory: %w", err)
 	}
 
 	logDir, err := dirResolver.GetLogDirectory()
 	if err != nil {
-		log.Fatalf("Failed to get log directory: %v", err)
+		return bridgeOptions{}, fmt.Errorf("failed to get log directory: %w", err)
 	}
 
-	// Set up default paths
-	defaultConfigPath := filepath.Join(configDir, "lsp_config.json")
-	defaultLogPath := filepath.Join(logDir, "mcp-lsp-bridge.log")
+	return bridgeOptions{configDir: configDir, logDir: logDir}, nil
+}
 
-	// Parse command line flags
-	var confPath string
+// registerFlags adds the config and logging flags to fs
+func (o *bridgeOptions) registerFlags(fs *flag.FlagSet) {
+	defaultConfigPath := filepath.Join(o.configDir, "lsp_config.json")
 
-	var logPath string
+	fs.StringVar(&o.confPath, "config", defaultConfigPath, "Path to LSP configuration file")
+	fs.StringVar(&o.confPath, "c", defaultConfigPath, "Path to LSP configuration file (short)")
+	fs.StringVar(&o.logPath, "log-path", "", "Path to log file (overrides config and default)")
+	fs.StringVar(&o.logPath, "l", "", "Path to log file (short)")
+	fs.StringVar(&o.logLevel, "log-level", "", "Log level: debug, info, warn, error (overrides config)")
+	fs.StringVar(&o.logFormat, "log-format", os.Getenv("MCP_LSP_LOG_FORMAT"), "Log format: json or text (overrides config, default json)")
+}
 
-	var logLevel string
-
-	flag.StringVar(&confPath, "config", defaultConfigPath, "Path to LSP configuration file")
-	flag.StringVar(&confPath, "c", defaultConfigPath, "Path to LSP configuration file (short)")
-	flag.StringVar(&logPath, "log-path", "", "Path to log file (overrides config and default)")
-	flag.StringVar(&logPath, "l", "", "Path to log file (short)")
-	flag.StringVar(&logLevel, "log-level", "", "Log level: debug, info, warn, error (overrides config)")
-	flag.Parse()
+// newBridge loads the configuration, initializes the logger and creates the bridge.
+// The caller owns logger.Close.
+func newBridge(opts bridgeOptions) (*bridge.MCPLSPBridge, error) {
+	defaultLogPath := filepath.Join(opts.logDir, "mcp-lsp-bridge.log")
 
 	// Validate command line arguments for security
-	if err := validateCommandLineArgs(confPath, logPath, configDir, logDir); err != nil {
-		fmt.Fprintf(os.Stderr, "ERROR: Invalid command line arguments: %v\n", err)
-		os.Exit(1)
+	if err := validateCommandLineArgs(opts.confPath, opts.logPath, opts.configDir, opts.logDir); err != nil {
+		return nil, fmt.Errorf("invalid command line arguments: %w", err)
 	}
 
 	// Load LSP configuration
 	// Attempt to load config from multiple locations
-	config, err := tryLoadConfig(confPath, configDir)
+	config, err := tryLoadConfig(opts.confPath, opts.configDir)
 	logConfig := logger.LoggerConfig{}
 
 	if err != nil {
 		// Detailed error logging
-		fullErrMsg := fmt.Sprintf("CRITICAL: Failed to load LSP config from '%s': %v", confPath, err)
+		fullErrMsg := fmt.Sprintf("CRITICAL: Failed to load LSP config from '%s': %v", opts.confPath, err)
 		fmt.Fprintln(os.Stderr, fullErrMsg)
 		log.Println(fullErrMsg)
 
@@ -168,6 +183,7 @@ func main() {
 			Global: struct {
 				LogPath            string `json:"log_file_path"`
 				LogLevel           string `json:"log_level"`
+				LogFormat          string `json:"log_format"`
 				MaxLogFiles        int    `json:"max_log_files"`
 				MaxRestartAttempts int    `json:"max_restart_attempts"`
 				RestartDelayMs     int    `json:"restart_delay_ms"`
@@ -185,6 +201,7 @@ func main() {
 			LogPath:     config.Global.LogPath,
 			LogLevel:    config.Global.LogLevel,
 			MaxLogFiles: config.Global.MaxLogFiles,
+			Format:      config.Global.LogFormat,
 		}
 	}
 
@@ -193,12 +210,19 @@ func main() {
 	lsp.ApplyEnvOverrides(config)
 
 	// Override with command-line flags if provided
-	if logPath != "" {
-		logConfig.LogPath = logPath
+	if opts.logPath != "" {
+		logConfig.LogPath = opts.logPath
 	}
 
-	if logLevel != "" {
-		logConfig.LogLevel = logLevel
+	if opts.logLevel != "" {
+		logConfig.LogLevel = opts.logLevel
+	}
+
+	if opts.logFormat != "" {
+		logConfig.Format = opts.logFormat
+	}
+	if logConfig.Format != "" && logConfig.Format != "json" && logConfig.Format != "text" {
+		return nil, fmt.Errorf("invalid log format %q: expected json or text", logConfig.Format)
 	}
 
 	// Ensure we have a log path (use default if not specified)
@@ -207,22 +231,13 @@ func main() {
 	}
 
 	if err := logger.InitLogger(logConfig); err != nil {
-		panic("Failed to initialize logger: " + err.Error())
-	}
-	defer logger.Close()
-
-	logger.Info("Starting MCP-LSP Bridge...")
-
-	// Debug: log to a file that persists between calls
-	debugFile, _ := os.OpenFile("/tmp/mcp-debug.log", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-	if debugFile != nil {
-		fmt.Fprintf(debugFile, "=== MCP-LSP Bridge started at %s ===\n", time.Now().Format(time.RFC3339))
-		defer debugFile.Close()
+		return nil, fmt.Errorf("failed to initialize logger: %w", err)
 	}
 
 	cwd, err := os.Getwd()
 	if err != nil {
-		panic("Failed to get current working directory: " + err.Error())
+		logger.Close()
+		return nil, fmt.Errorf("failed to get current working directory: %w", err)
 	}
 
 	// In container mode we must anchor workspace operations to the mounted workspace root,
@@ -234,7 +249,63 @@ func main() {
 	}
 
 	// Create and initialize the bridge
-	bridgeInstance := bridge.NewMCPLSPBridge(config, allowedDirs)
+	return bridge.NewMCPLSPBridge(config, allowedDirs), nil
+}
+
+func main() {
+	if len(os.Args) > 1 {
+		if run, ok := subcommands[os.Args[1]]; ok {
+			os.Exit(run(os.Args[2:]))
+		}
+	}
+
+	opts, err := defaultBridgeOptions()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Parse command line flags
+	var transport string
+
+	var host string
+
+	var port int
+
+	opts.registerFlags(flag.CommandLine)
+	flag.StringVar(&transport, "transport", mcpserver.TransportStdio, "MCP transport: stdio, http (streamable HTTP) or sse")
+	flag.StringVar(&host, "host", "0.0.0.0", "Listen host for the http and sse transports")
+	flag.IntVar(&port, "port", 8080, "Listen port for the http and sse transports")
+	metricsAddr := flag.String("metrics-addr", os.Getenv(metrics.AddrEnv), "Serve Prometheus metrics on this address, e.g. :9465 (disabled when empty)")
+	flag.Parse()
+
+	if transport != mcpserver.TransportStdio && transport != mcpserver.TransportHTTP && transport != mcpserver.TransportSSE {
+		fmt.Fprintf(os.Stderr, "ERROR: Invalid --transport %q: expected stdio, http or sse\n", transport)
+		os.Exit(1)
+	}
+
+	bridgeInstance, err := newBridge(opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		os.Exit(1)
+	}
+	defer logger.Close()
+
+	logger.Info("Starting MCP-LSP Bridge...")
+
+	if *metricsAddr != "" {
+		if _, err := metrics.Serve(*metricsAddr, metrics.Default); err != nil {
+			logger.Warn("Metrics endpoint disabled: " + err.Error())
+		} else {
+			logger.Info("Metrics listening on " + *metricsAddr + "/metrics")
+		}
+	}
+
+	// Debug: log to a file that persists between calls
+	debugFile, _ := os.OpenFile("/tmp/mcp-debug.log", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if debugFile != nil {
+		fmt.Fprintf(debugFile, "=== MCP-LSP Bridge started at %s ===\n", time.Now().Format(time.RFC3339))
+		defer debugFile.Close()
+	}
 
 	// Setup MCP server with bridge
 	mcpServer := mcpserver.SetupMCPServer(bridgeInstance)
@@ -254,7 +325,16 @@ func main() {
 	// Start MCP server
 	logger.Info("Starting MCP server...")
 
-	if err := server.ServeStdio(mcpServer); err != nil {
+	// HTTP transports keep one bridge (and its LSP connections) alive for every client
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	err = mcpserver.Serve(ctx, mcpServer, bridgeInstance, mcpserver.TransportConfig{
+		Transport: transport,
+		Addr:      net.JoinHostPort(host, strconv.Itoa(port)),
+		AuthToken: os.Getenv(mcpserver.AuthTokenEnv),
+	})
+	if err != nil {
 		logger.Error("MCP server error: " + err.Error())
 	}
 }
@@ -0,0 +1,130 @@
+package mcpserver
+
+import (
+	"context"
+	"encoding/json"
+
+	"rockerboo/mcp-lsp-bridge/interfaces"
+	"rockerboo/mcp-lsp-bridge/logger"
+	"rockerboo/mcp-lsp-bridge/mcpserver/tools"
+	"rockerboo/mcp-lsp-bridge/utils"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// HostPathsArgument is the tool argument that turns the host path translation off
+// for one call, e.g. to pass a result on to something running in the container
+const HostPathsArgument = "host_paths"
+
+// hostPathMapper returns the bridge's mapper when it translates paths
+func hostPathMapper(bridge interfaces.BridgeInterface) *utils.DockerPathMapper {
+	if !bridge.HasPathMapper() {
+		return nil
+	}
+	mapper := bridge.GetPathMapper()
+	if mapper == nil || !mapper.IsEnabled() {
+		return nil
+	}
+	return mapper
+}
+
+// HostPathMiddleware translates the paths and file URIs of tool results from the
+// container (/projects/...) into the host form the MCP client can open
+// (D:/Projects/..., file:///D:/Projects/...). Language servers and tools only see
+// container paths; without this every location in a result is unusable outside
+// the container. Text and structured content are both rewritten, error results
+// included. It does nothing when the bridge has no path mapper.
+func HostPathMiddleware(bridge interfaces.BridgeInterface) server.ToolHandlerMiddleware {
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			result, err := next(ctx, request)
+			if err != nil || result == nil || !request.GetBool(HostPathsArgument, true) {
+				return result, err
+			}
+			if mapper := hostPathMapper(bridge); mapper != nil {
+				rewriteToolResult(ctx, result, mapper)
+			}
+			return result, nil
+		}
+	}
+}
+
+// rewriteToolResult rewrites a result in place
+func rewriteToolResult(ctx context.Context, result *mcp.CallToolResult, mapper *utils.DockerPathMapper) {
+	for i, content := range result.Content {
+		switch c := content.(type) {
+		case mcp.TextContent:
+			c.Text = mapper.ContainerToHostText(c.Text)
+			result.Content[i] = c
+		case *mcp.TextContent:
+			c.Text = mapper.ContainerToHostText(c.Text)
+		}
+	}
+	if result.StructuredContent == nil {
+		return
+	}
+
+	// Structured content can be any Go value; rewrite its JSON form
+	raw, err := json.Marshal(result.StructuredContent)
+	if err != nil {
+		logger.WarnContext(ctx, "structured tool result left with container paths", "error", err)
+		return
+	}
+	var generic any
+	if err := json.Unmarshal(raw, &generic); err != nil {
+		logger.WarnContext(ctx, "structured tool result left with container paths", "error", err)
+		return
+	}
+	result.StructuredContent = rewriteJSONValue(generic, mapper)
+}
+
+// rewriteJSONValue rewrites every string of a decoded JSON value, object keys
+// included: workspace edits key their changes by document URI
+func rewriteJSONValue(v any, mapper *utils.DockerPathMapper) any {
+	switch t := v.(type) {
+	case string:
+		return mapper.ContainerToHostText(t)
+	case []any:
+		for i := range t {
+			t[i] = rewriteJSONValue(t[i], mapper)
+		}
+	case map[string]any:
+		rewritten := make(map[string]any, len(t))
+		for k, val := range t {
+			rewritten[mapper.ContainerToHostText(k)] = rewriteJSONValue(val, mapper)
+		}
+		return rewritten
+	}
+	return v
+}
+
+// hostPathsToolServer adds the HostPathsArgument to the schema of every tool it
+// registers, so clients can see and pass it
+type hostPathsToolServer struct {
+	tools.ToolServer
+}
+
+func (s hostPathsToolServer) AddTool(tool mcp.Tool, handler server.ToolHandlerFunc) {
+	if tool.RawInputSchema == nil {
+		properties := make(map[string]any, len(tool.InputSchema.Properties)+1)
+		for k, v := range tool.InputSchema.Properties {
+			properties[k] = v
+		}
+		properties[HostPathsArgument] = map[string]any{
+			"type":        "boolean",
+			"description": "Return host paths and URIs instead of the container paths the language server uses (default: true)",
+			"default":     true,
+		}
+		tool.InputSchema.Properties = properties
+	}
+	s.ToolServer.AddTool(tool, handler)
+}
+
+// withHostPathsArgument wraps the tool server when the bridge translates paths
+func withHostPathsArgument(mcpServer tools.ToolServer, bridge interfaces.BridgeInterface) tools.ToolServer {
+	if hostPathMapper(bridge) == nil {
+		return mcpServer
+	}
+	return hostPathsToolServer{ToolServer: mcpServer}
+}
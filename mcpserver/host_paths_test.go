@@ -0,0 +1,98 @@
+package mcpserver
+
+import (
+	"context"
+	"testing"
+
+	"rockerboo/mcp-lsp-bridge/mocks"
+	"rockerboo/mcp-lsp-bridge/utils"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingToolServer struct {
+	tools map[string]mcp.Tool
+}
+
+func (s *recordingToolServer) AddTool(tool mcp.Tool, handler server.ToolHandlerFunc) {
+	s.tools[tool.Name] = tool
+}
+
+func mappedBridge(t *testing.T) *mocks.MockBridge {
+	mapper, err := utils.NewDockerPathMapper("D:/Projects 1C", "/projects")
+	require.NoError(t, err)
+	require.NoError(t, mapper.AddMount("E:/Lib", "/lib"))
+
+	bridge := &mocks.MockBridge{}
+	bridge.On("HasPathMapper").Return(true)
+	bridge.On("GetPathMapper").Return(mapper)
+	return bridge
+}
+
+func callTool(t *testing.T, handler server.ToolHandlerFunc, args map[string]any) *mcp.CallToolResult {
+	result, err := handler(context.Background(), mcp.CallToolRequest{
+		Params: mcp.CallToolParams{Name: "definition", Arguments: args},
+	})
+	require.NoError(t, err)
+	return result
+}
+
+func TestHostPathMiddleware(t *testing.T) {
+	type location struct {
+		URI  string `json:"uri"`
+		Path string `json:"path"`
+	}
+	tool := func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultStructured(map[string]any{
+			"locations": []location{{URI: "file:///projects/cfg/Module.bsl", Path: "/lib/Common.bsl"}},
+			"changes":   map[string]any{"file:///lib/A.bsl": []any{"edit"}},
+		}, "Definition: file:///projects/cfg/Module.bsl:3:1\nAlso in /lib/Common.bsl"), nil
+	}
+
+	handler := HostPathMiddleware(mappedBridge(t))(tool)
+
+	result := callTool(t, handler, map[string]any{"uri": "D:/Projects 1C/cfg/Module.bsl"})
+	require.Len(t, result.Content, 1)
+	text, ok := result.Content[0].(mcp.TextContent)
+	require.True(t, ok)
+	assert.Equal(t, "Definition: file:///D:/Projects%201C/cfg/Module.bsl:3:1\nAlso in E:/Lib/Common.bsl", text.Text)
+	assert.Equal(t, map[string]any{
+		"locations": []any{map[string]any{"uri": "file:///D:/Projects%201C/cfg/Module.bsl", "path": "E:/Lib/Common.bsl"}},
+		"changes":   map[string]any{"file:///E:/Lib/A.bsl": []any{"edit"}},
+	}, result.StructuredContent)
+
+	t.Run("opt out", func(t *testing.T) {
+		result := callTool(t, handler, map[string]any{HostPathsArgument: false})
+		assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "file:///projects/cfg/Module.bsl")
+	})
+
+	t.Run("error results", func(t *testing.T) {
+		failing := HostPathMiddleware(mappedBridge(t))(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			return mcp.NewToolResultError("cannot read /projects/x.bsl"), nil
+		})
+		assert.Equal(t, "cannot read D:/Projects 1C/x.bsl", callTool(t, failing, nil).Content[0].(mcp.TextContent).Text)
+	})
+
+	t.Run("no mapper", func(t *testing.T) {
+		plain := HostPathMiddleware(&mocks.MockBridge{})(tool)
+		assert.Contains(t, callTool(t, plain, nil).Content[0].(mcp.TextContent).Text, "file:///projects/cfg/Module.bsl")
+	})
+}
+
+func TestRegisterAllToolsHostPathsArgument(t *testing.T) {
+	registry := &recordingToolServer{tools: map[string]mcp.Tool{}}
+	RegisterAllTools(registry, mappedBridge(t))
+	require.NotEmpty(t, registry.tools)
+	for name, tool := range registry.tools {
+		assert.Contains(t, tool.InputSchema.Properties, HostPathsArgument, name)
+	}
+
+	registry = &recordingToolServer{tools: map[string]mcp.Tool{}}
+	RegisterAllTools(registry, &mocks.MockBridge{})
+	for name, tool := range registry.tools {
+		assert.NotContains(t, tool.InputSchema.Properties, HostPathsArgument, name)
+	}
+}
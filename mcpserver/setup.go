@@ -1,10 +1,8 @@
 package mcpserver
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
-	"fmt"
+	"time"
 
 	bridgepkg "rockerboo/mcp-lsp-bridge/bridge"
 	"rockerboo/mcp-lsp-bridge/interfaces"
@@ -19,37 +17,16 @@ func SetupMCPServer(bridge interfaces.BridgeInterface) *server.MCPServer {
 	hooks := &server.Hooks{}
 
 	hooks.AddBeforeAny(func(ctx context.Context, id any, method mcp.MCPMethod, message any) {
-		logger.Debug("beforeAny:", method, id, message)
+		logger.DebugContext(ctx, "mcp request", "method", method, "id", id, "message", logger.Redact(message))
 	})
 	hooks.AddOnSuccess(func(ctx context.Context, id any, method mcp.MCPMethod, message any, result any) {
-		logger.Debug("onSuccess:", method, id, message, result)
+		logger.DebugContext(ctx, "mcp response", "method", method, "id", id, "result", logger.Redact(result))
 	})
 	hooks.AddOnError(func(ctx context.Context, id any, method mcp.MCPMethod, message any, err error) {
-		logger.Error("onError:", method, id, message, err)
+		logger.ErrorContext(ctx, "mcp request failed", "method", method, "id", id, "message", logger.Redact(message), "error", err)
 	})
 	hooks.AddBeforeInitialize(func(ctx context.Context, id any, message *mcp.InitializeRequest) {
-		logger.Debug("beforeInitialize:", id, message)
-	})
-	hooks.AddOnRequestInitialization(func(ctx context.Context, id any, message any) error {
-		idStr := fmt.Sprintf("%v", id)
-
-		var prettyJSON bytes.Buffer
-		switch v := message.(type) {
-		case []byte:
-			if err := json.Indent(&prettyJSON, v, "", "  "); err != nil {
-				prettyJSON.Write(v) // fallback if not valid JSON
-			}
-		case string:
-			if err := json.Indent(&prettyJSON, []byte(v), "", "  "); err != nil {
-				prettyJSON.WriteString(v) // fallback
-			}
-		default:
-			jsonData, _ := json.MarshalIndent(v, "", "  ")
-			prettyJSON.Write(jsonData)
-		}
-
-		logger.Debug(fmt.Sprintf("AddOnRequestInitialization: id=%s, message=%s", idStr, prettyJSON.String()))
-		return nil
+		logger.DebugContext(ctx, "mcp initialize", "id", id, "client", message.Params.ClientInfo.Name, "protocol", message.Params.ProtocolVersion)
 	})
 	hooks.AddAfterInitialize(func(ctx context.Context, id any, message *mcp.InitializeRequest, result *mcp.InitializeResult) {
 		logger.Debug("afterInitialize:", id, message, result)
@@ -60,19 +37,14 @@ func SetupMCPServer(bridge interfaces.BridgeInterface) *server.MCPServer {
 			b.StartAutoConnect()
 		}
 	})
-	hooks.AddAfterCallTool(func(ctx context.Context, id any, message *mcp.CallToolRequest, result *mcp.CallToolResult) {
-		logger.Debug("afterCallTool:", id, message, result)
-	})
-	hooks.AddBeforeCallTool(func(ctx context.Context, id any, message *mcp.CallToolRequest) {
-		logger.Debug("beforeCallTool:", id, message)
-	})
-
 	mcpServer := server.NewMCPServer(
 		"mcp-lsp-bridge",
 		"1.0.0",
 		server.WithToolCapabilities(true),
 		server.WithLogging(),
 		server.WithHooks(hooks),
+		server.WithToolHandlerMiddleware(ToolCallMiddleware),
+		server.WithToolHandlerMiddleware(HostPathMiddleware(bridge)),
 		server.WithInstructions(`This MCP server provides comprehensive Language Server Protocol (LSP) integration for advanced code analysis and manipulation across multiple programming languages.
 
 ## Key Capabilities & Usage Flow
@@ -115,3 +87,40 @@ func setupDefaultSession(mcpServer *server.MCPServer) {
 		logger.Info("Default session registered successfully")
 	}
 }
+
+// ToolCallMiddleware gives every tool call a correlation id, carried in the context,
+// and logs the start and the end of the call. Handlers bind the bridge to the context,
+// so the id reaches the session manager in the correlationId field of its requests.
+func ToolCallMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		id := logger.NewCorrelationID()
+		ctx = logger.WithCorrelationID(ctx, id)
+
+		name := request.Params.Name
+		start := time.Now()
+		logger.InfoContext(ctx, "tool call started", "tool", name, "arguments", logger.Redact(request.Params.Arguments))
+
+		result, err := next(ctx, request)
+
+		elapsed := time.Since(start).Milliseconds()
+		switch {
+		case err != nil:
+			logger.ErrorContext(ctx, "tool call failed", "tool", name, "duration_ms", elapsed, "error", err)
+		case result != nil && result.IsError:
+			logger.WarnContext(ctx, "tool call returned an error", "tool", name, "duration_ms", elapsed, "error", toolResultMessage(result))
+		default:
+			logger.InfoContext(ctx, "tool call finished", "tool", name, "duration_ms", elapsed)
+		}
+		return result, err
+	}
+}
+
+// toolResultMessage returns the first text of an error result
+func toolResultMessage(result *mcp.CallToolResult) string {
+	for _, content := range result.Content {
+		if text, ok := content.(mcp.TextContent); ok {
+			return text.Text
+		}
+	}
+	return ""
+}
@@ -7,6 +7,9 @@ import (
 
 // Registers all MCP tools with the server
 func RegisterAllTools(mcpServer tools.ToolServer, bridge interfaces.BridgeInterface) {
+	// Every tool takes host_paths when results are translated to host paths
+	mcpServer = withHostPathsArgument(mcpServer, bridge)
+
 	// Core analysis tools
 
 	// New unified symbol exploration tool
@@ -31,6 +34,14 @@ func RegisterAllTools(mcpServer tools.ToolServer, bridge interfaces.BridgeInterf
 	// Code intelligence tools
 	tools.RegisterHoverTool(mcpServer, bridge)
 	tools.RegisterDefinitionTool(mcpServer, bridge)
+	tools.RegisterDeclarationTool(mcpServer, bridge)
+	tools.RegisterTypeDefinitionTool(mcpServer, bridge)
+	// Read/write occurrences of a variable within its module
+	tools.RegisterDocumentHighlightTool(mcpServer, bridge)
+	// Members of global context objects and metadata managers, instead of guessed names
+	tools.RegisterCompletionTool(mcpServer, bridge)
+	// Per-method cognitive/cyclomatic complexity and test lenses of a module or directory
+	tools.RegisterCodeLensTool(mcpServer, bridge)
 	tools.RegisterSelectionRangeTool(mcpServer, bridge)
 	// tools.RegisterSignatureHelpTool(mcpServer, bridge)  // BSL LS не поддерживает signature help
 	// tools.RegisterDiagnosticsTool(mcpServer, bridge)
@@ -57,12 +68,28 @@ func RegisterAllTools(mcpServer tools.ToolServer, bridge interfaces.BridgeInterf
 	tools.RegisterCallHierarchyTool(mcpServer, bridge)
 	tools.RegisterCallGraphTool(mcpServer, bridge)
 
+	// 1C configuration metadata (Designer/EDT dumps), no language server needed
+	tools.RegisterMetadataExploreTool(mcpServer, bridge)
+
+	// &НаКлиенте/&НаСервере checks over the call hierarchy, using the metadata for module contexts
+	tools.RegisterContextCheckTool(mcpServer, bridge)
+
+	// Unused methods over the whole configuration, scanned page by page
+	tools.RegisterDeadCodeTool(mcpServer, bridge)
+
 	// Workspace analysis
-	// tools.RegisterWorkspaceDiagnosticsTool(mcpServer, bridge) // Too heavy/noisy for AI agent workflows
+	// Served from pushed diagnostics once indexing is done; the workspace/diagnostic pull is only a fallback
+	tools.RegisterWorkspaceDiagnosticsTool(mcpServer, bridge)
 
 	// Document diagnostics
 	tools.RegisterDocumentDiagnosticsTool(mcpServer, bridge)
 
+	// Diagnostics added or fixed between two git revisions, via temporary worktrees
+	tools.RegisterDiagnosticsDiffTool(mcpServer, bridge)
+
+	// In-memory document overlays: check proposed edits before writing them
+	tools.RegisterUpdateBufferTool(mcpServer, bridge)
+
 	// Workspace notifications and commands
 	// did_change_watched_files - needed for notifying LSP about new files (essential for call_graph)
 	tools.RegisterDidChangeWatchedFilesTool(mcpServer, bridge)
@@ -26,6 +26,8 @@ func AnalyzeCode(bridge interfaces.BridgeInterface) (mcp.Tool, server.ToolHandle
 			mcp.WithNumber("line", mcp.Description("Line of the file to analyze")),
 			mcp.WithNumber("character", mcp.Description("Character of the line to analyze")),
 		), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			bridge := bridge.WithContext(ctx)
+
 			uri, err := request.RequireString("uri")
 			if err != nil {
 				logger.Error("analyze_code: URI parsing failed", err)
@@ -2,10 +2,11 @@ package tools
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
+	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"rockerboo/mcp-lsp-bridge/interfaces"
@@ -23,6 +24,10 @@ const (
 	DefaultMaxNodes  = 100
 	HardLimitNodes   = 500
 	TimeoutSeconds   = 60
+
+	// IndexedHardLimitNodes replaces HardLimitNodes when the call graph index answers;
+	// index lookups take microseconds, so only the response size is limited
+	IndexedHardLimitNodes = 10000
 )
 
 // Known BSL entry points (event handlers, commands, etc.)
@@ -79,6 +84,7 @@ type CallGraphNode struct {
 	Line         uint32           `json:"line"`
 	Character    uint32           `json:"character"`
 	IsEntryPoint bool             `json:"is_entry_point,omitempty"`
+	Triggers     []string         `json:"triggers,omitempty"` // what calls an entry point, e.g. "item Товары.OnChange of form ФормаЭлемента"
 	IsCycle      bool             `json:"is_cycle,omitempty"`
 	Depth        int              `json:"depth"`
 	Direction    string           `json:"direction"` // "up", "down", "root"
@@ -97,6 +103,8 @@ type CallGraphResult struct {
 	TruncateReason string         `json:"truncate_reason,omitempty"`
 	CyclesFound    int            `json:"cycles_found"`
 	EntryPoints    []string       `json:"entry_points_found,omitempty"`
+	IndexedQueries int            `json:"indexed_queries"` // answered by the call graph index
+	LiveQueries    int            `json:"live_queries"`    // sent to the language server
 	ElapsedMs      int64          `json:"elapsed_ms"`
 }
 
@@ -108,6 +116,8 @@ type callGraphBuilder struct {
 	nodeCount      int
 	nodeCountMu    sync.Mutex
 	maxNodes       int
+	directionNodes map[string]int // nodes per direction ("up", "down")
+	directionLimit map[string]int // hard node limit per direction, none when missing
 	depthUp        int
 	depthDown      int
 	cyclesFound    int
@@ -120,6 +130,37 @@ type callGraphBuilder struct {
 	ctx            context.Context
 	truncated      bool
 	truncateReason string
+	indexedQueries int64
+	liveQueries    int64
+	configs        configLookup // configurations of the walked modules, resolved once per call
+	root           rootCalls
+}
+
+// rootCalls keeps the index answers for the root item. They decide the node limits
+// before the walk and are its first level, so the root is looked up only once.
+type rootCalls struct {
+	key             string
+	incoming        []protocol.CallHierarchyIncomingCall
+	outgoing        []protocol.CallHierarchyOutgoingCall
+	incomingIndexed bool
+	outgoingIndexed bool
+}
+
+// seedRoot asks the call graph index about item and reports which directions it answers
+func (b *callGraphBuilder) seedRoot(item protocol.CallHierarchyItem) (incoming, outgoing bool) {
+	b.root.key = callItemKey(&item)
+	if calls, indexed, err := b.bridge.IndexedIncomingCalls(b.ctx, item); err == nil && indexed {
+		b.root.incoming, b.root.incomingIndexed = calls, true
+	}
+	if calls, indexed, err := b.bridge.IndexedOutgoingCalls(b.ctx, item); err == nil && indexed {
+		b.root.outgoing, b.root.outgoingIndexed = calls, true
+	}
+	return b.root.incomingIndexed, b.root.outgoingIndexed
+}
+
+// callItemKey identifies a call hierarchy item by its position
+func callItemKey(item *protocol.CallHierarchyItem) string {
+	return fmt.Sprintf("%s:%d:%d", item.Uri, item.Range.Start.Line, item.Range.Start.Character)
 }
 
 // RegisterCallGraphTool registers the call graph tool
@@ -143,11 +184,25 @@ Parameters:
 - depth_down: How deep to trace callees (default: 5, 0 = unlimited up to hard limit)
 - max_nodes: Maximum nodes to collect (default: 100, 0 = unlimited up to 500)
 
+With lsp-session-manager the graph is answered from a persistent call graph index
+built after indexing; then the hard limit of a direction (callers or callees) the index
+answers is 10000 nodes and only files changed since indexing are queried live (see
+indexed_queries / live_queries in the output).
+
 Output includes:
 - Complete call trees (incoming/outgoing)
-- Entry point detection (BSL events like ПриЗаписи, ПриОткрытии)
+- Entry point detection: handlers bound in Form.xml/Form.form (form, item and command
+  events), event subscriptions and scheduled jobs when the configuration dump is
+  available, otherwise BSL event names like ПриЗаписи, ПриОткрытии
 - Cycle detection with markers
-- Truncation info if limits reached`),
+- Truncation info if limits reached
+
+Formats (format parameter):
+- json (default): nested incoming/outgoing trees as described above
+- dot, mermaid, graphml: the trees flattened into nodes and edges, cycle edges dashed,
+  ready for graphviz, Markdown docs or yEd/Gephi
+- adjacency: the flat node and edge lists as JSON
+cluster=module|object groups nodes by .bsl module or by metadata object (Catalog.Товары)`),
 			mcp.WithDestructiveHintAnnotation(false),
 			mcp.WithString("uri", mcp.Description("URI to the file"), mcp.Required()),
 			mcp.WithNumber("line", mcp.Description("Line number (0-based)"), mcp.Required()),
@@ -155,7 +210,11 @@ Output includes:
 			mcp.WithNumber("depth_up", mcp.Description("Max depth for incoming calls (default: 5, 0 = unlimited)")),
 			mcp.WithNumber("depth_down", mcp.Description("Max depth for outgoing calls (default: 5, 0 = unlimited)")),
 			mcp.WithNumber("max_nodes", mcp.Description("Max total nodes (default: 100, 0 = unlimited, hard limit: 500)")),
+			mcp.WithString("format", mcp.Description("Output format: json (default), dot, mermaid, graphml, adjacency"), mcp.Enum(CallGraphFormats...)),
+			mcp.WithString("cluster", mcp.Description("Group nodes in dot/mermaid/graphml/adjacency: none (default), module, object"), mcp.Enum(CallGraphClusters...)),
 		), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			bridge := bridge.WithContext(ctx)
+
 			startTime := time.Now()
 
 			// Parse required parameters
@@ -175,30 +234,28 @@ Output includes:
 			}
 
 			// Parse optional parameters with defaults
+			// 0 means unlimited; the hard limit is applied once it is known whether the index answers
 			depthUp := DefaultDepthUp
 			if val, err := request.RequireInt("depth_up"); err == nil {
 				depthUp = val
-				if depthUp == 0 {
-					depthUp = HardLimitNodes // 0 means unlimited, but respect hard limit through node count
-				}
 			}
 
 			depthDown := DefaultDepthDown
 			if val, err := request.RequireInt("depth_down"); err == nil {
 				depthDown = val
-				if depthDown == 0 {
-					depthDown = HardLimitNodes
-				}
 			}
 
 			maxNodes := DefaultMaxNodes
 			if val, err := request.RequireInt("max_nodes"); err == nil {
 				maxNodes = val
-				if maxNodes == 0 || maxNodes > HardLimitNodes {
-					maxNodes = HardLimitNodes
-				}
 			}
 
+			format := request.GetString("format", CallGraphFormatJSON)
+			if !slices.Contains(CallGraphFormats, format) {
+				return mcp.NewToolResultError(fmt.Sprintf("unknown format %q: expected one of %s", format, strings.Join(CallGraphFormats, ", "))), nil
+			}
+			cluster := request.GetString("cluster", CallGraphClusterNone)
+
 			if result, ok := CheckReadyOrReturn(bridge); !ok {
 				return result, nil
 			}
@@ -221,7 +278,7 @@ Output includes:
 			normalizedURI := bridge.NormalizeURIForLSP(uri)
 
 			// Prepare call hierarchy to get the root item
-			prepItems, err := bridge.PrepareCallHierarchy(normalizedURI, lineUint32, characterUint32)
+			prepItems, err := bridge.PrepareCallHierarchyContext(timeoutCtx, normalizedURI, lineUint32, characterUint32)
 			if err != nil {
 				return mcp.NewToolResultError(fmt.Sprintf("Failed to prepare call hierarchy: %v", err)), nil
 			}
@@ -233,24 +290,42 @@ Output includes:
 			// Use first item as root
 			rootItem := prepItems[0]
 
-			// Create builder
 			builder := &callGraphBuilder{
-				bridge:    bridge,
-				visited:   make(map[string]bool),
-				maxNodes:  maxNodes,
-				depthUp:   depthUp,
-				depthDown: depthDown,
-				ctx:       timeoutCtx,
+				bridge:         bridge,
+				visited:        make(map[string]bool),
+				directionNodes: make(map[string]int),
+				ctx:            timeoutCtx,
 			}
 
+			// Each direction gets the larger limit only when the index answers for it
+			hardLimitUp, hardLimitDown := HardLimitNodes, HardLimitNodes
+			indexedUp, indexedDown := builder.seedRoot(rootItem)
+			if indexedUp {
+				hardLimitUp = IndexedHardLimitNodes
+			}
+			if indexedDown {
+				hardLimitDown = IndexedHardLimitNodes
+			}
+			if depthUp == 0 {
+				depthUp = hardLimitUp // unlimited, but respect hard limit through node count
+			}
+			if depthDown == 0 {
+				depthDown = hardLimitDown
+			}
+			if hardLimit := max(hardLimitUp, hardLimitDown); maxNodes == 0 || maxNodes > hardLimit {
+				maxNodes = hardLimit
+			}
+			builder.maxNodes = maxNodes
+			builder.directionLimit = map[string]int{"up": hardLimitUp, "down": hardLimitDown}
+			builder.depthUp = depthUp
+			builder.depthDown = depthDown
+
 			// Build root node
+			builder.reserveNode("root")
 			rootNode := builder.itemToNode(&rootItem, 0, "root")
 
 			// Check if root is an entry point
-			if isEntryPoint(rootItem.Name) {
-				rootNode.IsEntryPoint = true
-				builder.addEntryPoint(rootItem.Name)
-			}
+			builder.markEntryPoint(rootNode, &rootItem)
 
 			// Build incoming tree (callers) - parallel
 			var incomingTree *CallGraphNode
@@ -283,27 +358,24 @@ Output includes:
 				TruncateReason: builder.truncateReason,
 				CyclesFound:    builder.cyclesFound,
 				EntryPoints:    builder.entryPoints,
+				IndexedQueries: int(atomic.LoadInt64(&builder.indexedQueries)),
+				LiveQueries:    int(atomic.LoadInt64(&builder.liveQueries)),
 				ElapsedMs:      time.Since(startTime).Milliseconds(),
 			}
 
-			// Marshal to JSON
-			jsonBytes, err := json.MarshalIndent(result, "", "  ")
+			output, err := ExportCallGraph(result, format, cluster)
 			if err != nil {
-				return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal result: %v", err)), nil
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to export call graph: %v", err)), nil
 			}
 
-			return mcp.NewToolResultText(string(jsonBytes)), nil
+			return mcp.NewToolResultText(output), nil
 		}
 }
 
-// itemToNode converts a CallHierarchyItem to a CallGraphNode
+// itemToNode converts a CallHierarchyItem to a CallGraphNode reserved with reserveNode
 func (b *callGraphBuilder) itemToNode(item *protocol.CallHierarchyItem, depth int, direction string) *CallGraphNode {
-	b.nodeCountMu.Lock()
-	b.nodeCount++
-	b.nodeCountMu.Unlock()
-
 	return &CallGraphNode{
-		ID:        fmt.Sprintf("%s:%d:%d", item.Uri, item.Range.Start.Line, item.Range.Start.Character),
+		ID:        callItemKey(item),
 		Name:      item.Name,
 		Kind:      symbolKindToString(item.Kind),
 		URI:       string(item.Uri),
@@ -330,13 +402,9 @@ func (b *callGraphBuilder) buildIncomingTree(item *protocol.CallHierarchyItem, d
 	}
 
 	// Check node limit
-	b.nodeCountMu.Lock()
-	if b.nodeCount >= b.maxNodes {
-		b.nodeCountMu.Unlock()
-		b.setTruncated(fmt.Sprintf("max_nodes limit reached (%d)", b.maxNodes))
+	if b.nodeLimitReached("up") {
 		return nil
 	}
-	b.nodeCountMu.Unlock()
 
 	// Update max depth reached
 	b.depthMu.Lock()
@@ -346,7 +414,7 @@ func (b *callGraphBuilder) buildIncomingTree(item *protocol.CallHierarchyItem, d
 	b.depthMu.Unlock()
 
 	// Get incoming calls from LSP
-	calls, err := b.bridge.IncomingCalls(*item)
+	calls, err := b.incomingCalls(*item)
 	if err != nil {
 		logger.Error("call_graph: failed to get incoming calls", err)
 		return nil
@@ -371,14 +439,10 @@ func (b *callGraphBuilder) buildIncomingTree(item *protocol.CallHierarchyItem, d
 	semaphore := make(chan struct{}, 5) // Limit concurrent LSP calls
 
 	for _, call := range calls {
-		// Check limits before spawning goroutine
-		b.nodeCountMu.Lock()
-		if b.nodeCount >= b.maxNodes {
-			b.nodeCountMu.Unlock()
-			b.setTruncated(fmt.Sprintf("max_nodes limit reached (%d)", b.maxNodes))
+		// Count the node before spawning, so parallel callers cannot overshoot the limits
+		if !b.reserveNode("up") {
 			break
 		}
-		b.nodeCountMu.Unlock()
 
 		select {
 		case <-b.ctx.Done():
@@ -396,7 +460,7 @@ func (b *callGraphBuilder) buildIncomingTree(item *protocol.CallHierarchyItem, d
 			defer func() { <-semaphore }() // Release
 
 			callerItem := callCopy.From
-			nodeKey := fmt.Sprintf("%s:%d:%d", callerItem.Uri, callerItem.Range.Start.Line, callerItem.Range.Start.Character)
+			nodeKey := callItemKey(&callerItem)
 
 			// Check for cycle
 			b.visitedMu.RLock()
@@ -423,10 +487,7 @@ func (b *callGraphBuilder) buildIncomingTree(item *protocol.CallHierarchyItem, d
 			b.visitedMu.Unlock()
 
 			// Check if entry point
-			if isEntryPoint(callerItem.Name) {
-				node.IsEntryPoint = true
-				b.addEntryPoint(callerItem.Name)
-			}
+			b.markEntryPoint(node, &callerItem)
 
 			// Recurse for incoming calls
 			childTree := b.buildIncomingTree(&callerItem, depth+1)
@@ -465,13 +526,9 @@ func (b *callGraphBuilder) buildOutgoingTree(item *protocol.CallHierarchyItem, d
 	}
 
 	// Check node limit
-	b.nodeCountMu.Lock()
-	if b.nodeCount >= b.maxNodes {
-		b.nodeCountMu.Unlock()
-		b.setTruncated(fmt.Sprintf("max_nodes limit reached (%d)", b.maxNodes))
+	if b.nodeLimitReached("down") {
 		return nil
 	}
-	b.nodeCountMu.Unlock()
 
 	// Update max depth reached
 	b.depthMu.Lock()
@@ -481,7 +538,7 @@ func (b *callGraphBuilder) buildOutgoingTree(item *protocol.CallHierarchyItem, d
 	b.depthMu.Unlock()
 
 	// Get outgoing calls from LSP
-	calls, err := b.bridge.OutgoingCalls(*item)
+	calls, err := b.outgoingCalls(*item)
 	if err != nil {
 		logger.Error("call_graph: failed to get outgoing calls", err)
 		return nil
@@ -506,14 +563,10 @@ func (b *callGraphBuilder) buildOutgoingTree(item *protocol.CallHierarchyItem, d
 	semaphore := make(chan struct{}, 5) // Limit concurrent LSP calls
 
 	for _, call := range calls {
-		// Check limits before spawning goroutine
-		b.nodeCountMu.Lock()
-		if b.nodeCount >= b.maxNodes {
-			b.nodeCountMu.Unlock()
-			b.setTruncated(fmt.Sprintf("max_nodes limit reached (%d)", b.maxNodes))
+		// Count the node before spawning, so parallel callers cannot overshoot the limits
+		if !b.reserveNode("down") {
 			break
 		}
-		b.nodeCountMu.Unlock()
 
 		select {
 		case <-b.ctx.Done():
@@ -531,7 +584,7 @@ func (b *callGraphBuilder) buildOutgoingTree(item *protocol.CallHierarchyItem, d
 			defer func() { <-semaphore }() // Release
 
 			calleeItem := callCopy.To
-			nodeKey := fmt.Sprintf("%s:%d:%d", calleeItem.Uri, calleeItem.Range.Start.Line, calleeItem.Range.Start.Character)
+			nodeKey := callItemKey(&calleeItem)
 
 			// Check for cycle
 			b.visitedMu.RLock()
@@ -578,6 +631,40 @@ func (b *callGraphBuilder) buildOutgoingTree(item *protocol.CallHierarchyItem, d
 	return containerNode
 }
 
+// incomingCalls answers from the call graph index when it can, otherwise asks the server
+func (b *callGraphBuilder) incomingCalls(item protocol.CallHierarchyItem) ([]protocol.CallHierarchyIncomingCall, error) {
+	if b.root.incomingIndexed && callItemKey(&item) == b.root.key {
+		atomic.AddInt64(&b.indexedQueries, 1)
+		return b.root.incoming, nil
+	}
+	calls, indexed, err := b.bridge.IndexedIncomingCalls(b.ctx, item)
+	if err != nil {
+		logger.Warn(fmt.Sprintf("call_graph: index lookup failed, querying the server: %v", err))
+	} else if indexed {
+		atomic.AddInt64(&b.indexedQueries, 1)
+		return calls, nil
+	}
+	atomic.AddInt64(&b.liveQueries, 1)
+	return b.bridge.IncomingCallsContext(b.ctx, item)
+}
+
+// outgoingCalls answers from the call graph index when it can, otherwise asks the server
+func (b *callGraphBuilder) outgoingCalls(item protocol.CallHierarchyItem) ([]protocol.CallHierarchyOutgoingCall, error) {
+	if b.root.outgoingIndexed && callItemKey(&item) == b.root.key {
+		atomic.AddInt64(&b.indexedQueries, 1)
+		return b.root.outgoing, nil
+	}
+	calls, indexed, err := b.bridge.IndexedOutgoingCalls(b.ctx, item)
+	if err != nil {
+		logger.Warn(fmt.Sprintf("call_graph: index lookup failed, querying the server: %v", err))
+	} else if indexed {
+		atomic.AddInt64(&b.indexedQueries, 1)
+		return calls, nil
+	}
+	atomic.AddInt64(&b.liveQueries, 1)
+	return b.bridge.OutgoingCallsContext(b.ctx, item)
+}
+
 // isEntryPoint checks if a method name is a known BSL entry point
 func isEntryPoint(name string) bool {
 	// Check exact match first
@@ -595,6 +682,16 @@ func isEntryPoint(name string) bool {
 	return false
 }
 
+// markEntryPoint flags node when the platform calls item, using the form and
+// configuration bindings when available (see bslEntryPoint)
+func (b *callGraphBuilder) markEntryPoint(node *CallGraphNode, item *protocol.CallHierarchyItem) {
+	if ok, triggers := bslEntryPoint(&b.configs, string(item.Uri), item.Name); ok {
+		node.IsEntryPoint = true
+		node.Triggers = triggers
+		b.addEntryPoint(item.Name)
+	}
+}
+
 // addEntryPoint safely adds an entry point to the list
 func (b *callGraphBuilder) addEntryPoint(name string) {
 	b.entryMu.Lock()
@@ -609,6 +706,54 @@ func (b *callGraphBuilder) addEntryPoint(name string) {
 	b.entryPoints = append(b.entryPoints, name)
 }
 
+// nodeLimitReached reports whether no more nodes may be added in direction
+// and records why the graph is truncated
+func (b *callGraphBuilder) nodeLimitReached(direction string) bool {
+	b.nodeCountMu.Lock()
+	reason := b.nodeLimitLocked(direction)
+	b.nodeCountMu.Unlock()
+
+	if reason == "" {
+		return false
+	}
+	b.setTruncated(reason)
+	return true
+}
+
+// reserveNode counts one more node in direction unless a limit is reached
+func (b *callGraphBuilder) reserveNode(direction string) bool {
+	b.nodeCountMu.Lock()
+	reason := b.nodeLimitLocked(direction)
+	if reason == "" {
+		b.nodeCount++
+		if b.directionNodes != nil {
+			b.directionNodes[direction]++
+		}
+	}
+	b.nodeCountMu.Unlock()
+
+	if reason == "" {
+		return true
+	}
+	b.setTruncated(reason)
+	return false
+}
+
+// nodeLimitLocked returns why no more nodes may be added in direction, "" if they may
+func (b *callGraphBuilder) nodeLimitLocked(direction string) string {
+	if b.nodeCount >= b.maxNodes {
+		return fmt.Sprintf("max_nodes limit reached (%d)", b.maxNodes)
+	}
+	if limit, ok := b.directionLimit[direction]; ok && b.directionNodes[direction] >= limit {
+		calls := "outgoing"
+		if direction == "up" {
+			calls = "incoming"
+		}
+		return fmt.Sprintf("hard limit reached for %s calls (%d)", calls, limit)
+	}
+	return ""
+}
+
 // setTruncated safely sets truncation status
 func (b *callGraphBuilder) setTruncated(reason string) {
 	b.nodeCountMu.Lock()
@@ -0,0 +1,349 @@
+package tools
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"rockerboo/mcp-lsp-bridge/utils"
+)
+
+// Call graph output formats
+const (
+	CallGraphFormatJSON      = "json"      // nested incoming/outgoing trees (CallGraphResult)
+	CallGraphFormatDOT       = "dot"       // graphviz
+	CallGraphFormatMermaid   = "mermaid"   // flowchart for Markdown docs
+	CallGraphFormatGraphML   = "graphml"   // yEd, Gephi
+	CallGraphFormatAdjacency = "adjacency" // JSON node and edge lists
+)
+
+// Node clustering for the flat formats
+const (
+	CallGraphClusterNone   = "none"
+	CallGraphClusterModule = "module" // one cluster per .bsl file
+	CallGraphClusterObject = "object" // one cluster per metadata object, e.g. Catalog.Товары
+)
+
+// CallGraphFormats lists the values accepted by the format parameter
+var CallGraphFormats = []string{CallGraphFormatJSON, CallGraphFormatDOT, CallGraphFormatMermaid, CallGraphFormatGraphML, CallGraphFormatAdjacency}
+
+// CallGraphClusters lists the values accepted by the cluster parameter
+var CallGraphClusters = []string{CallGraphClusterNone, CallGraphClusterModule, CallGraphClusterObject}
+
+// FlatCallGraphNode is a method in the flattened call graph
+type FlatCallGraphNode struct {
+	ID           string   `json:"id"`
+	Name         string   `json:"name"`
+	Kind         string   `json:"kind,omitempty"`
+	URI          string   `json:"uri"`
+	Line         uint32   `json:"line"`
+	Root         bool     `json:"root,omitempty"`
+	IsEntryPoint bool     `json:"is_entry_point,omitempty"`
+	Triggers     []string `json:"triggers,omitempty"`
+	Cluster      string   `json:"cluster,omitempty"`
+}
+
+// FlatCallGraphEdge is a call from one method to another. Cycle marks a call back
+// to a method already in the graph, where the tree traversal stopped.
+type FlatCallGraphEdge struct {
+	From  string `json:"from"`
+	To    string `json:"to"`
+	Cycle bool   `json:"cycle,omitempty"`
+}
+
+// FlatCallGraph is a call graph as node and edge lists
+type FlatCallGraph struct {
+	Nodes []FlatCallGraphNode `json:"nodes"`
+	Edges []FlatCallGraphEdge `json:"edges"`
+}
+
+// FlattenCallGraph turns the incoming and outgoing trees into node and edge lists.
+// Nodes are deduplicated by ID; cluster is one of the CallGraphCluster* values.
+func FlattenCallGraph(result *CallGraphResult, cluster string) *FlatCallGraph {
+	flat := &FlatCallGraph{Nodes: []FlatCallGraphNode{}, Edges: []FlatCallGraphEdge{}}
+	if result == nil || result.Root == nil {
+		return flat
+	}
+
+	var configs configLookup
+	nodes := make(map[string]int)
+	edges := make(map[[2]string]int)
+	addNode := func(n *CallGraphNode) {
+		if i, ok := nodes[n.ID]; ok {
+			// A cycle marker carries no entry point information; keep the richer copy
+			if n.IsEntryPoint && !flat.Nodes[i].IsEntryPoint {
+				flat.Nodes[i].IsEntryPoint, flat.Nodes[i].Triggers = true, n.Triggers
+			}
+			return
+		}
+		nodes[n.ID] = len(flat.Nodes)
+		flat.Nodes = append(flat.Nodes, FlatCallGraphNode{
+			ID:           n.ID,
+			Name:         n.Name,
+			Kind:         n.Kind,
+			URI:          n.URI,
+			Line:         n.Line,
+			IsEntryPoint: n.IsEntryPoint,
+			Triggers:     n.Triggers,
+			Cluster:      callGraphCluster(&configs, n.URI, cluster),
+		})
+	}
+	addEdge := func(from, to string, cycle bool) {
+		key := [2]string{from, to}
+		if i, ok := edges[key]; ok {
+			flat.Edges[i].Cycle = flat.Edges[i].Cycle || cycle
+			return
+		}
+		edges[key] = len(flat.Edges)
+		flat.Edges = append(flat.Edges, FlatCallGraphEdge{From: from, To: to, Cycle: cycle})
+	}
+
+	addNode(result.Root)
+	flat.Nodes[0].Root = true
+
+	// Incoming trees list callers as children, outgoing trees list callees
+	var walk func(parent *CallGraphNode, children []*CallGraphNode, incoming bool)
+	walk = func(parent *CallGraphNode, children []*CallGraphNode, incoming bool) {
+		for _, child := range children {
+			addNode(child)
+			if incoming {
+				addEdge(child.ID, parent.ID, child.IsCycle)
+			} else {
+				addEdge(parent.ID, child.ID, child.IsCycle)
+			}
+			if !child.IsCycle {
+				walk(child, child.Children, incoming)
+			}
+		}
+	}
+	if result.IncomingTree != nil {
+		walk(result.Root, result.IncomingTree.Children, true)
+	}
+	if result.OutgoingTree != nil {
+		walk(result.Root, result.OutgoingTree.Children, false)
+	}
+	return flat
+}
+
+// callGraphCluster names the cluster of a node: its module or its metadata object,
+// falling back to the file name outside a configuration dump
+func callGraphCluster(configs *configLookup, uri, cluster string) string {
+	if cluster == "" || cluster == CallGraphClusterNone {
+		return ""
+	}
+	path := utils.URIToFilePath(uri)
+	if cfg, err := configs.forFile(path); err == nil {
+		if owner, ok := cfg.Owner(path); ok {
+			if cluster == CallGraphClusterObject && owner.Object != nil {
+				return owner.Object.FullName()
+			}
+			return moduleDisplayName(owner)
+		}
+	}
+	return filepath.Base(path)
+}
+
+// ExportCallGraph renders a call graph in one of the CallGraphFormat* formats
+func ExportCallGraph(result *CallGraphResult, format, cluster string) (string, error) {
+	if cluster != "" && !slices.Contains(CallGraphClusters, cluster) {
+		return "", fmt.Errorf("unknown cluster %q: expected one of %s", cluster, strings.Join(CallGraphClusters, ", "))
+	}
+	switch format {
+	case "", CallGraphFormatJSON:
+		data, err := json.MarshalIndent(result, "", "  ")
+		return string(data), err
+	case CallGraphFormatAdjacency:
+		data, err := json.MarshalIndent(FlattenCallGraph(result, cluster), "", "  ")
+		return string(data), err
+	case CallGraphFormatDOT:
+		return exportDOT(FlattenCallGraph(result, cluster)), nil
+	case CallGraphFormatMermaid:
+		return exportMermaid(FlattenCallGraph(result, cluster)), nil
+	case CallGraphFormatGraphML:
+		return exportGraphML(FlattenCallGraph(result, cluster))
+	default:
+		return "", fmt.Errorf("unknown format %q: expected one of %s", format, strings.Join(CallGraphFormats, ", "))
+	}
+}
+
+// clusterOrder groups node indexes by cluster, in order of first appearance
+func clusterOrder(flat *FlatCallGraph) ([]string, map[string][]int) {
+	var names []string
+	members := make(map[string][]int)
+	for i, n := range flat.Nodes {
+		if _, ok := members[n.Cluster]; !ok {
+			names = append(names, n.Cluster)
+		}
+		members[n.Cluster] = append(members[n.Cluster], i)
+	}
+	return names, members
+}
+
+// shortIDs maps node IDs (URIs with positions) to n0, n1... for readable output
+func shortIDs(flat *FlatCallGraph) map[string]string {
+	ids := make(map[string]string, len(flat.Nodes))
+	for i, n := range flat.Nodes {
+		ids[n.ID] = fmt.Sprintf("n%d", i)
+	}
+	return ids
+}
+
+func exportDOT(flat *FlatCallGraph) string {
+	ids := shortIDs(flat)
+	quote := func(s string) string { return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"` }
+	nodeLine := func(b *strings.Builder, indent string, n FlatCallGraphNode) {
+		attrs := []string{"label=" + quote(n.Name), "tooltip=" + quote(fmt.Sprintf("%s:%d", n.URI, n.Line+1))}
+		if n.Root {
+			attrs = append(attrs, "penwidth=2")
+		}
+		if n.IsEntryPoint {
+			attrs = append(attrs, "style=filled", `fillcolor="#fff3b0"`)
+		}
+		fmt.Fprintf(b, "%s%s [%s];\n", indent, ids[n.ID], strings.Join(attrs, ", "))
+	}
+
+	var b strings.Builder
+	b.WriteString("digraph call_graph {\n  rankdir=LR;\n  node [shape=box, fontname=\"Helvetica\"];\n")
+	names, members := clusterOrder(flat)
+	for i, name := range names {
+		indent := "  "
+		if name != "" {
+			fmt.Fprintf(&b, "  subgraph cluster_%d {\n    label=%s;\n", i, quote(name))
+			indent = "    "
+		}
+		for _, idx := range members[name] {
+			nodeLine(&b, indent, flat.Nodes[idx])
+		}
+		if name != "" {
+			b.WriteString("  }\n")
+		}
+	}
+	for _, e := range flat.Edges {
+		if e.Cycle {
+			fmt.Fprintf(&b, "  %s -> %s [style=dashed, color=red, label=\"cycle\"];\n", ids[e.From], ids[e.To])
+		} else {
+			fmt.Fprintf(&b, "  %s -> %s;\n", ids[e.From], ids[e.To])
+		}
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+func exportMermaid(flat *FlatCallGraph) string {
+	ids := shortIDs(flat)
+	label := func(s string) string { return `"` + strings.ReplaceAll(s, `"`, "#quot;") + `"` }
+
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+	names, members := clusterOrder(flat)
+	var entries []string
+	for i, name := range names {
+		indent := "  "
+		if name != "" {
+			fmt.Fprintf(&b, "  subgraph c%d[%s]\n", i, label(name))
+			indent = "    "
+		}
+		for _, idx := range members[name] {
+			n := flat.Nodes[idx]
+			fmt.Fprintf(&b, "%s%s[%s]\n", indent, ids[n.ID], label(n.Name))
+			if n.IsEntryPoint {
+				entries = append(entries, ids[n.ID])
+			}
+		}
+		if name != "" {
+			b.WriteString("  end\n")
+		}
+	}
+	for _, e := range flat.Edges {
+		if e.Cycle {
+			fmt.Fprintf(&b, "  %s -.->|cycle| %s\n", ids[e.From], ids[e.To])
+		} else {
+			fmt.Fprintf(&b, "  %s --> %s\n", ids[e.From], ids[e.To])
+		}
+	}
+	b.WriteString("  classDef root stroke-width:3px\n  classDef entry fill:#fff3b0\n")
+	if len(flat.Nodes) > 0 {
+		fmt.Fprintf(&b, "  class %s root\n", ids[flat.Nodes[0].ID])
+	}
+	if len(entries) > 0 {
+		sort.Strings(entries)
+		fmt.Fprintf(&b, "  class %s entry\n", strings.Join(entries, ","))
+	}
+	return b.String()
+}
+
+func exportGraphML(flat *FlatCallGraph) (string, error) {
+	type data struct {
+		Key   string `xml:"key,attr"`
+		Value string `xml:",chardata"`
+	}
+	type key struct {
+		ID   string `xml:"id,attr"`
+		For  string `xml:"for,attr"`
+		Name string `xml:"attr.name,attr"`
+		Type string `xml:"attr.type,attr"`
+	}
+	type node struct {
+		ID   string `xml:"id,attr"`
+		Data []data `xml:"data"`
+	}
+	type edge struct {
+		Source string `xml:"source,attr"`
+		Target string `xml:"target,attr"`
+		Data   []data `xml:"data"`
+	}
+	type graph struct {
+		ID          string `xml:"id,attr"`
+		EdgeDefault string `xml:"edgedefault,attr"`
+		Nodes       []node `xml:"node"`
+		Edges       []edge `xml:"edge"`
+	}
+	type graphML struct {
+		XMLName xml.Name `xml:"graphml"`
+		XMLNS   string   `xml:"xmlns,attr"`
+		Keys    []key    `xml:"key"`
+		Graph   graph    `xml:"graph"`
+	}
+
+	doc := graphML{
+		XMLNS: "http://graphml.graphdrawing.org/xmlns",
+		Keys: []key{
+			{ID: "name", For: "node", Name: "name", Type: "string"},
+			{ID: "kind", For: "node", Name: "kind", Type: "string"},
+			{ID: "uri", For: "node", Name: "uri", Type: "string"},
+			{ID: "line", For: "node", Name: "line", Type: "int"},
+			{ID: "root", For: "node", Name: "root", Type: "boolean"},
+			{ID: "entry", For: "node", Name: "entry_point", Type: "boolean"},
+			{ID: "cluster", For: "node", Name: "cluster", Type: "string"},
+			{ID: "cycle", For: "edge", Name: "cycle", Type: "boolean"},
+		},
+		Graph: graph{ID: "call_graph", EdgeDefault: "directed"},
+	}
+	ids := shortIDs(flat)
+	for _, n := range flat.Nodes {
+		doc.Graph.Nodes = append(doc.Graph.Nodes, node{ID: ids[n.ID], Data: []data{
+			{Key: "name", Value: n.Name},
+			{Key: "kind", Value: n.Kind},
+			{Key: "uri", Value: n.URI},
+			{Key: "line", Value: fmt.Sprint(n.Line)},
+			{Key: "root", Value: fmt.Sprint(n.Root)},
+			{Key: "entry", Value: fmt.Sprint(n.IsEntryPoint)},
+			{Key: "cluster", Value: n.Cluster},
+		}})
+	}
+	for _, e := range flat.Edges {
+		doc.Graph.Edges = append(doc.Graph.Edges, edge{Source: ids[e.From], Target: ids[e.To], Data: []data{
+			{Key: "cycle", Value: fmt.Sprint(e.Cycle)},
+		}})
+	}
+
+	out, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return xml.Header + string(out) + "\n", nil
+}
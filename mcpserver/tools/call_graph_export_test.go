@@ -0,0 +1,141 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"rockerboo/mcp-lsp-bridge/utils"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/mcptest"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sampleCallGraph is Handler -> Main -> Helper -> Main (cycle), with Handler an entry point
+func sampleCallGraph(uri string) *CallGraphResult {
+	node := func(name string, line uint32) *CallGraphNode {
+		return &CallGraphNode{ID: uri + ":" + name, Name: name, Kind: "method", URI: uri, Line: line}
+	}
+	root := node("Main", 0)
+	handler := node("Handler", 10)
+	handler.IsEntryPoint, handler.Triggers = true, []string{`form "Форма".OnOpen`}
+	helper := node("Helper", 20)
+	back := node("Main", 0)
+	back.IsCycle = true
+	helper.Children = []*CallGraphNode{back}
+
+	return &CallGraphResult{
+		Root:         root,
+		IncomingTree: &CallGraphNode{Name: "Callers of Main", Children: []*CallGraphNode{handler}},
+		OutgoingTree: &CallGraphNode{Name: "Calls from Main", Children: []*CallGraphNode{helper}},
+	}
+}
+
+func TestFlattenCallGraph(t *testing.T) {
+	flat := FlattenCallGraph(sampleCallGraph("file:///m.bsl"), CallGraphClusterNone)
+
+	var names []string
+	for _, n := range flat.Nodes {
+		names = append(names, n.Name)
+	}
+	assert.Equal(t, []string{"Main", "Handler", "Helper"}, names, "the cycle marker is the root again")
+	assert.True(t, flat.Nodes[0].Root)
+	assert.True(t, flat.Nodes[1].IsEntryPoint)
+
+	assert.Equal(t, []FlatCallGraphEdge{
+		{From: "file:///m.bsl:Handler", To: "file:///m.bsl:Main"},
+		{From: "file:///m.bsl:Main", To: "file:///m.bsl:Helper"},
+		{From: "file:///m.bsl:Helper", To: "file:///m.bsl:Main", Cycle: true},
+	}, flat.Edges)
+}
+
+func TestExportCallGraph_Formats(t *testing.T) {
+	graph := sampleCallGraph("file:///m.bsl")
+
+	dot, err := ExportCallGraph(graph, CallGraphFormatDOT, "")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(dot, "digraph call_graph {"))
+	assert.Contains(t, dot, `n0 [label="Main"`)
+	assert.Contains(t, dot, "n1 -> n0;")
+	assert.Contains(t, dot, `n2 -> n0 [style=dashed, color=red, label="cycle"];`)
+
+	mermaid, err := ExportCallGraph(graph, CallGraphFormatMermaid, "")
+	require.NoError(t, err)
+	assert.Contains(t, mermaid, "flowchart LR\n")
+	assert.Contains(t, mermaid, "  n0 --> n2\n")
+	assert.Contains(t, mermaid, "  n2 -.->|cycle| n0\n")
+	assert.Contains(t, mermaid, "  class n1 entry\n")
+
+	graphml, err := ExportCallGraph(graph, CallGraphFormatGraphML, "")
+	require.NoError(t, err)
+	var doc struct {
+		Nodes []struct {
+			ID string `xml:"id,attr"`
+		} `xml:"graph>node"`
+		Edges []struct {
+			Source string `xml:"source,attr"`
+		} `xml:"graph>edge"`
+	}
+	require.NoError(t, xml.Unmarshal([]byte(graphml), &doc))
+	assert.Len(t, doc.Nodes, 3)
+	assert.Len(t, doc.Edges, 3)
+
+	adjacency, err := ExportCallGraph(graph, CallGraphFormatAdjacency, "")
+	require.NoError(t, err)
+	var flat FlatCallGraph
+	require.NoError(t, json.Unmarshal([]byte(adjacency), &flat))
+	assert.Len(t, flat.Edges, 3)
+
+	_, err = ExportCallGraph(graph, "svg", "")
+	assert.Error(t, err)
+	_, err = ExportCallGraph(graph, CallGraphFormatDOT, "package")
+	assert.Error(t, err)
+}
+
+func TestExportCallGraph_ClustersByMetadataObject(t *testing.T) {
+	root := writeDesignerDump(t)
+	uri := utils.FilePathToURI(filepath.Join(root, "Catalogs/Товары/Forms/ФормаЭлемента/Ext/Form/Module.bsl"))
+
+	flat := FlattenCallGraph(sampleCallGraph(uri), CallGraphClusterObject)
+	assert.Equal(t, "Catalog.Товары", flat.Nodes[0].Cluster)
+
+	flat = FlattenCallGraph(sampleCallGraph(uri), CallGraphClusterModule)
+	assert.Equal(t, "Catalog.Товары.Form.ФормаЭлемента", flat.Nodes[0].Cluster)
+
+	dot, err := ExportCallGraph(sampleCallGraph(uri), CallGraphFormatDOT, CallGraphClusterObject)
+	require.NoError(t, err)
+	assert.Contains(t, dot, "subgraph cluster_0 {\n    label=\"Catalog.Товары\";\n    n0 [")
+
+	mermaid, err := ExportCallGraph(sampleCallGraph("file:///elsewhere/Module.bsl"), CallGraphFormatMermaid, CallGraphClusterModule)
+	require.NoError(t, err)
+	assert.Contains(t, mermaid, `subgraph c0["Module.bsl"]`, "outside a dump the file name is the cluster")
+}
+
+func TestCallGraphTool_Format(t *testing.T) {
+	bridge := newIndexedCallChain()
+
+	tool, handler := CallGraphTool(bridge)
+	mcpServer, err := mcptest.NewServer(t, server.ServerTool{Tool: tool, Handler: handler})
+	require.NoError(t, err)
+
+	result, err := mcpServer.Client().CallTool(context.Background(), mcp.CallToolRequest{
+		Request: mcp.Request{Method: "tools/call"},
+		Params: mcp.CallToolParams{Name: "call_graph", Arguments: map[string]any{
+			"uri": "file:///projects/CommonModules/Common/Ext/Module.bsl", "line": 0, "character": 10,
+			"depth_down": 2, "depth_up": 1, "format": "mermaid",
+		}},
+	})
+	require.NoError(t, err)
+	require.False(t, result.IsError, "%#v", result.Content)
+
+	text := result.Content[0].(mcp.TextContent).Text
+	assert.Contains(t, text, `n0["Main"]`)
+	assert.Contains(t, text, "n0 --> ")
+	assert.Contains(t, text, " --> n0", "the caller points at the root")
+}
@@ -0,0 +1,189 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+
+	"rockerboo/mcp-lsp-bridge/interfaces"
+	"rockerboo/mcp-lsp-bridge/mocks"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/mcptest"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/myleshyson/lsprotocol-go/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// indexedMockBridge answers the call graph index methods of MockBridge from maps.
+// Methods listed in dirty are reported as not indexed, liveIncoming disables the
+// index for incoming calls. lookups counts the index queries per method and direction.
+type indexedMockBridge struct {
+	*mocks.MockBridge
+	outgoing     map[string][]protocol.CallHierarchyOutgoingCall
+	incoming     map[string][]protocol.CallHierarchyIncomingCall
+	dirty        map[string]bool
+	liveIncoming bool
+	lookupsMu    sync.Mutex
+	lookups      map[string]int
+}
+
+func (b *indexedMockBridge) lookup(key string) {
+	b.lookupsMu.Lock()
+	defer b.lookupsMu.Unlock()
+	if b.lookups == nil {
+		b.lookups = make(map[string]int)
+	}
+	b.lookups[key]++
+}
+
+func (b *indexedMockBridge) WithContext(ctx context.Context) interfaces.BridgeInterface {
+	return b
+}
+
+func (b *indexedMockBridge) IndexedIncomingCalls(ctx context.Context, item protocol.CallHierarchyItem) ([]protocol.CallHierarchyIncomingCall, bool, error) {
+	b.lookup("incoming " + item.Name)
+	if b.dirty[item.Name] || b.liveIncoming {
+		return nil, false, nil
+	}
+	return b.incoming[item.Name], true, nil
+}
+
+func (b *indexedMockBridge) IndexedOutgoingCalls(ctx context.Context, item protocol.CallHierarchyItem) ([]protocol.CallHierarchyOutgoingCall, bool, error) {
+	b.lookup("outgoing " + item.Name)
+	if b.dirty[item.Name] {
+		return nil, false, nil
+	}
+	return b.outgoing[item.Name], true, nil
+}
+
+func bslMethod(name string, line uint32) protocol.CallHierarchyItem {
+	return protocol.CallHierarchyItem{
+		Name:  name,
+		Kind:  protocol.SymbolKindMethod,
+		Uri:   "file:///projects/CommonModules/Common/Ext/Module.bsl",
+		Range: protocol.Range{Start: protocol.Position{Line: line}, End: protocol.Position{Line: line + 3}},
+	}
+}
+
+// newIndexedCallChain builds a linear chain Main -> StepA -> ... -> StepK, deeper than the default depth
+func newIndexedCallChain() *indexedMockBridge {
+	b := &indexedMockBridge{
+		MockBridge: &mocks.MockBridge{},
+		outgoing:   map[string][]protocol.CallHierarchyOutgoingCall{},
+		incoming:   map[string][]protocol.CallHierarchyIncomingCall{},
+		dirty:      map[string]bool{},
+	}
+	names := []string{"Main"}
+	for i := 1; i <= 11; i++ {
+		names = append(names, "Step"+string(rune('A'+i-1)))
+	}
+	for i := 0; i+1 < len(names); i++ {
+		b.outgoing[names[i]] = []protocol.CallHierarchyOutgoingCall{{To: bslMethod(names[i+1], uint32(10*(i+1)))}}
+	}
+	b.incoming["Main"] = []protocol.CallHierarchyIncomingCall{{From: bslMethod("ПриСозданииНаСервере", 200)}}
+
+	b.On("PrepareCallHierarchy", "file:///projects/CommonModules/Common/Ext/Module.bsl", uint32(0), uint32(10)).
+		Return([]protocol.CallHierarchyItem{bslMethod("Main", 0)}, nil)
+	return b
+}
+
+func callGraphFromTool(t *testing.T, bridge *indexedMockBridge, arguments map[string]any) CallGraphResult {
+	t.Helper()
+
+	tool, handler := CallGraphTool(bridge)
+	mcpServer, err := mcptest.NewServer(t, server.ServerTool{Tool: tool, Handler: handler})
+	require.NoError(t, err)
+
+	arguments["uri"] = "file:///projects/CommonModules/Common/Ext/Module.bsl"
+	arguments["line"] = 0
+	arguments["character"] = 10
+	result, err := mcpServer.Client().CallTool(context.Background(), mcp.CallToolRequest{
+		Request: mcp.Request{Method: "tools/call"},
+		Params:  mcp.CallToolParams{Name: "call_graph", Arguments: arguments},
+	})
+	require.NoError(t, err)
+	require.False(t, result.IsError, "%#v", result.Content)
+
+	var graph CallGraphResult
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].(mcp.TextContent).Text), &graph))
+	return graph
+}
+
+func outgoingDepth(node *CallGraphNode) int {
+	depth := 0
+	for node != nil && len(node.Children) > 0 {
+		depth++
+		node = node.Children[0]
+	}
+	return depth
+}
+
+func TestCallGraphTool_AnswersFromIndexWithUnlimitedDepth(t *testing.T) {
+	bridge := newIndexedCallChain()
+
+	graph := callGraphFromTool(t, bridge, map[string]any{"depth_down": 0, "depth_up": 0, "max_nodes": 0})
+
+	assert.Equal(t, 11, outgoingDepth(graph.OutgoingTree), "the whole chain is followed past the default depth")
+	assert.Equal(t, 0, graph.LiveQueries)
+	assert.Positive(t, graph.IndexedQueries)
+	assert.Contains(t, graph.EntryPoints, "ПриСозданииНаСервере")
+	assert.False(t, graph.Truncated)
+
+	// No live call hierarchy requests beyond preparing the root
+	bridge.AssertNotCalled(t, "OutgoingCalls", mock.Anything)
+	bridge.AssertNotCalled(t, "IncomingCalls", mock.Anything)
+
+	// The root answers that chose the limits are reused by the walk
+	assert.Equal(t, 1, bridge.lookups["incoming Main"])
+	assert.Equal(t, 1, bridge.lookups["outgoing Main"])
+}
+
+func TestCallGraphTool_QueriesDirtyFilesLive(t *testing.T) {
+	bridge := newIndexedCallChain()
+	bridge.dirty["StepC"] = true
+
+	// StepC was edited after indexing: the server now reports a different callee
+	bridge.On("OutgoingCalls", mock.MatchedBy(func(item protocol.CallHierarchyItem) bool { return item.Name == "StepC" })).
+		Return([]protocol.CallHierarchyOutgoingCall{{To: bslMethod("NewHelper", 500)}}, nil)
+
+	graph := callGraphFromTool(t, bridge, map[string]any{"depth_down": 0, "depth_up": 1})
+
+	assert.Equal(t, 1, graph.LiveQueries)
+	assert.Positive(t, graph.IndexedQueries)
+
+	// Main -> StepA -> StepB -> StepC -> NewHelper (live), then the index again
+	node := graph.OutgoingTree
+	var names []string
+	for node != nil && len(node.Children) > 0 {
+		node = node.Children[0]
+		names = append(names, node.Name)
+	}
+	assert.Equal(t, []string{"StepA", "StepB", "StepC", "NewHelper"}, names)
+	bridge.AssertExpectations(t)
+}
+
+func TestCallGraphTool_LimitsLiveDirectionSeparately(t *testing.T) {
+	bridge := newIndexedCallChain()
+	bridge.liveIncoming = true
+
+	// More callers than a live traversal may collect
+	callers := make([]protocol.CallHierarchyIncomingCall, HardLimitNodes+100)
+	for i := range callers {
+		callers[i] = protocol.CallHierarchyIncomingCall{From: bslMethod(fmt.Sprintf("Caller%d", i), uint32(1000+10*i))}
+	}
+	bridge.On("IncomingCalls", mock.MatchedBy(func(item protocol.CallHierarchyItem) bool { return item.Name == "Main" })).
+		Return(callers, nil)
+
+	graph := callGraphFromTool(t, bridge, map[string]any{"depth_down": 0, "depth_up": 1, "max_nodes": 0})
+
+	assert.Equal(t, 11, outgoingDepth(graph.OutgoingTree), "the indexed direction keeps the indexed limit")
+	require.NotNil(t, graph.IncomingTree)
+	assert.Less(t, len(graph.IncomingTree.Children), len(callers))
+	assert.True(t, graph.Truncated)
+	assert.Equal(t, fmt.Sprintf("hard limit reached for incoming calls (%d)", HardLimitNodes), graph.TruncateReason)
+}
@@ -27,6 +27,8 @@ func CallHierarchyTool(bridge interfaces.BridgeInterface) (mcp.Tool, server.Tool
 			mcp.WithNumber("character", mcp.Description("Character position (0-based)")),
 			mcp.WithString("direction", mcp.Description("Direction: 'incoming', 'outgoing', or 'both' (default: 'both')")),
 		), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			bridge := bridge.WithContext(ctx)
+
 			// Parse and validate parameters
 			uri, err := request.RequireString("uri")
 			if err != nil {
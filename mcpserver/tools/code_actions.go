@@ -26,6 +26,8 @@ func CodeActionTool(bridge interfaces.BridgeInterface) (mcp.Tool, server.ToolHan
 			mcp.WithNumber("end_line", mcp.Description("End line number (0-based, optional) - for range-based actions, defaults to start line")),
 			mcp.WithNumber("end_character", mcp.Description("End character position (0-based, optional) - for range-based actions, defaults to start character")),
 		), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			bridge := bridge.WithContext(ctx)
+
 			// Parse and validate parameters
 			uri, err := request.RequireString("uri")
 			if err != nil {
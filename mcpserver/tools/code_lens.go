@@ -0,0 +1,305 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"rockerboo/mcp-lsp-bridge/analysis"
+	"rockerboo/mcp-lsp-bridge/async"
+	"rockerboo/mcp-lsp-bridge/interfaces"
+	"rockerboo/mcp-lsp-bridge/logger"
+	"rockerboo/mcp-lsp-bridge/utils"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/myleshyson/lsprotocol-go/protocol"
+)
+
+const (
+	// CodeLensTimeout bounds a code lens scan; a directory holds many modules
+	CodeLensTimeout = 5 * time.Minute
+	// maxCodeLensFiles caps the modules scanned for one call
+	maxCodeLensFiles   = 2000
+	defaultCodeLensTop = 50
+	maxCodeLensTop     = 500
+)
+
+// fileCodeLenses are the resolved lenses of one module
+type fileCodeLenses struct {
+	uri    string
+	lenses []protocol.CodeLens
+}
+
+// RegisterCodeLensTool registers the code_lens tool
+func RegisterCodeLensTool(mcpServer ToolServer, bridge interfaces.BridgeInterface) {
+	mcpServer.AddTool(CodeLensTool(bridge))
+}
+
+func CodeLensTool(bridge interfaces.BridgeInterface) (mcp.Tool, server.ToolHandlerFunc) {
+	return mcp.NewTool("code_lens",
+			mcp.WithDescription(`Resolved code lenses of a module or of every module in a directory. BSL LS shows the cognitive and cyclomatic complexity of each method and run/debug lenses on test methods.
+
+USAGE:
+- Most complex methods of a subsystem: uri="file://path/CommonModules", kind="complexity"
+- Tests of a module: uri="file://path/Tests/Module.bsl", kind="tests"
+
+PARAMETERS: uri (required, a .bsl file or a directory), kind (all | complexity | tests, default: all), limit (entries per section, default: 50, max: 500)
+OUTPUT: Methods ranked by cognitive complexity (cyclomatic breaks ties), test lenses with their commands, other lenses`),
+			mcp.WithDestructiveHintAnnotation(false),
+			mcp.WithString("uri", mcp.Description("Module file or directory URI"), mcp.Required()),
+			mcp.WithString("kind", mcp.Description("Which lenses to return: all, complexity or tests (default: all)"), mcp.Enum("all", "complexity", "tests")),
+			mcp.WithNumber("limit", mcp.Description("Maximum entries per section (default: 50, max: 500)"), mcp.Min(1), mcp.Max(maxCodeLensTop)),
+		), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			bridge := bridge.WithContext(ctx)
+
+			uri, err := request.RequireString("uri")
+			if err != nil {
+				logger.Error("code_lens: URI parsing failed", err)
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			kind := request.GetString("kind", "all")
+			if kind != "all" && kind != "complexity" && kind != "tests" {
+				return mcp.NewToolResultError("kind must be all, complexity or tests"), nil
+			}
+			limit := request.GetInt("limit", defaultCodeLensTop)
+			if limit < 1 || limit > maxCodeLensTop {
+				return mcp.NewToolResultError(fmt.Sprintf("limit must be between 1 and %d", maxCodeLensTop)), nil
+			}
+
+			if result, ok := CheckReadyOrReturn(bridge); !ok {
+				return result, nil
+			}
+
+			normalizedURI := bridge.NormalizeURIForLSP(uri)
+			files, err := bslFiles(utils.URIToFilePath(normalizedURI))
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Cannot list modules: %v", err)), nil
+			}
+			if len(files) == 0 {
+				return mcp.NewToolResultError(fmt.Sprintf("no .bsl modules in %s", normalizedURI)), nil
+			}
+			truncatedFiles := len(files) > maxCodeLensFiles
+			if truncatedFiles {
+				files = files[:maxCodeLensFiles]
+			}
+
+			modules, errs := collectCodeLenses(ctx, bridge, files, kind)
+			if truncatedFiles {
+				errs = append(errs, fmt.Sprintf("only the first %d modules were scanned", maxCodeLensFiles))
+			}
+			return mcp.NewToolResultText(formatCodeLenses(bridge, normalizedURI, modules, kind, limit, errs)), nil
+		}
+}
+
+// collectCodeLenses fetches and resolves the lenses of the files concurrently. Lenses
+// the kind does not ask for are dropped before they are resolved.
+func collectCodeLenses(ctx context.Context, bridge interfaces.BridgeInterface, files []string, kind string) ([]fileCodeLenses, []string) {
+	ctx, cancel := context.WithTimeout(ctx, CodeLensTimeout)
+	defer cancel()
+
+	// The semaphore keeps the language server responsive
+	semaphore := make(chan struct{}, 5)
+	ops := make(map[string]func() ([]protocol.CodeLens, error), len(files))
+	for _, file := range files {
+		ops[file] = func() ([]protocol.CodeLens, error) {
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+			return fileCodeLens(ctx, bridge, utils.FilePathToURI(file), kind)
+		}
+	}
+
+	var errs []string
+	results, err := async.MapWithKeys(ctx, ops)
+	if err != nil {
+		errs = append(errs, fmt.Sprintf("stopped early: %v", err))
+	}
+
+	modules := make([]fileCodeLenses, 0, len(results))
+	for _, r := range results {
+		uri := utils.FilePathToURI(r.Key)
+		if r.Error != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", uri, r.Error))
+			continue
+		}
+		modules = append(modules, fileCodeLenses{uri: uri, lenses: r.Value})
+	}
+	sort.Slice(modules, func(i, j int) bool { return modules[i].uri < modules[j].uri })
+	sort.Strings(errs)
+	return modules, errs
+}
+
+// fileCodeLens returns the resolved lenses of one module matching kind
+func fileCodeLens(ctx context.Context, bridge interfaces.BridgeInterface, uri, kind string) ([]protocol.CodeLens, error) {
+	lenses, err := bridge.CodeLens(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	wanted := make([]protocol.CodeLens, 0, len(lenses))
+	for _, lens := range lenses {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if !codeLensKindWanted(analysis.ClassifyCodeLens(lens), kind, lens.Command != nil) {
+			continue
+		}
+		if lens.Command == nil {
+			resolved, err := bridge.ResolveCodeLens(uri, lens)
+			if err != nil {
+				// One failed lens should not cost the whole module
+				logger.Warn(fmt.Sprintf("code_lens: resolve at %s:%d failed: %v", uri, lens.Range.Start.Line, err))
+				continue
+			}
+			lens = *resolved
+			// Lenses without a data id are only known once resolved
+			if !codeLensKindWanted(analysis.ClassifyCodeLens(lens), kind, true) {
+				continue
+			}
+		}
+		wanted = append(wanted, lens)
+	}
+	return wanted, nil
+}
+
+// codeLensKindWanted tells whether a lens of k belongs to the requested kind; an
+// unresolved lens of unknown kind is kept until its command tells
+func codeLensKindWanted(k analysis.CodeLensKind, kind string, resolved bool) bool {
+	switch kind {
+	case "complexity":
+		return analysis.IsComplexityLens(k) || (k == analysis.OtherLens && !resolved)
+	case "tests":
+		return isTestLens(k) || (k == analysis.OtherLens && !resolved)
+	}
+	return true
+}
+
+func isTestLens(k analysis.CodeLensKind) bool {
+	return k == analysis.RunTestLens || k == analysis.RunAllTestsLens || k == analysis.DebugTestLens
+}
+
+// formatMethodComplexity shows a method's complexity, with its location when the
+// ranking spans several files
+func formatMethodComplexity(method analysis.MethodComplexity, withURI bool) string {
+	var b strings.Builder
+	if method.Name != "" {
+		b.WriteString(method.Name)
+	} else {
+		fmt.Fprintf(&b, "<line %d>", method.Line)
+	}
+	var values []string
+	if method.Cognitive != nil {
+		values = append(values, fmt.Sprintf("cognitive %d", *method.Cognitive))
+	}
+	if method.Cyclomatic != nil {
+		values = append(values, fmt.Sprintf("cyclomatic %d", *method.Cyclomatic))
+	}
+	fmt.Fprintf(&b, ": %s", strings.Join(values, ", "))
+	if withURI {
+		fmt.Fprintf(&b, " — %s line=%d", method.Uri, method.Line)
+	} else {
+		fmt.Fprintf(&b, " (line=%d)", method.Line)
+	}
+	return b.String()
+}
+
+// formatCodeLens shows one lens that is not a complexity value
+func formatCodeLens(uri string, lens protocol.CodeLens) string {
+	title, command := "<unresolved>", ""
+	if lens.Command != nil {
+		title, command = strings.TrimSpace(lens.Command.Title), lens.Command.Command
+	}
+	line := fmt.Sprintf("%s line=%d: %s", uri, lens.Range.Start.Line, title)
+	if name := analysis.CodeLensMethodName(lens); name != "" {
+		line += " — " + name
+	}
+	if command != "" {
+		line += fmt.Sprintf(" (command: %s)", command)
+	}
+	return line
+}
+
+func formatCodeLenses(bridge interfaces.BridgeInterface, uri string, modules []fileCodeLenses, kind string, limit int, errs []string) string {
+	var (
+		methods      []analysis.MethodComplexity
+		tests, other []string
+		total        int
+	)
+	for _, module := range modules {
+		total += len(module.lenses)
+		fileMethods := analysis.MethodComplexities(module.uri, module.lenses)
+		if unnamedMethods(fileMethods) {
+			// Lenses that do not name their method are matched with its symbol
+			if symbols, err := bridge.GetDocumentSymbols(module.uri); err == nil {
+				analysis.NameMethodsFromSymbols(fileMethods, symbols)
+			}
+		}
+		methods = append(methods, fileMethods...)
+
+		for _, lens := range module.lenses {
+			switch k := analysis.ClassifyCodeLens(lens); {
+			case analysis.IsComplexityLens(k):
+			case isTestLens(k):
+				tests = append(tests, formatCodeLens(module.uri, lens))
+			default:
+				other = append(other, formatCodeLens(module.uri, lens))
+			}
+		}
+	}
+	analysis.RankMethodComplexity(methods)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Code lenses in %s: %d modules, %d lenses", uri, len(modules), total)
+	if kind != "all" {
+		fmt.Fprintf(&b, " (%s)", kind)
+	}
+	b.WriteString("\n")
+
+	writeSection := func(title string, entries []string) {
+		if len(entries) == 0 {
+			return
+		}
+		fmt.Fprintf(&b, "\n%s (%d", title, len(entries))
+		if len(entries) > limit {
+			fmt.Fprintf(&b, ", showing the first %d", limit)
+			entries = entries[:limit]
+		}
+		b.WriteString("):\n")
+		for i, entry := range entries {
+			fmt.Fprintf(&b, "%d. %s\n", i+1, entry)
+		}
+	}
+
+	if len(methods) > 0 {
+		ranking := make([]string, len(methods))
+		for i, method := range methods {
+			ranking[i] = formatMethodComplexity(method, len(modules) > 1)
+		}
+		fmt.Fprintf(&b, "Average method complexity: %.2f\n", analysis.AverageMethodComplexity(methods))
+		writeSection("COMPLEXITY RANKING", ranking)
+	}
+	writeSection("TESTS", tests)
+	writeSection("OTHER LENSES", other)
+
+	if total == 0 {
+		b.WriteString("\nNo code lenses.\n")
+	}
+	if len(errs) > 0 {
+		b.WriteString("\nERRORS:\n")
+		for _, e := range errs {
+			fmt.Fprintf(&b, "- %s\n", e)
+		}
+	}
+	return b.String()
+}
+
+func unnamedMethods(methods []analysis.MethodComplexity) bool {
+	for _, m := range methods {
+		if m.Name == "" {
+			return true
+		}
+	}
+	return false
+}
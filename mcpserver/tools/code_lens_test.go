@@ -0,0 +1,120 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"rockerboo/mcp-lsp-bridge/mocks"
+	"rockerboo/mcp-lsp-bridge/utils"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/mcptest"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/myleshyson/lsprotocol-go/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func callCodeLens(t *testing.T, bridge *mocks.MockBridge, arguments map[string]any) string {
+	t.Helper()
+
+	tool, handler := CodeLensTool(bridge)
+	mcpServer, err := mcptest.NewServer(t, server.ServerTool{Tool: tool, Handler: handler})
+	require.NoError(t, err)
+
+	result, err := mcpServer.Client().CallTool(context.Background(), mcp.CallToolRequest{
+		Request: mcp.Request{Method: "tools/call"},
+		Params:  mcp.CallToolParams{Name: "code_lens", Arguments: arguments},
+	})
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content)
+	return result.Content[0].(mcp.TextContent).Text
+}
+
+func codeLensAt(line uint32, title, command string, data map[string]any) protocol.CodeLens {
+	lens := protocol.CodeLens{Range: protocol.Range{Start: protocol.Position{Line: line, Character: 10}, End: protocol.Position{Line: line, Character: 20}}}
+	if data != nil {
+		lens.Data = data
+	}
+	if title != "" {
+		lens.Command = &protocol.Command{Title: title, Command: command}
+	}
+	return lens
+}
+
+func TestCodeLensTool(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"Documents/Sale/ObjectModule.bsl", "Tests/Module.bsl", "Tests/readme.txt"} {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o750))
+		require.NoError(t, os.WriteFile(path, []byte("\n"), 0o600))
+	}
+	saleURI := utils.FilePathToURI(filepath.Join(dir, "Documents/Sale/ObjectModule.bsl"))
+	testsURI := utils.FilePathToURI(filepath.Join(dir, "Tests/Module.bsl"))
+
+	unresolved := codeLensAt(3, "", "", map[string]any{"id": "cognitiveComplexity", "methodName": "ОбработкаПроведения"})
+	runTest := codeLensAt(8, "", "", map[string]any{"id": "runTest", "testName": "ТестПроведения"})
+	newBridge := func() *mocks.MockBridge {
+		bridge := &mocks.MockBridge{}
+		bridge.On("CodeLens", saleURI).Return([]protocol.CodeLens{
+			unresolved,
+			codeLensAt(3, "Cyclomatic complexity is 7", "", nil),
+			codeLensAt(30, "Когнитивная сложность 4", "", nil),
+			codeLensAt(30, "Цикломатическая сложность 3", "", nil),
+		}, nil)
+		bridge.On("ResolveCodeLens", saleURI, unresolved).Return(&protocol.CodeLens{
+			Range: unresolved.Range, Data: unresolved.Data, Command: &protocol.Command{Title: "Cognitive complexity is 19"},
+		}, nil)
+		bridge.On("GetDocumentSymbols", saleURI).Return([]protocol.DocumentSymbol{
+			{Name: "Заполнить", Kind: protocol.SymbolKindMethod, SelectionRange: protocol.Range{Start: protocol.Position{Line: 30}}},
+		}, nil)
+		bridge.On("CodeLens", testsURI).Return([]protocol.CodeLens{
+			codeLensAt(0, "⚙ Run all tests", "language-1c-bsl.languageServer.runAllTests", nil),
+			runTest,
+			codeLensAt(12, "Cognitive complexity is 1", "", nil),
+		}, nil)
+		bridge.On("ResolveCodeLens", testsURI, runTest).Return(&protocol.CodeLens{
+			Range: runTest.Range, Data: runTest.Data, Command: &protocol.Command{Title: "⚙ Run test", Command: "language-1c-bsl.languageServer.runTest"},
+		}, nil)
+		bridge.On("GetDocumentSymbols", testsURI).Return([]protocol.DocumentSymbol{}, nil)
+		return bridge
+	}
+
+	t.Run("directory", func(t *testing.T) {
+		bridge := newBridge()
+		text := callCodeLens(t, bridge, map[string]any{"uri": utils.FilePathToURI(dir)})
+
+		assert.Contains(t, text, "2 modules, 7 lenses")
+		assert.Contains(t, text, "Average method complexity: 8.00")
+		assert.Contains(t, text, "1. ОбработкаПроведения: cognitive 19, cyclomatic 7 — "+saleURI+" line=3")
+		assert.Contains(t, text, "2. Заполнить: cognitive 4, cyclomatic 3 — "+saleURI+" line=30")
+		assert.Contains(t, text, "3. <line 12>: cognitive 1 — "+testsURI+" line=12")
+		assert.Contains(t, text, "TESTS (2):")
+		assert.Contains(t, text, testsURI+" line=8: ⚙ Run test — ТестПроведения (command: language-1c-bsl.languageServer.runTest)")
+		assert.NotContains(t, text, "OTHER LENSES")
+	})
+
+	t.Run("complexity of one file", func(t *testing.T) {
+		bridge := newBridge()
+		text := callCodeLens(t, bridge, map[string]any{"uri": saleURI, "kind": "complexity", "limit": 1})
+
+		assert.Contains(t, text, "1 modules, 4 lenses (complexity)")
+		assert.Contains(t, text, "COMPLEXITY RANKING (2, showing the first 1)")
+		assert.Contains(t, text, "1. ОбработкаПроведения: cognitive 19, cyclomatic 7 (line=3)")
+		assert.NotContains(t, text, "Заполнить")
+		bridge.AssertNotCalled(t, "CodeLens", testsURI)
+	})
+
+	t.Run("tests only", func(t *testing.T) {
+		bridge := newBridge()
+		text := callCodeLens(t, bridge, map[string]any{"uri": testsURI, "kind": "tests"})
+
+		assert.Contains(t, text, "1 modules, 2 lenses (tests)")
+		assert.Contains(t, text, "1. "+testsURI+" line=0: ⚙ Run all tests")
+		assert.NotContains(t, text, "COMPLEXITY")
+		bridge.AssertNotCalled(t, "ResolveCodeLens", saleURI, mock.Anything)
+	})
+}
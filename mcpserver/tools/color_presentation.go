@@ -27,6 +27,8 @@ func ColorPresentationTool(bridge interfaces.BridgeInterface) (mcp.Tool, server.
 			mcp.WithNumber("blue", mcp.Description("Blue component (0..1)"), mcp.Required()),
 			mcp.WithNumber("alpha", mcp.Description("Alpha component (0..1)"), mcp.Required()),
 		), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			bridge := bridge.WithContext(ctx)
+
 			uri, err := request.RequireString("uri")
 			if err != nil {
 				logger.Error("color_presentation: URI parsing failed", err)
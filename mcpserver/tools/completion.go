@@ -0,0 +1,317 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"rockerboo/mcp-lsp-bridge/interfaces"
+	"rockerboo/mcp-lsp-bridge/logger"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/myleshyson/lsprotocol-go/protocol"
+)
+
+const (
+	defaultCompletionLimit = 30
+	maxCompletionLimit     = 200
+	// completionDocLimit caps the documentation shown per item, in runes
+	completionDocLimit = 400
+)
+
+// completionKindNames are indexed by protocol.CompletionItemKind
+var completionKindNames = [...]string{
+	1: "text", 2: "method", 3: "function", 4: "constructor", 5: "field",
+	6: "variable", 7: "class", 8: "interface", 9: "module", 10: "property",
+	11: "unit", 12: "value", 13: "enum", 14: "keyword", 15: "snippet",
+	16: "color", 17: "file", 18: "reference", 19: "folder", 20: "enum member",
+	21: "constant", 22: "struct", 23: "event", 24: "operator", 25: "type parameter",
+}
+
+func completionKindToString(kind *protocol.CompletionItemKind) string {
+	if kind == nil || int(*kind) >= len(completionKindNames) || completionKindNames[*kind] == "" {
+		return ""
+	}
+	return completionKindNames[*kind]
+}
+
+func CompletionTool(bridge interfaces.BridgeInterface) (mcp.Tool, server.ToolHandlerFunc) {
+	return mcp.NewTool("completion",
+			mcp.WithDescription(`List what the language server would complete at a position: methods and properties of global context objects, metadata managers (Справочники., Документы. ...), module methods, local variables and keywords. Use it BEFORE writing a call you are not sure exists instead of guessing method names.
+
+USAGE:
+- Members after a dot: uri="file://path/Module.bsl", line=10, character=15 (cursor right after "Справочники.")
+- Narrow down: uri="file://path", line=10, character=18, prefix="Номен"
+- Complete unsaved code: uri="file://path", line=10, character=18, text="<full module text with the line being typed>"
+
+PARAMETERS: uri (required), line/character (required, 0-based cursor position), text (optional full document content, used for this request only), keep_buffer (default: false, keep text as the in-memory buffer like update_buffer), prefix (optional, case-insensitive), limit (default: 30, max: 200), resolve (default: true)
+OUTPUT: Ranked items with kind, detail and documentation`),
+			mcp.WithDestructiveHintAnnotation(false),
+			mcp.WithString("uri", mcp.Description("URI to the file"), mcp.Required()),
+			mcp.WithNumber("line", mcp.Description("Line number (0-based)"), mcp.Required(), mcp.Min(0)),
+			mcp.WithNumber("character", mcp.Description("Character position (0-based) of the cursor"), mcp.Required(), mcp.Min(0)),
+			mcp.WithString("text", mcp.Description("Full content of the document to complete against instead of the file on disk; the previous buffer is restored afterwards unless keep_buffer=true")),
+			mcp.WithBoolean("keep_buffer", mcp.Description("Keep text as the in-memory buffer until update_buffer revert=true or the file is written (default: false)"), mcp.DefaultBool(false)),
+			mcp.WithString("prefix", mcp.Description("Only items whose label (or filter text) starts with this, case-insensitive")),
+			mcp.WithNumber("limit", mcp.Description("Maximum number of items to return (default: 30, max: 200)"), mcp.Min(1), mcp.Max(maxCompletionLimit)),
+			mcp.WithBoolean("resolve", mcp.Description("Fetch detail and documentation of the returned items via completionItem/resolve (default: true)"), mcp.DefaultBool(true)),
+		), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			bridge := bridge.WithContext(ctx)
+
+			uri, err := request.RequireString("uri")
+			if err != nil {
+				logger.Error("completion: URI parsing failed", err)
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			line, err := request.RequireInt("line")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			character, err := request.RequireInt("character")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			lineUint32, err := safeUint32(line)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Invalid line number: %v", err)), nil
+			}
+			characterUint32, err := safeUint32(character)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Invalid character position: %v", err)), nil
+			}
+
+			prefix := request.GetString("prefix", "")
+			limit := request.GetInt("limit", defaultCompletionLimit)
+			if limit < 1 || limit > maxCompletionLimit {
+				return mcp.NewToolResultError(fmt.Sprintf("limit must be between 1 and %d", maxCompletionLimit)), nil
+			}
+			resolve := request.GetBool("resolve", true)
+
+			text, hasText := request.GetArguments()["text"].(string)
+			keepBuffer := request.GetBool("keep_buffer", false)
+			var editor bufferEditor
+			if hasText {
+				var ok bool
+				if editor, ok = bridge.(bufferEditor); !ok {
+					return mcp.NewToolResultError("text requires in-memory buffers, which this bridge implementation does not support"), nil
+				}
+			}
+
+			if result, ok := CheckReadyOrReturn(bridge); !ok {
+				return result, nil
+			}
+
+			// restore puts back what the server saw before text replaced it
+			restore := func() error { return nil }
+			if hasText {
+				previous, hadOverlay := editor.BufferOverlay(uri)
+				if _, err := editor.UpdateBuffer(uri, text); err != nil {
+					logger.Error("completion: buffer update failed", fmt.Sprintf("URI: %s, Error: %v", uri, err))
+					return mcp.NewToolResultError(fmt.Sprintf("Failed to update buffer: %v", err)), nil
+				}
+				if !keepBuffer {
+					restore = func() error {
+						var err error
+						if hadOverlay {
+							_, err = editor.UpdateBuffer(uri, previous)
+						} else {
+							_, err = editor.RevertBuffer(uri)
+						}
+						if err != nil {
+							logger.Warn(fmt.Sprintf("completion: failed to restore the buffer of %s: %v", uri, err))
+						}
+						return err
+					}
+				}
+			}
+
+			list, err := bridge.Completion(uri, lineUint32, characterUint32)
+			if err != nil {
+				logger.Error("completion: Request failed", fmt.Sprintf("URI: %s, Line: %d, Character: %d, Error: %v", uri, line, character, err))
+				_ = restore()
+				return mcp.NewToolResultError(fmt.Sprintf("Completion failed: %v", err)), nil
+			}
+
+			total := len(list.Items)
+			items := rankCompletionItems(list.Items, prefix)
+			matched := len(items)
+			if len(items) > limit {
+				items = items[:limit]
+			}
+
+			if resolve {
+				for i, item := range items {
+					if item.Documentation != nil && item.Detail != "" {
+						continue
+					}
+					resolved, err := bridge.ResolveCompletionItem(uri, item)
+					if err != nil {
+						// One failed item should not cost the whole list
+						logger.Warn(fmt.Sprintf("completion: resolve of %q failed: %v", item.Label, err))
+						continue
+					}
+					items[i] = *resolved
+				}
+			}
+
+			// Restore only now: items are resolved against the text they were completed on
+			var bufferNote string
+			switch {
+			case !hasText:
+			case keepBuffer:
+				bufferNote = "Completed against the given text, which is now the in-memory buffer; call update_buffer with revert=true to drop it."
+			default:
+				if err := restore(); err != nil {
+					bufferNote = fmt.Sprintf("Completed against the given text, but restoring the previous buffer failed: %v. Call update_buffer with revert=true.", err)
+				} else {
+					bufferNote = "Completed against the given text; the previous buffer was restored."
+				}
+			}
+
+			return mcp.NewToolResultText(formatCompletionItems(uri, line, character, items, matched, total, prefix, list.IsIncomplete, bufferNote)), nil
+		}
+}
+
+// completionFilterText is what typed characters are matched against
+func completionFilterText(item protocol.CompletionItem) string {
+	if item.FilterText != "" {
+		return item.FilterText
+	}
+	return item.Label
+}
+
+// completionSortText is the server's ranking key
+func completionSortText(item protocol.CompletionItem) string {
+	if item.SortText != "" {
+		return item.SortText
+	}
+	return item.Label
+}
+
+// rankCompletionItems keeps the items matching prefix and orders them the way an
+// editor would: preselected first, then exact-case prefix matches, deprecated
+// items last, and the server's sortText in between
+func rankCompletionItems(items []protocol.CompletionItem, prefix string) []protocol.CompletionItem {
+	lowerPrefix := strings.ToLower(prefix)
+	ranked := make([]protocol.CompletionItem, 0, len(items))
+	for _, item := range items {
+		if prefix == "" || strings.HasPrefix(strings.ToLower(completionFilterText(item)), lowerPrefix) {
+			ranked = append(ranked, item)
+		}
+	}
+
+	score := func(item protocol.CompletionItem) int {
+		s := 0
+		if item.Preselect {
+			s -= 4
+		}
+		if prefix != "" && strings.HasPrefix(completionFilterText(item), prefix) {
+			s -= 2
+		}
+		if isDeprecatedCompletion(item) {
+			s += 8
+		}
+		return s
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		si, sj := score(ranked[i]), score(ranked[j])
+		if si != sj {
+			return si < sj
+		}
+		ti, tj := completionSortText(ranked[i]), completionSortText(ranked[j])
+		if ti != tj {
+			return ti < tj
+		}
+		return ranked[i].Label < ranked[j].Label
+	})
+	return ranked
+}
+
+func isDeprecatedCompletion(item protocol.CompletionItem) bool {
+	if item.Deprecated {
+		return true
+	}
+	for _, tag := range item.Tags {
+		if tag == protocol.CompletionItemTagDeprecated {
+			return true
+		}
+	}
+	return false
+}
+
+// completionDocumentation returns the documentation as plain text, shortened
+func completionDocumentation(doc *protocol.Or2[string, protocol.MarkupContent]) string {
+	if doc == nil {
+		return ""
+	}
+	var text string
+	switch v := doc.Value.(type) {
+	case string:
+		text = v
+	case protocol.MarkupContent:
+		text = v.Value
+	}
+	text = strings.TrimSpace(text)
+	if runes := []rune(text); len(runes) > completionDocLimit {
+		text = strings.TrimSpace(string(runes[:completionDocLimit])) + "…"
+	}
+	return text
+}
+
+func formatCompletionItems(uri string, line, character int, items []protocol.CompletionItem, matched, total int, prefix string, incomplete bool, bufferNote string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Completion at %s:%d:%d: ", uri, line, character)
+	if prefix != "" {
+		fmt.Fprintf(&b, "%d of %d items match prefix %q", matched, total, prefix)
+	} else {
+		fmt.Fprintf(&b, "%d items", total)
+	}
+	if len(items) < matched {
+		fmt.Fprintf(&b, ", showing the first %d", len(items))
+	}
+	b.WriteString("\n")
+	if incomplete {
+		b.WriteString("The server marked the list as incomplete: type more of the name (or pass prefix) to get the remaining items.\n")
+	}
+	if bufferNote != "" {
+		b.WriteString(bufferNote + "\n")
+	}
+	if len(items) == 0 {
+		b.WriteString("\nNo completion items.\n")
+		return b.String()
+	}
+
+	for i, item := range items {
+		fmt.Fprintf(&b, "\n%d. %s", i+1, item.Label)
+		if item.LabelDetails != nil && item.LabelDetails.Detail != "" {
+			b.WriteString(item.LabelDetails.Detail)
+		}
+		if kind := completionKindToString(item.Kind); kind != "" {
+			fmt.Fprintf(&b, " (%s)", kind)
+		}
+		if isDeprecatedCompletion(item) {
+			b.WriteString(" [deprecated]")
+		}
+		if item.Detail != "" {
+			fmt.Fprintf(&b, " — %s", item.Detail)
+		} else if item.LabelDetails != nil && item.LabelDetails.Description != "" {
+			fmt.Fprintf(&b, " — %s", item.LabelDetails.Description)
+		}
+		b.WriteString("\n")
+		if item.InsertText != "" && item.InsertText != item.Label {
+			fmt.Fprintf(&b, "   Inserts: %s\n", item.InsertText)
+		}
+		if doc := completionDocumentation(item.Documentation); doc != "" {
+			b.WriteString("   " + strings.ReplaceAll(doc, "\n", "\n   ") + "\n")
+		}
+	}
+	return b.String()
+}
+
+// RegisterCompletionTool registers the completion tool with the MCP server.
+func RegisterCompletionTool(mcpServer ToolServer, bridge interfaces.BridgeInterface) {
+	mcpServer.AddTool(CompletionTool(bridge))
+}
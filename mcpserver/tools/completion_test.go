@@ -0,0 +1,149 @@
+package tools
+
+import (
+	"context"
+	"testing"
+
+	"rockerboo/mcp-lsp-bridge/interfaces"
+	"rockerboo/mcp-lsp-bridge/mocks"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/mcptest"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/myleshyson/lsprotocol-go/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func callCompletion(t *testing.T, bridge interfaces.BridgeInterface, arguments map[string]any) *mcp.CallToolResult {
+	t.Helper()
+
+	tool, handler := CompletionTool(bridge)
+	mcpServer, err := mcptest.NewServer(t, server.ServerTool{Tool: tool, Handler: handler})
+	require.NoError(t, err)
+
+	result, err := mcpServer.Client().CallTool(context.Background(), mcp.CallToolRequest{
+		Request: mcp.Request{Method: "tools/call"},
+		Params:  mcp.CallToolParams{Name: "completion", Arguments: arguments},
+	})
+	require.NoError(t, err)
+	return result
+}
+
+func completionKind(k protocol.CompletionItemKind) *protocol.CompletionItemKind {
+	return &k
+}
+
+func TestRankCompletionItems(t *testing.T) {
+	items := []protocol.CompletionItem{
+		{Label: "НайтиПоКоду", SortText: "2"},
+		{Label: "найтиПоНаименованию", SortText: "1"},
+		{Label: "НайтиПоРеквизиту", SortText: "0", Deprecated: true},
+		{Label: "Выбрать", SortText: "0"},
+		{Label: "ПустаяСсылка", FilterText: "НайтиПустую", SortText: "5", Preselect: true},
+	}
+
+	var labels []string
+	for _, item := range rankCompletionItems(items, "Найти") {
+		labels = append(labels, item.Label)
+	}
+	assert.Equal(t, []string{"ПустаяСсылка", "НайтиПоКоду", "найтиПоНаименованию", "НайтиПоРеквизиту"}, labels,
+		"preselected first, exact-case prefix before other case, deprecated last")
+
+	assert.Len(t, rankCompletionItems(items, ""), len(items))
+	assert.Equal(t, "Выбрать", rankCompletionItems(items, "")[1].Label, "sortText orders the rest")
+	assert.Empty(t, rankCompletionItems(items, "Записать"))
+}
+
+func TestCompletionTool(t *testing.T) {
+	uri := "file:///projects/CommonModules/Common/Ext/Module.bsl"
+	items := []protocol.CompletionItem{
+		{Label: "Номенклатура", Kind: completionKind(protocol.CompletionItemKindModule), SortText: "1"},
+		{Label: "Контрагенты", Kind: completionKind(protocol.CompletionItemKindModule), SortText: "2",
+			Detail:        "СправочникМенеджер.Контрагенты",
+			Documentation: &protocol.Or2[string, protocol.MarkupContent]{Value: "Контрагенты организации"}},
+		{Label: "Организации", Kind: completionKind(protocol.CompletionItemKindModule), SortText: "3"},
+	}
+
+	t.Run("resolves and limits", func(t *testing.T) {
+		bridge := &mocks.MockBridge{}
+		bridge.On("Completion", uri, uint32(4), uint32(16)).Return(&protocol.CompletionList{IsIncomplete: true, Items: items}, nil)
+		resolved := items[0]
+		resolved.Detail = "СправочникМенеджер.Номенклатура"
+		resolved.Documentation = &protocol.Or2[string, protocol.MarkupContent]{Value: protocol.MarkupContent{Kind: protocol.MarkupKindMarkdown, Value: "Товары и услуги"}}
+		bridge.On("ResolveCompletionItem", uri, items[0]).Return(&resolved, nil)
+
+		result := callCompletion(t, bridge, map[string]any{"uri": uri, "line": 4, "character": 16, "limit": 2})
+		require.False(t, result.IsError)
+		text := result.Content[0].(mcp.TextContent).Text
+
+		assert.Contains(t, text, "3 items, showing the first 2")
+		assert.Contains(t, text, "incomplete")
+		assert.Contains(t, text, "1. Номенклатура (module) — СправочникМенеджер.Номенклатура\n   Товары и услуги")
+		assert.Contains(t, text, "2. Контрагенты (module) — СправочникМенеджер.Контрагенты\n   Контрагенты организации")
+		assert.NotContains(t, text, "Организации")
+		bridge.AssertNumberOfCalls(t, "ResolveCompletionItem", 1)
+	})
+
+	t.Run("prefix without resolve", func(t *testing.T) {
+		bridge := &mocks.MockBridge{}
+		bridge.On("Completion", uri, uint32(4), uint32(16)).Return(&protocol.CompletionList{Items: items}, nil)
+
+		result := callCompletion(t, bridge, map[string]any{"uri": uri, "line": 4, "character": 16, "prefix": "орг", "resolve": false})
+		require.False(t, result.IsError)
+		text := result.Content[0].(mcp.TextContent).Text
+		assert.Contains(t, text, `1 of 3 items match prefix "орг"`)
+		assert.Contains(t, text, "1. Организации (module)")
+		bridge.AssertNotCalled(t, "ResolveCompletionItem", mock.Anything, mock.Anything)
+	})
+
+	text := "Процедура Тест()\n\tСправочники.\nКонецПроцедуры"
+
+	t.Run("buffer text is reverted", func(t *testing.T) {
+		bridge := &bufferMockBridge{MockBridge: &mocks.MockBridge{}}
+		bridge.On("BufferOverlay", uri).Return("", false)
+		bridge.On("UpdateBuffer", uri, text).Return(int32(3), nil).Once()
+		bridge.On("Completion", uri, uint32(1), uint32(13)).Return(&protocol.CompletionList{}, nil)
+		bridge.On("RevertBuffer", uri).Return(int32(4), nil).Once()
+
+		result := callCompletion(t, bridge, map[string]any{"uri": uri, "line": 1, "character": 13, "text": text})
+		require.False(t, result.IsError)
+		out := result.Content[0].(mcp.TextContent).Text
+		assert.Contains(t, out, "the previous buffer was restored")
+		assert.Contains(t, out, "No completion items.")
+		bridge.AssertExpectations(t)
+	})
+
+	t.Run("previous overlay is restored after a failed request", func(t *testing.T) {
+		bridge := &bufferMockBridge{MockBridge: &mocks.MockBridge{}}
+		bridge.On("BufferOverlay", uri).Return("Черновик", true)
+		bridge.On("UpdateBuffer", uri, text).Return(int32(3), nil).Once()
+		bridge.On("Completion", uri, uint32(1), uint32(13)).Return((*protocol.CompletionList)(nil), assert.AnError)
+		bridge.On("UpdateBuffer", uri, "Черновик").Return(int32(4), nil).Once()
+
+		result := callCompletion(t, bridge, map[string]any{"uri": uri, "line": 1, "character": 13, "text": text})
+		require.True(t, result.IsError)
+		bridge.AssertExpectations(t)
+		bridge.AssertNotCalled(t, "RevertBuffer", mock.Anything)
+	})
+
+	t.Run("keep buffer", func(t *testing.T) {
+		bridge := &bufferMockBridge{MockBridge: &mocks.MockBridge{}}
+		bridge.On("BufferOverlay", uri).Return("", false)
+		bridge.On("UpdateBuffer", uri, text).Return(int32(3), nil).Once()
+		bridge.On("Completion", uri, uint32(1), uint32(13)).Return(&protocol.CompletionList{}, nil)
+
+		result := callCompletion(t, bridge, map[string]any{"uri": uri, "line": 1, "character": 13, "text": text, "keep_buffer": true})
+		require.False(t, result.IsError)
+		assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "now the in-memory buffer")
+		bridge.AssertExpectations(t)
+		bridge.AssertNotCalled(t, "RevertBuffer", mock.Anything)
+	})
+
+	t.Run("text without buffer support", func(t *testing.T) {
+		result := callCompletion(t, &mocks.MockBridge{}, map[string]any{"uri": uri, "line": 1, "character": 1, "text": "x"})
+		require.True(t, result.IsError)
+		assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "in-memory buffers")
+	})
+}
@@ -0,0 +1,470 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"rockerboo/mcp-lsp-bridge/async"
+	"rockerboo/mcp-lsp-bridge/interfaces"
+	"rockerboo/mcp-lsp-bridge/logger"
+	"rockerboo/mcp-lsp-bridge/metadata"
+	"rockerboo/mcp-lsp-bridge/utils"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/myleshyson/lsprotocol-go/protocol"
+)
+
+const (
+	// ContextCheckTimeout bounds a context check; a directory holds many modules
+	ContextCheckTimeout = 5 * time.Minute
+	// MaxContextCheckDepth limits how far callees are followed
+	MaxContextCheckDepth = 10
+)
+
+// ContextCheckMethod is one side of a checked call
+type ContextCheckMethod struct {
+	Name      string `json:"name"`
+	URI       string `json:"uri"`
+	Line      uint32 `json:"line"`
+	Context   string `json:"context"`             // client, server, client+server
+	Directive string `json:"directive,omitempty"` // e.g. НаСервереБезКонтекста; empty when the module decides
+}
+
+// ContextViolation is a call that cannot happen in the caller's execution context
+type ContextViolation struct {
+	Kind    string             `json:"kind"` // client_to_server, server_to_client, no_context
+	Message string             `json:"message"`
+	Caller  ContextCheckMethod `json:"caller"`
+	Callee  ContextCheckMethod `json:"callee"`
+	Line    uint32             `json:"line"` // call site in the caller's file
+}
+
+// ContextCheckResult is the outcome of a context check over a file or directory
+type ContextCheckResult struct {
+	URI            string             `json:"uri"`
+	Files          int                `json:"files"`
+	MethodsChecked int                `json:"methods_checked"`
+	CallsChecked   int                `json:"calls_checked"`
+	Unresolved     int                `json:"calls_unresolved"` // callees outside the configuration dump
+	Total          int                `json:"total"`
+	Offset         int                `json:"offset"`
+	Violations     []ContextViolation `json:"violations"`
+	Truncated      bool               `json:"truncated,omitempty"`
+	Errors         []string           `json:"errors,omitempty"`
+	ElapsedMs      int64              `json:"elapsed_ms"`
+}
+
+// RegisterContextCheckTool registers the context_check tool
+func RegisterContextCheckTool(mcpServer ToolServer, bridge interfaces.BridgeInterface) {
+	mcpServer.AddTool(ContextCheckTool(bridge))
+}
+
+func ContextCheckTool(bridge interfaces.BridgeInterface) (mcp.Tool, server.ToolHandlerFunc) {
+	return mcp.NewTool("context_check",
+			mcp.WithDescription(`Find calls that break the 1C execution context: client code calling server-only
+methods, server code calling client-only methods, and context-free form methods
+(&НаСервереБезКонтекста) calling methods that need the form.
+
+Each method gets its context from its compilation directive (&НаКлиенте, &НаСервере,
+&НаСервереБезКонтекста, &НаКлиентеНаСервереБезКонтекста...) in form and command modules,
+and from the module otherwise: common module flags (Client, Server, ServerCall),
+object/manager modules on the server. Calls come from the outgoing call hierarchy.
+A client method may call a server method of its own form or of a common module with ServerCall.
+
+Needs the configuration dump (Designer or EDT) next to the modules.
+
+Parameters:
+- uri: a .bsl module or a directory of modules
+- depth: how far to follow callees (default: 1 = calls made in the scope, max 10)
+- offset / limit: pagination over the violations (default 0 / 50, max 500)`),
+			mcp.WithDestructiveHintAnnotation(false),
+			mcp.WithString("uri", mcp.Description("Module file or directory URI"), mcp.Required()),
+			mcp.WithNumber("depth", mcp.Description("Follow callees this many calls deep (default: 1, max 10)"), mcp.Min(1), mcp.Max(MaxContextCheckDepth)),
+			mcp.WithNumber("offset", mcp.Description("Skip N violations (default: 0)"), mcp.Min(0)),
+			mcp.WithNumber("limit", mcp.Description("Max violations (default: 50, max 500)"), mcp.Min(1), mcp.Max(500)),
+		), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			bridge := bridge.WithContext(ctx)
+
+			uri, err := request.RequireString("uri")
+			if err != nil {
+				return mcp.NewToolResultError("uri is required"), nil
+			}
+			depth := min(max(request.GetInt("depth", 1), 1), MaxContextCheckDepth)
+			offset := max(request.GetInt("offset", 0), 0)
+			limit := min(request.GetInt("limit", 50), 500)
+
+			if result, ok := CheckReadyOrReturn(bridge); !ok {
+				return result, nil
+			}
+
+			result, err := checkExecutionContext(ctx, bridge, bridge.NormalizeURIForLSP(uri), depth)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			result.Offset = offset
+			if offset < len(result.Violations) {
+				result.Violations = result.Violations[offset:min(offset+limit, len(result.Violations))]
+			} else {
+				result.Violations = []ContextViolation{}
+			}
+
+			payload, err := json.MarshalIndent(result, "", "  ")
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal result: %v", err)), nil
+			}
+			return mcp.NewToolResultText(string(payload)), nil
+		}
+}
+
+// checkExecutionContext checks the calls made by every method in a module or a directory
+// of modules, following callees depth calls deep. Violations are sorted by file and line.
+func checkExecutionContext(ctx context.Context, bridge interfaces.BridgeInterface, uri string, depth int) (*ContextCheckResult, error) {
+	startTime := time.Now()
+	ctx, cancel := context.WithTimeout(ctx, ContextCheckTimeout)
+	defer cancel()
+
+	path := utils.URIToFilePath(uri)
+	files, err := bslFiles(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no .bsl modules in %s", uri)
+	}
+	if _, err := metadataCache.ForFile(files[0]); err != nil {
+		return nil, fmt.Errorf("execution contexts need the configuration dump: %w", err)
+	}
+
+	checker := &contextChecker{
+		calls:   &callGraphBuilder{bridge: bridge, ctx: ctx},
+		lines:   make(map[string][]string),
+		visited: make(map[string]*contextVisit),
+		result:  &ContextCheckResult{URI: uri, Files: len(files), Violations: []ContextViolation{}},
+	}
+
+	// Modules are checked concurrently; the semaphore keeps the language server responsive
+	semaphore := make(chan struct{}, 5)
+	ops := make(map[string]func() (struct{}, error), len(files))
+	for _, file := range files {
+		ops[file] = func() (struct{}, error) {
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+			return struct{}{}, checker.checkModule(ctx, bridge, utils.FilePathToURI(file), depth)
+		}
+	}
+	results, err := async.MapWithKeys(ctx, ops)
+	if err != nil {
+		checker.addError(fmt.Sprintf("stopped early: %v", err))
+	}
+	for _, r := range results {
+		if r.Error != nil {
+			checker.addError(fmt.Sprintf("%s: %v", utils.FilePathToURI(r.Key), r.Error))
+		}
+	}
+
+	// After a timeout the remaining workers may still be finishing, so return a copy
+	checker.mu.Lock()
+	result := *checker.result
+	result.Violations = append([]ContextViolation{}, result.Violations...)
+	result.Errors = append([]string(nil), result.Errors...)
+	checker.mu.Unlock()
+
+	result.Truncated = err != nil
+	sort.Slice(result.Violations, func(i, j int) bool {
+		a, b := result.Violations[i], result.Violations[j]
+		if a.Caller.URI != b.Caller.URI {
+			return a.Caller.URI < b.Caller.URI
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Callee.Name < b.Callee.Name
+	})
+	result.Total = len(result.Violations)
+	result.ElapsedMs = time.Since(startTime).Milliseconds()
+	return &result, nil
+}
+
+// contextChecker walks the outgoing calls of methods and records context violations
+type contextChecker struct {
+	calls   *callGraphBuilder // outgoing calls, from the call graph index when available
+	mu      sync.Mutex
+	lines   map[string][]string      // file path -> source lines
+	visited map[string]*contextVisit // method -> its checked calls
+	result  *ContextCheckResult
+}
+
+// contextVisit records that the calls of a method were checked. A method reached
+// again with more depth left walks its callees again, without checking its own
+// calls a second time.
+type contextVisit struct {
+	depth int                                  // largest depth the callees were walked with
+	ready chan struct{}                        // closed once calls is set
+	calls []protocol.CallHierarchyOutgoingCall // calls to methods with a known context
+}
+
+// checkModule checks every method declared in the module at uri
+func (c *contextChecker) checkModule(ctx context.Context, bridge interfaces.BridgeInterface, uri string, depth int) error {
+	symbols, err := bridge.GetDocumentSymbols(uri)
+	if err != nil {
+		return err
+	}
+	for _, sym := range bslMethods(symbols) {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		items, err := bridge.PrepareCallHierarchyContext(ctx, uri, sym.SelectionRange.Start.Line, sym.SelectionRange.Start.Character)
+		if err != nil {
+			logger.Warn(fmt.Sprintf("context_check: prepare call hierarchy for %s failed: %v", sym.Name, err))
+			continue
+		}
+		if len(items) > 0 {
+			c.checkMethod(ctx, items[0], depth)
+		}
+	}
+	return nil
+}
+
+// checkMethod checks the calls made by item and, while depth allows, by its callees
+func (c *contextChecker) checkMethod(ctx context.Context, item protocol.CallHierarchyItem, depth int) {
+	if depth <= 0 || ctx.Err() != nil {
+		return
+	}
+	key := string(item.Uri) + "#" + strings.ToLower(item.Name)
+	c.mu.Lock()
+	visit, seen := c.visited[key]
+	if seen && visit.depth >= depth {
+		c.mu.Unlock()
+		return
+	}
+	if !seen {
+		visit = &contextVisit{ready: make(chan struct{})}
+		c.visited[key] = visit
+	}
+	visit.depth = depth
+	c.mu.Unlock()
+
+	if seen {
+		select {
+		case <-visit.ready:
+		case <-ctx.Done():
+			return
+		}
+	} else {
+		visit.calls = c.checkCalls(item)
+		close(visit.ready)
+	}
+
+	for _, call := range visit.calls {
+		c.checkMethod(ctx, call.To, depth-1)
+	}
+}
+
+// checkCalls records the violations in the calls made by item and returns the calls
+// whose callee context is known
+func (c *contextChecker) checkCalls(item protocol.CallHierarchyItem) []protocol.CallHierarchyOutgoingCall {
+	caller, ok := c.resolve(item)
+	if !ok {
+		return nil
+	}
+	calls, err := c.calls.outgoingCalls(item)
+	if err != nil {
+		logger.Warn(fmt.Sprintf("context_check: outgoing calls of %s failed: %v", item.Name, err))
+		return nil
+	}
+
+	c.mu.Lock()
+	c.result.MethodsChecked++
+	c.mu.Unlock()
+
+	var resolved []protocol.CallHierarchyOutgoingCall
+	for _, call := range calls {
+		callee, ok := c.resolve(call.To)
+		c.mu.Lock()
+		c.result.CallsChecked++
+		if !ok {
+			c.result.Unresolved++
+		}
+		c.mu.Unlock()
+		if !ok {
+			continue
+		}
+		resolved = append(resolved, call)
+
+		for _, kind := range metadata.CheckCall(caller, callee, call.To.Uri == item.Uri) {
+			line := call.To.Range.Start.Line
+			if len(call.FromRanges) > 0 {
+				line = call.FromRanges[0].Start.Line
+			}
+			v := ContextViolation{
+				Kind:   kind,
+				Caller: contextCheckMethod(item, caller),
+				Callee: contextCheckMethod(call.To, callee),
+				Line:   line,
+			}
+			v.Message = violationMessage(v)
+			c.mu.Lock()
+			c.result.Violations = append(c.result.Violations, v)
+			c.mu.Unlock()
+		}
+	}
+	return resolved
+}
+
+// resolve determines the execution context of a method from its module and directive;
+// ok is false for methods outside a configuration dump
+func (c *contextChecker) resolve(item protocol.CallHierarchyItem) (metadata.MethodContext, bool) {
+	path := utils.URIToFilePath(string(item.Uri))
+	cfg, err := c.calls.configs.forFile(path)
+	if err != nil {
+		return metadata.MethodContext{}, false
+	}
+	owner, ok := cfg.Owner(path)
+	if !ok {
+		return metadata.MethodContext{}, false
+	}
+
+	c.mu.Lock()
+	lines, ok := c.lines[path]
+	c.mu.Unlock()
+	if !ok {
+		lines = readModuleLines(path)
+		c.mu.Lock()
+		c.lines[path] = lines
+		c.mu.Unlock()
+	}
+
+	if directive, ok := metadata.FindDirective(lines, int(item.Range.Start.Line)); ok {
+		return metadata.ResolveMethod(owner, &directive), true
+	}
+	return metadata.ResolveMethod(owner, nil), true
+}
+
+func (c *contextChecker) addError(msg string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.result.Errors = append(c.result.Errors, msg)
+}
+
+func contextCheckMethod(item protocol.CallHierarchyItem, mc metadata.MethodContext) ContextCheckMethod {
+	return ContextCheckMethod{
+		Name:      item.Name,
+		URI:       string(item.Uri),
+		Line:      item.Range.Start.Line,
+		Context:   mc.Context.String(),
+		Directive: mc.Directive,
+	}
+}
+
+func violationMessage(v ContextViolation) string {
+	side := func(m ContextCheckMethod) string {
+		if m.Directive != "" {
+			return fmt.Sprintf("%s (&%s)", m.Name, m.Directive)
+		}
+		return fmt.Sprintf("%s (%s)", m.Name, m.Context)
+	}
+	switch v.Kind {
+	case metadata.ViolationClientToServer:
+		return fmt.Sprintf("%s runs on the client and calls %s, which the client cannot reach", side(v.Caller), side(v.Callee))
+	case metadata.ViolationServerToClient:
+		return fmt.Sprintf("%s runs on the server and calls client-only %s", side(v.Caller), side(v.Callee))
+	default:
+		return fmt.Sprintf("%s has no form context and calls %s, which needs the form", side(v.Caller), side(v.Callee))
+	}
+}
+
+// readModuleLines returns the source lines of a module without the byte order mark,
+// or nil when it cannot be read
+func readModuleLines(path string) []string {
+	data, err := os.ReadFile(path) // #nosec G304 -- module of the configuration
+	if err != nil {
+		return nil
+	}
+	return strings.Split(strings.TrimPrefix(string(data), "\ufeff"), "\n")
+}
+
+// bslMethods returns the procedures and functions among symbols, including those nested in regions
+func bslMethods(symbols []protocol.DocumentSymbol) []protocol.DocumentSymbol {
+	var out []protocol.DocumentSymbol
+	for _, sym := range symbols {
+		switch sym.Kind {
+		case protocol.SymbolKindMethod, protocol.SymbolKindFunction:
+			out = append(out, sym)
+		default:
+			out = append(out, bslMethods(sym.Children)...)
+		}
+	}
+	return out
+}
+
+// bslFiles returns path itself for a file, or the .bsl files below a directory
+func bslFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+	var files []string
+	err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if p != path && strings.HasPrefix(d.Name(), ".") {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if strings.EqualFold(filepath.Ext(p), ".bsl") {
+			files = append(files, p)
+		}
+		return nil
+	})
+	sort.Strings(files)
+	return files, err
+}
+
+// handleContextCheck handles the 'context_check' analysis type in the compact project_analysis format
+func handleContextCheck(ctx context.Context, bridge interfaces.BridgeInterface, query string, offset, limit int, response *strings.Builder) (*mcp.CallToolResult, error) {
+	result, err := checkExecutionContext(ctx, bridge, bridge.NormalizeURIForLSP(query), 1)
+	if err != nil {
+		fmt.Fprintf(response, "ERROR: %v\n", err)
+		return mcp.NewToolResultText(response.String()), nil
+	}
+
+	fmt.Fprintf(response, "CONTEXT_CHECK|files=%d|methods=%d|calls=%d|unresolved=%d|violations=%d\n",
+		result.Files, result.MethodsChecked, result.CallsChecked, result.Unresolved, result.Total)
+	for _, e := range result.Errors {
+		fmt.Fprintf(response, "ERROR|%s\n", e)
+	}
+	if result.Total == 0 {
+		response.WriteString("NO_VIOLATIONS\n")
+		return mcp.NewToolResultText(response.String()), nil
+	}
+	if offset >= result.Total {
+		fmt.Fprintf(response, "OFFSET_EXCEEDED: %d >= %d\n", offset, result.Total)
+		return mcp.NewToolResultText(response.String()), nil
+	}
+
+	// Format: INDEX|KIND|URI:LINE|CALLER(CONTEXT)|CALLEE(CONTEXT)|CALLEE_URI:LINE
+	end := min(offset+limit, result.Total)
+	for i, v := range result.Violations[offset:end] {
+		fmt.Fprintf(response, "%d|%s|%s:%d|%s(%s)|%s(%s)|%s:%d\n", offset+i+1, v.Kind, v.Caller.URI, v.Line,
+			v.Caller.Name, v.Caller.Context, v.Callee.Name, v.Callee.Context, v.Callee.URI, v.Callee.Line)
+	}
+	if end < result.Total {
+		fmt.Fprintf(response, "MORE|next_offset=%d\n", end)
+	}
+	return mcp.NewToolResultText(response.String()), nil
+}
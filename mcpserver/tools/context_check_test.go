@@ -0,0 +1,199 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"rockerboo/mcp-lsp-bridge/mocks"
+	"rockerboo/mcp-lsp-bridge/utils"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/mcptest"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/myleshyson/lsprotocol-go/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const contextFormModule = `&НаКлиенте
+Процедура ПриОткрытии(Отказ)
+	ЗаполнитьНаСервере();
+	ОбщегоНазначения.Сделать();
+	Записать();
+КонецПроцедуры
+
+&НаСервереБезКонтекста
+Процедура ЗаполнитьНаСервере()
+	ОбщегоНазначенияКлиент.ПоказатьСообщение();
+	Обновить();
+КонецПроцедуры
+
+&НаСервере
+Процедура Обновить()
+КонецПроцедуры
+`
+
+// newContextCheckBridge writes a dump whose form module makes one call of each violation
+// kind and mocks the symbols and outgoing calls the language server would report
+func newContextCheckBridge(t *testing.T) (*indexedMockBridge, string, string) {
+	t.Helper()
+	root := writeDesignerDump(t)
+	formPath := filepath.Join(root, "Catalogs/Товары/Forms/ФормаЭлемента/Ext/Form/Module.bsl")
+	require.NoError(t, os.WriteFile(formPath, []byte(contextFormModule), 0o644))
+	clientModule := filepath.Join(root, "CommonModules/ОбщегоНазначенияКлиент/Ext/Module.bsl")
+	require.NoError(t, os.MkdirAll(filepath.Dir(clientModule), 0o755))
+	require.NoError(t, os.WriteFile(clientModule, []byte("Процедура ПоказатьСообщение() Экспорт\nКонецПроцедуры\n"), 0o644))
+	formURI := utils.FilePathToURI(formPath)
+
+	method := func(name, path string, line uint32) protocol.CallHierarchyItem {
+		uri := path
+		if !strings.HasPrefix(path, "file://") {
+			uri = utils.FilePathToURI(filepath.Join(root, path))
+		}
+		return protocol.CallHierarchyItem{
+			Name:  name,
+			Kind:  protocol.SymbolKindMethod,
+			Uri:   protocol.DocumentUri(uri),
+			Range: protocol.Range{Start: protocol.Position{Line: line}, End: protocol.Position{Line: line + 1}},
+		}
+	}
+	call := func(to protocol.CallHierarchyItem, line uint32) protocol.CallHierarchyOutgoingCall {
+		return protocol.CallHierarchyOutgoingCall{To: to, FromRanges: []protocol.Range{{Start: protocol.Position{Line: line}}}}
+	}
+	form := "Catalogs/Товары/Forms/ФормаЭлемента/Ext/Form/Module.bsl"
+	onOpen := method("ПриОткрытии", form, 1)
+	fill := method("ЗаполнитьНаСервере", form, 8)
+	update := method("Обновить", form, 14)
+
+	b := &indexedMockBridge{
+		MockBridge: &mocks.MockBridge{},
+		outgoing: map[string][]protocol.CallHierarchyOutgoingCall{
+			"ПриОткрытии": {
+				call(fill, 2),
+				call(method("Сделать", "CommonModules/ОбщегоНазначения/Ext/Module.bsl", 0), 3),
+				call(method("Записать", "Catalogs/Товары/Ext/ObjectModule.bsl", 0), 4),
+				call(method("Внешняя", "file:///elsewhere/Module.bsl", 0), 4),
+			},
+			"ЗаполнитьНаСервере": {
+				call(method("ПоказатьСообщение", "CommonModules/ОбщегоНазначенияКлиент/Ext/Module.bsl", 0), 9),
+				call(update, 10),
+			},
+		},
+		incoming: map[string][]protocol.CallHierarchyIncomingCall{},
+		dirty:    map[string]bool{},
+	}
+
+	symbol := func(item protocol.CallHierarchyItem) protocol.DocumentSymbol {
+		return protocol.DocumentSymbol{Name: item.Name, Kind: protocol.SymbolKindMethod, Range: item.Range, SelectionRange: item.Range}
+	}
+	b.On("GetDocumentSymbols", formURI).Return([]protocol.DocumentSymbol{
+		symbol(onOpen),
+		{Name: "Служебные", Kind: protocol.SymbolKindNamespace, Children: []protocol.DocumentSymbol{symbol(fill), symbol(update)}},
+	}, nil)
+	b.On("GetDocumentSymbols", mock.Anything).Return([]protocol.DocumentSymbol{}, nil)
+	for _, item := range []protocol.CallHierarchyItem{onOpen, fill, update} {
+		b.On("PrepareCallHierarchy", formURI, item.Range.Start.Line, uint32(0)).Return([]protocol.CallHierarchyItem{item}, nil)
+	}
+	return b, root, formURI
+}
+
+func TestContextCheckTool(t *testing.T) {
+	bridge, _, formURI := newContextCheckBridge(t)
+
+	tool, handler := ContextCheckTool(bridge)
+	mcpServer, err := mcptest.NewServer(t, server.ServerTool{Tool: tool, Handler: handler})
+	require.NoError(t, err)
+
+	res, err := mcpServer.Client().CallTool(context.Background(), mcp.CallToolRequest{
+		Request: mcp.Request{Method: "tools/call"},
+		Params:  mcp.CallToolParams{Name: "context_check", Arguments: map[string]any{"uri": formURI}},
+	})
+	require.NoError(t, err)
+	require.False(t, res.IsError, "%v", res.Content)
+
+	var result ContextCheckResult
+	require.NoError(t, json.Unmarshal([]byte(res.Content[0].(mcp.TextContent).Text), &result))
+
+	assert.Equal(t, 3, result.MethodsChecked)
+	assert.Equal(t, 6, result.CallsChecked)
+	assert.Equal(t, 1, result.Unresolved)
+	require.Equal(t, 3, result.Total)
+
+	var got []string
+	for _, v := range result.Violations {
+		got = append(got, v.Kind+" "+v.Caller.Name+" -> "+v.Callee.Name)
+	}
+	assert.Equal(t, []string{
+		"client_to_server ПриОткрытии -> Записать",
+		"server_to_client ЗаполнитьНаСервере -> ПоказатьСообщение",
+		"no_context ЗаполнитьНаСервере -> Обновить",
+	}, got)
+	assert.Equal(t, uint32(4), result.Violations[0].Line)
+	assert.Equal(t, "НаКлиенте", result.Violations[0].Caller.Directive)
+	assert.Equal(t, "server", result.Violations[0].Callee.Context)
+}
+
+func TestContextCheckWalksAgainWithMoreDepth(t *testing.T) {
+	bridge, _, formURI := newContextCheckBridge(t)
+	onOpen := bridge.outgoing["ПриОткрытии"][0].To
+	onOpen.Name, onOpen.Range.Start.Line = "ПриОткрытии", 1
+	fill := bridge.outgoing["ПриОткрытии"][0].To
+	showMessage := bridge.outgoing["ЗаполнитьНаСервере"][0].To
+	bridge.outgoing["Обновить"] = []protocol.CallHierarchyOutgoingCall{{To: showMessage, FromRanges: []protocol.Range{{Start: protocol.Position{Line: 15}}}}}
+
+	checker := &contextChecker{
+		calls:   &callGraphBuilder{bridge: bridge, ctx: context.Background()},
+		lines:   make(map[string][]string),
+		visited: make(map[string]*contextVisit),
+		result:  &ContextCheckResult{URI: formURI, Violations: []ContextViolation{}},
+	}
+
+	// Reached first with depth 1: only its own calls are checked
+	checker.checkMethod(context.Background(), fill, 1)
+	assert.Len(t, checker.result.Violations, 2)
+
+	// Reached again with more depth left, the callees are walked but the calls
+	// of ЗаполнитьНаСервере are not reported twice
+	checker.checkMethod(context.Background(), onOpen, 3)
+
+	var got []string
+	for _, v := range checker.result.Violations {
+		got = append(got, v.Kind+" "+v.Caller.Name+" -> "+v.Callee.Name)
+	}
+	assert.ElementsMatch(t, []string{
+		"server_to_client ЗаполнитьНаСервере -> ПоказатьСообщение",
+		"no_context ЗаполнитьНаСервере -> Обновить",
+		"client_to_server ПриОткрытии -> Записать",
+		"server_to_client Обновить -> ПоказатьСообщение",
+	}, got)
+	assert.Equal(t, 6, checker.result.MethodsChecked, "every method is checked once")
+}
+
+func TestProjectAnalysisContextCheck(t *testing.T) {
+	bridge, root, _ := newContextCheckBridge(t)
+
+	var response strings.Builder
+	_, err := handleContextCheck(context.Background(), bridge, utils.FilePathToURI(root), 1, 10, &response)
+	require.NoError(t, err)
+
+	out := response.String()
+	assert.Contains(t, out, "CONTEXT_CHECK|files=4|methods=3|calls=6|unresolved=1|violations=3\n")
+	assert.Contains(t, out, "2|server_to_client|")
+	assert.NotContains(t, out, "1|client_to_server|", "offset skips the first violation")
+	assert.NotContains(t, out, "MORE|")
+}
+
+func TestContextCheckToolNeedsConfiguration(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Module.bsl"), []byte(""), 0o644))
+
+	bridge := &mocks.MockBridge{}
+	_, err := checkExecutionContext(context.Background(), bridge, utils.FilePathToURI(dir), 1)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "configuration dump")
+}
@@ -0,0 +1,367 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"rockerboo/mcp-lsp-bridge/async"
+	"rockerboo/mcp-lsp-bridge/interfaces"
+	"rockerboo/mcp-lsp-bridge/metadata"
+	"rockerboo/mcp-lsp-bridge/utils"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/myleshyson/lsprotocol-go/protocol"
+)
+
+const (
+	// DeadCodeTimeout bounds one page of the dead code scan
+	DeadCodeTimeout = 5 * time.Minute
+	// DefaultDeadCodeModules is the number of modules scanned per call
+	DefaultDeadCodeModules = 50
+	// MaxDeadCodeModules caps the page size
+	MaxDeadCodeModules = 500
+)
+
+// DeadCodeMethod is a procedure or function nothing calls
+type DeadCodeMethod struct {
+	Name   string `json:"name"`
+	Kind   string `json:"kind"`
+	Line   uint32 `json:"line"`
+	Export bool   `json:"export"`
+}
+
+// DeadCodeModule groups the unused methods of one module
+type DeadCodeModule struct {
+	URI           string           `json:"uri"`
+	Module        string           `json:"module,omitempty"` // e.g. CommonModule.ОбщегоНазначения, Catalog.Товары.ObjectModule
+	Methods       int              `json:"methods"`
+	EntryPoints   int              `json:"entry_points,omitempty"`
+	UnusedExports []DeadCodeMethod `json:"unused_exports,omitempty"`
+	UnusedLocals  []DeadCodeMethod `json:"unused_locals,omitempty"`
+}
+
+// DeadCodeResult is one page of a dead code scan. Pass NextCursor back as cursor
+// to continue; Done is set once the last module was scanned.
+type DeadCodeResult struct {
+	Scope          string           `json:"scope"`
+	ModulesTotal   int              `json:"modules_total"`
+	Cursor         string           `json:"cursor,omitempty"`
+	NextCursor     string           `json:"next_cursor,omitempty"`
+	Done           bool             `json:"done"`
+	ModulesScanned int              `json:"modules_scanned"`
+	MethodsChecked int              `json:"methods_checked"`
+	EntryPoints    int              `json:"entry_points"` // skipped: form events, subscriptions, jobs, services...
+	UnusedExports  int              `json:"unused_exports"`
+	UnusedLocals   int              `json:"unused_locals"`
+	Modules        []DeadCodeModule `json:"modules"` // only modules with findings
+	Errors         []string         `json:"errors,omitempty"`
+	ElapsedMs      int64            `json:"elapsed_ms"`
+}
+
+// RegisterDeadCodeTool registers the dead_code tool
+func RegisterDeadCodeTool(mcpServer ToolServer, bridge interfaces.BridgeInterface) {
+	mcpServer.AddTool(DeadCodeTool(bridge))
+}
+
+func DeadCodeTool(bridge interfaces.BridgeInterface) (mcp.Tool, server.ToolHandlerFunc) {
+	return mcp.NewTool("dead_code",
+			mcp.WithDescription(`Find procedures and functions nothing calls, grouped by module.
+
+Every method from the document symbols is checked for incoming calls, then for references.
+Methods the platform calls are skipped: handlers bound in forms, event subscription,
+scheduled job, HTTP and web service handlers (from the configuration dump), and module
+events such as ПередЗаписью. Local methods passed by name in a string of the same module
+(ОписаниеОповещения, ПодключитьОбработчикОжидания) count as used.
+
+The scan is paginated by module and resumable: each call scans up to "modules" modules
+in path order and returns next_cursor; call again with cursor=next_cursor until done=true.
+Results are independent per page, so a scan can be resumed at any later time.
+
+Parameters:
+- uri: directory or module to scan (default: the project root)
+- cursor: next_cursor from the previous call
+- modules: modules per call (default: 50, max 500)
+- include_locals: also report non-export methods (default: true)`),
+			mcp.WithDestructiveHintAnnotation(false),
+			mcp.WithString("uri", mcp.Description("Directory or module URI (optional, defaults to the project root)")),
+			mcp.WithString("cursor", mcp.Description("Resume from next_cursor of the previous call")),
+			mcp.WithNumber("modules", mcp.Description("Modules per call (default: 50, max 500)"), mcp.Min(1), mcp.Max(MaxDeadCodeModules)),
+			mcp.WithBoolean("include_locals", mcp.Description("Report unused non-export methods too (default: true)")),
+		), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			bridge := bridge.WithContext(ctx)
+
+			uri := request.GetString("uri", "")
+			if uri == "" {
+				dirs := bridge.AllowedDirectories()
+				if len(dirs) == 0 {
+					return mcp.NewToolResultError("uri is required: no allowed directories configured"), nil
+				}
+				uri = dirs[0]
+			}
+			uri = bridge.NormalizeURIForLSP(uri)
+			cursor := request.GetString("cursor", "")
+			pageSize := min(max(request.GetInt("modules", DefaultDeadCodeModules), 1), MaxDeadCodeModules)
+			includeLocals := request.GetBool("include_locals", true)
+
+			if result, ok := CheckReadyOrReturn(bridge); !ok {
+				return result, nil
+			}
+
+			result, err := findDeadCode(ctx, bridge, uri, cursor, pageSize, includeLocals)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			payload, err := json.MarshalIndent(result, "", "  ")
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal result: %v", err)), nil
+			}
+			return mcp.NewToolResultText(string(payload)), nil
+		}
+}
+
+// findDeadCode scans one page of modules under uri, starting at cursor: the path of the
+// first module to scan, relative to the scope, as returned in NextCursor
+func findDeadCode(ctx context.Context, bridge interfaces.BridgeInterface, uri, cursor string, pageSize int, includeLocals bool) (*DeadCodeResult, error) {
+	startTime := time.Now()
+	ctx, cancel := context.WithTimeout(ctx, DeadCodeTimeout)
+	defer cancel()
+
+	scope := utils.URIToFilePath(uri)
+	files, err := bslFiles(scope)
+	if err != nil {
+		return nil, err
+	}
+	rel := func(path string) string {
+		r, err := filepath.Rel(scope, path)
+		if err != nil || r == "." {
+			return filepath.Base(path)
+		}
+		return filepath.ToSlash(r)
+	}
+
+	start := sort.Search(len(files), func(i int) bool { return rel(files[i]) >= cursor })
+	end := min(start+pageSize, len(files))
+	page := files[start:end]
+
+	// One builder for the page, so the modules share their configuration lookups
+	calls := &callGraphBuilder{bridge: bridge, ctx: ctx}
+	ops := make([]func() (DeadCodeModule, error), 0, len(page))
+	semaphore := make(chan struct{}, 5) // keep the language server responsive
+	for _, file := range page {
+		ops = append(ops, func() (DeadCodeModule, error) {
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+			module, err := deadCodeInModule(ctx, bridge, calls, file, includeLocals)
+			if err != nil {
+				return module, fmt.Errorf("%s: %w", rel(file), err)
+			}
+			return module, nil
+		})
+	}
+	results, err := async.Map(ctx, ops)
+	if err != nil {
+		return nil, fmt.Errorf("dead code scan stopped before the page finished (%v); call again with cursor=%q", err, cursor)
+	}
+
+	result := &DeadCodeResult{
+		Scope:          uri,
+		ModulesTotal:   len(files),
+		Cursor:         cursor,
+		Done:           end == len(files),
+		ModulesScanned: len(page),
+		Modules:        []DeadCodeModule{},
+	}
+	if !result.Done {
+		result.NextCursor = rel(files[end])
+	}
+	for _, r := range results {
+		if r.Error != nil {
+			result.Errors = append(result.Errors, r.Error.Error())
+			continue
+		}
+		m := r.Value
+		result.MethodsChecked += m.Methods
+		result.EntryPoints += m.EntryPoints
+		result.UnusedExports += len(m.UnusedExports)
+		result.UnusedLocals += len(m.UnusedLocals)
+		if len(m.UnusedExports)+len(m.UnusedLocals) > 0 {
+			result.Modules = append(result.Modules, m)
+		}
+	}
+	sort.Slice(result.Modules, func(i, j int) bool { return result.Modules[i].URI < result.Modules[j].URI })
+	sort.Strings(result.Errors)
+	result.ElapsedMs = time.Since(startTime).Milliseconds()
+	return result, nil
+}
+
+// deadCodeInModule checks every method declared in the module at path
+func deadCodeInModule(ctx context.Context, bridge interfaces.BridgeInterface, calls *callGraphBuilder, path string, includeLocals bool) (DeadCodeModule, error) {
+	uri := utils.FilePathToURI(path)
+	module := DeadCodeModule{URI: uri}
+	if cfg, err := calls.configs.forFile(path); err == nil {
+		if owner, ok := cfg.Owner(path); ok {
+			module.Module = moduleDisplayName(owner)
+		}
+	}
+
+	symbols, err := bridge.GetDocumentSymbols(uri)
+	if err != nil {
+		return module, err
+	}
+	lines := readModuleLines(path)
+
+	for _, sym := range bslMethods(symbols) {
+		if ctx.Err() != nil {
+			return module, ctx.Err()
+		}
+		module.Methods++
+		if ok, _ := bslEntryPoint(&calls.configs, uri, sym.Name); ok {
+			module.EntryPoints++
+			continue
+		}
+		export := isExportDeclaration(lines, int(sym.Range.Start.Line))
+		if !export && !includeLocals {
+			continue
+		}
+
+		used, err := methodIsUsed(ctx, bridge, calls, uri, sym)
+		if err != nil {
+			return module, fmt.Errorf("%s: %w", sym.Name, err)
+		}
+		if used || (!export && passedByName(lines, sym.Name)) {
+			continue
+		}
+
+		method := DeadCodeMethod{
+			Name:   sym.Name,
+			Kind:   symbolKindToString(sym.Kind),
+			Line:   sym.SelectionRange.Start.Line,
+			Export: export,
+		}
+		if export {
+			module.UnusedExports = append(module.UnusedExports, method)
+		} else {
+			module.UnusedLocals = append(module.UnusedLocals, method)
+		}
+	}
+	return module, nil
+}
+
+// methodIsUsed asks for incoming calls first (answered by the call graph index when
+// available) and falls back to references, which also cover calls the call hierarchy misses
+func methodIsUsed(ctx context.Context, bridge interfaces.BridgeInterface, calls *callGraphBuilder, uri string, sym protocol.DocumentSymbol) (bool, error) {
+	pos := sym.SelectionRange.Start
+	items, err := bridge.PrepareCallHierarchyContext(ctx, uri, pos.Line, pos.Character)
+	if err != nil {
+		return false, err
+	}
+	if len(items) > 0 {
+		incoming, err := calls.incomingCalls(items[0])
+		if err != nil {
+			return false, err
+		}
+		if len(incoming) > 0 {
+			return true, nil
+		}
+	}
+
+	refs, err := bridge.FindSymbolReferences("bsl", uri, pos.Line, pos.Character, false)
+	if err != nil {
+		return false, err
+	}
+	for _, ref := range refs {
+		if string(ref.Uri) != uri || ref.Range.Start.Line != pos.Line {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// isExportDeclaration reports whether the method declared at or after lines[decl]
+// has the Экспорт (Export) keyword after its parameter list
+func isExportDeclaration(lines []string, decl int) bool {
+	if decl < 0 {
+		return false
+	}
+	// The symbol range may start at the directive or annotations above the declaration
+	for ; decl < len(lines); decl++ {
+		line := strings.ToLower(strings.TrimSpace(lines[decl]))
+		if hasAnyPrefix(line, "процедура", "функция", "procedure", "function", "асинх", "async") {
+			break
+		}
+	}
+
+	depth, seenParen := 0, false
+	for i := decl; i < len(lines) && i < decl+50; i++ {
+		line, inString := lines[i], false
+	scan:
+		for j, r := range line {
+			switch {
+			case r == '"':
+				inString = !inString
+			case inString:
+			case r == '/' && strings.HasPrefix(line[j:], "//"):
+				break scan
+			case r == '(':
+				depth++
+				seenParen = true
+			case r == ')':
+				depth--
+				if seenParen && depth == 0 {
+					rest := strings.ToLower(line[j+1:])
+					if k := strings.Index(rest, "//"); k >= 0 {
+						rest = rest[:k]
+					}
+					word := strings.TrimSpace(rest)
+					return strings.HasPrefix(word, "экспорт") || strings.HasPrefix(word, "export")
+				}
+			}
+		}
+	}
+	return false
+}
+
+// passedByName reports whether the module passes name in a string literal, the way
+// notification and idle handlers are referenced: ОписаниеОповещения("Имя", ЭтотОбъект)
+func passedByName(lines []string, name string) bool {
+	quoted := strings.ToLower(`"` + name + `"`)
+	for _, line := range lines {
+		if strings.Contains(strings.ToLower(line), quoted) {
+			return true
+		}
+	}
+	return false
+}
+
+// moduleDisplayName names a module by its owner, e.g. CommonModule.ОбщегоНазначения,
+// Catalog.Товары.ObjectModule or Catalog.Товары.Form.ФормаЭлемента
+func moduleDisplayName(owner metadata.FileOwner) string {
+	switch {
+	case owner.Object == nil:
+		return "Configuration." + owner.Module.Kind
+	case owner.Form != nil:
+		return owner.Object.FullName() + ".Form." + owner.Form.Name
+	case owner.Command != nil:
+		return owner.Object.FullName() + ".Command." + owner.Command.Name
+	case owner.Object.Type == metadata.CommonModule:
+		return owner.Object.FullName()
+	default:
+		return owner.Object.FullName() + "." + owner.Module.Kind
+	}
+}
+
+func hasAnyPrefix(s string, prefixes ...string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,156 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"rockerboo/mcp-lsp-bridge/mocks"
+	"rockerboo/mcp-lsp-bridge/utils"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/mcptest"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/myleshyson/lsprotocol-go/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const deadCodeCommonModule = `Процедура Используемая() Экспорт
+КонецПроцедуры
+
+Функция Неиспользуемая(Параметр = "(") Экспорт // комментарий
+КонецФункции
+
+Процедура Локальная()
+КонецПроцедуры
+
+Процедура ПослеВопроса(Ответ, Параметры)
+КонецПроцедуры
+
+Процедура Спросить() Экспорт
+	Оповещение = Новый ОписаниеОповещения("ПослеВопроса", ЭтотОбъект);
+КонецПроцедуры
+`
+
+// newDeadCodeBridge mocks a dump where Используемая has a caller, Спросить is only
+// referenced and ПередЗаписью in the object module is an event handler
+func newDeadCodeBridge(t *testing.T) (*indexedMockBridge, string) {
+	t.Helper()
+	root := writeDesignerDump(t)
+	commonPath := filepath.Join(root, "CommonModules/ОбщегоНазначения/Ext/Module.bsl")
+	require.NoError(t, os.WriteFile(commonPath, []byte(deadCodeCommonModule), 0o644))
+	objectPath := filepath.Join(root, "Catalogs/Товары/Ext/ObjectModule.bsl")
+	require.NoError(t, os.WriteFile(objectPath, []byte("Процедура ПередЗаписью(Отказ)\nКонецПроцедуры\n"), 0o644))
+	commonURI, objectURI := utils.FilePathToURI(commonPath), utils.FilePathToURI(objectPath)
+
+	b := &indexedMockBridge{
+		MockBridge: &mocks.MockBridge{},
+		outgoing:   map[string][]protocol.CallHierarchyOutgoingCall{},
+		incoming: map[string][]protocol.CallHierarchyIncomingCall{
+			"Используемая": {{From: bslMethod("Вызывающая", 40)}},
+		},
+		dirty: map[string]bool{},
+	}
+
+	symbols := func(uri string, methods map[string]uint32) []protocol.DocumentSymbol {
+		var out []protocol.DocumentSymbol
+		for name, line := range methods {
+			r := protocol.Range{Start: protocol.Position{Line: line}, End: protocol.Position{Line: line + 1}}
+			out = append(out, protocol.DocumentSymbol{Name: name, Kind: protocol.SymbolKindMethod, Range: r,
+				SelectionRange: protocol.Range{Start: protocol.Position{Line: line, Character: 10}}})
+			b.On("PrepareCallHierarchy", uri, line, uint32(10)).Return([]protocol.CallHierarchyItem{
+				{Name: name, Kind: protocol.SymbolKindMethod, Uri: protocol.DocumentUri(uri), Range: r},
+			}, nil)
+		}
+		return out
+	}
+	b.On("GetDocumentSymbols", commonURI).Return(symbols(commonURI, map[string]uint32{
+		"Используемая": 0, "Неиспользуемая": 3, "Локальная": 6, "ПослеВопроса": 9, "Спросить": 12,
+	}), nil)
+	b.On("GetDocumentSymbols", objectURI).Return(symbols(objectURI, map[string]uint32{"ПередЗаписью": 0}), nil)
+	b.On("GetDocumentSymbols", mock.Anything).Return([]protocol.DocumentSymbol{}, nil)
+
+	b.On("FindSymbolReferences", "bsl", commonURI, uint32(12), uint32(10), false).Return([]protocol.Location{
+		{Uri: "file:///projects/Other.bsl", Range: protocol.Range{Start: protocol.Position{Line: 5}}},
+	}, nil)
+	// Some servers return the declaration even without includeDeclaration
+	b.On("FindSymbolReferences", "bsl", commonURI, uint32(3), uint32(10), false).Return([]protocol.Location{
+		{Uri: protocol.DocumentUri(commonURI), Range: protocol.Range{Start: protocol.Position{Line: 3, Character: 10}}},
+	}, nil)
+	b.On("FindSymbolReferences", "bsl", mock.Anything, mock.Anything, mock.Anything, false).Return([]protocol.Location{}, nil)
+	return b, root
+}
+
+func callDeadCode(t *testing.T, bridge *indexedMockBridge, arguments map[string]any) DeadCodeResult {
+	t.Helper()
+
+	tool, handler := DeadCodeTool(bridge)
+	mcpServer, err := mcptest.NewServer(t, server.ServerTool{Tool: tool, Handler: handler})
+	require.NoError(t, err)
+
+	res, err := mcpServer.Client().CallTool(context.Background(), mcp.CallToolRequest{
+		Request: mcp.Request{Method: "tools/call"},
+		Params:  mcp.CallToolParams{Name: "dead_code", Arguments: arguments},
+	})
+	require.NoError(t, err)
+	require.False(t, res.IsError, "%v", res.Content)
+
+	var result DeadCodeResult
+	require.NoError(t, json.Unmarshal([]byte(res.Content[0].(mcp.TextContent).Text), &result))
+	return result
+}
+
+func TestDeadCodeTool_ResumesByCursor(t *testing.T) {
+	bridge, root := newDeadCodeBridge(t)
+	uri := utils.FilePathToURI(root)
+
+	first := callDeadCode(t, bridge, map[string]any{"uri": uri, "modules": 2})
+	assert.Equal(t, 3, first.ModulesTotal)
+	assert.Equal(t, 2, first.ModulesScanned)
+	assert.False(t, first.Done)
+	assert.Equal(t, "CommonModules/ОбщегоНазначения/Ext/Module.bsl", first.NextCursor)
+	assert.Equal(t, 1, first.EntryPoints, "ПередЗаписью is an object module event")
+	assert.Empty(t, first.Modules)
+
+	second := callDeadCode(t, bridge, map[string]any{"uri": uri, "modules": 2, "cursor": first.NextCursor})
+	assert.True(t, second.Done)
+	assert.Equal(t, 1, second.ModulesScanned)
+	assert.Equal(t, 5, second.MethodsChecked)
+	require.Len(t, second.Modules, 1)
+
+	module := second.Modules[0]
+	assert.Equal(t, "CommonModule.ОбщегоНазначения", module.Module)
+	assert.Equal(t, []DeadCodeMethod{{Name: "Неиспользуемая", Kind: "method", Line: 3, Export: true}}, module.UnusedExports)
+	assert.Equal(t, []DeadCodeMethod{{Name: "Локальная", Kind: "method", Line: 6}}, module.UnusedLocals,
+		"ПослеВопроса is passed by name to ОписаниеОповещения")
+}
+
+func TestDeadCodeTool_ExportsOnly(t *testing.T) {
+	bridge, root := newDeadCodeBridge(t)
+
+	result := callDeadCode(t, bridge, map[string]any{
+		"uri":            utils.FilePathToURI(filepath.Join(root, "CommonModules")),
+		"include_locals": false,
+	})
+	assert.True(t, result.Done)
+	assert.Equal(t, 1, result.UnusedExports)
+	assert.Equal(t, 0, result.UnusedLocals)
+}
+
+func TestIsExportDeclaration(t *testing.T) {
+	lines := []string{
+		"&НаСервере",
+		"Функция Получить(Знач А,",
+		`	Б = ")") Экспорт`,
+		"КонецФункции",
+		"Процедура Б() // Экспорт",
+		"Procedure C() Export",
+	}
+	assert.True(t, isExportDeclaration(lines, 0), "the range may start at the directive")
+	assert.False(t, isExportDeclaration(lines, 4))
+	assert.True(t, isExportDeclaration(lines, 5))
+}
@@ -0,0 +1,102 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"rockerboo/mcp-lsp-bridge/interfaces"
+	"rockerboo/mcp-lsp-bridge/logger"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/myleshyson/lsprotocol-go/protocol"
+)
+
+// locationFinder is a bridge request answering with definition-like locations
+type locationFinder func(uri string, line, character uint32) ([]protocol.Or2[protocol.LocationLink, protocol.Location], error)
+
+// DeclarationTool exposes LSP textDocument/declaration for a specific (uri,line,character).
+func DeclarationTool(bridge interfaces.BridgeInterface) (mcp.Tool, server.ToolHandlerFunc) {
+	return mcp.NewTool("declaration",
+		mcp.WithDescription(`Get the declaration of the symbol at a cursor position using LSP textDocument/declaration: where a variable is declared (Перем, parameter, first assignment) rather than where its value comes from.
+
+USAGE:
+- declaration: uri="file://path", line=15, character=10
+
+PARAMETERS: uri (required), line/character (required, 0-based)
+OUTPUT: One or more target locations (file + range) suitable for get_range_content/navigation`),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithString("uri", mcp.Description("URI to the file"), mcp.Required()),
+		mcp.WithNumber("line", mcp.Description("Line number (0-based)"), mcp.Required(), mcp.Min(0)),
+		mcp.WithNumber("character", mcp.Description("Character position (0-based)"), mcp.Required(), mcp.Min(0)),
+	), locationHandler(bridge, "declaration", "DECLARATION", "declarations", bridge.FindDeclarations)
+}
+
+// TypeDefinitionTool exposes LSP textDocument/typeDefinition for a specific (uri,line,character).
+func TypeDefinitionTool(bridge interfaces.BridgeInterface) (mcp.Tool, server.ToolHandlerFunc) {
+	return mcp.NewTool("type_definition",
+		mcp.WithDescription(`Get the definition of the type of the symbol at a cursor position using LSP textDocument/typeDefinition, e.g. the metadata object behind a variable holding a reference.
+
+USAGE:
+- type_definition: uri="file://path", line=15, character=10
+
+PARAMETERS: uri (required), line/character (required, 0-based)
+OUTPUT: One or more target locations (file + range) suitable for get_range_content/navigation`),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithString("uri", mcp.Description("URI to the file"), mcp.Required()),
+		mcp.WithNumber("line", mcp.Description("Line number (0-based)"), mcp.Required(), mcp.Min(0)),
+		mcp.WithNumber("character", mcp.Description("Character position (0-based)"), mcp.Required(), mcp.Min(0)),
+	), locationHandler(bridge, "type_definition", "TYPE DEFINITION", "type definitions", bridge.FindTypeDefinitions)
+}
+
+// locationHandler parses the position, calls find and lists the locations found
+func locationHandler(bridge interfaces.BridgeInterface, name, header, noun string, find locationFinder) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		bridge := bridge.WithContext(ctx)
+
+		uri, err := request.RequireString("uri")
+		if err != nil {
+			logger.Error(name+": URI parsing failed", err)
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		line, err := request.RequireInt("line")
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		character, err := request.RequireInt("character")
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		lineU, err := safeUint32(line)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Invalid line number: %v", err)), nil
+		}
+		charU, err := safeUint32(character)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Invalid character position: %v", err)), nil
+		}
+
+		if result, ok := CheckReadyOrReturn(bridge); !ok {
+			return result, nil
+		}
+
+		locations, err := find(uri, lineU, charU)
+		if err != nil {
+			logger.Error(name+": request failed", fmt.Sprintf("URI: %s, Line: %d, Character: %d, Error: %v", uri, line, character, err))
+			return mcp.NewToolResultError(fmt.Sprintf("%s request failed: %v", name, err)), nil
+		}
+
+		return mcp.NewToolResultText(formatLocationLinks(header, noun, locations)), nil
+	}
+}
+
+// RegisterDeclarationTool registers the declaration tool with the MCP server.
+func RegisterDeclarationTool(mcpServer ToolServer, bridge interfaces.BridgeInterface) {
+	mcpServer.AddTool(DeclarationTool(bridge))
+}
+
+// RegisterTypeDefinitionTool registers the type_definition tool with the MCP server.
+func RegisterTypeDefinitionTool(mcpServer ToolServer, bridge interfaces.BridgeInterface) {
+	mcpServer.AddTool(TypeDefinitionTool(bridge))
+}
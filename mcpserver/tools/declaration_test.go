@@ -0,0 +1,75 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"rockerboo/mcp-lsp-bridge/mocks"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/mcptest"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/myleshyson/lsprotocol-go/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func callLocationTool(t *testing.T, tool mcp.Tool, handler server.ToolHandlerFunc, arguments map[string]any) *mcp.CallToolResult {
+	t.Helper()
+
+	mcpServer, err := mcptest.NewServer(t, server.ServerTool{Tool: tool, Handler: handler})
+	require.NoError(t, err)
+
+	result, err := mcpServer.Client().CallTool(context.Background(), mcp.CallToolRequest{
+		Request: mcp.Request{Method: "tools/call"},
+		Params:  mcp.CallToolParams{Name: tool.Name, Arguments: arguments},
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, result.Content)
+	return result
+}
+
+func TestDeclarationTool(t *testing.T) {
+	uri := "file:///projects/Module.bsl"
+	bridge := &mocks.MockBridge{}
+	bridge.On("FindDeclarations", uri, uint32(12), uint32(4)).Return([]protocol.Or2[protocol.LocationLink, protocol.Location]{{
+		Value: protocol.Location{Uri: protocol.DocumentUri(uri), Range: protocol.Range{
+			Start: protocol.Position{Line: 2, Character: 6}, End: protocol.Position{Line: 2, Character: 13},
+		}},
+	}}, nil)
+
+	tool, handler := DeclarationTool(bridge)
+	result := callLocationTool(t, tool, handler, map[string]any{"uri": uri, "line": 12, "character": 4})
+	require.False(t, result.IsError, result.Content)
+
+	text := result.Content[0].(mcp.TextContent).Text
+	assert.Contains(t, text, "DECLARATION:")
+	assert.Contains(t, text, "Count: 1")
+	assert.Contains(t, text, uri)
+}
+
+func TestTypeDefinitionTool(t *testing.T) {
+	uri := "file:///projects/Module.bsl"
+
+	t.Run("none found", func(t *testing.T) {
+		bridge := &mocks.MockBridge{}
+		bridge.On("FindTypeDefinitions", uri, uint32(1), uint32(2)).Return([]protocol.Or2[protocol.LocationLink, protocol.Location]{}, nil)
+
+		tool, handler := TypeDefinitionTool(bridge)
+		result := callLocationTool(t, tool, handler, map[string]any{"uri": uri, "line": 1, "character": 2})
+		require.False(t, result.IsError, result.Content)
+		assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "No type definitions found.")
+	})
+
+	t.Run("request error", func(t *testing.T) {
+		bridge := &mocks.MockBridge{}
+		bridge.On("FindTypeDefinitions", uri, uint32(1), uint32(2)).
+			Return([]protocol.Or2[protocol.LocationLink, protocol.Location](nil), errors.New("method not supported"))
+
+		tool, handler := TypeDefinitionTool(bridge)
+		result := callLocationTool(t, tool, handler, map[string]any{"uri": uri, "line": 1, "character": 2})
+		assert.True(t, result.IsError)
+		assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "method not supported")
+	})
+}
@@ -33,6 +33,8 @@ OUTPUT: One or more target locations (file + range) suitable for get_range_conte
 			mcp.WithNumber("character", mcp.Description("Character position (0-based)"), mcp.Required(), mcp.Min(0)),
 			mcp.WithString("language", mcp.Description("Optional language override (e.g., bsl)")),
 		), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			bridge := bridge.WithContext(ctx)
+
 			uri, err := request.RequireString("uri")
 			if err != nil {
 				logger.Error("definition: URI parsing failed", err)
@@ -95,12 +97,18 @@ func RegisterDefinitionTool(mcpServer ToolServer, bridge interfaces.BridgeInterf
 }
 
 func formatDefinitions(defs []protocol.Or2[protocol.LocationLink, protocol.Location]) string {
+	return formatLocationLinks("DEFINITION", "definitions", defs)
+}
+
+// formatLocationLinks lists the first 20 targets of a definition-like request
+// under the given header
+func formatLocationLinks(header, noun string, defs []protocol.Or2[protocol.LocationLink, protocol.Location]) string {
 	if len(defs) == 0 {
-		return "DEFINITION:\nNo definitions found."
+		return fmt.Sprintf("%s:\nNo %s found.", header, noun)
 	}
 
 	var b strings.Builder
-	b.WriteString("DEFINITION:\n")
+	b.WriteString(header + ":\n")
 	fmt.Fprintf(&b, "Count: %d\n\n", len(defs))
 
 	limit := len(defs)
@@ -130,7 +138,7 @@ func formatDefinitions(defs []protocol.Or2[protocol.LocationLink, protocol.Locat
 			)
 			fmt.Fprintf(&b, "   URI: %s\n", u)
 		default:
-			fmt.Fprintf(&b, "%d. Unsupported definition type: %T\n", i+1, def.Value)
+			fmt.Fprintf(&b, "%d. Unsupported location type: %T\n", i+1, def.Value)
 		}
 	}
 
@@ -23,6 +23,8 @@ func ProjectLanguageDetectionTool(bridge interfaces.BridgeInterface) (mcp.Tool,
 			mcp.WithString("project_path", mcp.Description("Path to the project directory to analyze")),
 			mcp.WithString("mode", mcp.Description("Detection mode: 'all' for all languages, 'primary' for primary language only (default: 'all')")),
 		), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			bridge := bridge.WithContext(ctx)
+
 			projectPath, err := request.RequireString("project_path")
 			if err != nil {
 				logger.Error("detect_project_languages: Project path parsing failed", err)
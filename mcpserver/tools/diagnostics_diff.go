@@ -0,0 +1,480 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"rockerboo/mcp-lsp-bridge/async"
+	"rockerboo/mcp-lsp-bridge/interfaces"
+	"rockerboo/mcp-lsp-bridge/logger"
+	"rockerboo/mcp-lsp-bridge/lsp"
+	"rockerboo/mcp-lsp-bridge/report"
+	"rockerboo/mcp-lsp-bridge/utils"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/myleshyson/lsprotocol-go/protocol"
+)
+
+const (
+	// DiagnosticsDiffTimeout bounds checkout and diagnostics of both revisions
+	DiagnosticsDiffTimeout = 15 * time.Minute
+	// DefaultDiagnosticsDiffFiles is the number of changed files checked per call
+	DefaultDiagnosticsDiffFiles = 100
+	// MaxDiagnosticsDiffFiles caps the number of changed files checked
+	MaxDiagnosticsDiffFiles = 1000
+)
+
+// documentDiagnosticsProvider is implemented by bridges that pull textDocument/diagnostic
+type documentDiagnosticsProvider interface {
+	GetDocumentDiagnostics(uri string, identifier string, previousResultId string) (*protocol.DocumentDiagnosticReport, error)
+}
+
+// documentCloser is implemented by bridges that can send textDocument/didClose
+type documentCloser interface {
+	CloseDocument(uri string) error
+}
+
+// DiagnosticsDiffOptions selects the revisions and files diagnostics_diff compares
+type DiagnosticsDiffOptions struct {
+	Repo     string                // directory inside the git repository; limits the changed files
+	Base     string                // revision the branch starts from, e.g. main
+	Head     string                // revision under review (default HEAD)
+	Path     string                // glob over changed file paths, see utils.MatchGlob
+	Filter   lsp.DiagnosticsFilter // severity and code filters
+	MaxFiles int
+}
+
+// DiagnosticsDiffResult lists the diagnostics Head added and removed compared to Base.
+// Findings are matched by fingerprint, so diagnostics that only moved are unchanged.
+type DiagnosticsDiffResult struct {
+	Base         string           `json:"base"`
+	Head         string           `json:"head"`
+	BaseCommit   string           `json:"base_commit"`
+	HeadCommit   string           `json:"head_commit"`
+	ChangedFiles int              `json:"changed_files"`
+	FilesChecked int              `json:"files_checked"`
+	Truncated    bool             `json:"truncated,omitempty"`
+	Added        []report.Finding `json:"added"`
+	Removed      []report.Finding `json:"removed"`
+	Unchanged    int              `json:"unchanged"`
+	Errors       []string         `json:"errors,omitempty"`
+	ElapsedMs    int64            `json:"elapsed_ms"`
+}
+
+// RegisterDiagnosticsDiffTool registers the diagnostics_diff tool
+func RegisterDiagnosticsDiffTool(mcpServer ToolServer, bridge interfaces.BridgeInterface) {
+	mcpServer.AddTool(DiagnosticsDiffTool(bridge))
+}
+
+func DiagnosticsDiffTool(bridge interfaces.BridgeInterface) (mcp.Tool, server.ToolHandlerFunc) {
+	return mcp.NewTool("diagnostics_diff",
+			mcp.WithDescription(`Compare diagnostics of two git revisions: which warnings and errors a branch introduced or fixed.
+
+Both revisions are checked out into temporary git worktrees, and document diagnostics are pulled
+for the .bsl files that differ between them. Diagnostics are matched by fingerprint (code,
+normalized message and the flagged source line), so diagnostics that only moved count as unchanged.
+
+USAGE:
+- Review a branch: base="main"
+- Compare two commits: base="a1b2c3d", head="feature/x"
+- Only errors in common modules: base="main", severity="error", path="CommonModules/**"
+
+Parameters:
+- base: revision to compare against (required)
+- head: revision under review (default: HEAD)
+- repo: directory inside the repository (default: the project root); only files under it are compared
+- severity, code, path: filters as in workspace_diagnostics
+- max_files: changed files to check (default: 100, max 1000)
+- format: text (default) or json`),
+			mcp.WithDestructiveHintAnnotation(false),
+			mcp.WithString("base", mcp.Description("Base revision (branch, tag or commit)"), mcp.Required()),
+			mcp.WithString("head", mcp.Description("Head revision (default: HEAD)")),
+			mcp.WithString("repo", mcp.Description("Directory inside the git repository (optional, defaults to the project root)")),
+			mcp.WithString("severity", mcp.Description("Minimum severity to include: error, warning, information or hint")),
+			mcp.WithString("code", mcp.Description("Comma-separated diagnostic codes to include")),
+			mcp.WithString("path", mcp.Description("Glob over changed file paths to include")),
+			mcp.WithNumber("max_files", mcp.Description("Changed files to check (default: 100, max 1000)"), mcp.Min(1), mcp.Max(MaxDiagnosticsDiffFiles)),
+			mcp.WithString("format", mcp.Description("Output format: text or json (default: text)"), mcp.Enum("text", "json")),
+		), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			bridge := bridge.WithContext(ctx)
+
+			base, err := request.RequireString("base")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			repo := request.GetString("repo", "")
+			if repo == "" {
+				dirs := bridge.AllowedDirectories()
+				if len(dirs) == 0 {
+					return mcp.NewToolResultError("repo is required: no allowed directories configured"), nil
+				}
+				repo = dirs[0]
+			}
+
+			filter, err := ParseDiagnosticsFilter(request.GetString("severity", ""), request.GetString("code", ""), "", "")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			format := request.GetString("format", "text")
+			if format != "text" && format != "json" {
+				return mcp.NewToolResultError(fmt.Sprintf("invalid format %q: expected text or json", format)), nil
+			}
+
+			if result, ok := CheckReadyOrReturn(bridge); !ok {
+				return result, nil
+			}
+
+			result, err := DiffDiagnostics(ctx, bridge, DiagnosticsDiffOptions{
+				Repo:     utils.URIToFilePath(bridge.NormalizeURIForLSP(repo)),
+				Base:     base,
+				Head:     request.GetString("head", "HEAD"),
+				Path:     request.GetString("path", ""),
+				Filter:   filter,
+				MaxFiles: request.GetInt("max_files", DefaultDiagnosticsDiffFiles),
+			})
+			if err != nil {
+				logger.Error("diagnostics_diff: failed", err)
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			if format == "json" {
+				payload, err := json.MarshalIndent(result, "", "  ")
+				if err != nil {
+					return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal result: %v", err)), nil
+				}
+				return mcp.NewToolResultText(string(payload)), nil
+			}
+			return mcp.NewToolResultText(FormatDiagnosticsDiff(result)), nil
+		}
+}
+
+// DiffDiagnostics checks out both revisions into temporary worktrees inside the git
+// directory, pulls document diagnostics for the changed .bsl files on each side and
+// matches them by fingerprint. The worktrees are removed before it returns.
+func DiffDiagnostics(ctx context.Context, bridge interfaces.BridgeInterface, opts DiagnosticsDiffOptions) (*DiagnosticsDiffResult, error) {
+	startTime := time.Now()
+	ctx, cancel := context.WithTimeout(ctx, DiagnosticsDiffTimeout)
+	defer cancel()
+
+	provider, ok := bridge.(documentDiagnosticsProvider)
+	if !ok {
+		return nil, errors.New("document diagnostics not supported by this bridge implementation")
+	}
+	if opts.Head == "" {
+		opts.Head = "HEAD"
+	}
+	if opts.MaxFiles <= 0 {
+		opts.MaxFiles = DefaultDiagnosticsDiffFiles
+	}
+	opts.MaxFiles = min(opts.MaxFiles, MaxDiagnosticsDiffFiles)
+
+	result := &DiagnosticsDiffResult{Base: opts.Base, Head: opts.Head, Added: []report.Finding{}, Removed: []report.Finding{}}
+	var err error
+	if result.BaseCommit, err = resolveCommit(ctx, opts.Repo, opts.Base); err != nil {
+		return nil, err
+	}
+	if result.HeadCommit, err = resolveCommit(ctx, opts.Repo, opts.Head); err != nil {
+		return nil, err
+	}
+
+	files, err := changedBSLFiles(ctx, opts.Repo, result.BaseCommit, result.HeadCommit, opts.Path)
+	if err != nil {
+		return nil, err
+	}
+	result.ChangedFiles = len(files)
+	if len(files) > opts.MaxFiles {
+		files, result.Truncated = files[:opts.MaxFiles], true
+	}
+	if len(files) == 0 {
+		result.ElapsedMs = time.Since(startTime).Milliseconds()
+		return result, nil
+	}
+
+	worktrees, cleanup, err := addDiffWorktrees(ctx, opts.Repo, result.BaseCommit, result.HeadCommit)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	type fileDiagnostics struct {
+		base, head []lsp.PublishedDiagnostics
+	}
+	ops := make([]func() (fileDiagnostics, error), 0, len(files))
+	semaphore := make(chan struct{}, 5) // keep the language server responsive
+	for _, file := range files {
+		ops = append(ops, func() (fileDiagnostics, error) {
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			var fd fileDiagnostics
+			var err error
+			if fd.base, err = worktreeDiagnostics(bridge, provider, worktrees[0], file, opts.Filter); err != nil {
+				return fd, fmt.Errorf("%s@%s: %w", file, opts.Base, err)
+			}
+			if fd.head, err = worktreeDiagnostics(bridge, provider, worktrees[1], file, opts.Filter); err != nil {
+				return fd, fmt.Errorf("%s@%s: %w", file, opts.Head, err)
+			}
+			return fd, nil
+		})
+	}
+	results, err := async.Map(ctx, ops)
+	if err != nil {
+		return nil, fmt.Errorf("diagnostics diff stopped before all files were checked: %w", err)
+	}
+
+	var baseDocs, headDocs []lsp.PublishedDiagnostics
+	for _, r := range results {
+		if r.Error != nil {
+			result.Errors = append(result.Errors, r.Error.Error())
+			continue
+		}
+		result.FilesChecked++
+		baseDocs = append(baseDocs, r.Value.base...)
+		headDocs = append(headDocs, r.Value.head...)
+	}
+	sort.Strings(result.Errors)
+
+	result.Added, result.Removed, result.Unchanged = matchFindings(
+		report.NewFindings(worktrees[0], baseDocs),
+		report.NewFindings(worktrees[1], headDocs),
+	)
+	result.ElapsedMs = time.Since(startTime).Milliseconds()
+	return result, nil
+}
+
+// matchFindings pairs base and head findings with equal fingerprints; every
+// occurrence is matched once, so a duplicated diagnostic is still added
+func matchFindings(base, head []report.Finding) (added, removed []report.Finding, unchanged int) {
+	remaining := make(map[string][]int)
+	for i, f := range base {
+		remaining[f.Fingerprint] = append(remaining[f.Fingerprint], i)
+	}
+
+	matched := make([]bool, len(base))
+	added, removed = []report.Finding{}, []report.Finding{}
+	for _, f := range head {
+		if indexes := remaining[f.Fingerprint]; len(indexes) > 0 {
+			matched[indexes[0]] = true
+			remaining[f.Fingerprint] = indexes[1:]
+			unchanged++
+			continue
+		}
+		f.New = true
+		added = append(added, f)
+	}
+	for i, f := range base {
+		if !matched[i] {
+			removed = append(removed, f)
+		}
+	}
+	return added, removed, unchanged
+}
+
+// worktreeDiagnostics pulls the diagnostics of one file in a worktree. Files missing
+// on that side (added or deleted by the change) have none.
+func worktreeDiagnostics(bridge interfaces.BridgeInterface, provider documentDiagnosticsProvider, worktree, file string, filter lsp.DiagnosticsFilter) ([]lsp.PublishedDiagnostics, error) {
+	path := filepath.Join(worktree, filepath.FromSlash(file))
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	uri := utils.FilePathToURI(path)
+
+	diagnosticReport, err := provider.GetDocumentDiagnostics(uri, "", "")
+	if closer, ok := bridge.(documentCloser); ok {
+		if closeErr := closer.CloseDocument(uri); closeErr != nil {
+			logger.Warn(fmt.Sprintf("diagnostics_diff: failed to close %s: %v", uri, closeErr))
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := documentReportDiagnostics(diagnosticReport)
+	if err != nil {
+		return nil, err
+	}
+	doc := lsp.PublishedDiagnostics{URI: uri}
+	for _, d := range items {
+		if filter.MatchesDiagnostic(d) {
+			doc.Diagnostics = append(doc.Diagnostics, d)
+		}
+	}
+	return []lsp.PublishedDiagnostics{doc}, nil
+}
+
+// documentReportDiagnostics returns the items of a full document diagnostic report
+func documentReportDiagnostics(diagnosticReport *protocol.DocumentDiagnosticReport) ([]protocol.Diagnostic, error) {
+	if diagnosticReport == nil {
+		return nil, errors.New("no diagnostic report available")
+	}
+
+	switch v := diagnosticReport.Value.(type) {
+	case protocol.RelatedFullDocumentDiagnosticReport:
+		return v.Items, nil
+	case *protocol.RelatedFullDocumentDiagnosticReport:
+		return v.Items, nil
+	case protocol.RelatedUnchangedDocumentDiagnosticReport, *protocol.RelatedUnchangedDocumentDiagnosticReport:
+		return nil, errors.New("server reported unchanged diagnostics without a previous result")
+	}
+
+	// Some servers leave the union undecoded, see formatDocumentDiagnostics
+	data, err := json.Marshal(diagnosticReport)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse diagnostic report: %w", err)
+	}
+	var full protocol.RelatedFullDocumentDiagnosticReport
+	if err := json.Unmarshal(data, &full); err != nil {
+		return nil, fmt.Errorf("failed to parse diagnostic report: %w", err)
+	}
+	return full.Items, nil
+}
+
+// runGit runs git in dir and returns its trimmed standard output
+func runGit(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", dir}, args...)...) // #nosec G204 -- fixed binary; revisions are checked by resolveCommit
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// resolveCommit turns a branch, tag or abbreviated hash into a full commit hash
+func resolveCommit(ctx context.Context, repo, revision string) (string, error) {
+	if revision == "" || strings.HasPrefix(revision, "-") {
+		return "", fmt.Errorf("invalid revision %q", revision)
+	}
+	commit, err := runGit(ctx, repo, "rev-parse", "--verify", "--quiet", revision+"^{commit}")
+	if err != nil {
+		return "", fmt.Errorf("unknown revision %q in %s", revision, repo)
+	}
+	return commit, nil
+}
+
+// changedBSLFiles lists .bsl files under repo that differ between the commits, as
+// slash paths relative to the repository root
+func changedBSLFiles(ctx context.Context, repo, base, head, glob string) ([]string, error) {
+	out, err := runGit(ctx, repo, "diff", "--name-only", "--no-renames", "-z", base, head, "--", "*.bsl")
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, file := range strings.Split(out, "\x00") {
+		if file == "" || (glob != "" && !utils.MatchGlob(glob, file)) {
+			continue
+		}
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// addDiffWorktrees checks out base and head into a temporary directory under the git
+// directory, which the language server can read whenever it can read the repository.
+// cleanup removes both worktrees.
+func addDiffWorktrees(ctx context.Context, repo, base, head string) ([2]string, func(), error) {
+	var worktrees [2]string
+
+	commonDir, err := runGit(ctx, repo, "rev-parse", "--git-common-dir")
+	if err != nil {
+		return worktrees, nil, err
+	}
+	if !filepath.IsAbs(commonDir) {
+		commonDir = filepath.Join(repo, commonDir)
+	}
+
+	parent := filepath.Join(commonDir, "mcp-lsp-bridge")
+	if err := os.MkdirAll(parent, 0o750); err != nil {
+		return worktrees, nil, err
+	}
+	tmp, err := os.MkdirTemp(parent, "diff-")
+	if err != nil {
+		return worktrees, nil, err
+	}
+
+	cleanup := func() {
+		// The request context may be gone already; removing worktrees must still happen
+		cleanupCtx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		for _, wt := range worktrees {
+			if wt == "" {
+				continue
+			}
+			if _, err := runGit(cleanupCtx, repo, "worktree", "remove", "--force", wt); err != nil {
+				logger.Warn(fmt.Sprintf("diagnostics_diff: failed to remove worktree %s: %v", wt, err))
+			}
+		}
+		if err := os.RemoveAll(tmp); err != nil {
+			logger.Warn(fmt.Sprintf("diagnostics_diff: failed to remove %s: %v", tmp, err))
+		}
+		_, _ = runGit(cleanupCtx, repo, "worktree", "prune")
+	}
+
+	for i, commit := range []string{base, head} {
+		wt := filepath.Join(tmp, []string{"base", "head"}[i])
+		if _, err := runGit(ctx, repo, "worktree", "add", "--detach", "--quiet", wt, commit); err != nil {
+			cleanup()
+			return worktrees, nil, err
+		}
+		worktrees[i] = wt
+	}
+	return worktrees, cleanup, nil
+}
+
+// FormatDiagnosticsDiff renders a diff with the document diagnostics formatting
+func FormatDiagnosticsDiff(result *DiagnosticsDiffResult) string {
+	var out strings.Builder
+
+	fmt.Fprintf(&out, "DIAGNOSTICS DIFF: %s..%s (%.8s..%.8s)\n", result.Base, result.Head, result.BaseCommit, result.HeadCommit)
+	fmt.Fprintf(&out, "Changed .bsl files: %d, checked: %d\n", result.ChangedFiles, result.FilesChecked)
+	if result.Truncated {
+		out.WriteString("NOTE: more files changed than max_files; the rest were not checked\n")
+	}
+	fmt.Fprintf(&out, "Added: %d, removed: %d, unchanged: %d\n\n", len(result.Added), len(result.Removed), result.Unchanged)
+
+	writeSection := func(title string, findings []report.Finding) {
+		if len(findings) == 0 {
+			return
+		}
+		fmt.Fprintf(&out, "%s (%d):\n", title, len(findings))
+		out.WriteString(strings.Repeat("=", 50) + "\n")
+		for i, f := range findings {
+			if i == 0 || findings[i-1].Path != f.Path {
+				fmt.Fprintf(&out, "\n%s\n", f.Path)
+			}
+			out.WriteString(formatEnhancedDiagnostic(i+1, f.Diagnostic, strings.ToUpper(f.Severity)))
+		}
+		out.WriteString("\n")
+	}
+	writeSection("ADDED", result.Added)
+	writeSection("REMOVED", result.Removed)
+
+	if len(result.Added) == 0 && len(result.Removed) == 0 {
+		out.WriteString("No diagnostics were added or removed.\n")
+	}
+	if len(result.Errors) > 0 {
+		fmt.Fprintf(&out, "\nERRORS (%d):\n", len(result.Errors))
+		for _, e := range result.Errors {
+			fmt.Fprintf(&out, "- %s\n", e)
+		}
+	}
+	return out.String()
+}
@@ -0,0 +1,168 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"rockerboo/mcp-lsp-bridge/interfaces"
+	"rockerboo/mcp-lsp-bridge/mocks"
+	"rockerboo/mcp-lsp-bridge/report"
+	"rockerboo/mcp-lsp-bridge/utils"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/mcptest"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/myleshyson/lsprotocol-go/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// lintingMockBridge reports a warning for every line with a double semicolon
+type lintingMockBridge struct {
+	*mocks.MockBridge
+	mu     sync.Mutex
+	closed []string
+}
+
+func (b *lintingMockBridge) WithContext(ctx context.Context) interfaces.BridgeInterface {
+	return b
+}
+
+func (b *lintingMockBridge) GetDocumentDiagnostics(uri, identifier, previousResultId string) (*protocol.DocumentDiagnosticReport, error) {
+	data, err := os.ReadFile(utils.URIToFilePath(uri))
+	if err != nil {
+		return nil, err
+	}
+
+	warning := protocol.DiagnosticSeverityWarning
+	items := []protocol.Diagnostic{}
+	for i, line := range strings.Split(string(data), "\n") {
+		if strings.Contains(line, ";;") {
+			items = append(items, protocol.Diagnostic{
+				Range:    protocol.Range{Start: protocol.Position{Line: uint32(i)}, End: protocol.Position{Line: uint32(i), Character: 5}},
+				Severity: &warning,
+				Code:     &protocol.Or2[int32, string]{Value: "SemicolonPresence"},
+				Message:  "Лишняя точка с запятой",
+			})
+		}
+	}
+	return &protocol.DocumentDiagnosticReport{Value: protocol.RelatedFullDocumentDiagnosticReport{Kind: "full", Items: items}}, nil
+}
+
+func (b *lintingMockBridge) CloseDocument(uri string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = append(b.closed, uri)
+	return nil
+}
+
+// newDiffRepo commits a base version of two modules and a branch that moves one
+// warning, fixes one and adds one
+func newDiffRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	repo := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", repo, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, "%s", out)
+	}
+	write := func(path, content string) {
+		t.Helper()
+		full := filepath.Join(repo, path)
+		require.NoError(t, os.MkdirAll(filepath.Dir(full), 0o755))
+		require.NoError(t, os.WriteFile(full, []byte(content), 0o644))
+	}
+
+	git("init", "--quiet", "--initial-branch=main")
+	write("CommonModules/А/Ext/Module.bsl", "Процедура А()\n\tА = 1;;\n\tБ = 2;;\nКонецПроцедуры\n")
+	write("CommonModules/Б/Ext/Module.bsl", "Процедура Б()\nКонецПроцедуры\n")
+	write("README.md", "docs;;\n")
+	git("add", "-A")
+	git("commit", "--quiet", "-m", "base")
+
+	git("checkout", "--quiet", "-b", "feature")
+	write("CommonModules/А/Ext/Module.bsl", "// Сдвиг\nПроцедура А()\n\tА = 1;;\n\tБ = 2;\nКонецПроцедуры\n")
+	write("CommonModules/В/Ext/Module.bsl", "Процедура В()\n\tВ = 3;;\nКонецПроцедуры\n")
+	write("README.md", "more docs;;\n")
+	git("add", "-A")
+	git("commit", "--quiet", "-m", "feature")
+	return repo
+}
+
+func TestDiffDiagnostics(t *testing.T) {
+	repo := newDiffRepo(t)
+	bridge := &lintingMockBridge{MockBridge: &mocks.MockBridge{}}
+
+	result, err := DiffDiagnostics(context.Background(), bridge, DiagnosticsDiffOptions{Repo: repo, Base: "main", Head: "feature"})
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, result.ChangedFiles, "README.md is not a module")
+	assert.Equal(t, 2, result.FilesChecked)
+	assert.Equal(t, 1, result.Unchanged, "the moved warning is matched by fingerprint")
+	require.Len(t, result.Added, 1)
+	assert.Equal(t, "CommonModules/В/Ext/Module.bsl", result.Added[0].Path)
+	assert.Equal(t, 2, result.Added[0].Line)
+	require.Len(t, result.Removed, 1)
+	assert.Equal(t, "CommonModules/А/Ext/Module.bsl", result.Removed[0].Path)
+	assert.Equal(t, 3, result.Removed[0].Line)
+	assert.Len(t, bridge.closed, 3, "every opened worktree document is closed")
+
+	entries, err := os.ReadDir(filepath.Join(repo, ".git", "mcp-lsp-bridge"))
+	require.NoError(t, err)
+	assert.Empty(t, entries, "worktrees are removed")
+	out, err := exec.Command("git", "-C", repo, "worktree", "list").Output()
+	require.NoError(t, err)
+	assert.Equal(t, 1, strings.Count(string(out), "\n"))
+
+	text := FormatDiagnosticsDiff(result)
+	assert.Contains(t, text, "Added: 1, removed: 1, unchanged: 1")
+	assert.Contains(t, text, "ADDED (1):")
+	assert.Contains(t, text, "CommonModules/В/Ext/Module.bsl\n1. Лишняя точка с запятой\n   Location: Line 2")
+
+	_, err = DiffDiagnostics(context.Background(), bridge, DiagnosticsDiffOptions{Repo: repo, Base: "--output=/tmp/x"})
+	assert.ErrorContains(t, err, "invalid revision")
+}
+
+func TestDiagnosticsDiffTool_Filters(t *testing.T) {
+	repo := newDiffRepo(t)
+	bridge := &lintingMockBridge{MockBridge: &mocks.MockBridge{}}
+
+	tool, handler := DiagnosticsDiffTool(bridge)
+	mcpServer, err := mcptest.NewServer(t, server.ServerTool{Tool: tool, Handler: handler})
+	require.NoError(t, err)
+
+	res, err := mcpServer.Client().CallTool(context.Background(), mcp.CallToolRequest{
+		Request: mcp.Request{Method: "tools/call"},
+		Params: mcp.CallToolParams{Name: "diagnostics_diff", Arguments: map[string]any{
+			"repo": utils.FilePathToURI(repo), "base": "main", "head": "feature",
+			"path": "CommonModules/В/**", "severity": "error",
+		}},
+	})
+	require.NoError(t, err)
+	require.False(t, res.IsError, "%v", res.Content)
+
+	text := res.Content[0].(mcp.TextContent).Text
+	assert.Contains(t, text, "Changed .bsl files: 1, checked: 1")
+	assert.Contains(t, text, "No diagnostics were added or removed.", "warnings are below the severity filter")
+}
+
+func TestMatchFindings_Duplicates(t *testing.T) {
+	base := []report.Finding{{Path: "a.bsl", Line: 1, Fingerprint: "x"}}
+	head := []report.Finding{{Path: "a.bsl", Line: 1, Fingerprint: "x"}, {Path: "a.bsl", Line: 9, Fingerprint: "x"}}
+
+	added, removed, unchanged := matchFindings(base, head)
+	require.Len(t, added, 1, "a copied diagnostic is added")
+	assert.Equal(t, 9, added[0].Line)
+	assert.True(t, added[0].New)
+	assert.Empty(t, removed)
+	assert.Equal(t, 1, unchanged)
+}
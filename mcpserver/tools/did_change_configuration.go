@@ -19,6 +19,8 @@ func DidChangeConfigurationTool(bridge interfaces.BridgeInterface) (mcp.Tool, se
 			mcp.WithString("language", mcp.Description("Language server ID (e.g., 'bsl')."), mcp.Required()),
 			mcp.WithString("settings_json", mcp.Description("JSON object with configuration settings to pass to the server.")),
 		), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			bridge := bridge.WithContext(ctx)
+
 			language, err := request.RequireString("language")
 			if err != nil {
 				logger.Error("did_change_configuration: language parsing failed", err)
@@ -20,6 +20,8 @@ func DidChangeWatchedFilesTool(bridge interfaces.BridgeInterface) (mcp.Tool, ser
 			mcp.WithString("language", mcp.Description("Language server ID (e.g., 'bsl')."), mcp.Required()),
 			mcp.WithString("changes_json", mcp.Description("JSON array of file events: [{\"uri\":\"file:///path\",\"type\":1}]. type: 1=Created, 2=Changed, 3=Deleted."), mcp.Required()),
 		), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			bridge := bridge.WithContext(ctx)
+
 			language, err := request.RequireString("language")
 			if err != nil {
 				logger.Error("did_change_watched_files: language parsing failed", err)
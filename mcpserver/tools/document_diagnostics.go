@@ -29,6 +29,8 @@ OUTPUT: Full document diagnostic report with items, related documents, and resul
 			mcp.WithString("identifier", mcp.Description("Optional identifier for the diagnostic request")),
 			mcp.WithString("previous_result_id", mcp.Description("Optional result ID from previous diagnostic request for caching")),
 		), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			bridge := bridge.WithContext(ctx)
+
 			// Parse and validate parameters
 			uri, err := request.RequireString("uri")
 			if err != nil {
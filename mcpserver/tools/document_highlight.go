@@ -0,0 +1,150 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"rockerboo/mcp-lsp-bridge/interfaces"
+	"rockerboo/mcp-lsp-bridge/logger"
+	"rockerboo/mcp-lsp-bridge/utils"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/myleshyson/lsprotocol-go/protocol"
+)
+
+// highlightPreviewLimit caps the source line shown per occurrence, in runes
+const highlightPreviewLimit = 160
+
+func highlightKindToString(kind *protocol.DocumentHighlightKind) string {
+	if kind == nil {
+		return "text"
+	}
+	switch *kind {
+	case protocol.DocumentHighlightKindRead:
+		return "read"
+	case protocol.DocumentHighlightKindWrite:
+		return "write"
+	}
+	return "text"
+}
+
+// RegisterDocumentHighlightTool registers the document_highlight tool with the MCP server.
+func RegisterDocumentHighlightTool(mcpServer ToolServer, bridge interfaces.BridgeInterface) {
+	mcpServer.AddTool(DocumentHighlightTool(bridge))
+}
+
+func DocumentHighlightTool(bridge interfaces.BridgeInterface) (mcp.Tool, server.ToolHandlerFunc) {
+	return mcp.NewTool("document_highlight",
+			mcp.WithDescription(`List the occurrences of the symbol at a cursor position within its module using LSP textDocument/documentHighlight, each marked as a write (assignment) or a read where the server tells them apart. Answers "where is this local variable assigned" without reading the whole module.
+
+USAGE:
+- All occurrences: uri="file://path/Module.bsl", line=42, character=8
+- Assignments only: uri="file://path", line=42, character=8, kind="write"
+
+PARAMETERS: uri (required), line/character (required, 0-based), kind (all | read | write | text, default: all)
+OUTPUT: Occurrences in source order with their kind, range and source line`),
+			mcp.WithDestructiveHintAnnotation(false),
+			mcp.WithString("uri", mcp.Description("URI to the file"), mcp.Required()),
+			mcp.WithNumber("line", mcp.Description("Line number (0-based)"), mcp.Required(), mcp.Min(0)),
+			mcp.WithNumber("character", mcp.Description("Character position (0-based)"), mcp.Required(), mcp.Min(0)),
+			mcp.WithString("kind", mcp.Description("Only occurrences of this kind: all, read, write or text (default: all)"), mcp.Enum("all", "read", "write", "text")),
+		), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			bridge := bridge.WithContext(ctx)
+
+			uri, err := request.RequireString("uri")
+			if err != nil {
+				logger.Error("document_highlight: URI parsing failed", err)
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			line, err := request.RequireInt("line")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			character, err := request.RequireInt("character")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			lineUint32, err := safeUint32(line)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Invalid line number: %v", err)), nil
+			}
+			characterUint32, err := safeUint32(character)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Invalid character position: %v", err)), nil
+			}
+
+			kind := request.GetString("kind", "all")
+			switch kind {
+			case "all", "read", "write", "text":
+			default:
+				return mcp.NewToolResultError("kind must be all, read, write or text"), nil
+			}
+
+			if result, ok := CheckReadyOrReturn(bridge); !ok {
+				return result, nil
+			}
+
+			highlights, err := bridge.DocumentHighlight(uri, lineUint32, characterUint32)
+			if err != nil {
+				logger.Error("document_highlight: Request failed", fmt.Sprintf("URI: %s, Line: %d, Character: %d, Error: %v", uri, line, character, err))
+				return mcp.NewToolResultError(fmt.Sprintf("Document highlight failed: %v", err)), nil
+			}
+
+			lines := readModuleLines(utils.URIToFilePath(bridge.NormalizeURIForLSP(uri)))
+			return mcp.NewToolResultText(formatDocumentHighlights(uri, line, character, highlights, kind, lines)), nil
+		}
+}
+
+func formatDocumentHighlights(uri string, line, character int, highlights []protocol.DocumentHighlight, kind string, lines []string) string {
+	sort.SliceStable(highlights, func(i, j int) bool {
+		a, b := highlights[i].Range.Start, highlights[j].Range.Start
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Character < b.Character
+	})
+
+	counts := make(map[string]int)
+	shown := make([]protocol.DocumentHighlight, 0, len(highlights))
+	for _, h := range highlights {
+		k := highlightKindToString(h.Kind)
+		counts[k]++
+		if kind == "all" || kind == k {
+			shown = append(shown, h)
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Occurrences at %s:%d:%d: %d (write: %d, read: %d, text: %d)\n",
+		uri, line, character, len(highlights), counts["write"], counts["read"], counts["text"])
+	if len(highlights) > 0 && counts["text"] == len(highlights) {
+		b.WriteString("The server does not tell reads from writes for this symbol.\n")
+	}
+	if len(shown) == 0 {
+		if kind == "all" {
+			b.WriteString("\nNo occurrences.\n")
+		} else {
+			fmt.Fprintf(&b, "\nNo %s occurrences.\n", kind)
+		}
+		return b.String()
+	}
+
+	b.WriteString("\n")
+	for i, h := range shown {
+		fmt.Fprintf(&b, "%d. [%s] %d:%d-%d:%d", i+1, highlightKindToString(h.Kind),
+			h.Range.Start.Line, h.Range.Start.Character, h.Range.End.Line, h.Range.End.Character)
+		if int(h.Range.Start.Line) < len(lines) {
+			preview := strings.TrimSpace(strings.TrimRight(lines[h.Range.Start.Line], "\r"))
+			if runes := []rune(preview); len(runes) > highlightPreviewLimit {
+				preview = string(runes[:highlightPreviewLimit]) + "…"
+			}
+			fmt.Fprintf(&b, "  %s", preview)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,75 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"rockerboo/mcp-lsp-bridge/mocks"
+	"rockerboo/mcp-lsp-bridge/utils"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/myleshyson/lsprotocol-go/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func highlightAt(line, character uint32, kind *protocol.DocumentHighlightKind) protocol.DocumentHighlight {
+	return protocol.DocumentHighlight{Kind: kind, Range: protocol.Range{
+		Start: protocol.Position{Line: line, Character: character},
+		End:   protocol.Position{Line: line, Character: character + 5},
+	}}
+}
+
+func TestDocumentHighlightTool(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "Module.bsl")
+	source := "Процедура Пересчитать()\n\tСумма = 0;\n\tДля Каждого Строка Из Товары Цикл\n\t\tСумма = Сумма + Строка.Сумма;\n\tКонецЦикла;\nКонецПроцедуры\n"
+	require.NoError(t, os.WriteFile(path, []byte(source), 0o600))
+	uri := utils.FilePathToURI(path)
+
+	read, write := protocol.DocumentHighlightKindRead, protocol.DocumentHighlightKindWrite
+	newBridge := func() *mocks.MockBridge {
+		bridge := &mocks.MockBridge{}
+		bridge.On("DocumentHighlight", uri, uint32(3), uint32(2)).Return([]protocol.DocumentHighlight{
+			highlightAt(3, 10, &read),
+			highlightAt(3, 2, &write),
+			highlightAt(1, 1, &write),
+		}, nil)
+		return bridge
+	}
+
+	t.Run("all", func(t *testing.T) {
+		tool, handler := DocumentHighlightTool(newBridge())
+		result := callLocationTool(t, tool, handler, map[string]any{"uri": uri, "line": 3, "character": 2})
+		require.False(t, result.IsError, result.Content)
+
+		text := result.Content[0].(mcp.TextContent).Text
+		assert.Contains(t, text, "3 (write: 2, read: 1, text: 0)")
+		assert.Contains(t, text, "1. [write] 1:1-1:6  Сумма = 0;")
+		assert.Contains(t, text, "2. [write] 3:2-3:7  Сумма = Сумма + Строка.Сумма;")
+		assert.Contains(t, text, "3. [read] 3:10-3:15")
+	})
+
+	t.Run("writes only", func(t *testing.T) {
+		tool, handler := DocumentHighlightTool(newBridge())
+		result := callLocationTool(t, tool, handler, map[string]any{"uri": uri, "line": 3, "character": 2, "kind": "write"})
+		require.False(t, result.IsError, result.Content)
+
+		text := result.Content[0].(mcp.TextContent).Text
+		assert.Contains(t, text, "2. [write] 3:2-3:7")
+		assert.NotContains(t, text, "[read]")
+	})
+
+	t.Run("kind not reported", func(t *testing.T) {
+		bridge := &mocks.MockBridge{}
+		bridge.On("DocumentHighlight", uri, uint32(0), uint32(10)).Return([]protocol.DocumentHighlight{highlightAt(0, 10, nil)}, nil)
+
+		tool, handler := DocumentHighlightTool(bridge)
+		result := callLocationTool(t, tool, handler, map[string]any{"uri": uri, "line": 0, "character": 10, "kind": "write"})
+		require.False(t, result.IsError, result.Content)
+
+		text := result.Content[0].(mcp.TextContent).Text
+		assert.Contains(t, text, "does not tell reads from writes")
+		assert.Contains(t, text, "No write occurrences.")
+	})
+}
@@ -18,6 +18,8 @@ func DocumentLinkTool(bridge interfaces.BridgeInterface) (mcp.Tool, server.ToolH
 			mcp.WithDestructiveHintAnnotation(false),
 			mcp.WithString("uri", mcp.Description("URI to the file"), mcp.Required()),
 		), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			bridge := bridge.WithContext(ctx)
+
 			uri, err := request.RequireString("uri")
 			if err != nil {
 				logger.Error("document_link: URI parsing failed", err)
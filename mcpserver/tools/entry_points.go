@@ -0,0 +1,119 @@
+package tools
+
+import (
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"rockerboo/mcp-lsp-bridge/logger"
+	"rockerboo/mcp-lsp-bridge/metadata"
+	"rockerboo/mcp-lsp-bridge/utils"
+)
+
+// commandHandlers are the fixed names the platform calls in command modules
+var commandHandlers = []string{"ОбработкаКоманды", "CommandProcessing"}
+
+// bslEntryPoint reports whether the platform calls the procedure name defined in uri,
+// and what triggers it.
+//
+// When the file belongs to a configuration dump, the metadata decides: form module
+// procedures are entry points only when the form description binds them to a form,
+// item or command event; common module procedures only when an event subscription or
+// scheduled job names them; command modules have ОбработкаКоманды; other modules
+// (object, manager, session...) have events with fixed names, see bslEntryPoints.
+// Outside a configuration it falls back to matching names against bslEntryPoints.
+// configs may be nil for a one-off lookup.
+func bslEntryPoint(configs *configLookup, uri, name string) (bool, []string) {
+	path := utils.URIToFilePath(uri)
+	cfg, err := configs.forFile(path)
+	if err != nil {
+		return isEntryPoint(name), nil
+	}
+	owner, ok := cfg.Owner(path)
+	if !ok {
+		return isEntryPoint(name), nil
+	}
+
+	bindings, err := cfg.BindingsOf(path, name)
+	if err != nil {
+		logger.Warn("entry points: failed to read form bindings: " + err.Error())
+		return isEntryPoint(name), nil
+	}
+	if len(bindings) > 0 {
+		triggers := make([]string, 0, len(bindings))
+		for _, b := range bindings {
+			triggers = append(triggers, b.String())
+		}
+		return true, triggers
+	}
+
+	switch {
+	case owner.Form != nil:
+		if owner.Form.File == "" {
+			// No managed form description (e.g. an ordinary form), nothing to go by
+			return isEntryPoint(name), nil
+		}
+		return false, nil
+	case owner.Module.Kind == "CommandModule":
+		for _, h := range commandHandlers {
+			if strings.EqualFold(name, h) {
+				return true, []string{"command " + commandName(owner)}
+			}
+		}
+		return false, nil
+	case owner.Object != nil && owner.Object.Type == metadata.CommonModule:
+		return false, nil
+	}
+
+	for ep := range bslEntryPoints {
+		if strings.EqualFold(name, ep) {
+			return true, []string{owner.Module.Kind + " event " + ep}
+		}
+	}
+	return false, nil
+}
+
+func commandName(owner metadata.FileOwner) string {
+	if owner.Command != nil {
+		return owner.Command.Name
+	}
+	return owner.Object.Name
+}
+
+// configLookup remembers the configuration of each directory for the length of one
+// tool call. metadataCache checks every description file for changes on each lookup,
+// which is too much once per node or method of a walk. The zero value is ready to use.
+type configLookup struct {
+	mu    sync.Mutex
+	byDir map[string]configLookupResult
+}
+
+type configLookupResult struct {
+	cfg *metadata.Configuration
+	err error
+}
+
+// forFile returns the configuration path belongs to, as metadataCache.ForFile does;
+// a nil lookup asks metadataCache every time
+func (l *configLookup) forFile(path string) (*metadata.Configuration, error) {
+	if l == nil {
+		return metadataCache.ForFile(path)
+	}
+	dir := filepath.Dir(filepath.Clean(path))
+
+	l.mu.Lock()
+	r, ok := l.byDir[dir]
+	l.mu.Unlock()
+	if ok {
+		return r.cfg, r.err
+	}
+
+	r.cfg, r.err = metadataCache.ForFile(path)
+	l.mu.Lock()
+	if l.byDir == nil {
+		l.byDir = make(map[string]configLookupResult)
+	}
+	l.byDir[dir] = r
+	l.mu.Unlock()
+	return r.cfg, r.err
+}
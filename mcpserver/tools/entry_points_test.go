@@ -0,0 +1,102 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"rockerboo/mcp-lsp-bridge/utils"
+
+	"github.com/myleshyson/lsprotocol-go/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const boundForm = `<Form xmlns="http://v8.1c.ru/8.3/xcf/logform">
+	<Events><Event name="OnCreateAtServer">ПриСозданииНаСервере</Event></Events>
+	<ChildItems>
+		<InputField name="Товары" id="1"><Events><Event name="OnChange">ТоварыПриИзменении</Event></Events></InputField>
+	</ChildItems>
+	<Commands><Command name="Заполнить" id="1"><Action>Заполнить</Action></Command></Commands>
+</Form>`
+
+func TestBSLEntryPoint_UsesFormBindings(t *testing.T) {
+	root := writeDesignerDump(t)
+	formDir := filepath.Join(root, "Catalogs/Товары/Forms/ФормаЭлемента/Ext")
+	require.NoError(t, os.WriteFile(filepath.Join(formDir, "Form.xml"), []byte(boundForm), 0o644))
+	formModule := utils.FilePathToURI(filepath.Join(formDir, "Form/Module.bsl"))
+
+	ok, triggers := bslEntryPoint(nil, formModule, "ТоварыПриИзменении")
+	assert.True(t, ok, "handler bound under a custom name")
+	assert.Equal(t, []string{"item Товары.OnChange of form ФормаЭлемента"}, triggers)
+
+	ok, triggers = bslEntryPoint(nil, formModule, "Заполнить")
+	assert.True(t, ok)
+	assert.Equal(t, []string{"command Заполнить of form ФормаЭлемента"}, triggers)
+
+	ok, _ = bslEntryPoint(nil, formModule, "ПриОткрытии")
+	assert.False(t, ok, "a known event name that the form does not bind is not an entry point")
+
+	// Object modules keep the fixed event names
+	objectModule := utils.FilePathToURI(filepath.Join(root, "Catalogs/Товары/Ext/ObjectModule.bsl"))
+	ok, triggers = bslEntryPoint(nil, objectModule, "ПередЗаписью")
+	assert.True(t, ok)
+	assert.Equal(t, []string{"ObjectModule event ПередЗаписью"}, triggers)
+	ok, _ = bslEntryPoint(nil, objectModule, "ЗаполнитьРеквизиты")
+	assert.False(t, ok)
+
+	// Common module procedures are only called through subscriptions and jobs
+	commonModule := utils.FilePathToURI(filepath.Join(root, "CommonModules/ОбщегоНазначения/Ext/Module.bsl"))
+	ok, _ = bslEntryPoint(nil, commonModule, "ПриОткрытии")
+	assert.False(t, ok)
+}
+
+func TestBSLEntryPoint_FallsBackToNamesOutsideConfiguration(t *testing.T) {
+	ok, triggers := bslEntryPoint(nil, "file:///projects/CommonModules/Common/Ext/Module.bsl", "ПриСозданииНаСервере")
+	assert.True(t, ok)
+	assert.Empty(t, triggers)
+
+	ok, _ = bslEntryPoint(nil, "file:///projects/CommonModules/Common/Ext/Module.bsl", "ТоварыПриИзменении")
+	assert.False(t, ok)
+}
+
+func TestCallGraphBuilder_MarksBoundHandlers(t *testing.T) {
+	root := writeDesignerDump(t)
+	formDir := filepath.Join(root, "Catalogs/Товары/Forms/ФормаЭлемента/Ext")
+	require.NoError(t, os.WriteFile(filepath.Join(formDir, "Form.xml"), []byte(boundForm), 0o644))
+
+	b := &callGraphBuilder{}
+	node := &CallGraphNode{}
+	b.markEntryPoint(node, &protocol.CallHierarchyItem{
+		Name: "ТоварыПриИзменении",
+		Uri:  protocol.DocumentUri(utils.FilePathToURI(filepath.Join(formDir, "Form/Module.bsl"))),
+	})
+
+	assert.True(t, node.IsEntryPoint)
+	assert.Equal(t, []string{"item Товары.OnChange of form ФормаЭлемента"}, node.Triggers)
+	assert.Equal(t, []string{"ТоварыПриИзменении"}, b.entryPoints)
+}
+
+func TestConfigLookup_ResolvesOncePerCall(t *testing.T) {
+	root := writeDesignerDump(t)
+	module := filepath.Join(root, "Catalogs/Товары/Ext/ObjectModule.bsl")
+
+	var configs configLookup
+	first, err := configs.forFile(module)
+	require.NoError(t, err)
+
+	// A changed description makes the shared cache reparse, the lookup keeps its answer
+	later := time.Now().Add(time.Hour)
+	require.NoError(t, os.Chtimes(filepath.Join(root, "Configuration.xml"), later, later))
+	again, err := configs.forFile(module)
+	require.NoError(t, err)
+	assert.Same(t, first, again)
+
+	fresh, err := metadataCache.ForFile(module)
+	require.NoError(t, err)
+	assert.NotSame(t, first, fresh)
+
+	_, err = configs.forFile("/projects/CommonModules/Common/Ext/Module.bsl")
+	assert.Error(t, err)
+}
@@ -21,6 +21,8 @@ func ExecuteCommandTool(bridge interfaces.BridgeInterface) (mcp.Tool, server.Too
 			mcp.WithString("language", mcp.Description("Language server ID (e.g., 'bsl'). Required if uri is not provided.")),
 			mcp.WithString("uri", mcp.Description("Optional file URI to infer language when language is not provided.")),
 		), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			bridge := bridge.WithContext(ctx)
+
 			command, err := request.RequireString("command")
 			if err != nil {
 				logger.Error("execute_command: command parsing failed", err)
@@ -26,6 +26,8 @@ OUTPUT: Preview shows exact changes, apply mode confirms completion`),
 		mcp.WithNumber("tab_size", mcp.Description("Tab size for formatting (default: 4, affects indentation width)")),
 		mcp.WithString("apply", mcp.Description("CRITICAL: Whether to apply formatting changes. 'false' (default) = preview only, 'true' = actually format file. ALWAYS preview first!")),
 	), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		bridge := bridge.WithContext(ctx)
+
 		// Parse and validate parameters
 		uri, err := request.RequireString("uri")
 		if err != nil {
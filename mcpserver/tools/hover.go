@@ -26,6 +26,8 @@ OUTPUT: Formatted documentation with code examples and pkg.go.dev links`),
 			mcp.WithNumber("line", mcp.Description("Line number (0-based)"), mcp.Required(), mcp.Min(0)),
 			mcp.WithNumber("character", mcp.Description("Character position (0-based)"), mcp.Required(), mcp.Min(0)),
 		), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			bridge := bridge.WithContext(ctx)
+
 			// Parse and validate parameters
 			uri, err := request.RequireString("uri")
 			if err != nil {
@@ -31,6 +31,8 @@ func ImplementationTool(bridge interfaces.BridgeInterface) (mcp.Tool, server.Too
 			mcp.WithNumber("line", mcp.Description("Line number (0-based)")),
 			mcp.WithNumber("character", mcp.Description("Character position (0-based)")),
 		), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			bridge := bridge.WithContext(ctx)
+
 			// Parse and validate parameters
 			uri, err := request.RequireString("uri")
 			if err != nil {
@@ -93,7 +95,7 @@ func ImplementationTool(bridge interfaces.BridgeInterface) (mcp.Tool, server.Too
 			// Convert clients to async operations
 			ops := collections.TransformMap(clients, func(client types.LanguageClientInterface) func() ([]protocol.Location, error) {
 				return func() ([]protocol.Location, error) {
-					return client.Implementation(normalizedURI, lineUint32, characterUint32)
+					return client.WithContext(ctx).Implementation(normalizedURI, lineUint32, characterUint32)
 				}
 			})
 
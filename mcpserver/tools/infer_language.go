@@ -23,6 +23,8 @@ func InferLanguageTool(bridge interfaces.BridgeInterface) (mcp.Tool, server.Tool
 			mcp.WithDestructiveHintAnnotation(false),
 			mcp.WithString("file_path", mcp.Description("Path to the file to infer language")),
 		), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			bridge := bridge.WithContext(ctx)
+
 			filePath, err := request.RequireString("file_path")
 			if err != nil {
 				logger.Error("infer_language: File path parsing failed", err)
@@ -22,6 +22,8 @@ func LSPConnectTool(bridge interfaces.BridgeInterface) (mcp.Tool, server.ToolHan
 			mcp.WithDestructiveHintAnnotation(false),
 			mcp.WithString("language", mcp.Description("Programming language to connect")),
 		), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			bridge := bridge.WithContext(ctx)
+
 			language, err := request.RequireString("language")
 			if err != nil {
 				logger.Error("lsp_connect: Language parsing failed", err)
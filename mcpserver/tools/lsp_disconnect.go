@@ -20,6 +20,8 @@ func LSPDisconnectTool(bridge interfaces.BridgeInterface) (mcp.Tool, server.Tool
 			mcp.WithDescription("Disconnect all active language server clients"),
 			mcp.WithDestructiveHintAnnotation(false),
 		), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			bridge := bridge.WithContext(ctx)
+
 			// Close all active clients
 			bridge.CloseAllClients()
 
@@ -16,6 +16,8 @@ func LSPStatusTool(bridge interfaces.BridgeInterface) (mcp.Tool, server.ToolHand
 			mcp.WithDescription("Show current LSP connection status and server progress ($/progress). Useful for detecting whether a language server is still indexing or ready."),
 			mcp.WithDestructiveHintAnnotation(false),
 		), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			bridge := bridge.WithContext(ctx)
+
 			status, err := BuildLSPStatus(bridge)
 			if err != nil {
 				return mcp.NewToolResultError(err.Error()), nil
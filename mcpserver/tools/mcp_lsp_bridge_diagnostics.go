@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"rockerboo/mcp-lsp-bridge/interfaces"
 
@@ -21,6 +22,8 @@ func MCPLSPDiagnosticsTool(bridge interfaces.BridgeInterface) (mcp.Tool, server.
 			mcp.WithString("summary", mcp.Description("config, connected_clients, project_languages, all")),
 			mcp.WithString("project_path", mcp.Description("Optional: Path to the project directory for project_languages report. Defaults to current working directory.")),
 		), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			bridge := bridge.WithContext(ctx)
+
 			// Parse and validate parameters
 			reportType := request.GetString("report_type", "summary")
 
@@ -91,7 +94,14 @@ func MCPLSPDiagnosticsTool(bridge interfaces.BridgeInterface) (mcp.Tool, server.
 						sb.WriteString(fmt.Sprintf("  Language: %s\n", lang))
 						sb.WriteString(fmt.Sprintf("    Status: %d\n", metrics.GetStatus()))
 						sb.WriteString(fmt.Sprintf("    Last Error: %v\n", metrics.GetLastError()))
-						sb.WriteString(fmt.Sprintf("    Connection Attempts: %d\n", metrics.GetTotalRequests()))
+						sb.WriteString(fmt.Sprintf("    Requests: %d total, %d successful, %d failed\n",
+							metrics.GetTotalRequests(), metrics.GetSuccessfulRequests(), metrics.GetFailedRequests()))
+						if t := metrics.GetLastErrorTime(); !t.IsZero() {
+							sb.WriteString(fmt.Sprintf("    Last Error At: %s\n", t.Format(time.RFC3339)))
+						}
+						if pid := metrics.GetProcessID(); pid != 0 {
+							sb.WriteString(fmt.Sprintf("    Process ID: %d\n", pid))
+						}
 						sb.WriteString(fmt.Sprintf("    Last Connected At: %s\n", metrics.GetLastInitialized()))
 					}
 				}
@@ -0,0 +1,310 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"rockerboo/mcp-lsp-bridge/interfaces"
+	"rockerboo/mcp-lsp-bridge/metadata"
+	"rockerboo/mcp-lsp-bridge/utils"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// metadataCache is shared by the tools that need the configuration metadata;
+// a configuration is reparsed only after one of its description files changes
+var metadataCache = metadata.NewCache()
+
+// loadMetadata parses (or reuses) the configuration under workspaceURI,
+// defaulting to the first allowed directory
+func loadMetadata(bridge interfaces.BridgeInterface, workspaceURI string) (*metadata.Configuration, error) {
+	if workspaceURI == "" {
+		dirs := bridge.AllowedDirectories()
+		if len(dirs) == 0 {
+			return nil, errors.New("workspace_uri is required: no allowed directories configured")
+		}
+		workspaceURI = dirs[0]
+	} else {
+		workspaceURI = bridge.NormalizeURIForLSP(workspaceURI)
+	}
+	return metadataCache.Load(utils.URIToFilePath(workspaceURI))
+}
+
+// RegisterMetadataExploreTool registers the metadata_explore tool
+func RegisterMetadataExploreTool(mcpServer ToolServer, bridge interfaces.BridgeInterface) {
+	mcpServer.AddTool(MetadataExploreTool(bridge))
+}
+
+func MetadataExploreTool(bridge interfaces.BridgeInterface) (mcp.Tool, server.ToolHandlerFunc) {
+	return mcp.NewTool("metadata_explore",
+			mcp.WithDescription(`Browse the 1C configuration metadata tree read from the source dump
+(Designer: Configuration.xml + per-object XML; EDT: .mdo files). Works without the language server.
+
+ACTIONS:
+- summary (default): configuration name, format, object counts by type
+- list: objects of one type, e.g. type="CommonModule" (or "ОбщийМодуль", "CommonModules")
+- object: one object with its modules, forms (with the event handlers bound in the form), commands,
+  common module flags, e.g. name="Справочник.Товары"
+- search: objects whose name or synonym contains query, optionally limited to type
+- file: the object, form or command a .bsl file belongs to, e.g. uri=".../Catalogs/Товары/Ext/ObjectModule.bsl"
+- subsystems: the subsystem tree with the objects each subsystem includes
+
+All file references are URIs usable with the other tools.
+
+PAGINATION (list, search): offset (default 0), limit (default 50, max 500)`),
+			mcp.WithDestructiveHintAnnotation(false),
+			mcp.WithString("action", mcp.Description("summary, list, object, search, file or subsystems (default: summary)"),
+				mcp.Enum("summary", "list", "object", "search", "file", "subsystems")),
+			mcp.WithString("workspace_uri", mcp.Description("Directory containing the configuration dump (optional, defaults to the project root)")),
+			mcp.WithString("type", mcp.Description("Object type for list and search, in English, Russian or as a directory name")),
+			mcp.WithString("name", mcp.Description("Full object name for object, e.g. CommonModule.ОбщегоНазначения")),
+			mcp.WithString("query", mcp.Description("Text to find in object names and synonyms for search")),
+			mcp.WithString("uri", mcp.Description(".bsl file URI or path for file")),
+			mcp.WithNumber("offset", mcp.Description("Skip N results (default: 0)"), mcp.Min(0)),
+			mcp.WithNumber("limit", mcp.Description("Max results (default: 50, max 500)"), mcp.Min(1), mcp.Max(500)),
+		), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			bridge := bridge.WithContext(ctx)
+
+			action := request.GetString("action", "summary")
+			offset := request.GetInt("offset", 0)
+			limit := min(request.GetInt("limit", 50), 500)
+
+			cfg, err := loadMetadata(bridge, request.GetString("workspace_uri", ""))
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to load configuration metadata: %v", err)), nil
+			}
+
+			var objectType metadata.ObjectType
+			if t := request.GetString("type", ""); t != "" {
+				parsed, ok := metadata.ParseType(t)
+				if !ok {
+					return mcp.NewToolResultError(fmt.Sprintf("unknown object type %q", t)), nil
+				}
+				objectType = parsed
+			}
+
+			var result any
+			switch action {
+			case "summary":
+				result = metadataSummary(cfg)
+			case "list":
+				if objectType == "" {
+					return mcp.NewToolResultError("type is required for list"), nil
+				}
+				result = metadataPage(cfg.ObjectsOfType(objectType), offset, limit)
+			case "search":
+				query := request.GetString("query", "")
+				if query == "" {
+					return mcp.NewToolResultError("query is required for search"), nil
+				}
+				result = metadataPage(cfg.Search(query, objectType), offset, limit)
+			case "object":
+				name, err := request.RequireString("name")
+				if err != nil {
+					return mcp.NewToolResultError("name is required for object"), nil
+				}
+				obj := cfg.Find(name)
+				if obj == nil {
+					return mcp.NewToolResultError(fmt.Sprintf("object %q not found", name)), nil
+				}
+				result = newMetadataObjectView(obj)
+			case "file":
+				uri, err := request.RequireString("uri")
+				if err != nil {
+					return mcp.NewToolResultError("uri is required for file"), nil
+				}
+				owner, ok := cfg.Owner(utils.URIToFilePath(bridge.NormalizeURIForLSP(uri)))
+				if !ok {
+					return mcp.NewToolResultError(fmt.Sprintf("%s is not a module of the configuration", uri)), nil
+				}
+				result = newMetadataOwnerView(owner)
+			case "subsystems":
+				var tree []metadataSubsystemView
+				for _, obj := range cfg.ObjectsOfType(metadata.Subsystem) {
+					if obj.Parent == nil {
+						tree = append(tree, newMetadataSubsystemView(obj))
+					}
+				}
+				result = tree
+			default:
+				return mcp.NewToolResultError("Unknown action: " + action), nil
+			}
+
+			payload, err := json.MarshalIndent(result, "", "  ")
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal result: %v", err)), nil
+			}
+			return mcp.NewToolResultText(string(payload)), nil
+		}
+}
+
+type metadataSummaryView struct {
+	Name     string               `json:"name"`
+	Synonym  string               `json:"synonym,omitempty"`
+	Format   metadata.Format      `json:"format"`
+	Root     string               `json:"root"`
+	Modules  []metadataModuleView `json:"modules,omitempty"`
+	Objects  int                  `json:"objects"`
+	Counts   map[string]int       `json:"counts"`
+	Warnings []string             `json:"warnings,omitempty"`
+}
+
+type metadataModuleView struct {
+	Kind string `json:"kind"`
+	URI  string `json:"uri"`
+}
+
+// metadataListItem is the short form of an object in list and search results
+type metadataListItem struct {
+	FullName     string                      `json:"full_name"`
+	Synonym      string                      `json:"synonym,omitempty"`
+	CommonModule *metadata.CommonModuleFlags `json:"common_module,omitempty"`
+}
+
+type metadataListView struct {
+	Total   int                `json:"total"`
+	Offset  int                `json:"offset"`
+	Objects []metadataListItem `json:"objects"`
+}
+
+type metadataFormView struct {
+	Name     string             `json:"name"`
+	URI      string             `json:"uri,omitempty"`    // Form.xml / Form.form
+	Module   string             `json:"module,omitempty"` // form module URI
+	Handlers []metadata.Binding `json:"handlers,omitempty"`
+	Error    string             `json:"error,omitempty"` // the form description could not be read
+}
+
+type metadataCommandView struct {
+	Name   string `json:"name"`
+	Module string `json:"module,omitempty"`
+}
+
+type metadataObjectView struct {
+	FullName     string                      `json:"full_name"`
+	RussianName  string                      `json:"russian_name"`
+	Synonym      string                      `json:"synonym,omitempty"`
+	Comment      string                      `json:"comment,omitempty"`
+	URI          string                      `json:"uri"`
+	Modules      []metadataModuleView        `json:"modules,omitempty"`
+	Forms        []metadataFormView          `json:"forms,omitempty"`
+	Commands     []metadataCommandView       `json:"commands,omitempty"`
+	CommonModule *metadata.CommonModuleFlags `json:"common_module,omitempty"`
+	Handlers     []string                    `json:"handlers,omitempty"`
+	Content      []string                    `json:"content,omitempty"`
+	Subsystems   []string                    `json:"subsystems,omitempty"`
+	Parent       string                      `json:"parent,omitempty"`
+}
+
+type metadataOwnerView struct {
+	Module  metadataModuleView  `json:"module"`
+	Object  *metadataObjectView `json:"object,omitempty"` // absent for configuration modules
+	Form    string              `json:"form,omitempty"`
+	Command string              `json:"command,omitempty"`
+}
+
+type metadataSubsystemView struct {
+	FullName   string                  `json:"full_name"`
+	Synonym    string                  `json:"synonym,omitempty"`
+	Content    []string                `json:"content,omitempty"`
+	Subsystems []metadataSubsystemView `json:"subsystems,omitempty"`
+}
+
+func metadataSummary(cfg *metadata.Configuration) metadataSummaryView {
+	view := metadataSummaryView{
+		Name:     cfg.Name,
+		Synonym:  cfg.Synonym,
+		Format:   cfg.Format,
+		Root:     utils.FilePathToURI(cfg.Root),
+		Modules:  metadataModules(cfg.Modules),
+		Objects:  len(cfg.Objects),
+		Counts:   map[string]int{},
+		Warnings: cfg.Warnings,
+	}
+	for _, obj := range cfg.Objects {
+		view.Counts[string(obj.Type)]++
+	}
+	return view
+}
+
+func metadataPage(objects []*metadata.Object, offset, limit int) metadataListView {
+	view := metadataListView{Total: len(objects), Offset: offset, Objects: []metadataListItem{}}
+	if offset >= len(objects) {
+		return view
+	}
+	end := min(offset+limit, len(objects))
+	for _, obj := range objects[offset:end] {
+		view.Objects = append(view.Objects, metadataListItem{
+			FullName:     obj.FullName(),
+			Synonym:      obj.Synonym,
+			CommonModule: obj.CommonModule,
+		})
+	}
+	return view
+}
+
+func metadataModules(modules []metadata.Module) []metadataModuleView {
+	var out []metadataModuleView
+	for _, m := range modules {
+		out = append(out, metadataModuleView{Kind: m.Kind, URI: utils.FilePathToURI(m.Path)})
+	}
+	return out
+}
+
+func newMetadataObjectView(obj *metadata.Object) *metadataObjectView {
+	view := &metadataObjectView{
+		FullName:     obj.FullName(),
+		RussianName:  obj.RussianName(),
+		Synonym:      obj.Synonym,
+		Comment:      obj.Comment,
+		URI:          utils.FilePathToURI(obj.File),
+		Modules:      metadataModules(obj.Modules),
+		CommonModule: obj.CommonModule,
+		Handlers:     obj.Handlers,
+		Content:      obj.Content,
+	}
+	for _, f := range obj.Forms {
+		form := metadataFormView{Name: f.Name, URI: utils.FilePathToURI(f.File), Module: utils.FilePathToURI(f.Module)}
+		if bindings, err := f.Bindings(); err != nil {
+			form.Error = err.Error()
+		} else {
+			form.Handlers = bindings
+		}
+		view.Forms = append(view.Forms, form)
+	}
+	for _, c := range obj.Commands {
+		view.Commands = append(view.Commands, metadataCommandView{Name: c.Name, Module: utils.FilePathToURI(c.Module)})
+	}
+	for _, s := range obj.Subsystems {
+		view.Subsystems = append(view.Subsystems, s.FullName())
+	}
+	if obj.Parent != nil {
+		view.Parent = obj.Parent.FullName()
+	}
+	return view
+}
+
+func newMetadataOwnerView(owner metadata.FileOwner) metadataOwnerView {
+	view := metadataOwnerView{Module: metadataModuleView{Kind: owner.Module.Kind, URI: utils.FilePathToURI(owner.Module.Path)}}
+	if owner.Object != nil {
+		view.Object = newMetadataObjectView(owner.Object)
+	}
+	if owner.Form != nil {
+		view.Form = owner.Form.Name
+	}
+	if owner.Command != nil {
+		view.Command = owner.Command.Name
+	}
+	return view
+}
+
+func newMetadataSubsystemView(obj *metadata.Object) metadataSubsystemView {
+	view := metadataSubsystemView{FullName: obj.FullName(), Synonym: obj.Synonym, Content: obj.Content}
+	for _, s := range obj.Subsystems {
+		view.Subsystems = append(view.Subsystems, newMetadataSubsystemView(s))
+	}
+	return view
+}
@@ -0,0 +1,130 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"rockerboo/mcp-lsp-bridge/mocks"
+	"rockerboo/mcp-lsp-bridge/utils"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/mcptest"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeDesignerDump writes a minimal Designer dump with two common modules and a catalog
+func writeDesignerDump(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	const header = `<MetaDataObject xmlns="http://v8.1c.ru/8.3/MDClasses" xmlns:v8="http://v8.1c.ru/8.1/data/core">`
+	files := map[string]string{
+		"Configuration.xml": header + `<Configuration><Properties><Name>Демо</Name></Properties><ChildObjects>
+			<CommonModule>ОбщегоНазначения</CommonModule>
+			<CommonModule>ОбщегоНазначенияКлиент</CommonModule>
+			<Catalog>Товары</Catalog>
+		</ChildObjects></Configuration></MetaDataObject>`,
+		"CommonModules/ОбщегоНазначения.xml":            header + `<CommonModule><Properties><Name>ОбщегоНазначения</Name><Server>true</Server><ServerCall>true</ServerCall></Properties></CommonModule></MetaDataObject>`,
+		"CommonModules/ОбщегоНазначения/Ext/Module.bsl": "",
+		"CommonModules/ОбщегоНазначенияКлиент.xml":      header + `<CommonModule><Properties><Name>ОбщегоНазначенияКлиент</Name><ClientManagedApplication>true</ClientManagedApplication></Properties></CommonModule></MetaDataObject>`,
+		"Catalogs/Товары.xml": header + `<Catalog><Properties><Name>Товары</Name>
+			<Synonym><v8:item><v8:lang>ru</v8:lang><v8:content>Номенклатура</v8:content></v8:item></Synonym></Properties>
+			<ChildObjects><Form>ФормаЭлемента</Form></ChildObjects></Catalog></MetaDataObject>`,
+		"Catalogs/Товары/Ext/ObjectModule.bsl":                    "",
+		"Catalogs/Товары/Forms/ФормаЭлемента/Ext/Form.xml":        "<Form/>",
+		"Catalogs/Товары/Forms/ФормаЭлемента/Ext/Form/Module.bsl": "",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+	return root
+}
+
+func callMetadataExplore(t *testing.T, root string, arguments map[string]any) *mcp.CallToolResult {
+	t.Helper()
+
+	bridge := &mocks.MockBridge{}
+	bridge.On("AllowedDirectories").Return([]string{root})
+
+	tool, handler := MetadataExploreTool(bridge)
+	mcpServer, err := mcptest.NewServer(t, server.ServerTool{Tool: tool, Handler: handler})
+	require.NoError(t, err)
+
+	result, err := mcpServer.Client().CallTool(context.Background(), mcp.CallToolRequest{
+		Request: mcp.Request{Method: "tools/call"},
+		Params:  mcp.CallToolParams{Name: "metadata_explore", Arguments: arguments},
+	})
+	require.NoError(t, err)
+	return result
+}
+
+func TestMetadataExploreTool_Summary(t *testing.T) {
+	root := writeDesignerDump(t)
+
+	result := callMetadataExplore(t, root, map[string]any{})
+	require.False(t, result.IsError)
+
+	var summary metadataSummaryView
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].(mcp.TextContent).Text), &summary))
+	assert.Equal(t, "Демо", summary.Name)
+	assert.Equal(t, 3, summary.Objects)
+	assert.Equal(t, map[string]int{"CommonModule": 2, "Catalog": 1}, summary.Counts)
+}
+
+func TestMetadataExploreTool_ListAndSearch(t *testing.T) {
+	root := writeDesignerDump(t)
+
+	result := callMetadataExplore(t, root, map[string]any{"action": "list", "type": "ОбщийМодуль", "limit": 1})
+	require.False(t, result.IsError)
+	var page metadataListView
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].(mcp.TextContent).Text), &page))
+	assert.Equal(t, 2, page.Total)
+	require.Len(t, page.Objects, 1)
+	assert.Equal(t, "CommonModule.ОбщегоНазначения", page.Objects[0].FullName)
+	assert.True(t, page.Objects[0].CommonModule.ServerCall)
+
+	result = callMetadataExplore(t, root, map[string]any{"action": "search", "query": "номенклатура"})
+	require.False(t, result.IsError)
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].(mcp.TextContent).Text), &page))
+	require.Len(t, page.Objects, 1)
+	assert.Equal(t, "Catalog.Товары", page.Objects[0].FullName)
+}
+
+func TestMetadataExploreTool_ObjectAndFile(t *testing.T) {
+	root := writeDesignerDump(t)
+
+	result := callMetadataExplore(t, root, map[string]any{"action": "object", "name": "Справочник.Товары"})
+	require.False(t, result.IsError)
+	var obj metadataObjectView
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].(mcp.TextContent).Text), &obj))
+	assert.Equal(t, "Catalog.Товары", obj.FullName)
+	assert.Equal(t, "Справочник.Товары", obj.RussianName)
+	require.Len(t, obj.Modules, 1)
+	assert.Equal(t, "ObjectModule", obj.Modules[0].Kind)
+	require.Len(t, obj.Forms, 1)
+	formModule := filepath.Join(root, "Catalogs/Товары/Forms/ФормаЭлемента/Ext/Form/Module.bsl")
+	assert.Equal(t, utils.FilePathToURI(formModule), obj.Forms[0].Module)
+
+	result = callMetadataExplore(t, root, map[string]any{"action": "file", "uri": utils.FilePathToURI(formModule)})
+	require.False(t, result.IsError, "%#v", result.Content)
+	var owner metadataOwnerView
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].(mcp.TextContent).Text), &owner))
+	assert.Equal(t, "FormModule", owner.Module.Kind)
+	assert.Equal(t, "ФормаЭлемента", owner.Form)
+	assert.Equal(t, "Catalog.Товары", owner.Object.FullName)
+}
+
+func TestMetadataExploreTool_Errors(t *testing.T) {
+	root := writeDesignerDump(t)
+
+	assert.True(t, callMetadataExplore(t, root, map[string]any{"action": "object", "name": "Catalog.Нет"}).IsError)
+	assert.True(t, callMetadataExplore(t, root, map[string]any{"action": "list", "type": "Widget"}).IsError)
+	assert.True(t, callMetadataExplore(t, root, map[string]any{"action": "list"}).IsError)
+	assert.True(t, callMetadataExplore(t, t.TempDir(), map[string]any{}).IsError, "no configuration in the workspace")
+}
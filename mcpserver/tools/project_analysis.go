@@ -39,7 +39,7 @@ USAGE:
 - Workspace overview: analysis_type="workspace_analysis", query="entire_project"
 
 ANALYSIS TYPES:
-workspace_symbols, document_symbols, references, definitions, text_search, workspace_analysis, symbol_relationships, file_analysis, pattern_analysis
+workspace_symbols, document_symbols, references, definitions, text_search, workspace_analysis, symbol_relationships, file_analysis, pattern_analysis, context_check
 
 QUICK GUIDE (what each type does + what query means):
 - workspace_symbols: find symbol candidates in the whole project. query = symbol name / substring.
@@ -51,6 +51,7 @@ QUICK GUIDE (what each type does + what query means):
 - workspace_analysis: high-level overview of the workspace. query = "entire_project" (or any placeholder).
 - symbol_relationships: analyze relationships around a symbol. query = symbol name.
 - pattern_analysis: analyze patterns across files. query = keyword/pattern.
+- context_check: 1C calls that break the execution context (client method calling a server-only one and back). query = module or directory path/URI. See the context_check tool.
 
 PAGINATION:
 - offset: skip N results (default 0)
@@ -63,10 +64,12 @@ PARAMETERS: analysis_type (required), query (required), limit (default: 20)`),
 			mcp.WithDestructiveHintAnnotation(false),
 			mcp.WithString("workspace_uri", mcp.Description("Project root URI (optional, defaults to detected project root).")),
 			mcp.WithString("query", mcp.Description("Symbol name OR file path OR text pattern (see examples above)."), mcp.Required()),
-			mcp.WithString("analysis_type", mcp.Description("Choose: workspace_symbols, document_symbols, references, definitions, text_search, workspace_analysis, symbol_relationships, file_analysis, pattern_analysis."), mcp.Required()),
+			mcp.WithString("analysis_type", mcp.Description("Choose: workspace_symbols, document_symbols, references, definitions, text_search, workspace_analysis, symbol_relationships, file_analysis, pattern_analysis, context_check."), mcp.Required()),
 			mcp.WithNumber("offset", mcp.Description("Skip N results (default: 0)."), mcp.DefaultNumber(0), mcp.Min(0)),
 			mcp.WithNumber("limit", mcp.Description("Max results (default: 20)."), mcp.Min(0), mcp.Max(100), mcp.DefaultNumber(20)),
 		), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			bridge := bridge.WithContext(ctx)
+
 			workspaceUri := request.GetString("workspace_uri", "")
 
 			// Normalize workspace_uri - handles both host paths and container paths
@@ -134,6 +137,10 @@ PARAMETERS: analysis_type (required), query (required), limit (default: 20)`),
 			if err != nil || len(clients) == 0 {
 				return mcp.NewToolResultError("No LSP clients available for detected languages"), nil
 			}
+			// Requests carry the deadline and the correlation id of the tool call
+			for lang, client := range clients {
+				clients[lang] = client.WithContext(ctx)
+			}
 
 			// Use the first available client in priority order
 			var lspClient types.LanguageClientInterface
@@ -166,7 +173,7 @@ PARAMETERS: analysis_type (required), query (required), limit (default: 20)`),
 			case "document_symbols":
 				return handleDocumentSymbols(bridge, query, offset, limit, &response)
 			case "references":
-				return handleReferences(bridge, clients, query, offset, limit, activeLanguage, &response)
+				return handleReferences(ctx, bridge, clients, query, offset, limit, activeLanguage, &response)
 			case "definitions":
 				return handleDefinitions(bridge, lspClient, query, activeLanguage, &response)
 			case "text_search":
@@ -179,6 +186,8 @@ PARAMETERS: analysis_type (required), query (required), limit (default: 20)`),
 				return handleFileAnalysis(bridge, clients, query, options, &response)
 			case "pattern_analysis":
 				return handlePatternAnalysis(bridge, clients, query, options, &response)
+			case "context_check":
+				return handleContextCheck(ctx, bridge, query, offset, limit, &response)
 			default:
 				return mcp.NewToolResultError("Unknown analysis type: " + analysisType), nil
 			}
@@ -553,7 +562,7 @@ func formatCompactSymbolChild(response *strings.Builder, sym *protocol.DocumentS
 }
 
 // Handles the 'references' analysis type
-func handleReferences(bridge interfaces.BridgeInterface, clients map[types.Language]types.LanguageClientInterface, query string, offset, limit int, activeLanguage types.Language, response *strings.Builder) (*mcp.CallToolResult, error) {
+func handleReferences(ctx context.Context, bridge interfaces.BridgeInterface, clients map[types.Language]types.LanguageClientInterface, query string, offset, limit int, activeLanguage types.Language, response *strings.Builder) (*mcp.CallToolResult, error) {
 	// Convert clients to async operations
 	ops := collections.TransformMap(clients, func(client types.LanguageClientInterface) func() ([]protocol.WorkspaceSymbol, error) {
 		return func() ([]protocol.WorkspaceSymbol, error) {
@@ -562,7 +571,6 @@ func handleReferences(bridge interfaces.BridgeInterface, clients map[types.Langu
 	})
 
 	// Execute symbol search across all clients in parallel
-	ctx := context.Background() // TODO: Pass context from caller
 	results, err := async.MapWithKeys(ctx, ops)
 	if err != nil {
 		fmt.Fprintf(response, "ERROR: %v\n", err)
@@ -856,7 +864,18 @@ func handleFileAnalysis(bridge interfaces.BridgeInterface, clients map[types.Lan
 		fmt.Fprintf(response, "  Classes: %d\n", complexity.ClassCount)
 		fmt.Fprintf(response, "  Variables: %d\n", complexity.VariableCount)
 		fmt.Fprintf(response, "  Complexity Score: %.2f\n", complexity.ComplexityScore)
-		fmt.Fprintf(response, "  Complexity Level: %s\n\n", complexity.ComplexityLevel)
+		fmt.Fprintf(response, "  Complexity Level: %s\n", complexity.ComplexityLevel)
+		if complexity.ComplexitySource == "code_lens" {
+			fmt.Fprintf(response, "  Source: average of %d methods measured by the language server\n", len(complexity.Methods))
+			for i, method := range complexity.Methods {
+				if i >= 10 {
+					fmt.Fprintf(response, "  ... and %d more methods (see code_lens)\n", len(complexity.Methods)-10)
+					break
+				}
+				fmt.Fprintf(response, "  - %s\n", formatMethodComplexity(method, false))
+			}
+		}
+		response.WriteString("\n")
 
 		// Import/Export analysis
 		importExport := fileData.ImportExport
@@ -1052,8 +1071,27 @@ func handleWorkspaceAnalysis(bridge interfaces.BridgeInterface, clients map[type
 	if workspaceData, ok := result.Data.(analysis.WorkspaceAnalysisData); ok {
 		fmt.Fprintf(response, "LANGUAGE DISTRIBUTION:\n")
 		for lang, stats := range workspaceData.LanguageDistribution {
-			fmt.Fprintf(response, "- %s: %d files (%.1f%%), %d symbols, avg complexity: %.2f\n",
+			fmt.Fprintf(response, "- %s: %d files (%.1f%%), %d symbols, avg complexity: %.2f",
 				lang, stats.FileCount, stats.Percentage, stats.SymbolCount, stats.ComplexityAvg)
+			if stats.MethodsMeasured > 0 {
+				fmt.Fprintf(response, " (%d methods measured", stats.MethodsMeasured)
+				if stats.FilesMeasured < stats.FileCount {
+					fmt.Fprintf(response, " in %d of %d files", stats.FilesMeasured, stats.FileCount)
+				}
+				response.WriteString(")")
+			}
+			response.WriteString("\n")
+		}
+
+		if len(workspaceData.ComplexityRanking) > 0 {
+			fmt.Fprintf(response, "\nMOST COMPLEX METHODS:\n")
+			for i, method := range workspaceData.ComplexityRanking {
+				fmt.Fprintf(response, "%d. %s\n", i+1, formatMethodComplexity(method, true))
+			}
+			if workspaceData.ComplexityFilesSkipped > 0 {
+				fmt.Fprintf(response, "Partial ranking: only the first %d files of each language (in path order) were measured, %d files were skipped.\n",
+					workspaceData.ComplexityFileLimit, workspaceData.ComplexityFilesSkipped)
+			}
 		}
 
 		fmt.Fprintf(response, "\nPROJECT OVERVIEW:\n")
@@ -23,6 +23,8 @@ func RangeContentTool(bridge interfaces.BridgeInterface) (mcp.Tool, server.ToolH
 			mcp.WithNumber("end_character", mcp.Description("End character (0-based)."), mcp.Required(), mcp.Min(0)),
 			mcp.WithBoolean("strict", mcp.Description("Strict bounds checking. If true, fails on any out-of-bounds characters. If false (default), clamps character positions to line boundaries."), mcp.DefaultBool(false)),
 		), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			bridge := bridge.WithContext(ctx)
+
 			logger.Info("GetRangeContent Tool: Received request")
 
 			// Parse parameters
@@ -24,6 +24,8 @@ func RangeFormattingTool(bridge interfaces.BridgeInterface) (mcp.Tool, server.To
 			mcp.WithBoolean("insert_spaces", mcp.Description("Use spaces for indentation (default: true)"), mcp.DefaultBool(true)),
 			mcp.WithString("apply", mcp.Description("Whether to apply formatting changes. 'false' (default) = preview only, 'true' = apply edits.")),
 		), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			bridge := bridge.WithContext(ctx)
+
 			uri, err := request.RequireString("uri")
 			if err != nil {
 				logger.Error("range_formatting: URI parsing failed", err)
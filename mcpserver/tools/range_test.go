@@ -13,6 +13,7 @@ import (
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/mcptest"
 	"github.com/mark3labs/mcp-go/server"
+	"github.com/myleshyson/lsprotocol-go/protocol"
 	"github.com/stretchr/testify/mock"
 )
 
@@ -351,6 +352,74 @@ line 4: fifth line`
 	}
 }
 
+func TestGetRangeContentTool_PositionEncoding(t *testing.T) {
+	testFile := filepath.Join(t.TempDir(), "Module.bsl")
+	content := "Процедура Тест😀()\r\n\tЗнач = 1;\r\nКонецПроцедуры"
+	if err := os.WriteFile(testFile, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	testCases := []struct {
+		name       string
+		enc        protocol.PositionEncodingKind
+		startChar  int
+		endLine    int
+		endChar    int
+		expected   string
+		expectFail bool
+	}{
+		{name: "utf-16 counts code units", enc: protocol.PositionEncodingKindUTF16, startChar: 10, endLine: 0, endChar: 16, expected: "Тест😀"},
+		{name: "utf-32 counts code points", enc: protocol.PositionEncodingKindUTF32, startChar: 10, endLine: 0, endChar: 15, expected: "Тест😀"},
+		{name: "utf-8 counts bytes", enc: protocol.PositionEncodingKindUTF8, startChar: 19, endLine: 0, endChar: 31, expected: "Тест😀"},
+		{name: "line terminators are kept", enc: protocol.PositionEncodingKindUTF16, startChar: 10, endLine: 1, endChar: 6, expected: "Тест😀()\r\n\tЗнач"},
+		{name: "inside a surrogate pair", enc: protocol.PositionEncodingKindUTF16, startChar: 10, endLine: 0, endChar: 15, expectFail: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			bridge := &mocks.MockBridge{}
+			bridge.On("IsAllowedDirectory", testFile).Return(testFile, nil)
+			bridge.On("PositionEncoding", testFile).Return(tc.enc)
+
+			tool, handler := RangeContentTool(bridge)
+			mcpServer, err := mcptest.NewServer(t, server.ServerTool{Tool: tool, Handler: handler})
+			if err != nil {
+				t.Fatalf("Could not create MCP server: %v", err)
+			}
+
+			toolResult, err := mcpServer.Client().CallTool(context.Background(), mcp.CallToolRequest{
+				Request: mcp.Request{Method: "tools/call"},
+				Params: mcp.CallToolParams{
+					Name: "get_range_content",
+					Arguments: map[string]any{
+						"uri":             testFile,
+						"start_line":      0,
+						"start_character": tc.startChar,
+						"end_line":        tc.endLine,
+						"end_character":   tc.endChar,
+					},
+				},
+			})
+			if err != nil {
+				t.Fatalf("Could not make request: %v", err)
+			}
+
+			if tc.expectFail {
+				if !toolResult.IsError {
+					t.Errorf("Expected error but got: %+v", toolResult.Content)
+				}
+				return
+			}
+			if toolResult.IsError {
+				t.Fatalf("Unexpected error: %v", toolResult.Content)
+			}
+			if text := toolResult.Content[0].(mcp.TextContent).Text; text != tc.expected {
+				t.Errorf("Expected text %q, got %q", tc.expected, text)
+			}
+		})
+	}
+}
+
 // Test parameter validation separately
 func TestGetRangeContentTool_ParameterValidation(t *testing.T) {
 	parameterTests := []struct {
@@ -1,7 +1,9 @@
 package tools
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"sort"
 	"strings"
@@ -41,6 +43,18 @@ type IndexingProgress struct {
 	ETASeconds     int    `json:"eta_seconds,omitempty"`
 	ElapsedSeconds int    `json:"elapsed_seconds,omitempty"`
 	Message        string `json:"message,omitempty"`
+
+	CallGraph map[string]interface{} `json:"call_graph,omitempty"`
+}
+
+// LSPMetrics are the request metrics of the Session Manager connection (Docker mode)
+type LSPMetrics struct {
+	PendingRequests int                       `json:"pending_requests"`
+	Requests        []lsp.SessionRequestStats `json:"requests,omitempty"`
+
+	// Session holds the session manager gauges: queueDepth, inflightRequests,
+	// pendingLspRequests and lspRssBytes (JVM resident memory)
+	Session map[string]interface{} `json:"session,omitempty"`
 }
 
 type LSPStatus struct {
@@ -49,6 +63,7 @@ type LSPStatus struct {
 	Activity []LSPActivity     `json:"activity"`
 	Clients  []LSPClientStatus `json:"clients,omitempty"`
 	Indexing *IndexingProgress `json:"indexing,omitempty"`
+	Metrics  *LSPMetrics       `json:"metrics,omitempty"`
 }
 
 type LSPStatusResponse struct {
@@ -143,7 +158,12 @@ func BuildLSPStatus(bridge interfaces.BridgeInterface) (LSPStatus, error) {
 		// Try to get indexing status from SessionAdapter
 		if status.Indexing == nil {
 			if sa, ok := client.(*lsp.SessionAdapter); ok {
+				status.Metrics = &LSPMetrics{
+					PendingRequests: lsp.SessionPendingRequests(),
+					Requests:        lsp.SessionRequestSummary(),
+				}
 				if idxStatus := sa.GetIndexingStatus(); idxStatus != nil {
+					status.Metrics.Session = idxStatus.Metrics
 					status.Indexing = &IndexingProgress{
 						State:          idxStatus.State,
 						Current:        idxStatus.Current,
@@ -151,6 +171,7 @@ func BuildLSPStatus(bridge interfaces.BridgeInterface) (LSPStatus, error) {
 						ETASeconds:     idxStatus.ETASeconds,
 						ElapsedSeconds: idxStatus.ElapsedSeconds,
 						Message:        idxStatus.Message,
+						CallGraph:      idxStatus.CallGraph,
 					}
 					// If indexing is active, mark as busy
 					if idxStatus.State == "indexing" {
@@ -291,3 +312,34 @@ func CheckReadyOrReturn(bridge interfaces.BridgeInterface) (*mcp.CallToolResult,
 	}
 	return mcp.NewToolResultText(payload), false
 }
+
+// WaitReady blocks until CheckReadyOrReturn lets tools run, for callers without an
+// agent that would retry on its own. It fails on status errors or when ctx ends.
+func WaitReady(ctx context.Context, bridge interfaces.BridgeInterface) error {
+	for {
+		result, ok := CheckReadyOrReturn(bridge)
+		if ok {
+			return nil
+		}
+		if result != nil && result.IsError {
+			return errors.New(toolResultText(result))
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("language servers not ready: %w", ctx.Err())
+		case <-time.After(2 * time.Second):
+		}
+	}
+}
+
+// toolResultText joins the text content of a tool result
+func toolResultText(result *mcp.CallToolResult) string {
+	var parts []string
+	for _, content := range result.Content {
+		if text, ok := content.(mcp.TextContent); ok {
+			parts = append(parts, text.Text)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
@@ -33,6 +33,8 @@ OUTPUT: All affected files with exact change locations`),
 			mcp.WithString("new_name", mcp.Description("New name for the symbol - must be a valid identifier for the programming language")),
 			mcp.WithString("apply", mcp.Description("CRITICAL: Whether to apply rename changes. 'false' (default) = preview only, 'true' = actually rename across codebase. ALWAYS preview first!")),
 		), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			bridge := bridge.WithContext(ctx)
+
 			// Parse and validate parameters
 			uri, err := request.RequireString("uri")
 			if err != nil {
@@ -87,15 +89,16 @@ OUTPUT: All affected files with exact change locations`),
 
 			if applyChanges {
 				// Apply the rename changes
-				err := bridge.ApplyWorkspaceEdit(result)
+				applied, err := bridge.ApplyWorkspaceEdit(result)
 				if err != nil {
 					logger.Error("rename: Failed to apply workspace edit", err)
-					return mcp.NewToolResultError("Failed to apply rename changes"), nil
+					return mcp.NewToolResultError(fmt.Sprintf("Failed to apply rename changes: %v", err)), nil
 				}
 
 				// Return success message with applied changes
 				content := formatWorkspaceEdit(result)
-				content += "\nRENAME APPLIED\nAll rename changes have been applied across the codebase."
+				content += "\nRENAME APPLIED\nAll rename changes have been applied across the codebase.\n"
+				content += formatWorkspaceEditResult(applied)
 
 				return mcp.NewToolResultText(content), nil
 			} else {
@@ -22,6 +22,8 @@ func SelectionRangeTool(bridge interfaces.BridgeInterface) (mcp.Tool, server.Too
 			mcp.WithNumber("line", mcp.Description("Line number (0-based) if positions_json not provided"), mcp.Min(0)),
 			mcp.WithNumber("character", mcp.Description("Character position (0-based) if positions_json not provided"), mcp.Min(0)),
 		), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			bridge := bridge.WithContext(ctx)
+
 			uri, err := request.RequireString("uri")
 			if err != nil {
 				logger.Error("selection_range: URI parsing failed", err)
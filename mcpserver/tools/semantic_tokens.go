@@ -24,6 +24,8 @@ func SemanticTokensTool(bridge interfaces.BridgeInterface) (mcp.Tool, server.Too
 			mcp.WithNumber("end_character", mcp.Description("End Character position (0-based)")),
 			mcp.WithString("type", mcp.Description("function, parameter, variable")),
 		), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			bridge := bridge.WithContext(ctx)
+
 			// Parse and validate parameters
 			uri, err := request.RequireString("uri")
 			if err != nil {
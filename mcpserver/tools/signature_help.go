@@ -25,6 +25,8 @@ func SignatureHelpTool(bridge interfaces.BridgeInterface) (mcp.Tool, server.Tool
 			mcp.WithNumber("character", mcp.Description("Character position (0-based) - position within function call parentheses")),
 		),
 		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			bridge := bridge.WithContext(ctx)
+
 			// Parse and validate parameters
 			uri, err := request.RequireString("uri")
 			if err != nil {
@@ -59,6 +59,8 @@ PARAMETERS: query (required), file_context (optional), detail_level (auto/basic/
 			mcp.WithNumber("limit", mcp.Description("Maximum number of detailed results to show (default: 3)"), mcp.Min(1)),
 			mcp.WithNumber("offset", mcp.Description("Number of results to skip for detailed view (default: 0)"), mcp.Min(0)),
 		), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			bridge := bridge.WithContext(ctx)
+
 			// Get session from context
 			session := server.ClientSessionFromContext(ctx)
 			if session == nil {
@@ -357,6 +359,7 @@ func generateDetailedSymbolInfo(bridge interfaces.BridgeInterface, symbol Symbol
 	if symbol.ContainerName != "" {
 		info.WriteString(fmt.Sprintf("Container: %s\n", symbol.ContainerName))
 	}
+	writeEntryPoint(&info, uri, symbol.Name)
 
 	// Try to get enhanced range information from document symbols
 	startLine, startChar, endLine, endChar, err := getEnhancedSymbolRange(bridge, symbol)
@@ -440,6 +443,7 @@ func generateDetailedMultipleSymbols(bridge interfaces.BridgeInterface, symbols
 		if symbol.ContainerName != "" {
 			info.WriteString(fmt.Sprintf("Container: %s\n", symbol.ContainerName))
 		}
+		writeEntryPoint(&info, uri, symbol.Name)
 
 		// Get precise coordinates using semantic tokens
 		preciseChar := FindPreciseCharacterPosition(bridge, uri, line, character, symbol.Name)
@@ -475,6 +479,14 @@ func generateDetailedMultipleSymbols(bridge interfaces.BridgeInterface, symbols
 	return mcp.NewToolResultText(info.String()), nil
 }
 
+// writeEntryPoint notes what calls a procedure bound in the configuration
+// (form/item/command events, event subscriptions, scheduled jobs)
+func writeEntryPoint(info *strings.Builder, uri, name string) {
+	if ok, triggers := bslEntryPoint(nil, uri, name); ok && len(triggers) > 0 {
+		info.WriteString(fmt.Sprintf("Entry point: %s\n", strings.Join(triggers, "; ")))
+	}
+}
+
 // generateSymbolSummary creates a summary of multiple symbol matches
 //
 //	func generateSymbolSummary(symbols []SymbolMatch, query, fileContext string) (*mcp.CallToolResult, error) {
@@ -0,0 +1,111 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"rockerboo/mcp-lsp-bridge/interfaces"
+	"rockerboo/mcp-lsp-bridge/logger"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/myleshyson/lsprotocol-go/protocol"
+)
+
+// bufferEditor is implemented by bridges that keep in-memory document overlays
+type bufferEditor interface {
+	UpdateBuffer(uri, text string) (int32, error)
+	RevertBuffer(uri string) (int32, error)
+	BufferOverlay(uri string) (text string, ok bool)
+}
+
+func UpdateBufferTool(bridge interfaces.BridgeInterface) (mcp.Tool, server.ToolHandlerFunc) {
+	return mcp.NewTool("update_buffer",
+			mcp.WithDescription(`Push proposed file content to the language server WITHOUT writing it to disk. Use it to check an edit before applying it: the new content is validated by diagnostics right away, and hover, definition, references, call_hierarchy and document_diagnostics answer against it until the buffer is reverted or the file is written.
+
+USAGE:
+- Try an edit: uri="file://path/Module.bsl", text="<full new module text>"
+- Skip diagnostics: uri="file://path", text="...", diagnostics=false
+- Drop the overlay: uri="file://path", revert=true
+
+PARAMETERS: uri (required), text (full document content, required unless revert=true), diagnostics (default: true), revert (default: false)
+OUTPUT: Document version sent to the server, followed by diagnostics for the new content`),
+			mcp.WithDestructiveHintAnnotation(false),
+			mcp.WithString("uri", mcp.Description("URI to the file"), mcp.Required()),
+			mcp.WithString("text", mcp.Description("Full new content of the document")),
+			mcp.WithBoolean("diagnostics", mcp.Description("Return diagnostics for the updated content (default: true)"), mcp.DefaultBool(true)),
+			mcp.WithBoolean("revert", mcp.Description("Discard the in-memory content and resync the document from disk (default: false)"), mcp.DefaultBool(false)),
+		), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			bridge := bridge.WithContext(ctx)
+
+			uri, err := request.RequireString("uri")
+			if err != nil {
+				logger.Error("update_buffer: URI parsing failed", err)
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			revert := request.GetBool("revert", false)
+			withDiagnostics := request.GetBool("diagnostics", true)
+
+			text, hasText := request.GetArguments()["text"].(string)
+			if !revert && !hasText {
+				return mcp.NewToolResultError("text is required unless revert=true"), nil
+			}
+
+			editor, ok := bridge.(bufferEditor)
+			if !ok {
+				return mcp.NewToolResultError("in-memory buffers are not supported by this bridge implementation"), nil
+			}
+
+			if result, ok := CheckReadyOrReturn(bridge); !ok {
+				return result, nil
+			}
+
+			var version int32
+			if revert {
+				version, err = editor.RevertBuffer(uri)
+			} else {
+				version, err = editor.UpdateBuffer(uri, text)
+			}
+			if err != nil {
+				logger.Error("update_buffer: Request failed", fmt.Sprintf("URI: %s, Revert: %t, Error: %v", uri, revert, err))
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to update buffer: %v", err)), nil
+			}
+
+			var result strings.Builder
+			if revert {
+				result.WriteString(fmt.Sprintf("Buffer reverted to disk content: %s (version %d)\n", uri, version))
+			} else {
+				result.WriteString(fmt.Sprintf("Buffer updated: %s (version %d)\n", uri, version))
+				result.WriteString("The file on disk is unchanged. Call update_buffer with revert=true to discard the overlay.\n")
+			}
+
+			if !withDiagnostics {
+				return mcp.NewToolResultText(result.String()), nil
+			}
+
+			diagnosticsProvider, ok := bridge.(interface {
+				GetDocumentDiagnostics(uri string, identifier string, previousResultId string) (*protocol.DocumentDiagnosticReport, error)
+			})
+			if !ok {
+				return mcp.NewToolResultText(result.String()), nil
+			}
+
+			report, err := diagnosticsProvider.GetDocumentDiagnostics(uri, "", "")
+			result.WriteString("\n")
+			if err != nil {
+				logger.Warn(fmt.Sprintf("update_buffer: diagnostics failed for %s: %v", uri, err))
+				result.WriteString(fmt.Sprintf("Diagnostics unavailable: %v\n", err))
+			} else {
+				result.WriteString(formatDocumentDiagnostics(report, uri))
+			}
+
+			return mcp.NewToolResultText(result.String()), nil
+		}
+}
+
+// RegisterUpdateBufferTool registers the update_buffer tool with the MCP server.
+func RegisterUpdateBufferTool(mcpServer ToolServer, bridge interfaces.BridgeInterface) {
+	mcpServer.AddTool(UpdateBufferTool(bridge))
+}
@@ -0,0 +1,130 @@
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"rockerboo/mcp-lsp-bridge/interfaces"
+	"rockerboo/mcp-lsp-bridge/mocks"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/mcptest"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/myleshyson/lsprotocol-go/protocol"
+)
+
+// bufferMockBridge adds the optional buffer and diagnostics methods to MockBridge
+type bufferMockBridge struct {
+	*mocks.MockBridge
+}
+
+func (b *bufferMockBridge) WithContext(ctx context.Context) interfaces.BridgeInterface {
+	return b
+}
+
+func (b *bufferMockBridge) UpdateBuffer(uri, text string) (int32, error) {
+	args := b.Called(uri, text)
+	return args.Get(0).(int32), args.Error(1)
+}
+
+func (b *bufferMockBridge) RevertBuffer(uri string) (int32, error) {
+	args := b.Called(uri)
+	return args.Get(0).(int32), args.Error(1)
+}
+
+func (b *bufferMockBridge) BufferOverlay(uri string) (string, bool) {
+	args := b.Called(uri)
+	return args.String(0), args.Bool(1)
+}
+
+func (b *bufferMockBridge) GetDocumentDiagnostics(uri string, identifier string, previousResultId string) (*protocol.DocumentDiagnosticReport, error) {
+	args := b.Called(uri, identifier, previousResultId)
+	return args.Get(0).(*protocol.DocumentDiagnosticReport), args.Error(1)
+}
+
+func callUpdateBuffer(t *testing.T, bridge *bufferMockBridge, arguments map[string]any) *mcp.CallToolResult {
+	t.Helper()
+
+	tool, handler := UpdateBufferTool(bridge)
+	mcpServer, err := mcptest.NewServer(t, server.ServerTool{Tool: tool, Handler: handler})
+	if err != nil {
+		t.Fatalf("Could not create MCP server: %v", err)
+	}
+
+	result, err := mcpServer.Client().CallTool(context.Background(), mcp.CallToolRequest{
+		Request: mcp.Request{Method: "tools/call"},
+		Params: mcp.CallToolParams{
+			Name:      "update_buffer",
+			Arguments: arguments,
+		},
+	})
+	if err != nil {
+		t.Fatalf("Error calling tool: %v", err)
+	}
+	return result
+}
+
+func TestUpdateBufferTool_ReturnsDiagnosticsForOverlay(t *testing.T) {
+	bridge := &bufferMockBridge{MockBridge: &mocks.MockBridge{}}
+	uri := "file:///projects/CommonModules/Common/Ext/Module.bsl"
+	text := "Процедура Тест()\nКонецПроцедуры"
+
+	severity := protocol.DiagnosticSeverityError
+	report := &protocol.DocumentDiagnosticReport{
+		Value: protocol.RelatedFullDocumentDiagnosticReport{
+			Items: []protocol.Diagnostic{
+				{
+					Range:    protocol.Range{Start: protocol.Position{Line: 0, Character: 10}, End: protocol.Position{Line: 0, Character: 14}},
+					Severity: &severity,
+					Message:  "Unused procedure",
+				},
+			},
+		},
+	}
+
+	bridge.On("UpdateBuffer", uri, text).Return(int32(4), nil)
+	bridge.On("GetDocumentDiagnostics", uri, "", "").Return(report, nil)
+
+	result := callUpdateBuffer(t, bridge, map[string]any{"uri": uri, "text": text})
+	if result.IsError {
+		t.Fatalf("Expected success, got error: %#v", result.Content)
+	}
+
+	output := result.Content[0].(mcp.TextContent).Text
+	if !strings.Contains(output, "version 4") || !strings.Contains(output, "Unused procedure") {
+		t.Fatalf("Unexpected output: %q", output)
+	}
+
+	bridge.AssertExpectations(t)
+}
+
+func TestUpdateBufferTool_Revert(t *testing.T) {
+	bridge := &bufferMockBridge{MockBridge: &mocks.MockBridge{}}
+	uri := "file:///projects/Module.bsl"
+
+	bridge.On("RevertBuffer", uri).Return(int32(5), nil)
+
+	result := callUpdateBuffer(t, bridge, map[string]any{"uri": uri, "revert": true, "diagnostics": false})
+	if result.IsError {
+		t.Fatalf("Expected success, got error: %#v", result.Content)
+	}
+
+	output := result.Content[0].(mcp.TextContent).Text
+	if !strings.Contains(output, "reverted") || !strings.Contains(output, "version 5") {
+		t.Fatalf("Unexpected output: %q", output)
+	}
+
+	bridge.AssertExpectations(t)
+}
+
+func TestUpdateBufferTool_RequiresText(t *testing.T) {
+	bridge := &bufferMockBridge{MockBridge: &mocks.MockBridge{}}
+
+	result := callUpdateBuffer(t, bridge, map[string]any{"uri": "file:///projects/Module.bsl"})
+	if !result.IsError {
+		t.Fatalf("Expected error when text is missing")
+	}
+
+	bridge.AssertExpectations(t)
+}
@@ -1,6 +1,7 @@
 package tools
 
 import (
+	"errors"
 	"fmt"
 	"math"
 	"os"
@@ -12,6 +13,7 @@ import (
 
 	"rockerboo/mcp-lsp-bridge/interfaces"
 	"rockerboo/mcp-lsp-bridge/logger"
+	"rockerboo/mcp-lsp-bridge/types"
 	"rockerboo/mcp-lsp-bridge/utils"
 
 	"github.com/mark3labs/mcp-go/mcp"
@@ -34,11 +36,15 @@ func FindPreciseCharacterPosition(bridge interfaces.BridgeInterface, uri string,
 
 	// Look for our symbol name in the semantic tokens on this line
 	for _, token := range tokens {
-		if token.Range.Start.Line == line && strings.Contains(token.Text, symbolName) {
-			// Found a token containing our symbol name on the right line
-			// Use the start character of this token for more precise positioning
-			logger.Debug(fmt.Sprintf("Found precise position for %s: char %d -> %d", symbolName, approxCharacter, token.Range.Start.Character))
-			return token.Range.Start.Character
+		if token.Range.Start.Line != line {
+			continue
+		}
+		if idx := strings.Index(token.Text, symbolName); idx >= 0 {
+			// Found a token containing our symbol name on the right line. Characters
+			// before the name count in the server's encoding, not in bytes.
+			character := token.Range.Start.Character + utils.CharacterLen(token.Text[:idx], bridge.PositionEncoding(uri))
+			logger.Debug(fmt.Sprintf("Found precise position for %s: char %d -> %d", symbolName, approxCharacter, character))
+			return character
 		}
 	}
 
@@ -483,6 +489,29 @@ func formatWorkspaceEdit(workspaceEdit *protocol.WorkspaceEdit) string {
 	return result.String()
 }
 
+// formatWorkspaceEditResult lists the files written by an applied workspace edit
+func formatWorkspaceEditResult(applied *types.WorkspaceEditResult) string {
+	if applied == nil || len(applied.Files) == 0 {
+		return "No files were changed\n"
+	}
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("FILES CHANGED (%d):\n", len(applied.Files)))
+	for _, file := range applied.Files {
+		switch {
+		case file.OldPath != "":
+			result.WriteString(fmt.Sprintf("   %s: %s -> %s", file.Kind, file.OldPath, file.Path))
+		default:
+			result.WriteString(fmt.Sprintf("   %s: %s", file.Kind, file.Path))
+		}
+		if file.Edits > 0 {
+			result.WriteString(fmt.Sprintf(" (%d edits)", file.Edits))
+		}
+		result.WriteString("\n")
+	}
+	return result.String()
+}
+
 // Helper function to format implementations
 func formatImplementations(implementations []protocol.Location) string {
 	var result strings.Builder
@@ -704,9 +733,7 @@ func getRangeContent(
 		if isRelative {
 			filePath = path.Join(mapper.ContainerRoot(), normalizedPath)
 		}
-		slash := strings.ReplaceAll(filePath, "\\", "/")
-		cr := strings.TrimSuffix(mapper.ContainerRoot(), "/")
-		if !(slash == cr || strings.HasPrefix(slash, cr+"/")) {
+		if !mapper.IsContainerPath(filePath) {
 			if mapped, mapErr := mapper.HostToContainer(filePath); mapErr == nil {
 				filePath = mapped
 			} else {
@@ -726,8 +753,11 @@ func getRangeContent(
 		return "", fmt.Errorf("failed to read file %s: %w", filePath, err)
 	}
 
-	// 3. Split into lines.
-	lines := strings.Split(string(content), "\n")
+	// 3. Split into lines. Characters are counted in the position encoding
+	// of the language server, like the positions its other tools report.
+	text := string(content)
+	enc := bridge.PositionEncoding(uri)
+	lines := utils.SplitLines(text)
 	linesLen, err := safeUint32(len(lines))
 	if err != nil {
 		return "", fmt.Errorf("file too large (too many lines): %w", err)
@@ -744,97 +774,40 @@ func getRangeContent(
 			startLine, startChar, endLine, endChar)
 	}
 
-	// 5. Helper: clamp or reject out-of-bounds character indices.
-	clamp := func(line string, pos uint32, lineNo int, which string) (uint32, error) {
-		lineLen, err := safeUint32(len(line))
-		if err != nil {
-			return 0, fmt.Errorf("line too long: %w", err)
-		}
-		if pos > lineLen {
+	// 5. Helper: turn a character into a byte offset in the text, clamping or
+	// rejecting characters past the end of the line.
+	offset := func(lineNo uint32, pos uint32, which string) (int, error) {
+		line := lines[lineNo]
+		off, err := utils.CharacterOffset(line.Text, pos, enc)
+		if errors.Is(err, utils.ErrCharacterPastLineEnd) {
 			if strict {
 				return 0, fmt.Errorf("invalid %s character on line %d: %d (line length: %d)",
-					which, lineNo, pos, lineLen)
+					which, lineNo, pos, utils.CharacterLen(line.Text, enc))
 			}
-			return lineLen, nil // clamp to line end
-		}
-		return pos, nil
-	}
-
-	// 6. Extract the requested text.
-	var result []string
-
-	if startLine == endLine {
-		// Single-line slice.
-		line := lines[startLine]
-		start, err := clamp(line, startChar, int(startLine), "start")
-		if err != nil {
-			return "", err
-		}
-		end, err := clamp(line, endChar, int(startLine), "end")
-		if err != nil {
-			return "", err
-		}
-		if start > end {
-			return "", fmt.Errorf("invalid character range on line %d: start %d > end %d",
-				startLine, start, end)
-		}
-		lineStartLen, err := safeUint32(len(line))
-		if err != nil {
-			return "", fmt.Errorf("line too long: %w", err)
-		}
-		if start >= lineStartLen {
-			result = append(result, "")
-		} else {
-			result = append(result, line[start:end])
-		}
-	} else {
-		// Multi-line slice.
-		// First line: from startChar to line end.
-		first := lines[startLine]
-		start, err := clamp(first, startChar, int(startLine), "start")
-		if err != nil {
-			return "", err
+			return line.End, nil // clamp to line end
 		}
-		firstLen, err := safeUint32(len(first))
 		if err != nil {
-			return "", fmt.Errorf("line too long: %w", err)
-		}
-		if start >= firstLen {
-			result = append(result, "")
-		} else {
-			result = append(result, first[start:])
-		}
-
-		// Full middle lines.
-		for i := startLine + 1; i < endLine; i++ {
-			result = append(result, lines[i])
+			return 0, fmt.Errorf("invalid %s character on line %d: %w", which, lineNo, err)
 		}
+		return line.Start + off, nil
+	}
 
-		// Last line: treat endChar as exclusive, but allow clamping/clipping
-		last := lines[endLine]
-		lineLen, err := safeUint32(len(last))
-		if err != nil {
-			return "", fmt.Errorf("line too long: %w", err)
-		}
+	// 6. Extract the requested text, line terminators included.
+	start, err := offset(startLine, startChar, "start")
+	if err != nil {
+		return "", err
+	}
 
-		if endChar > lineLen {
-			if strict {
-				return "", fmt.Errorf("invalid end character on line %d: %d (line length: %d)",
-					endLine, endChar, lineLen)
-			}
-			// non-strict: take the entire last line
-			result = append(result, last)
-		} else {
-			// exclusive index
-			endIdx := endChar
-			if endIdx > 0 {
-				endIdx--
-			}
-			result = append(result, last[:endIdx])
-		}
+	if startLine != endLine && endChar > 0 {
+		// Multi-line ranges treat endChar as one past the last character taken
+		endChar--
+	}
+	end, err := offset(endLine, endChar, "end")
+	if err != nil {
+		return "", err
 	}
 
-	return strings.Join(result, "\n"), nil
+	return text[start:end], nil
 }
 
 // ResolvedFileContext represents the result of file context resolution
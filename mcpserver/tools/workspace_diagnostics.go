@@ -2,7 +2,9 @@ package tools
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
@@ -10,7 +12,9 @@ import (
 	"rockerboo/mcp-lsp-bridge/collections"
 	"rockerboo/mcp-lsp-bridge/interfaces"
 	"rockerboo/mcp-lsp-bridge/logger"
+	"rockerboo/mcp-lsp-bridge/lsp"
 	"rockerboo/mcp-lsp-bridge/types"
+	"rockerboo/mcp-lsp-bridge/utils"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
@@ -34,12 +38,28 @@ USAGE:
 - Full scan: workspace_uri="file://project/root"
 - Check health: Review error categories and language-specific issues
 
+SOURCE:
+- Diagnostics pushed by the server (textDocument/publishDiagnostics) are cached. Once indexing is
+  complete and no file changes are pending they are served instantly without a workspace/diagnostic pull.
+- source="auto" (default) uses the cache when it is fresh, source="store" always uses it (possibly stale),
+  source="pull" always sends workspace/diagnostic.
+
+FILTERS: severity (error|warning|information|hint, keeps that level and more severe), code (comma-separated),
+path (glob such as "CommonModules/**/Module.bsl"), since (RFC3339, cached results only)
+
 PARAMETERS: workspace_uri (required)
 OUTPUT: Categorized diagnostics by language with error explanations and suggestions`),
 			mcp.WithDestructiveHintAnnotation(false),
 			mcp.WithString("workspace_uri", mcp.Description("URI to the workspace/project root")),
 			mcp.WithString("identifier", mcp.Description("Optional identifier for diagnostic session")), // TODO: Add optional when supported
+			mcp.WithString("source", mcp.Description("Where diagnostics come from: auto, store or pull (default: auto)"), mcp.Enum("auto", "store", "pull")),
+			mcp.WithString("severity", mcp.Description("Minimum severity to include: error, warning, information or hint")),
+			mcp.WithString("code", mcp.Description("Comma-separated diagnostic codes to include")),
+			mcp.WithString("path", mcp.Description("Glob over file paths to include")),
+			mcp.WithString("since", mcp.Description("RFC3339 timestamp; only diagnostics published after it (cached results only)")),
 		), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			bridge := bridge.WithContext(ctx)
+
 			// This tool is intentionally long-running on large BSL workspaces.
 			// Clamp execution to 10 minutes to avoid runaway scans.
 			ctx, cancel := context.WithTimeout(ctx, 10*time.Minute)
@@ -64,6 +84,16 @@ OUTPUT: Categorized diagnostics by language with error explanations and suggesti
 				identifier = id
 			}
 
+			source := request.GetString("source", "auto")
+			if source != "auto" && source != "store" && source != "pull" {
+				return mcp.NewToolResultError(fmt.Sprintf("invalid source %q: expected auto, store or pull", source)), nil
+			}
+
+			filter, err := diagnosticsFilterFromRequest(request)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
 			if result, ok := CheckReadyOrReturn(bridge); !ok {
 				return result, nil
 			}
@@ -89,10 +119,19 @@ OUTPUT: Categorized diagnostics by language with error explanations and suggesti
 				return mcp.NewToolResultError("No LSP clients available for detected languages"), nil
 			}
 
+			if source != "pull" {
+				if output, ok := publishedWorkspaceDiagnostics(clients, normalized, filter, source == "store"); ok {
+					return mcp.NewToolResultText(output), nil
+				}
+				if source == "store" {
+					return mcp.NewToolResultError("No cached diagnostics available: the language server has not published any yet"), nil
+				}
+			}
+
 			// Convert clients to async operations
 			ops := collections.TransformMap(clients, func(client types.LanguageClientInterface) func() (*protocol.WorkspaceDiagnosticReport, error) {
 				return func() (*protocol.WorkspaceDiagnosticReport, error) {
-					return client.WorkspaceDiagnostic(identifier)
+					return client.WorkspaceDiagnosticContext(ctx, identifier)
 				}
 			})
 
@@ -116,7 +155,7 @@ OUTPUT: Categorized diagnostics by language with error explanations and suggesti
 					logger.Warn(fmt.Sprintf("Workspace diagnostics failed for %s: %v", result.Key, result.Error))
 				} else if result.Value != nil {
 					// Extract diagnostics from the workspace report
-					diagnostics := extractDiagnosticsFromWorkspaceReport(result.Value)
+					diagnostics := filterWorkspaceReportDiagnostics(result.Value, filter)
 					allDiagnostics = append(allDiagnostics, diagnostics...)
 					languageResults = append(languageResults, LanguageDiagnosticResult{
 						Language:    string(result.Key),
@@ -151,6 +190,80 @@ type DiagnosticError struct {
 	Explanation string
 }
 
+// CollectWorkspaceDiagnostics returns the diagnostics of every document under
+// workspaceURI, sorted by URI. source works like the tool parameter: "auto" uses the
+// publishDiagnostics cache when it is fresh, "store" always uses it and "pull" always
+// sends workspace/diagnostic. Unlike the tool, a failing language server is an error
+// so that callers never mistake a partial result for a clean workspace.
+func CollectWorkspaceDiagnostics(ctx context.Context, bridge interfaces.BridgeInterface, workspaceURI, source string, filter lsp.DiagnosticsFilter) ([]lsp.PublishedDiagnostics, error) {
+	normalized := bridge.NormalizeURIForLSP(workspaceURI)
+	if after, ok := strings.CutPrefix(normalized, "file://"); ok {
+		normalized = after
+	}
+
+	languages, err := bridge.DetectProjectLanguages(normalized)
+	if err != nil {
+		return nil, fmt.Errorf("failed to detect project languages: %w", err)
+	}
+	if len(languages) == 0 {
+		return nil, nil
+	}
+
+	clients, err := bridge.GetMultiLanguageClients(collections.ToString(languages))
+	if err != nil || len(clients) == 0 {
+		return nil, errors.New("no LSP clients available for detected languages")
+	}
+
+	if source != "pull" {
+		if cached, ok := cachedWorkspaceDiagnostics(clients, normalized, filter, source == "store"); ok {
+			documents := cached.documents
+			sort.Slice(documents, func(i, j int) bool { return documents[i].URI < documents[j].URI })
+			return documents, nil
+		}
+		if source == "store" {
+			return nil, errors.New("no cached diagnostics available: the language server has not published any yet")
+		}
+	}
+
+	ops := collections.TransformMap(clients, func(client types.LanguageClientInterface) func() (*protocol.WorkspaceDiagnosticReport, error) {
+		return func() (*protocol.WorkspaceDiagnosticReport, error) {
+			return client.WorkspaceDiagnosticContext(ctx, "mcp-lsp-bridge-workspace-diagnostics")
+		}
+	})
+	results, err := async.MapWithKeys(ctx, ops)
+	if err != nil {
+		return nil, err
+	}
+
+	var documents []lsp.PublishedDiagnostics
+	for _, result := range results {
+		if result.Error != nil {
+			return nil, fmt.Errorf("workspace diagnostics failed for %s: %w", result.Key, result.Error)
+		}
+		if result.Value == nil {
+			continue
+		}
+		for _, item := range result.Value.Items {
+			full, ok := item.Value.(protocol.WorkspaceFullDocumentDiagnosticReport)
+			if !ok || !filter.MatchesURI(string(full.Uri)) || !utils.IsPathUnder(utils.URIToFilePath(string(full.Uri)), normalized) {
+				continue
+			}
+			doc := lsp.PublishedDiagnostics{URI: string(full.Uri)}
+			for _, d := range full.Items {
+				if filter.MatchesDiagnostic(d) {
+					doc.Diagnostics = append(doc.Diagnostics, d)
+				}
+			}
+			if len(doc.Diagnostics) > 0 {
+				documents = append(documents, doc)
+			}
+		}
+	}
+
+	sort.Slice(documents, func(i, j int) bool { return documents[i].URI < documents[j].URI })
+	return documents, nil
+}
+
 // extractDiagnosticsFromWorkspaceReport extracts core Diagnostic items from a WorkspaceDiagnosticReport
 func extractDiagnosticsFromWorkspaceReport(report *protocol.WorkspaceDiagnosticReport) []protocol.Diagnostic {
 	var diagnostics []protocol.Diagnostic
@@ -170,6 +283,161 @@ func extractDiagnosticsFromWorkspaceReport(report *protocol.WorkspaceDiagnosticR
 	return diagnostics
 }
 
+// filterWorkspaceReportDiagnostics extracts diagnostics from a pulled report, applying every filter except Since
+func filterWorkspaceReportDiagnostics(report *protocol.WorkspaceDiagnosticReport, filter lsp.DiagnosticsFilter) []protocol.Diagnostic {
+	var diagnostics []protocol.Diagnostic
+
+	for _, item := range report.Items {
+		full, ok := item.Value.(protocol.WorkspaceFullDocumentDiagnosticReport)
+		if !ok || !filter.MatchesURI(string(full.Uri)) {
+			continue
+		}
+		for _, d := range full.Items {
+			if filter.MatchesDiagnostic(d) {
+				diagnostics = append(diagnostics, d)
+			}
+		}
+	}
+
+	return diagnostics
+}
+
+// diagnosticsPublisher is implemented by clients that cache textDocument/publishDiagnostics
+type diagnosticsPublisher interface {
+	PublishedDiagnostics(filter lsp.DiagnosticsFilter) (*lsp.DiagnosticsSnapshot, error)
+}
+
+// diagnosticsFilterFromRequest reads the severity, code, path and since filters
+func diagnosticsFilterFromRequest(request mcp.CallToolRequest) (lsp.DiagnosticsFilter, error) {
+	return ParseDiagnosticsFilter(
+		request.GetString("severity", ""),
+		request.GetString("code", ""),
+		request.GetString("path", ""),
+		request.GetString("since", ""),
+	)
+}
+
+// ParseDiagnosticsFilter builds a filter from the textual severity, comma-separated
+// codes, path glob and RFC3339 since values; empty values match everything
+func ParseDiagnosticsFilter(severity, codes, path, since string) (lsp.DiagnosticsFilter, error) {
+	filter := lsp.DiagnosticsFilter{Path: path}
+
+	if severity != "" {
+		levels := map[string]protocol.DiagnosticSeverity{
+			"error":       protocol.DiagnosticSeverityError,
+			"warning":     protocol.DiagnosticSeverityWarning,
+			"information": protocol.DiagnosticSeverityInformation,
+			"info":        protocol.DiagnosticSeverityInformation,
+			"hint":        protocol.DiagnosticSeverityHint,
+		}
+		level, ok := levels[strings.ToLower(severity)]
+		if !ok {
+			return filter, fmt.Errorf("invalid severity %q: expected error, warning, information or hint", severity)
+		}
+		filter.Severity = int(level)
+	}
+
+	for _, code := range strings.Split(codes, ",") {
+		if code = strings.TrimSpace(code); code != "" {
+			filter.Code = append(filter.Code, code)
+		}
+	}
+
+	if since != "" {
+		t, err := time.Parse(time.RFC3339, since)
+		if err != nil {
+			return filter, fmt.Errorf("invalid since %q: expected RFC3339 timestamp", since)
+		}
+		filter.Since = t
+	}
+
+	return filter, nil
+}
+
+// cachedWorkspace is the publishDiagnostics cache of every client, limited to one workspace
+type cachedWorkspace struct {
+	languages []LanguageDiagnosticResult
+	documents []lsp.PublishedDiagnostics
+	total     []protocol.Diagnostic
+	updatedAt time.Time
+	fresh     bool
+}
+
+// cachedWorkspaceDiagnostics collects cached diagnostics under workspacePath.
+// ok is false when a client has no cache, or the cache is stale and allowStale is false.
+func cachedWorkspaceDiagnostics(clients map[types.Language]types.LanguageClientInterface, workspacePath string, filter lsp.DiagnosticsFilter, allowStale bool) (*cachedWorkspace, bool) {
+	languages := make([]string, 0, len(clients))
+	for language := range clients {
+		languages = append(languages, string(language))
+	}
+	sort.Strings(languages)
+
+	cached := &cachedWorkspace{fresh: true}
+
+	for _, language := range languages {
+		publisher, ok := clients[types.Language(language)].(diagnosticsPublisher)
+		if !ok {
+			return nil, false
+		}
+		snapshot, err := publisher.PublishedDiagnostics(filter)
+		if err != nil {
+			logger.Warn(fmt.Sprintf("workspace_diagnostics: cached diagnostics unavailable for %s: %v", language, err))
+			return nil, false
+		}
+		if snapshot.Tracked == 0 || (!snapshot.Fresh && !allowStale) {
+			return nil, false
+		}
+		cached.fresh = cached.fresh && snapshot.Fresh
+		if snapshot.UpdatedAt.After(cached.updatedAt) {
+			cached.updatedAt = snapshot.UpdatedAt
+		}
+
+		var diagnostics []protocol.Diagnostic
+		for _, doc := range snapshot.Documents {
+			if !utils.IsPathUnder(utils.URIToFilePath(doc.URI), workspacePath) {
+				continue
+			}
+			cached.documents = append(cached.documents, doc)
+			diagnostics = append(diagnostics, doc.Diagnostics...)
+		}
+		cached.total = append(cached.total, diagnostics...)
+		cached.languages = append(cached.languages, LanguageDiagnosticResult{Language: language, Diagnostics: diagnostics})
+	}
+
+	return cached, true
+}
+
+// publishedWorkspaceDiagnostics formats cached diagnostics for the workspace.
+// ok is false when a client has no cache, or the cache is stale and allowStale is false.
+func publishedWorkspaceDiagnostics(clients map[types.Language]types.LanguageClientInterface, workspacePath string, filter lsp.DiagnosticsFilter, allowStale bool) (string, bool) {
+	cached, ok := cachedWorkspaceDiagnostics(clients, workspacePath, filter, allowStale)
+	if !ok {
+		return "", false
+	}
+	documents := cached.documents
+
+	var result strings.Builder
+	state := "fresh"
+	if !cached.fresh {
+		state = "STALE - indexing is running or file changes are pending"
+	}
+	fmt.Fprintf(&result, "SOURCE: cached publishDiagnostics (%s, updated %s)\n\n", state, cached.updatedAt.Format(time.RFC3339))
+	result.WriteString(formatWorkspaceDiagnosticsByLanguage(cached.languages, cached.total, nil))
+
+	if len(documents) > 0 {
+		sort.SliceStable(documents, func(i, j int) bool {
+			return len(documents[i].Diagnostics) > len(documents[j].Diagnostics)
+		})
+		shown := min(20, len(documents))
+		fmt.Fprintf(&result, "FILES WITH MOST DIAGNOSTICS (%d of %d):\n", shown, len(documents))
+		for _, doc := range documents[:shown] {
+			fmt.Fprintf(&result, "  %s: %d\n", utils.URIToFilePath(doc.URI), len(doc.Diagnostics))
+		}
+	}
+
+	return result.String(), true
+}
+
 // categorizeDiagnosticError categorizes diagnostic errors for better user understanding
 func categorizeDiagnosticError(language string, err error) DiagnosticError {
 	errorStr := err.Error()
@@ -0,0 +1,164 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"rockerboo/mcp-lsp-bridge/lsp"
+	"rockerboo/mcp-lsp-bridge/mocks"
+	"rockerboo/mcp-lsp-bridge/types"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/mcptest"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/myleshyson/lsprotocol-go/protocol"
+	"github.com/stretchr/testify/mock"
+)
+
+// publishingMockClient adds the optional PublishedDiagnostics method to MockLanguageClient
+type publishingMockClient struct {
+	*mocks.MockLanguageClient
+}
+
+func (c *publishingMockClient) PublishedDiagnostics(filter lsp.DiagnosticsFilter) (*lsp.DiagnosticsSnapshot, error) {
+	args := c.Called(filter)
+	return args.Get(0).(*lsp.DiagnosticsSnapshot), args.Error(1)
+}
+
+func callWorkspaceDiagnostics(t *testing.T, client types.LanguageClientInterface, arguments map[string]any) string {
+	t.Helper()
+
+	bridge := &mocks.MockBridge{}
+	bridge.On("DetectProjectLanguages", "/projects").Return([]types.Language{"bsl"}, nil)
+	bridge.On("GetMultiLanguageClients", []string{"bsl"}).Return(map[types.Language]types.LanguageClientInterface{"bsl": client}, nil)
+
+	tool, handler := WorkspaceDiagnosticsTool(bridge)
+	mcpServer, err := mcptest.NewServer(t, server.ServerTool{Tool: tool, Handler: handler})
+	if err != nil {
+		t.Fatalf("Could not create MCP server: %v", err)
+	}
+
+	arguments["workspace_uri"] = "file:///projects"
+	result, err := mcpServer.Client().CallTool(context.Background(), mcp.CallToolRequest{
+		Request: mcp.Request{Method: "tools/call"},
+		Params: mcp.CallToolParams{
+			Name:      "workspace_diagnostics",
+			Arguments: arguments,
+		},
+	})
+	if err != nil {
+		t.Fatalf("Error calling tool: %v", err)
+	}
+	if result.IsError {
+		t.Fatalf("Expected success, got error: %#v", result.Content)
+	}
+	return result.Content[0].(mcp.TextContent).Text
+}
+
+func publishedSnapshot(fresh bool) *lsp.DiagnosticsSnapshot {
+	severity := protocol.DiagnosticSeverityWarning
+	return &lsp.DiagnosticsSnapshot{
+		Documents: []lsp.PublishedDiagnostics{
+			{
+				URI:        "file:///projects/CommonModules/Common/Ext/Module.bsl",
+				ReceivedAt: time.Now(),
+				Diagnostics: []protocol.Diagnostic{
+					{Severity: &severity, Message: "Unused variable"},
+				},
+			},
+		},
+		Total:     1,
+		Tracked:   120,
+		UpdatedAt: time.Now(),
+		Fresh:     fresh,
+	}
+}
+
+func TestWorkspaceDiagnosticsTool_ServesFreshPublishedDiagnostics(t *testing.T) {
+	client := &publishingMockClient{MockLanguageClient: &mocks.MockLanguageClient{}}
+	client.On("PublishedDiagnostics", lsp.DiagnosticsFilter{
+		Severity: int(protocol.DiagnosticSeverityWarning),
+		Code:     []string{"UnusedLocalVariable"},
+	}).Return(publishedSnapshot(true), nil)
+
+	output := callWorkspaceDiagnostics(t, client, map[string]any{"severity": "warning", "code": "UnusedLocalVariable"})
+
+	for _, expected := range []string{"cached publishDiagnostics (fresh", "Total diagnostics: 1", "/projects/CommonModules/Common/Ext/Module.bsl: 1"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected output to contain %q, got: %s", expected, output)
+		}
+	}
+	client.AssertNotCalled(t, "WorkspaceDiagnostic", mock.Anything)
+}
+
+func TestWorkspaceDiagnosticsTool_PullsWhenStoreIsStale(t *testing.T) {
+	client := &publishingMockClient{MockLanguageClient: &mocks.MockLanguageClient{}}
+	client.On("PublishedDiagnostics", lsp.DiagnosticsFilter{}).Return(publishedSnapshot(false), nil)
+	client.On("WorkspaceDiagnostic", "mcp-lsp-bridge-workspace-diagnostics").Return(&protocol.WorkspaceDiagnosticReport{}, nil)
+
+	output := callWorkspaceDiagnostics(t, client, map[string]any{})
+	if strings.Contains(output, "cached publishDiagnostics") {
+		t.Errorf("Expected pulled diagnostics, got: %s", output)
+	}
+	client.AssertExpectations(t)
+
+	// source=store accepts a stale cache
+	output = callWorkspaceDiagnostics(t, client, map[string]any{"source": "store"})
+	if !strings.Contains(output, "STALE") {
+		t.Errorf("Expected stale cache notice, got: %s", output)
+	}
+}
+
+func TestCollectWorkspaceDiagnostics(t *testing.T) {
+	warning, hint := protocol.DiagnosticSeverityWarning, protocol.DiagnosticSeverityHint
+	document := func(uri string) protocol.WorkspaceDocumentDiagnosticReport {
+		return protocol.WorkspaceDocumentDiagnosticReport{Value: protocol.WorkspaceFullDocumentDiagnosticReport{
+			Kind: "full",
+			Uri:  protocol.DocumentUri(uri),
+			Items: []protocol.Diagnostic{
+				{Severity: &warning, Message: "Unused variable"},
+				{Severity: &hint, Message: "Typo"},
+			},
+		}}
+	}
+
+	client := &publishingMockClient{MockLanguageClient: &mocks.MockLanguageClient{}}
+	client.On("WorkspaceDiagnostic", "mcp-lsp-bridge-workspace-diagnostics").Return(&protocol.WorkspaceDiagnosticReport{
+		Items: []protocol.WorkspaceDocumentDiagnosticReport{
+			document("file:///projects/CommonModules/B/Ext/Module.bsl"),
+			document("file:///elsewhere/Module.bsl"),
+			document("file:///projects-archive/Module.bsl"),
+			document("file:///projects/CommonModules/A/Ext/Module.bsl"),
+		},
+	}, nil)
+
+	bridge := &mocks.MockBridge{}
+	bridge.On("DetectProjectLanguages", "/projects").Return([]types.Language{"bsl"}, nil)
+	bridge.On("GetMultiLanguageClients", []string{"bsl"}).Return(map[types.Language]types.LanguageClientInterface{"bsl": client}, nil)
+
+	documents, err := CollectWorkspaceDiagnostics(context.Background(), bridge, "file:///projects", "pull",
+		lsp.DiagnosticsFilter{Severity: int(protocol.DiagnosticSeverityWarning)})
+	if err != nil {
+		t.Fatalf("Expected success, got: %v", err)
+	}
+	if len(documents) != 2 {
+		t.Fatalf("Expected the two workspace documents, got: %#v", documents)
+	}
+	if documents[0].URI != "file:///projects/CommonModules/A/Ext/Module.bsl" || len(documents[0].Diagnostics) != 1 {
+		t.Errorf("Expected sorted documents without hints, got: %#v", documents[0])
+	}
+	client.AssertNotCalled(t, "PublishedDiagnostics", mock.Anything)
+
+	failing := &mocks.MockLanguageClient{}
+	failing.On("WorkspaceDiagnostic", mock.Anything).Return((*protocol.WorkspaceDiagnosticReport)(nil), errors.New("server crashed"))
+	bridge = &mocks.MockBridge{}
+	bridge.On("DetectProjectLanguages", "/projects").Return([]types.Language{"bsl"}, nil)
+	bridge.On("GetMultiLanguageClients", []string{"bsl"}).Return(map[types.Language]types.LanguageClientInterface{"bsl": failing}, nil)
+
+	if _, err := CollectWorkspaceDiagnostics(context.Background(), bridge, "file:///projects", "auto", lsp.DiagnosticsFilter{}); err == nil {
+		t.Error("Expected a failing language server to fail the collection")
+	}
+}
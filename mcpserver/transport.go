@@ -0,0 +1,144 @@
+package mcpserver
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"rockerboo/mcp-lsp-bridge/interfaces"
+	"rockerboo/mcp-lsp-bridge/logger"
+	"rockerboo/mcp-lsp-bridge/mcpserver/tools"
+
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// Transports supported by Serve
+const (
+	TransportStdio = "stdio"
+	TransportHTTP  = "http"
+	TransportSSE   = "sse"
+)
+
+// AuthTokenEnv names the environment variable holding the bearer token for HTTP transports
+const AuthTokenEnv = "MCP_LSP_AUTH_TOKEN"
+
+// HealthPath serves the LSP readiness status without authentication
+const HealthPath = "/health"
+
+// TransportConfig selects how the MCP server is exposed
+type TransportConfig struct {
+	Transport string // stdio, http (streamable HTTP) or sse
+	Addr      string // listen address for http and sse, e.g. ":8080"
+	AuthToken string // bearer token required on MCP endpoints; empty disables auth
+}
+
+// Serve runs the MCP server on the configured transport until it fails or ctx is cancelled.
+// The HTTP transports serve any number of clients that all share one bridge.
+func Serve(ctx context.Context, mcpServer *server.MCPServer, bridge interfaces.BridgeInterface, cfg TransportConfig) error {
+	if cfg.Transport == "" || cfg.Transport == TransportStdio {
+		return server.ServeStdio(mcpServer)
+	}
+
+	handler, err := NewHTTPHandler(mcpServer, bridge, cfg.Transport, cfg.AuthToken)
+	if err != nil {
+		return err
+	}
+	if cfg.AuthToken == "" {
+		logger.Warn(fmt.Sprintf("MCP %s transport on %s has no authentication, set %s to require a bearer token", cfg.Transport, cfg.Addr, AuthTokenEnv))
+	}
+
+	httpServer := &http.Server{
+		Addr:              cfg.Addr,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := httpServer.Shutdown(shutdownCtx); err != nil {
+			logger.Warn("MCP HTTP server shutdown: " + err.Error())
+		}
+	}()
+
+	logger.Info(fmt.Sprintf("Serving MCP over %s on %s", cfg.Transport, cfg.Addr))
+	if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// NewHTTPHandler builds the HTTP handler for the http or sse transport:
+// the MCP endpoints behind bearer auth plus an unauthenticated health endpoint.
+//
+// Endpoints: http serves /mcp; sse serves /sse and /message.
+func NewHTTPHandler(mcpServer *server.MCPServer, bridge interfaces.BridgeInterface, transport string, authToken string) (http.Handler, error) {
+	var mcpHandler http.Handler
+	var paths []string
+
+	switch transport {
+	case TransportHTTP:
+		mcpHandler = server.NewStreamableHTTPServer(mcpServer,
+			server.WithHeartbeatInterval(30*time.Second),
+		)
+		paths = []string{"/mcp"}
+	case TransportSSE:
+		// Relative message endpoint: the host port is usually remapped by Docker
+		mcpHandler = server.NewSSEServer(mcpServer,
+			server.WithUseFullURLForMessageEndpoint(false),
+			server.WithKeepAlive(true),
+		)
+		paths = []string{"/sse", "/message"}
+	default:
+		return nil, fmt.Errorf("unknown transport %q: expected stdio, http or sse", transport)
+	}
+
+	mux := http.NewServeMux()
+	for _, path := range paths {
+		mux.Handle(path, requireBearerToken(authToken, mcpHandler))
+	}
+	mux.HandleFunc(HealthPath, healthHandler(bridge))
+	return mux, nil
+}
+
+// requireBearerToken rejects requests without "Authorization: Bearer <token>".
+// An empty token disables the check.
+func requireBearerToken(token string, next http.Handler) http.Handler {
+	if token == "" {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		provided, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="mcp-lsp-bridge"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// healthHandler reports the same status as lsp_status; 503 until the language servers are ready
+func healthHandler(bridge interfaces.BridgeInterface) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		status, err := tools.BuildLSPStatus(bridge)
+		if err != nil {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_ = json.NewEncoder(w).Encode(map[string]any{"ready": false, "error": err.Error()})
+			return
+		}
+
+		if !status.Ready {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		_ = json.NewEncoder(w).Encode(status)
+	}
+}
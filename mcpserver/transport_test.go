@@ -0,0 +1,123 @@
+package mcpserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"rockerboo/mcp-lsp-bridge/bridge"
+	"rockerboo/mcp-lsp-bridge/lsp"
+	"rockerboo/mcp-lsp-bridge/mocks"
+	"rockerboo/mcp-lsp-bridge/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const initializeRequest = `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{},"clientInfo":{"name":"test","version":"1.0.0"}}}`
+
+func newHTTPTestServer(t *testing.T, transport, token string) *httptest.Server {
+	t.Helper()
+
+	mockBridge := &mocks.MockBridge{}
+	handler, err := NewHTTPHandler(SetupMCPServer(mockBridge), mockBridge, transport, token)
+	require.NoError(t, err)
+
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func postInitialize(t *testing.T, url, token string) *http.Response {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(initializeRequest))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = resp.Body.Close() })
+	return resp
+}
+
+func TestHTTPTransportRequiresBearerToken(t *testing.T) {
+	srv := newHTTPTestServer(t, TransportHTTP, "secret")
+
+	resp := postInitialize(t, srv.URL+"/mcp", "")
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	resp = postInitialize(t, srv.URL+"/mcp", "wrong")
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	resp = postInitialize(t, srv.URL+"/mcp", "secret")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var result struct {
+		Result struct {
+			ServerInfo struct {
+				Name string `json:"name"`
+			} `json:"serverInfo"`
+		} `json:"result"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+	assert.Equal(t, "mcp-lsp-bridge", result.Result.ServerInfo.Name)
+}
+
+func TestHTTPTransportServesConcurrentSessions(t *testing.T) {
+	srv := newHTTPTestServer(t, TransportHTTP, "")
+
+	first := postInitialize(t, srv.URL+"/mcp", "")
+	second := postInitialize(t, srv.URL+"/mcp", "")
+	require.Equal(t, http.StatusOK, first.StatusCode)
+	require.Equal(t, http.StatusOK, second.StatusCode)
+
+	firstID := first.Header.Get("Mcp-Session-Id")
+	assert.NotEmpty(t, firstID)
+	assert.NotEqual(t, firstID, second.Header.Get("Mcp-Session-Id"))
+}
+
+func TestSSETransportRequiresBearerToken(t *testing.T) {
+	srv := newHTTPTestServer(t, TransportSSE, "secret")
+
+	resp, err := http.Get(srv.URL + "/sse")
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestHealthEndpointReportsLSPStatus(t *testing.T) {
+	config := &lsp.LSPServerConfig{
+		LanguageServers:      map[types.LanguageServer]lsp.LanguageServerConfig{},
+		LanguageServerMap:    map[types.LanguageServer][]types.Language{},
+		ExtensionLanguageMap: map[string]types.Language{},
+	}
+	b := bridge.NewMCPLSPBridge(config, []string{t.TempDir()})
+	handler, err := NewHTTPHandler(SetupMCPServer(b), b, TransportHTTP, "secret")
+	require.NoError(t, err)
+
+	// No authentication needed; not ready while no language server is connected
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, HealthPath, nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+
+	var status struct {
+		Ready bool   `json:"ready"`
+		State string `json:"state"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &status))
+	assert.False(t, status.Ready)
+	assert.Equal(t, "starting", status.State)
+}
+
+func TestNewHTTPHandlerRejectsUnknownTransport(t *testing.T) {
+	mockBridge := &mocks.MockBridge{}
+	_, err := NewHTTPHandler(SetupMCPServer(mockBridge), mockBridge, "websocket", "")
+	assert.Error(t, err)
+}
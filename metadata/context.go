@@ -0,0 +1,159 @@
+package metadata
+
+import "strings"
+
+// Context is where a method can run: on the client, on the server or both
+type Context uint8
+
+const (
+	ContextClient Context = 1 << iota
+	ContextServer
+
+	ContextBoth = ContextClient | ContextServer
+)
+
+func (c Context) String() string {
+	switch c {
+	case ContextClient:
+		return "client"
+	case ContextServer:
+		return "server"
+	case ContextBoth:
+		return "client+server"
+	default:
+		return "none"
+	}
+}
+
+// Directive is a compilation directive written before a method, e.g. &НаСервере
+type Directive struct {
+	Name      string  // as written, without &
+	Context   Context // where the method is compiled
+	NoContext bool    // no access to the form (…БезКонтекста)
+}
+
+// directives maps the lowercased Russian and English directive names
+var directives = map[string]Directive{
+	"наклиенте": {Context: ContextClient},
+	"atclient":  {Context: ContextClient},
+	"насервере": {Context: ContextServer},
+	"atserver":  {Context: ContextServer},
+	"насерверебезконтекста":          {Context: ContextServer, NoContext: true},
+	"atservernocontext":              {Context: ContextServer, NoContext: true},
+	"наклиентенасерверебезконтекста": {Context: ContextBoth, NoContext: true},
+	"atclientatservernocontext":      {Context: ContextBoth, NoContext: true},
+	"наклиентенасервере":             {Context: ContextBoth},
+	"atclientatserver":               {Context: ContextBoth},
+}
+
+// ParseDirective recognizes a compilation directive such as "&НаСервере" at the start
+// of line. Extension annotations (&Перед, &Вместо...) are not directives.
+func ParseDirective(line string) (Directive, bool) {
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, "&") {
+		return Directive{}, false
+	}
+	name := line[1:]
+	if i := strings.IndexFunc(name, func(r rune) bool { return r == ' ' || r == '\t' || r == '(' || r == '/' }); i >= 0 {
+		name = name[:i]
+	}
+	d, ok := directives[strings.ToLower(name)]
+	if !ok {
+		return Directive{}, false
+	}
+	d.Name = name
+	return d, true
+}
+
+// FindDirective returns the directive of the method declared on lines[decl], looking at
+// the declaration line and the annotation, comment and blank lines right above it
+func FindDirective(lines []string, decl int) (Directive, bool) {
+	if decl < 0 || decl >= len(lines) {
+		return Directive{}, false
+	}
+	if d, ok := ParseDirective(lines[decl]); ok {
+		return d, true
+	}
+	for i := decl - 1; i >= 0; i-- {
+		line := strings.TrimSpace(lines[i])
+		if d, ok := ParseDirective(line); ok {
+			return d, true
+		}
+		if line != "" && !strings.HasPrefix(line, "&") && !strings.HasPrefix(line, "//") {
+			return Directive{}, false
+		}
+	}
+	return Directive{}, false
+}
+
+// MethodContext is the execution context of one method
+type MethodContext struct {
+	Context    Context
+	Directive  string // directive name, empty when the module decides
+	Form       bool   // declared in a form module
+	NoContext  bool   // form method without access to the form
+	ServerCall bool   // server method the client may call (common module with ServerCall)
+}
+
+// ResolveMethod combines the module a method is declared in with its directive.
+// Directives only count in form and command modules; elsewhere the module decides:
+// common modules by their flags, application modules run on the client, and object,
+// manager, session and service modules on the server.
+func ResolveMethod(owner FileOwner, directive *Directive) MethodContext {
+	switch {
+	case owner.Form != nil || owner.Module.Kind == "FormModule":
+		mc := MethodContext{Context: ContextServer, Form: true} // no directive means &НаСервере
+		if directive != nil {
+			mc.Context, mc.Directive, mc.NoContext = directive.Context, directive.Name, directive.NoContext
+		}
+		return mc
+	case owner.Module.Kind == "CommandModule":
+		mc := MethodContext{Context: ContextClient}
+		if directive != nil {
+			mc.Context, mc.Directive = directive.Context, directive.Name
+		}
+		return mc
+	case owner.Object != nil && owner.Object.Type == CommonModule && owner.Object.CommonModule != nil:
+		flags := owner.Object.CommonModule
+		var mc MethodContext
+		if flags.ClientManagedApplication || flags.ClientOrdinaryApplication {
+			mc.Context |= ContextClient
+		}
+		if flags.Server || flags.ExternalConnection {
+			mc.Context |= ContextServer
+		}
+		mc.ServerCall = flags.Server && flags.ServerCall
+		return mc
+	case owner.Module.Kind == "ManagedApplicationModule" || owner.Module.Kind == "OrdinaryApplicationModule":
+		return MethodContext{Context: ContextClient}
+	default:
+		return MethodContext{Context: ContextServer}
+	}
+}
+
+// Violation kinds reported by CheckCall
+const (
+	ViolationClientToServer = "client_to_server" // client code calls a method the client cannot reach
+	ViolationServerToClient = "server_to_client" // server code calls a client-only method
+	ViolationNoContext      = "no_context"       // a context-free form method calls one that needs the form
+)
+
+// CheckCall reports why caller may not call callee, or nothing when the call is legal.
+// sameModule tells whether both are declared in the same module: a client form method
+// may call a server method of its own form, which is a server call.
+func CheckCall(caller, callee MethodContext, sameModule bool) []string {
+	var violations []string
+	if caller.Context&ContextClient != 0 && callee.Context&ContextClient == 0 {
+		serverCall := callee.ServerCall || (callee.Form && caller.Form && sameModule)
+		if !serverCall || callee.Context == 0 {
+			violations = append(violations, ViolationClientToServer)
+		}
+	}
+	if caller.Context&ContextServer != 0 && callee.Context&ContextServer == 0 {
+		violations = append(violations, ViolationServerToClient)
+	}
+	if len(violations) == 0 && caller.Form && caller.NoContext && callee.Form && !callee.NoContext && sameModule {
+		violations = append(violations, ViolationNoContext)
+	}
+	return violations
+}
@@ -0,0 +1,91 @@
+package metadata
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDirective(t *testing.T) {
+	d, ok := ParseDirective("  &НаСервереБезКонтекста // comment")
+	assert.True(t, ok)
+	assert.Equal(t, Directive{Name: "НаСервереБезКонтекста", Context: ContextServer, NoContext: true}, d)
+
+	d, ok = ParseDirective("&AtClient")
+	assert.True(t, ok)
+	assert.Equal(t, ContextClient, d.Context)
+
+	_, ok = ParseDirective(`&Перед("ПриОткрытии")`)
+	assert.False(t, ok, "extension annotations are not directives")
+	_, ok = ParseDirective("Процедура Тест()")
+	assert.False(t, ok)
+}
+
+func TestFindDirective(t *testing.T) {
+	lines := []string{
+		"&НаКлиенте",
+		`&После("ПриОткрытии")`,
+		"// Комментарий",
+		"Процедура А()",
+		"КонецПроцедуры",
+		"",
+		"Процедура Б()",
+	}
+	d, ok := FindDirective(lines, 3)
+	assert.True(t, ok)
+	assert.Equal(t, "НаКлиенте", d.Name)
+
+	_, ok = FindDirective(lines, 6)
+	assert.False(t, ok, "the search stops at the previous method")
+}
+
+func TestResolveMethod(t *testing.T) {
+	form := FileOwner{Module: Module{Kind: "FormModule"}, Form: &Form{Name: "Форма"}}
+	assert.Equal(t, MethodContext{Context: ContextServer, Form: true}, ResolveMethod(form, nil))
+	assert.Equal(t, MethodContext{Context: ContextClient, Form: true, Directive: "НаКлиенте"},
+		ResolveMethod(form, &Directive{Name: "НаКлиенте", Context: ContextClient}))
+
+	common := func(flags CommonModuleFlags) FileOwner {
+		return FileOwner{Object: &Object{Type: CommonModule, CommonModule: &flags}, Module: Module{Kind: "Module"}}
+	}
+	assert.Equal(t, MethodContext{Context: ContextServer, ServerCall: true}, ResolveMethod(common(CommonModuleFlags{Server: true, ServerCall: true}), nil))
+	assert.Equal(t, MethodContext{Context: ContextBoth}, ResolveMethod(common(CommonModuleFlags{Server: true, ClientManagedApplication: true}), nil))
+
+	object := FileOwner{Object: &Object{Type: "Catalog"}, Module: Module{Kind: "ObjectModule"}}
+	assert.Equal(t, MethodContext{Context: ContextServer}, ResolveMethod(object, nil))
+	app := FileOwner{Module: Module{Kind: "ManagedApplicationModule"}}
+	assert.Equal(t, MethodContext{Context: ContextClient}, ResolveMethod(app, nil))
+}
+
+func TestCheckCall(t *testing.T) {
+	client := MethodContext{Context: ContextClient, Form: true}
+	server := MethodContext{Context: ContextServer, Form: true}
+	noContext := MethodContext{Context: ContextServer, Form: true, NoContext: true}
+	serverModule := MethodContext{Context: ContextServer}
+	serverCall := MethodContext{Context: ContextServer, ServerCall: true}
+	clientModule := MethodContext{Context: ContextClient}
+	both := MethodContext{Context: ContextBoth, Form: true, NoContext: true}
+
+	tests := []struct {
+		name           string
+		caller, callee MethodContext
+		sameModule     bool
+		want           []string
+	}{
+		{"client calls its form's server method", client, server, true, nil},
+		{"client calls a context-free server method", client, noContext, true, nil},
+		{"client calls another form's server method", client, server, false, []string{ViolationClientToServer}},
+		{"client calls a server call module", client, serverCall, false, nil},
+		{"client calls a server-only module", client, serverModule, false, []string{ViolationClientToServer}},
+		{"server calls a client module", server, clientModule, false, []string{ViolationServerToClient}},
+		{"server calls a server module", server, serverModule, false, nil},
+		{"context-free calls a form method", noContext, server, true, []string{ViolationNoContext}},
+		{"client+server calls client only", both, clientModule, false, []string{ViolationServerToClient}},
+		{"client+server calls server only", both, serverModule, false, []string{ViolationClientToServer}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, CheckCall(tt.caller, tt.callee, tt.sameModule))
+		})
+	}
+}
@@ -0,0 +1,235 @@
+package metadata
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Binding sources: what makes the platform call a procedure
+const (
+	BindingForm              = "form"               // form event, e.g. OnCreateAtServer
+	BindingItem              = "item"               // form item event, e.g. OnChange of a field
+	BindingCommand           = "command"            // form command action
+	BindingEventSubscription = "event_subscription" // event subscription handler in a common module
+	BindingScheduledJob      = "scheduled_job"      // scheduled job method in a common module
+	BindingHTTPService       = "http_service"       // URL template method handler
+	BindingWebService        = "web_service"        // web service operation
+)
+
+// Binding is a procedure the platform calls because the configuration wires it,
+// as opposed to one called from code
+type Binding struct {
+	Procedure string `json:"procedure"`
+	Source    string `json:"source"`              // one of the Binding* constants
+	Element   string `json:"element,omitempty"`   // item, command, subscription, job or service name
+	Event     string `json:"event,omitempty"`     // OnOpen, OnChange...; Action for commands
+	Form      string `json:"form,omitempty"`      // form name for form, item and command bindings
+	CallType  string `json:"call_type,omitempty"` // Before, After or Override in extension forms
+}
+
+// String describes the trigger, e.g. "item Товары.OnChange" or "form ФормаЭлемента.OnOpen"
+func (b Binding) String() string {
+	switch b.Source {
+	case BindingForm:
+		return fmt.Sprintf("form %s.%s", b.Form, b.Event)
+	case BindingItem:
+		return fmt.Sprintf("item %s.%s of form %s", b.Element, b.Event, b.Form)
+	case BindingCommand:
+		return fmt.Sprintf("command %s of form %s", b.Element, b.Form)
+	default:
+		return strings.ReplaceAll(b.Source, "_", " ") + " " + b.Element
+	}
+}
+
+// Bindings returns the handlers wired in the form description, parsed on first use
+func (f *Form) Bindings() ([]Binding, error) {
+	f.bindOnce.Do(func() {
+		if f.File == "" {
+			return
+		}
+		f.bindings, f.bindErr = ParseFormBindings(f.File)
+		for i := range f.bindings {
+			f.bindings[i].Form = f.Name
+		}
+	})
+	return f.bindings, f.bindErr
+}
+
+// formFrame is an open element while streaming a form description
+type formFrame struct {
+	local string
+	name  string // name attribute (Designer) or first <name> child (EDT)
+	event string // <event> child of EDT <handlers>
+	attrs []xml.Attr
+}
+
+// ParseFormBindings reads the event handlers and command actions of a managed form.
+// Forms can be large, so the file is streamed instead of decoded into a tree.
+//
+// Designer Form.xml:
+//
+//	<Form><Events><Event name="OnOpen">ПриОткрытии</Event></Events>
+//	<ChildItems><InputField name="Товары"><Events><Event name="OnChange">ТоварыПриИзменении</Event>…
+//	<Commands><Command name="Заполнить"><Action>Заполнить</Action>…
+//
+// EDT Form.form:
+//
+//	<form:Form><handlers><event>OnOpen</event><name>ПриОткрытии</name></handlers>
+//	<items><name>Товары</name><handlers><event>OnChange</event><name>ТоварыПриИзменении</name></handlers>…
+//	<formCommands><name>Заполнить</name><action><handler><name>Заполнить</name></handler></action>…
+func ParseFormBindings(path string) ([]Binding, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	decoder := xml.NewDecoder(file)
+	var (
+		stack    []*formFrame
+		text     strings.Builder
+		bindings []Binding
+	)
+
+	// bindingFor tells whether the element holding an event list is the form itself or an item
+	bindingFor := func(owner int, procedure, event string, attrs []xml.Attr) {
+		if procedure == "" {
+			return
+		}
+		b := Binding{Procedure: procedure, Source: BindingForm, Event: event, CallType: attr(attrs, "callType")}
+		if owner > 0 {
+			b.Source = BindingItem
+			b.Element = stack[owner].name
+		}
+		bindings = append(bindings, b)
+	}
+
+	for {
+		tok, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parse %s: %w", path, err)
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			stack = append(stack, &formFrame{local: t.Name.Local, name: attr(t.Attr, "name"), attrs: t.Attr})
+			text.Reset()
+		case xml.CharData:
+			text.Write(t)
+		case xml.EndElement:
+			if len(stack) == 0 {
+				continue
+			}
+			f := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			value := strings.TrimSpace(text.String())
+			text.Reset()
+			if len(stack) == 0 {
+				continue
+			}
+			parent := stack[len(stack)-1]
+
+			switch {
+			// Designer
+			case f.local == "Event" && parent.local == "Events" && len(stack) >= 2:
+				bindingFor(len(stack)-2, value, f.name, f.attrs)
+			case f.local == "Action" && parent.local == "Command" && value != "":
+				bindings = append(bindings, Binding{Procedure: value, Source: BindingCommand, Element: parent.name, Event: "Action"})
+
+			// EDT
+			case f.local == "name" && parent.name == "":
+				parent.name = value
+			case f.local == "event" && parent.local == "handlers":
+				parent.event = value
+			case f.local == "handlers":
+				bindingFor(len(stack)-1, f.name, f.event, f.attrs)
+			case f.local == "handler" && parent.local == "action" && len(stack) >= 2 && stack[len(stack)-2].local == "formCommands" && f.name != "":
+				bindings = append(bindings, Binding{Procedure: f.name, Source: BindingCommand, Element: stack[len(stack)-2].name, Event: "Action"})
+			}
+		}
+	}
+	return bindings, nil
+}
+
+func attr(attrs []xml.Attr, name string) string {
+	for _, a := range attrs {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// Bindings returns the platform-called procedures of a module: form, item and command
+// handlers of a form module; event subscription and scheduled job handlers of a common
+// module; method handlers of an HTTP or web service module.
+func (cfg *Configuration) Bindings(modulePath string) ([]Binding, error) {
+	owner, ok := cfg.Owner(modulePath)
+	if !ok {
+		return nil, nil
+	}
+	if owner.Form != nil {
+		return owner.Form.Bindings()
+	}
+	return cfg.moduleBindings[owner.Module.Path], nil
+}
+
+// BindingsOf returns the bindings of one procedure in a module; 1C names are case-insensitive
+func (cfg *Configuration) BindingsOf(modulePath, procedure string) ([]Binding, error) {
+	all, err := cfg.Bindings(modulePath)
+	var out []Binding
+	for _, b := range all {
+		if strings.EqualFold(b.Procedure, procedure) {
+			out = append(out, b)
+		}
+	}
+	return out, err
+}
+
+// indexBindings maps the handlers configured outside forms to the modules that define them
+func (cfg *Configuration) indexBindings() {
+	for _, obj := range cfg.Objects {
+		var source string
+		switch obj.Type {
+		case EventSubscription:
+			source = BindingEventSubscription
+		case ScheduledJob:
+			source = BindingScheduledJob
+		case HTTPService, WebService:
+			source = BindingHTTPService
+			if obj.Type == WebService {
+				source = BindingWebService
+			}
+			for _, m := range obj.Modules {
+				for _, h := range obj.Handlers {
+					cfg.moduleBindings[m.Path] = append(cfg.moduleBindings[m.Path], Binding{Procedure: h, Source: source, Element: obj.Name})
+				}
+			}
+			continue
+		default:
+			continue
+		}
+
+		// "CommonModule.Имя.Процедура"
+		for _, h := range obj.Handlers {
+			parts := strings.Split(h, ".")
+			if len(parts) != 3 {
+				continue
+			}
+			module := cfg.Find(parts[0] + "." + parts[1])
+			if module == nil {
+				continue
+			}
+			for _, m := range module.Modules {
+				cfg.moduleBindings[m.Path] = append(cfg.moduleBindings[m.Path], Binding{Procedure: parts[2], Source: source, Element: obj.Name})
+			}
+		}
+	}
+}
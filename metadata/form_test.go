@@ -0,0 +1,122 @@
+package metadata
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const designerForm = `<?xml version="1.0" encoding="UTF-8"?>
+<Form xmlns="http://v8.1c.ru/8.3/xcf/logform" version="2.16">
+	<AutoCommandBar name="ФормаКоманднаяПанель" id="-1"/>
+	<Events>
+		<Event name="OnCreateAtServer">ПриСозданииНаСервере</Event>
+		<Event name="OnOpen" callType="After">Расш_ПриОткрытииПосле</Event>
+	</Events>
+	<ChildItems>
+		<UsualGroup name="Группа" id="3">
+			<ChildItems>
+				<InputField name="Товары" id="1">
+					<DataPath>Объект.Товары</DataPath>
+					<ContextMenu name="ТоварыКонтекстноеМеню" id="2"/>
+					<Events>
+						<Event name="OnChange">ТоварыПриИзменении</Event>
+					</Events>
+				</InputField>
+			</ChildItems>
+		</UsualGroup>
+		<Button name="ФормаЗаполнить" id="4">
+			<CommandName>Form.Command.Заполнить</CommandName>
+		</Button>
+	</ChildItems>
+	<Commands>
+		<Command name="Заполнить" id="1">
+			<Title><v8:item xmlns:v8="http://v8.1c.ru/8.1/data/core"><v8:lang>ru</v8:lang><v8:content>Заполнить</v8:content></v8:item></Title>
+			<Action>ЗаполнитьКоманда</Action>
+		</Command>
+	</Commands>
+</Form>`
+
+const edtForm = `<?xml version="1.0" encoding="UTF-8"?>
+<form:Form xmlns:form="http://g5.1c.ru/v8/dt/form" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance">
+  <items xsi:type="form:FormGroup">
+    <name>Группа</name>
+    <items xsi:type="form:FormField">
+      <name>Товары</name>
+      <id>1</id>
+      <handlers>
+        <event>OnChange</event>
+        <name>ТоварыПриИзменении</name>
+      </handlers>
+    </items>
+  </items>
+  <handlers>
+    <event>OnCreateAtServer</event>
+    <name>ПриСозданииНаСервере</name>
+  </handlers>
+  <formCommands>
+    <name>Заполнить</name>
+    <id>1</id>
+    <action xsi:type="form:FormCommandHandlerContainer">
+      <handler>
+        <name>ЗаполнитьКоманда</name>
+      </handler>
+    </action>
+  </formCommands>
+</form:Form>`
+
+func TestParseFormBindings(t *testing.T) {
+	want := []Binding{
+		{Procedure: "ТоварыПриИзменении", Source: BindingItem, Element: "Товары", Event: "OnChange"},
+		{Procedure: "ПриСозданииНаСервере", Source: BindingForm, Event: "OnCreateAtServer"},
+		{Procedure: "ЗаполнитьКоманда", Source: BindingCommand, Element: "Заполнить", Event: "Action"},
+	}
+
+	dir := t.TempDir()
+	edt := filepath.Join(dir, "Form.form")
+	require.NoError(t, os.WriteFile(edt, []byte(edtForm), 0o644))
+	bindings, err := ParseFormBindings(edt)
+	require.NoError(t, err)
+	assert.Equal(t, want, bindings)
+
+	designer := filepath.Join(dir, "Form.xml")
+	require.NoError(t, os.WriteFile(designer, []byte(designerForm), 0o644))
+	bindings, err = ParseFormBindings(designer)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, append(want,
+		Binding{Procedure: "Расш_ПриОткрытииПосле", Source: BindingForm, Event: "OnOpen", CallType: "After"},
+	), bindings)
+}
+
+func TestConfigurationBindings(t *testing.T) {
+	dir := designerFixture(t)
+	formFile := filepath.Join(dir, "Catalogs/Товары/Forms/ФормаЭлемента/Ext/Form.xml")
+	require.NoError(t, os.WriteFile(formFile, []byte(designerForm), 0o644))
+
+	cfg, err := Load(dir)
+	require.NoError(t, err)
+
+	formModule := filepath.Join(dir, "Catalogs/Товары/Forms/ФормаЭлемента/Ext/Form/Module.bsl")
+	bound, err := cfg.BindingsOf(formModule, "товарыприизменении")
+	require.NoError(t, err)
+	require.Len(t, bound, 1)
+	assert.Equal(t, "ФормаЭлемента", bound[0].Form)
+	assert.Equal(t, "item Товары.OnChange of form ФормаЭлемента", bound[0].String())
+
+	bound, err = cfg.BindingsOf(formModule, "ПриОткрытии")
+	require.NoError(t, err)
+	assert.Empty(t, bound, "only procedures wired in the form are bound")
+
+	// The event subscription points at a common module procedure
+	common := filepath.Join(dir, "CommonModules/ОбщегоНазначения/Ext/Module.bsl")
+	bound, err = cfg.BindingsOf(common, "ПередЗаписью")
+	require.NoError(t, err)
+	assert.Equal(t, []Binding{{Procedure: "ПередЗаписью", Source: BindingEventSubscription, Element: "ПередЗаписьюТоваров"}}, bound)
+
+	bindings, err := cfg.Bindings(filepath.Join(dir, "Catalogs/Товары/Ext/ObjectModule.bsl"))
+	require.NoError(t, err)
+	assert.Empty(t, bindings)
+}
@@ -0,0 +1,386 @@
+package metadata
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+}
+
+const designerHeader = `<?xml version="1.0" encoding="UTF-8"?>
+<MetaDataObject xmlns="http://v8.1c.ru/8.3/MDClasses" xmlns:v8="http://v8.1c.ru/8.1/data/core" xmlns:xr="http://v8.1c.ru/8.3/xcf/readable" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" version="2.16">`
+
+func designerFixture(t *testing.T) string {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"Configuration.xml": designerHeader + `
+	<Configuration uuid="1">
+		<Properties>
+			<Name>Торговля</Name>
+			<Synonym><v8:item><v8:lang>en</v8:lang><v8:content>Trade</v8:content></v8:item><v8:item><v8:lang>ru</v8:lang><v8:content>Торговля</v8:content></v8:item></Synonym>
+		</Properties>
+		<ChildObjects>
+			<Language>Русский</Language>
+			<Subsystem>Продажи</Subsystem>
+			<Role>Администратор</Role>
+			<CommonModule>ОбщегоНазначения</CommonModule>
+			<CommonForm>Вопрос</CommonForm>
+			<EventSubscription>ПередЗаписьюТоваров</EventSubscription>
+			<Catalog>Товары</Catalog>
+			<InformationRegister>Цены</InformationRegister>
+		</ChildObjects>
+	</Configuration>
+</MetaDataObject>`,
+		"Ext/SessionModule.bsl": "",
+		"Subsystems/Продажи.xml": designerHeader + `
+	<Subsystem uuid="2">
+		<Properties>
+			<Name>Продажи</Name>
+			<Content><xr:Item xsi:type="xr:MDObjectRef">Catalog.Товары</xr:Item></Content>
+		</Properties>
+		<ChildObjects><Subsystem>Опт</Subsystem></ChildObjects>
+	</Subsystem>
+</MetaDataObject>`,
+		"Subsystems/Продажи/Subsystems/Опт.xml": designerHeader + `
+	<Subsystem uuid="3"><Properties><Name>Опт</Name></Properties><ChildObjects/></Subsystem>
+</MetaDataObject>`,
+		"Roles/Администратор.xml": designerHeader + `
+	<Role uuid="4"><Properties><Name>Администратор</Name></Properties></Role>
+</MetaDataObject>`,
+		"CommonModules/ОбщегоНазначения.xml": designerHeader + `
+	<CommonModule uuid="5">
+		<Properties>
+			<Name>ОбщегоНазначения</Name>
+			<Global>false</Global>
+			<ClientManagedApplication>false</ClientManagedApplication>
+			<Server>true</Server>
+			<ExternalConnection>true</ExternalConnection>
+			<ClientOrdinaryApplication>false</ClientOrdinaryApplication>
+			<ServerCall>true</ServerCall>
+			<Privileged>false</Privileged>
+			<ReturnValuesReuse>DontUse</ReturnValuesReuse>
+		</Properties>
+	</CommonModule>
+</MetaDataObject>`,
+		"CommonModules/ОбщегоНазначения/Ext/Module.bsl": "",
+		"CommonForms/Вопрос.xml": designerHeader + `
+	<CommonForm uuid="6"><Properties><Name>Вопрос</Name></Properties></CommonForm>
+</MetaDataObject>`,
+		"CommonForms/Вопрос/Ext/Form.xml":        "<Form/>",
+		"CommonForms/Вопрос/Ext/Form/Module.bsl": "",
+		"EventSubscriptions/ПередЗаписьюТоваров.xml": designerHeader + `
+	<EventSubscription uuid="7">
+		<Properties>
+			<Name>ПередЗаписьюТоваров</Name>
+			<Handler>CommonModule.ОбщегоНазначения.ПередЗаписью</Handler>
+		</Properties>
+	</EventSubscription>
+</MetaDataObject>`,
+		"Catalogs/Товары.xml": designerHeader + `
+	<Catalog uuid="8">
+		<Properties>
+			<Name>Товары</Name>
+			<Synonym><v8:item><v8:lang>ru</v8:lang><v8:content>Номенклатура</v8:content></v8:item></Synonym>
+			<Comment>Справочник товаров</Comment>
+		</Properties>
+		<ChildObjects>
+			<Attribute uuid="9"><Properties><Name>Артикул</Name></Properties></Attribute>
+			<Form>ФормаЭлемента</Form>
+			<Command uuid="10"><Properties><Name>Печать</Name></Properties></Command>
+		</ChildObjects>
+	</Catalog>
+</MetaDataObject>`,
+		"Catalogs/Товары/Ext/ObjectModule.bsl":                    "",
+		"Catalogs/Товары/Ext/ManagerModule.bsl":                   "",
+		"Catalogs/Товары/Forms/ФормаЭлемента/Ext/Form.xml":        "<Form/>",
+		"Catalogs/Товары/Forms/ФормаЭлемента/Ext/Form/Module.bsl": "",
+		"Catalogs/Товары/Commands/Печать/Ext/CommandModule.bsl":   "",
+		"InformationRegisters/Цены/Ext/RecordSetModule.bsl":       "",
+	})
+	return root
+}
+
+func edtFixture(t *testing.T) string {
+	root := t.TempDir()
+	const mdclass = `xmlns:mdclass="http://g5.1c.ru/v8/dt/metadata/mdclass"`
+	writeFiles(t, root, map[string]string{
+		"src/Configuration/Configuration.mdo": `<?xml version="1.0" encoding="UTF-8"?>
+<mdclass:Configuration ` + mdclass + ` uuid="1">
+  <name>Торговля</name>
+  <synonym><key>ru</key><value>Торговля</value></synonym>
+  <subsystems>Subsystem.Продажи</subsystems>
+  <roles>Role.Администратор</roles>
+  <commonModules>CommonModule.ОбщегоНазначенияКлиент</commonModules>
+  <scheduledJobs>ScheduledJob.ОбновлениеЦен</scheduledJobs>
+  <catalogs>Catalog.Товары</catalogs>
+  <httpServices>HTTPService.API</httpServices>
+</mdclass:Configuration>`,
+		"src/Configuration/ManagedApplicationModule.bsl": "",
+		"src/Subsystems/Продажи/Продажи.mdo": `<mdclass:Subsystem ` + mdclass + `>
+  <name>Продажи</name>
+  <content>Catalog.Товары</content>
+  <subsystems>Опт</subsystems>
+</mdclass:Subsystem>`,
+		"src/Subsystems/Продажи/Subsystems/Опт/Опт.mdo": `<mdclass:Subsystem ` + mdclass + `><name>Опт</name></mdclass:Subsystem>`,
+		"src/Roles/Администратор/Администратор.mdo":     `<mdclass:Role ` + mdclass + `><name>Администратор</name></mdclass:Role>`,
+		"src/CommonModules/ОбщегоНазначенияКлиент/ОбщегоНазначенияКлиент.mdo": `<mdclass:CommonModule ` + mdclass + `>
+  <name>ОбщегоНазначенияКлиент</name>
+  <clientManagedApplication>true</clientManagedApplication>
+  <clientOrdinaryApplication>true</clientOrdinaryApplication>
+</mdclass:CommonModule>`,
+		"src/CommonModules/ОбщегоНазначенияКлиент/Module.bsl": "",
+		"src/ScheduledJobs/ОбновлениеЦен/ОбновлениеЦен.mdo": `<mdclass:ScheduledJob ` + mdclass + `>
+  <name>ОбновлениеЦен</name>
+  <methodName>CommonModule.ОбщегоНазначенияКлиент.ОбновитьЦены</methodName>
+</mdclass:ScheduledJob>`,
+		"src/Catalogs/Товары/Товары.mdo": `<mdclass:Catalog ` + mdclass + `>
+  <name>Товары</name>
+  <synonym><key>en</key><value>Goods</value></synonym>
+  <attributes><name>Артикул</name></attributes>
+  <forms><name>ФормаЭлемента</name></forms>
+  <commands><name>Печать</name></commands>
+</mdclass:Catalog>`,
+		"src/Catalogs/Товары/ObjectModule.bsl":                  "",
+		"src/Catalogs/Товары/Forms/ФормаЭлемента/Form.form":     "",
+		"src/Catalogs/Товары/Forms/ФормаЭлемента/Module.bsl":    "",
+		"src/Catalogs/Товары/Commands/Печать/CommandModule.bsl": "",
+		"src/HTTPServices/API/API.mdo": `<mdclass:HTTPService ` + mdclass + `>
+  <name>API</name>
+  <urlTemplates><name>Items</name><methods><name>GET</name><handler>ItemsGET</handler></methods></urlTemplates>
+</mdclass:HTTPService>`,
+		"src/HTTPServices/API/Module.bsl": "",
+	})
+	return root
+}
+
+func TestLocate(t *testing.T) {
+	designer := designerFixture(t)
+	root, format, err := Locate(designer)
+	require.NoError(t, err)
+	assert.Equal(t, designer, root)
+	assert.Equal(t, FormatDesigner, format)
+
+	edt := edtFixture(t)
+	root, format, err = Locate(edt)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(edt, "src"), root)
+	assert.Equal(t, FormatEDT, format)
+
+	_, _, err = Locate(t.TempDir())
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestParseDesigner(t *testing.T) {
+	dir := designerFixture(t)
+	cfg, err := Load(dir)
+	require.NoError(t, err)
+
+	assert.Equal(t, "Торговля", cfg.Name)
+	assert.Equal(t, "Торговля", cfg.Synonym, "Russian synonym is preferred")
+	assert.Equal(t, FormatDesigner, cfg.Format)
+	require.Len(t, cfg.Modules, 1)
+	assert.Equal(t, "SessionModule", cfg.Modules[0].Kind)
+
+	var names []string
+	for _, obj := range cfg.Objects {
+		names = append(names, obj.FullName())
+	}
+	assert.Equal(t, []string{
+		"Subsystem.Продажи",
+		"Subsystem.Продажи.Subsystem.Опт",
+		"CommonModule.ОбщегоНазначения",
+		"CommonForm.Вопрос",
+		"Role.Администратор",
+		"EventSubscription.ПередЗаписьюТоваров",
+		"Catalog.Товары",
+		"InformationRegister.Цены",
+	}, names)
+
+	goods := cfg.Find("Справочник.Товары")
+	require.NotNil(t, goods)
+	assert.Equal(t, "Номенклатура", goods.Synonym)
+	assert.Equal(t, "Справочник товаров", goods.Comment)
+	assert.Len(t, goods.Modules, 2)
+	require.Len(t, goods.Forms, 1)
+	assert.Equal(t, filepath.Join(dir, "Catalogs/Товары/Forms/ФормаЭлемента/Ext/Form.xml"), goods.Forms[0].File)
+	assert.Equal(t, filepath.Join(dir, "Catalogs/Товары/Forms/ФормаЭлемента/Ext/Form/Module.bsl"), goods.Forms[0].Module)
+	require.Len(t, goods.Commands, 1)
+	assert.Equal(t, "Печать", goods.Commands[0].Name)
+	assert.NotEmpty(t, goods.Commands[0].Module)
+
+	common := cfg.Find("CommonModule.ОбщегоНазначения")
+	require.NotNil(t, common)
+	assert.Equal(t, &CommonModuleFlags{Server: true, ServerCall: true, ExternalConnection: true, ReturnValuesReuse: "DontUse"}, common.CommonModule)
+
+	form := cfg.Find("commonform.вопрос")
+	require.NotNil(t, form)
+	require.Len(t, form.Forms, 1)
+	assert.Equal(t, filepath.Join(dir, "CommonForms/Вопрос/Ext/Form/Module.bsl"), form.Forms[0].Module)
+
+	sales := cfg.Find("Subsystem.Продажи")
+	require.NotNil(t, sales)
+	assert.Equal(t, []string{"Catalog.Товары"}, sales.Content)
+	require.Len(t, sales.Subsystems, 1)
+	assert.Same(t, cfg.Find("Подсистема.Продажи.Подсистема.Опт"), sales.Subsystems[0])
+
+	assert.Equal(t, []string{"CommonModule.ОбщегоНазначения.ПередЗаписью"}, cfg.Find("EventSubscription.ПередЗаписьюТоваров").Handlers)
+
+	// The register has no description file but its module is still mapped
+	prices := cfg.Find("InformationRegister.Цены")
+	require.NotNil(t, prices)
+	assert.Len(t, cfg.Warnings, 1)
+	assert.Len(t, prices.Modules, 1)
+}
+
+func TestParseEDT(t *testing.T) {
+	dir := edtFixture(t)
+	cfg, err := Load(dir)
+	require.NoError(t, err)
+
+	src := filepath.Join(dir, "src")
+	assert.Equal(t, "Торговля", cfg.Name)
+	assert.Equal(t, FormatEDT, cfg.Format)
+	assert.Empty(t, cfg.Warnings)
+	require.Len(t, cfg.Modules, 1)
+	assert.Equal(t, "ManagedApplicationModule", cfg.Modules[0].Kind)
+
+	client := cfg.Find("ОбщийМодуль.ОбщегоНазначенияКлиент")
+	require.NotNil(t, client)
+	assert.Equal(t, &CommonModuleFlags{ClientManagedApplication: true, ClientOrdinaryApplication: true}, client.CommonModule)
+	require.Len(t, client.Modules, 1)
+	assert.Equal(t, "Module", client.Modules[0].Kind)
+
+	goods := cfg.Find("Catalog.Товары")
+	require.NotNil(t, goods)
+	assert.Equal(t, "Goods", goods.Synonym, "falls back to the first synonym")
+	require.Len(t, goods.Forms, 1)
+	assert.Equal(t, filepath.Join(src, "Catalogs/Товары/Forms/ФормаЭлемента/Form.form"), goods.Forms[0].File)
+	assert.Equal(t, filepath.Join(src, "Catalogs/Товары/Commands/Печать/CommandModule.bsl"), goods.Commands[0].Module)
+
+	sales := cfg.Find("Subsystem.Продажи")
+	require.NotNil(t, sales)
+	assert.Equal(t, []string{"Catalog.Товары"}, sales.Content)
+	require.Len(t, sales.Subsystems, 1)
+	assert.Equal(t, "Опт", sales.Subsystems[0].Name)
+
+	assert.Equal(t, []string{"CommonModule.ОбщегоНазначенияКлиент.ОбновитьЦены"}, cfg.Find("ScheduledJob.ОбновлениеЦен").Handlers)
+	assert.Equal(t, []string{"ItemsGET"}, cfg.Find("HTTPService.API").Handlers)
+	assert.NotNil(t, cfg.Find("Role.Администратор"))
+}
+
+func TestOwner(t *testing.T) {
+	dir := edtFixture(t)
+	cfg, err := Load(dir)
+	require.NoError(t, err)
+	src := filepath.Join(dir, "src")
+
+	owner, ok := cfg.Owner(filepath.Join(src, "Catalogs/Товары/Forms/ФормаЭлемента/Module.bsl"))
+	require.True(t, ok)
+	assert.Equal(t, "Catalog.Товары", owner.Object.FullName())
+	assert.Equal(t, "ФормаЭлемента", owner.Form.Name)
+	assert.Equal(t, "FormModule", owner.Module.Kind)
+
+	owner, ok = cfg.Owner(filepath.Join(src, "Catalogs/Товары/Commands/Печать/CommandModule.bsl"))
+	require.True(t, ok)
+	assert.Equal(t, "Печать", owner.Command.Name)
+
+	owner, ok = cfg.Owner(filepath.Join(src, "Configuration/ManagedApplicationModule.bsl"))
+	require.True(t, ok)
+	assert.Nil(t, owner.Object)
+
+	_, ok = cfg.Owner(filepath.Join(src, "Nowhere.bsl"))
+	assert.False(t, ok)
+}
+
+func TestSearch(t *testing.T) {
+	cfg, err := Load(designerFixture(t))
+	require.NoError(t, err)
+
+	found := cfg.Search("номенклатура", "")
+	require.Len(t, found, 1)
+	assert.Equal(t, "Товары", found[0].Name)
+
+	assert.Len(t, cfg.Search("Продажи", Subsystem), 2, "nested subsystems match by full name")
+	assert.Empty(t, cfg.Search("Товары", CommonModule))
+	assert.Len(t, cfg.ObjectsOfType(Subsystem), 2)
+}
+
+func TestParseType(t *testing.T) {
+	for _, s := range []string{"Catalog", "catalogs", "СПРАВОЧНИК"} {
+		typ, ok := ParseType(s)
+		assert.True(t, ok, s)
+		assert.Equal(t, Catalog, typ)
+	}
+	_, ok := ParseType("Widget")
+	assert.False(t, ok)
+}
+
+func TestCacheReloadsChangedConfiguration(t *testing.T) {
+	dir := designerFixture(t)
+	cache := NewCache()
+
+	first, err := cache.Load(dir)
+	require.NoError(t, err)
+	again, err := cache.Load(dir)
+	require.NoError(t, err)
+	assert.Same(t, first, again)
+
+	file := filepath.Join(dir, "Catalogs/Товары.xml")
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(file, later, later))
+
+	reloaded, err := cache.Load(dir)
+	require.NoError(t, err)
+	assert.NotSame(t, first, reloaded)
+}
+
+func TestCacheParsesOnceForConcurrentLoads(t *testing.T) {
+	dir := designerFixture(t)
+	cache := NewCache()
+
+	configs := make([]*Configuration, 8)
+	var wg sync.WaitGroup
+	for i := range configs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cfg, err := cache.Load(dir)
+			assert.NoError(t, err)
+			configs[i] = cfg
+		}()
+	}
+	wg.Wait()
+
+	for _, cfg := range configs[1:] {
+		assert.Same(t, configs[0], cfg)
+	}
+}
+
+func TestCacheForFile(t *testing.T) {
+	dir := edtFixture(t)
+	cache := NewCache()
+
+	cfg, err := cache.ForFile(filepath.Join(dir, "src/Catalogs/Товары/Forms/ФормаЭлемента/Module.bsl"))
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "src"), cfg.Root)
+
+	same, err := cache.ForFile(filepath.Join(dir, "src/Configuration/ManagedApplicationModule.bsl"))
+	require.NoError(t, err)
+	assert.Same(t, cfg, same)
+
+	_, err = cache.ForFile(filepath.Join(t.TempDir(), "Module.bsl"))
+	assert.ErrorIs(t, err, ErrNotFound)
+}
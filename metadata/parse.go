@@ -0,0 +1,428 @@
+package metadata
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNotFound is returned when no configuration dump is found under a directory
+var ErrNotFound = errors.New("no 1C configuration found (expected Configuration.xml or Configuration/Configuration.mdo)")
+
+// locateDepth is how deep Locate looks for a dump below the given directory,
+// enough for layouts like <repo>/src/cf/Configuration.xml
+const locateDepth = 3
+
+// Locate finds the configuration dump in dir or below it and returns its root:
+// the directory holding Configuration.xml (Designer) or Configuration/Configuration.mdo (EDT).
+func Locate(dir string) (string, Format, error) {
+	level := []string{filepath.Clean(dir)}
+	for depth := 0; depth <= locateDepth && len(level) > 0; depth++ {
+		var next []string
+		for _, d := range level {
+			if isFile(filepath.Join(d, "Configuration.xml")) {
+				return d, FormatDesigner, nil
+			}
+			if isFile(filepath.Join(d, "Configuration", "Configuration.mdo")) {
+				return d, FormatEDT, nil
+			}
+			entries, err := os.ReadDir(d)
+			if err != nil {
+				continue
+			}
+			for _, e := range entries {
+				name := e.Name()
+				if !e.IsDir() || strings.HasPrefix(name, ".") || name == "node_modules" {
+					continue
+				}
+				next = append(next, filepath.Join(d, name))
+			}
+		}
+		level = next
+	}
+	return "", "", fmt.Errorf("%w in %s", ErrNotFound, dir)
+}
+
+// Load locates and parses the configuration dump in dir or below it
+func Load(dir string) (*Configuration, error) {
+	root, format, err := Locate(dir)
+	if err != nil {
+		return nil, err
+	}
+	if format == FormatEDT {
+		return ParseEDT(root)
+	}
+	return ParseDesigner(root)
+}
+
+// objectRef is an object listed in the configuration, to be parsed from its own file
+type objectRef struct {
+	kind Kind
+	name string
+	file string // .xml or .mdo description
+	dir  string // directory with the object's modules, forms and commands
+}
+
+// ParseDesigner parses a Designer dump: root/Configuration.xml lists the objects,
+// each described by root/<Dir>/<Name>.xml with its files under root/<Dir>/<Name>/.
+func ParseDesigner(root string) (*Configuration, error) {
+	file := filepath.Join(root, "Configuration.xml")
+	doc, err := readXML(file)
+	if err != nil {
+		return nil, err
+	}
+	el := doc.child("Configuration")
+	if el == nil {
+		return nil, fmt.Errorf("%s: no <Configuration> element", file)
+	}
+	props := el.child("Properties")
+
+	cfg := newConfiguration(root, FormatDesigner, props.text("Name"), props.synonym())
+	cfg.stamp(file)
+	cfg.Modules = listModules(filepath.Join(root, "Ext"))
+
+	var refs []objectRef
+	if children := el.child("ChildObjects"); children != nil {
+		for _, c := range children.Nodes {
+			kind, ok := KindOf(ObjectType(c.XMLName.Local))
+			name := strings.TrimSpace(c.Text)
+			if !ok || name == "" {
+				continue
+			}
+			refs = append(refs, objectRef{
+				kind: kind,
+				name: name,
+				file: filepath.Join(root, kind.Dir, name+".xml"),
+				dir:  filepath.Join(root, kind.Dir, name),
+			})
+		}
+	}
+
+	cfg.parseObjects(refs)
+	return cfg, nil
+}
+
+// ParseEDT parses an EDT project source directory: root/Configuration/Configuration.mdo
+// lists the objects, each described by root/<Dir>/<Name>/<Name>.mdo next to its files.
+func ParseEDT(root string) (*Configuration, error) {
+	file := filepath.Join(root, "Configuration", "Configuration.mdo")
+	el, err := readXML(file)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := newConfiguration(root, FormatEDT, el.text("name"), el.synonym())
+	cfg.stamp(file)
+	cfg.Modules = listModules(filepath.Join(root, "Configuration"))
+
+	var refs []objectRef
+	for _, kind := range Kinds {
+		for _, c := range el.children(kind.List) {
+			// <catalogs>Catalog.Товары</catalogs>
+			_, name, ok := strings.Cut(strings.TrimSpace(c.Text), ".")
+			if !ok || name == "" {
+				continue
+			}
+			refs = append(refs, edtRef(kind, filepath.Join(root, kind.Dir), name))
+		}
+	}
+
+	cfg.parseObjects(refs)
+	return cfg, nil
+}
+
+func edtRef(kind Kind, parent, name string) objectRef {
+	dir := filepath.Join(parent, name)
+	return objectRef{kind: kind, name: name, file: filepath.Join(dir, name+".mdo"), dir: dir}
+}
+
+func newConfiguration(root string, format Format, name, synonym string) *Configuration {
+	return &Configuration{
+		Name:    name,
+		Synonym: synonym,
+		Format:  format,
+		Root:    root,
+		byName:  make(map[string]*Object),
+		byFile:  make(map[string]FileOwner),
+		stamps:  make(map[string]time.Time),
+
+		moduleBindings: make(map[string][]Binding),
+	}
+}
+
+// parseObjects parses the object files in parallel, keeps them in Kinds order and indexes them.
+// An object whose file cannot be parsed is kept with its name only and reported in Warnings.
+func (cfg *Configuration) parseObjects(refs []objectRef) {
+	order := make(map[ObjectType]int, len(Kinds))
+	for i, k := range Kinds {
+		order[k.Type] = i
+	}
+	sort.SliceStable(refs, func(i, j int) bool { return order[refs[i].kind.Type] < order[refs[j].kind.Type] })
+
+	type parsed struct {
+		objects  []*Object
+		files    []string
+		warnings []string
+	}
+	results := make([]parsed, len(refs))
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < runtime.NumCPU(); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				p := &results[i]
+				cfg.parseObject(refs[i], nil, &p.objects, &p.files, &p.warnings)
+			}
+		}()
+	}
+	for i := range refs {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	for _, p := range results {
+		cfg.Objects = append(cfg.Objects, p.objects...)
+		for _, f := range p.files {
+			cfg.stamp(f)
+		}
+		cfg.Warnings = append(cfg.Warnings, p.warnings...)
+	}
+	cfg.index()
+	cfg.indexBindings()
+}
+
+// parseObject parses one object and, for subsystems, its nested subsystems depth first
+func (cfg *Configuration) parseObject(ref objectRef, parent *Object, objects *[]*Object, files, warnings *[]string) {
+	obj := &Object{Type: ref.kind.Type, Name: ref.name, File: ref.file, Parent: parent}
+	*objects = append(*objects, obj)
+	if parent != nil {
+		parent.Subsystems = append(parent.Subsystems, obj)
+	}
+	*files = append(*files, ref.file)
+
+	var nested []objectRef
+	if doc, err := readXML(ref.file); err != nil {
+		*warnings = append(*warnings, err.Error())
+	} else if cfg.Format == FormatEDT {
+		nested = parseEDTObject(obj, doc, ref)
+	} else {
+		nested = parseDesignerObject(obj, doc, ref)
+	}
+
+	cfg.attachFiles(obj, ref.dir)
+	for _, f := range obj.Forms {
+		if f.File != "" {
+			*files = append(*files, f.File)
+		}
+	}
+
+	for _, n := range nested {
+		cfg.parseObject(n, obj, objects, files, warnings)
+	}
+}
+
+// parseDesignerObject reads <MetaDataObject><Catalog><Properties/><ChildObjects/></Catalog></MetaDataObject>
+func parseDesignerObject(obj *Object, doc *node, ref objectRef) []objectRef {
+	el := doc.child(string(obj.Type))
+	if el == nil {
+		return nil
+	}
+	props := el.child("Properties")
+	children := el.child("ChildObjects")
+
+	if name := props.text("Name"); name != "" {
+		obj.Name = name
+	}
+	obj.Synonym = props.synonym()
+	obj.Comment = props.text("Comment")
+	readProperties(obj, props)
+
+	for _, f := range children.children("Form") {
+		obj.Forms = append(obj.Forms, &Form{Name: strings.TrimSpace(f.Text)})
+	}
+	for _, c := range children.children("Command") {
+		obj.Commands = append(obj.Commands, &Command{Name: c.child("Properties").text("Name")})
+	}
+
+	switch obj.Type {
+	case Subsystem:
+		for _, item := range props.child("Content").children("Item") {
+			obj.Content = append(obj.Content, strings.TrimSpace(item.Text))
+		}
+		var nested []objectRef
+		kind, _ := KindOf(Subsystem)
+		for _, s := range children.children("Subsystem") {
+			name := strings.TrimSpace(s.Text)
+			nested = append(nested, objectRef{
+				kind: kind,
+				name: name,
+				file: filepath.Join(ref.dir, "Subsystems", name+".xml"),
+				dir:  filepath.Join(ref.dir, "Subsystems", name),
+			})
+		}
+		return nested
+	case HTTPService:
+		obj.Handlers = texts(children.find("Handler"))
+	case WebService:
+		obj.Handlers = texts(children.find("ProcedureName"))
+	}
+	return nil
+}
+
+// parseEDTObject reads <mdclass:Catalog> with camelCase properties and repeated
+// <forms>/<commands>/<subsystems> elements directly on the root
+func parseEDTObject(obj *Object, el *node, ref objectRef) []objectRef {
+	if name := el.text("name"); name != "" {
+		obj.Name = name
+	}
+	obj.Synonym = el.synonym()
+	obj.Comment = el.text("comment")
+	readProperties(obj, el)
+
+	for _, f := range el.children("forms") {
+		obj.Forms = append(obj.Forms, &Form{Name: f.text("name")})
+	}
+	for _, c := range el.children("commands") {
+		obj.Commands = append(obj.Commands, &Command{Name: c.text("name")})
+	}
+
+	switch obj.Type {
+	case Subsystem:
+		obj.Content = texts(el.children("content"))
+		var nested []objectRef
+		kind, _ := KindOf(Subsystem)
+		for _, s := range el.children("subsystems") {
+			// Nested subsystems are listed by name, older exports use "Subsystem.Name"
+			name := strings.TrimSpace(s.Text)
+			if _, after, ok := strings.Cut(name, "."); ok {
+				name = after
+			}
+			nested = append(nested, edtRef(kind, filepath.Join(ref.dir, "Subsystems"), name))
+		}
+		return nested
+	case HTTPService:
+		obj.Handlers = texts(el.find("handler"))
+	case WebService:
+		obj.Handlers = texts(el.find("procedureName"))
+	}
+	return nil
+}
+
+// readProperties reads the properties named alike in both formats (case differs)
+func readProperties(obj *Object, props *node) {
+	switch obj.Type {
+	case CommonModule:
+		obj.CommonModule = &CommonModuleFlags{
+			Global:                    props.bool("Global"),
+			ClientManagedApplication:  props.bool("ClientManagedApplication"),
+			ClientOrdinaryApplication: props.bool("ClientOrdinaryApplication"),
+			Server:                    props.bool("Server"),
+			ServerCall:                props.bool("ServerCall"),
+			ExternalConnection:        props.bool("ExternalConnection"),
+			Privileged:                props.bool("Privileged"),
+			ReturnValuesReuse:         props.text("ReturnValuesReuse"),
+		}
+	case EventSubscription:
+		if h := props.text("Handler"); h != "" {
+			obj.Handlers = []string{h}
+		}
+	case ScheduledJob:
+		if h := props.text("MethodName"); h != "" {
+			obj.Handlers = []string{h}
+		}
+	}
+}
+
+// attachFiles resolves the .bsl modules, forms and commands of an object.
+//
+// Designer: <dir>/Ext/ObjectModule.bsl, <dir>/Forms/<F>/Ext/Form.xml + Ext/Form/Module.bsl,
+// <dir>/Commands/<C>/Ext/CommandModule.bsl; a common form keeps its form in <dir>/Ext.
+// EDT: the same without the Ext level, and Form.form instead of Form.xml.
+func (cfg *Configuration) attachFiles(obj *Object, dir string) {
+	ext := func(parts ...string) string {
+		if cfg.Format == FormatDesigner {
+			parts = append([]string{"Ext"}, parts...)
+		}
+		return filepath.Join(parts...)
+	}
+	formFile := func(formDir string) (string, string) {
+		if cfg.Format == FormatDesigner {
+			return filepath.Join(formDir, "Ext", "Form.xml"), filepath.Join(formDir, "Ext", "Form", "Module.bsl")
+		}
+		return filepath.Join(formDir, "Form.form"), filepath.Join(formDir, "Module.bsl")
+	}
+
+	switch obj.Type {
+	case Subsystem, Role:
+		return
+	case CommonForm:
+		// The common form is its own single form; in EDT its Module.bsl is the form module
+		obj.Forms = []*Form{{Name: obj.Name}}
+		file, module := formFile(dir)
+		obj.Forms[0].File = existing(file)
+		obj.Forms[0].Module = existing(module)
+		return
+	}
+
+	obj.Modules = listModules(filepath.Join(dir, ext()))
+	for _, f := range obj.Forms {
+		file, module := formFile(filepath.Join(dir, "Forms", f.Name))
+		f.File = existing(file)
+		f.Module = existing(module)
+	}
+	for _, c := range obj.Commands {
+		c.Module = existing(filepath.Join(dir, "Commands", c.Name, ext("CommandModule.bsl")))
+	}
+}
+
+// listModules returns the .bsl files directly in dir
+func listModules(dir string) []Module {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var modules []Module
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.EqualFold(filepath.Ext(name), ".bsl") {
+			continue
+		}
+		modules = append(modules, Module{
+			Kind: strings.TrimSuffix(name, filepath.Ext(name)),
+			Path: filepath.Join(dir, name),
+		})
+	}
+	return modules
+}
+
+func texts(nodes []*node) []string {
+	var out []string
+	for _, n := range nodes {
+		if t := strings.TrimSpace(n.Text); t != "" {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+func existing(path string) string {
+	if isFile(path) {
+		return path
+	}
+	return ""
+}
+
+func isFile(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
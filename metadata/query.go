@@ -0,0 +1,192 @@
+package metadata
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// index builds the name and file lookups after parsing
+func (cfg *Configuration) index() {
+	for _, m := range cfg.Modules {
+		cfg.byFile[filepath.Clean(m.Path)] = FileOwner{Module: m}
+	}
+	for _, obj := range cfg.Objects {
+		cfg.byName[strings.ToLower(obj.FullName())] = obj
+		for _, m := range obj.Modules {
+			cfg.byFile[filepath.Clean(m.Path)] = FileOwner{Object: obj, Module: m}
+		}
+		for _, f := range obj.Forms {
+			if f.Module != "" {
+				cfg.byFile[filepath.Clean(f.Module)] = FileOwner{Object: obj, Module: Module{Kind: "FormModule", Path: f.Module}, Form: f}
+			}
+		}
+		for _, c := range obj.Commands {
+			if c.Module != "" {
+				cfg.byFile[filepath.Clean(c.Module)] = FileOwner{Object: obj, Module: Module{Kind: "CommandModule", Path: c.Module}, Command: c}
+			}
+		}
+	}
+}
+
+// Find returns an object by full name in English or Russian: "Catalog.Товары",
+// "Справочник.Товары", "Subsystem.Продажи.Subsystem.Опт". Lookup is case-insensitive.
+func (cfg *Configuration) Find(fullName string) *Object {
+	parts := strings.Split(strings.TrimSpace(fullName), ".")
+	if len(parts) < 2 || len(parts)%2 != 0 {
+		return nil
+	}
+	for i := 0; i < len(parts); i += 2 {
+		t, ok := ParseType(parts[i])
+		if !ok {
+			return nil
+		}
+		parts[i] = string(t)
+	}
+	return cfg.byName[strings.ToLower(strings.Join(parts, "."))]
+}
+
+// ObjectsOfType returns the objects of one type in configuration order
+func (cfg *Configuration) ObjectsOfType(t ObjectType) []*Object {
+	var out []*Object
+	for _, obj := range cfg.Objects {
+		if obj.Type == t {
+			out = append(out, obj)
+		}
+	}
+	return out
+}
+
+// Search returns objects whose name, synonym or full name contains query, case-insensitively.
+// An empty objectType searches all types.
+func (cfg *Configuration) Search(query string, objectType ObjectType) []*Object {
+	q := strings.ToLower(strings.TrimSpace(query))
+	var out []*Object
+	for _, obj := range cfg.Objects {
+		if objectType != "" && obj.Type != objectType {
+			continue
+		}
+		if strings.Contains(strings.ToLower(obj.Name), q) ||
+			strings.Contains(strings.ToLower(obj.Synonym), q) ||
+			strings.Contains(strings.ToLower(obj.FullName()), q) ||
+			strings.Contains(strings.ToLower(obj.RussianName()), q) {
+			out = append(out, obj)
+		}
+	}
+	return out
+}
+
+// Owner returns the object, form or command a .bsl file belongs to
+func (cfg *Configuration) Owner(path string) (FileOwner, bool) {
+	owner, ok := cfg.byFile[filepath.Clean(path)]
+	return owner, ok
+}
+
+// stamp records the modification time of a description file for Stale
+func (cfg *Configuration) stamp(path string) {
+	info, err := os.Stat(path)
+	if err != nil {
+		cfg.stamps[path] = time.Time{}
+		return
+	}
+	cfg.stamps[path] = info.ModTime()
+}
+
+// Stale reports whether any description file changed since parsing. Adding or removing
+// objects rewrites Configuration.xml/.mdo, so new objects are noticed as well.
+func (cfg *Configuration) Stale() bool {
+	for path, modTime := range cfg.stamps {
+		info, err := os.Stat(path)
+		if err != nil {
+			if !modTime.IsZero() {
+				return true
+			}
+			continue
+		}
+		if !info.ModTime().Equal(modTime) {
+			return true
+		}
+	}
+	return false
+}
+
+// Cache keeps parsed configurations by directory and reparses one once it is stale.
+// c.mu only guards the maps; checking and parsing a configuration hold the lock of
+// its entry, so lookups of other configurations don't wait for them.
+type Cache struct {
+	mu      sync.Mutex
+	configs map[string]*cacheEntry
+	roots   map[string]string // directory of a file -> configuration root found above it
+}
+
+// cacheEntry holds one configuration; callers that find it stale while another one
+// reparses it wait for that parse instead of starting their own
+type cacheEntry struct {
+	mu  sync.Mutex
+	cfg *Configuration
+}
+
+func NewCache() *Cache {
+	return &Cache{
+		configs: make(map[string]*cacheEntry),
+		roots:   make(map[string]string),
+	}
+}
+
+// ForFile returns the configuration a file belongs to, looking for the dump root
+// in the file's directory and its parents
+func (c *Cache) ForFile(path string) (*Configuration, error) {
+	dir := filepath.Dir(filepath.Clean(path))
+
+	c.mu.Lock()
+	root, ok := c.roots[dir]
+	c.mu.Unlock()
+
+	if !ok {
+		for d := dir; ; {
+			if isFile(filepath.Join(d, "Configuration.xml")) || isFile(filepath.Join(d, "Configuration", "Configuration.mdo")) {
+				root = d
+				break
+			}
+			parent := filepath.Dir(d)
+			if parent == d {
+				return nil, fmt.Errorf("%w above %s", ErrNotFound, path)
+			}
+			d = parent
+		}
+		c.mu.Lock()
+		c.roots[dir] = root
+		c.mu.Unlock()
+	}
+	return c.Load(root)
+}
+
+// Load returns the configuration in dir or below it, parsing it on first use or after changes
+func (c *Cache) Load(dir string) (*Configuration, error) {
+	dir = filepath.Clean(dir)
+
+	c.mu.Lock()
+	entry, ok := c.configs[dir]
+	if !ok {
+		entry = &cacheEntry{}
+		c.configs[dir] = entry
+	}
+	c.mu.Unlock()
+
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	if entry.cfg != nil && !entry.cfg.Stale() {
+		return entry.cfg, nil
+	}
+	cfg, err := Load(dir)
+	if err != nil {
+		entry.cfg = nil
+		return nil, err
+	}
+	entry.cfg = cfg
+	return cfg, nil
+}
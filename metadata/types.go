@@ -0,0 +1,222 @@
+// Package metadata reads the metadata tree of a 1C:Enterprise configuration from
+// its source dump: Designer format (Configuration.xml plus one XML file per object)
+// or EDT format (one .mdo file per object under src/).
+package metadata
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// Format is the layout of a configuration dump
+type Format string
+
+const (
+	FormatDesigner Format = "designer"
+	FormatEDT      Format = "edt"
+)
+
+// ObjectType is the English metadata class name, e.g. "Catalog" or "CommonModule"
+type ObjectType string
+
+const (
+	Catalog                    ObjectType = "Catalog"
+	Document                   ObjectType = "Document"
+	DocumentJournal            ObjectType = "DocumentJournal"
+	Enum                       ObjectType = "Enum"
+	Report                     ObjectType = "Report"
+	DataProcessor              ObjectType = "DataProcessor"
+	ChartOfCharacteristicTypes ObjectType = "ChartOfCharacteristicTypes"
+	ChartOfAccounts            ObjectType = "ChartOfAccounts"
+	ChartOfCalculationTypes    ObjectType = "ChartOfCalculationTypes"
+	InformationRegister        ObjectType = "InformationRegister"
+	AccumulationRegister       ObjectType = "AccumulationRegister"
+	AccountingRegister         ObjectType = "AccountingRegister"
+	CalculationRegister        ObjectType = "CalculationRegister"
+	BusinessProcess            ObjectType = "BusinessProcess"
+	Task                       ObjectType = "Task"
+	ExchangePlan               ObjectType = "ExchangePlan"
+	Constant                   ObjectType = "Constant"
+	FilterCriterion            ObjectType = "FilterCriterion"
+	SettingsStorage            ObjectType = "SettingsStorage"
+	CommonModule               ObjectType = "CommonModule"
+	CommonForm                 ObjectType = "CommonForm"
+	CommonCommand              ObjectType = "CommonCommand"
+	Subsystem                  ObjectType = "Subsystem"
+	Role                       ObjectType = "Role"
+	EventSubscription          ObjectType = "EventSubscription"
+	ScheduledJob               ObjectType = "ScheduledJob"
+	HTTPService                ObjectType = "HTTPService"
+	WebService                 ObjectType = "WebService"
+)
+
+// Kind describes where objects of one type live in both dump formats
+type Kind struct {
+	Type    ObjectType
+	Dir     string // directory of the objects in both formats, e.g. "Catalogs"
+	List    string // child element of Configuration.mdo listing the objects (EDT)
+	Russian string // Russian class name used in 1C code, e.g. "Справочник"
+}
+
+// Kinds lists the supported object types in the order the Designer shows them
+var Kinds = []Kind{
+	{Subsystem, "Subsystems", "subsystems", "Подсистема"},
+	{CommonModule, "CommonModules", "commonModules", "ОбщийМодуль"},
+	{SettingsStorage, "SettingsStorages", "settingsStorages", "ХранилищеНастроек"},
+	{CommonForm, "CommonForms", "commonForms", "ОбщаяФорма"},
+	{CommonCommand, "CommonCommands", "commonCommands", "ОбщаяКоманда"},
+	{Role, "Roles", "roles", "Роль"},
+	{EventSubscription, "EventSubscriptions", "eventSubscriptions", "ПодпискаНаСобытие"},
+	{ScheduledJob, "ScheduledJobs", "scheduledJobs", "РегламентноеЗадание"},
+	{FilterCriterion, "FilterCriteria", "filterCriteria", "КритерийОтбора"},
+	{WebService, "WebServices", "webServices", "WebСервис"},
+	{HTTPService, "HTTPServices", "httpServices", "HTTPСервис"},
+	{Constant, "Constants", "constants", "Константа"},
+	{Catalog, "Catalogs", "catalogs", "Справочник"},
+	{Document, "Documents", "documents", "Документ"},
+	{DocumentJournal, "DocumentJournals", "documentJournals", "ЖурналДокументов"},
+	{Enum, "Enums", "enums", "Перечисление"},
+	{Report, "Reports", "reports", "Отчет"},
+	{DataProcessor, "DataProcessors", "dataProcessors", "Обработка"},
+	{ChartOfCharacteristicTypes, "ChartsOfCharacteristicTypes", "chartsOfCharacteristicTypes", "ПланВидовХарактеристик"},
+	{ChartOfAccounts, "ChartsOfAccounts", "chartsOfAccounts", "ПланСчетов"},
+	{ChartOfCalculationTypes, "ChartsOfCalculationTypes", "chartsOfCalculationTypes", "ПланВидовРасчета"},
+	{InformationRegister, "InformationRegisters", "informationRegisters", "РегистрСведений"},
+	{AccumulationRegister, "AccumulationRegisters", "accumulationRegisters", "РегистрНакопления"},
+	{AccountingRegister, "AccountingRegisters", "accountingRegisters", "РегистрБухгалтерии"},
+	{CalculationRegister, "CalculationRegisters", "calculationRegisters", "РегистрРасчета"},
+	{BusinessProcess, "BusinessProcesses", "businessProcesses", "БизнесПроцесс"},
+	{Task, "Tasks", "tasks", "Задача"},
+	{ExchangePlan, "ExchangePlans", "exchangePlans", "ПланОбмена"},
+}
+
+// KindOf returns the kind of a known object type
+func KindOf(t ObjectType) (Kind, bool) {
+	for _, k := range Kinds {
+		if k.Type == t {
+			return k, true
+		}
+	}
+	return Kind{}, false
+}
+
+// ParseType resolves a type given as English name, directory name or Russian name,
+// case-insensitively: "Catalog", "catalogs" and "Справочник" all give Catalog.
+func ParseType(s string) (ObjectType, bool) {
+	for _, k := range Kinds {
+		if strings.EqualFold(s, string(k.Type)) || strings.EqualFold(s, k.Dir) || strings.EqualFold(s, k.Russian) {
+			return k.Type, true
+		}
+	}
+	return "", false
+}
+
+// Configuration is the parsed metadata tree
+type Configuration struct {
+	Name    string   `json:"name"`
+	Synonym string   `json:"synonym,omitempty"`
+	Format  Format   `json:"format"`
+	Root    string   `json:"root"`              // directory holding Configuration.xml, or the EDT src directory
+	Modules []Module `json:"modules,omitempty"` // session, application and external connection modules
+
+	// Objects holds every object including nested subsystems, grouped by type in Kinds order
+	Objects []*Object `json:"-"`
+
+	// Warnings lists object files that could not be read; those objects keep their name only
+	Warnings []string `json:"warnings,omitempty"`
+
+	byName map[string]*Object   // lowercase full name -> object
+	byFile map[string]FileOwner // cleaned .bsl path -> owner
+	stamps map[string]time.Time // description files -> modification time at parse
+
+	moduleBindings map[string][]Binding // module path -> handlers configured outside forms
+}
+
+// Object is one metadata object with its modules
+type Object struct {
+	Type    ObjectType `json:"type"`
+	Name    string     `json:"name"`
+	Synonym string     `json:"synonym,omitempty"`
+	Comment string     `json:"comment,omitempty"`
+	File    string     `json:"file"` // the .xml or .mdo description
+
+	Modules  []Module   `json:"modules,omitempty"`
+	Forms    []*Form    `json:"forms,omitempty"`
+	Commands []*Command `json:"commands,omitempty"`
+
+	// CommonModule holds the compilation flags of a common module
+	CommonModule *CommonModuleFlags `json:"common_module,omitempty"`
+
+	// Handlers are the procedures the platform calls: "CommonModule.Module.Method" for
+	// event subscriptions and scheduled jobs, method names of the module for HTTP and web services
+	Handlers []string `json:"handlers,omitempty"`
+
+	// Content lists the objects included in a subsystem, e.g. "Catalog.Товары"
+	Content    []string  `json:"content,omitempty"`
+	Subsystems []*Object `json:"subsystems,omitempty"`
+	Parent     *Object   `json:"-"` // enclosing subsystem
+}
+
+// Module is a .bsl file of an object or of the configuration
+type Module struct {
+	Kind string `json:"kind"` // file name without extension: ObjectModule, ManagerModule, Module, SessionModule...
+	Path string `json:"path"`
+}
+
+// Form is a managed form of an object; a common form has a single form named after it
+type Form struct {
+	Name   string `json:"name"`
+	File   string `json:"file,omitempty"`   // Ext/Form.xml (Designer) or Form.form (EDT)
+	Module string `json:"module,omitempty"` // form module .bsl
+
+	bindOnce sync.Once
+	bindings []Binding
+	bindErr  error
+}
+
+// Command is a command of an object or a common command
+type Command struct {
+	Name   string `json:"name"`
+	Module string `json:"module,omitempty"`
+}
+
+// CommonModuleFlags are the properties that decide where a common module is compiled
+type CommonModuleFlags struct {
+	Global                    bool   `json:"global"`
+	ClientManagedApplication  bool   `json:"client_managed_application"`
+	ClientOrdinaryApplication bool   `json:"client_ordinary_application"`
+	Server                    bool   `json:"server"`
+	ServerCall                bool   `json:"server_call"`
+	ExternalConnection        bool   `json:"external_connection"`
+	Privileged                bool   `json:"privileged"`
+	ReturnValuesReuse         string `json:"return_values_reuse,omitempty"`
+}
+
+// FileOwner tells which object, form or command a module belongs to
+type FileOwner struct {
+	Object  *Object  // nil for configuration modules
+	Module  Module   // the module itself
+	Form    *Form    // set for form modules
+	Command *Command // set for command modules
+}
+
+// FullName returns the name used in 1C code, e.g. "Catalog.Товары".
+// Nested subsystems include their parents: "Subsystem.Продажи.Subsystem.Опт".
+func (o *Object) FullName() string {
+	name := string(o.Type) + "." + o.Name
+	if o.Parent != nil {
+		return o.Parent.FullName() + "." + name
+	}
+	return name
+}
+
+// RussianName returns the full name with the Russian class name, e.g. "Справочник.Товары"
+func (o *Object) RussianName() string {
+	kind, _ := KindOf(o.Type)
+	name := kind.Russian + "." + o.Name
+	if o.Parent != nil {
+		return o.Parent.RussianName() + "." + name
+	}
+	return name
+}
@@ -0,0 +1,111 @@
+package metadata
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// node is a namespace-agnostic XML element. Both dump formats are read through it:
+// Designer nests properties under <Properties>, EDT puts them on the root in camelCase,
+// so lookups match local names case-insensitively.
+type node struct {
+	XMLName xml.Name
+	Text    string `xml:",chardata"`
+	Nodes   []node `xml:",any"`
+}
+
+func readXML(path string) (*node, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var root node
+	if err := xml.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return &root, nil
+}
+
+// child returns the first child element with the given local name
+func (n *node) child(name string) *node {
+	if n == nil {
+		return nil
+	}
+	for i := range n.Nodes {
+		if strings.EqualFold(n.Nodes[i].XMLName.Local, name) {
+			return &n.Nodes[i]
+		}
+	}
+	return nil
+}
+
+// children returns all child elements with the given local name
+func (n *node) children(name string) []*node {
+	if n == nil {
+		return nil
+	}
+	var out []*node
+	for i := range n.Nodes {
+		if strings.EqualFold(n.Nodes[i].XMLName.Local, name) {
+			out = append(out, &n.Nodes[i])
+		}
+	}
+	return out
+}
+
+// text returns the trimmed text of the first child with the given name
+func (n *node) text(name string) string {
+	if c := n.child(name); c != nil {
+		return strings.TrimSpace(c.Text)
+	}
+	return ""
+}
+
+func (n *node) bool(name string) bool {
+	return n.text(name) == "true"
+}
+
+// find returns all descendants with the given local name, depth first
+func (n *node) find(name string) []*node {
+	var out []*node
+	for i := range n.Nodes {
+		c := &n.Nodes[i]
+		if strings.EqualFold(c.XMLName.Local, name) {
+			out = append(out, c)
+		}
+		out = append(out, c.find(name)...)
+	}
+	return out
+}
+
+// synonym returns the Russian synonym, or the first one when there is no Russian one.
+// Designer: <Synonym><v8:item><v8:lang>ru</v8:lang><v8:content>…</v8:content></v8:item></Synonym>
+// EDT: <synonym><key>ru</key><value>…</value></synonym>, repeated per language
+func (n *node) synonym() string {
+	var first string
+	pick := func(lang, content string) bool {
+		if first == "" {
+			first = content
+		}
+		return lang == "ru"
+	}
+
+	if s := n.child("Synonym"); s != nil {
+		for _, item := range s.children("item") {
+			if pick(item.text("lang"), item.text("content")) {
+				return item.text("content")
+			}
+		}
+	}
+	for _, s := range n.children("synonym") {
+		if s.child("key") == nil {
+			continue
+		}
+		if pick(s.text("key"), s.text("value")) {
+			return s.text("value")
+		}
+	}
+	return first
+}
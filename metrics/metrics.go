@@ -0,0 +1,394 @@
+// Package metrics keeps request counters, latency histograms and gauges and
+// exposes them in the Prometheus text format. It is intentionally small: the
+// bridge and the session manager need a handful of series, not a client library.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultLatencyBuckets covers fast symbol lookups up to slow workspace-wide requests (seconds)
+var DefaultLatencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120}
+
+// Default is the registry served by the /metrics endpoint of both binaries
+var Default = NewRegistry()
+
+// Registry holds metric families in registration order
+type Registry struct {
+	mu         sync.Mutex
+	families   []family
+	names      map[string]bool
+	collectors []func()
+}
+
+// family is one metric name with its HELP and TYPE lines
+type family interface {
+	write(w *bufio.Writer)
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{names: make(map[string]bool)}
+}
+
+// register adds a family; a duplicate name is a programming error
+func (r *Registry) register(name string, f family) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.names[name] {
+		panic("metrics: duplicate metric " + name)
+	}
+	r.names[name] = true
+	r.families = append(r.families, f)
+}
+
+// OnCollect registers fn to run before every scrape, to refresh gauges that are
+// cheaper to read on demand (process RSS, queue lengths)
+func (r *Registry) OnCollect(fn func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.collectors = append(r.collectors, fn)
+}
+
+// Collect runs the OnCollect hooks
+func (r *Registry) Collect() {
+	r.mu.Lock()
+	collectors := append([]func(){}, r.collectors...)
+	r.mu.Unlock()
+
+	for _, fn := range collectors {
+		fn()
+	}
+}
+
+// WriteText collects and writes every family in the Prometheus text format 0.0.4
+func (r *Registry) WriteText(w io.Writer) error {
+	r.Collect()
+
+	r.mu.Lock()
+	families := append([]family{}, r.families...)
+	r.mu.Unlock()
+
+	bw := bufio.NewWriter(w)
+	for _, f := range families {
+		f.write(bw)
+	}
+	return bw.Flush()
+}
+
+// desc is the name, help and label names shared by every metric type
+type desc struct {
+	name   string
+	help   string
+	labels []string
+}
+
+func (d desc) header(w *bufio.Writer, kind string) {
+	help := strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(d.help)
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", d.name, help, d.name, kind)
+}
+
+// key joins label values into a series key; it panics on a label count mismatch
+func (d desc) key(values []string) string {
+	if len(values) != len(d.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", d.name, len(d.labels), len(values)))
+	}
+	return strings.Join(values, "\xff")
+}
+
+// labelString renders {a="x",b="y"}, with extra appended after the declared labels
+func (d desc) labelString(values []string, extra ...string) string {
+	if len(d.labels) == 0 && len(extra) == 0 {
+		return ""
+	}
+	escape := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	parts := make([]string, 0, len(d.labels)+len(extra)/2)
+	for i, label := range d.labels {
+		parts = append(parts, label+`="`+escape.Replace(values[i])+`"`)
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		parts = append(parts, extra[i]+`="`+escape.Replace(extra[i+1])+`"`)
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+// sortedKeys returns map keys in a stable order for deterministic output
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// valueSeries is one labelled value of a counter or gauge
+type valueSeries struct {
+	labels []string
+	value  float64
+}
+
+// valueVec is the storage shared by Counter and Gauge
+type valueVec struct {
+	desc
+	mu     sync.Mutex
+	series map[string]*valueSeries
+}
+
+func (v *valueVec) add(delta float64, values []string) {
+	key := v.key(values)
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	s, ok := v.series[key]
+	if !ok {
+		s = &valueSeries{labels: append([]string{}, values...)}
+		v.series[key] = s
+	}
+	s.value += delta
+}
+
+func (v *valueVec) set(value float64, values []string) {
+	key := v.key(values)
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	s, ok := v.series[key]
+	if !ok {
+		s = &valueSeries{labels: append([]string{}, values...)}
+		v.series[key] = s
+	}
+	s.value = value
+}
+
+func (v *valueVec) get(values []string) float64 {
+	key := v.key(values)
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if s, ok := v.series[key]; ok {
+		return s.value
+	}
+	return 0
+}
+
+func (v *valueVec) writeSeries(w *bufio.Writer, kind string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.header(w, kind)
+	if len(v.labels) == 0 && len(v.series) == 0 {
+		// Unlabelled metrics are always present, even before the first update
+		fmt.Fprintf(w, "%s 0\n", v.name)
+		return
+	}
+	for _, key := range sortedKeys(v.series) {
+		s := v.series[key]
+		fmt.Fprintf(w, "%s%s %s\n", v.name, v.labelString(s.labels), formatFloat(s.value))
+	}
+}
+
+// Counter is a monotonically increasing value per label set
+type Counter struct {
+	valueVec
+}
+
+// NewCounter registers a counter with the given label names
+func (r *Registry) NewCounter(name, help string, labels ...string) *Counter {
+	c := &Counter{valueVec{desc: desc{name: name, help: help, labels: labels}, series: make(map[string]*valueSeries)}}
+	r.register(name, c)
+	return c
+}
+
+// Inc adds one to the series with the given label values
+func (c *Counter) Inc(values ...string) {
+	c.add(1, values)
+}
+
+// Add adds a non-negative delta to the series with the given label values
+func (c *Counter) Add(delta float64, values ...string) {
+	if delta < 0 {
+		panic("metrics: counter " + c.name + " cannot decrease")
+	}
+	c.add(delta, values)
+}
+
+// Value returns the current value of a series
+func (c *Counter) Value(values ...string) float64 {
+	return c.get(values)
+}
+
+func (c *Counter) write(w *bufio.Writer) {
+	c.writeSeries(w, "counter")
+}
+
+// Gauge is a value per label set that can go up and down
+type Gauge struct {
+	valueVec
+}
+
+// NewGauge registers a gauge with the given label names
+func (r *Registry) NewGauge(name, help string, labels ...string) *Gauge {
+	g := &Gauge{valueVec{desc: desc{name: name, help: help, labels: labels}, series: make(map[string]*valueSeries)}}
+	r.register(name, g)
+	return g
+}
+
+// Set replaces the value of a series
+func (g *Gauge) Set(value float64, values ...string) {
+	g.set(value, values)
+}
+
+// Add changes the value of a series by delta
+func (g *Gauge) Add(delta float64, values ...string) {
+	g.add(delta, values)
+}
+
+// Value returns the current value of a series
+func (g *Gauge) Value(values ...string) float64 {
+	return g.get(values)
+}
+
+func (g *Gauge) write(w *bufio.Writer) {
+	g.writeSeries(w, "gauge")
+}
+
+// histogramSeries is one labelled histogram; counts are per bucket, not cumulative
+type histogramSeries struct {
+	labels []string
+	counts []uint64 // len(buckets)+1, the last one is +Inf
+	count  uint64
+	sum    float64
+}
+
+// Histogram counts observations into fixed buckets per label set
+type Histogram struct {
+	desc
+	buckets []float64
+	mu      sync.Mutex
+	series  map[string]*histogramSeries
+}
+
+// NewHistogram registers a histogram; buckets are upper bounds in increasing order
+func (r *Registry) NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	if !sort.Float64sAreSorted(buckets) {
+		panic("metrics: histogram " + name + " buckets are not sorted")
+	}
+	h := &Histogram{
+		desc:    desc{name: name, help: help, labels: labels},
+		buckets: append([]float64{}, buckets...),
+		series:  make(map[string]*histogramSeries),
+	}
+	r.register(name, h)
+	return h
+}
+
+// Observe records one value in the series with the given label values
+func (h *Histogram) Observe(value float64, values ...string) {
+	key := h.key(values)
+	idx := sort.SearchFloat64s(h.buckets, value) // first bucket with bound >= value
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s, ok := h.series[key]
+	if !ok {
+		s = &histogramSeries{labels: append([]string{}, values...), counts: make([]uint64, len(h.buckets)+1)}
+		h.series[key] = s
+	}
+	s.counts[idx]++
+	s.count++
+	s.sum += value
+}
+
+// HistogramSnapshot is a copy of one histogram series
+type HistogramSnapshot struct {
+	Labels []string
+	Count  uint64
+	Sum    float64
+
+	bounds []float64
+	counts []uint64
+}
+
+// Mean returns the average observation, 0 without observations
+func (s HistogramSnapshot) Mean() float64 {
+	if s.Count == 0 {
+		return 0
+	}
+	return s.Sum / float64(s.Count)
+}
+
+// Quantile estimates the q-quantile by linear interpolation inside its bucket,
+// like histogram_quantile. Values above the last bound report that bound.
+func (s HistogramSnapshot) Quantile(q float64) float64 {
+	if s.Count == 0 {
+		return 0
+	}
+	rank := q * float64(s.Count)
+	var seen uint64
+	for i, n := range s.counts {
+		if n == 0 || float64(seen+n) < rank {
+			seen += n
+			continue
+		}
+		if i == len(s.bounds) {
+			return s.bounds[len(s.bounds)-1]
+		}
+		lower := 0.0
+		if i > 0 {
+			lower = s.bounds[i-1]
+		}
+		return lower + (s.bounds[i]-lower)*(rank-float64(seen))/float64(n)
+	}
+	return s.bounds[len(s.bounds)-1]
+}
+
+// Snapshot copies every series, ordered by label values
+func (h *Histogram) Snapshot() []HistogramSnapshot {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	snapshots := make([]HistogramSnapshot, 0, len(h.series))
+	for _, key := range sortedKeys(h.series) {
+		s := h.series[key]
+		snapshots = append(snapshots, HistogramSnapshot{
+			Labels: append([]string{}, s.labels...),
+			Count:  s.count,
+			Sum:    s.sum,
+			bounds: h.buckets,
+			counts: append([]uint64{}, s.counts...),
+		})
+	}
+	return snapshots
+}
+
+func (h *Histogram) write(w *bufio.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.header(w, "histogram")
+	for _, key := range sortedKeys(h.series) {
+		s := h.series[key]
+		var cumulative uint64
+		for i, bound := range h.buckets {
+			cumulative += s.counts[i]
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, h.labelString(s.labels, "le", formatFloat(bound)), cumulative)
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, h.labelString(s.labels, "le", "+Inf"), s.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.name, h.labelString(s.labels), formatFloat(s.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.name, h.labelString(s.labels), s.count)
+	}
+}
@@ -0,0 +1,93 @@
+package metrics
+
+import (
+	"bytes"
+	"io"
+	"net/http/httptest"
+	"os"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteText(t *testing.T) {
+	r := NewRegistry()
+	requests := r.NewCounter("test_requests_total", "Requests by method", "method", "outcome")
+	latency := r.NewHistogram("test_request_duration_seconds", "Request latency", []float64{0.1, 1}, "method")
+	pending := r.NewGauge("test_pending_requests", "Pending requests")
+	state := r.NewGauge("test_state", "Current state", "state")
+
+	requests.Inc("textDocument/hover", "ok")
+	requests.Inc("textDocument/hover", "ok")
+	requests.Inc(`say "hi"`, "error")
+	latency.Observe(0.05, "textDocument/hover")
+	latency.Observe(0.5, "textDocument/hover")
+	latency.Observe(3, "textDocument/hover")
+	r.OnCollect(func() { state.Set(1, "indexing") })
+
+	var buf bytes.Buffer
+	require.NoError(t, r.WriteText(&buf))
+	assert.Equal(t, `# HELP test_requests_total Requests by method
+# TYPE test_requests_total counter
+test_requests_total{method="say \"hi\"",outcome="error"} 1
+test_requests_total{method="textDocument/hover",outcome="ok"} 2
+# HELP test_request_duration_seconds Request latency
+# TYPE test_request_duration_seconds histogram
+test_request_duration_seconds_bucket{method="textDocument/hover",le="0.1"} 1
+test_request_duration_seconds_bucket{method="textDocument/hover",le="1"} 2
+test_request_duration_seconds_bucket{method="textDocument/hover",le="+Inf"} 3
+test_request_duration_seconds_sum{method="textDocument/hover"} 3.55
+test_request_duration_seconds_count{method="textDocument/hover"} 3
+# HELP test_pending_requests Pending requests
+# TYPE test_pending_requests gauge
+test_pending_requests 0
+# HELP test_state Current state
+# TYPE test_state gauge
+test_state{state="indexing"} 1
+`, buf.String())
+
+	pending.Add(2)
+	pending.Add(-1)
+	assert.Equal(t, 1.0, pending.Value())
+	assert.Equal(t, 2.0, requests.Value("textDocument/hover", "ok"))
+	assert.Panics(t, func() { requests.Inc("missing outcome") })
+	assert.Panics(t, func() { r.NewGauge("test_state", "again") })
+
+	rec := httptest.NewRecorder()
+	r.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body, _ := io.ReadAll(rec.Body)
+	assert.Equal(t, contentType, rec.Header().Get("Content-Type"))
+	assert.Contains(t, string(body), "test_pending_requests 1\n")
+}
+
+func TestHistogramSnapshot(t *testing.T) {
+	r := NewRegistry()
+	latency := r.NewHistogram("test_latency_seconds", "Latency", []float64{1, 2, 4}, "method")
+	for _, v := range []float64{0.5, 1.5, 1.5, 3, 10} {
+		latency.Observe(v, "a")
+	}
+
+	snapshots := latency.Snapshot()
+	require.Len(t, snapshots, 1)
+	s := snapshots[0]
+	assert.Equal(t, []string{"a"}, s.Labels)
+	assert.Equal(t, uint64(5), s.Count)
+	assert.InDelta(t, 3.3, s.Mean(), 1e-9)
+	assert.InDelta(t, 1.75, s.Quantile(0.5), 1e-9, "the median is interpolated inside the (1, 2] bucket")
+	assert.Equal(t, 4.0, s.Quantile(0.99), "observations above the last bound report that bound")
+	assert.Equal(t, 0.0, HistogramSnapshot{}.Quantile(0.5))
+}
+
+func TestProcessRSS(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("ProcessRSS reads /proc")
+	}
+	rss, err := ProcessRSS(os.Getpid())
+	require.NoError(t, err)
+	assert.Greater(t, rss, int64(1<<20))
+
+	_, err = ProcessRSS(-1)
+	assert.Error(t, err)
+}
@@ -1,8 +1,10 @@
 package mocks
 
 import (
+	"context"
 	"encoding/json"
 
+	"rockerboo/mcp-lsp-bridge/interfaces"
 	"rockerboo/mcp-lsp-bridge/types"
 	"rockerboo/mcp-lsp-bridge/utils"
 
@@ -14,13 +16,29 @@ type MockBridge struct {
 	mock.Mock
 }
 
+func (m *MockBridge) WithContext(ctx context.Context) interfaces.BridgeInterface {
+	// Default behavior for tests: the mock stands in for its own views.
+	return m
+}
+
 func (m *MockBridge) HasPathMapper() bool {
 	// Default behavior for tests: no path mapping unless explicitly needed.
+	for _, c := range m.ExpectedCalls {
+		if c.Method == "HasPathMapper" {
+			return m.Called().Bool(0)
+		}
+	}
 	return false
 }
 
 func (m *MockBridge) GetPathMapper() *utils.DockerPathMapper {
-	// Default behavior for tests: no mapper.
+	// Default behavior for tests: no mapper unless configured.
+	for _, c := range m.ExpectedCalls {
+		if c.Method == "GetPathMapper" {
+			mapper, _ := m.Called().Get(0).(*utils.DockerPathMapper)
+			return mapper
+		}
+	}
 	return nil
 }
 
@@ -154,6 +172,16 @@ func (m *MockBridge) GetSignatureHelp(uri string, line, character uint32) (*prot
 	return args.Get(0).(*protocol.SignatureHelp), args.Error(1)
 }
 
+func (m *MockBridge) Completion(uri string, line, character uint32) (*protocol.CompletionList, error) {
+	args := m.Called(uri, line, character)
+	return args.Get(0).(*protocol.CompletionList), args.Error(1)
+}
+
+func (m *MockBridge) ResolveCompletionItem(uri string, item protocol.CompletionItem) (*protocol.CompletionItem, error) {
+	args := m.Called(uri, item)
+	return args.Get(0).(*protocol.CompletionItem), args.Error(1)
+}
+
 func (m *MockBridge) GetCodeActions(uri string, line, character, endLine, endCharacter uint32) ([]protocol.CodeAction, error) {
 	args := m.Called(uri, line, character, endLine, endCharacter)
 	return args.Get(0).([]protocol.CodeAction), args.Error(1)
@@ -184,9 +212,9 @@ func (m *MockBridge) PrepareRename(uri string, line, character uint32) (*protoco
 	return args.Get(0).(*protocol.PrepareRenameResult), args.Error(1)
 }
 
-func (m *MockBridge) ApplyWorkspaceEdit(edit *protocol.WorkspaceEdit) error {
+func (m *MockBridge) ApplyWorkspaceEdit(edit *protocol.WorkspaceEdit) (*types.WorkspaceEditResult, error) {
 	args := m.Called(edit)
-	return args.Error(0)
+	return args.Get(0).(*types.WorkspaceEditResult), args.Error(1)
 }
 
 func (m *MockBridge) FindImplementations(uri string, line, character uint32) ([]protocol.Location, error) {
@@ -194,11 +222,37 @@ func (m *MockBridge) FindImplementations(uri string, line, character uint32) ([]
 	return args.Get(0).([]protocol.Location), args.Error(1)
 }
 
+func (m *MockBridge) FindDeclarations(uri string, line, character uint32) ([]protocol.Or2[protocol.LocationLink, protocol.Location], error) {
+	args := m.Called(uri, line, character)
+	return args.Get(0).([]protocol.Or2[protocol.LocationLink, protocol.Location]), args.Error(1)
+}
+
+func (m *MockBridge) FindTypeDefinitions(uri string, line, character uint32) ([]protocol.Or2[protocol.LocationLink, protocol.Location], error) {
+	args := m.Called(uri, line, character)
+	return args.Get(0).([]protocol.Or2[protocol.LocationLink, protocol.Location]), args.Error(1)
+}
+
+func (m *MockBridge) DocumentHighlight(uri string, line, character uint32) ([]protocol.DocumentHighlight, error) {
+	args := m.Called(uri, line, character)
+	return args.Get(0).([]protocol.DocumentHighlight), args.Error(1)
+}
+
 func (m *MockBridge) SemanticTokens(uri string, targetTypes []string, startLine, startCharacter, endLine, endCharacter uint32) ([]types.TokenPosition, error) {
 	args := m.Called(uri, startLine, startCharacter, endLine, endCharacter)
 	return args.Get(0).([]types.TokenPosition), args.Error(1)
 }
 
+func (m *MockBridge) PositionEncoding(uri string) protocol.PositionEncodingKind {
+	// Most tests use ASCII content; default to the LSP default unless configured.
+	for _, c := range m.ExpectedCalls {
+		if c.Method == "PositionEncoding" {
+			args := m.Called(uri)
+			return args.Get(0).(protocol.PositionEncodingKind)
+		}
+	}
+	return protocol.PositionEncodingKindUTF16
+}
+
 func (m *MockBridge) FoldingRange(uri string) ([]protocol.FoldingRange, error) {
 	args := m.Called(uri)
 	return args.Get(0).([]protocol.FoldingRange), args.Error(1)
@@ -214,6 +268,16 @@ func (m *MockBridge) DocumentLink(uri string) ([]protocol.DocumentLink, error) {
 	return args.Get(0).([]protocol.DocumentLink), args.Error(1)
 }
 
+func (m *MockBridge) CodeLens(uri string) ([]protocol.CodeLens, error) {
+	args := m.Called(uri)
+	return args.Get(0).([]protocol.CodeLens), args.Error(1)
+}
+
+func (m *MockBridge) ResolveCodeLens(uri string, lens protocol.CodeLens) (*protocol.CodeLens, error) {
+	args := m.Called(uri, lens)
+	return args.Get(0).(*protocol.CodeLens), args.Error(1)
+}
+
 func (m *MockBridge) DocumentColor(uri string) ([]protocol.ColorInformation, error) {
 	args := m.Called(uri)
 	return args.Get(0).([]protocol.ColorInformation), args.Error(1)
@@ -254,6 +318,61 @@ func (m *MockBridge) OutgoingCalls(item protocol.CallHierarchyItem) ([]protocol.
 	return args.Get(0).([]protocol.CallHierarchyOutgoingCall), args.Error(1)
 }
 
+// The Context variants fall back to the plain method, so tests that do not care
+// about ctx set their expectations on the plain method
+func (m *MockBridge) PrepareCallHierarchyContext(ctx context.Context, uri string, line, character uint32) ([]protocol.CallHierarchyItem, error) {
+	for _, c := range m.ExpectedCalls {
+		if c.Method == "PrepareCallHierarchyContext" {
+			args := m.Called(ctx, uri, line, character)
+			return args.Get(0).([]protocol.CallHierarchyItem), args.Error(1)
+		}
+	}
+	return m.PrepareCallHierarchy(uri, line, character)
+}
+
+func (m *MockBridge) IncomingCallsContext(ctx context.Context, item protocol.CallHierarchyItem) ([]protocol.CallHierarchyIncomingCall, error) {
+	for _, c := range m.ExpectedCalls {
+		if c.Method == "IncomingCallsContext" {
+			args := m.Called(ctx, item)
+			return args.Get(0).([]protocol.CallHierarchyIncomingCall), args.Error(1)
+		}
+	}
+	return m.IncomingCalls(item)
+}
+
+func (m *MockBridge) OutgoingCallsContext(ctx context.Context, item protocol.CallHierarchyItem) ([]protocol.CallHierarchyOutgoingCall, error) {
+	for _, c := range m.ExpectedCalls {
+		if c.Method == "OutgoingCallsContext" {
+			args := m.Called(ctx, item)
+			return args.Get(0).([]protocol.CallHierarchyOutgoingCall), args.Error(1)
+		}
+	}
+	return m.OutgoingCalls(item)
+}
+
+// Without an expectation the call graph index is absent and callers query the server
+func (m *MockBridge) IndexedIncomingCalls(ctx context.Context, item protocol.CallHierarchyItem) ([]protocol.CallHierarchyIncomingCall, bool, error) {
+	for _, c := range m.ExpectedCalls {
+		if c.Method == "IndexedIncomingCalls" {
+			args := m.Called(ctx, item)
+			calls, _ := args.Get(0).([]protocol.CallHierarchyIncomingCall)
+			return calls, args.Bool(1), args.Error(2)
+		}
+	}
+	return nil, false, nil
+}
+
+func (m *MockBridge) IndexedOutgoingCalls(ctx context.Context, item protocol.CallHierarchyItem) ([]protocol.CallHierarchyOutgoingCall, bool, error) {
+	for _, c := range m.ExpectedCalls {
+		if c.Method == "IndexedOutgoingCalls" {
+			args := m.Called(ctx, item)
+			calls, _ := args.Get(0).([]protocol.CallHierarchyOutgoingCall)
+			return calls, args.Bool(1), args.Error(2)
+		}
+	}
+	return nil, false, nil
+}
+
 func (m *MockBridge) GetDocumentSymbols(uri string) ([]protocol.DocumentSymbol, error) {
 	args := m.Called(uri)
 	return args.Get(0).([]protocol.DocumentSymbol), args.Error(1)
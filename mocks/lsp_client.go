@@ -42,6 +42,11 @@ func (m *MockLanguageClient) Context() context.Context {
 	return args.Get(0).(context.Context)
 }
 
+func (m *MockLanguageClient) WithContext(ctx context.Context) types.LanguageClientInterface {
+	// Default behavior for tests: the mock stands in for its own views.
+	return m
+}
+
 func (m *MockLanguageClient) GetMetrics() types.ClientMetricsProvider {
 	args := m.Called()
 	return args.Get(0).(types.ClientMetricsProvider)
@@ -114,14 +119,14 @@ func (m *MockLanguageClient) TokenParser() types.SemanticTokensParserProvider {
 }
 
 // Text document synchronization
-func (m *MockLanguageClient) DidOpen(uri string, languageId protocol.LanguageKind, text string, version int32) error {
+func (m *MockLanguageClient) DidOpen(uri string, languageId protocol.LanguageKind, text string, version int32) (int32, error) {
 	args := m.Called(uri, languageId, text, version)
-	return args.Error(0)
+	return args.Get(0).(int32), args.Error(1)
 }
 
-func (m *MockLanguageClient) DidChange(uri string, version int32, changes []protocol.TextDocumentContentChangeEvent) error {
+func (m *MockLanguageClient) DidChange(uri string, version int32, changes []protocol.TextDocumentContentChangeEvent) (int32, error) {
 	args := m.Called(uri, version, changes)
-	return args.Error(0)
+	return args.Get(0).(int32), args.Error(1)
 }
 
 func (m *MockLanguageClient) DidSave(uri string, text *string) error {
@@ -250,11 +255,52 @@ func (m *MockLanguageClient) OutgoingCalls(item protocol.CallHierarchyItem) ([]p
 	return args.Get(0).([]protocol.CallHierarchyOutgoingCall), args.Error(1)
 }
 
+func (m *MockLanguageClient) Declaration(uri string, line, character uint32) ([]protocol.Or2[protocol.LocationLink, protocol.Location], error) {
+	args := m.Called(uri, line, character)
+	return args.Get(0).([]protocol.Or2[protocol.LocationLink, protocol.Location]), args.Error(1)
+}
+
+func (m *MockLanguageClient) TypeDefinition(uri string, line, character uint32) ([]protocol.Or2[protocol.LocationLink, protocol.Location], error) {
+	args := m.Called(uri, line, character)
+	return args.Get(0).([]protocol.Or2[protocol.LocationLink, protocol.Location]), args.Error(1)
+}
+
+func (m *MockLanguageClient) DocumentHighlight(uri string, line, character uint32) ([]protocol.DocumentHighlight, error) {
+	args := m.Called(uri, line, character)
+	return args.Get(0).([]protocol.DocumentHighlight), args.Error(1)
+}
+
 func (m *MockLanguageClient) SignatureHelp(uri string, line, character uint32) (*protocol.SignatureHelp, error) {
 	args := m.Called(uri, line, character)
 	return args.Get(0).(*protocol.SignatureHelp), args.Error(1)
 }
 
+func (m *MockLanguageClient) Completion(uri string, line, character uint32) (*protocol.CompletionList, error) {
+	args := m.Called(uri, line, character)
+	return args.Get(0).(*protocol.CompletionList), args.Error(1)
+}
+
+func (m *MockLanguageClient) ResolveCompletionItem(item protocol.CompletionItem) (*protocol.CompletionItem, error) {
+	args := m.Called(item)
+	return args.Get(0).(*protocol.CompletionItem), args.Error(1)
+}
+
+func (m *MockLanguageClient) CodeLens(uri string) ([]protocol.CodeLens, error) {
+	// Analysis asks every client for lenses; tests that do not care get none
+	for _, c := range m.ExpectedCalls {
+		if c.Method == "CodeLens" {
+			args := m.Called(uri)
+			return args.Get(0).([]protocol.CodeLens), args.Error(1)
+		}
+	}
+	return nil, nil
+}
+
+func (m *MockLanguageClient) ResolveCodeLens(lens protocol.CodeLens) (*protocol.CodeLens, error) {
+	args := m.Called(lens)
+	return args.Get(0).(*protocol.CodeLens), args.Error(1)
+}
+
 func (m *MockLanguageClient) SemanticTokens(uri string) (*protocol.SemanticTokens, error) {
 	args := m.Called(uri)
 	return args.Get(0).(*protocol.SemanticTokens), args.Error(1)
@@ -269,3 +315,68 @@ func (m *MockLanguageClient) DocumentDiagnostics(uri string, identifier string,
 	args := m.Called(uri, identifier, previousResultId)
 	return args.Get(0).(*protocol.DocumentDiagnosticReport), args.Error(1)
 }
+
+// The Context variants fall back to the plain method, so tests that do not care
+// about ctx set their expectations on the plain method
+func (m *MockLanguageClient) WorkspaceDiagnosticContext(ctx context.Context, identifier string) (*protocol.WorkspaceDiagnosticReport, error) {
+	for _, c := range m.ExpectedCalls {
+		if c.Method == "WorkspaceDiagnosticContext" {
+			args := m.Called(ctx, identifier)
+			return args.Get(0).(*protocol.WorkspaceDiagnosticReport), args.Error(1)
+		}
+	}
+	return m.WorkspaceDiagnostic(identifier)
+}
+
+func (m *MockLanguageClient) PrepareCallHierarchyContext(ctx context.Context, uri string, line, character uint32) ([]protocol.CallHierarchyItem, error) {
+	for _, c := range m.ExpectedCalls {
+		if c.Method == "PrepareCallHierarchyContext" {
+			args := m.Called(ctx, uri, line, character)
+			return args.Get(0).([]protocol.CallHierarchyItem), args.Error(1)
+		}
+	}
+	return m.PrepareCallHierarchy(uri, line, character)
+}
+
+func (m *MockLanguageClient) IncomingCallsContext(ctx context.Context, item protocol.CallHierarchyItem) ([]protocol.CallHierarchyIncomingCall, error) {
+	for _, c := range m.ExpectedCalls {
+		if c.Method == "IncomingCallsContext" {
+			args := m.Called(ctx, item)
+			return args.Get(0).([]protocol.CallHierarchyIncomingCall), args.Error(1)
+		}
+	}
+	return m.IncomingCalls(item)
+}
+
+func (m *MockLanguageClient) OutgoingCallsContext(ctx context.Context, item protocol.CallHierarchyItem) ([]protocol.CallHierarchyOutgoingCall, error) {
+	for _, c := range m.ExpectedCalls {
+		if c.Method == "OutgoingCallsContext" {
+			args := m.Called(ctx, item)
+			return args.Get(0).([]protocol.CallHierarchyOutgoingCall), args.Error(1)
+		}
+	}
+	return m.OutgoingCalls(item)
+}
+
+// Without an expectation the call graph index is absent and callers query the server
+func (m *MockLanguageClient) IndexedIncomingCalls(ctx context.Context, item protocol.CallHierarchyItem) ([]protocol.CallHierarchyIncomingCall, bool, error) {
+	for _, c := range m.ExpectedCalls {
+		if c.Method == "IndexedIncomingCalls" {
+			args := m.Called(ctx, item)
+			calls, _ := args.Get(0).([]protocol.CallHierarchyIncomingCall)
+			return calls, args.Bool(1), args.Error(2)
+		}
+	}
+	return nil, false, nil
+}
+
+func (m *MockLanguageClient) IndexedOutgoingCalls(ctx context.Context, item protocol.CallHierarchyItem) ([]protocol.CallHierarchyOutgoingCall, bool, error) {
+	for _, c := range m.ExpectedCalls {
+		if c.Method == "IndexedOutgoingCalls" {
+			args := m.Called(ctx, item)
+			calls, _ := args.Get(0).([]protocol.CallHierarchyOutgoingCall)
+			return calls, args.Bool(1), args.Error(2)
+		}
+	}
+	return nil, false, nil
+}
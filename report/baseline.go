@@ -0,0 +1,103 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+// BaselineVersion is the format version written to baseline files
+const BaselineVersion = 1
+
+// BaselineEntry is one fingerprint with the number of times it occurs. Path, code and
+// message are informational and make the file reviewable.
+type BaselineEntry struct {
+	Fingerprint string `json:"fingerprint"`
+	Count       int    `json:"count"`
+	Path        string `json:"path"`
+	Code        string `json:"code,omitempty"`
+	Message     string `json:"message"`
+}
+
+// Baseline is the set of accepted diagnostics; only findings beyond it fail a build
+type Baseline struct {
+	Version int             `json:"version"`
+	Entries []BaselineEntry `json:"entries"`
+}
+
+// NewBaseline accepts every finding, sorted so regenerated files diff cleanly
+func NewBaseline(findings []Finding) *Baseline {
+	index := make(map[string]int)
+	baseline := &Baseline{Version: BaselineVersion, Entries: []BaselineEntry{}}
+
+	for _, f := range findings {
+		if i, ok := index[f.Fingerprint]; ok {
+			baseline.Entries[i].Count++
+			continue
+		}
+		index[f.Fingerprint] = len(baseline.Entries)
+		baseline.Entries = append(baseline.Entries, BaselineEntry{
+			Fingerprint: f.Fingerprint, Count: 1, Path: f.Path, Code: f.Code, Message: f.Message,
+		})
+	}
+
+	sort.SliceStable(baseline.Entries, func(i, j int) bool {
+		a, b := baseline.Entries[i], baseline.Entries[j]
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		if a.Code != b.Code {
+			return a.Code < b.Code
+		}
+		return a.Fingerprint < b.Fingerprint
+	})
+	return baseline
+}
+
+// LoadBaseline reads a baseline file written by WriteBaseline
+func LoadBaseline(path string) (*Baseline, error) {
+	data, err := os.ReadFile(path) // #nosec G304 -- path given on the command line
+	if err != nil {
+		return nil, err
+	}
+
+	var baseline Baseline
+	if err := json.Unmarshal(data, &baseline); err != nil {
+		return nil, fmt.Errorf("invalid baseline %s: %w", path, err)
+	}
+	if baseline.Version != BaselineVersion {
+		return nil, fmt.Errorf("unsupported baseline version %d in %s", baseline.Version, path)
+	}
+	return &baseline, nil
+}
+
+// WriteBaseline writes b as indented JSON
+func WriteBaseline(w io.Writer, b *Baseline) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(b)
+}
+
+// Apply marks findings that are not covered by the baseline as new. A fingerprint
+// listed n times covers n occurrences, so a copied warning is still reported.
+// A nil baseline marks everything new.
+func (b *Baseline) Apply(findings []Finding) {
+	remaining := make(map[string]int)
+	if b != nil {
+		for _, e := range b.Entries {
+			remaining[e.Fingerprint] += e.Count
+		}
+	}
+
+	for i := range findings {
+		fp := findings[i].Fingerprint
+		if remaining[fp] > 0 {
+			remaining[fp]--
+			findings[i].New = false
+			continue
+		}
+		findings[i].New = true
+	}
+}
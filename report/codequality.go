@@ -0,0 +1,65 @@
+package report
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// codeQualityIssue is one entry of a GitLab Code Quality report
+type codeQualityIssue struct {
+	Description string              `json:"description"`
+	CheckName   string              `json:"check_name"`
+	Fingerprint string              `json:"fingerprint"`
+	Severity    string              `json:"severity"`
+	Location    codeQualityLocation `json:"location"`
+}
+
+type codeQualityLocation struct {
+	Path  string           `json:"path"`
+	Lines codeQualityLines `json:"lines"`
+}
+
+type codeQualityLines struct {
+	Begin int `json:"begin"`
+	End   int `json:"end,omitempty"`
+}
+
+// WriteCodeQuality writes every finding as a GitLab Code Quality issue. GitLab
+// compares the report with the target branch itself, so baselined findings stay in.
+func WriteCodeQuality(w io.Writer, findings []Finding) error {
+	issues := make([]codeQualityIssue, 0, len(findings))
+	for _, f := range findings {
+		checkName := f.Code
+		if checkName == "" {
+			checkName = f.Source
+		}
+		issues = append(issues, codeQualityIssue{
+			Description: f.Message,
+			CheckName:   checkName,
+			Fingerprint: f.Fingerprint,
+			Severity:    codeQualitySeverity(f.Severity),
+			Location: codeQualityLocation{
+				Path:  f.Path,
+				Lines: codeQualityLines{Begin: f.Line, End: f.EndLine},
+			},
+		})
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(issues)
+}
+
+// codeQualitySeverity maps severities to info, minor, major, critical or blocker
+func codeQualitySeverity(severity string) string {
+	switch severity {
+	case SeverityError:
+		return "critical"
+	case SeverityWarning:
+		return "major"
+	case SeverityInformation:
+		return "minor"
+	default:
+		return "info"
+	}
+}
@@ -0,0 +1,173 @@
+// Package report turns language server diagnostics into CI reports (SARIF,
+// JUnit XML, GitLab Code Quality) and compares them against a baseline.
+package report
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"rockerboo/mcp-lsp-bridge/lsp"
+	"rockerboo/mcp-lsp-bridge/utils"
+
+	"github.com/myleshyson/lsprotocol-go/protocol"
+)
+
+// Severity names used in reports
+const (
+	SeverityError       = "error"
+	SeverityWarning     = "warning"
+	SeverityInformation = "information"
+	SeverityHint        = "hint"
+)
+
+// maxSnippetLines caps how much of a multi-line range goes into the fingerprint
+const maxSnippetLines = 10
+
+// Finding is one diagnostic with a workspace-relative location and a fingerprint
+// that survives line shifts. Lines and columns are 1-based; columns count UTF-16
+// code units like LSP.
+type Finding struct {
+	Path        string `json:"path"`
+	Line        int    `json:"line"`
+	Column      int    `json:"column"`
+	EndLine     int    `json:"end_line"`
+	EndColumn   int    `json:"end_column"`
+	Severity    string `json:"severity"`
+	Code        string `json:"code,omitempty"`
+	Source      string `json:"source,omitempty"`
+	Message     string `json:"message"`
+	HelpURI     string `json:"help_uri,omitempty"`
+	Fingerprint string `json:"fingerprint"`
+	New         bool   `json:"new"`
+
+	Diagnostic protocol.Diagnostic `json:"-"` // as reported by the server
+}
+
+// NewFindings converts documents to findings sorted by path and position. Paths are
+// relative to root; the source lines of each range are read for the fingerprint.
+func NewFindings(root string, documents []lsp.PublishedDiagnostics) []Finding {
+	var findings []Finding
+
+	for _, doc := range documents {
+		path := utils.URIToFilePath(doc.URI)
+		rel := relativePath(root, path)
+
+		var lines []string
+		if data, err := os.ReadFile(path); err == nil { // #nosec G304 -- a document reported by the language server
+			lines = strings.Split(strings.TrimPrefix(string(data), "\ufeff"), "\n")
+		}
+
+		for _, d := range doc.Diagnostics {
+			code := lsp.DiagnosticCode(d)
+			f := Finding{
+				Path:       rel,
+				Line:       int(d.Range.Start.Line) + 1,
+				Column:     int(d.Range.Start.Character) + 1,
+				EndLine:    int(d.Range.End.Line) + 1,
+				EndColumn:  int(d.Range.End.Character) + 1,
+				Severity:   severityName(d.Severity),
+				Code:       code,
+				Source:     d.Source,
+				Message:    d.Message,
+				Diagnostic: d,
+			}
+			if d.CodeDescription != nil {
+				f.HelpURI = string(d.CodeDescription.Href)
+			}
+			f.Fingerprint = Fingerprint(rel, code, d.Message, snippet(lines, d.Range))
+			findings = append(findings, f)
+		}
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		a, b := findings[i], findings[j]
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	return findings
+}
+
+// Fingerprint identifies a diagnostic by file, code, normalized message and a hash
+// of the flagged source, so it does not change when the code moves up or down
+func Fingerprint(path, code, message, snippet string) string {
+	snippetHash := sha256.Sum256([]byte(snippet))
+	sum := sha256.Sum256([]byte(strings.Join([]string{
+		path, code, NormalizeMessage(message), hex.EncodeToString(snippetHash[:8]),
+	}, "\x00")))
+	return hex.EncodeToString(sum[:16])
+}
+
+var (
+	digitsPattern     = regexp.MustCompile(`[0-9]+`)
+	whitespacePattern = regexp.MustCompile(`\s+`)
+)
+
+// NormalizeMessage lowercases the message, collapses whitespace and masks numbers,
+// which often carry line numbers or lengths that change with unrelated edits
+func NormalizeMessage(message string) string {
+	message = digitsPattern.ReplaceAllString(strings.ToLower(message), "#")
+	return strings.TrimSpace(whitespacePattern.ReplaceAllString(message, " "))
+}
+
+// snippet is the trimmed text of the lines covered by r
+func snippet(lines []string, r protocol.Range) string {
+	start := int(r.Start.Line)
+	end := min(int(r.End.Line), start+maxSnippetLines-1, len(lines)-1)
+
+	var parts []string
+	for i := start; i <= end; i++ {
+		if line := strings.TrimSpace(lines[i]); line != "" {
+			parts = append(parts, whitespacePattern.ReplaceAllString(line, " "))
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// relativePath is path relative to root with forward slashes, or path itself when
+// it is outside root
+func relativePath(root, path string) string {
+	if root != "" {
+		if rel, err := filepath.Rel(root, path); err == nil && !strings.HasPrefix(rel, "..") {
+			return filepath.ToSlash(rel)
+		}
+	}
+	return filepath.ToSlash(path)
+}
+
+// severityName maps an LSP severity; a missing one counts as an error like in editors
+func severityName(severity *protocol.DiagnosticSeverity) string {
+	if severity == nil {
+		return SeverityError
+	}
+	switch *severity {
+	case protocol.DiagnosticSeverityWarning:
+		return SeverityWarning
+	case protocol.DiagnosticSeverityInformation:
+		return SeverityInformation
+	case protocol.DiagnosticSeverityHint:
+		return SeverityHint
+	default:
+		return SeverityError
+	}
+}
+
+// CountNew returns how many findings are not in the baseline
+func CountNew(findings []Finding) int {
+	n := 0
+	for _, f := range findings {
+		if f.New {
+			n++
+		}
+	}
+	return n
+}
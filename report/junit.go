@@ -0,0 +1,102 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+type junitSuites struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Suites   []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Cases    []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnit writes one test case per file with findings. A file fails when it has
+// new findings; baselined ones are listed in system-out so the case still passes.
+func WriteJUnit(w io.Writer, suiteName string, findings []Finding) error {
+	suite := junitSuite{Name: suiteName, Cases: []junitCase{}}
+
+	for start := 0; start < len(findings); {
+		end := start
+		for end < len(findings) && findings[end].Path == findings[start].Path {
+			end++
+		}
+		suite.Cases = append(suite.Cases, junitFileCase(findings[start:end]))
+		start = end
+	}
+
+	// An empty report would look like a broken job in CI dashboards
+	if len(suite.Cases) == 0 {
+		suite.Cases = append(suite.Cases, junitCase{Name: "diagnostics", ClassName: suiteName})
+	}
+
+	for _, c := range suite.Cases {
+		if c.Failure != nil {
+			suite.Failures++
+		}
+	}
+	suite.Tests = len(suite.Cases)
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(junitSuites{Tests: suite.Tests, Failures: suite.Failures, Suites: []junitSuite{suite}}); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// junitFileCase reports the findings of one file
+func junitFileCase(findings []Finding) junitCase {
+	c := junitCase{Name: findings[0].Path, ClassName: findings[0].Path}
+
+	var failed, known strings.Builder
+	newCount := 0
+	for _, f := range findings {
+		line := fmt.Sprintf("%s:%d:%d: %s [%s] %s\n", f.Path, f.Line, f.Column, f.Severity, f.Code, f.Message)
+		if f.New {
+			newCount++
+			failed.WriteString(line)
+		} else {
+			known.WriteString(line)
+		}
+	}
+
+	if newCount > 0 {
+		c.Failure = &junitFailure{
+			Message: fmt.Sprintf("%d new diagnostic(s)", newCount),
+			Type:    "diagnostics",
+			Text:    failed.String(),
+		}
+	}
+	if known.Len() > 0 {
+		c.SystemOut = "In baseline:\n" + known.String()
+	}
+	return c
+}
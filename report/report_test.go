@@ -0,0 +1,141 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"rockerboo/mcp-lsp-bridge/lsp"
+	"rockerboo/mcp-lsp-bridge/utils"
+
+	"github.com/myleshyson/lsprotocol-go/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func diagnostic(line uint32, code, message string, severity protocol.DiagnosticSeverity) protocol.Diagnostic {
+	return protocol.Diagnostic{
+		Range: protocol.Range{
+			Start: protocol.Position{Line: line, Character: 1},
+			End:   protocol.Position{Line: line, Character: 8},
+		},
+		Severity: &severity,
+		Code:     &protocol.Or2[int32, string]{Value: code},
+		Source:   "bsl-language-server",
+		Message:  message,
+	}
+}
+
+// writeModule writes src as CommonModules/Общий/Ext/Module.bsl and returns the root and URI
+func writeModule(t *testing.T, root, src string) string {
+	t.Helper()
+	path := filepath.Join(root, "CommonModules/Общий/Ext/Module.bsl")
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(src), 0o644))
+	return utils.FilePathToURI(path)
+}
+
+func TestFingerprintSurvivesLineShift(t *testing.T) {
+	root := t.TempDir()
+	uri := writeModule(t, root, "Процедура А()\n\tА = 1;;\nКонецПроцедуры\n")
+	before := NewFindings(root, []lsp.PublishedDiagnostics{{URI: uri, Diagnostics: []protocol.Diagnostic{
+		diagnostic(1, "SemicolonPresence", "Строка 2: лишняя точка с запятой", protocol.DiagnosticSeverityWarning),
+	}}})
+
+	uri = writeModule(t, root, "// Новый комментарий\n\nПроцедура А()\n    А = 1;;\nКонецПроцедуры\n")
+	after := NewFindings(root, []lsp.PublishedDiagnostics{{URI: uri, Diagnostics: []protocol.Diagnostic{
+		diagnostic(3, "SemicolonPresence", "Строка 4:  лишняя точка с запятой", protocol.DiagnosticSeverityWarning),
+	}}})
+
+	require.Len(t, before, 1)
+	require.Len(t, after, 1)
+	assert.Equal(t, "CommonModules/Общий/Ext/Module.bsl", after[0].Path)
+	assert.Equal(t, 4, after[0].Line)
+	assert.Equal(t, before[0].Fingerprint, after[0].Fingerprint, "numbers, indentation and the line are ignored")
+
+	assert.NotEqual(t, before[0].Fingerprint, Fingerprint(before[0].Path, "SemicolonPresence", before[0].Message, "Б = 2;;"),
+		"a different snippet is a different finding")
+}
+
+func TestBaselineApply(t *testing.T) {
+	findings := []Finding{
+		{Path: "a.bsl", Fingerprint: "x"},
+		{Path: "a.bsl", Fingerprint: "x"},
+		{Path: "b.bsl", Fingerprint: "y"},
+	}
+	baseline := NewBaseline(findings[:1])
+	assert.Equal(t, []BaselineEntry{{Fingerprint: "x", Count: 1, Path: "a.bsl"}}, baseline.Entries)
+
+	baseline.Apply(findings)
+	assert.Equal(t, []bool{false, true, true}, []bool{findings[0].New, findings[1].New, findings[2].New},
+		"a copy of a baselined diagnostic is new")
+	assert.Equal(t, 2, CountNew(findings))
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteBaseline(&buf, NewBaseline(findings)))
+	path := filepath.Join(t.TempDir(), "baseline.json")
+	require.NoError(t, os.WriteFile(path, buf.Bytes(), 0o644))
+	loaded, err := LoadBaseline(path)
+	require.NoError(t, err)
+	loaded.Apply(findings)
+	assert.Equal(t, 0, CountNew(findings))
+
+	var none *Baseline
+	none.Apply(findings)
+	assert.Equal(t, 3, CountNew(findings))
+}
+
+func TestWriteReports(t *testing.T) {
+	findings := []Finding{
+		{Path: "a.bsl", Line: 2, Column: 1, EndLine: 2, EndColumn: 5, Severity: SeverityWarning, Code: "LineLength",
+			Message: "too long", Fingerprint: "f1", New: true, HelpURI: "https://example.com/LineLength"},
+		{Path: "a.bsl", Line: 7, Column: 1, EndLine: 7, EndColumn: 5, Severity: SeverityError, Code: "LineLength",
+			Message: "way too long", Fingerprint: "f2"},
+		{Path: "b.bsl", Line: 1, Column: 1, EndLine: 1, EndColumn: 2, Severity: SeverityHint, Code: "Typo",
+			Message: "typo", Fingerprint: "f3"},
+	}
+
+	var sarif bytes.Buffer
+	require.NoError(t, WriteSARIF(&sarif, findings, SARIFOptions{RootURI: "file:///projects", Baseline: true}))
+	var log sarifLog
+	require.NoError(t, json.Unmarshal(sarif.Bytes(), &log))
+	assert.Equal(t, "2.1.0", log.Version)
+	run := log.Runs[0]
+	assert.Equal(t, "file:///projects/", run.OriginalURIBaseIDs["%SRCROOT%"].URI)
+	assert.Equal(t, []sarifRule{
+		{ID: "LineLength", HelpURI: "https://example.com/LineLength", Default: &sarifDefault{Level: "error"}},
+		{ID: "Typo", Default: &sarifDefault{Level: "note"}},
+	}, run.Tool.Driver.Rules)
+	require.Len(t, run.Results, 3)
+	assert.Equal(t, "new", run.Results[0].BaselineState)
+	assert.Equal(t, "unchanged", run.Results[1].BaselineState)
+	assert.Equal(t, "f2", run.Results[1].PartialFingerprints[sarifFingerprintKey])
+	assert.Equal(t, sarifRegion{StartLine: 7, StartColumn: 1, EndLine: 7, EndColumn: 5}, run.Results[1].Locations[0].PhysicalLocation.Region)
+
+	var junit bytes.Buffer
+	require.NoError(t, WriteJUnit(&junit, "bsl-diagnostics", findings))
+	var suites junitSuites
+	require.NoError(t, xml.Unmarshal(junit.Bytes(), &suites))
+	assert.Equal(t, 2, suites.Tests)
+	assert.Equal(t, 1, suites.Failures)
+	cases := suites.Suites[0].Cases
+	require.NotNil(t, cases[0].Failure)
+	assert.Equal(t, "a.bsl:2:1: warning [LineLength] too long\n", cases[0].Failure.Text)
+	assert.Contains(t, cases[0].SystemOut, "way too long")
+	assert.Nil(t, cases[1].Failure, "only baselined findings in b.bsl")
+
+	var quality bytes.Buffer
+	require.NoError(t, WriteCodeQuality(&quality, findings))
+	var issues []codeQualityIssue
+	require.NoError(t, json.Unmarshal(quality.Bytes(), &issues))
+	require.Len(t, issues, 3)
+	assert.Equal(t, codeQualityIssue{
+		Description: "too long", CheckName: "LineLength", Fingerprint: "f1", Severity: "major",
+		Location: codeQualityLocation{Path: "a.bsl", Lines: codeQualityLines{Begin: 2, End: 2}},
+	}, issues[0])
+	assert.Equal(t, "critical", issues[1].Severity)
+	assert.Equal(t, "info", issues[2].Severity)
+}
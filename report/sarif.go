@@ -0,0 +1,172 @@
+package report
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"strings"
+)
+
+// SARIF 2.1.0, trimmed to what code scanning and GitLab SAST widgets read
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	// sarifFingerprintKey names the partial fingerprint computed by Fingerprint
+	sarifFingerprintKey = "mcpLspBridge/v1"
+)
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool               sarifTool                        `json:"tool"`
+	OriginalURIBaseIDs map[string]sarifArtifactLocation `json:"originalUriBaseIds,omitempty"`
+	ColumnKind         string                           `json:"columnKind"`
+	Results            []sarifResult                    `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name  string      `json:"name"`
+	Rules []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID      string        `json:"id"`
+	HelpURI string        `json:"helpUri,omitempty"`
+	Default *sarifDefault `json:"defaultConfiguration,omitempty"`
+}
+
+type sarifDefault struct {
+	Level string `json:"level"`
+}
+
+type sarifResult struct {
+	RuleID              string            `json:"ruleId,omitempty"`
+	Level               string            `json:"level"`
+	Message             sarifMessage      `json:"message"`
+	Locations           []sarifLocation   `json:"locations"`
+	PartialFingerprints map[string]string `json:"partialFingerprints"`
+	BaselineState       string            `json:"baselineState,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId,omitempty"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn"`
+	EndLine     int `json:"endLine"`
+	EndColumn   int `json:"endColumn"`
+}
+
+// SARIFOptions describes the run written by WriteSARIF
+type SARIFOptions struct {
+	ToolName string // driver name, e.g. "bsl-language-server"
+	RootURI  string // file URI of the workspace; paths are relative to %SRCROOT%
+	Baseline bool   // set baselineState on every result
+}
+
+// WriteSARIF writes findings as a SARIF 2.1.0 log with one run
+func WriteSARIF(w io.Writer, findings []Finding, opts SARIFOptions) error {
+	run := sarifRun{
+		Tool:       sarifTool{Driver: sarifDriver{Name: opts.ToolName, Rules: sarifRules(findings)}},
+		ColumnKind: "utf16CodeUnits",
+		Results:    make([]sarifResult, 0, len(findings)),
+	}
+	if run.Tool.Driver.Name == "" {
+		run.Tool.Driver.Name = "mcp-lsp-bridge"
+	}
+	if opts.RootURI != "" {
+		// SARIF requires base URIs to end with a slash
+		root := strings.TrimSuffix(opts.RootURI, "/") + "/"
+		run.OriginalURIBaseIDs = map[string]sarifArtifactLocation{"%SRCROOT%": {URI: root}}
+	}
+
+	for _, f := range findings {
+		result := sarifResult{
+			RuleID:  f.Code,
+			Level:   sarifLevel(f.Severity),
+			Message: sarifMessage{Text: f.Message},
+			Locations: []sarifLocation{{PhysicalLocation: sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifactLocation{URI: f.Path, URIBaseID: "%SRCROOT%"},
+				Region:           sarifRegion{StartLine: f.Line, StartColumn: f.Column, EndLine: f.EndLine, EndColumn: f.EndColumn},
+			}}},
+			PartialFingerprints: map[string]string{sarifFingerprintKey: f.Fingerprint},
+		}
+		if opts.Baseline {
+			result.BaselineState = "unchanged"
+			if f.New {
+				result.BaselineState = "new"
+			}
+		}
+		run.Results = append(run.Results, result)
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(sarifLog{Schema: sarifSchema, Version: sarifVersion, Runs: []sarifRun{run}})
+}
+
+// sarifRules lists every diagnostic code once, with the most severe level seen
+func sarifRules(findings []Finding) []sarifRule {
+	rules := make(map[string]*sarifRule)
+	for _, f := range findings {
+		if f.Code == "" {
+			continue
+		}
+		rule, ok := rules[f.Code]
+		if !ok {
+			rule = &sarifRule{ID: f.Code, Default: &sarifDefault{Level: sarifLevel(f.Severity)}}
+			rules[f.Code] = rule
+		}
+		if rule.HelpURI == "" {
+			rule.HelpURI = f.HelpURI
+		}
+		if sarifLevelRank[sarifLevel(f.Severity)] > sarifLevelRank[rule.Default.Level] {
+			rule.Default.Level = sarifLevel(f.Severity)
+		}
+	}
+
+	list := make([]sarifRule, 0, len(rules))
+	for _, rule := range rules {
+		list = append(list, *rule)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list
+}
+
+var sarifLevelRank = map[string]int{"note": 1, "warning": 2, "error": 3}
+
+// sarifLevel maps severities to SARIF levels; information and hints are notes
+func sarifLevel(severity string) string {
+	switch severity {
+	case SeverityError:
+		return "error"
+	case SeverityWarning:
+		return "warning"
+	default:
+		return "note"
+	}
+}
@@ -74,6 +74,9 @@ type LanguageClientInterface interface {
 	SendNotification(method string, params any) error
 	Close() error
 	Context() context.Context
+	// WithContext returns a client whose requests start from ctx, so they carry its
+	// deadline and correlation id. It shares the connection and state of the client.
+	WithContext(ctx context.Context) LanguageClientInterface
 	GetMetrics() ClientMetricsProvider
 	IsConnected() bool
 	Status() int
@@ -93,9 +96,10 @@ type LanguageClientInterface interface {
 	SetupSemanticTokens() error
 	TokenParser() SemanticTokensParserProvider
 
-	// Text document synchronization
-	DidOpen(uri string, languageId protocol.LanguageKind, text string, version int32) error
-	DidChange(uri string, version int32, changes []protocol.TextDocumentContentChangeEvent) error
+	// Text document synchronization. DidOpen and DidChange return the version the
+	// server now has, which a shared session may have advanced past the one sent.
+	DidOpen(uri string, languageId protocol.LanguageKind, text string, version int32) (int32, error)
+	DidChange(uri string, version int32, changes []protocol.TextDocumentContentChangeEvent) (int32, error)
 	DidSave(uri string, text *string) error
 	DidClose(uri string) error
 
@@ -123,10 +127,28 @@ type LanguageClientInterface interface {
 	Hover(uri string, line, character uint32) (*protocol.Hover, error)
 	DocumentSymbols(uri string) ([]protocol.DocumentSymbol, error)
 	Implementation(uri string, line, character uint32) ([]protocol.Location, error)
+	Declaration(uri string, line, character uint32) ([]protocol.Or2[protocol.LocationLink, protocol.Location], error)
+	TypeDefinition(uri string, line, character uint32) ([]protocol.Or2[protocol.LocationLink, protocol.Location], error)
+	DocumentHighlight(uri string, line, character uint32) ([]protocol.DocumentHighlight, error)
 	SignatureHelp(uri string, line, character uint32) (*protocol.SignatureHelp, error)
+	Completion(uri string, line, character uint32) (*protocol.CompletionList, error)
+	ResolveCompletionItem(item protocol.CompletionItem) (*protocol.CompletionItem, error)
+	CodeLens(uri string) ([]protocol.CodeLens, error)
+	ResolveCodeLens(lens protocol.CodeLens) (*protocol.CodeLens, error)
 	SemanticTokens(uri string) (*protocol.SemanticTokens, error)
 	SemanticTokensRange(uri string, startLine, startCharacter, endLine, endCharacter uint32) (*protocol.SemanticTokens, error)
 	DocumentDiagnostics(uri string, identifier string, previousResultId string) (*protocol.DocumentDiagnosticReport, error)
+
+	// Variants of the methods above that cancel the request together with ctx
+	WorkspaceDiagnosticContext(ctx context.Context, identifier string) (*protocol.WorkspaceDiagnosticReport, error)
+	PrepareCallHierarchyContext(ctx context.Context, uri string, line, character uint32) ([]protocol.CallHierarchyItem, error)
+	IncomingCallsContext(ctx context.Context, item protocol.CallHierarchyItem) ([]protocol.CallHierarchyIncomingCall, error)
+	OutgoingCallsContext(ctx context.Context, item protocol.CallHierarchyItem) ([]protocol.CallHierarchyOutgoingCall, error)
+
+	// Call graph index. indexed is false when the client cannot answer from an index
+	// and the caller should ask the server instead.
+	IndexedIncomingCalls(ctx context.Context, item protocol.CallHierarchyItem) (calls []protocol.CallHierarchyIncomingCall, indexed bool, err error)
+	IndexedOutgoingCalls(ctx context.Context, item protocol.CallHierarchyItem) (calls []protocol.CallHierarchyOutgoingCall, indexed bool, err error)
 }
 
 type LSPConnectionInterface interface {
@@ -150,6 +172,7 @@ type LanguageServer string
 type GlobalConfig struct {
 	LogPath            string `json:"log_file_path"`
 	LogLevel           string `json:"log_level"`
+	LogFormat          string `json:"log_format"`
 	MaxLogFiles        int    `json:"max_log_files"`
 	MaxRestartAttempts int    `json:"max_restart_attempts"`
 	RestartDelayMs     int    `json:"restart_delay_ms"`
@@ -0,0 +1,24 @@
+package types
+
+// FileChangeKind describes what applying a workspace edit did to a file
+type FileChangeKind string
+
+const (
+	FileChangeEdited  FileChangeKind = "edited"
+	FileChangeCreated FileChangeKind = "created"
+	FileChangeRenamed FileChangeKind = "renamed"
+	FileChangeDeleted FileChangeKind = "deleted"
+)
+
+// TouchedFile is one file written, created, renamed or deleted by a workspace edit
+type TouchedFile struct {
+	Path    string         `json:"path"`
+	OldPath string         `json:"oldPath,omitempty"` // set for renames
+	Kind    FileChangeKind `json:"kind"`
+	Edits   int            `json:"edits,omitempty"` // number of text edits applied
+}
+
+// WorkspaceEditResult reports the outcome of an applied workspace edit
+type WorkspaceEditResult struct {
+	Files []TouchedFile `json:"files"`
+}
@@ -0,0 +1,76 @@
+package utils
+
+import (
+	"path"
+	"strings"
+)
+
+// MatchGlob reports whether a slash-separated path matches a glob pattern.
+//
+// Besides the path.Match syntax the pattern may use "**" as a whole segment
+// to match any number of directories. A pattern without a slash is matched
+// against the last path element only, so "*.bsl" matches files at any depth,
+// and a relative pattern may match anywhere below the root, so
+// "CommonModules/*/Ext/Module.bsl" matches inside any workspace.
+// Backslashes in both arguments are treated as separators.
+func MatchGlob(pattern, name string) bool {
+	pattern = strings.ReplaceAll(pattern, "\\", "/")
+	name = strings.ReplaceAll(name, "\\", "/")
+
+	if !strings.Contains(pattern, "/") {
+		matched, err := path.Match(pattern, path.Base(name))
+		return err == nil && matched
+	}
+
+	segments := splitGlobPath(pattern)
+	if !isAbsoluteGlob(pattern) && (len(segments) == 0 || segments[0] != "**") {
+		segments = append([]string{"**"}, segments...)
+	}
+	return matchSegments(segments, splitGlobPath(name))
+}
+
+func isAbsoluteGlob(pattern string) bool {
+	return strings.HasPrefix(pattern, "/") || (len(pattern) > 1 && pattern[1] == ':')
+}
+
+func splitGlobPath(p string) []string {
+	parts := strings.Split(p, "/")
+	segments := parts[:0]
+	for _, part := range parts {
+		if part != "" {
+			segments = append(segments, part)
+		}
+	}
+	return segments
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			// Collapse repeated ** and try every possible split point
+			for len(pattern) > 0 && pattern[0] == "**" {
+				pattern = pattern[1:]
+			}
+			if len(pattern) == 0 {
+				return true
+			}
+			for i := range name {
+				if matchSegments(pattern, name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+
+		if len(name) == 0 {
+			return false
+		}
+		matched, err := path.Match(pattern[0], name[0])
+		if err != nil || !matched {
+			return false
+		}
+		pattern = pattern[1:]
+		name = name[1:]
+	}
+	return len(name) == 0
+}
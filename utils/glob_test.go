@@ -0,0 +1,29 @@
+package utils
+
+import "testing"
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{"*.bsl", "/projects/CommonModules/Common/Ext/Module.bsl", true},
+		{"*.os", "/projects/CommonModules/Common/Ext/Module.bsl", false},
+		{"/projects/**/*.bsl", "/projects/CommonModules/Common/Ext/Module.bsl", true},
+		{"/projects/**", "/projects/Module.bsl", true},
+		{"/other/**", "/projects/Module.bsl", false},
+		{"CommonModules/*/Ext/Module.bsl", "/projects/CommonModules/Common/Ext/Module.bsl", true},
+		{"CommonModules/*/Ext/Module.bsl", "/projects/Catalogs/Items/Ext/ObjectModule.bsl", false},
+		{"**/Ext/*Module.bsl", "/projects/Catalogs/Items/Ext/ObjectModule.bsl", true},
+		{"/projects/*/Module.bsl", "/projects/a/b/Module.bsl", false},
+		{"C:/work/**/*.bsl", "C:\\work\\src\\Module.bsl", true},
+		{"[", "/projects/Module.bsl", false},
+	}
+
+	for _, tt := range tests {
+		if got := MatchGlob(tt.pattern, tt.name); got != tt.want {
+			t.Errorf("MatchGlob(%q, %q) = %v, want %v", tt.pattern, tt.name, got, tt.want)
+		}
+	}
+}
@@ -5,14 +5,22 @@ import (
 	"fmt"
 	"os"
 	"path"
+	"sort"
 	"strings"
 )
 
+// PathMount is one host directory and the container directory it is mounted at
+type PathMount struct {
+	HostRoot      string // D:/Path/To/Projects (normalized with forward slashes)
+	ContainerRoot string // /projects
+}
+
 // DockerPathMapper handles path conversion between host system and Docker container
 type DockerPathMapper struct {
-	hostRoot      string // D:/Path/To/Projects (normalized with forward slashes)
-	containerRoot string // /projects
-	enabled       bool   // true if working in Docker mode
+	hostRoot      string      // D:/Path/To/Projects (normalized with forward slashes)
+	containerRoot string      // /projects
+	mounts        []PathMount // every mount, the primary one (hostRoot, containerRoot) first
+	enabled       bool        // true if working in Docker mode
 }
 
 // IsWindowsAbsPath checks if a path is a Windows absolute path (e.g., C:\... or C:/...)
@@ -55,13 +63,34 @@ func hasPrefixFold(p, prefix string) bool {
 	return strings.EqualFold(p[:len(prefix)], prefix)
 }
 
-// NewDockerPathMapper creates a new DockerPathMapper instance
-func NewDockerPathMapper(hostRoot, containerRoot string) (*DockerPathMapper, error) {
+// isUnder reports whether p is root or inside it; "/projects2" is not inside "/projects"
+func isUnder(p, root string, fold bool) bool {
+	if fold {
+		if !hasPrefixFold(p, root) {
+			return false
+		}
+	} else if !strings.HasPrefix(p, root) {
+		return false
+	}
+	return len(p) == len(root) || p[len(root)] == '/' || strings.HasSuffix(root, "/")
+}
+
+// IsPathUnder reports whether path p is root or inside it, comparing whole path
+// segments. Windows paths are compared case-insensitively.
+func IsPathUnder(p, root string) bool {
+	if p == "" || root == "" {
+		return false
+	}
+	return isUnder(normalizePathSeparators(p), normalizePathSeparators(root), IsWindowsAbsPath(root))
+}
+
+// newPathMount validates and normalizes one mount
+func newPathMount(hostRoot, containerRoot string) (PathMount, error) {
 	if hostRoot == "" {
-		return nil, errors.New("host root path cannot be empty")
+		return PathMount{}, errors.New("host root path cannot be empty")
 	}
 	if containerRoot == "" {
-		return nil, errors.New("container root path cannot be empty")
+		return PathMount{}, errors.New("container root path cannot be empty")
 	}
 
 	// Normalize host root path - convert backslashes to forward slashes
@@ -71,16 +100,72 @@ func NewDockerPathMapper(hostRoot, containerRoot string) (*DockerPathMapper, err
 	// For container paths, use simple string cleaning to avoid Windows path issues
 	cleanContainerRoot := strings.TrimSuffix(containerRoot, "/")
 	if !strings.HasPrefix(cleanContainerRoot, "/") {
-		return nil, errors.New("container root must be an absolute path starting with /")
+		return PathMount{}, errors.New("container root must be an absolute path starting with /")
+	}
+	return PathMount{HostRoot: cleanHostRoot, ContainerRoot: cleanContainerRoot}, nil
+}
+
+// NewDockerPathMapper creates a new DockerPathMapper instance
+func NewDockerPathMapper(hostRoot, containerRoot string) (*DockerPathMapper, error) {
+	mount, err := newPathMount(hostRoot, containerRoot)
+	if err != nil {
+		return nil, err
 	}
 
 	return &DockerPathMapper{
-		hostRoot:      cleanHostRoot,
-		containerRoot: cleanContainerRoot,
+		hostRoot:      mount.HostRoot,
+		containerRoot: mount.ContainerRoot,
+		mounts:        []PathMount{mount},
 		enabled:       true,
 	}, nil
 }
 
+// AddMount adds a further host directory mounted into the container, e.g. a shared
+// library next to the projects. Paths resolve against the most specific mount.
+func (dpm *DockerPathMapper) AddMount(hostRoot, containerRoot string) error {
+	mount, err := newPathMount(hostRoot, containerRoot)
+	if err != nil {
+		return err
+	}
+	for _, m := range dpm.mounts {
+		if m.ContainerRoot == mount.ContainerRoot {
+			return fmt.Errorf("container path %s is already mounted from %s", m.ContainerRoot, m.HostRoot)
+		}
+	}
+	if !dpm.enabled {
+		dpm.hostRoot, dpm.containerRoot, dpm.enabled = mount.HostRoot, mount.ContainerRoot, true
+	}
+	dpm.mounts = append(dpm.mounts, mount)
+	return nil
+}
+
+// Mounts returns every mount, the primary one first
+func (dpm *DockerPathMapper) Mounts() []PathMount {
+	return append([]PathMount(nil), dpm.mounts...)
+}
+
+// ParsePathMounts parses mounts written as host=container pairs separated by ";"
+// or newlines: "D:/Lib=/lib;E:/Shared=/shared"
+func ParsePathMounts(spec string) ([]PathMount, error) {
+	var mounts []PathMount
+	for _, entry := range strings.FieldsFunc(spec, func(r rune) bool { return r == ';' || r == '\n' }) {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		host, container, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("mount %q is not host=container", entry)
+		}
+		mount, err := newPathMount(strings.TrimSpace(host), strings.TrimSpace(container))
+		if err != nil {
+			return nil, fmt.Errorf("mount %q: %w", entry, err)
+		}
+		mounts = append(mounts, mount)
+	}
+	return mounts, nil
+}
+
 // NewDockerPathMapperFromEnv creates a DockerPathMapper from environment variables
 func NewDockerPathMapperFromEnv() (*DockerPathMapper, error) {
 	// Try different environment variable names for host root
@@ -94,16 +179,25 @@ func NewDockerPathMapperFromEnv() (*DockerPathMapper, error) {
 		containerRoot = "/projects" // Default container root
 	}
 
-	// If no host root is specified, return disabled mapper
-	if hostRoot == "" {
-		return &DockerPathMapper{
-			hostRoot:      "",
-			containerRoot: containerRoot,
-			enabled:       false,
-		}, nil
+	// Further mounts (PROJECTS_MOUNTS="D:/Lib=/lib;E:/Shared=/shared")
+	extra, err := ParsePathMounts(os.Getenv("PROJECTS_MOUNTS"))
+	if err != nil {
+		return nil, fmt.Errorf("PROJECTS_MOUNTS: %w", err)
 	}
 
-	return NewDockerPathMapper(hostRoot, containerRoot)
+	// If no host root is specified, the first further mount becomes the primary one
+	mapper := &DockerPathMapper{containerRoot: containerRoot}
+	if hostRoot != "" {
+		if mapper, err = NewDockerPathMapper(hostRoot, containerRoot); err != nil {
+			return nil, err
+		}
+	}
+	for _, m := range extra {
+		if err := mapper.AddMount(m.HostRoot, m.ContainerRoot); err != nil {
+			return nil, fmt.Errorf("PROJECTS_MOUNTS: %w", err)
+		}
+	}
+	return mapper, nil
 }
 
 // IsEnabled returns true if the path mapper is enabled (Docker mode)
@@ -148,24 +242,22 @@ func (dpm *DockerPathMapper) HostToContainer(hostPath string) (string, error) {
 	// Normalize the input path - convert backslashes to forward slashes
 	cleanPath := normalizePathSeparators(filePath)
 
-	// hostRoot is already normalized in NewDockerPathMapper
-	normalizedHostRoot := dpm.hostRoot
-
-	// Check if path is within the host root directory (case-insensitive for Windows paths)
-	if !hasPrefixFold(cleanPath, normalizedHostRoot) {
-		return "", fmt.Errorf("path %s is outside mounted directory %s", cleanPath, normalizedHostRoot)
+	// Find the mount the path is in (case-insensitive for Windows paths)
+	mount, ok := dpm.mountForHostPath(cleanPath)
+	if !ok {
+		return "", fmt.Errorf("path %s is outside mounted directory %s", cleanPath, dpm.hostRoot)
 	}
 
 	// Extract relative path (preserve original case for the relative portion)
-	relativePath := cleanPath[len(normalizedHostRoot):]
+	relativePath := cleanPath[len(mount.HostRoot):]
 	relativePath = strings.TrimPrefix(relativePath, "/")
 
 	// Build container path
 	var containerPath string
 	if relativePath == "" {
-		containerPath = dpm.containerRoot
+		containerPath = mount.ContainerRoot
 	} else {
-		containerPath = path.Join(dpm.containerRoot, relativePath)
+		containerPath = path.Join(mount.ContainerRoot, relativePath)
 	}
 
 	// Normalize the final path
@@ -178,7 +270,8 @@ func (dpm *DockerPathMapper) HostToContainer(hostPath string) (string, error) {
 	return containerPath, nil
 }
 
-// ContainerToHost converts a container path to host path
+// ContainerToHost converts a container path, or a file:// URI of one, to the host
+// path or URI
 func (dpm *DockerPathMapper) ContainerToHost(containerPath string) (string, error) {
 	if !dpm.enabled {
 		return containerPath, nil // Return as-is if disabled
@@ -188,32 +281,142 @@ func (dpm *DockerPathMapper) ContainerToHost(containerPath string) (string, erro
 		return "", errors.New("container path cannot be empty")
 	}
 
+	isURI := strings.HasPrefix(containerPath, "file://")
+	filePath := containerPath
+	if isURI {
+		p, err := FileURIToPath(containerPath)
+		if err != nil {
+			return "", err
+		}
+		filePath = p
+	}
+
 	// Clean and normalize the input path (container is always slash-based)
-	cleanPath := normalizePathSeparators(containerPath)
+	cleanPath := normalizePathSeparators(filePath)
 
-	// Check if path is within the container root directory
-	if !strings.HasPrefix(cleanPath, dpm.containerRoot) {
+	// Find the mount the path is in
+	mount, ok := dpm.mountForContainerPath(cleanPath)
+	if !ok {
 		return "", fmt.Errorf("path %s is outside container root %s", cleanPath, dpm.containerRoot)
 	}
 
 	// Replace container root with host root
-	relativePath := strings.TrimPrefix(cleanPath, dpm.containerRoot)
+	relativePath := strings.TrimPrefix(cleanPath, mount.ContainerRoot)
 	relativePath = strings.TrimPrefix(relativePath, "/")
 
 	// Build host path (keep forward slashes - the caller can convert if needed)
 	var hostPath string
 	if relativePath == "" {
-		hostPath = dpm.hostRoot
+		hostPath = mount.HostRoot
 	} else {
-		hostPath = path.Join(dpm.hostRoot, relativePath)
+		hostPath = path.Join(mount.HostRoot, relativePath)
 	}
 
 	// Normalize the final path
 	hostPath = path.Clean(hostPath)
 
+	if isURI {
+		return PathToFileURI(hostPath)
+	}
 	return hostPath, nil
 }
 
+// IsContainerPath reports whether a slash-separated path is inside one of the mounts
+func (dpm *DockerPathMapper) IsContainerPath(p string) bool {
+	_, ok := dpm.mountForContainerPath(strings.ReplaceAll(p, "\\", "/"))
+	return ok
+}
+
+// mountForHostPath returns the most specific mount containing a host path
+func (dpm *DockerPathMapper) mountForHostPath(p string) (PathMount, bool) {
+	var best PathMount
+	found := false
+	for _, m := range dpm.mounts {
+		if isUnder(p, m.HostRoot, true) && (!found || len(m.HostRoot) > len(best.HostRoot)) {
+			best, found = m, true
+		}
+	}
+	return best, found
+}
+
+// mountForContainerPath returns the most specific mount containing a container path
+func (dpm *DockerPathMapper) mountForContainerPath(p string) (PathMount, bool) {
+	var best PathMount
+	found := false
+	for _, m := range dpm.mounts {
+		if isUnder(p, m.ContainerRoot, false) && (!found || len(m.ContainerRoot) > len(best.ContainerRoot)) {
+			best, found = m, true
+		}
+	}
+	return best, found
+}
+
+// ContainerToHostText rewrites every container path and file:// URI in free text,
+// JSON included, into its host form. Only the mount root is replaced, so the rest
+// of a path may contain anything, spaces and percent-encoding included. A root
+// preceded by a path character ("/opt/projects", "http://x/projects") or followed
+// by a name character ("/projects2") is left alone.
+func (dpm *DockerPathMapper) ContainerToHostText(text string) string {
+	if !dpm.enabled || !strings.Contains(text, "/") {
+		return text
+	}
+
+	// Most specific mount first so /projects/lib wins over /projects
+	mounts := dpm.Mounts()
+	sort.SliceStable(mounts, func(i, j int) bool {
+		return len(mounts[i].ContainerRoot) > len(mounts[j].ContainerRoot)
+	})
+
+	var out strings.Builder
+	last := 0
+	for i := 0; i < len(text); i++ {
+		isURI := strings.HasPrefix(text[i:], "file://")
+		if !isURI && (text[i] != '/' || (i > 0 && isPathByte(text[i-1]))) {
+			continue
+		}
+		rest := text[i:]
+		if isURI {
+			rest = text[i+len("file://"):]
+		}
+		for _, m := range mounts {
+			end := len(text) - len(rest) + len(m.ContainerRoot)
+			if !strings.HasPrefix(rest, m.ContainerRoot) || (end < len(text) && isNameByte(text[end])) {
+				continue
+			}
+			replacement := m.HostRoot
+			if isURI {
+				uri, err := PathToFileURI(m.HostRoot)
+				if err != nil {
+					continue
+				}
+				replacement = uri
+			}
+			out.WriteString(text[last:i])
+			out.WriteString(replacement)
+			last = end
+			i = end - 1
+			break
+		}
+	}
+	if last == 0 {
+		return text
+	}
+	out.WriteString(text[last:])
+	return out.String()
+}
+
+// isNameByte reports whether c can be part of a file name; bytes of multi-byte
+// UTF-8 characters count, so "/projectsЁ" is not under "/projects"
+func isNameByte(c byte) bool {
+	return c >= 0x80 || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' ||
+		strings.IndexByte("._-~%+", c) >= 0
+}
+
+// isPathByte reports whether c can come right before a "/" inside a longer path or URL
+func isPathByte(c byte) bool {
+	return isNameByte(c) || c == '/' || c == ':' || c == '\\'
+}
+
 // ValidatePath checks if a host path is within the allowed directory
 func (dpm *DockerPathMapper) ValidatePath(hostPath string) error {
 	if !dpm.enabled {
@@ -232,8 +435,8 @@ func (dpm *DockerPathMapper) ValidatePath(hostPath string) error {
 		cleanPath = path.Clean(cleanPath)
 	}
 
-	// Check if path is within host root (case-insensitive for Windows paths)
-	if !hasPrefixFold(cleanPath, dpm.hostRoot) {
+	// Check if path is within a mounted host directory (case-insensitive for Windows paths)
+	if _, ok := dpm.mountForHostPath(cleanPath); !ok {
 		return fmt.Errorf("path is outside mounted directory: %s", hostPath)
 	}
 
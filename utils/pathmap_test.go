@@ -689,3 +689,183 @@ func TestRealWorldPaths(t *testing.T) {
 		})
 	}
 }
+
+func TestDockerPathMapperMounts(t *testing.T) {
+	mapper, err := NewDockerPathMapper("D:/Path/To/Projects", "/projects")
+	if err != nil {
+		t.Fatalf("Failed to create mapper: %v", err)
+	}
+	if err := mapper.AddMount(`E:\Shared Lib`, "/projects/lib"); err != nil {
+		t.Fatalf("Failed to add mount: %v", err)
+	}
+	if err := mapper.AddMount("F:/Other", "/other/"); err != nil {
+		t.Fatalf("Failed to add mount: %v", err)
+	}
+	if err := mapper.AddMount("G:/Dup", "/other"); err == nil {
+		t.Errorf("Expected an error for a container path mounted twice")
+	}
+	if mapper.HostRoot() != "D:/Path/To/Projects" || mapper.ContainerRoot() != "/projects" {
+		t.Errorf("Primary mount changed: %s -> %s", mapper.HostRoot(), mapper.ContainerRoot())
+	}
+
+	toHost := map[string]string{
+		"/projects/temp/file.bsl":        "D:/Path/To/Projects/temp/file.bsl",
+		"/projects/lib/Common.bsl":       "E:/Shared Lib/Common.bsl",
+		"/projects/library/Common.bsl":   "D:/Path/To/Projects/library/Common.bsl",
+		"/other":                         "F:/Other",
+		"file:///projects/lib/A%20B.bsl": "file:///E:/Shared%20Lib/A%20B.bsl",
+		"file:///other/%D0%90.bsl":       "file:///F:/Other/%D0%90.bsl",
+		"file:///projects/Module.bsl":    "file:///D:/Path/To/Projects/Module.bsl",
+	}
+	for in, expected := range toHost {
+		result, err := mapper.ContainerToHost(in)
+		if err != nil {
+			t.Errorf("ContainerToHost(%s): unexpected error: %v", in, err)
+			continue
+		}
+		if result != expected {
+			t.Errorf("ContainerToHost(%s): expected %s, got %s", in, expected, result)
+		}
+	}
+	if _, err := mapper.ContainerToHost("/projects2/file.bsl"); err == nil {
+		t.Errorf("Expected /projects2 to be outside /projects")
+	}
+
+	toContainer := map[string]string{
+		"e:/shared lib/Common.bsl":        "/projects/lib/Common.bsl",
+		"F:/Other/x.bsl":                  "/other/x.bsl",
+		"D:/Path/To/Projects/x.bsl":       "/projects/x.bsl",
+		"file:///F:/Other/sub/%D0%90.bsl": "file:///other/sub/А.bsl",
+	}
+	for in, expected := range toContainer {
+		result, err := mapper.HostToContainer(in)
+		if err != nil {
+			t.Errorf("HostToContainer(%s): unexpected error: %v", in, err)
+			continue
+		}
+		if result != expected {
+			t.Errorf("HostToContainer(%s): expected %s, got %s", in, expected, result)
+		}
+	}
+	if _, err := mapper.HostToContainer("D:/Path/To/ProjectsX/a.bsl"); err == nil {
+		t.Errorf("Expected ProjectsX to be outside Projects")
+	}
+	if err := mapper.ValidatePath("F:/Other/x.bsl"); err != nil {
+		t.Errorf("Expected a path in a further mount to be valid: %v", err)
+	}
+
+	for p, expected := range map[string]bool{"/projects": true, "/other/a": true, "/projects2": false, "/tmp/x": false} {
+		if mapper.IsContainerPath(p) != expected {
+			t.Errorf("IsContainerPath(%s): expected %v", p, expected)
+		}
+	}
+}
+
+func TestContainerToHostText(t *testing.T) {
+	mapper, err := NewDockerPathMapper("D:/My Projects", "/projects")
+	if err != nil {
+		t.Fatalf("Failed to create mapper: %v", err)
+	}
+	if err := mapper.AddMount("E:/Lib", "/projects/lib"); err != nil {
+		t.Fatalf("Failed to add mount: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		text     string
+		expected string
+	}{
+		{"uri", "Definition: file:///projects/cfg/Module.bsl:10:5", "Definition: file:///D:/My%20Projects/cfg/Module.bsl:10:5"},
+		{"bare path", "File: /projects/cfg/Module.bsl (line 3)", "File: D:/My Projects/cfg/Module.bsl (line 3)"},
+		{"json", `{"uri":"file:///projects/lib/A.bsl","path":"/projects/lib"}`, `{"uri":"file:///E:/Lib/A.bsl","path":"E:/Lib"}`},
+		{"more specific mount", "/projects/lib/x and /projects/library/x", "E:/Lib/x and D:/My Projects/library/x"},
+		{"root only", "root=/projects", "root=D:/My Projects"},
+		{"name continues", "/projects2/x /projectsЁ", "/projects2/x /projectsЁ"},
+		{"inside another path", "/opt/projects/x http://host/projects/x", "/opt/projects/x http://host/projects/x"},
+		{"other uri", "file:///tmp/projects/x", "file:///tmp/projects/x"},
+		{"unchanged", "no paths here", "no paths here"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := mapper.ContainerToHostText(tt.text); result != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, result)
+			}
+		})
+	}
+
+	disabled := &DockerPathMapper{containerRoot: "/projects"}
+	if disabled.ContainerToHostText("/projects/x") != "/projects/x" {
+		t.Errorf("A disabled mapper must not rewrite text")
+	}
+}
+
+func TestParsePathMounts(t *testing.T) {
+	mounts, err := ParsePathMounts(`D:\Lib=/lib; E:/Shared Files=/shared/` + "\n\n")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := []PathMount{{"D:/Lib", "/lib"}, {"E:/Shared Files", "/shared"}}
+	if len(mounts) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, mounts)
+	}
+	for i := range expected {
+		if mounts[i] != expected[i] {
+			t.Errorf("Expected %v, got %v", expected[i], mounts[i])
+		}
+	}
+	if _, err := ParsePathMounts("D:/Lib"); err == nil {
+		t.Errorf("Expected an error for a mount without a container path")
+	}
+	if _, err := ParsePathMounts("D:/Lib=lib"); err == nil {
+		t.Errorf("Expected an error for a relative container path")
+	}
+}
+
+func TestNewDockerPathMapperFromEnvMounts(t *testing.T) {
+	t.Setenv("HOST_PROJECTS_ROOT", "")
+	t.Setenv("PROJECTS_HOST_ROOT", "")
+	t.Setenv("PROJECTS_ROOT", "")
+	t.Setenv("PROJECTS_MOUNTS", "D:/Lib=/lib;E:/Data=/data")
+
+	mapper, err := NewDockerPathMapperFromEnv()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !mapper.IsEnabled() || mapper.ContainerRoot() != "/lib" || len(mapper.Mounts()) != 2 {
+		t.Errorf("Expected the first mount to be the primary one, got %v", mapper.Mounts())
+	}
+
+	t.Setenv("HOST_PROJECTS_ROOT", "C:/Projects")
+	mapper, err = NewDockerPathMapperFromEnv()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if mapper.ContainerRoot() != "/projects" || len(mapper.Mounts()) != 3 {
+		t.Errorf("Expected /projects and two further mounts, got %v", mapper.Mounts())
+	}
+
+	t.Setenv("PROJECTS_MOUNTS", "D:/Lib")
+	if _, err := NewDockerPathMapperFromEnv(); err == nil {
+		t.Errorf("Expected an error for a malformed PROJECTS_MOUNTS")
+	}
+}
+
+func TestIsPathUnder(t *testing.T) {
+	tests := []struct {
+		path, root string
+		want       bool
+	}{
+		{"/projects/app/Module.bsl", "/projects/app", true},
+		{"/projects/app", "/projects/app/", true},
+		{"/projects/app2/Module.bsl", "/projects/app", false},
+		{"/projects/Module.bsl", "/", true},
+		{`C:\Projects\App\Module.bsl`, "c:/projects/app", true},
+		{"C:/Projects/App2/Module.bsl", "C:/Projects/App", false},
+		{"/projects/app", "", false},
+	}
+	for _, tt := range tests {
+		if got := IsPathUnder(tt.path, tt.root); got != tt.want {
+			t.Errorf("IsPathUnder(%q, %q) = %v, want %v", tt.path, tt.root, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/myleshyson/lsprotocol-go/protocol"
+)
+
+// ClientPositionEncodings are offered in general.positionEncodings at initialize,
+// most preferred first. Positions are shown to agents and typed back by them, and
+// agents count characters, which is what UTF-32 counts; UTF-16 agrees with it for
+// everything outside the astral planes. UTF-16 is mandatory and the default.
+var ClientPositionEncodings = []protocol.PositionEncodingKind{
+	protocol.PositionEncodingKindUTF32,
+	protocol.PositionEncodingKindUTF16,
+	protocol.PositionEncodingKindUTF8,
+}
+
+// ErrCharacterPastLineEnd is returned for a character beyond the end of its line
+var ErrCharacterPastLineEnd = errors.New("character is past the end of the line")
+
+// ServerPositionEncoding returns the position encoding the server picked at
+// initialize; servers that do not say count UTF-16 code units
+func ServerPositionEncoding(caps protocol.ServerCapabilities) protocol.PositionEncodingKind {
+	if caps.PositionEncoding == nil {
+		return protocol.PositionEncodingKindUTF16
+	}
+	return NormalizePositionEncoding(*caps.PositionEncoding)
+}
+
+// NormalizePositionEncoding maps unknown or empty encodings to UTF-16
+func NormalizePositionEncoding(enc protocol.PositionEncodingKind) protocol.PositionEncodingKind {
+	switch enc {
+	case protocol.PositionEncodingKindUTF8, protocol.PositionEncodingKindUTF32:
+		return enc
+	}
+	return protocol.PositionEncodingKindUTF16
+}
+
+// runeUnits returns how many units of enc the rune takes; size is its UTF-8 length
+// in the text, so invalid bytes count as one unit each
+func runeUnits(r rune, size int, enc protocol.PositionEncodingKind) int {
+	switch enc {
+	case protocol.PositionEncodingKindUTF8:
+		return size
+	case protocol.PositionEncodingKindUTF32:
+		return 1
+	}
+	if r == utf8.RuneError && size == 1 {
+		return 1
+	}
+	return utf16.RuneLen(r)
+}
+
+// CharacterLen returns the length of s in units of enc
+func CharacterLen(s string, enc protocol.PositionEncodingKind) uint32 {
+	enc = NormalizePositionEncoding(enc)
+	var n uint32
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		n += uint32(runeUnits(r, size, enc)) // #nosec G115 - at most 4
+		i += size
+	}
+	return n
+}
+
+// CharacterOffset converts a character of line (without its terminator) into a byte
+// offset. A character past the end of the line wraps ErrCharacterPastLineEnd; one
+// pointing into the middle of a code point is an error as well.
+func CharacterOffset(line string, character uint32, enc protocol.PositionEncodingKind) (int, error) {
+	enc = NormalizePositionEncoding(enc)
+	var units uint32
+	for i := 0; i < len(line); {
+		if units == character {
+			return i, nil
+		}
+		r, size := utf8.DecodeRuneInString(line[i:])
+		units += uint32(runeUnits(r, size, enc)) // #nosec G115 - at most 4
+		if units > character {
+			return 0, fmt.Errorf("character %d splits the code point at byte %d (%s)", character, i, enc)
+		}
+		i += size
+	}
+	if units == character {
+		return len(line), nil
+	}
+	return 0, fmt.Errorf("character %d: %w (line length %d, %s)", character, ErrCharacterPastLineEnd, units, enc)
+}
+
+// Line is one line of a text with the byte offsets of its content
+type Line struct {
+	Text  string // without the terminator
+	Start int    // offset of the first byte
+	End   int    // offset of the terminator, or the end of the text
+}
+
+// SplitLines splits text into lines the way LSP counts them: \n, \r\n and \r all end
+// a line, and a text ending with a terminator has an empty last line
+func SplitLines(text string) []Line {
+	var lines []Line
+	start := 0
+	for {
+		next := strings.IndexAny(text[start:], "\r\n")
+		if next < 0 {
+			return append(lines, Line{Text: text[start:], Start: start, End: len(text)})
+		}
+		end := start + next
+		lines = append(lines, Line{Text: text[start:end], Start: start, End: end})
+		start = end + 1
+		if text[end] == '\r' && start < len(text) && text[start] == '\n' {
+			start++
+		}
+	}
+}
+
+// PositionOffset converts pos into a byte offset of text. A character past the end
+// of its line means the line end, as the spec requires; a line past the end of the
+// text and a character inside a code point are errors.
+func PositionOffset(text string, pos protocol.Position, enc protocol.PositionEncodingKind) (int, error) {
+	lines := SplitLines(text)
+	if int(pos.Line) >= len(lines) {
+		return 0, fmt.Errorf("line %d is past the end of the text (%d lines)", pos.Line, len(lines))
+	}
+	line := lines[pos.Line]
+	offset, err := CharacterOffset(line.Text, pos.Character, enc)
+	if errors.Is(err, ErrCharacterPastLineEnd) {
+		return line.End, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("line %d: %w", pos.Line, err)
+	}
+	return line.Start + offset, nil
+}
+
+// OffsetPosition converts a byte offset of text into a position counted in enc.
+// Offsets inside a line terminator or a code point are rounded down.
+func OffsetPosition(text string, offset int, enc protocol.PositionEncodingKind) protocol.Position {
+	offset = min(max(offset, 0), len(text))
+	lines := SplitLines(text)
+	for i, line := range lines {
+		if i+1 < len(lines) && offset >= lines[i+1].Start {
+			continue
+		}
+		offset = min(offset, line.End)
+		for offset > line.Start && offset < len(text) && !utf8.RuneStart(text[offset]) {
+			offset--
+		}
+		return protocol.Position{Line: uint32(i), Character: CharacterLen(text[line.Start:offset], enc)} // #nosec G115 - line count fits
+	}
+	return protocol.Position{}
+}
+
+// RangeText returns the text between the two positions of rng
+func RangeText(text string, rng protocol.Range, enc protocol.PositionEncodingKind) (string, error) {
+	start, err := PositionOffset(text, rng.Start, enc)
+	if err != nil {
+		return "", fmt.Errorf("invalid range start: %w", err)
+	}
+	end, err := PositionOffset(text, rng.End, enc)
+	if err != nil {
+		return "", fmt.Errorf("invalid range end: %w", err)
+	}
+	if end < start {
+		return "", fmt.Errorf("range end %d:%d is before start %d:%d",
+			rng.End.Line, rng.End.Character, rng.Start.Line, rng.Start.Character)
+	}
+	return text[start:end], nil
+}
@@ -0,0 +1,144 @@
+package utils
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/myleshyson/lsprotocol-go/protocol"
+)
+
+const (
+	utf8Enc  = protocol.PositionEncodingKindUTF8
+	utf16Enc = protocol.PositionEncodingKindUTF16
+	utf32Enc = protocol.PositionEncodingKindUTF32
+)
+
+func TestCharacterOffset(t *testing.T) {
+	// "Знач" is 4 runes of 2 bytes, the emoji is 4 bytes and a UTF-16 surrogate pair
+	line := "Знач 😀x"
+
+	tests := []struct {
+		name      string
+		character uint32
+		enc       protocol.PositionEncodingKind
+		offset    int
+		wantErr   string
+	}{
+		{"utf-16 after cyrillic", 4, utf16Enc, 8, ""},
+		{"utf-16 after surrogate pair", 7, utf16Enc, 13, ""},
+		{"utf-16 inside surrogate pair", 6, utf16Enc, 0, "splits the code point"},
+		{"utf-32 counts code points", 6, utf32Enc, 13, ""},
+		{"utf-8 counts bytes", 9, utf8Enc, 9, ""},
+		{"utf-8 inside a rune", 1, utf8Enc, 0, "splits the code point"},
+		{"unknown encoding is utf-16", 7, "utf-7", 13, ""},
+		{"end of line", 8, utf16Enc, 14, ""},
+		{"past the end of line", 9, utf16Enc, 0, "past the end of the line"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			offset, err := CharacterOffset(line, tc.character, tc.enc)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("CharacterOffset(%d) error = %v, want %q", tc.character, err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CharacterOffset(%d) unexpected error: %v", tc.character, err)
+			}
+			if offset != tc.offset {
+				t.Errorf("CharacterOffset(%d) = %d, want %d", tc.character, offset, tc.offset)
+			}
+		})
+	}
+
+	if _, err := CharacterOffset(line, 20, utf16Enc); !errors.Is(err, ErrCharacterPastLineEnd) {
+		t.Errorf("expected ErrCharacterPastLineEnd, got %v", err)
+	}
+}
+
+func TestCharacterLen(t *testing.T) {
+	s := "Знач 😀"
+	for enc, want := range map[protocol.PositionEncodingKind]uint32{utf8Enc: 13, utf16Enc: 7, utf32Enc: 6} {
+		if got := CharacterLen(s, enc); got != want {
+			t.Errorf("CharacterLen(%s) = %d, want %d", enc, got, want)
+		}
+	}
+}
+
+func TestSplitLines(t *testing.T) {
+	lines := SplitLines("a\r\nб\rc\n")
+	want := []Line{
+		{Text: "a", Start: 0, End: 1},
+		{Text: "б", Start: 3, End: 5},
+		{Text: "c", Start: 6, End: 7},
+		{Text: "", Start: 8, End: 8},
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("SplitLines returned %d lines, want %d: %+v", len(lines), len(want), lines)
+	}
+	for i := range want {
+		if lines[i] != want[i] {
+			t.Errorf("line %d = %+v, want %+v", i, lines[i], want[i])
+		}
+	}
+}
+
+func TestPositionOffsetRoundTrip(t *testing.T) {
+	text := "Процедура А()\r\n\tВозврат 😀;\r\nКонецПроцедуры"
+
+	for _, enc := range []protocol.PositionEncodingKind{utf8Enc, utf16Enc, utf32Enc} {
+		for offset := 0; offset <= len(text); offset++ {
+			pos := OffsetPosition(text, offset, enc)
+			back, err := PositionOffset(text, pos, enc)
+			if err != nil {
+				t.Fatalf("%s: PositionOffset(%+v) for offset %d: %v", enc, pos, offset, err)
+			}
+			if back > offset {
+				t.Fatalf("%s: offset %d -> %+v -> %d, rounded up", enc, offset, pos, back)
+			}
+		}
+	}
+
+	pos := OffsetPosition(text, len("Процедура А()\r\n\tВозврат "), utf16Enc)
+	if pos != (protocol.Position{Line: 1, Character: 9}) {
+		t.Errorf("OffsetPosition = %+v, want 1:9", pos)
+	}
+
+	// Characters past the end of a line mean the line end, lines past the end are errors
+	if offset, err := PositionOffset(text, protocol.Position{Line: 0, Character: 100}, utf16Enc); err != nil || offset != len("Процедура А()") {
+		t.Errorf("PositionOffset past line end = %d, %v", offset, err)
+	}
+	if _, err := PositionOffset(text, protocol.Position{Line: 3}, utf16Enc); err == nil {
+		t.Error("expected an error for a line past the end of the text")
+	}
+}
+
+func TestRangeText(t *testing.T) {
+	text := "Перем Имя😀Значение;\nКонец"
+	rng := protocol.Range{
+		Start: protocol.Position{Line: 0, Character: 6},
+		End:   protocol.Position{Line: 0, Character: 11},
+	}
+	got, err := RangeText(text, rng, utf16Enc)
+	if err != nil || got != "Имя😀" {
+		t.Errorf("RangeText = %q, %v; want %q", got, err, "Имя😀")
+	}
+
+	rng.End = protocol.Position{Line: 0, Character: 2}
+	if _, err := RangeText(text, rng, utf16Enc); err == nil {
+		t.Error("expected an error for an end before the start")
+	}
+}
+
+func TestServerPositionEncoding(t *testing.T) {
+	if got := ServerPositionEncoding(protocol.ServerCapabilities{}); got != utf16Enc {
+		t.Errorf("default encoding = %s, want utf-16", got)
+	}
+	enc := utf8Enc
+	if got := ServerPositionEncoding(protocol.ServerCapabilities{PositionEncoding: &enc}); got != utf8Enc {
+		t.Errorf("negotiated encoding = %s, want utf-8", got)
+	}
+}